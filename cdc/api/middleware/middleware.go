@@ -14,7 +14,14 @@
 package middleware
 
 import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -22,8 +29,10 @@ import (
 	"github.com/pingcap/tiflow/cdc/api"
 	"github.com/pingcap/tiflow/cdc/capture"
 	"github.com/pingcap/tiflow/cdc/model"
+	"github.com/pingcap/tiflow/pkg/audit"
 	"github.com/pingcap/tiflow/pkg/config"
 	"github.com/pingcap/tiflow/pkg/errors"
+	"github.com/pingcap/tiflow/pkg/security"
 	"github.com/pingcap/tiflow/pkg/upstream"
 	"go.uber.org/zap"
 )
@@ -31,6 +40,19 @@ import (
 // ClientVersionHeader is the header name of client version
 const ClientVersionHeader = "X-client-version"
 
+const (
+	// userSubjectPrefix and commonNameSubjectPrefix are the prefixes of the
+	// subjects authenticated by the HTTP basic auth and the client certificate.
+	userSubjectPrefix       = "user "
+	commonNameSubjectPrefix = "common name "
+	// forwardedToOwnerKey is set in the gin context if the request is forwarded to the owner.
+	forwardedToOwnerKey = "forwarded-to-owner"
+	// auditDiffKey is the key of the changes made by the request in the gin context.
+	auditDiffKey = "audit-diff"
+	// auditTimeout is the timeout of saving the audit record to etcd.
+	auditTimeout = 5 * time.Second
+)
+
 // LogMiddleware logs the api requests
 func LogMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -88,6 +110,7 @@ func ErrorHandleMiddleware() gin.HandlerFunc {
 func ForwardToOwnerMiddleware(p capture.Capture) gin.HandlerFunc {
 	return func(ctx *gin.Context) {
 		if !p.IsOwner() {
+			ctx.Set(forwardedToOwnerKey, true)
 			api.ForwardToOwner(ctx, p)
 
 			// Without calling Abort(), Gin will continue to process the next handler,
@@ -100,6 +123,98 @@ func ForwardToOwnerMiddleware(p capture.Capture) gin.HandlerFunc {
 	}
 }
 
+// AuditMiddleware records the mutating request to the audit log. It should be
+// placed before the authorization middlewares, so that the denied requests are
+// recorded too. The request is recorded by the capture which receives it from
+// the client, so that the user and the source address are the client's ones.
+// The requests forwarded by other captures are not recorded again, instead the
+// changes made by them are sent back in the AuditDiffHeader.
+func AuditMiddleware(capture capture.Capture, operation string) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		logger := audit.GetLogger()
+		if logger == nil {
+			ctx.Next()
+			return
+		}
+		var body []byte
+		if ctx.Request.Body != nil {
+			var err error
+			body, err = io.ReadAll(ctx.Request.Body)
+			if err != nil {
+				log.Warn("failed to read the request body for audit", zap.Error(err))
+			}
+			ctx.Request.Body = io.NopCloser(bytes.NewReader(body))
+		}
+		start := time.Now()
+		ctx.Next()
+
+		if api.GetForwardFromCapture(ctx) != "" {
+			return
+		}
+		record := &audit.Record{
+			Time:             start,
+			Operation:        operation,
+			Method:           ctx.Request.Method,
+			Path:             ctx.Request.URL.Path,
+			User:             getSubject(ctx),
+			SourceAddr:       ctx.ClientIP(),
+			ForwardedToOwner: ctx.GetBool(forwardedToOwnerKey),
+			Body:             audit.MaskBody(body),
+			Status:           ctx.Writer.Status(),
+		}
+		if info, err := capture.Info(); err == nil {
+			record.Capture = info.ID
+		}
+		if diff, ok := ctx.Get(auditDiffKey); ok {
+			record.Diff = diff.(json.RawMessage)
+		} else if encoded := api.GetForwardedAuditDiff(ctx); encoded != "" {
+			if diff, err := base64.StdEncoding.DecodeString(encoded); err == nil {
+				record.Diff = diff
+			}
+		}
+		if err := ctx.Errors.Last(); err != nil {
+			record.Error = err.Error()
+		}
+		auditCtx, cancel := context.WithTimeout(context.Background(), auditTimeout)
+		defer cancel()
+		logger.Log(auditCtx, capture.GetEtcdClient(), record)
+	}
+}
+
+// SetAuditDiff sets the changes made by the request, which are recorded by
+// AuditMiddleware. It must be called before the response is written, since
+// the changes of the forwarded request are sent back in the response header.
+func SetAuditDiff(ctx *gin.Context, diff interface{}) {
+	raw, err := json.Marshal(diff)
+	if err != nil {
+		log.Warn("failed to marshal the diff for audit", zap.Error(err))
+		return
+	}
+	if api.GetForwardFromCapture(ctx) != "" {
+		ctx.Header(api.AuditDiffHeader, base64.StdEncoding.EncodeToString(raw))
+		return
+	}
+	if audit.GetLogger() == nil {
+		return
+	}
+	ctx.Set(auditDiffKey, json.RawMessage(raw))
+}
+
+// getSubject returns the authorized subject, or the user of the HTTP basic auth,
+// or the common name of the client certificate of the request.
+func getSubject(ctx *gin.Context) string {
+	if subject, ok := ctx.Get(api.AuthorizedSubjectKey); ok {
+		return subject.(string)
+	}
+	if username, _, ok := ctx.Request.BasicAuth(); ok {
+		return userSubjectPrefix + username
+	}
+	if commonName := getPeerCommonName(ctx); commonName != "" {
+		return commonNameSubjectPrefix + commonName
+	}
+	return ""
+}
+
 // CheckServerReadyMiddleware checks if the server is ready
 func CheckServerReadyMiddleware(capture capture.Capture) gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -115,10 +230,17 @@ func CheckServerReadyMiddleware(capture capture.Capture) gin.HandlerFunc {
 }
 
 // AuthenticateMiddleware authenticates the request by query upstream TiDB.
+// If the role bindings are configured, the request should be authorized by
+// AuthorizeMiddleware, or the admin role is required.
 func AuthenticateMiddleware(capture capture.Capture) gin.HandlerFunc {
 	return func(ctx *gin.Context) {
 		serverCfg := config.GetGlobalServerConfig()
-		if serverCfg.Security.ClientUserRequired {
+		if serverCfg.Security.IsRBACEnabled() {
+			if _, ok := ctx.Get(api.AuthorizedSubjectKey); !ok &&
+				!authorize(ctx, capture, security.RoleAdmin, "") {
+				return
+			}
+		} else if serverCfg.Security.ClientUserRequired {
 			up, err := getUpstream(capture)
 			if err != nil {
 				_ = ctx.Error(err)
@@ -136,6 +258,162 @@ func AuthenticateMiddleware(capture capture.Capture) gin.HandlerFunc {
 	}
 }
 
+// NamespaceGetter returns the namespace the request operates on,
+// the empty namespace means the request operates on the whole cluster.
+type NamespaceGetter func(ctx *gin.Context) string
+
+// ClusterScope is the NamespaceGetter of the cluster-wide requests.
+func ClusterScope(*gin.Context) string {
+	return ""
+}
+
+// NamespaceFromQuery gets the namespace from the query parameter.
+func NamespaceFromQuery(ctx *gin.Context) string {
+	namespace := ctx.Query(api.APIOpVarNamespace)
+	if namespace == "" {
+		namespace = model.DefaultNamespace
+	}
+	return namespace
+}
+
+// NamespaceFromBody gets the namespace from the json body, the body is
+// restored after reading so that the handlers can read it again.
+func NamespaceFromBody(ctx *gin.Context) string {
+	var req struct {
+		Namespace string `json:"namespace"`
+	}
+	if ctx.Request.Body != nil {
+		body, err := io.ReadAll(ctx.Request.Body)
+		if err == nil {
+			// the malformed body is rejected by the handler later.
+			_ = json.Unmarshal(body, &req)
+		}
+		ctx.Request.Body = io.NopCloser(bytes.NewReader(body))
+	}
+	if req.Namespace == "" {
+		req.Namespace = model.DefaultNamespace
+	}
+	return req.Namespace
+}
+
+// AuthorizeMiddleware authorizes the request by the role bindings, the user
+// of the HTTP basic auth or the common name of the client certificate should
+// be granted the role in the namespace of the request. It should be placed
+// before ForwardToOwnerMiddleware, since the forwarded requests carry the
+// certificate of the capture instead of the client, the subject authorized
+// here is carried by them instead.
+// All the requests are allowed if no role binding is configured.
+func AuthorizeMiddleware(
+	capture capture.Capture, role security.Role, getNamespace NamespaceGetter,
+) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		serverCfg := config.GetGlobalServerConfig()
+		if !serverCfg.Security.IsRBACEnabled() {
+			ctx.Next()
+			return
+		}
+		if authorize(ctx, capture, role, getNamespace(ctx)) {
+			ctx.Next()
+		}
+	}
+}
+
+// authorize authenticates the subject of the request and checks whether it's
+// granted the role in the namespace, the request is aborted if it's not.
+func authorize(
+	ctx *gin.Context, capture capture.Capture, role security.Role, namespace string,
+) bool {
+	serverCfg := config.GetGlobalServerConfig()
+	subject, ok := getForwardedSubject(ctx)
+	if !ok {
+		if subject, ok = authenticate(ctx, capture); !ok {
+			return false
+		}
+	}
+
+	username, commonName := parseSubject(subject)
+	if !serverCfg.Security.GetRole(username, commonName, namespace).Covers(role) {
+		scope := "the cluster"
+		if namespace != "" {
+			scope = "the namespace " + namespace
+		}
+		errMsg := fmt.Sprintf("%s is not granted the %s role in %s", subject, role, scope)
+		ctx.IndentedJSON(http.StatusForbidden, model.NewHTTPError(
+			errors.ErrPermissionDenied.GenWithStackByArgs(errMsg)))
+		ctx.Abort()
+		return false
+	}
+	ctx.Set(api.AuthorizedSubjectKey, subject)
+	return true
+}
+
+// authenticate returns the subject of the request, which is the user of the
+// HTTP basic auth verified by the upstream TiDB, or the common name of the
+// client certificate. The request is aborted if it's not authenticated.
+func authenticate(ctx *gin.Context, capture capture.Capture) (string, bool) {
+	if username, password, ok := ctx.Request.BasicAuth(); ok {
+		up, err := getUpstream(capture)
+		if err != nil {
+			_ = ctx.Error(err)
+			ctx.Abort()
+			return "", false
+		}
+		if err := up.VerifyTiDBUser(ctx, username, password); err != nil {
+			ctx.IndentedJSON(http.StatusUnauthorized, model.NewHTTPError(
+				errors.ErrUnauthorized.GenWithStackByArgs(username, err.Error())))
+			ctx.Abort()
+			return "", false
+		}
+		return userSubjectPrefix + username, true
+	}
+	if commonName := getPeerCommonName(ctx); commonName != "" {
+		return commonNameSubjectPrefix + commonName, true
+	}
+	errMsg := "please specify the user and password via authorization header, or the client certificate"
+	ctx.IndentedJSON(http.StatusUnauthorized, model.NewHTTPError(
+		errors.ErrCredentialNotFound.GenWithStackByArgs(errMsg)))
+	ctx.Abort()
+	return "", false
+}
+
+// getForwardedSubject returns the subject authorized by the capture which
+// forwarded the request. It's trusted only if the request is sent with the
+// certificate of a capture of the cluster.
+func getForwardedSubject(ctx *gin.Context) (string, bool) {
+	subject := api.GetForwardedSubject(ctx)
+	if subject == "" {
+		return "", false
+	}
+	serverCfg := config.GetGlobalServerConfig()
+	if !serverCfg.Security.IsClusterCommonName(getPeerCommonName(ctx)) {
+		log.Warn("ignore the authorized subject forwarded by an untrusted peer",
+			zap.String("subject", subject),
+			zap.String("forwardFrom", api.GetForwardFromCapture(ctx)),
+			zap.String("commonName", getPeerCommonName(ctx)))
+		return "", false
+	}
+	return subject, true
+}
+
+// parseSubject returns the user or the common name of the authorized subject.
+func parseSubject(subject string) (username, commonName string) {
+	if name, ok := strings.CutPrefix(subject, userSubjectPrefix); ok {
+		return name, ""
+	}
+	if name, ok := strings.CutPrefix(subject, commonNameSubjectPrefix); ok {
+		return "", name
+	}
+	return "", ""
+}
+
+// getPeerCommonName returns the common name of the client certificate.
+func getPeerCommonName(ctx *gin.Context) string {
+	if ctx.Request.TLS != nil && len(ctx.Request.TLS.PeerCertificates) != 0 {
+		return ctx.Request.TLS.PeerCertificates[0].Subject.CommonName
+	}
+	return ""
+}
+
 func getUpstream(capture capture.Capture) (*upstream.Upstream, error) {
 	m, err := capture.GetUpstreamManager()
 	if err != nil {
@@ -15,12 +15,26 @@ package middleware
 
 import (
 	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"io"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/gin-gonic/gin"
+	"github.com/pingcap/tiflow/cdc/api"
 	"github.com/pingcap/tiflow/cdc/capture"
+	"github.com/pingcap/tiflow/cdc/model"
+	"github.com/pingcap/tiflow/pkg/audit"
+	"github.com/pingcap/tiflow/pkg/config"
+	"github.com/pingcap/tiflow/pkg/etcd"
+	"github.com/pingcap/tiflow/pkg/security"
 	"github.com/stretchr/testify/require"
 )
 
@@ -33,6 +47,14 @@ func (c *testCaptureInfoProvider) IsReady() bool {
 	return c.ready
 }
 
+func (c *testCaptureInfoProvider) Info() (model.CaptureInfo, error) {
+	return model.CaptureInfo{ID: "capture-1"}, nil
+}
+
+func (c *testCaptureInfoProvider) GetEtcdClient() etcd.CDCEtcdClient {
+	return nil
+}
+
 func TestCheckServerReadyMiddleware(t *testing.T) {
 	capture := &testCaptureInfoProvider{ready: false}
 	router := gin.New()
@@ -57,3 +79,163 @@ func TestCheckServerReadyMiddleware(t *testing.T) {
 	router.ServeHTTP(w, req)
 	require.Equal(t, http.StatusOK, w.Code)
 }
+
+func TestAuthorizeMiddleware(t *testing.T) {
+	originalConfig := config.GetGlobalServerConfig()
+	defer config.StoreGlobalServerConfig(originalConfig)
+	serverConfig := originalConfig.Clone()
+	serverConfig.Security = &security.Credential{
+		RoleBindings: []*security.RoleBinding{
+			{Role: security.RoleAdmin, CommonNames: []string{"admin"}},
+			{Role: security.RoleOperator, CommonNames: []string{"operator"}, Namespaces: []string{"ns1"}},
+			{Role: security.RoleViewer, CommonNames: []string{"operator"}},
+		},
+	}
+	config.StoreGlobalServerConfig(serverConfig)
+
+	capture := &testCaptureInfoProvider{ready: true}
+	router := gin.New()
+	ok := func(c *gin.Context) { c.Status(http.StatusOK) }
+	router.GET("/changefeeds", AuthorizeMiddleware(capture, security.RoleViewer, NamespaceFromQuery), ok)
+	router.POST("/changefeeds/pause", AuthorizeMiddleware(capture, security.RoleOperator, NamespaceFromQuery), ok)
+	router.POST("/changefeeds", AuthorizeMiddleware(capture, security.RoleOperator, NamespaceFromBody),
+		func(c *gin.Context) {
+			// the body can be read again by the handler.
+			body, err := io.ReadAll(c.Request.Body)
+			require.NoError(t, err)
+			require.Contains(t, string(body), "ns1")
+			c.Status(http.StatusOK)
+		})
+	router.POST("/drain", AuthorizeMiddleware(capture, security.RoleAdmin, ClusterScope), ok)
+	router.POST("/remove", AuthenticateMiddleware(capture), ok)
+
+	request := func(method, url, body, commonName string) int {
+		req := httptest.NewRequest(method, url, strings.NewReader(body))
+		if commonName != "" {
+			req.TLS = &tls.ConnectionState{PeerCertificates: []*x509.Certificate{
+				{Subject: pkix.Name{CommonName: commonName}},
+			}}
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	cases := []struct {
+		method     string
+		url        string
+		body       string
+		commonName string
+		expected   int
+	}{
+		{"GET", "/changefeeds", "", "", http.StatusUnauthorized},
+		{"GET", "/changefeeds", "", "unknown", http.StatusForbidden},
+		{"GET", "/changefeeds", "", "operator", http.StatusOK},
+		{"POST", "/changefeeds/pause", "", "operator", http.StatusForbidden},
+		{"POST", "/changefeeds/pause?namespace=ns1", "", "operator", http.StatusOK},
+		{"POST", "/changefeeds/pause?namespace=ns2", "", "admin", http.StatusOK},
+		{"POST", "/changefeeds", `{"namespace":"ns1"}`, "operator", http.StatusOK},
+		{"POST", "/drain", "", "operator", http.StatusForbidden},
+		{"POST", "/drain", "", "admin", http.StatusOK},
+		{"POST", "/remove", "", "operator", http.StatusForbidden},
+		{"POST", "/remove", "", "admin", http.StatusOK},
+	}
+	for _, c := range cases {
+		require.Equal(t, c.expected, request(c.method, c.url, c.body, c.commonName),
+			"%s %s by %s", c.method, c.url, c.commonName)
+	}
+
+	// all the requests are allowed if no role binding is configured.
+	serverConfig.Security.RoleBindings = nil
+	require.Equal(t, http.StatusOK, request("POST", "/drain", "", ""))
+}
+
+func TestAuthorizeForwardedRequest(t *testing.T) {
+	originalConfig := config.GetGlobalServerConfig()
+	defer config.StoreGlobalServerConfig(originalConfig)
+	serverConfig := originalConfig.Clone()
+	serverConfig.Security = &security.Credential{
+		RoleBindings: []*security.RoleBinding{
+			{Role: security.RoleViewer, CommonNames: []string{"viewer"}},
+		},
+		ClusterCommonNames: []string{"ticdc"},
+	}
+	config.StoreGlobalServerConfig(serverConfig)
+
+	capture := &testCaptureInfoProvider{ready: true}
+	router := gin.New()
+	router.GET("/processors", AuthorizeMiddleware(capture, security.RoleViewer, NamespaceFromQuery),
+		func(c *gin.Context) {
+			require.Equal(t, "common name viewer", c.GetString(api.AuthorizedSubjectKey))
+			c.Status(http.StatusOK)
+		})
+
+	request := func(peerCommonName, forwardFrom, subject string) int {
+		req := httptest.NewRequest("GET", "/processors", nil)
+		req.TLS = &tls.ConnectionState{PeerCertificates: []*x509.Certificate{
+			{Subject: pkix.Name{CommonName: peerCommonName}},
+		}}
+		if forwardFrom != "" {
+			req.Header.Set("TiCDC-ForwardFromCapture", forwardFrom)
+		}
+		if subject != "" {
+			req.Header.Set("TiCDC-AuthorizedSubject", subject)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	// the client authorized by the common name only.
+	require.Equal(t, http.StatusOK, request("viewer", "", ""))
+	// the request forwarded by a capture carries the subject authorized by it.
+	require.Equal(t, http.StatusOK, request("ticdc", "capture-2", "common name viewer"))
+	// the role of the forwarded subject is still checked.
+	require.Equal(t, http.StatusForbidden, request("ticdc", "capture-2", "common name unknown"))
+	// the subject is not trusted if the peer is not a capture of the cluster.
+	require.Equal(t, http.StatusForbidden, request("unknown", "capture-2", "common name viewer"))
+	// the subject is not trusted if the request is not forwarded.
+	require.Equal(t, http.StatusForbidden, request("ticdc", "", "common name viewer"))
+}
+
+func TestAuditMiddleware(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "audit.log")
+	cfg := &config.AuditConfig{Enable: true, Filename: filename}
+	require.NoError(t, cfg.ValidateAndAdjust())
+	require.NoError(t, audit.InitLogger(cfg, ""))
+	defer func() {
+		require.NoError(t, audit.InitLogger(nil, ""))
+	}()
+
+	capture := &testCaptureInfoProvider{ready: true}
+	router := gin.New()
+	router.POST("/update", AuditMiddleware(capture, "update-changefeed"), func(c *gin.Context) {
+		SetAuditDiff(c, map[string]int{"a": 1})
+		c.Status(http.StatusOK)
+	})
+
+	// the request forwarded by another capture is recorded by that capture,
+	// the changes are sent back in the response header.
+	req := httptest.NewRequest("POST", "/update", nil)
+	req.Header.Set("TiCDC-ForwardFromCapture", "capture-2")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Equal(t, base64.StdEncoding.EncodeToString([]byte(`{"a":1}`)),
+		w.Header().Get(api.AuditDiffHeader))
+	content, err := os.ReadFile(filename)
+	require.True(t, err != nil || len(content) == 0)
+
+	req = httptest.NewRequest("POST", "/update", nil)
+	req.SetBasicAuth("alice", "")
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Empty(t, w.Header().Get(api.AuditDiffHeader))
+	content, err = os.ReadFile(filename)
+	require.NoError(t, err)
+	require.Contains(t, string(content), `"operation":"update-changefeed"`)
+	require.Contains(t, string(content), `"user":"user alice"`)
+	require.Contains(t, string(content), `"capture":"capture-1"`)
+	require.Contains(t, string(content), `"diff":"{\"a\":1}"`)
+}
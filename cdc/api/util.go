@@ -17,6 +17,7 @@ import (
 	"bufio"
 	"context"
 	"encoding/json"
+	"io"
 	"net/http"
 	"strconv"
 	"strings"
@@ -70,6 +71,20 @@ const (
 	forwardTimes = "TiCDC-ForwardTimes"
 	// maxForwardTimes is the max time a request can be forwarded,  non-controller->controller->changefeed owner
 	maxForwardTimes = 2
+	// authorizedSubject is a header which carries the subject authorized by the
+	// capture which received the request from the client.
+	authorizedSubject = "TiCDC-AuthorizedSubject"
+
+	// AuthorizedSubjectKey is the key of the authorized subject in the gin context.
+	AuthorizedSubjectKey = "authorized-subject"
+
+	// AuditDiffHeader is a response header which carries the changes made by
+	// a forwarded request back to the capture which received the request from
+	// the client, the request is recorded to the audit log by that capture.
+	AuditDiffHeader = "TiCDC-AuditDiff"
+	// forwardedAuditDiffKey is the key of the AuditDiffHeader of the forwarded
+	// request in the gin context.
+	forwardedAuditDiffKey = "forwarded-audit-diff"
 )
 
 // IsHTTPBadRequestError check if a error is a http bad request error
@@ -197,8 +212,106 @@ func ForwardToOwner(c *gin.Context, p capture.Capture) {
 	ForwardToCapture(c, info.ID, owner.AdvertiseAddr)
 }
 
+// GetForwardFromCapture returns the id of the capture which forwarded the request,
+// it's empty if the request is sent by the client directly.
+func GetForwardFromCapture(c *gin.Context) string {
+	return c.GetHeader(forwardFromCapture)
+}
+
+// GetForwardedSubject returns the subject authorized by the capture which
+// forwarded the request, it's empty if the request isn't forwarded. The caller
+// should trust it only if the request is sent by a capture of the cluster.
+func GetForwardedSubject(c *gin.Context) string {
+	if GetForwardFromCapture(c) == "" {
+		return ""
+	}
+	return c.GetHeader(authorizedSubject)
+}
+
+// GetForwardedAuditDiff returns the AuditDiffHeader of the response of the
+// forwarded request, it's empty if the request isn't forwarded.
+func GetForwardedAuditDiff(c *gin.Context) string {
+	return c.GetString(forwardedAuditDiffKey)
+}
+
 // ForwardToCapture forward request to another
 func ForwardToCapture(c *gin.Context, fromID, toAddr string) {
+	req, err := newForwardRequest(
+		c, c.Request.Method, c.Request.RequestURI, c.Request.Body, fromID, toAddr)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	// forward toAddr owner
+	cli, err := httputil.NewClient(config.GetGlobalServerConfig().Security)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	resp, err := cli.Do(req)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	// write header
+	for k, values := range resp.Header {
+		// the audit diff is only sent back to the capture which received the
+		// request from the client.
+		if k == http.CanonicalHeaderKey(AuditDiffHeader) && GetForwardFromCapture(c) == "" {
+			if len(values) > 0 {
+				c.Set(forwardedAuditDiffKey, values[0])
+			}
+			continue
+		}
+		for _, v := range values {
+			c.Header(k, v)
+		}
+	}
+
+	// write status code
+	c.Status(resp.StatusCode)
+
+	// write response body
+	defer resp.Body.Close()
+	_, err = bufio.NewReader(resp.Body).WriteTo(c.Writer)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+}
+
+// GetFromCapture sends a GET request of the uri to another capture on behalf of
+// the request being handled, and decodes the JSON response into v.
+func GetFromCapture(c *gin.Context, fromID, toAddr, uri string, v interface{}) error {
+	req, err := newForwardRequest(c, http.MethodGet, uri, nil, fromID, toAddr)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	cli, err := httputil.NewClient(config.GetGlobalServerConfig().Security)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	resp, err := cli.Do(req)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		var httpErr model.HTTPError
+		if err := json.NewDecoder(resp.Body).Decode(&httpErr); err != nil {
+			return errors.Annotatef(err, "unexpected status code %d", resp.StatusCode)
+		}
+		return errors.Errorf("[%s]%s", httpErr.Code, httpErr.Error)
+	}
+	return errors.Trace(json.NewDecoder(resp.Body).Decode(v))
+}
+
+// newForwardRequest creates a request to another capture, which carries the
+// headers of the request being handled.
+func newForwardRequest(
+	c *gin.Context, method, uri string, body io.Reader, fromID, toAddr string,
+) (*http.Request, error) {
 	ctx := c.Request.Context()
 
 	timeStr := c.GetHeader(forwardTimes)
@@ -209,23 +322,19 @@ func ForwardToCapture(c *gin.Context, fromID, toAddr string) {
 	if len(timeStr) != 0 {
 		lastForwardTimes, err = strconv.ParseUint(timeStr, 10, 64)
 		if err != nil {
-			_ = c.Error(cerror.ErrRequestForwardErr.FastGenByArgs())
-			return
+			return nil, cerror.ErrRequestForwardErr.FastGenByArgs()
 		}
 		if lastForwardTimes > maxForwardTimes {
-			_ = c.Error(cerror.ErrRequestForwardErr.FastGenByArgs())
-			return
+			return nil, cerror.ErrRequestForwardErr.FastGenByArgs()
 		}
 	}
 
 	security := config.GetGlobalServerConfig().Security
 
 	// init a request
-	req, err := http.NewRequestWithContext(
-		ctx, c.Request.Method, c.Request.RequestURI, c.Request.Body)
+	req, err := http.NewRequestWithContext(ctx, method, uri, body)
 	if err != nil {
-		_ = c.Error(err)
-		return
+		return nil, err
 	}
 
 	req.URL.Host = toAddr
@@ -241,9 +350,15 @@ func ForwardToCapture(c *gin.Context, fromID, toAddr string) {
 			req.Header.Add(k, vv)
 		}
 	}
+	// only the subject authorized by this capture is carried, the one sent
+	// by the client is dropped.
+	req.Header.Del(authorizedSubject)
+	if subject := c.GetString(AuthorizedSubjectKey); subject != "" {
+		req.Header.Set(authorizedSubject, subject)
+	}
 	log.Info("forwarding request to capture",
-		zap.String("url", c.Request.RequestURI),
-		zap.String("method", c.Request.Method),
+		zap.String("url", uri),
+		zap.String("method", method),
 		zap.String("fromID", fromID),
 		zap.String("toAddr", toAddr),
 		zap.String("forwardTimes", timeStr))
@@ -251,35 +366,7 @@ func ForwardToCapture(c *gin.Context, fromID, toAddr string) {
 	req.Header.Add(forwardFromCapture, fromID)
 	lastForwardTimes++
 	req.Header.Add(forwardTimes, strconv.Itoa(int(lastForwardTimes)))
-	// forward toAddr owner
-	cli, err := httputil.NewClient(security)
-	if err != nil {
-		_ = c.Error(err)
-		return
-	}
-	resp, err := cli.Do(req)
-	if err != nil {
-		_ = c.Error(err)
-		return
-	}
-
-	// write header
-	for k, values := range resp.Header {
-		for _, v := range values {
-			c.Header(k, v)
-		}
-	}
-
-	// write status code
-	c.Status(resp.StatusCode)
-
-	// write response body
-	defer resp.Body.Close()
-	_, err = bufio.NewReader(resp.Body).WriteTo(c.Writer)
-	if err != nil {
-		_ = c.Error(err)
-		return
-	}
+	return req, nil
 }
 
 // HandleOwnerDrainCapture schedule drain the target capture
@@ -14,8 +14,10 @@
 package api
 
 import (
+	"net/http/httptest"
 	"testing"
 
+	"github.com/gin-gonic/gin"
 	"github.com/pingcap/errors"
 	cerror "github.com/pingcap/tiflow/pkg/errors"
 	"github.com/stretchr/testify/require"
@@ -32,3 +34,27 @@ func TestIsHTTPBadRequestError(t *testing.T) {
 	err = nil
 	require.Equal(t, false, IsHTTPBadRequestError(err))
 }
+
+func TestForwardRequestCarriesAuthorizedSubject(t *testing.T) {
+	t.Parallel()
+
+	newContext := func() *gin.Context {
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+		c.Request = httptest.NewRequest("GET", "/api/v2/changefeeds", nil)
+		// the subject sent by the client is never forwarded.
+		c.Request.Header.Set(authorizedSubject, "common name admin")
+		return c
+	}
+
+	c := newContext()
+	req, err := newForwardRequest(c, "GET", "/api/v2/changefeeds", nil, "capture-1", "127.0.0.1:8300")
+	require.NoError(t, err)
+	require.Empty(t, req.Header.Get(authorizedSubject))
+	require.Equal(t, "capture-1", req.Header.Get(forwardFromCapture))
+
+	c = newContext()
+	c.Set(AuthorizedSubjectKey, "common name viewer")
+	req, err = newForwardRequest(c, "GET", "/api/v2/changefeeds", nil, "capture-1", "127.0.0.1:8300")
+	require.NoError(t, err)
+	require.Equal(t, []string{"common name viewer"}, req.Header.Values(authorizedSubject))
+}
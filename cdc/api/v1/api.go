@@ -30,6 +30,7 @@ import (
 	cerror "github.com/pingcap/tiflow/pkg/errors"
 	"github.com/pingcap/tiflow/pkg/logutil"
 	"github.com/pingcap/tiflow/pkg/retry"
+	"github.com/pingcap/tiflow/pkg/security"
 	"github.com/pingcap/tiflow/pkg/util"
 	"github.com/pingcap/tiflow/pkg/version"
 	"github.com/tikv/client-go/v2/oracle"
@@ -71,38 +72,50 @@ func RegisterOpenAPIRoutes(router *gin.Engine, api OpenAPI) {
 	// common API
 	v1.GET("/status", api.ServerStatus)
 	v1.GET("/health", api.Health)
-	v1.POST("/log", SetLogLevel)
 
 	ownerMiddleware := middleware.ForwardToOwnerMiddleware(api.capture)
 	authenticateMiddleware := middleware.AuthenticateMiddleware(api.capture)
 
+	// the v1 APIs only operate on the changefeeds in the default namespace,
+	// the authorization middlewares must be placed before ownerMiddleware.
+	viewer := middleware.AuthorizeMiddleware(api.capture, security.RoleViewer, middleware.NamespaceFromQuery)
+	operator := middleware.AuthorizeMiddleware(api.capture, security.RoleOperator, middleware.NamespaceFromQuery)
+	admin := middleware.AuthorizeMiddleware(api.capture, security.RoleAdmin, middleware.NamespaceFromQuery)
+	clusterViewer := middleware.AuthorizeMiddleware(api.capture, security.RoleViewer, middleware.ClusterScope)
+	clusterAdmin := middleware.AuthorizeMiddleware(api.capture, security.RoleAdmin, middleware.ClusterScope)
+	// the audit middleware must be placed before the authorization middlewares.
+	audit := func(operation string) gin.HandlerFunc {
+		return middleware.AuditMiddleware(api.capture, operation)
+	}
+
+	v1.POST("/log", audit("set-log-level"), clusterAdmin, SetLogLevel)
+
 	// changefeed API
 	changefeedGroup := v1.Group("/changefeeds")
-	changefeedGroup.GET("", ownerMiddleware, api.ListChangefeed)
-	changefeedGroup.GET("/:changefeed_id", ownerMiddleware, api.GetChangefeed)
-	changefeedGroup.POST("", ownerMiddleware, authenticateMiddleware, api.CreateChangefeed)
-	changefeedGroup.PUT("/:changefeed_id", ownerMiddleware, authenticateMiddleware, api.UpdateChangefeed)
-	changefeedGroup.POST("/:changefeed_id/pause", ownerMiddleware, authenticateMiddleware, api.PauseChangefeed)
-	changefeedGroup.POST("/:changefeed_id/resume", ownerMiddleware, authenticateMiddleware, api.ResumeChangefeed)
-	changefeedGroup.DELETE("/:changefeed_id", ownerMiddleware, authenticateMiddleware, api.RemoveChangefeed)
-	changefeedGroup.POST("/:changefeed_id/tables/rebalance_table", ownerMiddleware, authenticateMiddleware, api.RebalanceTables)
-	changefeedGroup.POST("/:changefeed_id/tables/move_table", ownerMiddleware, authenticateMiddleware, api.MoveTable)
+	changefeedGroup.GET("", viewer, ownerMiddleware, api.ListChangefeed)
+	changefeedGroup.GET("/:changefeed_id", viewer, ownerMiddleware, api.GetChangefeed)
+	changefeedGroup.POST("", audit("create-changefeed"), operator, ownerMiddleware, authenticateMiddleware, api.CreateChangefeed)
+	changefeedGroup.PUT("/:changefeed_id", audit("update-changefeed"), operator, ownerMiddleware, authenticateMiddleware, api.UpdateChangefeed)
+	changefeedGroup.POST("/:changefeed_id/pause", audit("pause-changefeed"), operator, ownerMiddleware, authenticateMiddleware, api.PauseChangefeed)
+	changefeedGroup.POST("/:changefeed_id/resume", audit("resume-changefeed"), operator, ownerMiddleware, authenticateMiddleware, api.ResumeChangefeed)
+	changefeedGroup.DELETE("/:changefeed_id", audit("remove-changefeed"), admin, ownerMiddleware, authenticateMiddleware, api.RemoveChangefeed)
+	changefeedGroup.POST("/:changefeed_id/tables/rebalance_table", audit("rebalance-tables"), operator, ownerMiddleware, authenticateMiddleware, api.RebalanceTables)
+	changefeedGroup.POST("/:changefeed_id/tables/move_table", audit("move-table"), operator, ownerMiddleware, authenticateMiddleware, api.MoveTable)
 
 	// owner API
 	ownerGroup := v1.Group("/owner")
-	ownerGroup.POST("/resign", ownerMiddleware, api.ResignController)
+	ownerGroup.POST("/resign", audit("resign-owner"), clusterAdmin, ownerMiddleware, api.ResignController)
 
 	// processor API
 	processorGroup := v1.Group("/processors")
-	processorGroup.GET("", ownerMiddleware, api.ListProcessor)
+	processorGroup.GET("", clusterViewer, ownerMiddleware, api.ListProcessor)
 	processorGroup.GET("/:changefeed_id/:capture_id",
-		ownerMiddleware, api.GetProcessor)
+		viewer, ownerMiddleware, api.GetProcessor)
 
 	// capture API
 	captureGroup := v1.Group("/captures")
-	captureGroup.Use(ownerMiddleware)
-	captureGroup.GET("", api.ListCapture)
-	captureGroup.PUT("/drain", api.DrainCapture)
+	captureGroup.GET("", clusterViewer, ownerMiddleware, api.ListCapture)
+	captureGroup.PUT("/drain", audit("drain-capture"), clusterAdmin, ownerMiddleware, api.DrainCapture)
 }
 
 // ListChangefeed lists all changgefeeds in cdc cluster
@@ -17,6 +17,7 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/pingcap/tiflow/cdc/api/middleware"
 	"github.com/pingcap/tiflow/cdc/capture"
+	"github.com/pingcap/tiflow/pkg/security"
 )
 
 // OpenAPIV2 provides CDC v2 APIs
@@ -45,50 +46,74 @@ func RegisterOpenAPIV2Routes(router *gin.Engine, api OpenAPIV2) {
 
 	v2.GET("health", api.health)
 	v2.GET("status", api.serverStatus)
-	v2.POST("log", api.setLogLevel)
 
 	ownerMiddleware := middleware.ForwardToOwnerMiddleware(api.capture)
 	authenticateMiddleware := middleware.AuthenticateMiddleware(api.capture)
 
+	// the authorization middlewares must be placed before ownerMiddleware
+	viewer := middleware.AuthorizeMiddleware(api.capture, security.RoleViewer, middleware.NamespaceFromQuery)
+	operator := middleware.AuthorizeMiddleware(api.capture, security.RoleOperator, middleware.NamespaceFromQuery)
+	admin := middleware.AuthorizeMiddleware(api.capture, security.RoleAdmin, middleware.NamespaceFromQuery)
+	clusterViewer := middleware.AuthorizeMiddleware(api.capture, security.RoleViewer, middleware.ClusterScope)
+	clusterOperator := middleware.AuthorizeMiddleware(api.capture, security.RoleOperator, middleware.ClusterScope)
+	clusterAdmin := middleware.AuthorizeMiddleware(api.capture, security.RoleAdmin, middleware.ClusterScope)
+	// the audit middleware must be placed before the authorization middlewares.
+	audit := func(operation string) gin.HandlerFunc {
+		return middleware.AuditMiddleware(api.capture, operation)
+	}
+
+	v2.POST("log", audit("set-log-level"), clusterAdmin, api.setLogLevel)
+
 	// changefeed apis
 	changefeedGroup := v2.Group("/changefeeds")
-	changefeedGroup.GET("/:changefeed_id", ownerMiddleware, api.getChangeFeed)
-	changefeedGroup.POST("", ownerMiddleware, authenticateMiddleware, api.createChangefeed)
-	changefeedGroup.GET("", ownerMiddleware, api.listChangeFeeds)
-	changefeedGroup.PUT("/:changefeed_id", ownerMiddleware, authenticateMiddleware, api.updateChangefeed)
-	changefeedGroup.DELETE("/:changefeed_id", ownerMiddleware, authenticateMiddleware, api.deleteChangefeed)
-	changefeedGroup.GET("/:changefeed_id/meta_info", ownerMiddleware, api.getChangeFeedMetaInfo)
-	changefeedGroup.POST("/:changefeed_id/resume", ownerMiddleware, authenticateMiddleware, api.resumeChangefeed)
-	changefeedGroup.POST("/:changefeed_id/pause", ownerMiddleware, authenticateMiddleware, api.pauseChangefeed)
-	changefeedGroup.GET("/:changefeed_id/status", ownerMiddleware, api.status)
-	changefeedGroup.GET("/:changefeed_id/synced", ownerMiddleware, api.synced)
+	changefeedGroup.GET("/:changefeed_id", viewer, ownerMiddleware, api.getChangeFeed)
+	changefeedGroup.POST("", audit("create-changefeed"), middleware.AuthorizeMiddleware(api.capture, security.RoleOperator, middleware.NamespaceFromBody),
+		ownerMiddleware, authenticateMiddleware, api.createChangefeed)
+	changefeedGroup.GET("", viewer, ownerMiddleware, api.listChangeFeeds)
+	changefeedGroup.PUT("/:changefeed_id", audit("update-changefeed"), operator, ownerMiddleware, authenticateMiddleware, api.updateChangefeed)
+	changefeedGroup.POST("/:changefeed_id/validate", operator, ownerMiddleware, authenticateMiddleware, api.validateChangefeed)
+	changefeedGroup.DELETE("/:changefeed_id", audit("remove-changefeed"), admin, ownerMiddleware, authenticateMiddleware, api.deleteChangefeed)
+	changefeedGroup.GET("/:changefeed_id/meta_info", viewer, ownerMiddleware, api.getChangeFeedMetaInfo)
+	changefeedGroup.POST("/:changefeed_id/resume", audit("resume-changefeed"), operator, ownerMiddleware, authenticateMiddleware, api.resumeChangefeed)
+	changefeedGroup.POST("/:changefeed_id/pause", audit("pause-changefeed"), operator, ownerMiddleware, authenticateMiddleware, api.pauseChangefeed)
+	changefeedGroup.GET("/:changefeed_id/status", viewer, ownerMiddleware, api.status)
+	changefeedGroup.GET("/:changefeed_id/synced", viewer, ownerMiddleware, api.synced)
+	changefeedGroup.GET("/:changefeed_id/corrupted_keys", viewer, ownerMiddleware, api.listCorruptedKeys)
+	changefeedGroup.GET("/:changefeed_id/validation", viewer, ownerMiddleware, api.listValidationResults)
+	changefeedGroup.GET("/:changefeed_id/latency", viewer, ownerMiddleware, api.getChangefeedLatency)
+	changefeedGroup.GET("/:changefeed_id/hotspots", viewer, ownerMiddleware, api.getChangefeedHotspots)
 
 	// capture apis
 	captureGroup := v2.Group("/captures")
-	captureGroup.Use(ownerMiddleware)
-	captureGroup.POST("/:capture_id/drain", api.drainCapture)
-	captureGroup.GET("", api.listCaptures)
+	captureGroup.POST("/:capture_id/drain", audit("drain-capture"), clusterAdmin, ownerMiddleware, api.drainCapture)
+	captureGroup.GET("", clusterViewer, ownerMiddleware, api.listCaptures)
 
 	// processor apis
 	processorGroup := v2.Group("/processors")
-	processorGroup.GET("/:changefeed_id/:capture_id", ownerMiddleware, api.getProcessor)
-	processorGroup.GET("", ownerMiddleware, api.listProcessors)
+	processorGroup.GET("/:changefeed_id/:capture_id", viewer, ownerMiddleware, api.getProcessor)
+	processorGroup.GET("/:changefeed_id/:capture_id/latency", viewer, api.getProcessorLatency)
+	processorGroup.GET("/:changefeed_id/:capture_id/hotspots", viewer, api.getProcessorHotspots)
+	processorGroup.GET("/:changefeed_id/:capture_id/corrupted_keys", viewer, api.listCaptureCorruptedKeys)
+	processorGroup.GET("", clusterViewer, ownerMiddleware, api.listProcessors)
 
 	verifyTableGroup := v2.Group("/verify_table")
-	verifyTableGroup.POST("", api.verifyTable)
+	verifyTableGroup.POST("", clusterOperator, api.verifyTable)
 
 	// unsafe apis
 	unsafeGroup := v2.Group("/unsafe")
-	unsafeGroup.Use(ownerMiddleware)
-	unsafeGroup.GET("/metadata", authenticateMiddleware, api.CDCMetaData)
-	unsafeGroup.POST("/resolve_lock", authenticateMiddleware, api.ResolveLock)
-	unsafeGroup.DELETE("/service_gc_safepoint", authenticateMiddleware, api.DeleteServiceGcSafePoint)
+	unsafeGroup.GET("/metadata", clusterAdmin, ownerMiddleware, authenticateMiddleware, api.CDCMetaData)
+	unsafeGroup.POST("/resolve_lock", audit("unsafe-resolve-lock"),
+		clusterAdmin, ownerMiddleware, authenticateMiddleware, api.ResolveLock)
+	unsafeGroup.DELETE("/service_gc_safepoint", audit("unsafe-delete-service-gc-safepoint"),
+		clusterAdmin, ownerMiddleware, authenticateMiddleware, api.DeleteServiceGcSafePoint)
 
 	// owner apis
 	ownerGroup := v2.Group("/owner")
-	ownerGroup.Use(ownerMiddleware)
-	ownerGroup.POST("/resign", api.resignOwner)
+	ownerGroup.POST("/resign", audit("resign-owner"), clusterAdmin, ownerMiddleware, api.resignOwner)
+
+	// audit apis
+	v2.GET("/audit", clusterAdmin, api.listAuditRecords)
 
 	// common APIs
-	v2.POST("/tso", api.QueryTso)
+	v2.POST("/tso", clusterViewer, api.QueryTso)
 }
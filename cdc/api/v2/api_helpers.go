@@ -289,26 +289,20 @@ func (h APIV2HelpersImpl) verifyUpstream(
 	return nil
 }
 
-// verifyUpdateChangefeedConfig verifies config to update
-// a changefeed and returns a changefeedInfo
-func (APIV2HelpersImpl) verifyUpdateChangefeedConfig(
-	ctx context.Context,
-	cfg *ChangefeedConfig,
-	oldInfo *model.ChangeFeedInfo,
-	oldUpInfo *model.UpstreamInfo,
-	kvStorage tidbkv.Storage,
-	checkpointTs uint64,
-) (*model.ChangeFeedInfo, *model.UpstreamInfo, error) {
-	// update changefeed info
-	newInfo, err := oldInfo.Clone()
+// applyChangefeedConfig returns a copy of oldInfo updated by cfg, and
+// whether the replica config and the sink uri are updated.
+func applyChangefeedConfig(
+	cfg *ChangefeedConfig, oldInfo *model.ChangeFeedInfo,
+) (newInfo *model.ChangeFeedInfo, configUpdated, sinkURIUpdated bool, err error) {
+	newInfo, err = oldInfo.Clone()
 	if err != nil {
-		return nil, nil, cerror.ErrChangefeedUpdateRefused.GenWithStackByArgs(err.Error())
+		return nil, false, false,
+			cerror.ErrChangefeedUpdateRefused.GenWithStackByArgs(err.Error())
 	}
 
-	var configUpdated, sinkURIUpdated bool
 	if cfg.TargetTs != 0 {
 		if cfg.TargetTs <= newInfo.StartTs {
-			return nil, nil, cerror.ErrChangefeedUpdateRefused.GenWithStack(
+			return nil, false, false, cerror.ErrChangefeedUpdateRefused.GenWithStack(
 				"can not update target_ts:%d less than start_ts:%d",
 				cfg.TargetTs, newInfo.StartTs)
 		}
@@ -322,6 +316,24 @@ func (APIV2HelpersImpl) verifyUpdateChangefeedConfig(
 		sinkURIUpdated = true
 		newInfo.SinkURI = cfg.SinkURI
 	}
+	return newInfo, configUpdated, sinkURIUpdated, nil
+}
+
+// verifyUpdateChangefeedConfig verifies config to update
+// a changefeed and returns a changefeedInfo
+func (APIV2HelpersImpl) verifyUpdateChangefeedConfig(
+	ctx context.Context,
+	cfg *ChangefeedConfig,
+	oldInfo *model.ChangeFeedInfo,
+	oldUpInfo *model.UpstreamInfo,
+	kvStorage tidbkv.Storage,
+	checkpointTs uint64,
+) (*model.ChangeFeedInfo, *model.UpstreamInfo, error) {
+	// update changefeed info
+	newInfo, configUpdated, sinkURIUpdated, err := applyChangefeedConfig(cfg, oldInfo)
+	if err != nil {
+		return nil, nil, err
+	}
 
 	// verify changefeed info
 	f, err := filter.NewFilter(newInfo.Config, "")
@@ -0,0 +1,56 @@
+// Copyright 2024 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v2
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pingcap/tiflow/pkg/audit"
+	cerror "github.com/pingcap/tiflow/pkg/errors"
+)
+
+// apiOpVarAuditLimit is the query parameter of the number of the audit records.
+const apiOpVarAuditLimit = "limit"
+
+// listAuditRecords lists the recent audit records
+// @Summary List the recent audit records
+// @Description list the recent mutating API requests recorded by the audit log, the newest first
+// @Tags common,v2
+// @Produce json
+// @Param limit query int false "the max number of the records, all the records kept are returned by default"
+// @Success 200 {object} ListResponse[audit.Record]
+// @Failure 400,500 {object} model.HTTPError
+// @Router /api/v2/audit [get]
+func (h *OpenAPIV2) listAuditRecords(c *gin.Context) {
+	limit := 0
+	if limitStr := c.Query(apiOpVarAuditLimit); limitStr != "" {
+		var err error
+		limit, err = strconv.Atoi(limitStr)
+		if err != nil || limit < 0 {
+			_ = c.Error(cerror.ErrAPIInvalidParam.GenWithStack("invalid limit: %s", limitStr))
+			return
+		}
+	}
+	records, err := audit.ListRecords(c.Request.Context(), h.capture.GetEtcdClient(), limit)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, &ListResponse[*audit.Record]{
+		Total: len(records),
+		Items: records,
+	})
+}
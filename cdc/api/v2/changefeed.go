@@ -27,6 +27,7 @@ import (
 	"github.com/pingcap/log"
 	tidbkv "github.com/pingcap/tidb/pkg/kv"
 	"github.com/pingcap/tiflow/cdc/api"
+	"github.com/pingcap/tiflow/cdc/api/middleware"
 	"github.com/pingcap/tiflow/cdc/capture"
 	"github.com/pingcap/tiflow/cdc/model"
 	"github.com/pingcap/tiflow/pkg/config"
@@ -35,6 +36,7 @@ import (
 	"github.com/pingcap/tiflow/pkg/txnutil/gc"
 	"github.com/pingcap/tiflow/pkg/upstream"
 	"github.com/pingcap/tiflow/pkg/util"
+	"github.com/r3labs/diff"
 	"github.com/tikv/client-go/v2/oracle"
 	pd "github.com/tikv/pd/client"
 	clientv3 "go.etcd.io/etcd/client/v3"
@@ -494,10 +496,233 @@ func (h *OpenAPIV2) updateChangefeed(c *gin.Context) {
 		_ = c.Error(errors.Trace(err))
 		return
 	}
+	if changes, err := diffChangefeedInfo(oldCfInfo, newCfInfo); err == nil {
+		middleware.SetAuditDiff(c, changes)
+	}
 	c.JSON(http.StatusOK, toAPIModel(newCfInfo,
 		cfStatus.ResolvedTs, cfStatus.CheckpointTs, nil, true))
 }
 
+// validateChangefeed handles validate changefeed request, it verifies the
+// changefeed config update without applying it.
+// @Summary Validate a changefeed config update
+// @Description Verify a changefeed config update and show its effects without applying it
+// @Tags changefeed,v2
+// @Accept json
+// @Produce json
+// @Param changefeed_id  path  string  true  "changefeed_id"
+// @Param namespace query string false "default"
+// @Param changefeedConfig body ChangefeedConfig true "changefeed config"
+// @Success 200 {object} ChangefeedValidationResult
+// @Failure 500,400 {object} model.HTTPError
+// @Router /api/v2/changefeeds/{changefeed_id}/validate [post]
+func (h *OpenAPIV2) validateChangefeed(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	namespace := getNamespaceValueWithDefault(c)
+	changefeedID := model.ChangeFeedID{Namespace: namespace, ID: c.Param(api.APIOpVarChangefeedID)}
+	if err := model.ValidateChangefeedID(changefeedID.ID); err != nil {
+		_ = c.Error(cerror.ErrAPIInvalidParam.GenWithStack("invalid changefeed_id: %s",
+			changefeedID.ID))
+		return
+	}
+
+	oldCfInfo, err := h.capture.StatusProvider().GetChangeFeedInfo(ctx, changefeedID)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	cfStatus, err := h.capture.StatusProvider().GetChangeFeedStatus(ctx, changefeedID)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	oldCfInfo.Namespace = changefeedID.Namespace
+	oldCfInfo.ID = changefeedID.ID
+	oldUpInfo, err := h.capture.GetUpstreamInfo(ctx, oldCfInfo.UpstreamID,
+		oldCfInfo.Namespace)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	updateCfConfig := &ChangefeedConfig{}
+	if err = c.BindJSON(updateCfConfig); err != nil {
+		_ = c.Error(cerror.WrapError(cerror.ErrAPIInvalidParam, err))
+		return
+	}
+
+	newCfInfo, _, _, err := applyChangefeedConfig(updateCfConfig, oldCfInfo)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	result := &ChangefeedValidationResult{}
+	result.ConfigChanges, err = diffChangefeedInfo(oldCfInfo, newCfInfo)
+	if err != nil {
+		_ = c.Error(errors.Trace(err))
+		return
+	}
+
+	if err = h.helpers.verifyUpstream(ctx, updateCfConfig, oldCfInfo); err != nil {
+		result.Errors = append(result.Errors, err.Error())
+	}
+
+	upManager, err := h.capture.GetUpstreamManager()
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	var storage tidbkv.Storage
+	// Note: upManager is nil in some unit test cases
+	if len(updateCfConfig.PDAddrs) != 0 || upManager == nil {
+		storage, err = h.helpers.createTiStore(ctx, updateCfConfig.PDAddrs,
+			updateCfConfig.PDConfig.toCredential())
+		if err != nil {
+			_ = c.Error(errors.Trace(err))
+			return
+		}
+	} else {
+		up, ok := upManager.Get(oldCfInfo.UpstreamID)
+		if !ok {
+			_ = c.Error(errors.New(fmt.Sprintf("upstream %d not found", oldCfInfo.UpstreamID)))
+			return
+		}
+		storage = up.KVStorage
+	}
+
+	// verifyUpdateChangefeedConfig checks the filter, the compatibility of
+	// the sink config and creates the sink to verify the downstream.
+	verifiedInfo, _, err := h.helpers.verifyUpdateChangefeedConfig(ctx,
+		updateCfConfig, oldCfInfo, oldUpInfo, storage, cfStatus.CheckpointTs)
+	if err != nil {
+		result.Errors = append(result.Errors, err.Error())
+	} else {
+		newCfInfo = verifiedInfo
+	}
+
+	oldTables, err := getReplicatedTables(ctx, h.helpers, oldCfInfo, storage,
+		cfStatus.CheckpointTs, false)
+	if err != nil {
+		_ = c.Error(errors.Trace(err))
+		return
+	}
+	// The event router and column selectors are verified with the new config.
+	newTables, err := getReplicatedTables(ctx, h.helpers, newCfInfo, storage,
+		cfStatus.CheckpointTs, true)
+	if err != nil {
+		result.Errors = append(result.Errors, err.Error())
+	} else {
+		result.AddedTables, result.RemovedTables = diffTables(oldTables.EligibleTables,
+			newTables.EligibleTables)
+		result.IneligibleTables = newTables.IneligibleTables
+	}
+
+	result.Valid = len(result.Errors) == 0
+	c.JSON(http.StatusOK, result)
+}
+
+// diffChangefeedInfo returns the changes of the sink uri,
+// the target ts and the replica config of the changefeed.
+func diffChangefeedInfo(oldInfo, newInfo *model.ChangeFeedInfo) ([]ConfigChange, error) {
+	var changes []ConfigChange
+	if oldInfo.SinkURI != newInfo.SinkURI {
+		changes = append(changes, ConfigChange{
+			Path: "sink_uri",
+			From: util.MaskSensitiveDataInURI(oldInfo.SinkURI),
+			To:   util.MaskSensitiveDataInURI(newInfo.SinkURI),
+		})
+	}
+	if oldInfo.TargetTs != newInfo.TargetTs {
+		changes = append(changes, ConfigChange{
+			Path: "target_ts", From: oldInfo.TargetTs, To: newInfo.TargetTs,
+		})
+	}
+	oldCfg, newCfg := oldInfo.Config.Clone(), newInfo.Config.Clone()
+	oldCfg.MaskSensitiveData()
+	newCfg.MaskSensitiveData()
+	changelog, err := diff.Diff(oldCfg, newCfg)
+	if err != nil {
+		return nil, err
+	}
+	for _, change := range changelog {
+		changes = append(changes, ConfigChange{
+			Path: "config." + strings.Join(change.Path, "."),
+			From: change.From,
+			To:   change.To,
+		})
+	}
+	return changes, nil
+}
+
+// getReplicatedTables returns tables replicated by the changefeed at ts.
+// If verifySink is false, only the filter is applied.
+func getReplicatedTables(
+	ctx context.Context, helpers APIV2Helpers, info *model.ChangeFeedInfo,
+	storage tidbkv.Storage, ts uint64, verifySink bool,
+) (*Tables, error) {
+	var scheme, topic string
+	var protocol config.Protocol
+	if verifySink {
+		uri, err := url.Parse(info.SinkURI)
+		if err != nil {
+			return nil, cerror.WrapError(cerror.ErrSinkURIInvalid, err)
+		}
+		scheme = uri.Scheme
+		topic = strings.TrimFunc(uri.Path, func(r rune) bool {
+			return r == '/'
+		})
+		protocol, _ = config.ParseSinkProtocolFromString(util.GetOrZero(info.Config.Sink.Protocol))
+	}
+	ineligibleTables, eligibleTables, err := helpers.getVerifiedTables(
+		ctx, info.Config, storage, ts, scheme, topic, protocol)
+	if err != nil {
+		return nil, err
+	}
+	if info.Config.ForceReplicate {
+		eligibleTables = append(eligibleTables, ineligibleTables...)
+		ineligibleTables = nil
+	}
+	tables := &Tables{}
+	for _, tbl := range eligibleTables {
+		tables.EligibleTables = append(tables.EligibleTables, toAPITableName(tbl))
+	}
+	for _, tbl := range ineligibleTables {
+		tables.IneligibleTables = append(tables.IneligibleTables, toAPITableName(tbl))
+	}
+	return tables, nil
+}
+
+func toAPITableName(tbl model.TableName) TableName {
+	return TableName{
+		Schema:      tbl.Schema,
+		Table:       tbl.Table,
+		TableID:     tbl.TableID,
+		IsPartition: tbl.IsPartition,
+	}
+}
+
+// diffTables returns tables only in newTables and tables only in oldTables.
+func diffTables(oldTables, newTables []TableName) (added, removed []TableName) {
+	oldSet := make(map[int64]struct{}, len(oldTables))
+	for _, tbl := range oldTables {
+		oldSet[tbl.TableID] = struct{}{}
+	}
+	newSet := make(map[int64]struct{}, len(newTables))
+	for _, tbl := range newTables {
+		newSet[tbl.TableID] = struct{}{}
+		if _, ok := oldSet[tbl.TableID]; !ok {
+			added = append(added, tbl)
+		}
+	}
+	for _, tbl := range oldTables {
+		if _, ok := newSet[tbl.TableID]; !ok {
+			removed = append(removed, tbl)
+		}
+	}
+	return added, removed
+}
+
 // getChangefeed get detailed info of a changefeed
 // @Summary Get changefeed
 // @Description get detail information of a changefeed
@@ -867,6 +1092,7 @@ func (h *OpenAPIV2) status(c *gin.Context) {
 			Addr:    info.Error.Addr,
 			Code:    info.Error.Code,
 			Message: info.Error.Message,
+			Table:   info.Error.Table,
 		}
 	}
 	var lastWarning *RunningError
@@ -877,6 +1103,7 @@ func (h *OpenAPIV2) status(c *gin.Context) {
 			Addr:    info.Warning.Addr,
 			Code:    info.Warning.Code,
 			Message: info.Warning.Message,
+			Table:   info.Warning.Table,
 		}
 	}
 
@@ -886,6 +1113,7 @@ func (h *OpenAPIV2) status(c *gin.Context) {
 		ResolvedTs:   status.ResolvedTs,
 		LastError:    lastError,
 		LastWarning:  lastWarning,
+		ErrorHistory: toAPIErrorHistory(info.ErrorHistory),
 	})
 }
 
@@ -1050,6 +1278,7 @@ func toAPIModel(
 			Addr:    info.Error.Addr,
 			Code:    info.Error.Code,
 			Message: info.Error.Message,
+			Table:   info.Error.Table,
 		}
 	}
 
@@ -1074,6 +1303,7 @@ func toAPIModel(
 		Config:         ToAPIReplicaConfig(info.Config),
 		State:          info.State,
 		Error:          runningError,
+		ErrorHistory:   toAPIErrorHistory(info.ErrorHistory),
 		CreatorVersion: info.CreatorVersion,
 		CheckpointTs:   checkpointTs,
 		ResolvedTs:     resolvedTs,
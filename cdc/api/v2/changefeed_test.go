@@ -522,6 +522,99 @@ func TestUpdateChangefeed(t *testing.T) {
 	require.Equal(t, http.StatusOK, w.Code)
 }
 
+func TestValidateChangefeed(t *testing.T) {
+	t.Parallel()
+	validate := testCase{url: "/api/v2/changefeeds/%s/validate", method: "POST"}
+	helpers := NewMockAPIV2Helpers(gomock.NewController(t))
+	mockCapture := mock_capture.NewMockCapture(gomock.NewController(t))
+	apiV2 := NewOpenAPIV2ForTest(mockCapture, helpers)
+	router := newRouter(apiV2)
+
+	oldCfInfo := &model.ChangeFeedInfo{
+		ID:         changeFeedID.ID,
+		State:      model.StateNormal,
+		UpstreamID: 1,
+		Namespace:  model.DefaultNamespace,
+		SinkURI:    blackholeSink,
+		Config:     config.GetDefaultReplicaConfig(),
+	}
+	statusProvider := &mockStatusProvider{
+		changefeedInfo:   oldCfInfo,
+		changefeedStatus: &model.ChangeFeedStatusForAPI{CheckpointTs: 1},
+	}
+	mockCapture.EXPECT().StatusProvider().Return(statusProvider).AnyTimes()
+	mockCapture.EXPECT().IsReady().Return(true).AnyTimes()
+	mockCapture.EXPECT().IsOwner().Return(true).AnyTimes()
+	mockCapture.EXPECT().GetUpstreamInfo(gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(nil, nil).AnyTimes()
+	mockCapture.EXPECT().GetUpstreamManager().Return(nil, nil).AnyTimes()
+	helpers.EXPECT().verifyUpstream(gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(nil).AnyTimes()
+	helpers.EXPECT().createTiStore(gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(nil, nil).AnyTimes()
+
+	newReplicaConfig := config.GetDefaultReplicaConfig()
+	newReplicaConfig.Filter.Rules = []string{"test.t1"}
+	updateCfg := &ChangefeedConfig{
+		TargetTs:      10,
+		ReplicaConfig: ToAPIReplicaConfig(newReplicaConfig),
+	}
+	body, err := json.Marshal(updateCfg)
+	require.Nil(t, err)
+
+	// case 1: valid update, a table is no longer replicated
+	helpers.EXPECT().
+		verifyUpdateChangefeedConfig(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(&model.ChangeFeedInfo{SinkURI: blackholeSink, TargetTs: 10, Config: newReplicaConfig},
+			&model.UpstreamInfo{}, nil).Times(1)
+	helpers.EXPECT().
+		getVerifiedTables(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(nil, []model.TableName{
+			{Schema: "test", Table: "t1", TableID: 1},
+			{Schema: "test", Table: "t2", TableID: 2},
+		}, nil).Times(1)
+	helpers.EXPECT().
+		getVerifiedTables(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(nil, []model.TableName{{Schema: "test", Table: "t1", TableID: 1}}, nil).Times(1)
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequestWithContext(context.Background(), validate.method,
+		fmt.Sprintf(validate.url, changeFeedID.ID), bytes.NewReader(body))
+	router.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+	result := &ChangefeedValidationResult{}
+	require.Nil(t, json.NewDecoder(w.Body).Decode(result))
+	require.True(t, result.Valid)
+	require.Empty(t, result.AddedTables)
+	require.Equal(t, []TableName{{Schema: "test", Table: "t2", TableID: 2}}, result.RemovedTables)
+	paths := make([]string, 0, len(result.ConfigChanges))
+	for _, change := range result.ConfigChanges {
+		paths = append(paths, change.Path)
+	}
+	require.Contains(t, paths, "target_ts")
+	require.Contains(t, paths, "config.Filter.Rules.0")
+
+	// case 2: the sink verification failed
+	helpers.EXPECT().
+		verifyUpdateChangefeedConfig(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(nil, nil, cerrors.ErrChangefeedUpdateRefused.GenWithStackByArgs("bad sink")).Times(1)
+	helpers.EXPECT().
+		getVerifiedTables(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(nil, nil, nil).Times(1)
+	helpers.EXPECT().
+		getVerifiedTables(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(nil, nil, cerrors.ErrDispatcherFailed.GenWithStackByArgs()).Times(1)
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequestWithContext(context.Background(), validate.method,
+		fmt.Sprintf(validate.url, changeFeedID.ID), bytes.NewReader(body))
+	router.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+	result = &ChangefeedValidationResult{}
+	require.Nil(t, json.NewDecoder(w.Body).Decode(result))
+	require.False(t, result.Valid)
+	require.Len(t, result.Errors, 2)
+	require.Contains(t, result.Errors[0], "bad sink")
+}
+
 func TestListChangeFeeds(t *testing.T) {
 	t.Parallel()
 
@@ -0,0 +1,174 @@
+// Copyright 2024 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v2
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pingcap/tiflow/cdc/api"
+	"github.com/pingcap/tiflow/cdc/model"
+	cerror "github.com/pingcap/tiflow/pkg/errors"
+)
+
+const (
+	// apiOpVarHotspotLimit is the query parameter of the max number of
+	// the hottest tables and regions returned.
+	apiOpVarHotspotLimit = "limit"
+	defaultHotspotLimit  = 10
+)
+
+// getChangefeedHotspots gets the hottest tables and regions of a changefeed
+// @Summary Get the hottest tables and regions of a changefeed
+// @Description get the table spans and the regions with the highest event rates
+// @Description received from TiKV. The owner collects them from all the processors
+// @Description of the changefeed.
+// @Tags changefeed,v2
+// @Produce json
+// @Param changefeed_id path string true "changefeed_id"
+// @Param namespace query string false "default"
+// @Param limit query int false "the max number of the hottest tables and regions, 10 by default"
+// @Success 200 {object} ChangefeedHotspots
+// @Failure 400,500 {object} model.HTTPError
+// @Router /api/v2/changefeeds/{changefeed_id}/hotspots [get]
+func (h *OpenAPIV2) getChangefeedHotspots(c *gin.Context) {
+	ctx := c.Request.Context()
+	namespace := getNamespaceValueWithDefault(c)
+	changefeedID := model.ChangeFeedID{Namespace: namespace, ID: c.Param(api.APIOpVarChangefeedID)}
+	if err := model.ValidateChangefeedID(changefeedID.ID); err != nil {
+		_ = c.Error(cerror.ErrAPIInvalidParam.GenWithStack(
+			"invalid changefeed_id: %s", changefeedID.ID))
+		return
+	}
+	limit, err := getHotspotLimit(c)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	// make sure the changefeed exists.
+	if _, err := h.capture.StatusProvider().GetChangeFeedStatus(ctx, changefeedID); err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	uri := func(captureID model.CaptureID) string {
+		return fmt.Sprintf("/api/v2/processors/%s/%s/hotspots?%s",
+			url.PathEscape(changefeedID.ID), url.PathEscape(captureID),
+			url.Values{
+				api.APIOpVarNamespace: {changefeedID.Namespace},
+				apiOpVarHotspotLimit:  {strconv.Itoa(limit)},
+			}.Encode())
+	}
+	processors, errs, err := queryProcessors(c, h, changefeedID,
+		func() (*model.ProcessorHotspots, error) {
+			return h.capture.GetProcessorHotspots(ctx, changefeedID, limit)
+		}, uri)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	resp := &ChangefeedHotspots{
+		Namespace:    changefeedID.Namespace,
+		ChangefeedID: changefeedID.ID,
+		Tables:       make([]model.HotTable, 0),
+		Regions:      make([]model.HotRegion, 0),
+		Errors:       errs,
+	}
+	for _, p := range processors {
+		resp.Tables = append(resp.Tables, p.Tables...)
+		resp.Regions = append(resp.Regions, p.Regions...)
+	}
+	sort.Slice(resp.Tables, func(i, j int) bool {
+		if resp.Tables[i].EventRate != resp.Tables[j].EventRate {
+			return resp.Tables[i].EventRate > resp.Tables[j].EventRate
+		}
+		return resp.Tables[i].ByteRate > resp.Tables[j].ByteRate
+	})
+	sort.Slice(resp.Regions, func(i, j int) bool {
+		if resp.Regions[i].EventRate != resp.Regions[j].EventRate {
+			return resp.Regions[i].EventRate > resp.Regions[j].EventRate
+		}
+		return resp.Regions[i].ByteRate > resp.Regions[j].ByteRate
+	})
+	if len(resp.Tables) > limit {
+		resp.Tables = resp.Tables[:limit]
+	}
+	if len(resp.Regions) > limit {
+		resp.Regions = resp.Regions[:limit]
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// getProcessorHotspots gets the hottest tables and regions of a processor
+// @Summary Get the hottest tables and regions of a processor
+// @Description get the table spans and the regions with the highest event rates
+// @Description received from TiKV by the processor
+// @Tags processor,v2
+// @Produce json
+// @Param changefeed_id path string true "changefeed_id"
+// @Param capture_id path string true "capture_id"
+// @Param namespace query string false "default"
+// @Param limit query int false "the max number of the hottest tables and regions, 10 by default"
+// @Success 200 {object} model.ProcessorHotspots
+// @Failure 400,500 {object} model.HTTPError
+// @Router /api/v2/processors/{changefeed_id}/{capture_id}/hotspots [get]
+func (h *OpenAPIV2) getProcessorHotspots(c *gin.Context) {
+	ctx := c.Request.Context()
+	namespace := getNamespaceValueWithDefault(c)
+	changefeedID := model.ChangeFeedID{Namespace: namespace, ID: c.Param(api.APIOpVarChangefeedID)}
+	if err := model.ValidateChangefeedID(changefeedID.ID); err != nil {
+		_ = c.Error(cerror.ErrAPIInvalidParam.GenWithStack(
+			"invalid changefeed_id: %s", changefeedID.ID))
+		return
+	}
+	captureID := c.Param(apiOpVarCaptureID)
+	if err := model.ValidateChangefeedID(captureID); err != nil {
+		_ = c.Error(cerror.ErrAPIInvalidParam.GenWithStack(
+			"invalid capture_id: %s", captureID))
+		return
+	}
+	limit, err := getHotspotLimit(c)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	if h.forwardToOtherCapture(c, captureID) {
+		return
+	}
+
+	hotspots, err := h.capture.GetProcessorHotspots(ctx, changefeedID, limit)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, hotspots)
+}
+
+func getHotspotLimit(c *gin.Context) (int, error) {
+	limitStr := c.Query(apiOpVarHotspotLimit)
+	if limitStr == "" {
+		return defaultHotspotLimit, nil
+	}
+	limit, err := strconv.Atoi(limitStr)
+	if err != nil || limit < 0 {
+		return 0, cerror.ErrAPIInvalidParam.GenWithStack(
+			"invalid limit: %s", limitStr)
+	}
+	return limit, nil
+}
@@ -0,0 +1,138 @@
+// Copyright 2024 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v2
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pingcap/tiflow/cdc/api"
+	"github.com/pingcap/tiflow/cdc/model"
+	cerror "github.com/pingcap/tiflow/pkg/errors"
+	"github.com/pingcap/tiflow/pkg/integrity"
+)
+
+// listCorruptedKeys lists the corrupted keys of a changefeed
+// @Summary List the corrupted keys of a changefeed
+// @Description list the rows found corrupted by verifying the rows written
+// @Description to the downstream against their checksums. The keys are kept
+// @Description in the memory of the captures which write the rows, the owner
+// @Description collects them from all the captures.
+// @Tags changefeed,v2
+// @Produce json
+// @Param changefeed_id path string true "changefeed_id"
+// @Param namespace query string false "default"
+// @Success 200 {object} ChangefeedCorruptedKeys
+// @Failure 400,500 {object} model.HTTPError
+// @Router /api/v2/changefeeds/{changefeed_id}/corrupted_keys [get]
+func (h *OpenAPIV2) listCorruptedKeys(c *gin.Context) {
+	ctx := c.Request.Context()
+	namespace := getNamespaceValueWithDefault(c)
+	changefeedID := model.ChangeFeedID{Namespace: namespace, ID: c.Param(api.APIOpVarChangefeedID)}
+	if err := model.ValidateChangefeedID(changefeedID.ID); err != nil {
+		_ = c.Error(cerror.ErrAPIInvalidParam.GenWithStack(
+			"invalid changefeed_id: %s", changefeedID.ID))
+		return
+	}
+	provider := h.capture.StatusProvider()
+	// make sure the changefeed exists.
+	if _, err := provider.GetChangeFeedStatus(ctx, changefeedID); err != nil {
+		_ = c.Error(err)
+		return
+	}
+	// the keys are kept after the processor is moved to another capture,
+	// so all the captures are queried instead of the processors.
+	captures, err := provider.GetCaptures(ctx)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	self, err := h.capture.Info()
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	resp := &ChangefeedCorruptedKeys{
+		Namespace:    changefeedID.Namespace,
+		ChangefeedID: changefeedID.ID,
+		Keys:         make([]CorruptedKey, 0),
+	}
+	for _, capture := range captures {
+		var keys []integrity.CorruptedKey
+		if capture.ID == self.ID {
+			keys = integrity.ListCorruptedKeys(changefeedID.Namespace, changefeedID.ID)
+		} else {
+			uri := fmt.Sprintf("/api/v2/processors/%s/%s/corrupted_keys?%s",
+				url.PathEscape(changefeedID.ID), url.PathEscape(capture.ID),
+				url.Values{api.APIOpVarNamespace: {changefeedID.Namespace}}.Encode())
+			list := &ListResponse[integrity.CorruptedKey]{}
+			if err := api.GetFromCapture(c, self.ID, capture.AdvertiseAddr, uri, list); err != nil {
+				if resp.Errors == nil {
+					resp.Errors = make(map[string]string)
+				}
+				resp.Errors[capture.ID] = err.Error()
+				continue
+			}
+			keys = list.Items
+		}
+		for _, key := range keys {
+			resp.Keys = append(resp.Keys, CorruptedKey{CaptureID: capture.ID, CorruptedKey: key})
+		}
+	}
+	sort.SliceStable(resp.Keys, func(i, j int) bool {
+		return resp.Keys[i].DetectTime.Before(resp.Keys[j].DetectTime)
+	})
+	c.JSON(http.StatusOK, resp)
+}
+
+// listCaptureCorruptedKeys lists the corrupted keys of a changefeed found by a capture
+// @Summary List the corrupted keys of a changefeed found by a capture
+// @Description list the rows of the changefeed found corrupted by the capture,
+// @Description the oldest first
+// @Tags processor,v2
+// @Produce json
+// @Param changefeed_id path string true "changefeed_id"
+// @Param capture_id path string true "capture_id"
+// @Param namespace query string false "default"
+// @Success 200 {object} ListResponse[integrity.CorruptedKey]
+// @Failure 400,500 {object} model.HTTPError
+// @Router /api/v2/processors/{changefeed_id}/{capture_id}/corrupted_keys [get]
+func (h *OpenAPIV2) listCaptureCorruptedKeys(c *gin.Context) {
+	namespace := getNamespaceValueWithDefault(c)
+	changefeedID := model.ChangeFeedID{Namespace: namespace, ID: c.Param(api.APIOpVarChangefeedID)}
+	if err := model.ValidateChangefeedID(changefeedID.ID); err != nil {
+		_ = c.Error(cerror.ErrAPIInvalidParam.GenWithStack(
+			"invalid changefeed_id: %s", changefeedID.ID))
+		return
+	}
+	captureID := c.Param(apiOpVarCaptureID)
+	if err := model.ValidateChangefeedID(captureID); err != nil {
+		_ = c.Error(cerror.ErrAPIInvalidParam.GenWithStack(
+			"invalid capture_id: %s", captureID))
+		return
+	}
+	if h.forwardToOtherCapture(c, captureID) {
+		return
+	}
+
+	keys := integrity.ListCorruptedKeys(changefeedID.Namespace, changefeedID.ID)
+	c.JSON(http.StatusOK, &ListResponse[integrity.CorruptedKey]{
+		Total: len(keys),
+		Items: keys,
+	})
+}
@@ -0,0 +1,238 @@
+// Copyright 2024 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v2
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pingcap/tiflow/cdc/api"
+	"github.com/pingcap/tiflow/cdc/model"
+	cerror "github.com/pingcap/tiflow/pkg/errors"
+)
+
+const (
+	// apiOpVarSlowRegionLimit is the query parameter of the max number of
+	// the slowest regions returned for each processor.
+	apiOpVarSlowRegionLimit = "slow_region_limit"
+	defaultSlowRegionLimit  = 10
+)
+
+// getChangefeedLatency gets the latency breakdown of a changefeed
+// @Summary Get the latency breakdown of a changefeed
+// @Description get the progress of each table in each stage of the processors,
+// @Description the memory quota usage and the slowest regions of the processors.
+// @Description The owner collects them from all the processors of the changefeed.
+// @Tags changefeed,v2
+// @Produce json
+// @Param changefeed_id path string true "changefeed_id"
+// @Param namespace query string false "default"
+// @Param slow_region_limit query int false "the max number of the slowest regions of each processor, 10 by default"
+// @Success 200 {object} ChangefeedLatency
+// @Failure 400,500 {object} model.HTTPError
+// @Router /api/v2/changefeeds/{changefeed_id}/latency [get]
+func (h *OpenAPIV2) getChangefeedLatency(c *gin.Context) {
+	ctx := c.Request.Context()
+	namespace := getNamespaceValueWithDefault(c)
+	changefeedID := model.ChangeFeedID{Namespace: namespace, ID: c.Param(api.APIOpVarChangefeedID)}
+	if err := model.ValidateChangefeedID(changefeedID.ID); err != nil {
+		_ = c.Error(cerror.ErrAPIInvalidParam.GenWithStack(
+			"invalid changefeed_id: %s", changefeedID.ID))
+		return
+	}
+	limit, err := getSlowRegionLimit(c)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	provider := h.capture.StatusProvider()
+	status, err := provider.GetChangeFeedStatus(ctx, changefeedID)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	uri := func(captureID model.CaptureID) string {
+		return fmt.Sprintf("/api/v2/processors/%s/%s/latency?%s",
+			url.PathEscape(changefeedID.ID), url.PathEscape(captureID),
+			url.Values{
+				api.APIOpVarNamespace:   {changefeedID.Namespace},
+				apiOpVarSlowRegionLimit: {strconv.Itoa(limit)},
+			}.Encode())
+	}
+	processors, errs, err := queryProcessors(c, h, changefeedID,
+		func() (*model.ProcessorLatency, error) {
+			return h.capture.GetProcessorLatency(ctx, changefeedID, limit)
+		}, uri)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	resp := &ChangefeedLatency{
+		Namespace:    changefeedID.Namespace,
+		ChangefeedID: changefeedID.ID,
+		CheckpointTs: status.CheckpointTs,
+		ResolvedTs:   status.ResolvedTs,
+		Processors:   processors,
+		Errors:       errs,
+	}
+	sort.Slice(resp.Processors, func(i, j int) bool {
+		return resp.Processors[i].CaptureID < resp.Processors[j].CaptureID
+	})
+	c.JSON(http.StatusOK, resp)
+}
+
+// getProcessorLatency gets the latency breakdown of a processor
+// @Summary Get the latency breakdown of a processor
+// @Description get the progress of each table in each stage of the processor,
+// @Description the memory quota usage and the slowest regions of the processor
+// @Tags processor,v2
+// @Produce json
+// @Param changefeed_id path string true "changefeed_id"
+// @Param capture_id path string true "capture_id"
+// @Param namespace query string false "default"
+// @Param slow_region_limit query int false "the max number of the slowest regions, 10 by default"
+// @Success 200 {object} model.ProcessorLatency
+// @Failure 400,500 {object} model.HTTPError
+// @Router /api/v2/processors/{changefeed_id}/{capture_id}/latency [get]
+func (h *OpenAPIV2) getProcessorLatency(c *gin.Context) {
+	ctx := c.Request.Context()
+	namespace := getNamespaceValueWithDefault(c)
+	changefeedID := model.ChangeFeedID{Namespace: namespace, ID: c.Param(api.APIOpVarChangefeedID)}
+	if err := model.ValidateChangefeedID(changefeedID.ID); err != nil {
+		_ = c.Error(cerror.ErrAPIInvalidParam.GenWithStack(
+			"invalid changefeed_id: %s", changefeedID.ID))
+		return
+	}
+	captureID := c.Param(apiOpVarCaptureID)
+	if err := model.ValidateChangefeedID(captureID); err != nil {
+		_ = c.Error(cerror.ErrAPIInvalidParam.GenWithStack(
+			"invalid capture_id: %s", captureID))
+		return
+	}
+	limit, err := getSlowRegionLimit(c)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	if h.forwardToOtherCapture(c, captureID) {
+		return
+	}
+
+	latency, err := h.capture.GetProcessorLatency(ctx, changefeedID, limit)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, latency)
+}
+
+// forwardToOtherCapture forwards the request to the capture if it's not this capture.
+// It returns true if the request is handled.
+func (h *OpenAPIV2) forwardToOtherCapture(c *gin.Context, captureID model.CaptureID) bool {
+	self, err := h.capture.Info()
+	if err != nil {
+		_ = c.Error(err)
+		return true
+	}
+	if captureID == self.ID {
+		return false
+	}
+	_, captures, err := h.capture.GetEtcdClient().GetCaptures(c.Request.Context())
+	if err != nil {
+		_ = c.Error(err)
+		return true
+	}
+	for _, capture := range captures {
+		if capture.ID == captureID {
+			api.ForwardToCapture(c, self.ID, capture.AdvertiseAddr)
+			return true
+		}
+	}
+	_ = c.Error(cerror.ErrCaptureNotExist.GenWithStackByArgs(captureID))
+	return true
+}
+
+// queryProcessors queries the processors of the changefeed on all captures.
+// local queries the processor on this capture, and uri returns the path of
+// the capture-local API to query the processor on another capture.
+// The errors of querying the processors are returned by capture IDs.
+func queryProcessors[T any](
+	c *gin.Context, h *OpenAPIV2, changefeedID model.ChangeFeedID,
+	local func() (*T, error), uri func(captureID model.CaptureID) string,
+) ([]*T, map[string]string, error) {
+	ctx := c.Request.Context()
+	provider := h.capture.StatusProvider()
+	procInfos, err := provider.GetProcessors(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	captures, err := provider.GetCaptures(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	self, err := h.capture.Info()
+	if err != nil {
+		return nil, nil, err
+	}
+	addrs := make(map[model.CaptureID]string, len(captures))
+	for _, capture := range captures {
+		addrs[capture.ID] = capture.AdvertiseAddr
+	}
+
+	res := make([]*T, 0)
+	var errs map[string]string
+	for _, info := range procInfos {
+		if info.CfID != changefeedID {
+			continue
+		}
+		var v *T
+		if info.CaptureID == self.ID {
+			v, err = local()
+		} else if addr, ok := addrs[info.CaptureID]; ok {
+			v = new(T)
+			err = api.GetFromCapture(c, self.ID, addr, uri(info.CaptureID), v)
+		} else {
+			err = cerror.ErrCaptureNotExist.GenWithStackByArgs(info.CaptureID)
+		}
+		if err != nil {
+			if errs == nil {
+				errs = make(map[string]string)
+			}
+			errs[info.CaptureID] = err.Error()
+			continue
+		}
+		res = append(res, v)
+	}
+	return res, errs, nil
+}
+
+func getSlowRegionLimit(c *gin.Context) (int, error) {
+	limitStr := c.Query(apiOpVarSlowRegionLimit)
+	if limitStr == "" {
+		return defaultSlowRegionLimit, nil
+	}
+	limit, err := strconv.Atoi(limitStr)
+	if err != nil || limit < 0 {
+		return 0, cerror.ErrAPIInvalidParam.GenWithStack(
+			"invalid slow_region_limit: %s", limitStr)
+	}
+	return limit, nil
+}
@@ -53,6 +53,29 @@ type Tables struct {
 	EligibleTables   []TableName `json:"eligible_tables,omitempty"`
 }
 
+// ConfigChange is a change of a field in the changefeed config.
+type ConfigChange struct {
+	Path string      `json:"path"`
+	From interface{} `json:"from"`
+	To   interface{} `json:"to"`
+}
+
+// ChangefeedValidationResult is the result of validating a changefeed
+// config update without applying it.
+type ChangefeedValidationResult struct {
+	// Valid is true if the update passes all verifications.
+	Valid bool `json:"valid"`
+	// Errors are the verifications failed.
+	Errors []string `json:"errors,omitempty"`
+	// ConfigChanges is the structured diff of the changefeed config.
+	ConfigChanges []ConfigChange `json:"config_changes,omitempty"`
+	// AddedTables and RemovedTables are tables which start or stop
+	// being replicated after the update.
+	AddedTables      []TableName `json:"added_tables,omitempty"`
+	RemovedTables    []TableName `json:"removed_tables,omitempty"`
+	IneligibleTables []TableName `json:"ineligible_tables,omitempty"`
+}
+
 // TableName contains table information
 type TableName struct {
 	Schema      string `json:"database_name"`
@@ -111,6 +134,42 @@ type SyncedStatusConfig struct {
 	CheckpointInterval int64 `json:"checkpoint_interval"`
 }
 
+// ChangefeedRetryPolicy represents the retry policy of a changefeed.
+type ChangefeedRetryPolicy struct {
+	MaxRetries          int           `json:"max_retries"`
+	BackoffInitInterval *JSONDuration `json:"backoff_init_interval,omitempty" swaggertype:"string"`
+	BackoffMaxInterval  *JSONDuration `json:"backoff_max_interval,omitempty" swaggertype:"string"`
+	RetryableErrorCodes []string      `json:"retryable_error_codes,omitempty"`
+	FatalErrorCodes     []string      `json:"fatal_error_codes,omitempty"`
+	ErrorHistorySize    int           `json:"error_history_size"`
+}
+
+// AlertConfig represents the alert config of a changefeed.
+// This is a duplicate of config.AlertConfig
+type AlertConfig struct {
+	WebhookURLs            []string      `json:"webhook_urls,omitempty"`
+	CheckpointLagThreshold *JSONDuration `json:"checkpoint_lag_threshold,omitempty" swaggertype:"string"`
+	DDLBlockedThreshold    *JSONDuration `json:"ddl_blocked_threshold,omitempty" swaggertype:"string"`
+	NotifyStates           []string      `json:"notify_states,omitempty"`
+	MaxRetries             int           `json:"max_retries"`
+	DedupInterval          *JSONDuration `json:"dedup_interval,omitempty" swaggertype:"string"`
+}
+
+// ChangefeedTracingConfig represents the tracing config of a changefeed.
+type ChangefeedTracingConfig struct {
+	SampleRatio float64 `json:"sample_ratio"`
+}
+
+// ValidatorConfig represents the data consistency validator config of a changefeed.
+// This is a duplicate of config.ValidatorConfig
+type ValidatorConfig struct {
+	Enable      bool          `json:"enable"`
+	UpstreamURI string        `json:"upstream_uri"`
+	Tables      []string      `json:"tables"`
+	Interval    *JSONDuration `json:"interval,omitempty" swaggertype:"string"`
+	ChunkSize   int           `json:"chunk_size"`
+}
+
 // MarshalJSON marshal changefeed common info to json
 // we need to set feed state to normal if it is uninitialized and pending to warning
 // to hide the detail of uninitialized and pending state from user
@@ -204,6 +263,11 @@ type ReplicaConfig struct {
 	Integrity                    *IntegrityConfig           `json:"integrity"`
 	ChangefeedErrorStuckDuration *JSONDuration              `json:"changefeed_error_stuck_duration,omitempty"`
 	SyncedStatus                 *SyncedStatusConfig        `json:"synced_status,omitempty"`
+	Priority                     string                     `json:"priority,omitempty"`
+	RetryPolicy                  *ChangefeedRetryPolicy     `json:"retry_policy,omitempty"`
+	Alert                        *AlertConfig               `json:"alert,omitempty"`
+	Tracing                      *ChangefeedTracingConfig   `json:"tracing,omitempty"`
+	Validator                    *ValidatorConfig           `json:"validator,omitempty"`
 
 	// Deprecated: we don't use this field since v8.0.0.
 	SQLMode string `json:"sql_mode,omitempty"`
@@ -232,6 +296,7 @@ func (c *ReplicaConfig) toInternalReplicaConfigWithOriginConfig(
 		res.SyncPointRetention = &c.SyncPointRetention.duration
 	}
 	res.BDRMode = c.BDRMode
+	res.Priority = config.ChangefeedPriority(c.Priority)
 
 	if c.Filter != nil {
 		var efs []*config.EventFilterRule
@@ -318,6 +383,9 @@ func (c *ReplicaConfig) toInternalReplicaConfigWithOriginConfig(
 				AuthTLSCertificatePath:  c.Sink.PulsarConfig.AuthTLSCertificatePath,
 				AuthTLSPrivateKeyPath:   c.Sink.PulsarConfig.AuthTLSPrivateKeyPath,
 				OutputRawChangeEvent:    c.Sink.PulsarConfig.OutputRawChangeEvent,
+				EnableOrderingKey:       c.Sink.PulsarConfig.EnableOrderingKey,
+				EnableTransaction:       c.Sink.PulsarConfig.EnableTransaction,
+				TransactionTimeout:      (*config.TimeSec)(c.Sink.PulsarConfig.TransactionTimeout),
 			}
 			if c.Sink.PulsarConfig.OAuth2 != nil {
 				pulsarConfig.OAuth2 = &config.OAuth2{
@@ -353,6 +421,8 @@ func (c *ReplicaConfig) toInternalReplicaConfigWithOriginConfig(
 					LargeMessageHandleCompression: oldConfig.LargeMessageHandleCompression,
 					ClaimCheckStorageURI:          oldConfig.ClaimCheckStorageURI,
 					ClaimCheckRawValue:            oldConfig.ClaimCheckRawValue,
+					ClaimCheckFileExpirationDays:  oldConfig.ClaimCheckFileExpirationDays,
+					ClaimCheckFileCleanupCronSpec: oldConfig.ClaimCheckFileCleanupCronSpec,
 				}
 			}
 
@@ -405,6 +475,7 @@ func (c *ReplicaConfig) toInternalReplicaConfigWithOriginConfig(
 				LargeMessageHandle:           largeMessageHandle,
 				GlueSchemaRegistryConfig:     glueSchemaRegistryConfig,
 				OutputRawChangeEvent:         c.Sink.KafkaConfig.OutputRawChangeEvent,
+				TransactionalIDPrefix:        c.Sink.KafkaConfig.TransactionalIDPrefix,
 			}
 		}
 		var mysqlConfig *config.MySQLConfig
@@ -460,6 +531,7 @@ func (c *ReplicaConfig) toInternalReplicaConfigWithOriginConfig(
 			ColumnSelectors:                  columnSelectors,
 			SchemaRegistry:                   c.Sink.SchemaRegistry,
 			EncoderConcurrency:               c.Sink.EncoderConcurrency,
+			MQSpillThreshold:                 c.Sink.MQSpillThreshold,
 			Terminator:                       c.Sink.Terminator,
 			DateSeparator:                    c.Sink.DateSeparator,
 			EnablePartitionSeparator:         c.Sink.EnablePartitionSeparator,
@@ -510,15 +582,18 @@ func (c *ReplicaConfig) toInternalReplicaConfigWithOriginConfig(
 	}
 	if c.Scheduler != nil {
 		res.Scheduler = &config.ChangefeedSchedulerConfig{
-			EnableTableAcrossNodes: c.Scheduler.EnableTableAcrossNodes,
-			RegionThreshold:        c.Scheduler.RegionThreshold,
-			WriteKeyThreshold:      c.Scheduler.WriteKeyThreshold,
+			EnableTableAcrossNodes:     c.Scheduler.EnableTableAcrossNodes,
+			RegionThreshold:            c.Scheduler.RegionThreshold,
+			WriteKeyThreshold:          c.Scheduler.WriteKeyThreshold,
+			HotTableEventRateThreshold: c.Scheduler.HotTableEventRateThreshold,
 		}
 	}
 	if c.Integrity != nil {
 		res.Integrity = &integrity.Config{
-			IntegrityCheckLevel:   c.Integrity.IntegrityCheckLevel,
-			CorruptionHandleLevel: c.Integrity.CorruptionHandleLevel,
+			IntegrityCheckLevel:         c.Integrity.IntegrityCheckLevel,
+			CorruptionHandleLevel:       c.Integrity.CorruptionHandleLevel,
+			DownstreamVerifyLevel:       c.Integrity.DownstreamVerifyLevel,
+			DownstreamVerifySampleRatio: c.Integrity.DownstreamVerifySampleRatio,
 		}
 	}
 	if c.ChangefeedErrorStuckDuration != nil {
@@ -530,6 +605,52 @@ func (c *ReplicaConfig) toInternalReplicaConfigWithOriginConfig(
 			CheckpointInterval:  c.SyncedStatus.CheckpointInterval,
 		}
 	}
+	if c.RetryPolicy != nil {
+		res.RetryPolicy = &config.ChangefeedRetryPolicy{
+			MaxRetries:          c.RetryPolicy.MaxRetries,
+			RetryableErrorCodes: c.RetryPolicy.RetryableErrorCodes,
+			FatalErrorCodes:     c.RetryPolicy.FatalErrorCodes,
+			ErrorHistorySize:    c.RetryPolicy.ErrorHistorySize,
+		}
+		if c.RetryPolicy.BackoffInitInterval != nil {
+			res.RetryPolicy.BackoffInitInterval = &c.RetryPolicy.BackoffInitInterval.duration
+		}
+		if c.RetryPolicy.BackoffMaxInterval != nil {
+			res.RetryPolicy.BackoffMaxInterval = &c.RetryPolicy.BackoffMaxInterval.duration
+		}
+	}
+	if c.Alert != nil {
+		res.Alert = &config.AlertConfig{
+			WebhookURLs:  c.Alert.WebhookURLs,
+			NotifyStates: c.Alert.NotifyStates,
+			MaxRetries:   c.Alert.MaxRetries,
+		}
+		if c.Alert.CheckpointLagThreshold != nil {
+			res.Alert.CheckpointLagThreshold = &c.Alert.CheckpointLagThreshold.duration
+		}
+		if c.Alert.DDLBlockedThreshold != nil {
+			res.Alert.DDLBlockedThreshold = &c.Alert.DDLBlockedThreshold.duration
+		}
+		if c.Alert.DedupInterval != nil {
+			res.Alert.DedupInterval = &c.Alert.DedupInterval.duration
+		}
+	}
+	if c.Tracing != nil {
+		res.Tracing = &config.ChangefeedTracingConfig{
+			SampleRatio: c.Tracing.SampleRatio,
+		}
+	}
+	if c.Validator != nil {
+		res.Validator = &config.ValidatorConfig{
+			Enable:      c.Validator.Enable,
+			UpstreamURI: c.Validator.UpstreamURI,
+			Tables:      c.Validator.Tables,
+			ChunkSize:   c.Validator.ChunkSize,
+		}
+		if c.Validator.Interval != nil {
+			res.Validator.Interval = &c.Validator.Interval.duration
+		}
+	}
 	return res
 }
 
@@ -546,6 +667,7 @@ func ToAPIReplicaConfig(c *config.ReplicaConfig) *ReplicaConfig {
 		EnableSyncPoint:       cloned.EnableSyncPoint,
 		EnableTableMonitor:    cloned.EnableTableMonitor,
 		BDRMode:               cloned.BDRMode,
+		Priority:              string(cloned.Priority),
 	}
 
 	if cloned.SyncPointInterval != nil {
@@ -624,6 +746,8 @@ func ToAPIReplicaConfig(c *config.ReplicaConfig) *ReplicaConfig {
 					LargeMessageHandleCompression: oldConfig.LargeMessageHandleCompression,
 					ClaimCheckStorageURI:          oldConfig.ClaimCheckStorageURI,
 					ClaimCheckRawValue:            oldConfig.ClaimCheckRawValue,
+					ClaimCheckFileExpirationDays:  oldConfig.ClaimCheckFileExpirationDays,
+					ClaimCheckFileCleanupCronSpec: oldConfig.ClaimCheckFileCleanupCronSpec,
 				}
 			}
 
@@ -676,6 +800,7 @@ func ToAPIReplicaConfig(c *config.ReplicaConfig) *ReplicaConfig {
 				LargeMessageHandle:           largeMessageHandle,
 				GlueSchemaRegistryConfig:     glueSchemaRegistryConfig,
 				OutputRawChangeEvent:         cloned.Sink.KafkaConfig.OutputRawChangeEvent,
+				TransactionalIDPrefix:        cloned.Sink.KafkaConfig.TransactionalIDPrefix,
 			}
 		}
 		var mysqlConfig *MySQLConfig
@@ -719,6 +844,9 @@ func ToAPIReplicaConfig(c *config.ReplicaConfig) *ReplicaConfig {
 				AuthTLSCertificatePath:  cloned.Sink.PulsarConfig.AuthTLSCertificatePath,
 				AuthTLSPrivateKeyPath:   cloned.Sink.PulsarConfig.AuthTLSPrivateKeyPath,
 				OutputRawChangeEvent:    cloned.Sink.PulsarConfig.OutputRawChangeEvent,
+				EnableOrderingKey:       cloned.Sink.PulsarConfig.EnableOrderingKey,
+				EnableTransaction:       cloned.Sink.PulsarConfig.EnableTransaction,
+				TransactionTimeout:      (*int)(cloned.Sink.PulsarConfig.TransactionTimeout),
 			}
 			if cloned.Sink.PulsarConfig.OAuth2 != nil {
 				pulsarConfig.OAuth2 = &PulsarOAuth2{
@@ -762,6 +890,7 @@ func ToAPIReplicaConfig(c *config.ReplicaConfig) *ReplicaConfig {
 			CSVConfig:                        csvConfig,
 			ColumnSelectors:                  columnSelectors,
 			EncoderConcurrency:               cloned.Sink.EncoderConcurrency,
+			MQSpillThreshold:                 cloned.Sink.MQSpillThreshold,
 			Terminator:                       cloned.Sink.Terminator,
 			DateSeparator:                    cloned.Sink.DateSeparator,
 			EnablePartitionSeparator:         cloned.Sink.EnablePartitionSeparator,
@@ -833,16 +962,19 @@ func ToAPIReplicaConfig(c *config.ReplicaConfig) *ReplicaConfig {
 	}
 	if cloned.Scheduler != nil {
 		res.Scheduler = &ChangefeedSchedulerConfig{
-			EnableTableAcrossNodes: cloned.Scheduler.EnableTableAcrossNodes,
-			RegionThreshold:        cloned.Scheduler.RegionThreshold,
-			WriteKeyThreshold:      cloned.Scheduler.WriteKeyThreshold,
+			EnableTableAcrossNodes:     cloned.Scheduler.EnableTableAcrossNodes,
+			RegionThreshold:            cloned.Scheduler.RegionThreshold,
+			WriteKeyThreshold:          cloned.Scheduler.WriteKeyThreshold,
+			HotTableEventRateThreshold: cloned.Scheduler.HotTableEventRateThreshold,
 		}
 	}
 
 	if cloned.Integrity != nil {
 		res.Integrity = &IntegrityConfig{
-			IntegrityCheckLevel:   cloned.Integrity.IntegrityCheckLevel,
-			CorruptionHandleLevel: cloned.Integrity.CorruptionHandleLevel,
+			IntegrityCheckLevel:         cloned.Integrity.IntegrityCheckLevel,
+			CorruptionHandleLevel:       cloned.Integrity.CorruptionHandleLevel,
+			DownstreamVerifyLevel:       cloned.Integrity.DownstreamVerifyLevel,
+			DownstreamVerifySampleRatio: cloned.Integrity.DownstreamVerifySampleRatio,
 		}
 	}
 	if cloned.ChangefeedErrorStuckDuration != nil {
@@ -854,6 +986,52 @@ func ToAPIReplicaConfig(c *config.ReplicaConfig) *ReplicaConfig {
 			CheckpointInterval:  cloned.SyncedStatus.CheckpointInterval,
 		}
 	}
+	if cloned.RetryPolicy != nil {
+		res.RetryPolicy = &ChangefeedRetryPolicy{
+			MaxRetries:          cloned.RetryPolicy.MaxRetries,
+			RetryableErrorCodes: cloned.RetryPolicy.RetryableErrorCodes,
+			FatalErrorCodes:     cloned.RetryPolicy.FatalErrorCodes,
+			ErrorHistorySize:    cloned.RetryPolicy.ErrorHistorySize,
+		}
+		if cloned.RetryPolicy.BackoffInitInterval != nil {
+			res.RetryPolicy.BackoffInitInterval = &JSONDuration{*cloned.RetryPolicy.BackoffInitInterval}
+		}
+		if cloned.RetryPolicy.BackoffMaxInterval != nil {
+			res.RetryPolicy.BackoffMaxInterval = &JSONDuration{*cloned.RetryPolicy.BackoffMaxInterval}
+		}
+	}
+	if cloned.Alert != nil {
+		res.Alert = &AlertConfig{
+			WebhookURLs:  cloned.Alert.WebhookURLs,
+			NotifyStates: cloned.Alert.NotifyStates,
+			MaxRetries:   cloned.Alert.MaxRetries,
+		}
+		if cloned.Alert.CheckpointLagThreshold != nil {
+			res.Alert.CheckpointLagThreshold = &JSONDuration{*cloned.Alert.CheckpointLagThreshold}
+		}
+		if cloned.Alert.DDLBlockedThreshold != nil {
+			res.Alert.DDLBlockedThreshold = &JSONDuration{*cloned.Alert.DDLBlockedThreshold}
+		}
+		if cloned.Alert.DedupInterval != nil {
+			res.Alert.DedupInterval = &JSONDuration{*cloned.Alert.DedupInterval}
+		}
+	}
+	if cloned.Tracing != nil {
+		res.Tracing = &ChangefeedTracingConfig{
+			SampleRatio: cloned.Tracing.SampleRatio,
+		}
+	}
+	if cloned.Validator != nil {
+		res.Validator = &ValidatorConfig{
+			Enable:      cloned.Validator.Enable,
+			UpstreamURI: cloned.Validator.UpstreamURI,
+			Tables:      cloned.Validator.Tables,
+			ChunkSize:   cloned.Validator.ChunkSize,
+		}
+		if cloned.Validator.Interval != nil {
+			res.Validator.Interval = &JSONDuration{*cloned.Validator.Interval}
+		}
+	}
 	return res
 }
 
@@ -950,6 +1128,7 @@ type SinkConfig struct {
 	ColumnSelectors                  []*ColumnSelector   `json:"column_selectors,omitempty"`
 	TxnAtomicity                     *string             `json:"transaction_atomicity,omitempty"`
 	EncoderConcurrency               *int                `json:"encoder_concurrency,omitempty"`
+	MQSpillThreshold                 *int64              `json:"mq_spill_threshold,omitempty"`
 	Terminator                       *string             `json:"terminator,omitempty"`
 	DateSeparator                    *string             `json:"date_separator,omitempty"`
 	EnablePartitionSeparator         *bool               `json:"enable_partition_separator,omitempty"`
@@ -992,6 +1171,8 @@ type LargeMessageHandleConfig struct {
 	LargeMessageHandleCompression string `json:"large_message_handle_compression"`
 	ClaimCheckStorageURI          string `json:"claim_check_storage_uri"`
 	ClaimCheckRawValue            bool   `json:"claim_check_raw_value"`
+	ClaimCheckFileExpirationDays  int    `json:"claim_check_file_expiration_days,omitempty"`
+	ClaimCheckFileCleanupCronSpec string `json:"claim_check_file_cleanup_cron_spec,omitempty"`
 }
 
 // DispatchRule represents partition rule for a table
@@ -1043,13 +1224,18 @@ type ChangefeedSchedulerConfig struct {
 	RegionThreshold int `toml:"region_threshold" json:"region_threshold"`
 	// WriteKeyThreshold is the written keys threshold of splitting a table.
 	WriteKeyThreshold int `toml:"write_key_threshold" json:"write_key_threshold"`
+	// HotTableEventRateThreshold is the threshold of the events received from
+	// TiKV per second to split a hot table when it's being replicated.
+	HotTableEventRateThreshold int `toml:"hot_table_event_rate_threshold" json:"hot_table_event_rate_threshold"`
 }
 
 // IntegrityConfig is the config for integrity check
 // This is a duplicate of Integrity.Config
 type IntegrityConfig struct {
-	IntegrityCheckLevel   string `json:"integrity_check_level"`
-	CorruptionHandleLevel string `json:"corruption_handle_level"`
+	IntegrityCheckLevel         string  `json:"integrity_check_level"`
+	CorruptionHandleLevel       string  `json:"corruption_handle_level"`
+	DownstreamVerifyLevel       string  `json:"downstream_verify_level,omitempty"`
+	DownstreamVerifySampleRatio float64 `json:"downstream_verify_sample_ratio,omitempty"`
 }
 
 // EtcdData contains key/value pair of etcd data
@@ -1081,6 +1267,7 @@ type ChangeFeedInfo struct {
 	Config         *ReplicaConfig     `json:"config,omitempty"`
 	State          model.FeedState    `json:"state,omitempty"`
 	Error          *RunningError      `json:"error,omitempty"`
+	ErrorHistory   []*RunningError    `json:"error_history,omitempty"`
 	CreatorVersion string             `json:"creator_version,omitempty"`
 
 	ResolvedTs     uint64                    `json:"resolved_ts"`
@@ -1106,6 +1293,26 @@ type RunningError struct {
 	Addr    string     `json:"addr"`
 	Code    string     `json:"code"`
 	Message string     `json:"message"`
+	Table   string     `json:"table,omitempty"`
+}
+
+// toAPIErrorHistory converts the error history of a changefeed to the API model.
+func toAPIErrorHistory(history []*model.RunningError) []*RunningError {
+	if len(history) == 0 {
+		return nil
+	}
+	res := make([]*RunningError, 0, len(history))
+	for _, e := range history {
+		t := e.Time
+		res = append(res, &RunningError{
+			Time:    &t,
+			Addr:    e.Addr,
+			Code:    e.Code,
+			Message: e.Message,
+			Table:   e.Table,
+		})
+	}
+	return res
 }
 
 // toCredential generates a security.Credential from a PDConfig
@@ -1159,6 +1366,51 @@ type ProcessorDetail struct {
 	Tables []int64 `json:"table_ids"`
 }
 
+// ChangefeedLatency holds the latency breakdown of a changefeed,
+// which is collected by the owner from all the processors of the changefeed.
+type ChangefeedLatency struct {
+	Namespace    string                    `json:"namespace"`
+	ChangefeedID string                    `json:"changefeed_id"`
+	CheckpointTs uint64                    `json:"checkpoint_ts"`
+	ResolvedTs   uint64                    `json:"resolved_ts"`
+	Processors   []*model.ProcessorLatency `json:"processors"`
+	// Errors are the errors of collecting the latency from the processors,
+	// keyed by the capture ID.
+	Errors map[string]string `json:"errors,omitempty"`
+}
+
+// ChangefeedHotspots holds the hottest tables and regions of a changefeed,
+// which are collected by the owner from all the processors of the changefeed.
+type ChangefeedHotspots struct {
+	Namespace    string `json:"namespace"`
+	ChangefeedID string `json:"changefeed_id"`
+	// Tables are the table spans with the highest event rates, the hottest first.
+	Tables []model.HotTable `json:"tables"`
+	// Regions are the regions with the highest event rates, the hottest first.
+	Regions []model.HotRegion `json:"regions"`
+	// Errors are the errors of collecting the hotspots from the processors,
+	// keyed by the capture ID.
+	Errors map[string]string `json:"errors,omitempty"`
+}
+
+// ChangefeedCorruptedKeys are the corrupted keys of a changefeed collected
+// from all the captures.
+type ChangefeedCorruptedKeys struct {
+	Namespace    string `json:"namespace"`
+	ChangefeedID string `json:"changefeed_id"`
+	// Keys are the corrupted keys found by all the captures, the oldest first.
+	Keys []CorruptedKey `json:"keys"`
+	// Errors are the errors of collecting the keys from the captures,
+	// keyed by the capture ID.
+	Errors map[string]string `json:"errors,omitempty"`
+}
+
+// CorruptedKey is a corrupted key found by a capture.
+type CorruptedKey struct {
+	CaptureID string `json:"capture_id"`
+	integrity.CorruptedKey
+}
+
 // Liveness is the liveness status of a capture.
 // Liveness can only be changed from alive to stopping, and no way back.
 type Liveness int32
@@ -1213,6 +1465,9 @@ type PulsarConfig struct {
 	AuthTLSPrivateKeyPath   *string       `json:"auth-tls-private-key-path,omitempty"`
 	OAuth2                  *PulsarOAuth2 `json:"oauth2,omitempty"`
 	OutputRawChangeEvent    *bool         `json:"output-raw-change-event,omitempty"`
+	EnableOrderingKey       *bool         `json:"enable-ordering-key,omitempty"`
+	EnableTransaction       *bool         `json:"enable-transaction,omitempty"`
+	TransactionTimeout      *int          `json:"transaction-timeout,omitempty"`
 }
 
 // PulsarOAuth2 is the configuration for OAuth2
@@ -1263,6 +1518,7 @@ type KafkaConfig struct {
 	LargeMessageHandle           *LargeMessageHandleConfig `json:"large_message_handle,omitempty"`
 	GlueSchemaRegistryConfig     *GlueSchemaRegistryConfig `json:"glue_schema_registry_config,omitempty"`
 	OutputRawChangeEvent         *bool                     `json:"output_raw_change_event,omitempty"`
+	TransactionalIDPrefix        *string                   `json:"transactional_id_prefix,omitempty"`
 }
 
 // MySQLConfig represents a MySQL sink configuration
@@ -1298,11 +1554,12 @@ type CloudStorageConfig struct {
 
 // ChangefeedStatus holds common information of a changefeed in cdc
 type ChangefeedStatus struct {
-	State        string        `json:"state,omitempty"`
-	ResolvedTs   uint64        `json:"resolved_ts"`
-	CheckpointTs uint64        `json:"checkpoint_ts"`
-	LastError    *RunningError `json:"last_error,omitempty"`
-	LastWarning  *RunningError `json:"last_warning,omitempty"`
+	State        string          `json:"state,omitempty"`
+	ResolvedTs   uint64          `json:"resolved_ts"`
+	CheckpointTs uint64          `json:"checkpoint_ts"`
+	LastError    *RunningError   `json:"last_error,omitempty"`
+	LastWarning  *RunningError   `json:"last_warning,omitempty"`
+	ErrorHistory []*RunningError `json:"error_history,omitempty"`
 }
 
 // GlueSchemaRegistryConfig represents a glue schema registry configuration
@@ -87,6 +87,8 @@ var defaultAPIConfig = &ReplicaConfig{
 			Scheduler.RegionThreshold,
 		WriteKeyThreshold: config.GetDefaultReplicaConfig().
 			Scheduler.WriteKeyThreshold,
+		HotTableEventRateThreshold: config.GetDefaultReplicaConfig().
+			Scheduler.HotTableEventRateThreshold,
 	},
 	Integrity: &IntegrityConfig{
 		IntegrityCheckLevel:   config.GetDefaultReplicaConfig().Integrity.IntegrityCheckLevel,
@@ -153,6 +155,15 @@ func TestToAPIReplicaConfig(t *testing.T) {
 	cfg.Mounter = &config.MounterConfig{WorkerNum: 11}
 	cfg.Scheduler = &config.ChangefeedSchedulerConfig{
 		EnableTableAcrossNodes: true, RegionThreshold: 10001, WriteKeyThreshold: 10001,
+		HotTableEventRateThreshold: 10001,
+	}
+	cfg.Alert = &config.AlertConfig{
+		WebhookURLs:            []string{"http://127.0.0.1:8080/alert"},
+		CheckpointLagThreshold: util.AddressOf(5 * time.Minute),
+		DDLBlockedThreshold:    util.AddressOf(10 * time.Minute),
+		NotifyStates:           []string{"warning", "failed"},
+		MaxRetries:             3,
+		DedupInterval:          util.AddressOf(time.Minute),
 	}
 	cfg2 := ToAPIReplicaConfig(cfg).ToInternalReplicaConfig()
 	require.Equal(t, "", cfg2.Sink.DispatchRules[0].DispatcherRule)
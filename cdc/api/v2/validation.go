@@ -0,0 +1,58 @@
+// Copyright 2024 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v2
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pingcap/tiflow/cdc/api"
+	"github.com/pingcap/tiflow/cdc/model"
+	"github.com/pingcap/tiflow/cdc/validator"
+	cerror "github.com/pingcap/tiflow/pkg/errors"
+)
+
+// listValidationResults lists the data consistency validation results of a changefeed
+// @Summary List the validation results of a changefeed
+// @Description list the recent results of validating the data consistency between
+// @Description the upstream and the downstream at the syncpoints, the newest first.
+// @Description The results are kept in the memory of the owner.
+// @Tags changefeed,v2
+// @Produce json
+// @Param changefeed_id path string true "changefeed_id"
+// @Param namespace query string false "default"
+// @Success 200 {object} ListResponse[validator.Result]
+// @Failure 400,500 {object} model.HTTPError
+// @Router /api/v2/changefeeds/{changefeed_id}/validation [get]
+func (h *OpenAPIV2) listValidationResults(c *gin.Context) {
+	ctx := c.Request.Context()
+	namespace := getNamespaceValueWithDefault(c)
+	changefeedID := model.ChangeFeedID{Namespace: namespace, ID: c.Param(api.APIOpVarChangefeedID)}
+	if err := model.ValidateChangefeedID(changefeedID.ID); err != nil {
+		_ = c.Error(cerror.ErrAPIInvalidParam.GenWithStack(
+			"invalid changefeed_id: %s", changefeedID.ID))
+		return
+	}
+	// make sure the changefeed exists.
+	if _, err := h.capture.StatusProvider().GetChangeFeedInfo(ctx, changefeedID); err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	results := validator.GetResults(changefeedID)
+	c.JSON(http.StatusOK, &ListResponse[*validator.Result]{
+		Total: len(results),
+		Items: results,
+	})
+}
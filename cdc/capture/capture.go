@@ -29,6 +29,7 @@ import (
 	"github.com/pingcap/tiflow/cdc/model"
 	"github.com/pingcap/tiflow/cdc/owner"
 	"github.com/pingcap/tiflow/cdc/processor"
+	"github.com/pingcap/tiflow/cdc/processor/memquota"
 	"github.com/pingcap/tiflow/cdc/processor/sourcemanager/sorter/factory"
 	"github.com/pingcap/tiflow/cdc/vars"
 	"github.com/pingcap/tiflow/pkg/config"
@@ -70,6 +71,16 @@ type Capture interface {
 	Info() (model.CaptureInfo, error)
 	StatusProvider() owner.StatusProvider
 	WriteDebugInfo(ctx context.Context, w io.Writer)
+	// GetProcessorLatency returns the latency breakdown of the processor
+	// of the changefeed running on this capture.
+	GetProcessorLatency(
+		ctx context.Context, changefeedID model.ChangeFeedID, slowRegionLimit int,
+	) (*model.ProcessorLatency, error)
+	// GetProcessorHotspots returns the hottest tables and regions of the processor
+	// of the changefeed running on this capture.
+	GetProcessorHotspots(
+		ctx context.Context, changefeedID model.ChangeFeedID, limit int,
+	) (*model.ProcessorHotspots, error)
 
 	GetUpstreamManager() (*upstream.Manager, error)
 	GetEtcdClient() etcd.CDCEtcdClient
@@ -286,6 +297,7 @@ func (c *captureImpl) reset(ctx context.Context) (*vars.GlobalVars, error) {
 		MessageServer:        c.MessageServer,
 		MessageRouter:        c.MessageRouter,
 		SortEngineFactory:    c.sortEngineFactory,
+		MemQuotaArbiter:      memquota.NewArbiterFromMemoryPercentage(c.config.MaxMemoryPercentage),
 		ChangefeedThreadPool: c.ChangefeedThreadPool,
 	}
 	c.processorManager = c.newProcessorManager(
@@ -746,6 +758,70 @@ func (c *captureImpl) WriteDebugInfo(ctx context.Context, w io.Writer) {
 	wait(doneM)
 }
 
+// GetProcessorLatency returns the latency breakdown of the processor of the changefeed.
+func (c *captureImpl) GetProcessorLatency(
+	ctx context.Context, changefeedID model.ChangeFeedID, slowRegionLimit int,
+) (*model.ProcessorLatency, error) {
+	query := &processor.LatencyQuery{
+		ChangefeedID:    changefeedID,
+		SlowRegionLimit: slowRegionLimit,
+	}
+	err := c.queryProcessor(ctx, func(m processor.Manager, done chan<- error) {
+		m.QueryLatency(ctx, query, done)
+	})
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	if query.Resp == nil {
+		// The command is dropped because the context is canceled.
+		return nil, errors.Trace(ctx.Err())
+	}
+	return query.Resp, nil
+}
+
+// GetProcessorHotspots returns the hottest tables and regions of the processor of the changefeed.
+func (c *captureImpl) GetProcessorHotspots(
+	ctx context.Context, changefeedID model.ChangeFeedID, limit int,
+) (*model.ProcessorHotspots, error) {
+	query := &processor.HotspotQuery{
+		ChangefeedID: changefeedID,
+		Limit:        limit,
+	}
+	err := c.queryProcessor(ctx, func(m processor.Manager, done chan<- error) {
+		m.QueryHotspots(ctx, query, done)
+	})
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	if query.Resp == nil {
+		// The command is dropped because the context is canceled.
+		return nil, errors.Trace(ctx.Err())
+	}
+	return query.Resp, nil
+}
+
+// queryProcessor sends a query to the processor manager and waits for its completion.
+func (c *captureImpl) queryProcessor(
+	ctx context.Context, send func(m processor.Manager, done chan<- error),
+) error {
+	done := make(chan error, 1)
+	c.captureMu.Lock()
+	if c.processorManager == nil {
+		c.captureMu.Unlock()
+		return cerror.ErrCaptureNotInitialized.GenWithStackByArgs()
+	}
+	send(c.processorManager, done)
+	// Release the lock before waiting, see WriteDebugInfo.
+	c.captureMu.Unlock()
+
+	select {
+	case <-ctx.Done():
+		return errors.Trace(ctx.Err())
+	case err := <-done:
+		return errors.Trace(err)
+	}
+}
+
 // IsOwner returns whether the capture is an owner
 func (c *captureImpl) IsOwner() bool {
 	c.ownerMu.Lock()
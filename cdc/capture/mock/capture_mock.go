@@ -109,6 +109,36 @@ func (mr *MockCaptureMockRecorder) GetOwnerCaptureInfo(ctx interface{}) *gomock.
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOwnerCaptureInfo", reflect.TypeOf((*MockCapture)(nil).GetOwnerCaptureInfo), ctx)
 }
 
+// GetProcessorHotspots mocks base method.
+func (m *MockCapture) GetProcessorHotspots(ctx context.Context, changefeedID model.ChangeFeedID, limit int) (*model.ProcessorHotspots, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetProcessorHotspots", ctx, changefeedID, limit)
+	ret0, _ := ret[0].(*model.ProcessorHotspots)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetProcessorHotspots indicates an expected call of GetProcessorHotspots.
+func (mr *MockCaptureMockRecorder) GetProcessorHotspots(ctx, changefeedID, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetProcessorHotspots", reflect.TypeOf((*MockCapture)(nil).GetProcessorHotspots), ctx, changefeedID, limit)
+}
+
+// GetProcessorLatency mocks base method.
+func (m *MockCapture) GetProcessorLatency(ctx context.Context, changefeedID model.ChangeFeedID, slowRegionLimit int) (*model.ProcessorLatency, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetProcessorLatency", ctx, changefeedID, slowRegionLimit)
+	ret0, _ := ret[0].(*model.ProcessorLatency)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetProcessorLatency indicates an expected call of GetProcessorLatency.
+func (mr *MockCaptureMockRecorder) GetProcessorLatency(ctx, changefeedID, slowRegionLimit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetProcessorLatency", reflect.TypeOf((*MockCapture)(nil).GetProcessorLatency), ctx, changefeedID, slowRegionLimit)
+}
+
 // GetUpstreamInfo mocks base method.
 func (m *MockCapture) GetUpstreamInfo(arg0 context.Context, arg1 model.UpstreamID, arg2 string) (*model.UpstreamInfo, error) {
 	m.ctrl.T.Helper()
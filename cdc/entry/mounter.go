@@ -21,7 +21,6 @@ import (
 	"fmt"
 	"math"
 	"reflect"
-	"sort"
 	"time"
 	"unsafe"
 
@@ -39,6 +38,7 @@ import (
 	pfilter "github.com/pingcap/tiflow/pkg/filter"
 	"github.com/pingcap/tiflow/pkg/integrity"
 	"github.com/pingcap/tiflow/pkg/spanz"
+	"github.com/pingcap/tiflow/pkg/tracing"
 	"github.com/prometheus/client_golang/prometheus"
 	"go.uber.org/zap"
 )
@@ -131,6 +131,11 @@ func (m *mounter) DecodeEvent(ctx context.Context, event *model.PolymorphicEvent
 	if event.IsResolved() {
 		return nil
 	}
+	if event.SpanContext.IsSampled() {
+		span := tracing.StartSpan(event.SpanContext, tracing.SpanMounterDecode,
+			tracing.TxnAttributes(m.changefeedID, event.StartTs, event.CRTs)...)
+		defer span.End()
+	}
 	row, err := m.unmarshalAndMountRowChanged(ctx, event.RawKV)
 	if err != nil {
 		return errors.Trace(err)
@@ -449,33 +454,6 @@ func datum2Column(
 	return cols, rawCols, columnInfos, nil
 }
 
-func calculateColumnChecksum(
-	columnInfos []*timodel.ColumnInfo, rawColumns []types.Datum, tz *time.Location,
-) (uint32, error) {
-	columns := make([]rowcodec.ColData, 0, len(rawColumns))
-	for idx, col := range columnInfos {
-		column := rowcodec.ColData{
-			ColumnInfo: col,
-			Datum:      &rawColumns[idx],
-		}
-		columns = append(columns, column)
-	}
-	sort.Slice(columns, func(i, j int) bool {
-		return columns[i].ID < columns[j].ID
-	})
-
-	calculator := rowcodec.RowData{
-		Cols: columns,
-		Data: make([]byte, 0),
-	}
-
-	checksum, err := calculator.Checksum(tz)
-	if err != nil {
-		return 0, errors.Trace(err)
-	}
-	return checksum, nil
-}
-
 func (m *mounter) verifyColumnChecksum(
 	columnInfos []*timodel.ColumnInfo, rawColumns []types.Datum,
 	decoder *rowcodec.DatumMapDecoder, skipFail bool,
@@ -487,7 +465,7 @@ func (m *mounter) verifyColumnChecksum(
 		return 0, true, nil
 	}
 
-	checksum, err := calculateColumnChecksum(columnInfos, rawColumns, m.tz)
+	checksum, err := integrity.CalculateColumnChecksum(columnInfos, rawColumns, m.tz)
 	if err != nil {
 		log.Error("failed to calculate the checksum",
 			zap.Uint32("first", first), zap.Any("columnInfos", columnInfos),
@@ -681,7 +659,7 @@ func (m *mounter) verifyChecksum(
 		if !matched {
 			return expected, matched, err
 		}
-		columnChecksum, err := calculateColumnChecksum(columnInfos, rawColumns, m.tz)
+		columnChecksum, err := integrity.CalculateColumnChecksum(columnInfos, rawColumns, m.tz)
 		if err != nil {
 			log.Error("failed to calculate column-level checksum, after raw checksum verification passed",
 				zap.Any("columnsInfo", columnInfos), zap.Any("rawColumns", rawColumns),
@@ -156,13 +156,7 @@ func NewSnapshotFromMeta(
 			log.Debug("ignore database", zap.Stringer("db", dbinfo.Name), zap.Stringer("changefeed", id))
 			continue
 		}
-		vid := newVersionedID(dbinfo.ID, tag)
-		vid.target = dbinfo
-		snap.inner.schemas.ReplaceOrInsert(vid)
-
-		vname := newVersionedEntityName(-1, dbinfo.Name.O, tag) // -1 means the entity is a schema.
-		vname.target = dbinfo.ID
-		snap.inner.schemaNameToID.ReplaceOrInsert(vname)
+		snap.inner.loadSchema(dbinfo, tag)
 
 		rawTables, err := meta.GetMetasByDBID(dbinfo.ID)
 		if err != nil {
@@ -194,37 +188,8 @@ func NewSnapshotFromMeta(
 			}
 			tableInfos = append(tableInfos, tbInfo)
 		}
-
-		for _, tableInfo := range tableInfos {
-			tableInfo := model.WrapTableInfo(dbinfo.ID, dbinfo.Name.O, currentTs, tableInfo)
-			tableCount++
-			snap.inner.tables.ReplaceOrInsert(versionedID{
-				id:     tableInfo.ID,
-				tag:    tag,
-				target: tableInfo,
-			})
-			snap.inner.tableNameToID.ReplaceOrInsert(versionedEntityName{
-				prefix: dbinfo.ID,
-				entity: tableInfo.Name.O,
-				tag:    tag,
-				target: tableInfo.ID,
-			})
-
-			eligible := tableInfo.IsEligible(forceReplicate)
-			if !eligible {
-				snap.inner.ineligibleTables.ReplaceOrInsert(versionedID{id: tableInfo.ID, tag: tag})
-			}
-			if pi := tableInfo.GetPartitionInfo(); pi != nil {
-				for _, partition := range pi.Definitions {
-					vid := newVersionedID(partition.ID, tag)
-					vid.target = tableInfo
-					snap.inner.partitions.ReplaceOrInsert(vid)
-					if !eligible {
-						snap.inner.ineligibleTables.ReplaceOrInsert(versionedID{id: partition.ID, tag: tag})
-					}
-				}
-			}
-		}
+		snap.inner.loadTables(dbinfo, tableInfos, currentTs)
+		tableCount += len(tableInfos)
 	}
 
 	snap.inner.currentTs = currentTs
@@ -236,6 +201,24 @@ func NewSnapshotFromMeta(
 	return snap, nil
 }
 
+// NewSnapshotFromInfos creates a schema snapshot from the given schemas and tables.
+// tableInfos are grouped by the IDs of their schemas.
+func NewSnapshotFromInfos(
+	dbInfos []*timodel.DBInfo,
+	tableInfos map[int64][]*timodel.TableInfo,
+	currentTs uint64,
+	forceReplicate bool,
+) *Snapshot {
+	snap := NewEmptySnapshot(forceReplicate)
+	tag := negative(currentTs)
+	for _, dbInfo := range dbInfos {
+		snap.inner.loadSchema(dbInfo, tag)
+		snap.inner.loadTables(dbInfo, tableInfos[dbInfo.ID], currentTs)
+	}
+	snap.inner.currentTs = currentTs
+	return snap
+}
+
 // NewEmptySnapshot creates an empty schema snapshot.
 func NewEmptySnapshot(forceReplicate bool) *Snapshot {
 	inner := snapshot{
@@ -1116,6 +1099,51 @@ func (s *snapshot) renameTables(job *timodel.Job, currentTs uint64) error {
 	return nil
 }
 
+// loadSchema puts the schema into the snapshot with the given tag.
+func (s *snapshot) loadSchema(dbInfo *timodel.DBInfo, tag uint64) {
+	vid := newVersionedID(dbInfo.ID, tag)
+	vid.target = dbInfo
+	s.schemas.ReplaceOrInsert(vid)
+
+	vname := newVersionedEntityName(-1, dbInfo.Name.O, tag) // -1 means the entity is a schema.
+	vname.target = dbInfo.ID
+	s.schemaNameToID.ReplaceOrInsert(vname)
+}
+
+// loadTables puts the tables of the schema into the snapshot with the tag of currentTs.
+func (s *snapshot) loadTables(dbInfo *timodel.DBInfo, tableInfos []*timodel.TableInfo, currentTs uint64) {
+	tag := negative(currentTs)
+	for _, tableInfo := range tableInfos {
+		tableInfo := model.WrapTableInfo(dbInfo.ID, dbInfo.Name.O, currentTs, tableInfo)
+		s.tables.ReplaceOrInsert(versionedID{
+			id:     tableInfo.ID,
+			tag:    tag,
+			target: tableInfo,
+		})
+		s.tableNameToID.ReplaceOrInsert(versionedEntityName{
+			prefix: dbInfo.ID,
+			entity: tableInfo.Name.O,
+			tag:    tag,
+			target: tableInfo.ID,
+		})
+
+		eligible := tableInfo.IsEligible(s.forceReplicate)
+		if !eligible {
+			s.ineligibleTables.ReplaceOrInsert(versionedID{id: tableInfo.ID, tag: tag})
+		}
+		if pi := tableInfo.GetPartitionInfo(); pi != nil {
+			for _, partition := range pi.Definitions {
+				vid := newVersionedID(partition.ID, tag)
+				vid.target = tableInfo
+				s.partitions.ReplaceOrInsert(vid)
+				if !eligible {
+					s.ineligibleTables.ReplaceOrInsert(versionedID{id: partition.ID, tag: tag})
+				}
+			}
+		}
+	}
+}
+
 func (s *snapshot) iterTables(includeIneligible bool, f func(i *model.TableInfo)) {
 	tag := negative(s.currentTs)
 	var tableID int64 = -1
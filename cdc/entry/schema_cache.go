@@ -0,0 +1,574 @@
+// Copyright 2024 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package entry
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/pingcap/errors"
+	"github.com/pingcap/log"
+	tidbkv "github.com/pingcap/tidb/pkg/kv"
+	timodel "github.com/pingcap/tidb/pkg/meta/model"
+	"github.com/pingcap/tiflow/cdc/entry/schema"
+	"github.com/pingcap/tiflow/cdc/model"
+	"github.com/pingcap/tiflow/pkg/config"
+	"github.com/pingcap/tiflow/pkg/util"
+	"go.uber.org/zap"
+)
+
+const (
+	// schemaCacheFormatVersion is the version of the persisted format. Caches
+	// with other versions are ignored.
+	schemaCacheFormatVersion = 1
+
+	schemaCacheSnapshotFile   = "snapshot.json"
+	schemaCacheJobsFile       = "jobs.log"
+	schemaCacheResolvedTsFile = "resolved-ts"
+
+	// schemaCacheSyncInterval is the min interval to persist the resolved ts.
+	schemaCacheSyncInterval = time.Second
+	// maxSchemaCacheJobs is the max number of DDL jobs persisted before the GC ts.
+	// The snapshot is rewritten at the GC ts if there are more jobs.
+	maxSchemaCacheJobs = 256
+)
+
+// schemaCache persists the schema snapshots of a changefeed in the data dir
+// of the capture.
+//
+// It consists of a snapshot at baseTs, the DDL jobs handled by the schema
+// storage after baseTs, and the resolved ts to which all the DDL jobs are
+// persisted. The snapshot at any ts between baseTs and the resolved ts can be
+// restored by replaying the DDL jobs on the persisted snapshot, which is much
+// faster than loading all the table infos from TiKV meta.
+//
+// Errors of the cache never fail the changefeed. The cache is disabled and
+// removed instead, and the schema storage is built from TiKV meta next time.
+type schemaCache struct {
+	dir         string
+	fingerprint string
+	id          model.ChangeFeedID
+	role        util.Role
+
+	mu                sync.Mutex
+	disabled          bool
+	baseTs            uint64
+	baseSchemaVersion int64
+	jobs              []cachedJob
+	resolvedTs        uint64
+	lastSyncTime      time.Time
+}
+
+type cachedJob struct {
+	finishedTs    uint64
+	schemaVersion int64
+	raw           []byte
+}
+
+// persistedSnapshot is the persisted form of a schema snapshot.
+type persistedSnapshot struct {
+	FormatVersion int                  `json:"format-version"`
+	Fingerprint   string               `json:"fingerprint"`
+	CurrentTs     uint64               `json:"current-ts"`
+	SchemaVersion int64                `json:"schema-version"`
+	Schemas       []*timodel.DBInfo    `json:"schemas"`
+	Tables        []*timodel.TableInfo `json:"tables"`
+	// TableSchemaIDs are the schema IDs of Tables.
+	TableSchemaIDs []int64 `json:"table-schema-ids"`
+}
+
+// schemaCacheDir returns the directory of the schema cache of the changefeed.
+// Schema storages of the owner and the processor are cached separately.
+func schemaCacheDir(dataDir string, id model.ChangeFeedID, role util.Role) string {
+	return filepath.Join(dataDir, config.DefaultSchemaCacheDir, id.Namespace, id.ID, role.String())
+}
+
+// RemoveSchemaCache removes the schema caches of the changefeed in the data dir,
+// it's called after the changefeed is removed.
+func RemoveSchemaCache(dataDir string, id model.ChangeFeedID) {
+	dir := filepath.Join(dataDir, config.DefaultSchemaCacheDir, id.Namespace, id.ID)
+	if err := os.RemoveAll(dir); err != nil {
+		log.Warn("remove schema cache failed",
+			zap.String("namespace", id.Namespace),
+			zap.String("changefeed", id.ID),
+			zap.String("dir", dir),
+			zap.Error(err))
+		return
+	}
+	log.Info("schema cache removed",
+		zap.String("namespace", id.Namespace),
+		zap.String("changefeed", id.ID))
+}
+
+// RetainSchemaCaches removes the schema caches of the changefeeds which keep
+// returns false for, it's used to remove the caches of the changefeeds removed
+// while the capture is offline.
+func RetainSchemaCaches(dataDir string, keep func(id model.ChangeFeedID) bool) {
+	root := filepath.Join(dataDir, config.DefaultSchemaCacheDir)
+	namespaces, err := os.ReadDir(root)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			log.Warn("list schema caches failed", zap.String("dir", root), zap.Error(err))
+		}
+		return
+	}
+	for _, namespace := range namespaces {
+		if !namespace.IsDir() {
+			continue
+		}
+		changefeeds, err := os.ReadDir(filepath.Join(root, namespace.Name()))
+		if err != nil {
+			log.Warn("list schema caches failed",
+				zap.String("dir", filepath.Join(root, namespace.Name())), zap.Error(err))
+			continue
+		}
+		for _, changefeed := range changefeeds {
+			id := model.ChangeFeedID{Namespace: namespace.Name(), ID: changefeed.Name()}
+			if !keep(id) {
+				RemoveSchemaCache(dataDir, id)
+			}
+		}
+	}
+}
+
+// schemaCacheFingerprint returns the fingerprint of everything affecting the
+// content of schema snapshots, a cache is valid only if its fingerprint matches.
+func schemaCacheFingerprint(storage tidbkv.Storage, cfg *config.ReplicaConfig) (string, error) {
+	data, err := json.Marshal(struct {
+		Upstream       string               `json:"upstream"`
+		Filter         *config.FilterConfig `json:"filter"`
+		CaseSensitive  bool                 `json:"case-sensitive"`
+		ForceReplicate bool                 `json:"force-replicate"`
+	}{
+		Upstream:       storage.UUID(),
+		Filter:         cfg.Filter,
+		CaseSensitive:  cfg.CaseSensitive,
+		ForceReplicate: cfg.ForceReplicate,
+	})
+	if err != nil {
+		return "", errors.Trace(err)
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+func newSchemaCache(
+	dir string, fingerprint string, id model.ChangeFeedID, role util.Role,
+) *schemaCache {
+	return &schemaCache{dir: dir, fingerprint: fingerprint, id: id, role: role}
+}
+
+// load restores the snapshot at startTs and the schema version of it.
+// It returns false if the cache can't be used.
+func (c *schemaCache) load(
+	startTs uint64, forceReplicate bool,
+) (*schema.Snapshot, int64, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	start := time.Now()
+	snap, version, err := c.doLoad(startTs, forceReplicate)
+	if err != nil {
+		log.Warn("load schema cache failed",
+			zap.String("namespace", c.id.Namespace),
+			zap.String("changefeed", c.id.ID),
+			zap.String("role", c.role.String()),
+			zap.String("dir", c.dir),
+			zap.Error(err))
+		return nil, 0, false
+	}
+	if snap == nil {
+		log.Info("schema cache can't be used",
+			zap.String("namespace", c.id.Namespace),
+			zap.String("changefeed", c.id.ID),
+			zap.String("role", c.role.String()),
+			zap.Uint64("startTs", startTs))
+		return nil, 0, false
+	}
+	log.Info("schema snapshot restored from the cache",
+		zap.String("namespace", c.id.Namespace),
+		zap.String("changefeed", c.id.ID),
+		zap.String("role", c.role.String()),
+		zap.Uint64("startTs", startTs),
+		zap.Uint64("baseTs", c.baseTs),
+		zap.Int("jobs", len(c.jobs)),
+		zap.Duration("duration", time.Since(start)))
+	return snap, version, true
+}
+
+// doLoad returns a nil snapshot if the cache doesn't exist or it's not valid at startTs.
+func (c *schemaCache) doLoad(
+	startTs uint64, forceReplicate bool,
+) (*schema.Snapshot, int64, error) {
+	resolvedTs, err := c.readResolvedTs()
+	if err != nil || resolvedTs < startTs {
+		return nil, 0, err
+	}
+	base, err := c.readSnapshot()
+	if err != nil || base == nil {
+		return nil, 0, err
+	}
+	if base.FormatVersion != schemaCacheFormatVersion ||
+		base.Fingerprint != c.fingerprint || base.CurrentTs > startTs {
+		return nil, 0, nil
+	}
+	jobs, err := c.readJobs()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	snap := schema.NewSnapshotFromInfos(base.Schemas, groupTablesBySchema(base), base.CurrentTs, forceReplicate)
+	version := base.SchemaVersion
+	kept := jobs[:0]
+	for _, j := range jobs {
+		if j.finishedTs <= base.CurrentTs {
+			// The snapshot is rewritten but the jobs are not.
+			continue
+		}
+		if j.finishedTs > startTs {
+			// The DDL puller will send jobs after startTs again.
+			break
+		}
+		job := &timodel.Job{}
+		if err := json.Unmarshal(j.raw, job); err != nil {
+			return nil, 0, errors.Trace(err)
+		}
+		if err := snap.DoHandleDDL(job); err != nil {
+			return nil, 0, errors.Trace(err)
+		}
+		version = j.schemaVersion
+		kept = append(kept, j)
+	}
+	// Flatten the snapshot so it's the same as the one loaded from TiKV meta.
+	dbInfos, tableInfos := snapshotInfos(snap)
+	snap = schema.NewSnapshotFromInfos(dbInfos, tableInfos, startTs, forceReplicate)
+
+	c.baseTs = base.CurrentTs
+	c.baseSchemaVersion = base.SchemaVersion
+	c.jobs = kept
+	if err := c.writeJobs(); err != nil {
+		return nil, 0, err
+	}
+	c.resolvedTs = startTs
+	if err := c.writeResolvedTs(); err != nil {
+		return nil, 0, err
+	}
+	return snap, version, nil
+}
+
+// reset replaces the cache with the given snapshot.
+func (c *schemaCache) reset(snap *schema.Snapshot, schemaVersion int64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	start := time.Now()
+	c.baseTs = snap.CurrentTs()
+	c.baseSchemaVersion = schemaVersion
+	c.jobs = nil
+	c.resolvedTs = snap.CurrentTs()
+	err := os.MkdirAll(c.dir, 0o755)
+	if err == nil {
+		// Remove the resolved ts first so the cache is never valid with
+		// a mismatched snapshot and jobs.
+		err = removeIfExists(filepath.Join(c.dir, schemaCacheResolvedTsFile))
+	}
+	if err == nil {
+		err = c.writeSnapshot(snap, schemaVersion)
+	}
+	if err == nil {
+		err = c.writeJobs()
+	}
+	if err == nil {
+		err = c.writeResolvedTs()
+	}
+	if err != nil {
+		c.disable(err)
+		return
+	}
+	log.Info("schema snapshot persisted",
+		zap.String("namespace", c.id.Namespace),
+		zap.String("changefeed", c.id.ID),
+		zap.String("role", c.role.String()),
+		zap.Uint64("ts", c.baseTs),
+		zap.Duration("duration", time.Since(start)))
+}
+
+// appendJob persists the DDL job handled by the schema storage.
+func (c *schemaCache) appendJob(job *timodel.Job) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.disabled {
+		return
+	}
+
+	raw, err := json.Marshal(job)
+	if err != nil {
+		c.disable(errors.Trace(err))
+		return
+	}
+	f, err := os.OpenFile(filepath.Join(c.dir, schemaCacheJobsFile),
+		os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o644)
+	if err != nil {
+		c.disable(errors.Trace(err))
+		return
+	}
+	_, err = f.Write(append(raw, '\n'))
+	if err == nil {
+		// The job must be persisted before the resolved ts covering it.
+		err = f.Sync()
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		c.disable(errors.Trace(err))
+		return
+	}
+	c.jobs = append(c.jobs, cachedJob{
+		finishedTs:    job.BinlogInfo.FinishedTS,
+		schemaVersion: job.BinlogInfo.SchemaVersion,
+		raw:           raw,
+	})
+}
+
+// advanceResolvedTs persists the resolved ts of the schema storage.
+// It's throttled by schemaCacheSyncInterval.
+func (c *schemaCache) advanceResolvedTs(ts uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.disabled || ts <= c.resolvedTs || time.Since(c.lastSyncTime) < schemaCacheSyncInterval {
+		return
+	}
+	c.resolvedTs = ts
+	if err := c.writeResolvedTs(); err != nil {
+		c.disable(err)
+	}
+}
+
+// compact rewrites the snapshot of the cache with the given snapshot if there
+// are too many DDL jobs before it.
+func (c *schemaCache) compact(snap *schema.Snapshot) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.disabled {
+		return
+	}
+
+	ts := snap.CurrentTs()
+	n := 0
+	version := c.baseSchemaVersion
+	for ; n < len(c.jobs) && c.jobs[n].finishedTs <= ts; n++ {
+		version = c.jobs[n].schemaVersion
+	}
+	if n < maxSchemaCacheJobs {
+		return
+	}
+
+	start := time.Now()
+	// Jobs before the new snapshot are skipped if the jobs are not rewritten.
+	if err := c.writeSnapshot(snap, version); err != nil {
+		c.disable(err)
+		return
+	}
+	c.baseTs = ts
+	c.baseSchemaVersion = version
+	c.jobs = append([]cachedJob(nil), c.jobs[n:]...)
+	if err := c.writeJobs(); err != nil {
+		c.disable(err)
+		return
+	}
+	log.Info("schema cache compacted",
+		zap.String("namespace", c.id.Namespace),
+		zap.String("changefeed", c.id.ID),
+		zap.String("role", c.role.String()),
+		zap.Uint64("ts", ts),
+		zap.Int("compactedJobs", n),
+		zap.Duration("duration", time.Since(start)))
+}
+
+// disable disables and removes the cache after an error. It must be called with c.mu held.
+func (c *schemaCache) disable(err error) {
+	log.Warn("schema cache is disabled",
+		zap.String("namespace", c.id.Namespace),
+		zap.String("changefeed", c.id.ID),
+		zap.String("role", c.role.String()),
+		zap.String("dir", c.dir),
+		zap.Error(err))
+	c.disabled = true
+	c.jobs = nil
+	if err := os.RemoveAll(c.dir); err != nil {
+		log.Warn("remove schema cache failed",
+			zap.String("namespace", c.id.Namespace),
+			zap.String("changefeed", c.id.ID),
+			zap.String("dir", c.dir),
+			zap.Error(err))
+	}
+}
+
+func (c *schemaCache) readResolvedTs() (uint64, error) {
+	data, err := os.ReadFile(filepath.Join(c.dir, schemaCacheResolvedTsFile))
+	if os.IsNotExist(err) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, errors.Trace(err)
+	}
+	ts, err := strconv.ParseUint(string(bytes.TrimSpace(data)), 10, 64)
+	return ts, errors.Trace(err)
+}
+
+func (c *schemaCache) writeResolvedTs() error {
+	c.lastSyncTime = time.Now()
+	return writeFileAtomic(filepath.Join(c.dir, schemaCacheResolvedTsFile),
+		[]byte(strconv.FormatUint(c.resolvedTs, 10)))
+}
+
+func (c *schemaCache) readSnapshot() (*persistedSnapshot, error) {
+	data, err := os.ReadFile(filepath.Join(c.dir, schemaCacheSnapshotFile))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	p := &persistedSnapshot{}
+	if err := json.Unmarshal(data, p); err != nil {
+		return nil, errors.Trace(err)
+	}
+	if len(p.Tables) != len(p.TableSchemaIDs) {
+		return nil, errors.Errorf("mismatched tables %d and schema IDs %d",
+			len(p.Tables), len(p.TableSchemaIDs))
+	}
+	return p, nil
+}
+
+func (c *schemaCache) writeSnapshot(snap *schema.Snapshot, schemaVersion int64) error {
+	p := &persistedSnapshot{
+		FormatVersion: schemaCacheFormatVersion,
+		Fingerprint:   c.fingerprint,
+		CurrentTs:     snap.CurrentTs(),
+		SchemaVersion: schemaVersion,
+	}
+	snap.IterSchemas(func(dbInfo *timodel.DBInfo) {
+		p.Schemas = append(p.Schemas, dbInfo)
+	})
+	snap.IterTables(true, func(tableInfo *model.TableInfo) {
+		p.Tables = append(p.Tables, tableInfo.TableInfo)
+		p.TableSchemaIDs = append(p.TableSchemaIDs, tableInfo.SchemaID)
+	})
+	data, err := json.Marshal(p)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	return writeFileAtomic(filepath.Join(c.dir, schemaCacheSnapshotFile), data)
+}
+
+// readJobs reads the persisted DDL jobs, which are sorted by their finished ts.
+func (c *schemaCache) readJobs() ([]cachedJob, error) {
+	data, err := os.ReadFile(filepath.Join(c.dir, schemaCacheJobsFile))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	var jobs []cachedJob
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(nil, len(data)+1)
+	for scanner.Scan() {
+		raw := append([]byte(nil), scanner.Bytes()...)
+		job := &timodel.Job{}
+		// A broken job can only be left by a crash of the capture,
+		// the cache is rebuilt in this case.
+		if err := json.Unmarshal(raw, job); err != nil {
+			return nil, errors.Trace(err)
+		}
+		if job.BinlogInfo == nil {
+			return nil, errors.Errorf("missing binlog info of DDL job %d", job.ID)
+		}
+		jobs = append(jobs, cachedJob{
+			finishedTs:    job.BinlogInfo.FinishedTS,
+			schemaVersion: job.BinlogInfo.SchemaVersion,
+			raw:           raw,
+		})
+	}
+	return jobs, errors.Trace(scanner.Err())
+}
+
+func (c *schemaCache) writeJobs() error {
+	var buf bytes.Buffer
+	for _, j := range c.jobs {
+		buf.Write(j.raw)
+		buf.WriteByte('\n')
+	}
+	return writeFileAtomic(filepath.Join(c.dir, schemaCacheJobsFile), buf.Bytes())
+}
+
+// snapshotInfos returns the schemas and the tables grouped by schema IDs in the snapshot.
+func snapshotInfos(snap *schema.Snapshot) ([]*timodel.DBInfo, map[int64][]*timodel.TableInfo) {
+	var dbInfos []*timodel.DBInfo
+	snap.IterSchemas(func(dbInfo *timodel.DBInfo) {
+		dbInfos = append(dbInfos, dbInfo)
+	})
+	tableInfos := make(map[int64][]*timodel.TableInfo, len(dbInfos))
+	snap.IterTables(true, func(tableInfo *model.TableInfo) {
+		tableInfos[tableInfo.SchemaID] = append(tableInfos[tableInfo.SchemaID], tableInfo.TableInfo)
+	})
+	return dbInfos, tableInfos
+}
+
+func groupTablesBySchema(p *persistedSnapshot) map[int64][]*timodel.TableInfo {
+	tableInfos := make(map[int64][]*timodel.TableInfo, len(p.Schemas))
+	for i, tableInfo := range p.Tables {
+		schemaID := p.TableSchemaIDs[i]
+		tableInfos[schemaID] = append(tableInfos[schemaID], tableInfo)
+	}
+	return tableInfos
+}
+
+// writeFileAtomic writes the file by renaming a temporary file, so the file
+// is either the old one or the new one after a crash.
+func writeFileAtomic(path string, data []byte) error {
+	tmp := path + ".tmp"
+	f, err := os.OpenFile(tmp, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o644)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	_, err = f.Write(data)
+	if err == nil {
+		err = f.Sync()
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return errors.Trace(err)
+	}
+	return errors.Trace(os.Rename(tmp, path))
+}
+
+func removeIfExists(path string) error {
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return errors.Trace(err)
+	}
+	return nil
+}
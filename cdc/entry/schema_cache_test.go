@@ -0,0 +1,160 @@
+// Copyright 2024 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package entry
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	timodel "github.com/pingcap/tidb/pkg/meta/model"
+	pmodel "github.com/pingcap/tidb/pkg/parser/model"
+	"github.com/pingcap/tiflow/cdc/entry/schema"
+	"github.com/pingcap/tiflow/cdc/model"
+	"github.com/pingcap/tiflow/pkg/util"
+	"github.com/stretchr/testify/require"
+)
+
+func newCreateTableJob(tableID int64, name string, finishedTs uint64, schemaVersion int64) *timodel.Job {
+	return &timodel.Job{
+		ID:         tableID,
+		Type:       timodel.ActionCreateTable,
+		SchemaID:   1,
+		SchemaName: "test",
+		TableID:    tableID,
+		State:      timodel.JobStateDone,
+		BinlogInfo: &timodel.HistoryInfo{
+			SchemaVersion: schemaVersion,
+			FinishedTS:    finishedTs,
+			TableInfo: &timodel.TableInfo{
+				ID:   tableID,
+				Name: pmodel.NewCIStr(name),
+			},
+		},
+	}
+}
+
+func TestSchemaCache(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	id := model.DefaultChangeFeedID("test-schema-cache")
+	dbInfo := &timodel.DBInfo{ID: 1, Name: pmodel.NewCIStr("test")}
+	base := schema.NewSnapshotFromInfos(
+		[]*timodel.DBInfo{dbInfo},
+		map[int64][]*timodel.TableInfo{1: {{ID: 100, Name: pmodel.NewCIStr("t0")}}},
+		10, false)
+
+	cache := newSchemaCache(dir, "fingerprint", id, util.RoleProcessor)
+	cache.reset(base, 1)
+	cache.appendJob(newCreateTableJob(101, "t1", 20, 2))
+	cache.appendJob(newCreateTableJob(102, "t2", 30, 3))
+	cache.lastSyncTime = time.Time{}
+	cache.advanceResolvedTs(40)
+	require.False(t, cache.disabled)
+
+	// The cache can't be used beyond the resolved ts or with another fingerprint.
+	_, _, ok := newSchemaCache(dir, "fingerprint", id, util.RoleProcessor).load(41, false)
+	require.False(t, ok)
+	_, _, ok = newSchemaCache(dir, "other", id, util.RoleProcessor).load(25, false)
+	require.False(t, ok)
+	_, _, ok = newSchemaCache(dir, "fingerprint", id, util.RoleProcessor).load(5, false)
+	require.False(t, ok)
+
+	cache = newSchemaCache(dir, "fingerprint", id, util.RoleProcessor)
+	snap, version, ok := cache.load(25, false)
+	require.True(t, ok)
+	require.Equal(t, int64(2), version)
+	require.Equal(t, uint64(25), snap.CurrentTs())
+	var tables []string
+	snap.IterTables(true, func(tableInfo *model.TableInfo) {
+		require.Equal(t, uint64(25), tableInfo.Version)
+		tables = append(tables, tableInfo.TableName.String())
+	})
+	require.Equal(t, []string{"test.t0", "test.t1"}, tables)
+
+	// Jobs after the start ts are dropped, they will be sent by the DDL puller again.
+	require.Len(t, cache.jobs, 1)
+	_, _, ok = newSchemaCache(dir, "fingerprint", id, util.RoleProcessor).load(30, false)
+	require.False(t, ok)
+}
+
+func TestSchemaCacheCompact(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	id := model.DefaultChangeFeedID("test-schema-cache-compact")
+	dbInfo := &timodel.DBInfo{ID: 1, Name: pmodel.NewCIStr("test")}
+	snap := schema.NewSnapshotFromInfos([]*timodel.DBInfo{dbInfo}, nil, 10, false)
+
+	cache := newSchemaCache(dir, "fingerprint", id, util.RoleOwner)
+	cache.reset(snap, 1)
+	snaps := []*schema.Snapshot{snap}
+	for i := 0; i < maxSchemaCacheJobs+1; i++ {
+		job := newCreateTableJob(int64(100+i), fmt.Sprintf("t%d", i), uint64(20+i), int64(2+i))
+		snap = snap.Copy()
+		require.NoError(t, snap.DoHandleDDL(job))
+		snaps = append(snaps, snap)
+		cache.appendJob(job)
+	}
+
+	cache.compact(snaps[maxSchemaCacheJobs-1])
+	require.Equal(t, uint64(10), cache.baseTs)
+	cache.compact(snaps[maxSchemaCacheJobs])
+	require.Equal(t, snaps[maxSchemaCacheJobs].CurrentTs(), cache.baseTs)
+	require.Equal(t, int64(maxSchemaCacheJobs+1), cache.baseSchemaVersion)
+	require.Len(t, cache.jobs, 1)
+
+	cache.lastSyncTime = time.Time{}
+	cache.advanceResolvedTs(1000)
+	restored, version, ok := newSchemaCache(dir, "fingerprint", id, util.RoleOwner).load(1000, false)
+	require.True(t, ok)
+	require.Equal(t, int64(maxSchemaCacheJobs+2), version)
+	require.Equal(t, maxSchemaCacheJobs+1, restored.TableCount(true, func(_, _ string) bool { return true }))
+}
+
+func TestRemoveSchemaCache(t *testing.T) {
+	t.Parallel()
+
+	dataDir := t.TempDir()
+	removed := model.DefaultChangeFeedID("removed")
+	kept := model.DefaultChangeFeedID("kept")
+	orphan := model.ChangeFeedID{Namespace: "ns", ID: "orphan"}
+	snap := schema.NewEmptySnapshot(false)
+	for _, id := range []model.ChangeFeedID{removed, kept, orphan} {
+		for _, role := range []util.Role{util.RoleOwner, util.RoleProcessor} {
+			newSchemaCache(schemaCacheDir(dataDir, id, role), "fingerprint", id, role).reset(snap, 1)
+		}
+	}
+	exists := func(id model.ChangeFeedID) bool {
+		_, err := os.Stat(filepath.Join(schemaCacheDir(dataDir, id, util.RoleOwner), schemaCacheSnapshotFile))
+		return err == nil
+	}
+
+	RemoveSchemaCache(dataDir, removed)
+	require.False(t, exists(removed))
+	require.True(t, exists(kept))
+	require.True(t, exists(orphan))
+
+	RetainSchemaCaches(dataDir, func(id model.ChangeFeedID) bool {
+		return id == kept
+	})
+	require.True(t, exists(kept))
+	require.False(t, exists(orphan))
+
+	// nothing is removed if there is no cache.
+	RetainSchemaCaches(t.TempDir(), func(model.ChangeFeedID) bool { return false })
+}
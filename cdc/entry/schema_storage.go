@@ -27,6 +27,7 @@ import (
 	"github.com/pingcap/tiflow/cdc/entry/schema"
 	"github.com/pingcap/tiflow/cdc/kv"
 	"github.com/pingcap/tiflow/cdc/model"
+	"github.com/pingcap/tiflow/pkg/config"
 	"github.com/pingcap/tiflow/pkg/ddl"
 	cerror "github.com/pingcap/tiflow/pkg/errors"
 	"github.com/pingcap/tiflow/pkg/filter"
@@ -83,6 +84,9 @@ type schemaStorage struct {
 
 	id   model.ChangeFeedID
 	role util.Role
+
+	// cache persists the snapshots, it's nil if snapshots are not persisted.
+	cache *schemaCache
 }
 
 // NewSchemaStorage creates a new schema storage
@@ -91,25 +95,75 @@ func NewSchemaStorage(
 	forceReplicate bool, id model.ChangeFeedID,
 	role util.Role, filter filter.Filter,
 ) (SchemaStorage, error) {
-	var (
-		snap    *schema.Snapshot
-		version int64
-		err     error
-	)
+	snap, version, err := newSnapshot(storage, startTs, forceReplicate, id, filter)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	return newSchemaStorage(snap, version, startTs, forceReplicate, id, role, filter), nil
+}
+
+// NewSchemaStorageWithCache creates a new schema storage whose snapshots are
+// persisted in the data dir of the capture. The snapshot at startTs is restored
+// from the persisted one if it's still valid, otherwise it's loaded from TiKV meta.
+func NewSchemaStorageWithCache(
+	storage tidbkv.Storage, startTs uint64,
+	replicaConfig *config.ReplicaConfig, id model.ChangeFeedID,
+	role util.Role, filter filter.Filter,
+) (SchemaStorage, error) {
+	forceReplicate := replicaConfig.ForceReplicate
+	dataDir := config.GetGlobalServerConfig().DataDir
+	// DDLs discarded by event filters are not applied to the snapshots, so the
+	// snapshot restored by replaying the persisted DDLs may be different from
+	// the one loaded from TiKV meta.
+	hasEventFilters := replicaConfig.Filter != nil && len(replicaConfig.Filter.EventFilters) > 0
 	// storage may be nil in some unit test cases.
-	if storage == nil {
-		snap = schema.NewEmptySnapshot(forceReplicate)
-	} else {
-		meta := kv.GetSnapshotMeta(storage, startTs)
-		snap, err = schema.NewSnapshotFromMeta(id, meta, startTs, forceReplicate, filter)
-		if err != nil {
-			return nil, errors.Trace(err)
-		}
-		version, err = schema.GetSchemaVersion(meta)
+	if storage == nil || dataDir == "" || hasEventFilters {
+		return NewSchemaStorage(storage, startTs, forceReplicate, id, role, filter)
+	}
+	fingerprint, err := schemaCacheFingerprint(storage, replicaConfig)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	cache := newSchemaCache(schemaCacheDir(dataDir, id, role), fingerprint, id, role)
+
+	snap, version, ok := cache.load(startTs, forceReplicate)
+	if !ok {
+		snap, version, err = newSnapshot(storage, startTs, forceReplicate, id, filter)
 		if err != nil {
 			return nil, errors.Trace(err)
 		}
+		cache.reset(snap, version)
 	}
+	s := newSchemaStorage(snap, version, startTs, forceReplicate, id, role, filter)
+	s.cache = cache
+	return s, nil
+}
+
+func newSnapshot(
+	storage tidbkv.Storage, startTs uint64,
+	forceReplicate bool, id model.ChangeFeedID, filter filter.Filter,
+) (*schema.Snapshot, int64, error) {
+	// storage may be nil in some unit test cases.
+	if storage == nil {
+		return schema.NewEmptySnapshot(forceReplicate), 0, nil
+	}
+	meta := kv.GetSnapshotMeta(storage, startTs)
+	snap, err := schema.NewSnapshotFromMeta(id, meta, startTs, forceReplicate, filter)
+	if err != nil {
+		return nil, 0, errors.Trace(err)
+	}
+	version, err := schema.GetSchemaVersion(meta)
+	if err != nil {
+		return nil, 0, errors.Trace(err)
+	}
+	return snap, version, nil
+}
+
+func newSchemaStorage(
+	snap *schema.Snapshot, version int64, startTs uint64,
+	forceReplicate bool, id model.ChangeFeedID,
+	role util.Role, filter filter.Filter,
+) *schemaStorage {
 	return &schemaStorage{
 		snaps:          []*schema.Snapshot{snap},
 		resolvedTs:     startTs,
@@ -118,7 +172,7 @@ func NewSchemaStorage(
 		id:             id,
 		schemaVersion:  version,
 		role:           role,
-	}, nil
+	}
 }
 
 // getSnapshot returns the snapshot which currentTs is less than(but most close to)
@@ -235,6 +289,9 @@ func (s *schemaStorage) HandleDDLJob(job *timodel.Job) error {
 	}
 	s.snaps = append(s.snaps, snap)
 	s.schemaVersion = job.BinlogInfo.SchemaVersion
+	if s.cache != nil {
+		s.cache.appendJob(job)
+	}
 	s.AdvanceResolvedTs(job.BinlogInfo.FinishedTS)
 	log.Info("schemaStorage: update snapshot by the DDL job",
 		zap.String("namespace", s.id.Namespace),
@@ -337,6 +394,9 @@ func (s *schemaStorage) IsIneligibleTable(
 func (s *schemaStorage) AdvanceResolvedTs(ts uint64) {
 	if ts > s.ResolvedTs() {
 		atomic.StoreUint64(&s.resolvedTs, ts)
+		if s.cache != nil {
+			s.cache.advanceResolvedTs(ts)
+		}
 	}
 }
 
@@ -377,6 +437,9 @@ func (s *schemaStorage) DoGC(ts uint64) (lastSchemaTs uint64) {
 
 	lastSchemaTs = s.snaps[0].CurrentTs()
 	atomic.StoreUint64(&s.gcTs, lastSchemaTs)
+	if s.cache != nil {
+		s.cache.compact(s.snaps[0])
+	}
 	return
 }
 
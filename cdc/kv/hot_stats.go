@@ -0,0 +1,150 @@
+// Copyright 2024 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kv
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/pingcap/tiflow/cdc/kv/regionlock"
+	"github.com/pingcap/tiflow/cdc/processor/tablepb"
+)
+
+const (
+	// hotStatsInterval is the interval to calculate the throughput
+	// of subscribed spans and regions.
+	hotStatsInterval = 10 * time.Second
+	// maxHotRegions is the number of the hottest regions kept by a client.
+	maxHotRegions = 100
+)
+
+// Throughput is the throughput of a subscribed span or a region
+// in the last statistic interval.
+type Throughput struct {
+	// EventRate is the number of received events per second.
+	EventRate uint64
+	// ByteRate is the bytes of received events per second.
+	ByteRate uint64
+	// LockResolves is the number of lock resolvings in the last interval.
+	LockResolves uint64
+}
+
+func newThroughput(stats *regionlock.EventStats, interval time.Duration) Throughput {
+	events, bytes, lockResolves := stats.Take()
+	seconds := interval.Seconds()
+	if seconds <= 0 {
+		seconds = 1
+	}
+	return Throughput{
+		EventRate:    uint64(float64(events) / seconds),
+		ByteRate:     uint64(float64(bytes) / seconds),
+		LockResolves: lockResolves,
+	}
+}
+
+// HotRegion is a region with a high event rate.
+type HotRegion struct {
+	SubscriptionID SubscriptionID
+	Span           tablepb.Span
+	RegionID       uint64
+	Throughput
+}
+
+// hotRegions keeps the hottest regions found in the last statistic interval.
+type hotRegions struct {
+	sync.RWMutex
+	v []HotRegion
+}
+
+// Throughput returns the throughput of the subscribed span
+// in the last statistic interval.
+func (s *SharedClient) Throughput(subID SubscriptionID) Throughput {
+	s.totalSpans.RLock()
+	defer s.totalSpans.RUnlock()
+	if rt := s.totalSpans.v[subID]; rt != nil {
+		if tp := rt.throughput.Load(); tp != nil {
+			return *tp
+		}
+	}
+	return Throughput{}
+}
+
+// HotRegions returns the hottest regions of all subscribed spans, the hottest first.
+// At most limit regions are returned.
+func (s *SharedClient) HotRegions(limit int) []HotRegion {
+	s.hotRegions.RLock()
+	defer s.hotRegions.RUnlock()
+	if len(s.hotRegions.v) < limit {
+		limit = len(s.hotRegions.v)
+	}
+	res := make([]HotRegion, limit)
+	copy(res, s.hotRegions.v)
+	return res
+}
+
+func (s *SharedClient) calcThroughput(ctx context.Context) error {
+	ticker := time.NewTicker(hotStatsInterval)
+	defer ticker.Stop()
+	lastTime := time.Now()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+		now := time.Now()
+		s.updateThroughput(now.Sub(lastTime))
+		lastTime = now
+	}
+}
+
+func (s *SharedClient) updateThroughput(interval time.Duration) {
+	var regions []HotRegion
+	s.totalSpans.RLock()
+	for subscriptionID, rt := range s.totalSpans.v {
+		var lockResolves uint64
+		rt.rangeLock.IterAll(func(regionID uint64, state *regionlock.LockedRangeState) {
+			tp := newThroughput(&state.Stats, interval)
+			lockResolves += tp.LockResolves
+			if tp.EventRate == 0 && tp.LockResolves == 0 {
+				return
+			}
+			regions = append(regions, HotRegion{
+				SubscriptionID: subscriptionID,
+				Span:           rt.span,
+				RegionID:       regionID,
+				Throughput:     tp,
+			})
+		})
+		tp := newThroughput(&rt.stats, interval)
+		tp.LockResolves = lockResolves
+		rt.throughput.Store(&tp)
+	}
+	s.totalSpans.RUnlock()
+
+	sort.Slice(regions, func(i, j int) bool {
+		if regions[i].EventRate != regions[j].EventRate {
+			return regions[i].EventRate > regions[j].EventRate
+		}
+		return regions[i].ByteRate > regions[j].ByteRate
+	})
+	if len(regions) > maxHotRegions {
+		regions = regions[:maxHotRegions]
+	}
+	s.hotRegions.Lock()
+	s.hotRegions.v = regions
+	s.hotRegions.Unlock()
+}
@@ -165,6 +165,13 @@ var (
 			Name:      "slow_initialize_region_count",
 			Help:      "the number of slow initialize region",
 		}, []string{"namespace", "changefeed"})
+	regionScanGauge = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "ticdc",
+			Subsystem: "kvclient",
+			Name:      "region_scan_count",
+			Help:      "the number of region incremental scans queued or running in each store",
+		}, []string{"store", "state"})
 )
 
 // GetGlobalGrpcMetrics gets the global grpc metrics.
@@ -192,6 +199,7 @@ func InitMetrics(registry *prometheus.Registry) {
 	registry.MustRegister(workerBusyRatio)
 	registry.MustRegister(workerChannelSize)
 	registry.MustRegister(slowInitializeRegion)
+	registry.MustRegister(regionScanGauge)
 
 	// Register client metrics to registry.
 	registry.MustRegister(grpcMetrics)
@@ -17,8 +17,12 @@ import (
 	"sync"
 
 	"github.com/pingcap/tiflow/cdc/kv/regionlock"
+	"github.com/pingcap/tiflow/cdc/model"
 	"github.com/pingcap/tiflow/cdc/processor/tablepb"
+	"github.com/pingcap/tiflow/pkg/tracing"
 	"github.com/tikv/client-go/v2/tikv"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 )
 
 const (
@@ -84,6 +88,8 @@ type regionFeedState struct {
 	region    regionInfo
 	requestID uint64
 	matcher   *matcher
+	// scanSpan traces the initial scan of the region, it's nil if not sampled.
+	scanSpan trace.Span
 
 	// Transform: normal -> stopped -> removed.
 	// normal: the region is in replicating.
@@ -110,6 +116,24 @@ func (s *regionFeedState) start() {
 	s.matcher = newMatcher()
 }
 
+// startScanSpan starts tracing the initial scan of the region if it's sampled.
+func (s *regionFeedState) startScanSpan(changefeed model.ChangeFeedID) {
+	s.scanSpan = tracing.StartRegionScanSpan(
+		changefeed, s.region.span.TableID, s.getRegionID(), s.requestID)
+}
+
+// endScanSpan ends tracing the initial scan of the region, the error is
+// recorded if the region is stopped before initialized.
+func (s *regionFeedState) endScanSpan(err error) {
+	if s.scanSpan == nil {
+		return
+	}
+	if err != nil {
+		s.scanSpan.SetStatus(codes.Error, err.Error())
+	}
+	s.scanSpan.End()
+}
+
 // mark regionFeedState as stopped with the given error if possible.
 func (s *regionFeedState) markStopped(err error) {
 	s.state.Lock()
@@ -117,6 +141,7 @@ func (s *regionFeedState) markStopped(err error) {
 	if s.state.v == stateNormal {
 		s.state.v = stateStopped
 		s.state.err = err
+		s.endScanSpan(err)
 	}
 }
 
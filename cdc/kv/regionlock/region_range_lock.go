@@ -66,6 +66,27 @@ type LockedRangeState struct {
 	ResolvedTs  atomic.Uint64
 	Initialzied atomic.Bool
 	Created     time.Time
+	// Stats is used to find hot regions.
+	Stats EventStats
+}
+
+// EventStats accumulates the statistics of a locked range or a subscribed span.
+// It's reset every time it's taken.
+type EventStats struct {
+	Events       atomic.Uint64
+	Bytes        atomic.Uint64
+	LockResolves atomic.Uint64
+}
+
+// AddEvent adds an event with the given size to the statistics.
+func (s *EventStats) AddEvent(size uint64) {
+	s.Events.Add(1)
+	s.Bytes.Add(size)
+}
+
+// Take returns the accumulated statistics and resets them.
+func (s *EventStats) Take() (events, bytes, lockResolves uint64) {
+	return s.Events.Swap(0), s.Bytes.Swap(0), s.LockResolves.Swap(0)
 }
 
 // rangeLockEntry represents a locked range that defined by [startKey, endKey).
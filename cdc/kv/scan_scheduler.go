@@ -0,0 +1,312 @@
+// Copyright 2024 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kv
+
+import (
+	"container/heap"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/pingcap/errors"
+	"github.com/pingcap/tiflow/cdc/kv/regionlock"
+	"github.com/pingcap/tiflow/pkg/config"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	// maxStoreBusyBackoff is the max duration of pausing incremental scans in a busy store.
+	maxStoreBusyBackoff = 30 * time.Second
+	// scanSchedulerTickInterval is the interval to resume incremental scans
+	// in stores whose back-off is finished.
+	scanSchedulerTickInterval = 100 * time.Millisecond
+)
+
+type scanResult int
+
+const (
+	// scanInitialized means the incremental scan of the region is finished.
+	scanInitialized scanResult = iota
+	// scanFailed means the region fails before its incremental scan is finished.
+	scanFailed
+	// scanStoreBusy means the region fails because the store is busy.
+	scanStoreBusy
+)
+
+// scanClient is a client whose regions are scanned by the scanScheduler.
+type scanClient interface {
+	// sendRegionRequest sends the region to a stream of the store.
+	sendRegionRequest(rs *requestedStore, region regionInfo)
+	// failScan fails the region without sending it to the store.
+	failScan(region regionInfo)
+	// priorityRank returns the rank of the changefeed priority of the client,
+	// regions of the clients with higher ranks are scanned first.
+	priorityRank() int
+}
+
+var (
+	globalScanSchedulerOnce sync.Once
+	globalScanScheduler     *scanScheduler
+)
+
+// getGlobalScanScheduler returns the scan scheduler shared by all the clients in
+// the process, so the limit of a store is shared by all the changefeeds.
+// It's created with the config of the first client, which is the same for all
+// the clients since it's from the server config.
+func getGlobalScanScheduler(cfg *config.KVClientConfig) *scanScheduler {
+	globalScanSchedulerOnce.Do(func() {
+		var limit int
+		var backoff time.Duration
+		if cfg != nil {
+			limit, backoff = cfg.StoreScanConcurrency, time.Duration(cfg.StoreBusyBackoff)
+		}
+		globalScanScheduler = newScanScheduler(limit, backoff)
+		go globalScanScheduler.run()
+	})
+	return globalScanScheduler
+}
+
+// scanScheduler limits the concurrent region incremental scans in each store,
+// the limit is shared by all the clients of the scheduler.
+//
+// Regions waiting for scan slots are queued by priority: regions of the
+// changefeeds with higher priorities are scanned first, so the incremental
+// scans of a backfill changefeed can't delay the latency-sensitive ones.
+// Among the regions of the same priority, regions with larger resolved ts,
+// i.e. the regions of tables closer to catching up with the changefeed, are
+// scanned first. So adding many tables at once can't delay the resolved ts of
+// tables which have already caught up.
+// Incremental scans in a store are paused for a while if the store is busy.
+type scanScheduler struct {
+	limit   int
+	backoff time.Duration
+
+	mu sync.Mutex
+	// stores is keyed by the store address, which is the same for all the clients.
+	stores  map[string]*storeScans
+	running map[*regionlock.LockedRangeState]*pendingScan
+	seq     uint64
+}
+
+// storeScans is the incremental scans of a store.
+type storeScans struct {
+	running int
+	pending pendingScans
+	// Incremental scans are paused until busyUntil.
+	busyUntil time.Time
+	backoff   time.Duration
+
+	metricQueued  prometheus.Gauge
+	metricRunning prometheus.Gauge
+}
+
+type pendingScan struct {
+	client     scanClient
+	rs         *requestedStore
+	region     regionInfo
+	rank       int
+	resolvedTs uint64
+	seq        uint64
+}
+
+// pendingScans is a heap of regions waiting for incremental scans.
+type pendingScans []*pendingScan
+
+func (h pendingScans) Len() int { return len(h) }
+
+func (h pendingScans) Less(i, j int) bool {
+	if h[i].rank != h[j].rank {
+		return h[i].rank > h[j].rank
+	}
+	if h[i].resolvedTs != h[j].resolvedTs {
+		return h[i].resolvedTs > h[j].resolvedTs
+	}
+	return h[i].seq < h[j].seq
+}
+
+func (h pendingScans) Swap(i, j int) { h[i], h[j] = h[j], h[i] }
+
+func (h *pendingScans) Push(x any) { *h = append(*h, x.(*pendingScan)) }
+
+func (h *pendingScans) Pop() any {
+	old := *h
+	n := len(old)
+	x := old[n-1]
+	old[n-1] = nil
+	*h = old[:n-1]
+	return x
+}
+
+func newScanScheduler(limit int, backoff time.Duration) *scanScheduler {
+	if limit <= 0 {
+		limit = math.MaxInt
+	}
+	if backoff <= 0 {
+		backoff = time.Second
+	}
+	return &scanScheduler{
+		limit:   limit,
+		backoff: backoff,
+		stores:  make(map[string]*storeScans),
+		running: make(map[*regionlock.LockedRangeState]*pendingScan),
+	}
+}
+
+// add queues the region of the client to scan it in the store.
+// The regions are sent or failed with the lock held, which is fine since
+// the clients never block on them.
+func (s *scanScheduler) add(client scanClient, rs *requestedStore, region regionInfo) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	store := s.getStore(rs.storeAddr)
+	s.seq++
+	heap.Push(&store.pending, &pendingScan{
+		client:     client,
+		rs:         rs,
+		region:     region,
+		rank:       client.priorityRank(),
+		resolvedTs: region.resolvedTs(),
+		seq:        s.seq,
+	})
+	s.dispatch(store, time.Now())
+}
+
+// finish releases the scan slot of the region. If the store is busy,
+// incremental scans in the store are paused for a while.
+func (s *scanScheduler) finish(state *regionlock.LockedRangeState, result scanResult) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	scan, ok := s.running[state]
+	if !ok {
+		return
+	}
+	delete(s.running, state)
+	store := s.getStore(scan.rs.storeAddr)
+	store.running--
+	now := time.Now()
+	switch result {
+	case scanInitialized:
+		store.backoff = 0
+	case scanStoreBusy:
+		if store.backoff == 0 {
+			store.backoff = s.backoff
+		} else if store.backoff < maxStoreBusyBackoff {
+			store.backoff *= 2
+			if store.backoff > maxStoreBusyBackoff {
+				store.backoff = maxStoreBusyBackoff
+			}
+		}
+		store.busyUntil = now.Add(store.backoff)
+	}
+	s.dispatch(store, now)
+}
+
+// remove drops all the regions of the client, it must be called before the
+// client is closed so no more regions are sent to it.
+func (s *scanScheduler) remove(client scanClient) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for state, scan := range s.running {
+		if scan.client == client {
+			delete(s.running, state)
+			s.getStore(scan.rs.storeAddr).running--
+		}
+	}
+	now := time.Now()
+	for _, store := range s.stores {
+		pending := store.pending[:0]
+		for _, scan := range store.pending {
+			if scan.client != client {
+				pending = append(pending, scan)
+			}
+		}
+		for i := len(pending); i < len(store.pending); i++ {
+			store.pending[i] = nil
+		}
+		store.pending = pending
+		heap.Init(&store.pending)
+		s.dispatch(store, now)
+	}
+}
+
+// run resumes the incremental scans periodically. It's started once with the
+// global scheduler and runs for the lifetime of the process, since the
+// scheduler is shared by all the clients.
+func (s *scanScheduler) run() {
+	ticker := time.NewTicker(scanSchedulerTickInterval)
+	defer ticker.Stop()
+	for range ticker.C {
+		s.resume()
+	}
+}
+
+// resume dispatches the pending regions of the stores whose back-off is finished.
+// Regions initialized but not released yet are also released.
+func (s *scanScheduler) resume() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for state, scan := range s.running {
+		if state.Initialzied.Load() {
+			delete(s.running, state)
+			s.getStore(scan.rs.storeAddr).running--
+		}
+	}
+	now := time.Now()
+	for addr, store := range s.stores {
+		s.dispatch(store, now)
+		if store.running == 0 && len(store.pending) == 0 && !now.Before(store.busyUntil) {
+			delete(s.stores, addr)
+			regionScanGauge.DeleteLabelValues(addr, "queued")
+			regionScanGauge.DeleteLabelValues(addr, "running")
+		}
+	}
+}
+
+func (s *scanScheduler) getStore(addr string) *storeScans {
+	store := s.stores[addr]
+	if store == nil {
+		store = &storeScans{
+			metricQueued:  regionScanGauge.WithLabelValues(addr, "queued"),
+			metricRunning: regionScanGauge.WithLabelValues(addr, "running"),
+		}
+		s.stores[addr] = store
+	}
+	return store
+}
+
+// dispatch sends the regions which can be scanned in the store now.
+// Regions of stopped tables are failed. It must be called with s.mu held.
+func (s *scanScheduler) dispatch(store *storeScans, now time.Time) {
+	for len(store.pending) > 0 && store.running < s.limit && !now.Before(store.busyUntil) {
+		scan := heap.Pop(&store.pending).(*pendingScan)
+		if scan.region.subscribedTable.stopped.Load() {
+			scan.client.failScan(scan.region)
+			continue
+		}
+		s.running[scan.region.lockedRangeState] = scan
+		store.running++
+		scan.client.sendRegionRequest(scan.rs, scan.region)
+	}
+	store.metricQueued.Set(float64(len(store.pending)))
+	store.metricRunning.Set(float64(store.running))
+}
+
+// isStoreBusyErr returns true if the error means the store is too busy
+// to handle more requests.
+func isStoreBusyErr(err error) bool {
+	if e, ok := errors.Cause(err).(*eventError); ok {
+		return e.err.GetServerIsBusy() != nil || e.err.GetCongested() != nil
+	}
+	return false
+}
@@ -0,0 +1,215 @@
+// Copyright 2024 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kv
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pingcap/kvproto/pkg/cdcpb"
+	"github.com/pingcap/kvproto/pkg/errorpb"
+	"github.com/pingcap/tiflow/cdc/kv/regionlock"
+	"github.com/pingcap/tiflow/pkg/config"
+	"github.com/stretchr/testify/require"
+	"github.com/tikv/client-go/v2/tikv"
+)
+
+func newScanRegion(table *subscribedTable, regionID, resolvedTs uint64) regionInfo {
+	region := newRegionInfo(tikv.NewRegionVerID(regionID, 1, 1), table.span, nil, table)
+	region.lockedRangeState = &regionlock.LockedRangeState{}
+	region.lockedRangeState.ResolvedTs.Store(resolvedTs)
+	return region
+}
+
+// mockScanClient records the regions sent or failed by the scan scheduler.
+type mockScanClient struct {
+	sent, failed []uint64
+	rank         int
+}
+
+func (c *mockScanClient) sendRegionRequest(_ *requestedStore, region regionInfo) {
+	c.sent = append(c.sent, region.verID.GetID())
+}
+
+func (c *mockScanClient) failScan(region regionInfo) {
+	c.failed = append(c.failed, region.verID.GetID())
+}
+
+func (c *mockScanClient) priorityRank() int {
+	return c.rank
+}
+
+func TestScanScheduler(t *testing.T) {
+	t.Parallel()
+
+	s := newScanScheduler(2, time.Hour)
+	client := &mockScanClient{}
+	rs := &requestedStore{storeID: 1, storeAddr: "store1"}
+	table := &subscribedTable{}
+
+	regions := []regionInfo{
+		newScanRegion(table, 1, 10),
+		newScanRegion(table, 2, 30),
+		newScanRegion(table, 3, 20),
+		newScanRegion(table, 4, 40),
+	}
+	for _, region := range regions {
+		s.add(client, rs, region)
+	}
+	require.Equal(t, []uint64{1, 2}, client.sent)
+	require.Len(t, s.stores[rs.storeAddr].pending, 2)
+
+	// The region with the largest resolved ts is scanned first.
+	s.finish(regions[0].lockedRangeState, scanInitialized)
+	require.Equal(t, []uint64{1, 2, 4}, client.sent)
+
+	// Regions failed without scan slots are ignored.
+	s.finish(regions[0].lockedRangeState, scanFailed)
+	require.Equal(t, []uint64{1, 2, 4}, client.sent)
+
+	// Scans are paused if the store is busy.
+	s.finish(regions[1].lockedRangeState, scanStoreBusy)
+	require.Equal(t, []uint64{1, 2, 4}, client.sent)
+	require.Equal(t, time.Hour, s.stores[rs.storeAddr].backoff)
+	s.resume()
+	require.Equal(t, []uint64{1, 2, 4}, client.sent)
+
+	// Scans are resumed after the back-off.
+	s.stores[rs.storeAddr].busyUntil = time.Now()
+	s.resume()
+	require.Equal(t, []uint64{1, 2, 4, 3}, client.sent)
+	require.Empty(t, s.stores[rs.storeAddr].pending)
+	require.Len(t, s.running, 2)
+
+	// Initialized regions are released even if they are not finished.
+	regions[2].lockedRangeState.Initialzied.Store(true)
+	s.resume()
+	require.Len(t, s.running, 1)
+	require.Equal(t, 1, s.stores[rs.storeAddr].running)
+
+	// Regions of stopped tables are failed.
+	stopped := &subscribedTable{}
+	stopped.stopped.Store(true)
+	s.add(client, rs, newScanRegion(stopped, 5, 50))
+	require.Equal(t, []uint64{5}, client.failed)
+	require.Equal(t, []uint64{1, 2, 4, 3}, client.sent)
+}
+
+func TestScanSchedulerBackoff(t *testing.T) {
+	t.Parallel()
+
+	s := newScanScheduler(1, time.Second)
+	client := &mockScanClient{}
+	rs := &requestedStore{storeID: 1, storeAddr: "store1"}
+	table := &subscribedTable{}
+	for i := 0; i < 10; i++ {
+		region := newScanRegion(table, uint64(i), 10)
+		s.add(client, rs, region)
+		s.stores[rs.storeAddr].busyUntil = time.Time{}
+		s.resume()
+		s.finish(region.lockedRangeState, scanStoreBusy)
+	}
+	require.Equal(t, maxStoreBusyBackoff, s.stores[rs.storeAddr].backoff)
+
+	region := newScanRegion(table, 10, 10)
+	s.stores[rs.storeAddr].busyUntil = time.Time{}
+	s.add(client, rs, region)
+	s.finish(region.lockedRangeState, scanInitialized)
+	require.Zero(t, s.stores[rs.storeAddr].backoff)
+}
+
+func TestScanSchedulerSharedByClients(t *testing.T) {
+	t.Parallel()
+
+	s := newScanScheduler(2, time.Hour)
+	client1, client2 := &mockScanClient{}, &mockScanClient{}
+	// the stores of different clients with the same address share the limit.
+	rs1 := &requestedStore{storeID: 1, storeAddr: "store1"}
+	rs2 := &requestedStore{storeID: 1, storeAddr: "store1"}
+	other := &requestedStore{storeID: 2, storeAddr: "store2"}
+	table1, table2 := &subscribedTable{}, &subscribedTable{}
+
+	region1 := newScanRegion(table1, 1, 10)
+	s.add(client1, rs1, region1)
+	s.add(client1, rs1, newScanRegion(table1, 2, 10))
+	s.add(client2, rs2, newScanRegion(table2, 3, 20))
+	s.add(client2, rs2, newScanRegion(table2, 4, 10))
+	s.add(client2, other, newScanRegion(table2, 5, 10))
+	require.Equal(t, []uint64{1, 2}, client1.sent)
+	require.Equal(t, []uint64{5}, client2.sent)
+	require.Len(t, s.stores["store1"].pending, 2)
+
+	// the released slot is taken by the region of another client.
+	s.finish(region1.lockedRangeState, scanInitialized)
+	require.Equal(t, []uint64{5, 3}, client2.sent)
+
+	// the regions of the removed client release their slots,
+	// and its pending regions are dropped.
+	s.add(client1, rs1, newScanRegion(table1, 6, 30))
+	s.remove(client1)
+	require.Equal(t, []uint64{1, 2}, client1.sent)
+	require.Equal(t, []uint64{5, 3, 4}, client2.sent)
+	require.Empty(t, s.stores["store1"].pending)
+	require.Equal(t, 2, s.stores["store1"].running)
+	require.Len(t, s.running, 3)
+}
+
+func TestScanSchedulerPriority(t *testing.T) {
+	t.Parallel()
+
+	s := newScanScheduler(1, time.Hour)
+	low := &mockScanClient{rank: config.ChangefeedPriorityLow.Rank()}
+	high := &mockScanClient{rank: config.ChangefeedPriorityHigh.Rank()}
+	rs := &requestedStore{storeID: 1, storeAddr: "store1"}
+	lowTable, highTable := &subscribedTable{}, &subscribedTable{}
+
+	regions := []regionInfo{
+		newScanRegion(lowTable, 1, 10),
+		newScanRegion(lowTable, 2, 30),
+		newScanRegion(highTable, 3, 10),
+		newScanRegion(highTable, 4, 20),
+	}
+	for i, region := range regions {
+		client := low
+		if i >= 2 {
+			client = high
+		}
+		s.add(client, rs, region)
+	}
+	require.Equal(t, []uint64{1}, low.sent)
+
+	// the regions of the higher priority are scanned first,
+	// even if the regions of the lower priority have larger resolved ts.
+	s.finish(regions[0].lockedRangeState, scanInitialized)
+	require.Equal(t, []uint64{4}, high.sent)
+	s.finish(regions[3].lockedRangeState, scanInitialized)
+	require.Equal(t, []uint64{4, 3}, high.sent)
+	s.finish(regions[2].lockedRangeState, scanInitialized)
+	require.Equal(t, []uint64{1, 2}, low.sent)
+}
+
+func TestIsStoreBusyErr(t *testing.T) {
+	t.Parallel()
+
+	require.True(t, isStoreBusyErr(&eventError{err: &cdcpb.Error{
+		ServerIsBusy: &errorpb.ServerIsBusy{},
+	}}))
+	require.True(t, isStoreBusyErr(&eventError{err: &cdcpb.Error{
+		Congested: &cdcpb.Congested{},
+	}}))
+	require.False(t, isStoreBusyErr(&eventError{err: &cdcpb.Error{
+		RegionNotFound: &errorpb.RegionNotFound{},
+	}}))
+	require.False(t, isStoreBusyErr(&rpcCtxUnavailableErr{}))
+}
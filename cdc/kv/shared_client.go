@@ -17,6 +17,8 @@ import (
 	"context"
 	"encoding/binary"
 	"fmt"
+	"math"
+	"sort"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -141,6 +143,9 @@ type SharedClient struct {
 
 	clusterID  uint64
 	filterLoop bool
+	// priority is the priority of the changefeed, the incremental scans of
+	// the changefeeds with higher priorities are scheduled first.
+	priority config.ChangefeedPriority
 
 	pd           pd.Client
 	grpcPool     *sharedconn.ConnAndClientPool
@@ -152,6 +157,8 @@ type SharedClient struct {
 		sync.RWMutex
 		v map[SubscriptionID]*subscribedTable
 	}
+	hotRegions hotRegions
+	scans      *scanScheduler
 
 	workers []*sharedRegionWorker
 	// Note: stores is only motified in handleRegion goroutine,
@@ -224,6 +231,10 @@ type subscribedTable struct {
 	staleLocksTargetTs atomic.Uint64
 
 	lastAdvanceTime atomic.Int64
+
+	// To find hot tables and regions.
+	stats      regionlock.EventStats
+	throughput atomic.Pointer[Throughput]
 }
 
 // NewSharedClient creates a client.
@@ -231,6 +242,7 @@ func NewSharedClient(
 	changefeed model.ChangeFeedID,
 	cfg *config.ServerConfig,
 	filterLoop bool,
+	priority config.ChangefeedPriority,
 	pd pd.Client,
 	grpcPool *sharedconn.ConnAndClientPool,
 	regionCache *tikv.RegionCache,
@@ -242,6 +254,7 @@ func NewSharedClient(
 		config:     cfg,
 		clusterID:  0,
 		filterLoop: filterLoop,
+		priority:   priority,
 
 		pd:           pd,
 		grpcPool:     grpcPool,
@@ -262,6 +275,7 @@ func NewSharedClient(
 	} else {
 		s.logRegionDetails = log.Debug
 	}
+	s.scans = getGlobalScanScheduler(cfg.KVClient)
 
 	s.initMetrics()
 	return s
@@ -339,6 +353,39 @@ func (s *SharedClient) RegionCount(subID SubscriptionID) uint64 {
 	return 0
 }
 
+// SlowRegion is the slowest region of a subscribed span.
+type SlowRegion struct {
+	SubscriptionID SubscriptionID
+	Span           tablepb.Span
+	regionlock.LockedRangeStatistic
+}
+
+// SlowestRegions returns the slowest region of each subscribed span, the slowest first.
+// At most limit regions are returned.
+func (s *SharedClient) SlowestRegions(limit int) []SlowRegion {
+	s.totalSpans.RLock()
+	res := make([]SlowRegion, 0, len(s.totalSpans.v))
+	for subscriptionID, rt := range s.totalSpans.v {
+		attr := rt.rangeLock.IterAll(nil)
+		// No region is locked in the span.
+		if attr.SlowestRegion.ResolvedTs == math.MaxUint64 {
+			continue
+		}
+		res = append(res, SlowRegion{
+			SubscriptionID:       subscriptionID,
+			Span:                 rt.span,
+			LockedRangeStatistic: attr.SlowestRegion,
+		})
+	}
+	s.totalSpans.RUnlock()
+
+	sort.Slice(res, func(i, j int) bool { return res[i].ResolvedTs < res[j].ResolvedTs })
+	if len(res) > limit {
+		res = res[:limit]
+	}
+	return res
+}
+
 // Run the client.
 func (s *SharedClient) Run(ctx context.Context) error {
 	s.clusterID = s.pd.GetClusterID(ctx)
@@ -356,6 +403,7 @@ func (s *SharedClient) Run(ctx context.Context) error {
 	g.Go(func() error { return s.handleErrors(ctx) })
 	g.Go(func() error { return s.handleResolveLockTasks(ctx) })
 	g.Go(func() error { return s.logSlowRegions(ctx) })
+	g.Go(func() error { return s.calcThroughput(ctx) })
 
 	log.Info("event feed started",
 		zap.String("namespace", s.changefeed.Namespace),
@@ -368,6 +416,7 @@ func (s *SharedClient) Run(ctx context.Context) error {
 
 // Close closes the client. Must be called after `Run` returns.
 func (s *SharedClient) Close() {
+	s.scans.remove(s)
 	s.rangeTaskCh.CloseAndDrain()
 	s.regionCh.CloseAndDrain()
 	s.resolveLockTaskCh.CloseAndDrain()
@@ -437,22 +486,38 @@ func (s *SharedClient) handleRegions(ctx context.Context, eg *errgroup.Group) er
 			}
 
 			store := s.getStore(ctx, eg, region.rpcCtx.Peer.StoreId, region.rpcCtx.Addr)
-			stream := store.getStream()
-			stream.requests.In() <- region
-
-			s.logRegionDetails("event feed will request a region",
-				zap.String("namespace", s.changefeed.Namespace),
-				zap.String("changefeed", s.changefeed.ID),
-				zap.Uint64("streamID", stream.streamID),
-				zap.Any("subscriptionID", region.subscribedTable.subscriptionID),
-				zap.Uint64("regionID", region.verID.GetID()),
-				zap.String("span", region.span.String()),
-				zap.Uint64("storeID", store.storeID),
-				zap.String("addr", store.storeAddr))
+			// The region is sent to the store after it gets a scan slot.
+			s.scans.add(s, store, region)
 		}
 	}
 }
 
+// sendRegionRequest sends the region to a stream of the store.
+func (s *SharedClient) sendRegionRequest(store *requestedStore, region regionInfo) {
+	stream := store.getStream()
+	stream.requests.In() <- region
+
+	s.logRegionDetails("event feed will request a region",
+		zap.String("namespace", s.changefeed.Namespace),
+		zap.String("changefeed", s.changefeed.ID),
+		zap.Uint64("streamID", stream.streamID),
+		zap.Any("subscriptionID", region.subscribedTable.subscriptionID),
+		zap.Uint64("regionID", region.verID.GetID()),
+		zap.String("span", region.span.String()),
+		zap.Uint64("storeID", store.storeID),
+		zap.String("addr", store.storeAddr))
+}
+
+// priorityRank implements scanClient.
+func (s *SharedClient) priorityRank() int {
+	return s.priority.Rank()
+}
+
+// failScan fails the region which is not sent to the store.
+func (s *SharedClient) failScan(region regionInfo) {
+	s.onRegionFail(newRegionErrorInfo(region, &sendRequestToStoreErr{}))
+}
+
 func (s *SharedClient) attachRPCContextForRegion(ctx context.Context, region regionInfo) (regionInfo, bool) {
 	bo := tikv.NewBackoffer(ctx, tikvRequestMaxBackoff)
 	rpcCtx, err := s.regionCache.GetTiKVRPCContext(bo, region.verID, kvclientv2.ReplicaReadLeader, 0)
@@ -668,6 +733,12 @@ func (s *SharedClient) handleErrors(ctx context.Context) error {
 }
 
 func (s *SharedClient) doHandleError(ctx context.Context, errInfo regionErrorInfo) error {
+	if isStoreBusyErr(errInfo.err) {
+		s.scans.finish(errInfo.lockedRangeState, scanStoreBusy)
+	} else {
+		s.scans.finish(errInfo.lockedRangeState, scanFailed)
+	}
+
 	if errInfo.subscribedTable.rangeLock.UnlockRange(
 		errInfo.span.StartKey, errInfo.span.EndKey,
 		errInfo.verID.GetID(), errInfo.verID.GetVer(), errInfo.resolvedTs()) {
@@ -795,6 +866,7 @@ func (s *SharedClient) handleResolveLockTasks(ctx context.Context) error {
 				zap.Uint64("regionID", regionID),
 				zap.Error(err))
 		}
+		state.Stats.LockResolves.Add(1)
 		resolveLastRun[regionID] = time.Now()
 	}
 
@@ -148,6 +148,70 @@ func TestSubscribedTable(t *testing.T) {
 	s.resolveLockTaskCh.CloseAndDrain()
 }
 
+func TestSlowestRegions(t *testing.T) {
+	s := &SharedClient{resolveLockTaskCh: chann.NewAutoDrainChann[resolveLockTask]()}
+	defer s.resolveLockTaskCh.CloseAndDrain()
+	s.totalSpans.v = make(map[SubscriptionID]*subscribedTable)
+	for i := 1; i <= 3; i++ {
+		span := tablepb.Span{TableID: int64(i), StartKey: []byte{'a'}, EndKey: []byte{'z'}}
+		s.totalSpans.v[SubscriptionID(i)] = s.newSubscribedTable(SubscriptionID(i), span, 100, nil)
+	}
+	// No region is locked in the span of the table 3.
+	for i := 1; i <= 2; i++ {
+		table := s.totalSpans.v[SubscriptionID(i)]
+		res := table.rangeLock.LockRange(context.Background(), []byte{'b'}, []byte{'c'}, uint64(i), 100)
+		require.Equal(t, regionlock.LockRangeStatusSuccess, res.Status)
+		res.LockedRangeState.ResolvedTs.Store(uint64(300 - i*100))
+	}
+
+	regions := s.SlowestRegions(10)
+	require.Len(t, regions, 2)
+	require.Equal(t, SubscriptionID(2), regions[0].SubscriptionID)
+	require.Equal(t, uint64(2), regions[0].RegionID)
+	require.Equal(t, uint64(100), regions[0].ResolvedTs)
+	require.Equal(t, int64(1), regions[1].Span.TableID)
+	require.Equal(t, uint64(200), regions[1].ResolvedTs)
+
+	require.Len(t, s.SlowestRegions(1), 1)
+}
+
+func TestHotRegions(t *testing.T) {
+	s := &SharedClient{resolveLockTaskCh: chann.NewAutoDrainChann[resolveLockTask]()}
+	defer s.resolveLockTaskCh.CloseAndDrain()
+	s.totalSpans.v = make(map[SubscriptionID]*subscribedTable)
+	for i := 1; i <= 2; i++ {
+		span := tablepb.Span{TableID: int64(i), StartKey: []byte{'a'}, EndKey: []byte{'z'}}
+		table := s.newSubscribedTable(SubscriptionID(i), span, 100, nil)
+		s.totalSpans.v[SubscriptionID(i)] = table
+		res := table.rangeLock.LockRange(context.Background(), []byte{'b'}, []byte{'c'}, uint64(i), 100)
+		require.Equal(t, regionlock.LockRangeStatusSuccess, res.Status)
+		for j := 0; j < i*10; j++ {
+			table.stats.AddEvent(10)
+			res.LockedRangeState.Stats.AddEvent(10)
+		}
+		res.LockedRangeState.Stats.LockResolves.Add(1)
+	}
+
+	s.updateThroughput(10 * time.Second)
+	regions := s.HotRegions(10)
+	require.Len(t, regions, 2)
+	require.Equal(t, SubscriptionID(2), regions[0].SubscriptionID)
+	require.Equal(t, uint64(2), regions[0].RegionID)
+	require.Equal(t, uint64(2), regions[0].EventRate)
+	require.Equal(t, uint64(20), regions[0].ByteRate)
+	require.Equal(t, uint64(1), regions[0].LockResolves)
+	require.Equal(t, int64(1), regions[1].Span.TableID)
+	require.Equal(t, uint64(1), regions[1].EventRate)
+	require.Len(t, s.HotRegions(1), 1)
+	require.Equal(t, Throughput{EventRate: 2, ByteRate: 20, LockResolves: 1}, s.Throughput(2))
+
+	// The statistics are reset after the throughput is calculated.
+	s.updateThroughput(10 * time.Second)
+	require.Empty(t, s.HotRegions(10))
+	require.Equal(t, Throughput{}, s.Throughput(2))
+	require.Equal(t, Throughput{}, s.Throughput(3))
+}
+
 func TestConnectToOfflineOrFailedTiKV(t *testing.T) {
 	ctx, cancel := context.WithCancel(context.Background())
 	wg := &sync.WaitGroup{}
@@ -189,7 +253,7 @@ func TestConnectToOfflineOrFailedTiKV(t *testing.T) {
 			},
 			Debug: &config.DebugConfig{Puller: &config.PullerConfig{LogRegionDetails: false}},
 		},
-		false, pdClient, grpcPool, regionCache, pdClock, lockResolver,
+		false, config.ChangefeedPriorityNormal, pdClient, grpcPool, regionCache, pdClock, lockResolver,
 	)
 
 	defer func() {
@@ -220,6 +220,11 @@ func (w *sharedRegionWorker) processEvent(ctx context.Context, event statefulEve
 func (w *sharedRegionWorker) handleEventEntry(ctx context.Context, x *cdcpb.Event_Entries_, state *regionFeedState) error {
 	startTs := state.region.subscribedTable.startTs
 	emit := func(assembled model.RegionFeedEvent) bool {
+		if assembled.Val != nil {
+			size := uint64(assembled.Val.ApproximateDataSize())
+			state.region.subscribedTable.stats.AddEvent(size)
+			state.region.lockedRangeState.Stats.AddEvent(size)
+		}
 		e := newMultiplexingEvent(assembled, state.region.subscribedTable)
 		select {
 		case state.region.subscribedTable.eventCh <- e:
@@ -229,7 +234,13 @@ func (w *sharedRegionWorker) handleEventEntry(ctx context.Context, x *cdcpb.Even
 		}
 	}
 	tableID := state.region.subscribedTable.span.TableID
-	return handleEventEntry(x, startTs, state, w.metrics, emit, w.changefeed, tableID, w.client.logRegionDetails)
+	initialized := state.isInitialized()
+	err := handleEventEntry(x, startTs, state, w.metrics, emit, w.changefeed, tableID, w.client.logRegionDetails)
+	if !initialized && state.isInitialized() {
+		// Release the scan slot of the region for other regions in the store.
+		w.client.scans.finish(state.region.lockedRangeState, scanInitialized)
+	}
+	return err
 }
 
 func handleEventEntry(
@@ -256,6 +267,7 @@ func handleEventEntry(
 		case cdcpb.Event_INITIALIZED:
 			metrics.metricPullEventInitializedCounter.Inc()
 			state.setInitialized()
+			state.endScanSpan(nil)
 			logRegionDetails("region is initialized",
 				zap.String("namespace", changefeed.Namespace),
 				zap.String("changefeed", changefeed.ID),
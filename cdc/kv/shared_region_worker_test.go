@@ -41,7 +41,7 @@ func newSharedClientForTestSharedRegionWorker() *SharedClient {
 			Puller: &config.PullerConfig{LogRegionDetails: false},
 		},
 	}
-	return NewSharedClient(model.ChangeFeedID{}, cfg, false, nil, nil, nil, nil, nil)
+	return NewSharedClient(model.ChangeFeedID{}, cfg, false, config.ChangefeedPriorityNormal, nil, nil, nil, nil, nil)
 }
 
 // For UPDATE SQL, its prewrite event has both value and old value.
@@ -354,6 +354,7 @@ func (s *requestedStream) send(ctx context.Context, c *SharedClient, rs *request
 
 			state := newRegionFeedState(region, uint64(subscriptionID))
 			state.start()
+			state.startScanSpan(c.changefeed)
 			s.setState(subscriptionID, region.verID.GetID(), state)
 
 			var cc *sharedconn.ConnAndClient
@@ -24,6 +24,7 @@ import (
 	"github.com/pingcap/log"
 	"github.com/pingcap/tiflow/pkg/config"
 	cerror "github.com/pingcap/tiflow/pkg/errors"
+	"github.com/pingcap/tiflow/pkg/security"
 	"github.com/pingcap/tiflow/pkg/sink"
 	"github.com/pingcap/tiflow/pkg/util"
 	"github.com/pingcap/tiflow/pkg/version"
@@ -169,6 +170,9 @@ type ChangeFeedInfo struct {
 	State   FeedState             `json:"state"`
 	Error   *RunningError         `json:"error"`
 	Warning *RunningError         `json:"warning"`
+	// ErrorHistory records the recent errors and warnings of the changefeed,
+	// the oldest one comes first.
+	ErrorHistory []*RunningError `json:"error-history,omitempty"`
 
 	CreatorVersion string `json:"creator-version"`
 	// Epoch is the epoch of a changefeed, changes on every restart.
@@ -224,31 +228,37 @@ func (info *ChangeFeedInfo) isFailedByGC() bool {
 	return cerror.IsChangefeedGCFastFailErrorCode(errors.RFCErrorCode(info.Error.Code))
 }
 
-// String implements fmt.Stringer interface, but hide some sensitive information
+// String implements fmt.Stringer interface, but hide some sensitive information.
+// The secrets are masked instead of encrypted even if the secret key is configured,
+// so the logs show the plain sink uri and replica config.
 func (info *ChangeFeedInfo) String() (str string) {
-	var err error
-	str, err = info.Marshal()
-	if err != nil {
-		log.Error("failed to marshal changefeed info", zap.Error(err))
-		return
+	// alias the original type to bypass the encryption in MarshalJSON
+	type Alias ChangeFeedInfo
+	clone := *info
+	clone.SinkURI = util.MaskSensitiveDataInURI(info.SinkURI)
+	if info.Config != nil {
+		clone.Config = info.Config.Clone()
+		clone.Config.MaskSensitiveData()
 	}
-	clone := new(ChangeFeedInfo)
-	err = clone.Unmarshal([]byte(str))
+
+	data, err := json.Marshal((*Alias)(&clone))
 	if err != nil {
-		log.Error("failed to unmarshal changefeed info", zap.Error(err))
+		log.Error("failed to marshal changefeed info", zap.Error(cerror.WrapError(cerror.ErrMarshalFailed, err)))
 		return
 	}
+	return string(data)
+}
 
-	clone.SinkURI = util.MaskSensitiveDataInURI(clone.SinkURI)
-	if clone.Config != nil {
-		clone.Config.MaskSensitiveData()
+// AppendErrorHistory appends the error to the error history and keeps
+// at most maxSize recent errors.
+func (info *ChangeFeedInfo) AppendErrorHistory(err *RunningError, maxSize int) {
+	if err == nil || maxSize <= 0 {
+		return
 	}
-
-	str, err = clone.Marshal()
-	if err != nil {
-		log.Error("failed to marshal changefeed info", zap.Error(err))
+	info.ErrorHistory = append(info.ErrorHistory, err)
+	if len(info.ErrorHistory) > maxSize {
+		info.ErrorHistory = info.ErrorHistory[len(info.ErrorHistory)-maxSize:]
 	}
-	return
 }
 
 // GetStartTs returns StartTs if it's specified or using the
@@ -293,15 +303,99 @@ func (info *ChangeFeedInfo) Unmarshal(data []byte) error {
 	return nil
 }
 
+// MarshalJSON implements json.Marshaler, the secrets in the sink uri and the
+// replica config are encrypted if the secret key is configured.
+func (info *ChangeFeedInfo) MarshalJSON() ([]byte, error) {
+	// alias the original type to prevent recursive call of MarshalJSON
+	type Alias ChangeFeedInfo
+	cipher := security.GetSecretCipher()
+	if cipher == nil {
+		return json.Marshal((*Alias)(info))
+	}
+
+	encrypted := *info
+	if info.Config != nil {
+		encrypted.Config = info.Config.Clone()
+	}
+	err := encrypted.rangeSensitiveData(func(value *string) error {
+		ciphertext, err := cipher.Encrypt(*value)
+		if err != nil {
+			return err
+		}
+		*value = ciphertext
+		return nil
+	})
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	return json.Marshal((*Alias)(&encrypted))
+}
+
+// UnmarshalJSON implements json.Unmarshaler, the encrypted secrets are decrypted
+// transparently, and the plaintext secrets persisted before the secret key is
+// configured are kept as is, they are encrypted the next time the info is saved.
+func (info *ChangeFeedInfo) UnmarshalJSON(data []byte) error {
+	// alias the original type to prevent recursive call of UnmarshalJSON
+	type Alias ChangeFeedInfo
+	if err := json.Unmarshal(data, (*Alias)(info)); err != nil {
+		return err
+	}
+	return info.rangeSensitiveData(func(value *string) error {
+		if !security.IsEncryptedSecret(*value) {
+			return nil
+		}
+		cipher := security.GetSecretCipher()
+		if cipher == nil {
+			return cerror.ErrDecryptSecretFailed.GenWithStackByArgs(
+				"the secret key is not configured, please set security.secret-key-path")
+		}
+		plaintext, err := cipher.Decrypt(*value)
+		if err != nil {
+			return err
+		}
+		*value = plaintext
+		return nil
+	})
+}
+
+// rangeSensitiveData calls fn with the sensitive data in the sink uri and the replica config.
+func (info *ChangeFeedInfo) rangeSensitiveData(fn func(*string) error) error {
+	if info.SinkURI != "" && (security.IsEncryptedSecret(info.SinkURI) ||
+		util.ContainsSensitiveDataInURI(info.SinkURI)) {
+		if err := fn(&info.SinkURI); err != nil {
+			return err
+		}
+	}
+	if info.Config != nil {
+		return info.Config.RangeSensitiveData(fn)
+	}
+	return nil
+}
+
 // Clone returns a cloned ChangeFeedInfo
 func (info *ChangeFeedInfo) Clone() (*ChangeFeedInfo, error) {
-	s, err := info.Marshal()
-	if err != nil {
-		return nil, err
+	// copy the fields directly instead of round-tripping through json,
+	// which would encrypt and decrypt the secrets for nothing.
+	cloned := *info
+	if info.Config != nil {
+		cloned.Config = info.Config.Clone()
+	}
+	cloneRunningError := func(e *RunningError) *RunningError {
+		if e == nil {
+			return nil
+		}
+		c := *e
+		return &c
+	}
+	cloned.Error = cloneRunningError(info.Error)
+	cloned.Warning = cloneRunningError(info.Warning)
+	if info.ErrorHistory != nil {
+		cloned.ErrorHistory = make([]*RunningError, 0, len(info.ErrorHistory))
+		for _, e := range info.ErrorHistory {
+			cloned.ErrorHistory = append(cloned.ErrorHistory, cloneRunningError(e))
+		}
 	}
-	cloned := new(ChangeFeedInfo)
-	err = cloned.Unmarshal([]byte(s))
-	return cloned, err
+	return &cloned, nil
 }
 
 // VerifyAndComplete verifies changefeed info and may fill in some fields.
@@ -361,8 +455,9 @@ func (info *ChangeFeedInfo) RmUnusedFields() {
 		info.rmMQOnlyFields()
 	} else {
 		// remove schema registry for MQ downstream with
-		// protocol other than avro
-		if util.GetOrZero(info.Config.Sink.Protocol) != config.ProtocolAvro.String() {
+		// protocol other than avro and protobuf
+		protocol := util.GetOrZero(info.Config.Sink.Protocol)
+		if protocol != config.ProtocolAvro.String() && protocol != config.ProtocolProtobuf.String() {
 			info.Config.Sink.SchemaRegistry = nil
 		}
 	}
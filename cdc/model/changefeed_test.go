@@ -22,6 +22,7 @@ import (
 
 	"github.com/pingcap/tiflow/pkg/config"
 	"github.com/pingcap/tiflow/pkg/errors"
+	"github.com/pingcap/tiflow/pkg/security"
 	"github.com/pingcap/tiflow/pkg/util"
 	"github.com/stretchr/testify/require"
 	"github.com/tikv/client-go/v2/oracle"
@@ -881,14 +882,79 @@ func TestChangeFeedInfoClone(t *testing.T) {
 			CaseSensitive:    true,
 			CheckGCSafePoint: true,
 		},
+		Error:        &RunningError{Code: "CDC:ErrSinkURIInvalid"},
+		ErrorHistory: []*RunningError{{Code: "CDC:ErrSinkURIInvalid"}},
 	}
 
 	cloned, err := info.Clone()
 	require.Nil(t, err)
+	require.Equal(t, info, cloned)
 	sinkURI := "mysql://unix:/var/run/tidb.sock"
 	cloned.SinkURI = sinkURI
+	cloned.Config.CaseSensitive = false
+	cloned.Error.Code = "CDC:ErrProcessorUnknown"
+	cloned.ErrorHistory[0].Code = "CDC:ErrProcessorUnknown"
 	require.Equal(t, sinkURI, cloned.SinkURI)
 	require.Equal(t, "blackhole://", info.SinkURI)
+	require.True(t, info.Config.CaseSensitive)
+	require.Equal(t, "CDC:ErrSinkURIInvalid", info.Error.Code)
+	require.Equal(t, "CDC:ErrSinkURIInvalid", info.ErrorHistory[0].Code)
+}
+
+func TestChangeFeedInfoEncryptSecrets(t *testing.T) {
+	// the test is not parallel since it changes the global secret cipher.
+	newInfo := func() *ChangeFeedInfo {
+		cfg := config.GetDefaultReplicaConfig()
+		cfg.Sink.KafkaConfig = &config.KafkaConfig{
+			SASLUser:     util.AddressOf("user"),
+			SASLPassword: util.AddressOf("kafka-password"),
+		}
+		cfg.Consistent.Storage = "file:///tmp/redo"
+		return &ChangeFeedInfo{
+			SinkURI: "kafka://127.0.0.1:9092/topic?sasl-password=uri-password",
+			Config:  cfg,
+		}
+	}
+	plaintext, err := newInfo().Marshal()
+	require.NoError(t, err)
+	require.Contains(t, plaintext, "kafka-password")
+
+	keyPath, err := security.NewSecretKeyFile4Test()
+	require.NoError(t, err)
+	require.NoError(t, security.InitSecretCipher(&security.Credential{SecretKeyPath: keyPath}))
+	defer security.SetSecretCipher(nil)
+
+	info := newInfo()
+	encrypted, err := info.Marshal()
+	require.NoError(t, err)
+	require.NotContains(t, encrypted, "kafka-password")
+	require.NotContains(t, encrypted, "uri-password")
+	// the uri without sensitive data is kept in plaintext.
+	require.Contains(t, encrypted, "file:///tmp/redo")
+	// the original info is not changed.
+	require.Equal(t, "kafka-password", *info.Config.Sink.KafkaConfig.SASLPassword)
+
+	// the secrets are masked rather than encrypted in the logs.
+	str := info.String()
+	require.Contains(t, str, "kafka://127.0.0.1:9092/topic?sasl-password=xxxxx")
+	require.NotContains(t, str, "kafka-password")
+	require.NotContains(t, str, "uri-password")
+	require.Equal(t, "kafka-password", *info.Config.Sink.KafkaConfig.SASLPassword)
+
+	decrypted := new(ChangeFeedInfo)
+	require.NoError(t, decrypted.Unmarshal([]byte(encrypted)))
+	require.Equal(t, info.SinkURI, decrypted.SinkURI)
+	require.Equal(t, "kafka-password", *decrypted.Config.Sink.KafkaConfig.SASLPassword)
+
+	// the plaintext secrets persisted before are still readable.
+	decrypted = new(ChangeFeedInfo)
+	require.NoError(t, decrypted.Unmarshal([]byte(plaintext)))
+	require.Equal(t, "kafka-password", *decrypted.Config.Sink.KafkaConfig.SASLPassword)
+
+	// the encrypted secrets can't be read without the secret key.
+	security.SetSecretCipher(nil)
+	err = new(ChangeFeedInfo).Unmarshal([]byte(encrypted))
+	require.True(t, errors.ErrDecryptSecretFailed.Equal(err))
 }
 
 func TestChangefeedInfoStringer(t *testing.T) {
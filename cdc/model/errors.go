@@ -28,6 +28,8 @@ type RunningError struct {
 	Addr    string    `json:"addr"`
 	Code    string    `json:"code"`
 	Message string    `json:"message"`
+	// Table is the table involved in the error, it can be empty.
+	Table string `json:"table,omitempty"`
 }
 
 // ShouldFailChangefeed return true if a running error contains a changefeed not retry error.
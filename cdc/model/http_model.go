@@ -194,6 +194,75 @@ type ProcessorDetail struct {
 	Tables []int64 `json:"table_ids"`
 }
 
+// ProcessorLatency holds the latency breakdown of a processor.
+type ProcessorLatency struct {
+	CaptureID string `json:"capture_id"`
+	// CurrentTs is the ts of the PD clock when the latency is collected.
+	CurrentTs uint64 `json:"current_ts"`
+	// SinkMemQuotaUsed is the used bytes of the sink memory quota.
+	SinkMemQuotaUsed uint64 `json:"sink_mem_quota_used"`
+	// RedoMemQuotaUsed is the used bytes of the redo memory quota.
+	RedoMemQuotaUsed uint64         `json:"redo_mem_quota_used"`
+	Tables           []TableLatency `json:"tables"`
+	// SlowRegions are the slowest regions of the table spans, the slowest first.
+	SlowRegions []SlowRegion `json:"slow_regions"`
+}
+
+// TableLatency holds the progress of a table span in each stage of a processor.
+type TableLatency struct {
+	TableID           int64  `json:"table_id"`
+	TableName         string `json:"table_name"`
+	Span              string `json:"span"`
+	PullerResolvedTs  uint64 `json:"puller_resolved_ts"`
+	SorterResolvedTs  uint64 `json:"sorter_resolved_ts"`
+	MounterResolvedTs uint64 `json:"mounter_resolved_ts"`
+	SinkCheckpointTs  uint64 `json:"sink_checkpoint_ts"`
+	// RedoResolvedTs is 0 if redo log is disabled.
+	RedoResolvedTs uint64 `json:"redo_resolved_ts,omitempty"`
+}
+
+// SlowRegion holds the slowest region of a table span.
+type SlowRegion struct {
+	TableID     int64  `json:"table_id"`
+	RegionID    uint64 `json:"region_id"`
+	ResolvedTs  uint64 `json:"resolved_ts"`
+	Initialized bool   `json:"initialized"`
+}
+
+// ProcessorHotspots holds the hottest tables and regions of a processor.
+type ProcessorHotspots struct {
+	CaptureID string `json:"capture_id"`
+	// Tables are the table spans with the highest event rates, the hottest first.
+	Tables []HotTable `json:"tables"`
+	// Regions are the regions with the highest event rates, the hottest first.
+	Regions []HotRegion `json:"regions"`
+}
+
+// HotTable holds the throughput of a table span received from TiKV.
+type HotTable struct {
+	CaptureID   string `json:"capture_id"`
+	TableID     int64  `json:"table_id"`
+	TableName   string `json:"table_name"`
+	Span        string `json:"span"`
+	RegionCount uint64 `json:"region_count"`
+	// EventRate is the number of received events per second.
+	EventRate uint64 `json:"event_rate"`
+	// ByteRate is the bytes of received events per second.
+	ByteRate uint64 `json:"byte_rate"`
+}
+
+// HotRegion holds the throughput of a region received from TiKV.
+type HotRegion struct {
+	CaptureID string `json:"capture_id"`
+	TableID   int64  `json:"table_id"`
+	TableName string `json:"table_name"`
+	RegionID  uint64 `json:"region_id"`
+	EventRate uint64 `json:"event_rate"`
+	ByteRate  uint64 `json:"byte_rate"`
+	// LockResolves is the number of lock resolvings in the last statistic interval.
+	LockResolves uint64 `json:"lock_resolves"`
+}
+
 // CaptureTaskStatus holds TaskStatus of a capture
 type CaptureTaskStatus struct {
 	CaptureID string `json:"capture_id"`
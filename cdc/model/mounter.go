@@ -19,6 +19,7 @@ import (
 
 	"github.com/pingcap/log"
 	"github.com/pingcap/tidb/pkg/types"
+	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
 )
 
@@ -42,6 +43,10 @@ type PolymorphicEvent struct {
 	RawKV *RawKVEntry
 	Row   *RowChangedEvent
 
+	// SpanContext is the trace context of the transaction, it's valid only
+	// if the transaction is sampled by the tracing of the changefeed.
+	SpanContext trace.SpanContext
+
 	finished chan struct{}
 }
 
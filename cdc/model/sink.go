@@ -444,6 +444,11 @@ func (r *RowChangedEvent) GetCommitTs() uint64 {
 	return r.CommitTs
 }
 
+// GetStartTs returns the start timestamp of the transaction of this event.
+func (r *RowChangedEvent) GetStartTs() uint64 {
+	return r.StartTs
+}
+
 // TrySplitAndSortUpdateEvent do nothing
 func (r *RowChangedEvent) TrySplitAndSortUpdateEvent(_ string, _ bool) error {
 	return nil
@@ -1162,6 +1167,11 @@ func (t *SingleTableTxn) GetCommitTs() uint64 {
 	return t.CommitTs
 }
 
+// GetStartTs returns the start timestamp of the transaction.
+func (t *SingleTableTxn) GetStartTs() uint64 {
+	return t.StartTs
+}
+
 // GetPhysicalTableID returns the physical table id of the table in the transaction
 func (t *SingleTableTxn) GetPhysicalTableID() int64 {
 	return t.PhysicalTableID
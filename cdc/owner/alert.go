@@ -0,0 +1,186 @@
+// Copyright 2024 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package owner
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/pingcap/errors"
+	"github.com/pingcap/tiflow/cdc/model"
+	"github.com/pingcap/tiflow/pkg/alert"
+	"github.com/pingcap/tiflow/pkg/config"
+	"github.com/pingcap/tiflow/pkg/util"
+	"github.com/tikv/client-go/v2/oracle"
+)
+
+// alertChecker checks whether alerts should be fired for a changefeed.
+// It is called in every owner tick, so it's not thread-safe.
+type alertChecker struct {
+	id model.ChangeFeedID
+
+	// lastState is the state observed in the last check, it's empty before
+	// the first check so that no alert is fired when the owner is changed.
+	lastState model.FeedState
+
+	// blockedDDLCommitTs and blockedDDLSince record the DDL being executed.
+	blockedDDLCommitTs model.Ts
+	blockedDDLSince    time.Time
+
+	// notifier sends the alerts to webhooks, it's created on the first alert
+	// and closed once the changefeed is stopped and the alerts are sent, or
+	// the changefeed is removed.
+	notifier    alert.Notifier
+	newNotifier func() (alert.Notifier, error)
+}
+
+func newAlertChecker(id model.ChangeFeedID) *alertChecker {
+	return &alertChecker{
+		id: id,
+		newNotifier: func() (alert.Notifier, error) {
+			return alert.NewWebhookNotifier(config.GetGlobalServerConfig().Security)
+		},
+	}
+}
+
+// notify sends the alert to webhooks, the notifier is created if it's closed.
+func (c *alertChecker) notify(cfg *config.AlertConfig, a *alert.Alert) error {
+	if c.notifier == nil {
+		notifier, err := c.newNotifier()
+		if err != nil {
+			return errors.Trace(err)
+		}
+		c.notifier = notifier
+	}
+	c.notifier.Notify(cfg, a)
+	return nil
+}
+
+// closeNotifier closes the notifier if no alert is pending, so it never blocks
+// the owner. The notifier is kept with its dedup state otherwise, and should be
+// closed again in the next tick.
+func (c *alertChecker) closeNotifier() {
+	if c.notifier != nil && c.notifier.Pending() == 0 {
+		c.notifier.Close()
+		c.notifier = nil
+	}
+}
+
+// closeNotifierAsync closes the notifier in the background after the pending
+// alerts are sent, it's used when the checker is dropped.
+func (c *alertChecker) closeNotifierAsync() {
+	if c.notifier != nil {
+		go c.notifier.Close()
+		c.notifier = nil
+	}
+}
+
+// isStoppedState returns true if the changefeed is stopped and won't fire
+// alerts until it's resumed.
+func isStoppedState(info *model.ChangeFeedInfo) bool {
+	if info == nil {
+		return true
+	}
+	switch info.State {
+	case model.StateStopped, model.StateFailed, model.StateFinished, model.StateRemoved:
+		return true
+	}
+	return false
+}
+
+// getAlertConfig returns the alert config of the changefeed,
+// it falls back to the global alert config of the server.
+func getAlertConfig(info *model.ChangeFeedInfo) *config.AlertConfig {
+	if info != nil && info.Config != nil && info.Config.Alert != nil {
+		return info.Config.Alert
+	}
+	return config.GetGlobalServerConfig().Alert
+}
+
+// check returns alerts that should be fired.
+// executingDDL is the DDL being executed, it can be nil.
+func (c *alertChecker) check(
+	cfg *config.AlertConfig,
+	info *model.ChangeFeedInfo,
+	status *model.ChangeFeedStatus,
+	executingDDL *model.DDLEvent,
+	now time.Time,
+	pdTime time.Time,
+) []*alert.Alert {
+	if info == nil {
+		return nil
+	}
+	var alerts []*alert.Alert
+	newAlert := func(kind alert.Kind, dedupKey, msg string) *alert.Alert {
+		a := &alert.Alert{
+			Namespace:  c.id.Namespace,
+			Changefeed: c.id.ID,
+			Kind:       kind,
+			State:      string(info.State),
+			Message:    msg,
+			Time:       now,
+			DedupKey:   dedupKey,
+		}
+		if status != nil {
+			a.CheckpointTs = status.CheckpointTs
+		}
+		return a
+	}
+
+	if c.lastState != "" && c.lastState != info.State {
+		for _, s := range cfg.NotifyStates {
+			if s != string(info.State) {
+				continue
+			}
+			msg := fmt.Sprintf("changefeed state changed from %s to %s", c.lastState, info.State)
+			if info.Error != nil && (info.State == model.StateFailed || info.State == model.StatePending) {
+				msg = fmt.Sprintf("%s, error: [%s] %s", msg, info.Error.Code, info.Error.Message)
+			} else if info.Warning != nil && info.State == model.StateWarning {
+				msg = fmt.Sprintf("%s, warning: [%s] %s", msg, info.Warning.Code, info.Warning.Message)
+			}
+			alerts = append(alerts, newAlert(alert.KindStateChanged,
+				fmt.Sprintf("%s/%s", alert.KindStateChanged, info.State), msg))
+			break
+		}
+	}
+	c.lastState = info.State
+
+	if threshold := util.GetOrZero(cfg.CheckpointLagThreshold); threshold > 0 &&
+		status != nil && info.State.IsRunning() {
+		lag := pdTime.Sub(oracle.GetTimeFromTS(status.CheckpointTs))
+		if lag > threshold {
+			alerts = append(alerts, newAlert(alert.KindCheckpointLag,
+				string(alert.KindCheckpointLag),
+				fmt.Sprintf("checkpoint lag %s exceeds the threshold %s", lag, threshold)))
+		}
+	}
+
+	if executingDDL == nil {
+		c.blockedDDLCommitTs = 0
+		c.blockedDDLSince = time.Time{}
+	} else {
+		if executingDDL.CommitTs != c.blockedDDLCommitTs {
+			c.blockedDDLCommitTs = executingDDL.CommitTs
+			c.blockedDDLSince = now
+		}
+		threshold := util.GetOrZero(cfg.DDLBlockedThreshold)
+		if blocked := now.Sub(c.blockedDDLSince); threshold > 0 && blocked > threshold {
+			alerts = append(alerts, newAlert(alert.KindDDLBlocked,
+				fmt.Sprintf("%s/%d", alert.KindDDLBlocked, executingDDL.CommitTs),
+				fmt.Sprintf("DDL %q with commitTs %d is blocked for %s",
+					executingDDL.Query, executingDDL.CommitTs, blocked)))
+		}
+	}
+	return alerts
+}
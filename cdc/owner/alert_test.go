@@ -0,0 +1,171 @@
+// Copyright 2024 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package owner
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/pingcap/tiflow/cdc/model"
+	"github.com/pingcap/tiflow/pkg/alert"
+	"github.com/pingcap/tiflow/pkg/config"
+	"github.com/pingcap/tiflow/pkg/util"
+	"github.com/stretchr/testify/require"
+	"github.com/tikv/client-go/v2/oracle"
+)
+
+func TestAlertCheckerStateChanged(t *testing.T) {
+	t.Parallel()
+
+	c := newAlertChecker(model.DefaultChangeFeedID("test"))
+	cfg := &config.AlertConfig{
+		WebhookURLs:  []string{"http://127.0.0.1:8080"},
+		NotifyStates: []string{string(model.StateFailed)},
+	}
+	now := time.Now()
+	info := &model.ChangeFeedInfo{State: model.StateFailed}
+	// no alert is fired in the first check
+	require.Empty(t, c.check(cfg, info, nil, nil, now, now))
+
+	info.State = model.StateNormal
+	require.Empty(t, c.check(cfg, info, nil, nil, now, now))
+
+	info.State = model.StateFailed
+	info.Error = &model.RunningError{Code: "CDC:ErrGCTTLExceeded", Message: "gc ttl exceeded"}
+	alerts := c.check(cfg, info, nil, nil, now, now)
+	require.Len(t, alerts, 1)
+	require.Equal(t, alert.KindStateChanged, alerts[0].Kind)
+	require.Equal(t, "state-changed/failed", alerts[0].DedupKey)
+	require.Contains(t, alerts[0].Message, "CDC:ErrGCTTLExceeded")
+
+	// the state is not changed
+	require.Empty(t, c.check(cfg, info, nil, nil, now, now))
+}
+
+func TestAlertCheckerCheckpointLag(t *testing.T) {
+	t.Parallel()
+
+	c := newAlertChecker(model.DefaultChangeFeedID("test"))
+	cfg := &config.AlertConfig{
+		WebhookURLs:            []string{"http://127.0.0.1:8080"},
+		CheckpointLagThreshold: util.AddressOf(time.Minute),
+	}
+	now := time.Now()
+	info := &model.ChangeFeedInfo{State: model.StateNormal}
+	status := &model.ChangeFeedStatus{
+		CheckpointTs: oracle.GoTimeToTS(now.Add(-30 * time.Second)),
+	}
+	require.Empty(t, c.check(cfg, info, status, nil, now, now))
+
+	status.CheckpointTs = oracle.GoTimeToTS(now.Add(-2 * time.Minute))
+	alerts := c.check(cfg, info, status, nil, now, now)
+	require.Len(t, alerts, 1)
+	require.Equal(t, alert.KindCheckpointLag, alerts[0].Kind)
+	require.Equal(t, status.CheckpointTs, alerts[0].CheckpointTs)
+
+	// stopped changefeeds are not checked
+	info.State = model.StateStopped
+	require.Empty(t, c.check(cfg, info, status, nil, now, now))
+}
+
+func TestAlertCheckerDDLBlocked(t *testing.T) {
+	t.Parallel()
+
+	c := newAlertChecker(model.DefaultChangeFeedID("test"))
+	cfg := &config.AlertConfig{
+		WebhookURLs:         []string{"http://127.0.0.1:8080"},
+		DDLBlockedThreshold: util.AddressOf(time.Minute),
+	}
+	now := time.Now()
+	info := &model.ChangeFeedInfo{State: model.StateNormal}
+	ddl := &model.DDLEvent{CommitTs: 100, Query: "alter table t add column c int"}
+	require.Empty(t, c.check(cfg, info, nil, ddl, now, now))
+	require.Empty(t, c.check(cfg, info, nil, ddl, now.Add(30*time.Second), now))
+
+	alerts := c.check(cfg, info, nil, ddl, now.Add(2*time.Minute), now)
+	require.Len(t, alerts, 1)
+	require.Equal(t, alert.KindDDLBlocked, alerts[0].Kind)
+	require.Equal(t, "ddl-blocked/100", alerts[0].DedupKey)
+
+	// a new DDL resets the timer
+	ddl = &model.DDLEvent{CommitTs: 200, Query: "create table t1 (id int)"}
+	require.Empty(t, c.check(cfg, info, nil, ddl, now.Add(3*time.Minute), now))
+	require.Empty(t, c.check(cfg, info, nil, nil, now.Add(5*time.Minute), now))
+}
+
+type mockNotifier struct {
+	alerts  []*alert.Alert
+	pending int
+	closed  atomic.Bool
+}
+
+func (n *mockNotifier) Notify(_ *config.AlertConfig, a *alert.Alert) {
+	n.alerts = append(n.alerts, a)
+}
+
+func (n *mockNotifier) Pending() int {
+	return n.pending
+}
+
+func (n *mockNotifier) Close() {
+	n.closed.Store(true)
+}
+
+func TestAlertCheckerNotifier(t *testing.T) {
+	t.Parallel()
+
+	c := newAlertChecker(model.DefaultChangeFeedID("test"))
+	var notifiers []*mockNotifier
+	c.newNotifier = func() (alert.Notifier, error) {
+		n := &mockNotifier{}
+		notifiers = append(notifiers, n)
+		return n, nil
+	}
+	cfg := &config.AlertConfig{WebhookURLs: []string{"http://127.0.0.1:8080"}}
+
+	// the notifier is created on the first alert.
+	c.closeNotifier()
+	require.Empty(t, notifiers)
+	require.NoError(t, c.notify(cfg, &alert.Alert{}))
+	require.NoError(t, c.notify(cfg, &alert.Alert{}))
+	require.Len(t, notifiers, 1)
+	require.Len(t, notifiers[0].alerts, 2)
+
+	// the notifier is not closed until the pending alerts are sent.
+	notifiers[0].pending = 1
+	c.closeNotifier()
+	require.False(t, notifiers[0].closed.Load())
+	require.NoError(t, c.notify(cfg, &alert.Alert{}))
+	require.Len(t, notifiers, 1)
+
+	// the notifier is created again after it's closed.
+	notifiers[0].pending = 0
+	c.closeNotifier()
+	require.True(t, notifiers[0].closed.Load())
+	require.NoError(t, c.notify(cfg, &alert.Alert{}))
+	require.Len(t, notifiers, 2)
+	require.False(t, notifiers[1].closed.Load())
+
+	// the notifier with pending alerts is closed in the background.
+	notifiers[1].pending = 1
+	c.closeNotifierAsync()
+	require.Eventually(t, notifiers[1].closed.Load, 5*time.Second, 10*time.Millisecond)
+
+	require.True(t, isStoppedState(nil))
+	require.True(t, isStoppedState(&model.ChangeFeedInfo{State: model.StateStopped}))
+	require.True(t, isStoppedState(&model.ChangeFeedInfo{State: model.StateFailed}))
+	require.False(t, isStoppedState(&model.ChangeFeedInfo{State: model.StatePending}))
+	require.False(t, isStoppedState(&model.ChangeFeedInfo{State: model.StateNormal}))
+}
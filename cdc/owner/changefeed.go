@@ -32,6 +32,7 @@ import (
 	"github.com/pingcap/tiflow/cdc/redo"
 	"github.com/pingcap/tiflow/cdc/scheduler"
 	"github.com/pingcap/tiflow/cdc/scheduler/schedulepb"
+	"github.com/pingcap/tiflow/cdc/validator"
 	"github.com/pingcap/tiflow/cdc/vars"
 	"github.com/pingcap/tiflow/pkg/config"
 	cerror "github.com/pingcap/tiflow/pkg/errors"
@@ -154,6 +155,9 @@ type changefeed struct {
 	downstreamObserver observer.Observer
 	observerLastTick   *atomic.Time
 
+	// validator is nil if the data consistency validation is not enabled.
+	validator *validator.Validator
+
 	newDDLPuller func(
 		up *upstream.Upstream,
 		startTs uint64,
@@ -336,6 +340,7 @@ func (c *changefeed) handleErr(ctx context.Context, err error) {
 		Addr:    config.GetGlobalServerConfig().AdvertiseAddr,
 		Code:    code,
 		Message: err.Error(),
+		Table:   cerror.TableOf(err),
 	})
 	c.releaseResources(ctx)
 }
@@ -356,6 +361,7 @@ func (c *changefeed) handleWarning(err error) {
 		Addr:    config.GetGlobalServerConfig().AdvertiseAddr,
 		Code:    code,
 		Message: err.Error(),
+		Table:   cerror.TableOf(err),
 	})
 }
 
@@ -651,9 +657,9 @@ LOOP2:
 	if err != nil {
 		return errors.Trace(err)
 	}
-	c.schema, err = entry.NewSchemaStorage(
+	c.schema, err = entry.NewSchemaStorageWithCache(
 		c.upstream.KVStorage, ddlStartTs,
-		cfInfo.Config.ForceReplicate, c.id, util.RoleOwner, filter)
+		cfInfo.Config, c.id, util.RoleOwner, filter)
 	if err != nil {
 		return errors.Trace(err)
 	}
@@ -689,6 +695,18 @@ LOOP2:
 	}
 	c.observerLastTick = atomic.NewTime(time.Time{})
 
+	c.validator, err = validator.NewValidator(c.id, cfInfo.SinkURI, cfInfo.Config)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if c.validator != nil {
+		c.wg.Add(1)
+		go func() {
+			defer c.wg.Done()
+			c.Throw(ctx)(c.validator.Run(cancelCtx))
+		}()
+	}
+
 	c.redoDDLMgr = redo.NewDDLManager(c.id, cfInfo.Config.Consistent, ddlStartTs)
 	if c.redoDDLMgr.Enabled() {
 		c.wg.Add(1)
@@ -728,6 +746,7 @@ LOOP2:
 	// create scheduler
 	cfg := *c.cfg
 	cfg.ChangefeedSettings = cfInfo.Config.Scheduler
+	cfg.AdjustByPriority(cfInfo.Config.Priority)
 	epoch := cfInfo.Epoch
 	c.scheduler, err = c.newScheduler(ctx, c.id, c.upstream, epoch, &cfg, c.redoMetaMgr, c.globalVars)
 	if err != nil {
@@ -819,6 +838,10 @@ func (c *changefeed) releaseResources(ctx context.Context) {
 	if c.downstreamObserver != nil {
 		_ = c.downstreamObserver.Close()
 	}
+	if c.validator != nil {
+		c.validator.Close(c.isRemoved)
+		c.validator = nil
+	}
 
 	c.schema = nil
 	c.barriers = nil
@@ -946,6 +969,9 @@ func (c *changefeed) handleBarrier(ctx context.Context,
 			if err := c.ddlSink.emitSyncPoint(ctx, barrierTs); err != nil {
 				return errors.Trace(err)
 			}
+			if c.validator != nil {
+				c.validator.OnSyncPoint(barrierTs)
+			}
 			c.barriers.Update(syncPointBarrier, nextSyncPointTs)
 		case finishBarrier:
 			c.feedStateManager.MarkFinished()
@@ -30,6 +30,7 @@ import (
 	"github.com/pingcap/tiflow/cdc/puller"
 	"github.com/pingcap/tiflow/cdc/redo"
 	"github.com/pingcap/tiflow/cdc/scheduler/schedulepb"
+	cerror "github.com/pingcap/tiflow/pkg/errors"
 	"github.com/pingcap/tiflow/pkg/filter"
 	"go.uber.org/zap"
 )
@@ -465,6 +466,9 @@ func (m *ddlManager) executeDDL(ctx context.Context) error {
 
 	done, err := m.ddlSink.emitDDLEvent(ctx, m.executingDDL)
 	if err != nil {
+		if m.executingDDL.TableInfo != nil {
+			err = cerror.WithTable(err, m.executingDDL.TableInfo.TableName.String())
+		}
 		return errors.Trace(err)
 	}
 	if done {
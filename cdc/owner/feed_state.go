@@ -35,6 +35,8 @@ type ChangefeedState interface {
 	SetError(*model.RunningError)
 	// TakeProcessorErrors reuturns the error of the changefeed and clean the error.
 	TakeProcessorErrors() []*model.RunningError
+	// AppendErrorHistory records the error in the error history of the changefeed.
+	AppendErrorHistory(err *model.RunningError, maxSize int)
 	// CleanUpTaskPositions removes the task positions of the changefeed.
 	CleanUpTaskPositions()
 	// UpdateChangefeedState returns the task status of the changefeed.
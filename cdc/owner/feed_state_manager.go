@@ -21,6 +21,7 @@ import (
 	"github.com/pingcap/errors"
 	"github.com/pingcap/log"
 	"github.com/pingcap/tiflow/cdc/model"
+	"github.com/pingcap/tiflow/pkg/config"
 	cerrors "github.com/pingcap/tiflow/pkg/errors"
 	"github.com/pingcap/tiflow/pkg/upstream"
 	"github.com/pingcap/tiflow/pkg/util"
@@ -83,6 +84,8 @@ type feedStateManager struct {
 	lastWarningReportCheckpointTs model.Ts                    // checkpoint ts of last warning report
 	backoffInterval               time.Duration               // the interval for restarting a changefeed in 'error' state
 	errBackoff                    *backoff.ExponentialBackOff // an exponential backoff for restarting a changefeed
+	retryPolicy                   *config.ChangefeedRetryPolicy
+	retryCount                    int // number of continuous retries since the last reset
 
 	// resolvedTs and initCheckpointTs is for checking whether resolved timestamp
 	// has been advanced or not.
@@ -102,25 +105,45 @@ func NewFeedStateManager(up *upstream.Upstream,
 	m.state = state
 
 	m.errBackoff = backoff.NewExponentialBackOff()
-	m.errBackoff.InitialInterval = defaultBackoffInitInterval
-	m.errBackoff.MaxInterval = defaultBackoffMaxInterval
 	m.errBackoff.Multiplier = defaultBackoffMultiplier
 	m.errBackoff.RandomizationFactor = defaultBackoffRandomizationFactor
 	// backoff will stop once the defaultBackoffMaxElapsedTime has elapsed.
 	m.errBackoff.MaxElapsedTime = *state.GetChangefeedInfo().Config.ChangefeedErrorStuckDuration
 	m.changefeedErrorStuckDuration = *state.GetChangefeedInfo().Config.ChangefeedErrorStuckDuration
+	m.applyRetryPolicy(state.GetChangefeedInfo().Config.RetryPolicy)
 
 	m.resetErrRetry()
 	m.isRetrying = false
 	return m
 }
 
+// applyRetryPolicy applies the retry policy of the changefeed to the backoff.
+func (m *feedStateManager) applyRetryPolicy(policy *config.ChangefeedRetryPolicy) {
+	m.retryPolicy = policy
+	m.errBackoff.InitialInterval = defaultBackoffInitInterval
+	m.errBackoff.MaxInterval = defaultBackoffMaxInterval
+	if policy == nil {
+		return
+	}
+	if policy.BackoffInitInterval != nil {
+		m.errBackoff.InitialInterval = *policy.BackoffInitInterval
+	}
+	if policy.BackoffMaxInterval != nil {
+		m.errBackoff.MaxInterval = *policy.BackoffMaxInterval
+	}
+}
+
 func (m *feedStateManager) shouldRetry() bool {
 	// changefeed should not retry within [m.lastErrorRetryTime, m.lastErrorRetryTime + m.backoffInterval).
 	return time.Since(m.lastErrorRetryTime) >= m.backoffInterval
 }
 
 func (m *feedStateManager) shouldFailWhenRetry() bool {
+	// fail the changefeed if it has been retried too many times.
+	if m.retryPolicy != nil && m.retryPolicy.MaxRetries > 0 &&
+		m.retryCount >= m.retryPolicy.MaxRetries {
+		return true
+	}
 	// retry the changefeed
 	m.backoffInterval = m.errBackoff.NextBackOff()
 	// NextBackOff() will return -1 once the MaxElapsedTime has elapsed,
@@ -130,6 +153,7 @@ func (m *feedStateManager) shouldFailWhenRetry() bool {
 	}
 
 	m.lastErrorRetryTime = time.Now()
+	m.retryCount++
 	return false
 }
 
@@ -138,6 +162,7 @@ func (m *feedStateManager) resetErrRetry() {
 	m.errBackoff.Reset()
 	m.backoffInterval = m.errBackoff.NextBackOff()
 	m.lastErrorRetryTime = time.Unix(0, 0)
+	m.retryCount = 0
 }
 
 func (m *feedStateManager) Tick(resolvedTs model.Ts,
@@ -178,6 +203,7 @@ func (m *feedStateManager) Tick(resolvedTs model.Ts,
 			m.errBackoff.MaxElapsedTime = changefeedErrorStuckDuration
 			m.changefeedErrorStuckDuration = changefeedErrorStuckDuration
 		}
+		m.applyRetryPolicy(m.state.GetChangefeedInfo().Config.RetryPolicy)
 		return
 	}
 
@@ -206,6 +232,7 @@ func (m *feedStateManager) Tick(resolvedTs model.Ts,
 				zap.String("changefeed", m.state.GetID().ID),
 				zap.Time("lastRetryTime", m.lastErrorRetryTime),
 				zap.Uint64("lastRetryCheckpointTs", m.lastErrorRetryCheckpointTs),
+				zap.Int("retryCount", m.retryCount),
 			)
 			m.shouldBeRunning = false
 			m.patchState(model.StateFailed)
@@ -404,8 +431,8 @@ func (m *feedStateManager) HandleError(errs ...*model.RunningError) {
 	// if there are a fastFail error in errs, we can just fastFail the changefeed
 	// and no need to patch other error to the changefeed info
 	for _, err := range errs {
-		if cerrors.IsChangefeedGCFastFailErrorCode(errors.RFCErrorCode(err.Code)) ||
-			err.ShouldFailChangefeed() {
+		if m.isFatalError(err) {
+			m.recordErrorHistory(err)
 			m.state.SetError(err)
 			m.shouldBeRunning = false
 			m.patchState(model.StateFailed)
@@ -441,6 +468,7 @@ func (m *feedStateManager) HandleError(errs ...*model.RunningError) {
 		m.patchState(model.StatePending)
 
 		// patch the last error to changefeed info
+		m.recordErrorHistory(lastError)
 		m.state.SetError(lastError)
 
 		// The errBackoff needs to be reset before the first retry.
@@ -484,9 +512,28 @@ func (m *feedStateManager) HandleWarning(errs ...*model.RunningError) {
 	}
 
 	m.patchState(model.StateWarning)
+	m.recordErrorHistory(lastError)
 	m.state.SetWarning(lastError)
 }
 
+// isFatalError returns true if the error should fail the changefeed immediately.
+// The retry policy of the changefeed can override the default classification,
+// except errors related to GC which can't be recovered by retrying.
+func (m *feedStateManager) isFatalError(err *model.RunningError) bool {
+	if cerrors.IsChangefeedGCFastFailErrorCode(errors.RFCErrorCode(err.Code)) {
+		return true
+	}
+	if m.retryPolicy.IsFatalCode(err.Code) {
+		return true
+	}
+	return err.ShouldFailChangefeed() && !m.retryPolicy.IsRetryableCode(err.Code)
+}
+
+// recordErrorHistory records the error in the error history of the changefeed.
+func (m *feedStateManager) recordErrorHistory(err *model.RunningError) {
+	m.state.AppendErrorHistory(err, m.retryPolicy.GetErrorHistorySize())
+}
+
 // GenerateChangefeedEpoch generates a unique changefeed epoch.
 func GenerateChangefeedEpoch(ctx context.Context, pdClient pd.Client) uint64 {
 	phyTs, logical, err := pdClient.GetTS(ctx)
@@ -1166,3 +1166,80 @@ func TestUpdateChangefeedWithChangefeedErrorStuckDuration(t *testing.T) {
 	require.False(t, manager.ShouldRunning())
 	require.Equal(t, state.Info.State, model.StateFailed)
 }
+
+func TestHandleErrorWithRetryPolicy(t *testing.T) {
+	globalVars, changefeedInfo := vars.NewGlobalVarsAndChangefeedInfo4Test()
+	manager := newFeedStateManager4Test(200, 1600, 0, 1.0)
+	manager.applyRetryPolicy(&config.ChangefeedRetryPolicy{
+		MaxRetries:          2,
+		BackoffInitInterval: util.AddressOf(10 * time.Millisecond),
+		BackoffMaxInterval:  util.AddressOf(10 * time.Millisecond),
+		ErrorHistorySize:    2,
+	})
+	require.Equal(t, 10*time.Millisecond, manager.errBackoff.InitialInterval)
+	require.Equal(t, 10*time.Millisecond, manager.errBackoff.MaxInterval)
+	manager.resetErrRetry()
+
+	state := orchestrator.NewChangefeedReactorState(etcd.DefaultCDCClusterID,
+		model.DefaultChangeFeedID(changefeedInfo.ID))
+	tester := orchestrator.NewReactorStateTester(t, state, nil)
+	state.PatchInfo(func(info *model.ChangeFeedInfo) (*model.ChangeFeedInfo, bool, error) {
+		require.Nil(t, info)
+		return &model.ChangeFeedInfo{SinkURI: "123", Config: &config.ReplicaConfig{}}, true, nil
+	})
+	state.PatchStatus(func(status *model.ChangeFeedStatus) (*model.ChangeFeedStatus, bool, error) {
+		require.Nil(t, status)
+		return &model.ChangeFeedStatus{CheckpointTs: 200}, true, nil
+	})
+	tester.MustApplyPatches()
+	manager.state = state
+	manager.Tick(0, state.Status, state.Info)
+	tester.MustApplyPatches()
+
+	for i := 0; i < 3; i++ {
+		state.PatchTaskPosition(globalVars.CaptureInfo.ID,
+			func(position *model.TaskPosition) (*model.TaskPosition, bool, error) {
+				return &model.TaskPosition{Error: &model.RunningError{
+					Addr:    globalVars.CaptureInfo.AdvertiseAddr,
+					Code:    "CDC:ErrEtcdSessionDone",
+					Message: fmt.Sprintf("fake error %d for test", i),
+					Table:   "test.t",
+				}}, true, nil
+			})
+		tester.MustApplyPatches()
+		manager.Tick(0, state.Status, state.Info)
+		tester.MustApplyPatches()
+		require.Equal(t, model.StatePending, state.Info.State)
+		time.Sleep(20 * time.Millisecond)
+		manager.Tick(0, state.Status, state.Info)
+		tester.MustApplyPatches()
+	}
+	// the changefeed has been retried twice, it should be failed now.
+	require.Equal(t, model.StateFailed, state.Info.State)
+	require.False(t, manager.ShouldRunning())
+	// only the last 2 errors are kept
+	require.Len(t, state.Info.ErrorHistory, 2)
+	require.Equal(t, "fake error 1 for test", state.Info.ErrorHistory[0].Message)
+	require.Equal(t, "fake error 2 for test", state.Info.ErrorHistory[1].Message)
+	require.Equal(t, "test.t", state.Info.ErrorHistory[1].Table)
+}
+
+func TestErrorClassificationWithRetryPolicy(t *testing.T) {
+	manager := newFeedStateManager4Test(0, 0, 0, 0)
+	unretryable := &model.RunningError{Code: string(cerror.ErrSinkURIInvalid.RFCCode())}
+	gcErr := &model.RunningError{Code: string(cerror.ErrStartTsBeforeGC.RFCCode())}
+	retryable := &model.RunningError{Code: "CDC:ErrEtcdSessionDone"}
+
+	require.True(t, manager.isFatalError(unretryable))
+	require.True(t, manager.isFatalError(gcErr))
+	require.False(t, manager.isFatalError(retryable))
+
+	manager.applyRetryPolicy(&config.ChangefeedRetryPolicy{
+		RetryableErrorCodes: []string{unretryable.Code, gcErr.Code},
+		FatalErrorCodes:     []string{retryable.Code},
+	})
+	require.False(t, manager.isFatalError(unretryable))
+	// errors related to GC can't be retried
+	require.True(t, manager.isFatalError(gcErr))
+	require.True(t, manager.isFatalError(retryable))
+}
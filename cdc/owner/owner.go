@@ -141,6 +141,9 @@ type ownerImpl struct {
 	cfg *config.SchedulerConfig
 
 	globalVars *vars.GlobalVars
+
+	// alertCheckers are used to send alerts of changefeeds to webhooks.
+	alertCheckers map[model.ChangeFeedID]*alertChecker
 }
 
 var (
@@ -163,6 +166,7 @@ func NewOwner(
 		cfg:             cfg,
 		etcdClient:      globalVars.EtcdClient,
 		globalVars:      globalVars,
+		alertCheckers:   make(map[model.ChangeFeedID]*alertChecker),
 	}
 }
 
@@ -235,6 +239,7 @@ func (o *ownerImpl) Tick(stdCtx context.Context, rawState orchestrator.ReactorSt
 		}
 		checkpointTs, minTableBarrierTs := cfReactor.Tick(stdCtx, changefeedState.Info, changefeedState.Status, captures)
 		updateStatus(changefeedState, checkpointTs, minTableBarrierTs)
+		o.checkAlerts(changefeedID, cfReactor)
 	}
 	o.changefeedTicked = true
 
@@ -246,6 +251,10 @@ func (o *ownerImpl) Tick(stdCtx context.Context, rawState orchestrator.ReactorSt
 			}
 			reactor.Close(stdCtx)
 			delete(o.changefeeds, changefeedID)
+			if checker, ok := o.alertCheckers[changefeedID]; ok {
+				checker.closeNotifierAsync()
+				delete(o.alertCheckers, changefeedID)
+			}
 		}
 	}
 
@@ -254,6 +263,9 @@ func (o *ownerImpl) Tick(stdCtx context.Context, rawState orchestrator.ReactorSt
 		for _, reactor := range o.changefeeds {
 			reactor.Close(stdCtx)
 		}
+		for _, checker := range o.alertCheckers {
+			checker.closeNotifierAsync()
+		}
 		return state, cerror.ErrReactorFinished.GenWithStackByArgs()
 	}
 
@@ -263,6 +275,44 @@ func (o *ownerImpl) Tick(stdCtx context.Context, rawState orchestrator.ReactorSt
 	return state, nil
 }
 
+// checkAlerts checks alerts of the changefeed and sends them to webhooks.
+func (o *ownerImpl) checkAlerts(changefeedID model.ChangeFeedID, cfReactor *changefeed) {
+	cfg := getAlertConfig(cfReactor.latestInfo)
+	checker, ok := o.alertCheckers[changefeedID]
+	if !cfg.Enabled() {
+		if ok {
+			checker.closeNotifier()
+		}
+		return
+	}
+	if !ok {
+		checker = newAlertChecker(changefeedID)
+		o.alertCheckers[changefeedID] = checker
+	}
+	var executingDDL *model.DDLEvent
+	if cfReactor.ddlManager != nil {
+		executingDDL = cfReactor.ddlManager.executingDDL
+	}
+	alerts := checker.check(cfg, cfReactor.latestInfo, cfReactor.latestStatus,
+		executingDDL, time.Now(), cfReactor.upstream.PDClock.CurrentTime())
+	for _, a := range alerts {
+		log.Info("changefeed alert fired",
+			zap.String("namespace", changefeedID.Namespace),
+			zap.String("changefeed", changefeedID.ID),
+			zap.String("kind", string(a.Kind)),
+			zap.String("message", a.Message))
+		if err := checker.notify(cfg, a); err != nil {
+			log.Warn("fail to create alert notifier", zap.Error(err))
+			break
+		}
+	}
+	// the notifier of a stopped changefeed is closed once its alerts are sent,
+	// it's created again once the changefeed fires new alerts.
+	if isStoppedState(cfReactor.latestInfo) {
+		checker.closeNotifier()
+	}
+}
+
 // preflightCheck makes sure that the metadata in Etcd is complete enough to run the tick.
 // If the metadata is not complete, such as when the ChangeFeedStatus is nil,
 // this function will reconstruct the lost metadata and skip this tick.
@@ -480,6 +530,8 @@ func (o *ownerImpl) Bootstrap(state *orchestrator.GlobalReactorState) {
 }
 
 // fixChangefeedInfos attempts to fix incompatible or incorrect meta information in changefeed state.
+// The infos are always saved back, so the plaintext secrets and the secrets encrypted by
+// the rotated keys are encrypted by the current secret key.
 func fixChangefeedInfos(state *orchestrator.GlobalReactorState) {
 	for _, changefeedState := range state.Changefeeds {
 		if changefeedState != nil {
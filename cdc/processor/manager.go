@@ -22,11 +22,13 @@ import (
 	"github.com/pingcap/errors"
 	"github.com/pingcap/failpoint"
 	"github.com/pingcap/log"
+	"github.com/pingcap/tiflow/cdc/entry"
 	"github.com/pingcap/tiflow/cdc/model"
 	"github.com/pingcap/tiflow/cdc/vars"
 	"github.com/pingcap/tiflow/pkg/config"
 	cerror "github.com/pingcap/tiflow/pkg/errors"
 	"github.com/pingcap/tiflow/pkg/etcd"
+	"github.com/pingcap/tiflow/pkg/integrity"
 	"github.com/pingcap/tiflow/pkg/orchestrator"
 	"github.com/pingcap/tiflow/pkg/upstream"
 	"github.com/prometheus/client_golang/prometheus"
@@ -38,9 +40,29 @@ type commandTp int
 const (
 	commandTpUnknown commandTp = iota
 	commandTpWriteDebugInfo
+	commandTpQueryLatency
+	commandTpQueryHotspots
 	processorLogsWarnDuration = 1 * time.Second
 )
 
+// LatencyQuery queries the latency breakdown of the processor of a changefeed.
+type LatencyQuery struct {
+	ChangefeedID model.ChangeFeedID
+	// SlowRegionLimit is the max number of the slowest regions returned.
+	SlowRegionLimit int
+
+	Resp *model.ProcessorLatency
+}
+
+// HotspotQuery queries the hottest tables and regions of the processor of a changefeed.
+type HotspotQuery struct {
+	ChangefeedID model.ChangeFeedID
+	// Limit is the max number of the hottest tables and regions returned.
+	Limit int
+
+	Resp *model.ProcessorHotspots
+}
+
 type command struct {
 	tp      commandTp
 	payload interface{}
@@ -56,6 +78,14 @@ type Manager interface {
 	Close()
 
 	WriteDebugInfo(ctx context.Context, w io.Writer, done chan<- error)
+
+	// QueryLatency queries the latency breakdown of a processor,
+	// `done` is closed upon the query completion.
+	QueryLatency(ctx context.Context, query *LatencyQuery, done chan<- error)
+
+	// QueryHotspots queries the hottest tables and regions of a processor,
+	// `done` is closed upon the query completion.
+	QueryHotspots(ctx context.Context, query *HotspotQuery, done chan<- error)
 }
 
 // managerImpl is a manager of processor, which maintains the state and behavior of processors
@@ -80,6 +110,9 @@ type managerImpl struct {
 	) *processor
 	cfg        *config.SchedulerConfig
 	globalVars *vars.GlobalVars
+	// changefeeds are the existing changefeeds of the last tick, it's used
+	// to remove the schema caches of the removed changefeeds.
+	changefeeds map[model.ChangeFeedID]struct{}
 
 	metricProcessorCloseDuration prometheus.Observer
 }
@@ -175,6 +208,13 @@ func (m *managerImpl) Tick(stdCtx context.Context, state orchestrator.ReactorSta
 			}
 		}
 	}
+	// the corrupted keys are kept after the processor is closed, drop
+	// them once the changefeed is removed.
+	integrity.RetainCorruptedKeys(func(namespace, id string) bool {
+		changefeed, ok := globalState.Changefeeds[model.ChangeFeedID{Namespace: namespace, ID: id}]
+		return ok && changefeed.Info != nil
+	})
+	m.retainSchemaCaches(globalState)
 
 	if err := m.upstreamManager.Tick(stdCtx, globalState); err != nil {
 		return state, errors.Trace(err)
@@ -240,6 +280,7 @@ func patchProcessorErr(captureInfo *model.CaptureInfo,
 				Addr:    captureInfo.AdvertiseAddr,
 				Code:    code,
 				Message: err.Error(),
+				Table:   cerror.TableOf(err),
 			}
 			return position, true, nil
 		})
@@ -272,11 +313,41 @@ func patchProcessorWarning(captureInfo *model.CaptureInfo,
 				Addr:    captureInfo.AdvertiseAddr,
 				Code:    code,
 				Message: err.Error(),
+				Table:   cerror.TableOf(err),
 			}
 			return position, true, nil
 		})
 }
 
+// retainSchemaCaches removes the schema caches of the removed changefeeds.
+// The caches of the changefeeds removed while the capture is offline are
+// removed at the first tick.
+func (m *managerImpl) retainSchemaCaches(globalState *orchestrator.GlobalReactorState) {
+	dataDir := config.GetGlobalServerConfig().DataDir
+	if dataDir == "" {
+		return
+	}
+	changefeeds := make(map[model.ChangeFeedID]struct{}, len(globalState.Changefeeds))
+	for id, changefeed := range globalState.Changefeeds {
+		if changefeed.Info != nil {
+			changefeeds[id] = struct{}{}
+		}
+	}
+	if m.changefeeds == nil {
+		entry.RetainSchemaCaches(dataDir, func(id model.ChangeFeedID) bool {
+			_, ok := changefeeds[id]
+			return ok
+		})
+	} else {
+		for id := range m.changefeeds {
+			if _, ok := changefeeds[id]; !ok {
+				entry.RemoveSchemaCache(dataDir, id)
+			}
+		}
+	}
+	m.changefeeds = changefeeds
+}
+
 func (m *managerImpl) closeProcessor(changefeedID model.ChangeFeedID) {
 	processor, exist := m.processors[changefeedID]
 	if exist {
@@ -321,6 +392,26 @@ func (m *managerImpl) WriteDebugInfo(
 	}
 }
 
+// QueryLatency queries the latency breakdown of a processor.
+func (m *managerImpl) QueryLatency(
+	ctx context.Context, query *LatencyQuery, done chan<- error,
+) {
+	err := m.sendCommand(ctx, commandTpQueryLatency, query, done)
+	if err != nil {
+		log.Warn("send command commandTpQueryLatency failed", zap.Error(err))
+	}
+}
+
+// QueryHotspots queries the hottest tables and regions of a processor.
+func (m *managerImpl) QueryHotspots(
+	ctx context.Context, query *HotspotQuery, done chan<- error,
+) {
+	err := m.sendCommand(ctx, commandTpQueryHotspots, query, done)
+	if err != nil {
+		log.Warn("send command commandTpQueryHotspots failed", zap.Error(err))
+	}
+}
+
 // sendCommands sends command to manager.
 // `done` is closed upon command completion or sendCommand returns error.
 func (m *managerImpl) sendCommand(
@@ -352,6 +443,22 @@ func (m *managerImpl) handleCommand() {
 		if err != nil {
 			cmd.done <- err
 		}
+	case commandTpQueryLatency:
+		query := cmd.payload.(*LatencyQuery)
+		p, ok := m.processors[query.ChangefeedID]
+		if !ok {
+			cmd.done <- cerror.ErrProcessorNotFound.GenWithStackByArgs(query.ChangefeedID.String())
+			return
+		}
+		query.Resp = p.latency(query.SlowRegionLimit)
+	case commandTpQueryHotspots:
+		query := cmd.payload.(*HotspotQuery)
+		p, ok := m.processors[query.ChangefeedID]
+		if !ok {
+			cmd.done <- cerror.ErrProcessorNotFound.GenWithStackByArgs(query.ChangefeedID.String())
+			return
+		}
+		query.Resp = p.hotspots(query.Limit)
 	default:
 		log.Warn("Unknown command in processor manager", zap.Any("command", cmd))
 	}
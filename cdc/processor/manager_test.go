@@ -18,6 +18,8 @@ import (
 	"context"
 	"fmt"
 	"math"
+	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
@@ -303,3 +305,45 @@ func TestManagerLiveness(t *testing.T) {
 	s.liveness.Store(model.LivenessCaptureStopping)
 	require.Equal(t, model.LivenessCaptureStopping, p.liveness.Load())
 }
+
+func TestManagerRemoveSchemaCaches(t *testing.T) {
+	dataDir := t.TempDir()
+	oldConfig := config.GetGlobalServerConfig()
+	serverConfig := oldConfig.Clone()
+	serverConfig.DataDir = dataDir
+	config.StoreGlobalServerConfig(serverConfig)
+	defer config.StoreGlobalServerConfig(oldConfig)
+
+	removed := model.DefaultChangeFeedID("removed")
+	kept := model.DefaultChangeFeedID("kept")
+	orphan := model.DefaultChangeFeedID("orphan")
+	cacheDir := func(id model.ChangeFeedID) string {
+		return filepath.Join(dataDir, config.DefaultSchemaCacheDir, id.Namespace, id.ID)
+	}
+	for _, id := range []model.ChangeFeedID{removed, kept, orphan} {
+		require.NoError(t, os.MkdirAll(cacheDir(id), 0o755))
+	}
+	exists := func(id model.ChangeFeedID) bool {
+		_, err := os.Stat(cacheDir(id))
+		return err == nil
+	}
+
+	m := &managerImpl{}
+	state := orchestrator.NewGlobalStateForTest(etcd.DefaultCDCClusterID)
+	for _, id := range []model.ChangeFeedID{removed, kept} {
+		state.Changefeeds[id] = orchestrator.NewChangefeedReactorState(etcd.DefaultCDCClusterID, id)
+		state.Changefeeds[id].Info = &model.ChangeFeedInfo{}
+	}
+
+	// the caches of the changefeeds not existing are removed at the first tick.
+	m.retainSchemaCaches(state)
+	require.True(t, exists(removed))
+	require.True(t, exists(kept))
+	require.False(t, exists(orphan))
+
+	// the cache is removed after the changefeed is removed.
+	delete(state.Changefeeds, removed)
+	m.retainSchemaCaches(state)
+	require.False(t, exists(removed))
+	require.True(t, exists(kept))
+}
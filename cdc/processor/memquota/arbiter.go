@@ -0,0 +1,186 @@
+// Copyright 2024 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package memquota
+
+import (
+	"math"
+	"sort"
+	"sync"
+	"sync/atomic"
+
+	"github.com/pingcap/log"
+	"github.com/pingcap/tiflow/pkg/config"
+	"github.com/pingcap/tiflow/pkg/util"
+	"go.uber.org/zap"
+)
+
+// lowPriorityBudgetPercentage is the percentage of the capture memory budget
+// that low priority changefeeds can use. Once the total usage of the capture
+// exceeds it, low priority changefeeds are throttled so that the remaining
+// budget is left to normal and high priority changefeeds.
+const lowPriorityBudgetPercentage = 70
+
+// Arbiter arbitrates memory quotas of all changefeeds on one capture.
+// Each changefeed still has its own MemQuota, the arbiter only decides
+// whether an acquisition is allowed under the capture level budget
+// according to the priority of the changefeed.
+//
+// The capture level usage is accounted by a single atomic counter which is
+// updated by every acquisition and release of the attached quotas. Quotas
+// blocked by the arbiter are recorded as waiters, and only they are woken up,
+// in the order of their priorities, when the memory is released.
+type Arbiter struct {
+	// totalBytes is the memory budget of the capture, 0 means no limit.
+	totalBytes uint64
+	// usedBytes is the total used bytes of all attached quotas.
+	usedBytes atomic.Uint64
+
+	// waiterCount is the number of waiters, it avoids locking mu on releases
+	// if there is no waiter.
+	waiterCount atomic.Int64
+	mu          sync.Mutex
+	// waiters are the quotas whose acquisitions are blocked by the arbiter.
+	waiters map[*MemQuota]struct{}
+}
+
+// NewArbiter creates an Arbiter with the given capture level budget.
+func NewArbiter(totalBytes uint64) *Arbiter {
+	return &Arbiter{
+		totalBytes: totalBytes,
+		waiters:    make(map[*MemQuota]struct{}),
+	}
+}
+
+// NewArbiterFromMemoryPercentage creates an Arbiter whose budget is the given
+// percentage of the memory limit of current process. The arbitration is
+// disabled if percentage is 0 or the memory limit can't be detected.
+func NewArbiterFromMemoryPercentage(percentage int) *Arbiter {
+	if percentage <= 0 {
+		return NewArbiter(0)
+	}
+	totalMemory, err := util.GetMemoryLimit()
+	if err != nil {
+		log.Warn("fail to get memory limit, capture memory arbitration is disabled",
+			zap.Error(err))
+		return NewArbiter(0)
+	}
+	budget := totalMemory / 100 * uint64(percentage)
+	log.Info("capture memory arbitration is enabled",
+		zap.Int("maxMemoryPercentage", percentage),
+		zap.Uint64("budget", budget))
+	return NewArbiter(budget)
+}
+
+// Enabled returns true if the capture level budget is set.
+func (a *Arbiter) Enabled() bool {
+	return a != nil && a.totalBytes > 0
+}
+
+// GetUsedBytes returns the total used bytes of all attached quotas.
+func (a *Arbiter) GetUsedBytes() uint64 {
+	return a.usedBytes.Load()
+}
+
+// tryAcquire acquires nBytes if a changefeed with the given priority
+// is allowed to use them under the capture level budget.
+func (a *Arbiter) tryAcquire(priority config.ChangefeedPriority, nBytes uint64) bool {
+	limit := a.limitOf(priority)
+	for {
+		usedBytes := a.usedBytes.Load()
+		if limit != math.MaxUint64 && usedBytes+nBytes > limit {
+			return false
+		}
+		if a.usedBytes.CompareAndSwap(usedBytes, usedBytes+nBytes) {
+			return true
+		}
+	}
+}
+
+// forceAcquire acquires nBytes regardless of the budget.
+func (a *Arbiter) forceAcquire(nBytes uint64) {
+	a.usedBytes.Add(nBytes)
+}
+
+// release returns nBytes, the caller must call wakeUpWaiters afterwards
+// without holding any lock of the quotas.
+func (a *Arbiter) release(nBytes uint64) {
+	// Note that "usedBytes.Add(^(nBytes - 1))" means "usedBytes.Sub(nBytes)".
+	a.usedBytes.Add(^(nBytes - 1))
+}
+
+// wakeUpWaiters wakes up the waiters which may be unblocked.
+func (a *Arbiter) wakeUpWaiters() {
+	if a.waiterCount.Load() == 0 {
+		return
+	}
+
+	usedBytes := a.usedBytes.Load()
+	a.mu.Lock()
+	var woken []*MemQuota
+	for q := range a.waiters {
+		if usedBytes < a.limitOf(q.priority) {
+			woken = append(woken, q)
+			delete(a.waiters, q)
+		}
+	}
+	a.waiterCount.Store(int64(len(a.waiters)))
+	a.mu.Unlock()
+
+	// Wake up the waiters out of mu, because a waiter holds its own lock when
+	// it's added, and the higher priority ones go first.
+	sort.Slice(woken, func(i, j int) bool {
+		return woken[i].priority.Rank() > woken[j].priority.Rank()
+	})
+	for _, q := range woken {
+		q.wakeUp()
+	}
+}
+
+// limitOf returns the capture level usage above which changefeeds with the
+// given priority are throttled.
+func (a *Arbiter) limitOf(priority config.ChangefeedPriority) uint64 {
+	if !a.Enabled() {
+		return math.MaxUint64
+	}
+	switch priority.Normalize() {
+	case config.ChangefeedPriorityHigh:
+		return math.MaxUint64
+	case config.ChangefeedPriorityLow:
+		return a.totalBytes / 100 * lowPriorityBudgetPercentage
+	default:
+		return a.totalBytes
+	}
+}
+
+// addWaiter records that the acquisition of q is blocked by the arbiter,
+// it returns false if q is a waiter already.
+func (a *Arbiter) addWaiter(q *MemQuota) bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if _, ok := a.waiters[q]; ok {
+		return false
+	}
+	a.waiters[q] = struct{}{}
+	a.waiterCount.Store(int64(len(a.waiters)))
+	return true
+}
+
+// unregister removes q and returns its remaining used bytes to the arbiter.
+func (a *Arbiter) unregister(q *MemQuota, usedBytes uint64) {
+	a.mu.Lock()
+	delete(a.waiters, q)
+	a.waiterCount.Store(int64(len(a.waiters)))
+	a.mu.Unlock()
+	a.release(usedBytes)
+}
@@ -0,0 +1,154 @@
+// Copyright 2024 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package memquota
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pingcap/tiflow/cdc/model"
+	"github.com/pingcap/tiflow/pkg/config"
+	"github.com/stretchr/testify/require"
+)
+
+func TestArbiterDisabled(t *testing.T) {
+	t.Parallel()
+
+	a := NewArbiter(0)
+	require.False(t, a.Enabled())
+	m := NewMemQuota(model.DefaultChangeFeedID("1"), 100, "")
+	m.AttachArbiter(a, config.ChangefeedPriorityLow)
+	defer m.Close()
+
+	require.True(t, m.TryAcquire(100))
+	require.Equal(t, uint64(100), a.GetUsedBytes())
+
+	var nilArbiter *Arbiter
+	require.False(t, nilArbiter.Enabled())
+}
+
+func TestArbiterThrottleByPriority(t *testing.T) {
+	t.Parallel()
+
+	a := NewArbiter(1000)
+	low := NewMemQuota(model.DefaultChangeFeedID("low"), 1000, "")
+	low.AttachArbiter(a, config.ChangefeedPriorityLow)
+	defer low.Close()
+	normal := NewMemQuota(model.DefaultChangeFeedID("normal"), 1000, "")
+	normal.AttachArbiter(a, "")
+	defer normal.Close()
+	high := NewMemQuota(model.DefaultChangeFeedID("high"), 1000, "")
+	high.AttachArbiter(a, config.ChangefeedPriorityHigh)
+	defer high.Close()
+
+	// Low priority changefeeds can use at most 70% of the budget.
+	require.True(t, low.TryAcquire(600))
+	require.True(t, low.TryAcquire(100))
+	require.False(t, low.TryAcquire(1))
+
+	// Normal priority changefeeds can use the whole budget.
+	require.True(t, normal.TryAcquire(300))
+	require.False(t, normal.TryAcquire(1))
+
+	// High priority changefeeds are only limited by their own quota.
+	require.True(t, high.TryAcquire(500))
+	require.Equal(t, uint64(1500), a.GetUsedBytes())
+
+	high.Refund(500)
+	normal.Refund(300)
+	require.False(t, low.TryAcquire(1))
+	low.Refund(100)
+	require.True(t, low.TryAcquire(100))
+}
+
+func TestArbiterWakeUpBlockAcquire(t *testing.T) {
+	t.Parallel()
+
+	a := NewArbiter(100)
+	low := NewMemQuota(model.DefaultChangeFeedID("low"), 100, "")
+	low.AttachArbiter(a, config.ChangefeedPriorityLow)
+	defer low.Close()
+	normal := NewMemQuota(model.DefaultChangeFeedID("normal"), 100, "")
+	normal.AttachArbiter(a, config.ChangefeedPriorityNormal)
+	defer normal.Close()
+
+	require.True(t, normal.TryAcquire(80))
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		// It's blocked by the arbiter rather than its own quota.
+		require.NoError(t, low.BlockAcquire(50))
+	}()
+	require.Eventually(t, func() bool {
+		return a.waiterCount.Load() == 1
+	}, 5*time.Second, 10*time.Millisecond)
+	normal.Refund(80)
+	wg.Wait()
+	require.Equal(t, uint64(50), a.GetUsedBytes())
+	require.Equal(t, int64(0), a.waiterCount.Load())
+}
+
+func TestArbiterConcurrentAccounting(t *testing.T) {
+	t.Parallel()
+
+	a := NewArbiter(1000)
+	quotas := make([]*MemQuota, 0, 4)
+	for i := 0; i < 4; i++ {
+		m := NewMemQuota(model.DefaultChangeFeedID(fmt.Sprintf("%d", i)), 600, "")
+		m.AttachArbiter(a, config.ChangefeedPriorityNormal)
+		defer m.Close()
+		quotas = append(quotas, m)
+	}
+
+	var wg sync.WaitGroup
+	for _, m := range quotas {
+		for j := 0; j < 4; j++ {
+			wg.Add(1)
+			go func(m *MemQuota) {
+				defer wg.Done()
+				for k := 0; k < 1000; k++ {
+					if k%2 == 0 {
+						require.NoError(t, m.BlockAcquire(100))
+						m.Refund(100)
+					} else if m.TryAcquire(100) {
+						m.Refund(100)
+					}
+					// the budget is never exceeded by the concurrent acquisitions.
+					require.LessOrEqual(t, a.GetUsedBytes(), uint64(1000))
+				}
+			}(m)
+		}
+	}
+	wg.Wait()
+	require.Equal(t, uint64(0), a.GetUsedBytes())
+	for _, m := range quotas {
+		require.Equal(t, uint64(0), m.GetUsedBytes())
+	}
+}
+
+func TestArbiterUnregisterOnClose(t *testing.T) {
+	t.Parallel()
+
+	a := NewArbiter(100)
+	m := NewMemQuota(model.DefaultChangeFeedID("1"), 100, "")
+	m.AttachArbiter(a, config.ChangefeedPriorityNormal)
+	require.True(t, m.TryAcquire(100))
+	require.Equal(t, uint64(100), a.GetUsedBytes())
+	m.Close()
+	require.Equal(t, uint64(0), a.GetUsedBytes())
+}
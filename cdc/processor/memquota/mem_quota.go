@@ -23,11 +23,20 @@ import (
 	"github.com/pingcap/log"
 	"github.com/pingcap/tiflow/cdc/model"
 	"github.com/pingcap/tiflow/cdc/processor/tablepb"
+	"github.com/pingcap/tiflow/pkg/config"
 	"github.com/pingcap/tiflow/pkg/spanz"
 	"github.com/prometheus/client_golang/prometheus"
 	"go.uber.org/zap"
 )
 
+const (
+	// spillHighWatermark and spillLowWatermark are the ratios of the quota,
+	// the pending messages of the sink start to be spilled once the bytes in
+	// memory exceed the high watermark, and stop once they're below the low one.
+	spillHighWatermark = 0.8
+	spillLowWatermark  = 0.6
+)
+
 // MemConsumeRecord is used to trace memory usage.
 type MemConsumeRecord struct {
 	ResolvedTs model.ResolvedTs
@@ -42,6 +51,11 @@ type MemQuota struct {
 
 	// usedBytes is the memory usage of one changefeed.
 	usedBytes atomic.Uint64
+	// spilledBytes is the part of usedBytes spilled to the local disk by the sink,
+	// which isn't counted as the memory in use until it's read back.
+	spilledBytes atomic.Uint64
+	// spilling indicates whether the sink should spill the pending messages.
+	spilling atomic.Bool
 
 	// isClosed is used to indicate whether the mem quota is closed.
 	isClosed atomic.Bool
@@ -52,9 +66,20 @@ type MemQuota struct {
 
 	// blockAcquireCond is used to notify the blocked acquire.
 	blockAcquireCond *sync.Cond
+	// waiting is the number of blocked acquisitions, it avoids locking
+	// blockAcquireCond on releases if there is no blocked acquisition.
+	waiting atomic.Int64
 
-	metricTotal prometheus.Gauge
-	metricUsed  prometheus.Gauge
+	// arbiterMu synchronizes the accounting of the arbiter with detaching
+	// it on close, so the arbiter is updated exactly once for every byte.
+	arbiterMu sync.RWMutex
+	// arbiter is the capture level arbiter, it can be nil.
+	arbiter  *Arbiter
+	priority config.ChangefeedPriority
+
+	metricTotal     prometheus.Gauge
+	metricUsed      prometheus.Gauge
+	metricThrottled prometheus.Counter
 
 	// mu protects the following fields.
 	mu sync.Mutex
@@ -72,6 +97,8 @@ func NewMemQuota(changefeedID model.ChangeFeedID, totalBytes uint64, comp string
 			changefeedID.ID, "total", comp),
 		metricUsed: MemoryQuota.WithLabelValues(changefeedID.Namespace,
 			changefeedID.ID, "used", comp),
+		metricThrottled: MemoryQuotaThrottledCount.WithLabelValues(changefeedID.Namespace,
+			changefeedID.ID, comp),
 		closeBg: make(chan struct{}, 1),
 
 		tableMemory: spanz.NewHashMap[[]*MemConsumeRecord](),
@@ -91,6 +118,7 @@ func NewMemQuota(changefeedID model.ChangeFeedID, totalBytes uint64, comp string
 			timer.Stop()
 			MemoryQuota.DeleteLabelValues(changefeedID.Namespace, changefeedID.ID, "total", comp)
 			MemoryQuota.DeleteLabelValues(changefeedID.Namespace, changefeedID.ID, "used", comp)
+			MemoryQuotaThrottledCount.DeleteLabelValues(changefeedID.Namespace, changefeedID.ID, comp)
 		}()
 		for {
 			select {
@@ -106,43 +134,103 @@ func NewMemQuota(changefeedID model.ChangeFeedID, totalBytes uint64, comp string
 	return m
 }
 
+// AttachArbiter registers the quota to a capture level arbiter. After that,
+// acquisitions are throttled according to the priority when the capture
+// is under memory pressure. It must be called before any acquisition.
+func (m *MemQuota) AttachArbiter(arbiter *Arbiter, priority config.ChangefeedPriority) {
+	if arbiter == nil {
+		return
+	}
+	m.arbiter = arbiter
+	m.priority = priority.Normalize()
+	log.Info("Memory quota is attached to capture arbiter",
+		zap.String("namespace", m.changefeedID.Namespace),
+		zap.String("changefeed", m.changefeedID.ID),
+		zap.String("priority", string(m.priority)))
+}
+
 // TryAcquire returns true if the memory quota is available, otherwise returns false.
 func (m *MemQuota) TryAcquire(nBytes uint64) bool {
+	ok, throttled := m.tryAcquire(nBytes)
+	// The blocked acquisitions may be rejected by the bytes given back.
+	if throttled && m.waiting.Load() > 0 {
+		m.wakeUp()
+	}
+	return ok
+}
+
+// tryAcquire acquires nBytes from the quota and then the arbiter, throttled
+// is true if it's rejected by the arbiter, in which case the bytes acquired
+// from the quota are given back without waking up any acquisition.
+func (m *MemQuota) tryAcquire(nBytes uint64) (ok bool, throttled bool) {
+	m.arbiterMu.RLock()
+	defer m.arbiterMu.RUnlock()
 	for {
 		usedBytes := m.usedBytes.Load()
-		if usedBytes+nBytes > m.totalBytes {
-			return false
+		if m.inMemoryBytes(usedBytes)+nBytes > m.totalBytes {
+			return false, false
 		}
 		if m.usedBytes.CompareAndSwap(usedBytes, usedBytes+nBytes) {
-			return true
+			break
 		}
 	}
+	if m.arbiter == nil || m.arbiter.tryAcquire(m.priority, nBytes) {
+		return true, false
+	}
+	m.usedBytes.Add(^(nBytes - 1))
+	m.metricThrottled.Inc()
+	return false, true
 }
 
 // ForceAcquire is used to force acquire the memory quota.
 func (m *MemQuota) ForceAcquire(nBytes uint64) {
+	m.arbiterMu.RLock()
+	defer m.arbiterMu.RUnlock()
 	m.usedBytes.Add(nBytes)
+	if m.arbiter != nil {
+		m.arbiter.forceAcquire(nBytes)
+	}
 }
 
 // BlockAcquire is used to block the request when the memory quota is not available.
 func (m *MemQuota) BlockAcquire(nBytes uint64) error {
+	m.blockAcquireCond.L.Lock()
+	defer m.blockAcquireCond.L.Unlock()
+	// waiting must be increased before the acquisition is tried,
+	// so a concurrent release either is seen or wakes up it.
+	m.waiting.Add(1)
+	defer m.waiting.Add(-1)
 	for {
 		if m.isClosed.Load() {
 			return context.Canceled
 		}
-		usedBytes := m.usedBytes.Load()
-		if usedBytes+nBytes > m.totalBytes {
-			m.blockAcquireCond.L.Lock()
-			m.blockAcquireCond.Wait()
-			m.blockAcquireCond.L.Unlock()
-			continue
-		}
-		if m.usedBytes.CompareAndSwap(usedBytes, usedBytes+nBytes) {
+		ok, throttled := m.tryAcquire(nBytes)
+		if ok {
 			return nil
 		}
+		// Try again once it's recorded as a waiter of the arbiter,
+		// because the memory may be released before that.
+		if throttled && m.addArbiterWaiter() {
+			continue
+		}
+		m.blockAcquireCond.Wait()
 	}
 }
 
+// addArbiterWaiter records the quota as a waiter of the arbiter.
+func (m *MemQuota) addArbiterWaiter() bool {
+	m.arbiterMu.RLock()
+	defer m.arbiterMu.RUnlock()
+	return m.arbiter != nil && m.arbiter.addWaiter(m)
+}
+
+// wakeUp wakes up the blocked acquisitions.
+func (m *MemQuota) wakeUp() {
+	m.blockAcquireCond.L.Lock()
+	m.blockAcquireCond.Broadcast()
+	m.blockAcquireCond.L.Unlock()
+}
+
 // Refund directly release the memory quota.
 func (m *MemQuota) Refund(nBytes uint64) {
 	if nBytes == 0 {
@@ -153,9 +241,7 @@ func (m *MemQuota) Refund(nBytes uint64) {
 		log.Panic("MemQuota.refund fail",
 			zap.Uint64("used", usedBytes), zap.Uint64("refund", nBytes))
 	}
-	if m.usedBytes.Add(^(nBytes - 1)) < m.totalBytes {
-		m.blockAcquireCond.Broadcast()
-	}
+	m.releaseBytes(nBytes)
 }
 
 // AddTable adds a table into the quota.
@@ -180,11 +266,7 @@ func (m *MemQuota) Record(span tablepb.Span, resolved model.ResolvedTs, nBytes u
 				zap.Uint64("used", usedBytes), zap.Uint64("record", nBytes))
 		}
 		// If we cannot find the table, then the previous acquired memory quota needed to be returned.
-		// Note that "usedBytes.Add(^(nBytes - 1))" means "usedBytes.Sub(nBytes)". But atomic don't
-		// have Sub method.
-		if m.usedBytes.Add(^(nBytes - 1)) < m.totalBytes {
-			m.blockAcquireCond.Broadcast()
-		}
+		m.releaseBytes(nBytes)
 		return
 	}
 	m.tableMemory.ReplaceOrInsert(span, append(m.tableMemory.GetV(span), &MemConsumeRecord{
@@ -223,9 +305,7 @@ func (m *MemQuota) Release(span tablepb.Span, resolved model.ResolvedTs) {
 		log.Panic("MemQuota.release fail",
 			zap.Uint64("used", usedBytes), zap.Uint64("release", toRelease))
 	}
-	if m.usedBytes.Add(^(toRelease - 1)) < m.totalBytes {
-		m.blockAcquireCond.Broadcast()
-	}
+	m.releaseBytes(toRelease)
 }
 
 // RemoveTable clears all records of the table and remove the table.
@@ -264,16 +344,24 @@ func (m *MemQuota) clear(span tablepb.Span) uint64 {
 		cleaned += record.Size
 	}
 
-	if m.usedBytes.Add(^(cleaned - 1)) < m.totalBytes {
-		m.blockAcquireCond.Broadcast()
-	}
+	m.releaseBytes(cleaned)
 	return cleaned
 }
 
 // Close the mem quota and notify the blocked acquire.
 func (m *MemQuota) Close() {
 	if m.isClosed.CompareAndSwap(false, true) {
-		m.blockAcquireCond.Broadcast()
+		m.arbiterMu.Lock()
+		arbiter := m.arbiter
+		if arbiter != nil {
+			arbiter.unregister(m, m.usedBytes.Load())
+			m.arbiter = nil
+		}
+		m.arbiterMu.Unlock()
+		if arbiter != nil {
+			arbiter.wakeUpWaiters()
+		}
+		m.wakeUp()
 		close(m.closeBg)
 		m.wg.Wait()
 	}
@@ -284,7 +372,80 @@ func (m *MemQuota) GetUsedBytes() uint64 {
 	return m.usedBytes.Load()
 }
 
+// ShouldSpill returns true if the sink should spill the pending messages to
+// the local disk. It starts to return true once the bytes in memory exceed the
+// high watermark, and keeps returning true until they're below the low watermark,
+// so the sink doesn't switch between spilling and not spilling frequently.
+func (m *MemQuota) ShouldSpill() bool {
+	inMemory := m.inMemoryBytes(m.usedBytes.Load())
+	if m.spilling.Load() {
+		if inMemory < uint64(float64(m.totalBytes)*spillLowWatermark) {
+			m.spilling.Store(false)
+		}
+	} else if inMemory > uint64(float64(m.totalBytes)*spillHighWatermark) {
+		m.spilling.Store(true)
+	}
+	return m.spilling.Load()
+}
+
+// Spill transfers nBytes of the messages spilled by the sink out of the memory
+// in use, so the following acquisitions aren't blocked by them. The arbiter
+// still accounts them, so the memory of the capture is never overcommitted.
+func (m *MemQuota) Spill(nBytes uint64) {
+	if nBytes == 0 {
+		return
+	}
+	m.spilledBytes.Add(nBytes)
+	if m.waiting.Load() > 0 {
+		m.wakeUp()
+	}
+}
+
+// Unspill transfers nBytes of the messages read back by the sink into the memory in use.
+func (m *MemQuota) Unspill(nBytes uint64) {
+	if nBytes == 0 {
+		return
+	}
+	m.spilledBytes.Add(^(nBytes - 1))
+}
+
+// inMemoryBytes returns the bytes of usedBytes which are not spilled. The spilled
+// bytes may exceed the used ones if the table is removed before they're read back.
+func (m *MemQuota) inMemoryBytes(usedBytes uint64) uint64 {
+	spilledBytes := m.spilledBytes.Load()
+	if spilledBytes >= usedBytes {
+		return 0
+	}
+	return usedBytes - spilledBytes
+}
+
 // hasAvailable returns true if the memory quota is available, otherwise returns false.
 func (m *MemQuota) hasAvailable(nBytes uint64) bool {
-	return m.usedBytes.Load()+nBytes <= m.totalBytes
+	return m.inMemoryBytes(m.usedBytes.Load())+nBytes <= m.totalBytes
+}
+
+// releaseBytes returns nBytes to the quota and the arbiter, and wakes up
+// the blocked acquisitions of the quota and the waiters of the arbiter.
+func (m *MemQuota) releaseBytes(nBytes uint64) {
+	if nBytes == 0 {
+		return
+	}
+	m.arbiterMu.RLock()
+	// Note that "usedBytes.Add(^(nBytes - 1))" means "usedBytes.Sub(nBytes)". But atomic don't
+	// have Sub method.
+	m.usedBytes.Add(^(nBytes - 1))
+	arbiter := m.arbiter
+	if arbiter != nil {
+		arbiter.release(nBytes)
+	}
+	m.arbiterMu.RUnlock()
+
+	// Wake up the blocked acquisitions without holding arbiterMu,
+	// because they hold blockAcquireCond.L when acquiring it.
+	if m.waiting.Load() > 0 {
+		m.wakeUp()
+	}
+	if arbiter != nil {
+		arbiter.wakeUpWaiters()
+	}
 }
@@ -157,6 +157,38 @@ func TestMemQuotaHasAvailable(t *testing.T) {
 	require.False(t, m.hasAvailable(1))
 }
 
+func TestMemQuotaSpill(t *testing.T) {
+	t.Parallel()
+
+	m := NewMemQuota(model.DefaultChangeFeedID("1"), 100, "")
+	defer m.Close()
+
+	// spilling starts once the high watermark is exceeded.
+	require.True(t, m.TryAcquire(80))
+	require.False(t, m.ShouldSpill())
+	require.True(t, m.TryAcquire(10))
+	require.True(t, m.ShouldSpill())
+	require.False(t, m.hasAvailable(20))
+
+	// the spilled bytes are transferred out of the memory in use,
+	// and spilling continues until the low watermark is reached.
+	m.Spill(20)
+	require.True(t, m.hasAvailable(30))
+	require.True(t, m.ShouldSpill())
+	m.Spill(20)
+	require.False(t, m.ShouldSpill())
+	require.True(t, m.TryAcquire(40))
+	require.Equal(t, uint64(130), m.GetUsedBytes())
+
+	// the bytes read back are transferred into the memory in use.
+	m.Unspill(40)
+	require.False(t, m.hasAvailable(1))
+	require.True(t, m.ShouldSpill())
+	m.Refund(130)
+	require.False(t, m.ShouldSpill())
+	require.True(t, m.hasAvailable(100))
+}
+
 func TestMemQuotaRecordAndRelease(t *testing.T) {
 	t.Parallel()
 
@@ -28,7 +28,19 @@ var MemoryQuota = prometheus.NewGaugeVec(
 	// type includes total, used, component includes sink and redo.
 	[]string{"namespace", "changefeed", "type", "component"})
 
+// MemoryQuotaThrottledCount counts acquisitions of a changefeed that are
+// throttled by the capture level memory arbitration.
+var MemoryQuotaThrottledCount = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Namespace: "ticdc",
+		Subsystem: "sinkmanager",
+		Name:      "memory_quota_throttled_count",
+		Help:      "the number of memory quota acquisitions throttled by the capture arbiter",
+	},
+	[]string{"namespace", "changefeed", "component"})
+
 // InitMetrics registers all metrics in this file.
 func InitMetrics(registry *prometheus.Registry) {
 	registry.MustRegister(MemoryQuota)
+	registry.MustRegister(MemoryQuotaThrottledCount)
 }
@@ -10,6 +10,7 @@ import (
 	reflect "reflect"
 
 	gomock "github.com/golang/mock/gomock"
+	processor "github.com/pingcap/tiflow/cdc/processor"
 	orchestrator "github.com/pingcap/tiflow/pkg/orchestrator"
 )
 
@@ -48,6 +49,30 @@ func (mr *MockManagerMockRecorder) Close() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Close", reflect.TypeOf((*MockManager)(nil).Close))
 }
 
+// QueryHotspots mocks base method.
+func (m *MockManager) QueryHotspots(ctx context.Context, query *processor.HotspotQuery, done chan<- error) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "QueryHotspots", ctx, query, done)
+}
+
+// QueryHotspots indicates an expected call of QueryHotspots.
+func (mr *MockManagerMockRecorder) QueryHotspots(ctx, query, done interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "QueryHotspots", reflect.TypeOf((*MockManager)(nil).QueryHotspots), ctx, query, done)
+}
+
+// QueryLatency mocks base method.
+func (m *MockManager) QueryLatency(ctx context.Context, query *processor.LatencyQuery, done chan<- error) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "QueryLatency", ctx, query, done)
+}
+
+// QueryLatency indicates an expected call of QueryLatency.
+func (mr *MockManagerMockRecorder) QueryLatency(ctx, query, done interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "QueryLatency", reflect.TypeOf((*MockManager)(nil).QueryLatency), ctx, query, done)
+}
+
 // Tick mocks base method.
 func (m *MockManager) Tick(ctx context.Context, state orchestrator.ReactorState) (orchestrator.ReactorState, error) {
 	m.ctrl.T.Helper()
@@ -18,6 +18,7 @@ import (
 	"fmt"
 	"io"
 	"net/url"
+	"sort"
 	"strconv"
 	"sync"
 	"time"
@@ -44,6 +45,7 @@ import (
 	"github.com/pingcap/tiflow/pkg/retry"
 	"github.com/pingcap/tiflow/pkg/sink"
 	"github.com/pingcap/tiflow/pkg/sink/mysql"
+	"github.com/pingcap/tiflow/pkg/tracing"
 	"github.com/pingcap/tiflow/pkg/upstream"
 	"github.com/pingcap/tiflow/pkg/util"
 	"github.com/prometheus/client_golang/prometheus"
@@ -391,6 +393,8 @@ func (p *processor) getStatsFromSourceManagerAndSinkManager(
 	stats := tablepb.Stats{
 		RegionCount: pullerStats.RegionCount,
 		BarrierTs:   sinkStats.BarrierTs,
+		EventRate:   pullerStats.EventRate,
+		ByteRate:    pullerStats.ByteRate,
 		StageCheckpoints: map[string]tablepb.Checkpoint{
 			"puller-ingress": {
 				CheckpointTs: pullerStats.CheckpointTsIngress,
@@ -644,6 +648,7 @@ func (p *processor) lazyInitImpl(_ context.Context) (err error) {
 
 	// Clone the config to avoid data race
 	cfConfig := p.latestInfo.Config.Clone()
+	tracing.SetSampleRatio(p.changefeedID, cfConfig.Tracing.GetSampleRatio())
 
 	p.filter, err = filter.NewFilter(cfConfig, util.GetTimeZoneName(tz))
 	if err != nil {
@@ -693,7 +698,8 @@ func (p *processor) lazyInitImpl(_ context.Context) (err error) {
 		p.changefeedID, p.upstream, p.mg.r,
 		sortEngine, pullerSplitUpdateMode,
 		util.GetOrZero(cfConfig.BDRMode),
-		util.GetOrZero(cfConfig.EnableTableMonitor))
+		util.GetOrZero(cfConfig.EnableTableMonitor),
+		cfConfig.Priority)
 	p.sourceManager.name = "SourceManager"
 	p.sourceManager.changefeedID = p.changefeedID
 	p.sourceManager.spawn(ctx)
@@ -704,7 +710,8 @@ func (p *processor) lazyInitImpl(_ context.Context) (err error) {
 	}
 	p.sinkManager.r = sinkmanager.New(
 		p.changefeedID, p.latestInfo.SinkURI, cfConfig, p.upstream,
-		p.ddlHandler.r.schemaStorage, p.redo.r, p.sourceManager.r, isMysqlBackend)
+		p.ddlHandler.r.schemaStorage, p.redo.r, p.sourceManager.r, isMysqlBackend,
+		p.globalVars.MemQuotaArbiter)
 	p.sinkManager.name = "SinkManager"
 	p.sinkManager.changefeedID = p.changefeedID
 	p.sinkManager.spawn(ctx)
@@ -772,7 +779,6 @@ func (p *processor) handleErrorCh() (err error) {
 func (p *processor) initDDLHandler() error {
 	checkpointTs := p.latestInfo.GetCheckpointTs(p.latestStatus)
 	minTableBarrierTs := p.latestStatus.MinTableBarrierTs
-	forceReplicate := p.latestInfo.Config.ForceReplicate
 
 	// if minTableBarrierTs == checkpointTs it means owner can't tell whether the DDL on checkpointTs has
 	// been executed or not. So the DDL puller must start at checkpointTs-1.
@@ -782,8 +788,8 @@ func (p *processor) initDDLHandler() error {
 	} else {
 		ddlStartTs = checkpointTs - 1
 	}
-	schemaStorage, err := entry.NewSchemaStorage(p.upstream.KVStorage, ddlStartTs,
-		forceReplicate, p.changefeedID, util.RoleProcessor, p.filter)
+	schemaStorage, err := entry.NewSchemaStorageWithCache(p.upstream.KVStorage, ddlStartTs,
+		p.latestInfo.Config, p.changefeedID, util.RoleProcessor, p.filter)
 	if err != nil {
 		return errors.Trace(err)
 	}
@@ -899,6 +905,7 @@ func (p *processor) Close() error {
 	// clean up metrics first to avoid some metrics are not cleaned up
 	// when error occurs during closing the processor
 	p.cleanupMetrics()
+	tracing.RemoveChangefeed(p.changefeedID)
 
 	p.sinkManager.stop()
 	p.sinkManager.r = nil
@@ -989,6 +996,107 @@ func (p *processor) WriteDebugInfo(w io.Writer) error {
 	return nil
 }
 
+// latency returns the latency breakdown of the processor.
+func (p *processor) latency(slowRegionLimit int) *model.ProcessorLatency {
+	res := &model.ProcessorLatency{CaptureID: p.captureInfo.ID}
+	if !p.initialized.Load() {
+		return res
+	}
+	res.CurrentTs = oracle.GoTimeToTS(p.upstream.PDClock.CurrentTime())
+	res.SinkMemQuotaUsed, res.RedoMemQuotaUsed = p.sinkManager.r.GetMemQuotaUsage()
+
+	snap := p.ddlHandler.r.schemaStorage.GetLastSnapshot()
+	spans := p.sinkManager.r.GetAllCurrentTableSpans()
+	res.Tables = make([]model.TableLatency, 0, len(spans))
+	for _, span := range spans {
+		progress, ok := p.sinkManager.r.GetTableProgress(span)
+		if !ok {
+			continue
+		}
+		table := model.TableLatency{
+			TableID:           span.TableID,
+			Span:              span.String(),
+			PullerResolvedTs:  p.sourceManager.r.GetTablePullerStats(span).ResolvedTsEgress,
+			SorterResolvedTs:  progress.SorterResolvedTs,
+			MounterResolvedTs: progress.MountedResolvedTs,
+			SinkCheckpointTs:  progress.CheckpointTs,
+			RedoResolvedTs:    progress.RedoResolvedTs,
+		}
+		if x, ok := snap.PhysicalTableByID(span.TableID); ok {
+			table.TableName = x.TableName.QuoteString()
+		}
+		res.Tables = append(res.Tables, table)
+	}
+	sort.Slice(res.Tables, func(i, j int) bool {
+		if res.Tables[i].TableID != res.Tables[j].TableID {
+			return res.Tables[i].TableID < res.Tables[j].TableID
+		}
+		return res.Tables[i].Span < res.Tables[j].Span
+	})
+
+	for _, region := range p.sourceManager.r.GetSlowestRegions(slowRegionLimit) {
+		res.SlowRegions = append(res.SlowRegions, model.SlowRegion{
+			TableID:     region.Span.TableID,
+			RegionID:    region.RegionID,
+			ResolvedTs:  region.ResolvedTs,
+			Initialized: region.Initialized,
+		})
+	}
+	return res
+}
+
+// hotspots returns the hottest table spans and regions of the processor.
+func (p *processor) hotspots(limit int) *model.ProcessorHotspots {
+	res := &model.ProcessorHotspots{CaptureID: p.captureInfo.ID}
+	if !p.initialized.Load() {
+		return res
+	}
+
+	snap := p.ddlHandler.r.schemaStorage.GetLastSnapshot()
+	tableName := func(tableID model.TableID) string {
+		if x, ok := snap.PhysicalTableByID(tableID); ok {
+			return x.TableName.QuoteString()
+		}
+		return ""
+	}
+	spans := p.sinkManager.r.GetAllCurrentTableSpans()
+	res.Tables = make([]model.HotTable, 0, len(spans))
+	for _, span := range spans {
+		stats := p.sourceManager.r.GetTablePullerStats(span)
+		res.Tables = append(res.Tables, model.HotTable{
+			CaptureID:   p.captureInfo.ID,
+			TableID:     span.TableID,
+			TableName:   tableName(span.TableID),
+			Span:        span.String(),
+			RegionCount: stats.RegionCount,
+			EventRate:   stats.EventRate,
+			ByteRate:    stats.ByteRate,
+		})
+	}
+	sort.Slice(res.Tables, func(i, j int) bool {
+		if res.Tables[i].EventRate != res.Tables[j].EventRate {
+			return res.Tables[i].EventRate > res.Tables[j].EventRate
+		}
+		return res.Tables[i].ByteRate > res.Tables[j].ByteRate
+	})
+	if len(res.Tables) > limit {
+		res.Tables = res.Tables[:limit]
+	}
+
+	for _, region := range p.sourceManager.r.GetHotRegions(limit) {
+		res.Regions = append(res.Regions, model.HotRegion{
+			CaptureID:    p.captureInfo.ID,
+			TableID:      region.Span.TableID,
+			TableName:    tableName(region.Span.TableID),
+			RegionID:     region.RegionID,
+			EventRate:    region.EventRate,
+			ByteRate:     region.ByteRate,
+			LockResolves: region.LockResolves,
+		})
+	}
+	return res
+}
+
 func (p *processor) calculateTableBarrierTs(
 	barrier *schedulepb.Barrier,
 ) map[model.TableID]model.Ts {
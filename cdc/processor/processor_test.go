@@ -462,6 +462,35 @@ func TestProcessorError(t *testing.T) {
 	tester.MustApplyPatches()
 }
 
+// The table of the error reported by a processor sub-component should be
+// recorded in the task position.
+func TestProcessorTableError(t *testing.T) {
+	globalVars, changefeedVars := vars.NewGlobalVarsAndChangefeedInfo4Test()
+	ctx := context.Background()
+	liveness := model.LivenessCaptureAlive
+	p, tester, changefeed := initProcessor4Test(t, &liveness, false, globalVars, changefeedVars)
+
+	// init tick
+	require.Nil(t, p.lazyInit(ctx))
+	err, _ := p.Tick(ctx, changefeed.Info, changefeed.Status)
+	require.Nil(t, err)
+	createTaskPosition(changefeed, p.captureInfo)
+	tester.MustApplyPatches()
+
+	// send an error of a table sink
+	p.sinkManager.errors <- cerror.WithTable(
+		cerror.ErrMySQLTxnError.GenWithStackByArgs(), "test.t")
+	err, _ = p.Tick(ctx, changefeed.Info, changefeed.Status)
+	require.Error(t, err)
+	patchProcessorErr(p.captureInfo, changefeed, err)
+	tester.MustApplyPatches()
+	runningErr := changefeed.TaskPositions[p.captureInfo.ID].Error
+	require.Equal(t, "CDC:ErrMySQLTxnError", runningErr.Code)
+	require.Equal(t, "test.t", runningErr.Table)
+	require.Nil(t, p.Close())
+	tester.MustApplyPatches()
+}
+
 func TestProcessorExit(t *testing.T) {
 	globalVars, changefeedVars := vars.NewGlobalVarsAndChangefeedInfo4Test()
 	liveness := model.LivenessCaptureAlive
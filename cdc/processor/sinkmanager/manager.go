@@ -17,6 +17,7 @@ import (
 	"context"
 	"fmt"
 	"math"
+	"strconv"
 	"sync"
 	"time"
 
@@ -62,6 +63,18 @@ type TableStats struct {
 	BarrierTs    model.Ts
 }
 
+// TableProgress is the progress of a table in the sinkManager.
+type TableProgress struct {
+	// SorterResolvedTs is the resolved ts received from the sorter.
+	SorterResolvedTs model.Ts
+	// MountedResolvedTs is the resolved ts of the events mounted and written into the table sink.
+	MountedResolvedTs model.Ts
+	// CheckpointTs is the checkpoint ts of the table sink.
+	CheckpointTs model.Ts
+	// RedoResolvedTs is the resolved ts of the redo log. It's 0 if redo log is disabled.
+	RedoResolvedTs model.Ts
+}
+
 // SinkManager is the implementation of SinkManager.
 type SinkManager struct {
 	changefeedID model.ChangeFeedID
@@ -146,6 +159,7 @@ func New(
 	redoDMLMgr redo.DMLManager,
 	sourceManager *sourcemanager.SourceManager,
 	isMysqlBackend bool,
+	arbiter *memquota.Arbiter,
 ) *SinkManager {
 	m := &SinkManager{
 		changefeedID:        changefeedID,
@@ -188,6 +202,8 @@ func New(
 		m.sinkMemQuota = memquota.NewMemQuota(changefeedID, totalQuota, "sink")
 		m.redoMemQuota = memquota.NewMemQuota(changefeedID, 0, "redo")
 	}
+	m.sinkMemQuota.AttachArbiter(arbiter, config.Priority)
+	m.redoMemQuota.AttachArbiter(arbiter, config.Priority)
 
 	m.ready = make(chan struct{})
 	return m
@@ -365,6 +381,7 @@ func (m *SinkManager) initSinkFactory() (chan error, uint64) {
 		emitError(err)
 		return m.sinkFactory.errors, m.sinkFactory.version
 	}
+	m.sinkFactory.f.SetMemoryQuota(m.sinkMemQuota)
 
 	log.Info("Sink manager inits sink factory success",
 		zap.String("namespace", m.changefeedID.Namespace),
@@ -399,7 +416,7 @@ func (m *SinkManager) clearSinkFactory() {
 func (m *SinkManager) startSinkWorkers(ctx context.Context, eg *errgroup.Group, splitTxn bool) {
 	for i := 0; i < sinkWorkerNum; i++ {
 		w := newSinkWorker(m.changefeedID, m.sourceManager,
-			m.sinkMemQuota, splitTxn)
+			m.sinkMemQuota, splitTxn, m.tableName)
 		m.sinkWorkers = append(m.sinkWorkers, w)
 		eg.Go(func() error { return w.handleTasks(ctx, m.sinkTaskChan) })
 	}
@@ -572,7 +589,7 @@ func (m *SinkManager) generateSinkTasks(ctx context.Context) error {
 							zap.Error(restartErr))
 					}
 				default:
-					return sinkErr
+					return cerror.WithTable(sinkErr, m.tableName(tableSink.span))
 				}
 				continue
 			}
@@ -820,6 +837,17 @@ func (m *SinkManager) UpdateBarrierTs(globalBarrierTs model.Ts, tableBarrier map
 	})
 }
 
+// tableName returns the name of the table of the span, which is attached to
+// the errors of the table sink. The table ID is used if the name isn't found.
+func (m *SinkManager) tableName(span tablepb.Span) string {
+	if snap := m.schemaStorage.GetLastSnapshot(); snap != nil {
+		if info, ok := snap.PhysicalTableByID(span.TableID); ok {
+			return info.TableName.String()
+		}
+	}
+	return strconv.FormatInt(span.TableID, 10)
+}
+
 // AddTable adds a table(TableSink) to the sink manager.
 func (m *SinkManager) AddTable(span tablepb.Span, startTs model.Ts, targetTs model.Ts) *tableSinkWrapper {
 	sinkWrapper := newTableSinkWrapper(
@@ -1028,6 +1056,30 @@ func (m *SinkManager) GetTableStats(span tablepb.Span) TableStats {
 	}
 }
 
+// GetTableProgress returns the progress of the table.
+// Unlike GetTableStats, it doesn't release the memory quota of the table.
+func (m *SinkManager) GetTableProgress(span tablepb.Span) (TableProgress, bool) {
+	value, ok := m.tableSinks.Load(span)
+	if !ok {
+		return TableProgress{}, false
+	}
+	tableSink := value.(*tableSinkWrapper)
+	progress := TableProgress{
+		SorterResolvedTs:  tableSink.getReceivedSorterResolvedTs(),
+		MountedResolvedTs: tableSink.getResolvedTs().ResolvedMark(),
+		CheckpointTs:      tableSink.getCheckpointTs().ResolvedMark(),
+	}
+	if m.redoDMLMgr != nil {
+		progress.RedoResolvedTs = m.redoDMLMgr.GetResolvedTs(span)
+	}
+	return progress, true
+}
+
+// GetMemQuotaUsage returns the used bytes of the sink and the redo memory quota.
+func (m *SinkManager) GetMemQuotaUsage() (sinkUsed, redoUsed uint64) {
+	return m.sinkMemQuota.GetUsedBytes(), m.redoMemQuota.GetUsedBytes()
+}
+
 // WaitForReady implements pkg/util.Runnable.
 func (m *SinkManager) WaitForReady(ctx context.Context) {
 	select {
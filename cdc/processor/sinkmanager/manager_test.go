@@ -26,6 +26,7 @@ import (
 	"github.com/pingcap/tiflow/cdc/processor/sourcemanager/sorter"
 	"github.com/pingcap/tiflow/cdc/processor/tablepb"
 	"github.com/pingcap/tiflow/pkg/config"
+	cerror "github.com/pingcap/tiflow/pkg/errors"
 	"github.com/pingcap/tiflow/pkg/spanz"
 	"github.com/stretchr/testify/require"
 	"go.uber.org/zap"
@@ -281,6 +282,40 @@ func TestGetTableStatsToReleaseMemQuota(t *testing.T) {
 	}, 5*time.Second, 10*time.Millisecond)
 }
 
+func TestGetTableProgress(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	changefeedInfo := getChangefeedInfo()
+	manager, _, e := CreateManagerWithMemEngine(t, ctx, model.DefaultChangeFeedID("1"),
+		changefeedInfo, make(chan error, 1))
+	defer func() {
+		cancel()
+		manager.Close()
+	}()
+
+	span := spanz.TableIDToComparableSpan(1)
+	_, ok := manager.GetTableProgress(span)
+	require.False(t, ok)
+
+	manager.AddTable(span, 1, 100)
+	addTableAndAddEventsToSortEngine(t, e, span)
+	manager.UpdateBarrierTs(4, nil)
+	manager.UpdateReceivedSorterResolvedTs(span, 5)
+	manager.schemaStorage.AdvanceResolvedTs(5)
+	require.NoError(t, manager.StartTable(span, 0))
+
+	require.Eventually(t, func() bool {
+		progress, ok := manager.GetTableProgress(span)
+		return ok && progress.SorterResolvedTs == 5 &&
+			progress.MountedResolvedTs == 4 && progress.CheckpointTs == 4 &&
+			progress.RedoResolvedTs == 0
+	}, 5*time.Second, 10*time.Millisecond)
+	sinkUsed, redoUsed := manager.GetMemQuotaUsage()
+	require.Equal(t, uint64(0), redoUsed)
+	require.Equal(t, manager.sinkMemQuota.GetUsedBytes(), sinkUsed)
+}
+
 func TestDoNotGenerateTableSinkTaskWhenTableIsNotReplicating(t *testing.T) {
 	t.Parallel()
 
@@ -368,10 +403,12 @@ func TestSinkManagerRunWithErrors(t *testing.T) {
 
 	timer := time.NewTimer(5 * time.Second)
 	select {
-	case <-errCh:
+	case err := <-errCh:
 		if !timer.Stop() {
 			<-timer.C
 		}
+		// The table ID is used as the table isn't in the schema storage.
+		require.Equal(t, "1", cerror.TableOf(err))
 		return
 	case <-timer.C:
 		log.Panic("must get an error instead of a timeout")
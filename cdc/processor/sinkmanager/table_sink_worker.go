@@ -25,7 +25,9 @@ import (
 	"github.com/pingcap/tiflow/cdc/processor/memquota"
 	"github.com/pingcap/tiflow/cdc/processor/sourcemanager"
 	"github.com/pingcap/tiflow/cdc/processor/sourcemanager/sorter"
+	"github.com/pingcap/tiflow/cdc/processor/tablepb"
 	"github.com/pingcap/tiflow/cdc/sink/tablesink"
+	cerror "github.com/pingcap/tiflow/pkg/errors"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/tikv/client-go/v2/oracle"
 	"go.uber.org/zap"
@@ -63,6 +65,8 @@ type sinkWorker struct {
 	sinkMemQuota  *memquota.MemQuota
 	// splitTxn indicates whether to split the transaction into multiple batches.
 	splitTxn bool
+	// tableName is used to attach the table name to the errors of the tasks.
+	tableName func(span tablepb.Span) string
 
 	// Metrics.
 	metricOutputEventCountKV prometheus.Counter
@@ -74,12 +78,14 @@ func newSinkWorker(
 	sourceManager *sourcemanager.SourceManager,
 	sinkQuota *memquota.MemQuota,
 	splitTxn bool,
+	tableName func(span tablepb.Span) string,
 ) *sinkWorker {
 	return &sinkWorker{
 		changefeedID:  changefeedID,
 		sourceManager: sourceManager,
 		sinkMemQuota:  sinkQuota,
 		splitTxn:      splitTxn,
+		tableName:     tableName,
 
 		metricOutputEventCountKV: outputEventCount.WithLabelValues(changefeedID.Namespace, changefeedID.ID, "kv"),
 	}
@@ -97,7 +103,7 @@ func (w *sinkWorker) handleTasks(ctx context.Context, taskChan <-chan *sinkTask)
 				err = errors.New("SinkWorkerTaskError")
 			})
 			if err != nil {
-				return err
+				return cerror.WithTable(err, w.tableName(task.span))
 			}
 		}
 	}
@@ -140,7 +140,8 @@ func (suite *tableSinkWorkerSuite) createWorker(
 	quota.ForceAcquire(uint64(testEventSize))
 	quota.AddTable(suite.testSpan)
 
-	return newSinkWorker(suite.testChangefeedID, sm, quota, splitTxn), sortEngine
+	tableName := func(span tablepb.Span) string { return "test.t" }
+	return newSinkWorker(suite.testChangefeedID, sm, quota, splitTxn, tableName), sortEngine
 }
 
 func (suite *tableSinkWorkerSuite) addEventsToSortEngine(
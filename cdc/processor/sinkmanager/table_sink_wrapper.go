@@ -220,6 +220,13 @@ func (t *tableSinkWrapper) updateResolvedTs(ts model.ResolvedTs) error {
 	return t.tableSink.s.UpdateResolvedTs(ts)
 }
 
+// getResolvedTs returns the resolved ts of the events written into the table sink.
+func (t *tableSinkWrapper) getResolvedTs() model.ResolvedTs {
+	t.tableSink.innerMu.Lock()
+	defer t.tableSink.innerMu.Unlock()
+	return t.tableSink.resolvedTs
+}
+
 func (t *tableSinkWrapper) getLastSyncedTs() uint64 {
 	t.tableSink.RLock()
 	defer t.tableSink.RUnlock()
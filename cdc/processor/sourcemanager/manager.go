@@ -27,6 +27,7 @@ import (
 	"github.com/pingcap/tiflow/cdc/processor/tablepb"
 	"github.com/pingcap/tiflow/cdc/puller"
 	"github.com/pingcap/tiflow/pkg/config"
+	"github.com/pingcap/tiflow/pkg/tracing"
 	"github.com/pingcap/tiflow/pkg/txnutil"
 	"github.com/pingcap/tiflow/pkg/upstream"
 	"github.com/tikv/client-go/v2/tikv"
@@ -76,8 +77,9 @@ func New(
 	splitUpdateMode PullerSplitUpdateMode,
 	bdrMode bool,
 	enableTableMonitor bool,
+	priority config.ChangefeedPriority,
 ) *SourceManager {
-	return newSourceManager(changefeedID, up, mg, engine, splitUpdateMode, bdrMode, enableTableMonitor)
+	return newSourceManager(changefeedID, up, mg, engine, splitUpdateMode, bdrMode, enableTableMonitor, priority)
 }
 
 // NewForTest creates a new source manager for testing.
@@ -110,6 +112,7 @@ func newSourceManager(
 	splitUpdateMode PullerSplitUpdateMode,
 	bdrMode bool,
 	enableTableMonitor bool,
+	priority config.ChangefeedPriority,
 ) *SourceManager {
 	mgr := &SourceManager{
 		ready:              make(chan struct{}),
@@ -125,7 +128,7 @@ func newSourceManager(
 	serverConfig := config.GetGlobalServerConfig()
 	grpcPool := sharedconn.NewConnAndClientPool(mgr.up.SecurityConfig, kv.GetGlobalGrpcMetrics())
 	client := kv.NewSharedClient(
-		mgr.changefeedID, serverConfig, mgr.bdrMode,
+		mgr.changefeedID, serverConfig, mgr.bdrMode, priority,
 		mgr.up.PDClient, grpcPool, mgr.up.RegionCache, mgr.up.PDClock,
 		txnutil.NewLockerResolver(mgr.up.KVStorage.(tikv.Storage), mgr.changefeedID),
 	)
@@ -146,9 +149,14 @@ func newSourceManager(
 				}
 				deleteEvent := model.NewPolymorphicEvent(deleteKVEntry)
 				insertEvent := model.NewPolymorphicEvent(insertKVEntry)
+				deleteEvent.SpanContext = tracing.TxnSpanContext(mgr.changefeedID, raw.StartTs, raw.CRTs)
+				insertEvent.SpanContext = deleteEvent.SpanContext
 				mgr.engine.Add(spans[0], deleteEvent, insertEvent)
 			} else {
 				pEvent := model.NewPolymorphicEvent(raw)
+				if raw.OpType != model.OpTypeResolved {
+					pEvent.SpanContext = tracing.TxnSpanContext(mgr.changefeedID, raw.StartTs, raw.CRTs)
+				}
 				mgr.engine.Add(spans[0], pEvent)
 			}
 		}
@@ -232,6 +240,24 @@ func (m *SourceManager) GetTableSorterStats(span tablepb.Span) sorter.TableStats
 	return m.engine.GetStatsByTable(span)
 }
 
+// GetSlowestRegions returns the slowest region of each table span, the slowest first.
+func (m *SourceManager) GetSlowestRegions(limit int) []kv.SlowRegion {
+	// Only nil in unit tests.
+	if m.puller == nil {
+		return nil
+	}
+	return m.puller.SlowestRegions(limit)
+}
+
+// GetHotRegions returns the hottest regions of all table spans, the hottest first.
+func (m *SourceManager) GetHotRegions(limit int) []kv.HotRegion {
+	// Only nil in unit tests.
+	if m.puller == nil {
+		return nil
+	}
+	return m.puller.HotRegions(limit)
+}
+
 // Run implements util.Runnable.
 func (m *SourceManager) Run(ctx context.Context, _ ...chan<- error) error {
 	close(m.ready)
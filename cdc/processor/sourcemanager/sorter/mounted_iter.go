@@ -20,14 +20,16 @@ import (
 	"github.com/pingcap/tiflow/cdc/entry"
 	"github.com/pingcap/tiflow/cdc/model"
 	"github.com/pingcap/tiflow/cdc/processor/memquota"
+	"github.com/pingcap/tiflow/pkg/tracing"
 	"github.com/prometheus/client_golang/prometheus"
 )
 
 // MountedEventIter is just like EventIterator, but returns mounted events.
 type MountedEventIter struct {
-	iter  EventIterator
-	mg    entry.MounterGroup
-	quota *memquota.MemQuota
+	changefeedID model.ChangeFeedID
+	iter         EventIterator
+	mg           entry.MounterGroup
+	quota        *memquota.MemQuota
 
 	rawEvents      []rawEvent
 	rawEventBuffer rawEvent
@@ -46,10 +48,11 @@ func NewMountedEventIter(
 	quota *memquota.MemQuota,
 ) *MountedEventIter {
 	return &MountedEventIter{
-		iter:      iter,
-		mg:        mg,
-		quota:     quota,
-		rawEvents: make([]rawEvent, 0, maxBatchSize),
+		changefeedID: changefeedID,
+		iter:         iter,
+		mg:           mg,
+		quota:        quota,
+		rawEvents:    make([]rawEvent, 0, maxBatchSize),
 
 		mountWaitDuration: mountWaitDuration.WithLabelValues(changefeedID.Namespace, changefeedID.ID),
 	}
@@ -103,6 +106,7 @@ func (i *MountedEventIter) readBatch(ctx context.Context) error {
 
 	keepFetching := true
 	for keepFetching && len(i.rawEvents) < cap(i.rawEvents) {
+		fetchStart := time.Now()
 		event, txnFinished, err := i.iter.Next()
 		if err != nil {
 			return err
@@ -112,6 +116,7 @@ func (i *MountedEventIter) readBatch(ctx context.Context) error {
 			i.iter = nil
 			break
 		}
+		i.traceFetch(event, fetchStart)
 
 		var size int64
 		if event.RawKV != nil {
@@ -146,6 +151,21 @@ func (i *MountedEventIter) readBatch(ctx context.Context) error {
 	return nil
 }
 
+// traceFetch records the SpanSorterFetch span if the transaction is sampled.
+// The trace context is restored here since the on-disk sort engines lose it.
+func (i *MountedEventIter) traceFetch(event *model.PolymorphicEvent, fetchStart time.Time) {
+	if event.IsResolved() || event.RawKV == nil {
+		return
+	}
+	if !event.SpanContext.IsValid() {
+		event.SpanContext = tracing.TxnSpanContext(i.changefeedID, event.StartTs, event.CRTs)
+	}
+	if event.SpanContext.IsSampled() {
+		tracing.StartSpanAt(event.SpanContext, tracing.SpanSorterFetch, fetchStart,
+			tracing.TxnAttributes(i.changefeedID, event.StartTs, event.CRTs)...).End()
+	}
+}
+
 // Close implements sorter.EventIterator.
 func (i *MountedEventIter) Close() error {
 	for idx := i.nextToEmit; idx < len(i.rawEvents); idx++ {
@@ -112,8 +112,10 @@ func NewDDLJobPuller(
 	ddlJobPuller.sorter = memorysorter.NewEntrySorter(changefeed)
 
 	grpcPool := sharedconn.NewConnAndClientPool(up.SecurityConfig, kv.GetGlobalGrpcMetrics())
+	// The DDL spans only have a few regions, and the whole changefeed is blocked
+	// until they are scanned, so they are always scanned with the high priority.
 	client := kv.NewSharedClient(
-		changefeed, cfg, ddlPullerFilterLoop,
+		changefeed, cfg, ddlPullerFilterLoop, config.ChangefeedPriorityHigh,
 		pdCli, grpcPool, regionCache, pdClock,
 		txnutil.NewLockerResolver(kvStorage.(tikv.Storage), changefeed),
 	)
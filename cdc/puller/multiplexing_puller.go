@@ -25,6 +25,7 @@ import (
 	"github.com/pingcap/tiflow/cdc/model"
 	"github.com/pingcap/tiflow/cdc/processor/tablepb"
 	"github.com/pingcap/tiflow/cdc/puller/frontier"
+	cerror "github.com/pingcap/tiflow/pkg/errors"
 	"github.com/pingcap/tiflow/pkg/pdutil"
 	"github.com/pingcap/tiflow/pkg/spanz"
 	"github.com/prometheus/client_golang/prometheus"
@@ -384,7 +385,7 @@ func (p *MultiplexingPuller) runEventHandler(ctx context.Context, inputCh <-chan
 			p.queueKvDuration.Observe(float64(time.Since(e.Start).Milliseconds()))
 			p.CounterKv.Inc()
 			if err := progress.consume.f(ctx, e.Val, progress.spans); err != nil {
-				return errors.Trace(err)
+				return cerror.WithTable(errors.Trace(err), progress.tableName)
 			}
 		} else if e.Resolved != nil {
 			p.CounterResolved.Add(float64(len(e.Resolved.Spans)))
@@ -450,7 +451,7 @@ func (p *MultiplexingPuller) runResolvedTsAdvancer(ctx context.Context) error {
 			}
 			p.queueResolvedDuration.Observe(float64(time.Since(event.Start).Milliseconds()))
 			if err := progress.handleResolvedSpans(ctx, spans); err != nil {
-				return errors.Trace(err)
+				return cerror.WithTable(errors.Trace(err), progress.tableName)
 			}
 		}
 		return nil
@@ -497,6 +498,8 @@ type Stats struct {
 	ResolvedTsIngress   model.Ts
 	CheckpointTsEgress  model.Ts
 	ResolvedTsEgress    model.Ts
+	EventRate           uint64
+	ByteRate            uint64
 }
 
 // Stats returns Stats.
@@ -507,11 +510,24 @@ func (p *MultiplexingPuller) Stats(span tablepb.Span) Stats {
 	if progress.tableProgress == nil {
 		return Stats{}
 	}
+	throughput := p.client.Throughput(progress.subID)
 	return Stats{
 		RegionCount:         p.client.RegionCount(progress.subID),
 		ResolvedTsIngress:   progress.maxIngressResolvedTs.Load(),
 		CheckpointTsIngress: progress.maxIngressResolvedTs.Load(),
 		ResolvedTsEgress:    progress.resolvedTs.Load(),
 		CheckpointTsEgress:  progress.resolvedTs.Load(),
+		EventRate:           throughput.EventRate,
+		ByteRate:            throughput.ByteRate,
 	}
 }
+
+// SlowestRegions returns the slowest region of each subscribed span, the slowest first.
+func (p *MultiplexingPuller) SlowestRegions(limit int) []kv.SlowRegion {
+	return p.client.SlowestRegions(limit)
+}
+
+// HotRegions returns the hottest regions of all subscribed spans, the hottest first.
+func (p *MultiplexingPuller) HotRegions(limit int) []kv.HotRegion {
+	return p.client.HotRegions(limit)
+}
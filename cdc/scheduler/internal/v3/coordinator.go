@@ -351,6 +351,19 @@ func (c *coordinator) poll(
 		ctx, &c.tableRanges, replications, c.captureM.Captures, c.compat)
 	allTasks := c.schedulerM.Schedule(
 		checkpointTs, currentSpans, c.captureM.Captures, replications, runningTasks)
+	// The spans split from a hot table are added from the checkpoint of
+	// the table before it's split.
+	for _, task := range allTasks {
+		if task.BurstBalance == nil {
+			continue
+		}
+		for i := range task.BurstBalance.AddTables {
+			add := &task.BurstBalance.AddTables[i]
+			if ts, ok := c.reconciler.CheckpointTs(add.Span); ok && ts > add.CheckpointTs {
+				add.CheckpointTs = ts
+			}
+		}
+	}
 
 	// Handle generated schedule tasks.
 	msgs, err = c.replicationM.HandleTasks(allTasks)
@@ -15,6 +15,7 @@ package keyspan
 
 import (
 	"bytes"
+	"time"
 
 	"github.com/pingcap/tiflow/cdc/model"
 	"github.com/pingcap/tiflow/cdc/processor/tablepb"
@@ -82,5 +83,6 @@ func NewReconcilerForTests(
 		tableSpans: make(map[int64]splittedSpans),
 		config:     config,
 		splitter:   []splitter{newRegionCountSplitter(model.ChangeFeedID{}, cache, config.RegionPerSpan)},
+		hotSince:   make(map[model.TableID]time.Time),
 	}
 }
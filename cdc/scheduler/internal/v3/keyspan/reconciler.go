@@ -15,6 +15,7 @@ package keyspan
 
 import (
 	"context"
+	"time"
 
 	"github.com/pingcap/log"
 	"github.com/pingcap/tiflow/cdc/model"
@@ -36,6 +37,13 @@ const (
 	// baseSpanNumberCoefficient is the base coefficient that use to
 	// multiply the number of captures to get the number of spans.
 	baseSpanNumberCoefficient = 3
+	// hotTableSplitInterval is the minimum interval of splitting hot tables
+	// of a changefeed. A split table is removed and added again, so it limits
+	// the replication pauses and rescans caused by the splits.
+	hotTableSplitInterval = 5 * time.Minute
+	// hotTableDuration is how long a table must keep being hot before it's
+	// split, so a table with a short burst of writes is not split.
+	hotTableDuration = 2 * time.Minute
 )
 
 type splitter interface {
@@ -47,6 +55,14 @@ type splitter interface {
 type splittedSpans struct {
 	byAddTable bool
 	spans      []tablepb.Span
+	// pendingSpans are the spans of a hot table being split,
+	// they are added after the old spans of the table are removed.
+	pendingSpans []tablepb.Span
+	// checkpointTs is the checkpoint of a hot table before it's split.
+	// The split spans are added from it instead of the changefeed checkpoint,
+	// so the table is neither rescanned from the changefeed checkpoint nor
+	// missing any data.
+	checkpointTs model.Ts
 }
 
 // Reconciler reconciles span and table mapping, make sure spans are in
@@ -59,6 +75,12 @@ type Reconciler struct {
 	config       *config.ChangefeedSchedulerConfig
 
 	splitter []splitter
+	// hotSplitter splits the hot tables being replicated.
+	hotSplitter splitter
+	// hotSince is the time since when a table is hot.
+	hotSince map[model.TableID]time.Time
+	// lastHotSplit is the time of the last hot table split.
+	lastHotSplit time.Time
 }
 
 // NewReconciler returns a Reconciler.
@@ -80,6 +102,8 @@ func NewReconciler(
 			newWriteSplitter(changefeedID, pdapi, config.WriteKeyThreshold),
 			newRegionCountSplitter(changefeedID, up.RegionCache, config.RegionThreshold),
 		},
+		hotSplitter: newHotSpanSplitter(changefeedID, pdapi),
+		hotSince:    make(map[model.TableID]time.Time),
 	}, nil
 }
 
@@ -91,6 +115,7 @@ func NewReconciler(
 // 4. Add table by DDL.
 // 5. Drop table by DDL.
 // 6. Some captures fail, does NOT affect spans.
+// 7. A hot table being replicated by one span is split into multiple spans.
 func (m *Reconciler) Reconcile(
 	ctx context.Context,
 	currentTables *replication.TableRanges,
@@ -110,6 +135,28 @@ func (m *Reconciler) Reconcile(
 
 		// Reconcile spans from current replications.
 		coveredSpans, holes := replications.FindHoles(tableStart, tableEnd)
+		if ss, ok := m.tableSpans[tableID]; ok && len(ss.pendingSpans) != 0 {
+			// 7. A hot table is being split.
+			if len(coveredSpans) != 0 {
+				// Wait until the old spans of the table are removed, and keep
+				// tracking their checkpoint as it may advance before removed.
+				for _, span := range coveredSpans {
+					if rep, ok := replications.Get(span); ok &&
+						rep.Checkpoint.CheckpointTs > ss.checkpointTs {
+						ss.checkpointTs = rep.Checkpoint.CheckpointTs
+					}
+				}
+				m.tableSpans[tableID] = ss
+				return true
+			}
+			m.tableSpans[tableID] = splittedSpans{
+				byAddTable:   true,
+				spans:        ss.pendingSpans,
+				checkpointTs: ss.checkpointTs,
+			}
+			updateCache = true
+			return true
+		}
 		if len(coveredSpans) == 0 {
 			// No such spans in replications.
 			if _, ok := m.tableSpans[tableID]; ok {
@@ -174,9 +221,14 @@ func (m *Reconciler) Reconcile(
 			// 2. owner switch and no capture fails.
 			ss := m.tableSpans[tableID]
 			ss.byAddTable = false
+			ss.checkpointTs = 0
 			ss.spans = ss.spans[:0]
 			ss.spans = append(ss.spans, coveredSpans...)
 			m.tableSpans[tableID] = ss
+			if compat.CheckSpanReplicationEnabled() &&
+				m.splitHotTable(ctx, tableID, coveredSpans, replications, len(aliveCaptures)) {
+				updateCache = true
+			}
 		}
 		return true
 	})
@@ -200,6 +252,7 @@ func (m *Reconciler) Reconcile(
 			if !ok {
 				// Found dropped table.
 				delete(m.tableSpans, tableID)
+				delete(m.hotSince, tableID)
 				updateCache = true
 			}
 		}
@@ -214,6 +267,73 @@ func (m *Reconciler) Reconcile(
 	return m.spanCache
 }
 
+// splitHotTable splits a table replicated by only one span if the event rate
+// of the span keeps exceeding the threshold for hotTableDuration. The split
+// spans are pending until the old span is removed, because spans of a table
+// must not overlap, and they are added from the checkpoint of the old span.
+// At most one table of the changefeed is split every hotTableSplitInterval.
+// It returns true if the table is split.
+func (m *Reconciler) splitHotTable(
+	ctx context.Context,
+	tableID model.TableID,
+	coveredSpans []tablepb.Span,
+	replications *spanz.BtreeMap[*replication.ReplicationSet],
+	totalCaptures int,
+) bool {
+	threshold := m.config.HotTableEventRateThreshold
+	if threshold <= 0 || m.hotSplitter == nil || len(coveredSpans) != 1 {
+		delete(m.hotSince, tableID)
+		return false
+	}
+	rep, ok := replications.Get(coveredSpans[0])
+	if !ok || rep == nil || rep.Stats.EventRate < uint64(threshold) {
+		delete(m.hotSince, tableID)
+		return false
+	}
+	now := time.Now()
+	hotSince, ok := m.hotSince[tableID]
+	if !ok {
+		m.hotSince[tableID] = now
+		return false
+	}
+	if now.Sub(hotSince) < hotTableDuration ||
+		now.Sub(m.lastHotSplit) < hotTableSplitInterval {
+		return false
+	}
+	m.lastHotSplit = now
+
+	spans := m.hotSplitter.split(ctx, coveredSpans[0], totalCaptures)
+	if len(spans) <= 1 {
+		// Check the table again after hotTableDuration.
+		m.hotSince[tableID] = now
+		return false
+	}
+	delete(m.hotSince, tableID)
+	log.Info("schedulerv3: split hot table",
+		zap.String("namespace", m.changefeedID.Namespace),
+		zap.String("changefeed", m.changefeedID.ID),
+		zap.Int64("tableID", tableID),
+		zap.Uint64("eventRate", rep.Stats.EventRate),
+		zap.Int("threshold", threshold),
+		zap.Uint64("checkpointTs", rep.Checkpoint.CheckpointTs),
+		zap.Int("spans", len(spans)))
+	m.tableSpans[tableID] = splittedSpans{
+		pendingSpans: spans,
+		checkpointTs: rep.Checkpoint.CheckpointTs,
+	}
+	return true
+}
+
+// CheckpointTs returns the checkpoint ts from which the span should be added.
+// It's only found for the spans split from a hot table being replicated.
+func (m *Reconciler) CheckpointTs(span tablepb.Span) (model.Ts, bool) {
+	ss, ok := m.tableSpans[span.TableID]
+	if !ok || ss.checkpointTs == 0 {
+		return 0, false
+	}
+	return ss.checkpointTs, true
+}
+
 const maxSpanNumber = 100
 
 func getSpansNumber(regionNum, captureNum int) int {
@@ -16,6 +16,7 @@ package keyspan
 import (
 	"context"
 	"testing"
+	"time"
 
 	"github.com/pingcap/tiflow/cdc/model"
 	"github.com/pingcap/tiflow/cdc/processor/tablepb"
@@ -260,6 +261,164 @@ func TestBatchAddRateLimit(t *testing.T) {
 	require.Equal(t, 1, len(reconciler.tableSpans))
 }
 
+type mockSplitter struct {
+	spans []tablepb.Span
+}
+
+func (m *mockSplitter) split(
+	ctx context.Context, span tablepb.Span, totalCaptures int,
+) []tablepb.Span {
+	return m.spans
+}
+
+func TestSplitHotTable(t *testing.T) {
+	t.Parallel()
+
+	cfg := &config.SchedulerConfig{
+		ChangefeedSettings: &config.ChangefeedSchedulerConfig{
+			EnableTableAcrossNodes:     true,
+			HotTableEventRateThreshold: 100,
+		},
+	}
+	compat := compat.New(cfg, map[string]*model.CaptureInfo{})
+	captures := map[model.CaptureID]*member.CaptureStatus{"1": nil, "2": nil}
+	ctx := context.Background()
+
+	tableSpan := spanz.TableIDToComparableSpan(1)
+	splitKey := append(tableSpan.StartKey, 1)
+	splitSpans := []tablepb.Span{
+		{TableID: 1, StartKey: tableSpan.StartKey, EndKey: splitKey},
+		{TableID: 1, StartKey: splitKey, EndKey: tableSpan.EndKey},
+	}
+	reconciler := NewReconcilerForTests(NewMockRegionCache(), cfg.ChangefeedSettings)
+	reconciler.hotSplitter = &mockSplitter{spans: splitSpans}
+	currentTables := &replication.TableRanges{}
+	currentTables.UpdateTables([]model.TableID{1})
+
+	// The table is not hot.
+	reps := spanz.NewBtreeMap[*replication.ReplicationSet]()
+	rep := &replication.ReplicationSet{
+		Stats:      tablepb.Stats{EventRate: 50},
+		Checkpoint: tablepb.Checkpoint{CheckpointTs: 100},
+	}
+	reps.ReplaceOrInsert(tableSpan, rep)
+	spans := reconciler.Reconcile(ctx, currentTables, reps, captures, compat)
+	require.Equal(t, []tablepb.Span{tableSpan}, spans)
+	require.NotContains(t, reconciler.hotSince, int64(1))
+
+	// The table becomes hot, it's not split until it keeps being hot
+	// for a while.
+	rep.Stats.EventRate = 200
+	spans = reconciler.Reconcile(ctx, currentTables, reps, captures, compat)
+	require.Equal(t, []tablepb.Span{tableSpan}, spans)
+	require.Contains(t, reconciler.hotSince, int64(1))
+
+	// A short burst of writes doesn't split the table.
+	rep.Stats.EventRate = 50
+	spans = reconciler.Reconcile(ctx, currentTables, reps, captures, compat)
+	require.Equal(t, []tablepb.Span{tableSpan}, spans)
+	require.NotContains(t, reconciler.hotSince, int64(1))
+
+	// Another table is split recently.
+	rep.Stats.EventRate = 200
+	reconciler.hotSince[1] = time.Now().Add(-hotTableDuration)
+	reconciler.lastHotSplit = time.Now()
+	spans = reconciler.Reconcile(ctx, currentTables, reps, captures, compat)
+	require.Equal(t, []tablepb.Span{tableSpan}, spans)
+
+	// The table keeps being hot, it's removed before adding the split spans.
+	reconciler.lastHotSplit = time.Time{}
+	spans = reconciler.Reconcile(ctx, currentTables, reps, captures, compat)
+	require.Empty(t, spans)
+	require.Equal(t, splitSpans, reconciler.tableSpans[1].pendingSpans)
+	require.Equal(t, uint64(100), reconciler.tableSpans[1].checkpointTs)
+	spans = reconciler.Reconcile(ctx, currentTables, reps, captures, compat)
+	require.Empty(t, spans)
+
+	// The table span is removed.
+	reps.Delete(tableSpan)
+	spans = reconciler.Reconcile(ctx, currentTables, reps, captures, compat)
+	require.Equal(t, splitSpans, spans)
+	require.True(t, reconciler.tableSpans[1].byAddTable)
+
+	// The split spans are added, and they are not split again.
+	for _, span := range splitSpans {
+		reps.ReplaceOrInsert(span, &replication.ReplicationSet{
+			Stats: tablepb.Stats{EventRate: 200},
+		})
+	}
+	spans = reconciler.Reconcile(ctx, currentTables, reps, captures, compat)
+	require.Equal(t, splitSpans, spans)
+	require.False(t, reconciler.tableSpans[1].byAddTable)
+	require.Empty(t, reconciler.tableSpans[1].pendingSpans)
+	_, ok := reconciler.CheckpointTs(splitSpans[0])
+	require.False(t, ok)
+}
+
+// The split spans of a hot table must cover the whole table, and start from
+// a checkpoint which is not less than the changefeed checkpoint and not
+// greater than the checkpoint of the table when it's removed.
+func TestSplitHotTableNoDataGap(t *testing.T) {
+	t.Parallel()
+
+	cfg := &config.SchedulerConfig{
+		ChangefeedSettings: &config.ChangefeedSchedulerConfig{
+			EnableTableAcrossNodes:     true,
+			HotTableEventRateThreshold: 100,
+		},
+	}
+	compat := compat.New(cfg, map[string]*model.CaptureInfo{})
+	captures := map[model.CaptureID]*member.CaptureStatus{"1": nil, "2": nil}
+	ctx := context.Background()
+
+	tableSpan := spanz.TableIDToComparableSpan(1)
+	splitKey1 := append(append([]byte{}, tableSpan.StartKey...), 1)
+	splitKey2 := append(append([]byte{}, tableSpan.StartKey...), 2)
+	splitSpans := []tablepb.Span{
+		{TableID: 1, StartKey: tableSpan.StartKey, EndKey: splitKey1},
+		{TableID: 1, StartKey: splitKey1, EndKey: splitKey2},
+		{TableID: 1, StartKey: splitKey2, EndKey: tableSpan.EndKey},
+	}
+	reconciler := NewReconcilerForTests(NewMockRegionCache(), cfg.ChangefeedSettings)
+	reconciler.hotSplitter = &mockSplitter{spans: splitSpans}
+	currentTables := &replication.TableRanges{}
+	currentTables.UpdateTables([]model.TableID{1})
+
+	changefeedCheckpointTs := model.Ts(90)
+	reps := spanz.NewBtreeMap[*replication.ReplicationSet]()
+	rep := &replication.ReplicationSet{
+		Stats:      tablepb.Stats{EventRate: 200},
+		Checkpoint: tablepb.Checkpoint{CheckpointTs: 100},
+	}
+	reps.ReplaceOrInsert(tableSpan, rep)
+	reconciler.hotSince[1] = time.Now().Add(-hotTableDuration)
+	spans := reconciler.Reconcile(ctx, currentTables, reps, captures, compat)
+	require.Empty(t, spans)
+
+	// The table keeps advancing until it's removed.
+	rep.Checkpoint.CheckpointTs = 110
+	spans = reconciler.Reconcile(ctx, currentTables, reps, captures, compat)
+	require.Empty(t, spans)
+	reps.Delete(tableSpan)
+	spans = reconciler.Reconcile(ctx, currentTables, reps, captures, compat)
+
+	// The split spans cover the whole table without any hole or overlap.
+	require.Equal(t, splitSpans, spans)
+	require.Equal(t, tableSpan.StartKey, spans[0].StartKey)
+	for i := 1; i < len(spans); i++ {
+		require.Equal(t, spans[i-1].EndKey, spans[i].StartKey)
+	}
+	require.Equal(t, tableSpan.EndKey, spans[len(spans)-1].EndKey)
+
+	// All events after the checkpoint of the removed table are replicated.
+	for _, span := range spans {
+		ts, ok := reconciler.CheckpointTs(span)
+		require.True(t, ok)
+		require.Equal(t, uint64(110), ts)
+		require.GreaterOrEqual(t, ts, changefeedCheckpointTs)
+	}
+}
+
 func TestGetSpansNumber(t *testing.T) {
 	tc := []struct {
 		regionCount int
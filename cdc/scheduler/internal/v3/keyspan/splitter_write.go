@@ -59,6 +59,14 @@ func (m *writeSplitter) split(
 	if m.writeKeyThreshold == 0 {
 		return nil
 	}
+	return m.splitByWrittenKeys(ctx, span, captureNum)
+}
+
+func (m *writeSplitter) splitByWrittenKeys(
+	ctx context.Context,
+	span tablepb.Span,
+	captureNum int,
+) []tablepb.Span {
 	regions, err := m.pdAPIClient.ScanRegions(ctx, span)
 	if err != nil {
 		// Skip split.
@@ -96,6 +104,30 @@ func (m *writeSplitter) split(
 	return splitInfo.Spans
 }
 
+// hotSpanSplitter splits a hot span which is being replicated by the written
+// keys of its regions. The write key threshold is ignored, because the span
+// is already known to be hot from its event rate.
+type hotSpanSplitter struct {
+	*writeSplitter
+}
+
+func newHotSpanSplitter(
+	changefeedID model.ChangeFeedID,
+	pdAPIClient pdutil.PDAPIClient,
+) *hotSpanSplitter {
+	return &hotSpanSplitter{
+		writeSplitter: newWriteSplitter(changefeedID, pdAPIClient, 0),
+	}
+}
+
+func (m *hotSpanSplitter) split(
+	ctx context.Context,
+	span tablepb.Span,
+	captureNum int,
+) []tablepb.Span {
+	return m.splitByWrittenKeys(ctx, span, captureNum)
+}
+
 // splitRegionsByWrittenKeysV1 tries to split the regions into at least `baseSpansNum` spans,
 // each span has approximately the same write weight.
 // The algorithm is:
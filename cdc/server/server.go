@@ -32,6 +32,7 @@ import (
 	"github.com/pingcap/tiflow/cdc"
 	"github.com/pingcap/tiflow/cdc/capture"
 	"github.com/pingcap/tiflow/cdc/processor/sourcemanager/sorter/factory"
+	"github.com/pingcap/tiflow/pkg/audit"
 	"github.com/pingcap/tiflow/pkg/config"
 	cerror "github.com/pingcap/tiflow/pkg/errors"
 	"github.com/pingcap/tiflow/pkg/etcd"
@@ -39,7 +40,9 @@ import (
 	clogutil "github.com/pingcap/tiflow/pkg/logutil"
 	"github.com/pingcap/tiflow/pkg/p2p"
 	"github.com/pingcap/tiflow/pkg/pdutil"
+	"github.com/pingcap/tiflow/pkg/security"
 	"github.com/pingcap/tiflow/pkg/tcpserver"
+	"github.com/pingcap/tiflow/pkg/tracing"
 	p2pProto "github.com/pingcap/tiflow/proto/p2p"
 	pd "github.com/tikv/pd/client"
 	"go.uber.org/zap"
@@ -57,6 +60,8 @@ const (
 	maxHTTPConnection = 1000
 	// httpConnectionTimeout is used to limit a connection max alive time of http server.
 	httpConnectionTimeout = 10 * time.Minute
+	// tracingShutdownTimeout is used to limit the time of flushing the pending spans.
+	tracingShutdownTimeout = 5 * time.Second
 	// maxGcTunerMemory is used to limit the max memory usage of cdc server. if the memory is larger than it, gc tuner will be disabled
 	maxGcTunerMemory = 512 * 1024 * 1024 * 1024
 )
@@ -88,6 +93,8 @@ type server struct {
 	pdAPIClient       pdutil.PDAPIClient
 	pdEndpoints       []string
 	sortEngineFactory *factory.SortEngineFactory
+	// shutdownTracing flushes the pending spans and stops the tracing exporters.
+	shutdownTracing func(context.Context) error
 }
 
 // New creates a server instance.
@@ -103,6 +110,11 @@ func New(pdEndpoints []string) (*server, error) {
 		}
 	}
 
+	// The secret key must be loaded before any changefeed info is read from or written to etcd.
+	if err := security.InitSecretCipher(conf.Security); err != nil {
+		return nil, errors.Trace(err)
+	}
+
 	// tcpServer is the unified frontend of the CDC server that serves
 	// both RESTful APIs and gRPC APIs.
 	// Note that we pass the TLS config to the tcpServer, so there is no need to
@@ -193,6 +205,17 @@ func (s *server) prepare(ctx context.Context) error {
 		return errors.Trace(err)
 	}
 
+	// The audit log is written to the data dir by default, which is decided by initDir.
+	conf = config.GetGlobalServerConfig()
+	if err := audit.InitLogger(conf.Audit, conf.DataDir); err != nil {
+		return errors.Trace(err)
+	}
+
+	s.shutdownTracing, err = tracing.InitTracerProvider(ctx, conf.Tracing, conf.AdvertiseAddr)
+	if err != nil {
+		return errors.Trace(err)
+	}
+
 	s.createSortEngineFactory()
 	s.setMemoryLimit()
 
@@ -386,6 +409,15 @@ func (s *server) Close() {
 	// puller send data to closed sort engine.
 	s.closeSortEngineFactory()
 
+	if s.shutdownTracing != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), tracingShutdownTimeout)
+		if err := s.shutdownTracing(ctx); err != nil {
+			log.Warn("shutdown tracing failed", zap.Error(err))
+		}
+		cancel()
+		s.shutdownTracing = nil
+	}
+
 	if s.statusServer != nil {
 		err := s.statusServer.Close()
 		if err != nil {
@@ -47,6 +47,7 @@ type DDLSink struct {
 	storage    storage.ExternalStorage
 	cfg        *cloudstorage.Config
 	cron       *cron.Cron
+	catalog    *cloudstorage.CatalogCompactor
 
 	lastCheckpointTs         atomic.Uint64
 	lastSendCheckpointTsTime time.Time
@@ -84,6 +85,7 @@ func newDDLSink(ctx context.Context,
 		storage:                  storage,
 		statistics:               metrics.NewStatistics(changefeedID, sink.TxnSink),
 		cfg:                      cfg,
+		catalog:                  cloudstorage.NewCatalogCompactor(storage),
 		lastSendCheckpointTsTime: time.Now(),
 	}
 
@@ -117,7 +119,8 @@ func (d *DDLSink) WriteDDLEvent(ctx context.Context, ddl *model.DDLEvent) error
 				return err1
 			}
 
-			return nil
+			return cloudstorage.WriteCatalogVersion(ctx, d.storage,
+				def.Schema, def.Table, def.TableVersion, path)
 		})
 	}
 
@@ -131,9 +134,13 @@ func (d *DDLSink) WriteDDLEvent(ctx context.Context, ddl *model.DDLEvent) error
 		// For exchange partition, we need to write the schema of the source table.
 		var sourceTableDef cloudstorage.TableDefinition
 		sourceTableDef.FromTableInfo(ddl.PreTableInfo, ddl.TableInfo.Version, d.cfg.OutputColumnID)
-		return writeFile(sourceTableDef)
+		if err := writeFile(sourceTableDef); err != nil {
+			return errors.Trace(err)
+		}
 	}
-	return nil
+	// the new version is visible to the readers once the DDL is written, and
+	// all the data files of the events before the DDL have been written.
+	return errors.Trace(d.catalog.Compact(ctx, ddl.CommitTs-1))
 }
 
 // WriteCheckpointTs writes the checkpoint ts to the cloud storage.
@@ -151,6 +158,11 @@ func (d *DDLSink) WriteCheckpointTs(ctx context.Context,
 		d.lastSendCheckpointTsTime = time.Now()
 		d.lastCheckpointTs.Store(ts)
 	}()
+	// the catalog is compacted before the checkpoint is written, so all the
+	// data files before the checkpoint are recorded in the root index.
+	if err := d.catalog.Compact(ctx, ts); err != nil {
+		return errors.Trace(err)
+	}
 	ckpt, err := json.Marshal(map[string]uint64{"checkpoint-ts": ts})
 	if err != nil {
 		return errors.Trace(err)
@@ -29,6 +29,7 @@ import (
 	"github.com/pingcap/tidb/pkg/parser/types"
 	"github.com/pingcap/tiflow/cdc/model"
 	"github.com/pingcap/tiflow/pkg/config"
+	"github.com/pingcap/tiflow/pkg/sink/cloudstorage"
 	"github.com/pingcap/tiflow/pkg/util"
 	"github.com/stretchr/testify/require"
 )
@@ -98,6 +99,14 @@ func TestWriteDDLEvent(t *testing.T) {
 		],
 		"TableColumnsTotal": 2
 	}`, string(tableSchema))
+
+	// the version is visible in the catalog once the ddl is written.
+	catalog, err := cloudstorage.LoadCatalog(ctx, sink.storage)
+	require.Nil(t, err)
+	require.Equal(t, uint64(99), catalog.CheckpointTs())
+	versions := catalog.Versions("test", "table1")
+	require.Len(t, versions, 1)
+	require.Equal(t, "test/table1/meta/schema_100_4192708364.json", versions[0].SchemaFile)
 }
 
 func TestWriteCheckpointTs(t *testing.T) {
@@ -141,6 +150,10 @@ func TestWriteCheckpointTs(t *testing.T) {
 	metadata, err := os.ReadFile(path.Join(parentDir, "metadata"))
 	require.Nil(t, err)
 	require.JSONEq(t, `{"checkpoint-ts":100}`, string(metadata))
+	// the catalog is compacted with the checkpoint.
+	index, err := os.ReadFile(path.Join(parentDir, ".catalog", "index.json"))
+	require.Nil(t, err)
+	require.JSONEq(t, `{"CheckpointTs":100,"Versions":null}`, string(index))
 }
 
 func TestCleanupExpiredFiles(t *testing.T) {
@@ -25,6 +25,7 @@ import (
 	"github.com/pingcap/tiflow/cdc/sink/metrics/mq"
 	"github.com/pingcap/tiflow/cdc/sink/util"
 	"github.com/pingcap/tiflow/pkg/config"
+	cerror "github.com/pingcap/tiflow/pkg/errors"
 	"github.com/pingcap/tiflow/pkg/sink/codec/common"
 	"go.uber.org/zap"
 )
@@ -43,25 +44,47 @@ type pulsarProducers struct {
 	id             model.ChangeFeedID
 }
 
-// SyncBroadcastMessage pulsar consume all partitions
-// totalPartitionsNum is not used
+// SyncBroadcastMessage sends the message to every partition of the topic,
+// so the consumer of each partition receives the DDL and resolved events.
+// totalPartitionsNum is not used, the partitions are looked up from pulsar.
 func (p *pulsarProducers) SyncBroadcastMessage(ctx context.Context, topic string,
 	totalPartitionsNum int32, message *common.Message,
 ) error {
-	// call SyncSendMessage
-	// pulsar consumer all partitions
-	return p.SyncSendMessage(ctx, topic, totalPartitionsNum, message)
+	partitions, err := p.client.TopicPartitions(topic)
+	if err != nil {
+		return cerror.WrapError(cerror.ErrPulsarSendMessage, err)
+	}
+	for _, partition := range partitions {
+		if err := p.syncSendMessage(ctx, topic, partition, message); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
-// SyncSendMessage sends a message
-// partitionNum is not used, pulsar consume all partitions
+// SyncSendMessage sends a message to the given partition of the topic.
 func (p *pulsarProducers) SyncSendMessage(ctx context.Context, topic string,
 	partitionNum int32, message *common.Message,
+) error {
+	partitions, err := p.client.TopicPartitions(topic)
+	if err != nil {
+		return cerror.WrapError(cerror.ErrPulsarSendMessage, err)
+	}
+	if partitionNum < 0 || int(partitionNum) >= len(partitions) {
+		return cerror.ErrPulsarInvalidPartitionNum.GenWithStackByArgs(partitionNum)
+	}
+	return p.syncSendMessage(ctx, topic, partitions[partitionNum], message)
+}
+
+// syncSendMessage sends a message to the partition topic, which is the topic
+// itself if it's not partitioned. The metrics are recorded by the topic.
+func (p *pulsarProducers) syncSendMessage(ctx context.Context, topic string,
+	partitionTopic string, message *common.Message,
 ) error {
 	wrapperSchemaAndTopic(message)
 	mq.IncPublishedDDLCount(topic, p.id.ID, message)
 
-	producer, err := p.GetProducerByTopic(topic)
+	producer, err := p.GetProducerByTopic(partitionTopic)
 	if err != nil {
 		log.Error("ddl SyncSendMessage GetProducerByTopic fail", zap.Error(err))
 		return err
@@ -80,12 +103,12 @@ func (p *pulsarProducers) SyncSendMessage(ctx context.Context, topic string,
 
 	if message.Type == model.MessageTypeDDL {
 		log.Info("pulsarProducers SyncSendMessage success",
-			zap.Any("mID", mID), zap.String("topic", topic),
+			zap.Any("mID", mID), zap.String("topic", partitionTopic),
 			zap.String("ddl", string(message.Value)))
 	}
 
 	log.Debug("pulsarProducers SyncSendMessage success",
-		zap.Any("mID", mID), zap.String("topic", topic))
+		zap.Any("mID", mID), zap.String("topic", partitionTopic))
 
 	mq.IncPublishedDDLSuccess(topic, p.id.ID, message)
 	return nil
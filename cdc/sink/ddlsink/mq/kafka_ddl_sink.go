@@ -28,6 +28,7 @@ import (
 	cerror "github.com/pingcap/tiflow/pkg/errors"
 	"github.com/pingcap/tiflow/pkg/sink/codec/builder"
 	"github.com/pingcap/tiflow/pkg/sink/kafka"
+	"github.com/pingcap/tiflow/pkg/sink/kafka/claimcheck"
 	tiflowutil "github.com/pingcap/tiflow/pkg/util"
 	"go.uber.org/zap"
 )
@@ -111,8 +112,17 @@ func NewKafkaDDLSink(
 		return nil, errors.Trace(err)
 	}
 
+	cleaner, err := claimcheck.NewCleaner(ctx, encoderConfig.LargeMessageHandle, changefeedID)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+
 	ddlProducer := producerCreator(ctx, changefeedID, syncProducer)
 	s := newDDLSink(changefeedID, ddlProducer, adminClient, topicManager, eventRouter, encoderBuilder, protocol)
+	if cleaner != nil {
+		// the expired claim-check files are removed by the owner in the background.
+		s.runCleaner(ctx, cleaner)
+	}
 	log.Info("DDL sink producer client created", zap.Duration("duration", time.Since(start)))
 	return s, nil
 }
@@ -28,6 +28,7 @@ import (
 	"github.com/pingcap/tiflow/pkg/sink"
 	"github.com/pingcap/tiflow/pkg/sink/codec"
 	"github.com/pingcap/tiflow/pkg/sink/kafka"
+	"github.com/pingcap/tiflow/pkg/sink/kafka/claimcheck"
 	"go.uber.org/zap"
 )
 
@@ -74,6 +75,11 @@ type DDLSink struct {
 	statistics *metrics.Statistics
 	// admin is used to query kafka cluster information.
 	admin kafka.ClusterAdminClient
+	// cleaner removes the expired claim-check files, it's nil if not required.
+	cleaner *claimcheck.Cleaner
+	// cleanerCancel stops the cleaner, and cleanerDone is closed after it exits.
+	cleanerCancel context.CancelFunc
+	cleanerDone   chan struct{}
 }
 
 func newDDLSink(
@@ -145,6 +151,9 @@ func (k *DDLSink) WriteDDLEvent(ctx context.Context, ddl *model.DDLEvent) error
 func (k *DDLSink) WriteCheckpointTs(ctx context.Context,
 	ts uint64, tables []*model.TableInfo,
 ) error {
+	if k.cleaner != nil {
+		k.cleaner.UpdateCheckpointTs(ts)
+	}
 	encoder := k.encoderBuilder.Build()
 	msg, err := encoder.EncodeCheckpointEvent(ts)
 	if err != nil {
@@ -186,7 +195,23 @@ func (k *DDLSink) WriteCheckpointTs(ctx context.Context,
 }
 
 // Close closes the sink.
+// runCleaner runs the cleaner in the background until the sink is closed.
+func (k *DDLSink) runCleaner(ctx context.Context, cleaner *claimcheck.Cleaner) {
+	ctx, cancel := context.WithCancel(ctx)
+	k.cleaner = cleaner
+	k.cleanerCancel = cancel
+	k.cleanerDone = make(chan struct{})
+	go func() {
+		defer close(k.cleanerDone)
+		cleaner.Run(ctx)
+	}()
+}
+
 func (k *DDLSink) Close() {
+	if k.cleanerCancel != nil {
+		k.cleanerCancel()
+		<-k.cleanerDone
+	}
 	if k.producer != nil {
 		k.producer.Close()
 	}
@@ -34,6 +34,7 @@ import (
 	"github.com/pingcap/tiflow/pkg/pdutil"
 	"github.com/pingcap/tiflow/pkg/sink"
 	"github.com/pingcap/tiflow/pkg/sink/cloudstorage"
+	"github.com/pingcap/tiflow/pkg/sink/codec"
 	"github.com/pingcap/tiflow/pkg/sink/codec/builder"
 	"github.com/pingcap/tiflow/pkg/sink/codec/common"
 	putil "github.com/pingcap/tiflow/pkg/util"
@@ -142,6 +143,8 @@ func NewDMLSink(ctx context.Context,
 	if err != nil {
 		return nil, cerror.WrapError(cerror.ErrStorageSinkInvalidConfig, err)
 	}
+	// the header encoder is nil if the data file has no header.
+	headerEncoder, _ := encoderBuilder.(codec.FileHeaderEncoder)
 
 	wgCtx, wgCancel := context.WithCancel(ctx)
 	s := &DMLSink{
@@ -168,7 +171,7 @@ func NewDMLSink(ctx context.Context,
 	// create a group of dml workers.
 	for i := 0; i < cfg.WorkerCount; i++ {
 		inputCh := chann.NewAutoDrainChann[eventFragment]()
-		s.workers[i] = newDMLWorker(i, s.changefeedID, storage, cfg, ext,
+		s.workers[i] = newDMLWorker(i, s.changefeedID, storage, cfg, ext, headerEncoder,
 			inputCh, pdClock, s.statistics)
 		workerChannels[i] = inputCh
 	}
@@ -30,6 +30,7 @@ import (
 	"github.com/pingcap/tiflow/pkg/errors"
 	"github.com/pingcap/tiflow/pkg/pdutil"
 	"github.com/pingcap/tiflow/pkg/sink/cloudstorage"
+	"github.com/pingcap/tiflow/pkg/sink/codec"
 	"github.com/pingcap/tiflow/pkg/sink/codec/common"
 	"github.com/prometheus/client_golang/prometheus"
 	"go.uber.org/zap"
@@ -43,6 +44,9 @@ type dmlWorker struct {
 	changeFeedID model.ChangeFeedID
 	storage      storage.ExternalStorage
 	config       *cloudstorage.Config
+	// headerEncoder encodes the header of each data file, it's nil
+	// if the protocol doesn't require a file header.
+	headerEncoder codec.FileHeaderEncoder
 	// toBeFlushedCh contains a set of batchedTask waiting to be flushed to cloud storage.
 	toBeFlushedCh          chan batchedTask
 	inputCh                *chann.DrainableChann[eventFragment]
@@ -109,6 +113,7 @@ func newDMLWorker(
 	storage storage.ExternalStorage,
 	config *cloudstorage.Config,
 	extension string,
+	headerEncoder codec.FileHeaderEncoder,
 	inputCh *chann.DrainableChann[eventFragment],
 	pdClock pdutil.Clock,
 	statistics *metrics.Statistics,
@@ -118,6 +123,7 @@ func newDMLWorker(
 		changeFeedID:      changefeedID,
 		storage:           storage,
 		config:            config,
+		headerEncoder:     headerEncoder,
 		inputCh:           inputCh,
 		toBeFlushedCh:     make(chan batchedTask, 64),
 		statistics:        statistics,
@@ -230,6 +236,18 @@ func (d *dmlWorker) flushMessages(ctx context.Context) error {
 					return errors.Trace(err)
 				}
 
+				// finally record the data directory in the catalog if it is new.
+				err = d.filePathGenerator.UpdateCatalog(ctx, table, date)
+				if err != nil {
+					log.Error("failed to update catalog in external storage",
+						zap.Int("workerID", d.id),
+						zap.String("namespace", d.changeFeedID.Namespace),
+						zap.String("changefeed", d.changeFeedID.ID),
+						zap.String("path", dataFilePath),
+						zap.Error(err))
+					return errors.Trace(err)
+				}
+
 				log.Debug("write file to storage success", zap.Int("workerID", d.id),
 					zap.String("namespace", d.changeFeedID.Namespace),
 					zap.String("changefeed", d.changeFeedID.ID),
@@ -255,6 +273,14 @@ func (d *dmlWorker) writeDataFile(ctx context.Context, path string, task *single
 	buf := bytes.NewBuffer(make([]byte, 0, task.size))
 	rowsCnt := 0
 	bytesCnt := int64(0)
+	if d.headerEncoder != nil {
+		header, err := d.headerEncoder.EncodeFileHeader(task.tableInfo)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		bytesCnt += int64(len(header))
+		buf.Write(header)
+	}
 	for _, msg := range task.msgs {
 		bytesCnt += int64(len(msg.Value))
 		rowsCnt += msg.GetRowsCount()
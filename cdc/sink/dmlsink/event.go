@@ -22,6 +22,8 @@ import (
 type TableEvent interface {
 	// GetCommitTs returns the commit timestamp of the event.
 	GetCommitTs() uint64
+	// GetStartTs returns the start timestamp of the transaction of the event.
+	GetStartTs() uint64
 	// TrySplitAndSortUpdateEvent split the update to delete and insert if the unique key is updated
 	TrySplitAndSortUpdateEvent(scheme string, outputRawChangeEvent bool) error
 }
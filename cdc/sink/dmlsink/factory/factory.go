@@ -32,6 +32,7 @@ import (
 	cerror "github.com/pingcap/tiflow/pkg/errors"
 	"github.com/pingcap/tiflow/pkg/pdutil"
 	"github.com/pingcap/tiflow/pkg/sink"
+	"github.com/pingcap/tiflow/pkg/sink/codec"
 	"github.com/pingcap/tiflow/pkg/sink/kafka"
 	v2 "github.com/pingcap/tiflow/pkg/sink/kafka/v2"
 	pulsarConfig "github.com/pingcap/tiflow/pkg/sink/pulsar"
@@ -184,6 +185,14 @@ func (s *SinkFactory) Close() {
 	}
 }
 
+// SetMemoryQuota passes the memory quota of the changefeed to the sink,
+// only the MQ sink uses it to spill the pending messages.
+func (s *SinkFactory) SetMemoryQuota(quota codec.SpillQuota) {
+	if sink, ok := s.rowSink.(interface{ SetMemoryQuota(codec.SpillQuota) }); ok {
+		sink.SetMemoryQuota(quota)
+	}
+}
+
 // Category returns category of s.
 func (s *SinkFactory) Category() Category {
 	if s.category == 0 {
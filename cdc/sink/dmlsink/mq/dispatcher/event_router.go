@@ -267,7 +267,7 @@ func validateTopicExpression(expr topic.Expression, scheme string, protocol conf
 	}
 
 	switch protocol {
-	case config.ProtocolAvro:
+	case config.ProtocolAvro, config.ProtocolProtobuf:
 		return expr.ValidateForAvro()
 	default:
 	}
@@ -34,6 +34,17 @@ type DMLProducer interface {
 	Close()
 }
 
+// TxnDMLProducer is the DMLProducer which sends messages in transactions.
+// A transaction is begun by the first message after the last commit, and the
+// callbacks of the messages are called only after the transaction is committed,
+// so the checkpoint never goes beyond the uncommitted messages.
+type TxnDMLProducer interface {
+	DMLProducer
+
+	// Commit commits the messages sent since the last commit.
+	Commit(ctx context.Context) error
+}
+
 // Factory is a function to create a producer.
 // errCh is used to report error to the caller(i.e. processor,owner).
 // Because the caller passes errCh to many goroutines,
@@ -0,0 +1,186 @@
+// Copyright 2024 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dmlproducer
+
+import (
+	"context"
+	"sync"
+
+	"github.com/pingcap/errors"
+	"github.com/pingcap/log"
+	"github.com/pingcap/tiflow/cdc/model"
+	cerror "github.com/pingcap/tiflow/pkg/errors"
+	"github.com/pingcap/tiflow/pkg/sink/codec/common"
+	"github.com/pingcap/tiflow/pkg/sink/kafka"
+	"go.uber.org/zap"
+)
+
+var _ TxnDMLProducer = (*kafkaTxnDMLProducer)(nil)
+
+// kafkaTxnDMLProducer sends messages to kafka in transactions.
+// The messages of all the tables are sent by one transactional producer, whose
+// client is shared by them, and the transactional id is derived from the
+// changefeed and the capture, see NewKafkaTransactionalID.
+// The caller should commit only at the boundaries of the upstream transactions,
+// so the consumers never see a partial upstream transaction.
+type kafkaTxnDMLProducer struct {
+	// id indicates which processor (changefeed) this sink belongs to.
+	id model.ChangeFeedID
+	// txnProducer is used to send messages to kafka in transactions.
+	txnProducer kafka.TransactionalProducer
+	// metricsCollector is used to report metrics.
+	metricsCollector kafka.MetricsCollector
+	// closedMu is used to protect `closed`.
+	// We need to ensure that closed producers are never written to.
+	closedMu sync.RWMutex
+	// closed is used to indicate whether the producer is closed.
+	// We also use it to guard against double closes.
+	closed bool
+
+	// failpointCh is used to inject failpoints to the run loop. Only used in test.
+	failpointCh chan error
+
+	cancel context.CancelFunc
+
+	// inTxn indicates whether a transaction is ongoing.
+	// It's only accessed by the goroutine sending messages.
+	inTxn bool
+	// callbacks are the callbacks of the messages in the ongoing transaction.
+	callbacks []func()
+}
+
+// NewKafkaTxnDMLProducer creates a new kafka producer which sends messages
+// in transactions.
+func NewKafkaTxnDMLProducer(
+	ctx context.Context,
+	changefeedID model.ChangeFeedID,
+	txnProducer kafka.TransactionalProducer,
+	metricsCollector kafka.MetricsCollector,
+	errCh chan error,
+	failpointCh chan error,
+) TxnDMLProducer {
+	log.Info("Starting kafka transactional DML producer ...",
+		zap.String("namespace", changefeedID.Namespace),
+		zap.String("changefeed", changefeedID.ID))
+
+	ctx, cancel := context.WithCancel(ctx)
+	k := &kafkaTxnDMLProducer{
+		id:               changefeedID,
+		txnProducer:      txnProducer,
+		metricsCollector: metricsCollector,
+		failpointCh:      failpointCh,
+		cancel:           cancel,
+	}
+
+	// Start collecting metrics.
+	go k.metricsCollector.Run(ctx)
+
+	go func() {
+		if err := k.txnProducer.AsyncRunCallback(ctx); err != nil &&
+			errors.Cause(err) != context.Canceled {
+			select {
+			case <-ctx.Done():
+				return
+			case errCh <- err:
+				log.Error("Kafka transactional DML producer run error",
+					zap.String("namespace", k.id.Namespace),
+					zap.String("changefeed", k.id.ID),
+					zap.Error(err))
+			default:
+				log.Error("Error channel is full in kafka transactional DML producer",
+					zap.String("namespace", k.id.Namespace),
+					zap.String("changefeed", k.id.ID),
+					zap.Error(err))
+			}
+		}
+	}()
+
+	return k
+}
+
+// AsyncSendMessage sends the message in the ongoing transaction,
+// which is begun if there is none.
+func (k *kafkaTxnDMLProducer) AsyncSendMessage(
+	ctx context.Context, topic string,
+	partition int32, message *common.Message,
+) error {
+	k.closedMu.RLock()
+	defer k.closedMu.RUnlock()
+
+	if k.closed {
+		return cerror.ErrKafkaProducerClosed.GenWithStackByArgs()
+	}
+	if !k.inTxn {
+		if err := k.txnProducer.BeginTxn(); err != nil {
+			return errors.Trace(err)
+		}
+		k.inTxn = true
+	}
+	if message.Callback != nil {
+		k.callbacks = append(k.callbacks, message.Callback)
+	}
+	// the acknowledgement doesn't mean the message is visible to the consumers,
+	// so the callback is deferred until the transaction is committed.
+	msg := *message
+	msg.Callback = nil
+	return k.txnProducer.AsyncSend(ctx, topic, partition, &msg)
+}
+
+// Commit commits the ongoing transaction and calls the callbacks of its messages.
+func (k *kafkaTxnDMLProducer) Commit(ctx context.Context) error {
+	k.closedMu.RLock()
+	defer k.closedMu.RUnlock()
+
+	if k.closed {
+		return cerror.ErrKafkaProducerClosed.GenWithStackByArgs()
+	}
+	if !k.inTxn {
+		return nil
+	}
+	k.inTxn = false
+	callbacks := k.callbacks
+	k.callbacks = nil
+	if err := k.txnProducer.CommitTxn(ctx); err != nil {
+		// The messages of the aborted transaction are discarded by the consumers,
+		// they are sent again after the changefeed is restarted from the checkpoint.
+		if abortErr := k.txnProducer.AbortTxn(ctx); abortErr != nil {
+			log.Warn("Abort kafka transaction failed",
+				zap.String("namespace", k.id.Namespace),
+				zap.String("changefeed", k.id.ID),
+				zap.Error(abortErr))
+		}
+		return errors.Trace(err)
+	}
+	for _, callback := range callbacks {
+		callback()
+	}
+	return nil
+}
+
+func (k *kafkaTxnDMLProducer) Close() {
+	// We have to hold the lock to synchronize closing with writing.
+	k.closedMu.Lock()
+	defer k.closedMu.Unlock()
+	// If the producer has already been closed, we should skip this close operation.
+	if k.closed {
+		log.Warn("Kafka transactional DML producer already closed",
+			zap.String("namespace", k.id.Namespace),
+			zap.String("changefeed", k.id.ID))
+		return
+	}
+	k.cancel()
+	close(k.failpointCh)
+	k.txnProducer.Close()
+	k.closed = true
+}
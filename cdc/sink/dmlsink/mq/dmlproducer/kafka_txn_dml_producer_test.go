@@ -0,0 +1,150 @@
+// Copyright 2024 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dmlproducer
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/pingcap/tiflow/cdc/model"
+	cerror "github.com/pingcap/tiflow/pkg/errors"
+	"github.com/pingcap/tiflow/pkg/sink/codec/common"
+	"github.com/pingcap/tiflow/pkg/sink/kafka"
+	"github.com/pingcap/tiflow/pkg/util"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/atomic"
+)
+
+func newTxnDMLProducer(
+	ctx context.Context, t *testing.T, cluster *kafka.MockCluster, transactionalID string,
+) TxnDMLProducer {
+	options := getOptions()
+	options.TransactionalID = transactionalID
+	changefeed := model.DefaultChangeFeedID("changefeed-test")
+	factory, err := cluster.FactoryCreator()(options, changefeed)
+	require.NoError(t, err)
+
+	adminClient, err := factory.AdminClient(ctx)
+	require.NoError(t, err)
+	metricsCollector := factory.MetricsCollector(util.RoleTester, adminClient)
+	txnProducer, err := factory.TransactionalProducer(ctx, options.TransactionalID, make(chan error, 1))
+	require.NoError(t, err)
+
+	return NewKafkaTxnDMLProducer(ctx, changefeed, txnProducer,
+		metricsCollector, make(chan error, 1), make(chan error, 1))
+}
+
+func TestTxnProducerCommit(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	cluster := kafka.NewMockCluster()
+	producer := newTxnDMLProducer(ctx, t, cluster, "test-txn-id")
+	defer producer.Close()
+
+	// nothing to commit.
+	require.NoError(t, producer.Commit(ctx))
+	require.Equal(t, 0, cluster.AbortedTxnCount())
+
+	count := atomic.NewInt64(0)
+	for i := 0; i < 10; i++ {
+		err := producer.AsyncSendMessage(ctx, kafka.DefaultMockTopicName, int32(i%2), &common.Message{
+			Key:      []byte("test-key"),
+			Value:    []byte("test-value"),
+			Callback: func() { count.Add(1) },
+		})
+		require.NoError(t, err)
+	}
+	// the messages are invisible and not acked before the commit.
+	require.Empty(t, cluster.GetMessages(kafka.DefaultMockTopicName, 0))
+	require.Equal(t, int64(0), count.Load())
+
+	require.NoError(t, producer.Commit(ctx))
+	require.Len(t, cluster.GetMessages(kafka.DefaultMockTopicName, 0), 5)
+	require.Len(t, cluster.GetMessages(kafka.DefaultMockTopicName, 1), 5)
+	require.Equal(t, int64(10), count.Load())
+
+	// the failed transaction is aborted, and the callbacks are never called.
+	cluster.InjectCommitError(errors.New("injected commit error"))
+	err := producer.AsyncSendMessage(ctx, kafka.DefaultMockTopicName, 0, &common.Message{
+		Value:    []byte("test-value"),
+		Callback: func() { count.Add(1) },
+	})
+	require.NoError(t, err)
+	err = producer.Commit(ctx)
+	require.ErrorIs(t, err, cerror.ErrKafkaTransactionFailed)
+	require.Equal(t, 1, cluster.AbortedTxnCount())
+	require.Len(t, cluster.GetMessages(kafka.DefaultMockTopicName, 0), 5)
+	require.Equal(t, int64(10), count.Load())
+}
+
+func TestTxnProducerFenced(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	cluster := kafka.NewMockCluster()
+	zombie := newTxnDMLProducer(ctx, t, cluster, "test-txn-id")
+	defer zombie.Close()
+
+	called := atomic.NewBool(false)
+	err := zombie.AsyncSendMessage(ctx, kafka.DefaultMockTopicName, 0, &common.Message{
+		Value:    []byte("zombie"),
+		Callback: func() { called.Store(true) },
+	})
+	require.NoError(t, err)
+
+	// the producer with the same transactional id fences the previous one.
+	producer := newTxnDMLProducer(ctx, t, cluster, "test-txn-id")
+	defer producer.Close()
+	err = producer.AsyncSendMessage(ctx, kafka.DefaultMockTopicName, 0, &common.Message{
+		Value: []byte("test-value"),
+	})
+	require.NoError(t, err)
+	require.NoError(t, producer.Commit(ctx))
+
+	err = zombie.Commit(ctx)
+	require.ErrorIs(t, err, cerror.ErrKafkaTransactionFailed)
+	require.False(t, called.Load())
+	messages := cluster.GetMessages(kafka.DefaultMockTopicName, 0)
+	require.Len(t, messages, 1)
+	require.Equal(t, []byte("test-value"), messages[0].Value)
+}
+
+func TestTxnProducerOfOtherCapture(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	cluster := kafka.NewMockCluster()
+	producer := newTxnDMLProducer(ctx, t, cluster, "test-txn-id-1")
+	defer producer.Close()
+	err := producer.AsyncSendMessage(ctx, kafka.DefaultMockTopicName, 0,
+		&common.Message{Value: []byte("capture-1")})
+	require.NoError(t, err)
+
+	// the producer of another capture has a different transactional id,
+	// so they never fence each other.
+	other := newTxnDMLProducer(ctx, t, cluster, "test-txn-id-2")
+	defer other.Close()
+	err = other.AsyncSendMessage(ctx, kafka.DefaultMockTopicName, 0,
+		&common.Message{Value: []byte("capture-2")})
+	require.NoError(t, err)
+	require.NoError(t, other.Commit(ctx))
+	require.NoError(t, producer.Commit(ctx))
+
+	messages := cluster.GetMessages(kafka.DefaultMockTopicName, 0)
+	require.Len(t, messages, 2)
+	require.Equal(t, []byte("capture-2"), messages[0].Value)
+	require.Equal(t, []byte("capture-1"), messages[1].Value)
+}
@@ -61,6 +61,9 @@ type pulsarDMLProducer struct {
 	errChan chan error
 
 	pConfig *config.PulsarConfig
+	// onSendDone is called after a message is acknowledged or fails to be sent,
+	// err is nil if it's acknowledged. It's only set in the transactional mode.
+	onSendDone func(err error)
 }
 
 // NewPulsarDMLProducer creates a new pulsar producer.
@@ -121,6 +124,9 @@ func NewPulsarDMLProducer(
 	}
 	log.Info("Pulsar DML producer created", zap.Stringer("changefeed", p.id),
 		zap.Duration("duration", time.Since(start)))
+	if pulsarConfig.GetEnableTransaction() {
+		return newPulsarTxnDMLProducer(p), nil
+	}
 	return p, nil
 }
 
@@ -148,9 +154,23 @@ func (p *pulsarDMLProducer) AsyncSendMessage(
 		p.failpointCh <- errors.New("pulsar sink injected error")
 		failpoint.Return(nil)
 	})
+	return p.sendMessage(ctx, topic, message, nil, message.Callback)
+}
+
+// sendMessage sends the message asynchronously in the transaction if it's not nil,
+// and calls the callback after the message is acknowledged.
+func (p *pulsarDMLProducer) sendMessage(
+	ctx context.Context, topic string, message *common.Message,
+	txn pulsar.Transaction, callback func(),
+) error {
 	data := &pulsar.ProducerMessage{
-		Payload: message.Value,
-		Key:     message.GetPartitionKey(),
+		Payload:     message.Value,
+		Key:         message.GetPartitionKey(),
+		Transaction: txn,
+	}
+	if p.pConfig.GetEnableOrderingKey() {
+		// the key-shared subscriptions dispatch the messages by the ordering key.
+		data.OrderingKey = message.GetPartitionKey()
 	}
 
 	producer, err := p.GetProducerByTopic(topic)
@@ -173,6 +193,9 @@ func (p *pulsarDMLProducer) AsyncSendMessage(
 					zap.String("schema", message.GetSchema()),
 					zap.Error(err))
 				mq.IncPublishedDMLFail(topic, p.id.ID, message.GetSchema())
+				if p.onSendDone != nil {
+					p.onSendDone(e)
+				}
 				// use this select to avoid send error to a closed channel
 				// the ctx will always be called before the errChan is closed
 				select {
@@ -183,11 +206,16 @@ func (p *pulsarDMLProducer) AsyncSendMessage(
 					log.Warn("Error channel is full in pulsar DML producer",
 						zap.Stringer("changefeed", p.id), zap.Error(e))
 				}
-			} else if message.Callback != nil {
-				// success
-				message.Callback()
+				return
+			}
+			// success
+			if callback != nil {
+				callback()
 				mq.IncPublishedDMLSuccess(topic, p.id.ID, message.GetSchema())
 			}
+			if p.onSendDone != nil {
+				p.onSendDone(nil)
+			}
 		})
 
 	mq.IncPublishedDMLCount(topic, p.id.ID, message.GetSchema())
@@ -247,6 +275,11 @@ func newProducer(
 	if pConfig.SendTimeout != nil {
 		option.SendTimeout = pConfig.SendTimeout.Duration()
 	}
+	if pConfig.GetEnableOrderingKey() {
+		// A batch is dispatched to a single consumer of the key-shared subscription,
+		// so the messages are batched by the key to keep them ordered.
+		option.BatcherBuilderType = pulsar.KeyBasedBatchBuilder
+	}
 
 	producer, err := client.CreateProducer(option)
 	if err != nil {
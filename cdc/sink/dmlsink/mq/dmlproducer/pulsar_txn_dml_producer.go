@@ -0,0 +1,186 @@
+// Copyright 2024 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dmlproducer
+
+import (
+	"context"
+	"sync"
+
+	"github.com/apache/pulsar-client-go/pulsar"
+	"github.com/pingcap/errors"
+	"github.com/pingcap/log"
+	"github.com/pingcap/tiflow/cdc/sink/metrics/mq"
+	cerror "github.com/pingcap/tiflow/pkg/errors"
+	"github.com/pingcap/tiflow/pkg/sink/codec/common"
+	"go.uber.org/zap"
+)
+
+var _ TxnDMLProducer = (*pulsarTxnDMLProducer)(nil)
+
+// pulsarTxnDMLProducer sends messages to pulsar in transactions.
+// A transaction is begun by the first message after the last commit,
+// and the callbacks of its messages are called after it's committed.
+// If any message of the transaction fails to be sent, the transaction
+// is aborted, so the consumers never see the partial transaction.
+type pulsarTxnDMLProducer struct {
+	*pulsarDMLProducer
+
+	// txn is the ongoing transaction, it's nil if no transaction is ongoing.
+	txn pulsar.Transaction
+	// pending are the messages sent in the ongoing transaction.
+	pending []pendingTxnMessage
+
+	// inflight waits for the messages sent in the ongoing transaction
+	// to be acknowledged or failed.
+	inflight sync.WaitGroup
+	// sendErrMu protects sendErr, which is set in the send callbacks.
+	sendErrMu sync.Mutex
+	// sendErr is the first error of the messages sent in the ongoing transaction.
+	sendErr error
+}
+
+// pendingTxnMessage is a message waiting for its transaction to be committed.
+type pendingTxnMessage struct {
+	topic    string
+	schema   string
+	callback func()
+}
+
+func newPulsarTxnDMLProducer(p *pulsarDMLProducer) *pulsarTxnDMLProducer {
+	txnProducer := &pulsarTxnDMLProducer{pulsarDMLProducer: p}
+	p.onSendDone = txnProducer.onSendDone
+	return txnProducer
+}
+
+func (p *pulsarTxnDMLProducer) onSendDone(err error) {
+	if err != nil {
+		p.sendErrMu.Lock()
+		if p.sendErr == nil {
+			p.sendErr = err
+		}
+		p.sendErrMu.Unlock()
+	}
+	p.inflight.Done()
+}
+
+func (p *pulsarTxnDMLProducer) takeSendErr() error {
+	p.sendErrMu.Lock()
+	defer p.sendErrMu.Unlock()
+	err := p.sendErr
+	p.sendErr = nil
+	return err
+}
+
+func (p *pulsarTxnDMLProducer) AsyncSendMessage(
+	ctx context.Context, topic string,
+	partition int32, message *common.Message,
+) error {
+	wrapperSchemaAndTopic(message)
+
+	p.closedMu.RLock()
+	defer p.closedMu.RUnlock()
+
+	if p.closed {
+		return cerror.ErrPulsarProducerClosed.GenWithStackByArgs()
+	}
+	if p.txn == nil {
+		txn, err := p.client.NewTransaction(p.pConfig.TransactionTimeout.Duration())
+		if err != nil {
+			return cerror.WrapError(cerror.ErrPulsarTransactionFailed, err)
+		}
+		p.txn = txn
+	}
+	p.pending = append(p.pending, pendingTxnMessage{
+		topic:    topic,
+		schema:   message.GetSchema(),
+		callback: message.Callback,
+	})
+	// the acknowledgement doesn't mean the message is visible to the consumers,
+	// so the callback and the success metric are deferred until the transaction
+	// is committed.
+	p.inflight.Add(1)
+	if err := p.sendMessage(ctx, topic, message, p.txn, nil); err != nil {
+		p.inflight.Done()
+		return err
+	}
+	return nil
+}
+
+// Commit commits the ongoing transaction, calls the callbacks of its messages
+// and records them as published.
+func (p *pulsarTxnDMLProducer) Commit(ctx context.Context) error {
+	p.closedMu.RLock()
+	defer p.closedMu.RUnlock()
+
+	if p.closed {
+		return cerror.ErrPulsarProducerClosed.GenWithStackByArgs()
+	}
+	if p.txn == nil {
+		return nil
+	}
+	txn := p.txn
+	p.txn = nil
+	pending := p.pending
+	p.pending = nil
+
+	// all the messages sent in the transaction must be acknowledged before it's
+	// committed, the transaction is aborted if any of them fails.
+	if err := p.waitInflight(ctx); err != nil {
+		p.abort(ctx, txn)
+		return errors.Trace(err)
+	}
+	if err := p.takeSendErr(); err != nil {
+		p.abort(ctx, txn)
+		return cerror.WrapError(cerror.ErrPulsarTransactionFailed, errors.Trace(err))
+	}
+	if err := txn.Commit(ctx); err != nil {
+		p.abort(ctx, txn)
+		return cerror.WrapError(cerror.ErrPulsarTransactionFailed, errors.Trace(err))
+	}
+	for _, m := range pending {
+		if m.callback != nil {
+			m.callback()
+		}
+		mq.IncPublishedDMLSuccess(m.topic, p.id.ID, m.schema)
+	}
+	return nil
+}
+
+// waitInflight waits for the messages sent in the ongoing transaction
+// to be acknowledged or failed.
+func (p *pulsarTxnDMLProducer) waitInflight(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		p.inflight.Wait()
+		close(done)
+	}()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-done:
+		return nil
+	}
+}
+
+// abort aborts the transaction, the messages of the aborted transaction are
+// discarded by the consumers, they are sent again after the changefeed is
+// restarted from the checkpoint.
+func (p *pulsarTxnDMLProducer) abort(ctx context.Context, txn pulsar.Transaction) {
+	if err := txn.Abort(ctx); err != nil {
+		log.Warn("Abort pulsar transaction failed",
+			zap.String("namespace", p.id.Namespace),
+			zap.String("changefeed", p.id.ID),
+			zap.Error(err))
+	}
+}
@@ -114,13 +114,23 @@ func NewKafkaDMLSink(
 	}
 
 	failpointCh := make(chan error, 1)
-	asyncProducer, err := factory.AsyncProducer(ctx, failpointCh)
-	if err != nil {
-		return nil, cerror.WrapError(cerror.ErrKafkaNewProducer, err)
-	}
-
 	metricsCollector := factory.MetricsCollector(tiflowutil.RoleProcessor, adminClient)
-	dmlProducer := producerCreator(ctx, changefeedID, asyncProducer, metricsCollector, errCh, failpointCh)
+	var dmlProducer dmlproducer.DMLProducer
+	if options.TransactionalID != "" {
+		// the messages are sent in kafka transactions in the exactly-once mode.
+		txnProducer, err := factory.TransactionalProducer(ctx, options.TransactionalID, failpointCh)
+		if err != nil {
+			return nil, cerror.WrapError(cerror.ErrKafkaNewProducer, err)
+		}
+		dmlProducer = dmlproducer.NewKafkaTxnDMLProducer(
+			ctx, changefeedID, txnProducer, metricsCollector, errCh, failpointCh)
+	} else {
+		asyncProducer, err := factory.AsyncProducer(ctx, failpointCh)
+		if err != nil {
+			return nil, cerror.WrapError(cerror.ErrKafkaNewProducer, err)
+		}
+		dmlProducer = producerCreator(ctx, changefeedID, asyncProducer, metricsCollector, errCh, failpointCh)
+	}
 	encoderGroup := codec.NewEncoderGroup(replicaConfig.Sink, encoderBuilder, changefeedID)
 	s := newDMLSink(ctx, changefeedID, dmlProducer, adminClient, topicManager, eventRouter, trans, encoderGroup,
 		protocol, scheme, replicaConfig.Sink.KafkaConfig.GetOutputRawChangeEvent(), errCh)
@@ -166,7 +166,8 @@ func (s *dmlSink) WriteEvents(txns ...*dmlsink.CallbackableEvent[*model.SingleTa
 			continue
 		}
 		rowCallback := toRowCallback(txn.Callback, uint64(len(txn.Event.Rows)))
-		for _, row := range txn.Event.Rows {
+		events := make([]mqEvent, 0, len(txn.Event.Rows))
+		for i, row := range txn.Event.Rows {
 			topic := s.alive.eventRouter.GetTopicForRowChange(row)
 			partitionNum, err := s.alive.topicManager.GetPartitionNum(s.ctx, topic)
 			failpoint.Inject("MQSinkGetPartitionError", func() {
@@ -191,10 +192,7 @@ func (s *dmlSink) WriteEvents(txns ...*dmlsink.CallbackableEvent[*model.SingleTa
 				return errors.Trace(err)
 			}
 
-			// This never be blocked because this is an unbounded channel.
-			// We already limit the memory usage by MemoryQuota at SinkManager level.
-			// So it is safe to send the event to a unbounded channel here.
-			s.alive.worker.msgChan.In() <- mqEvent{
+			events = append(events, mqEvent{
 				key: model.TopicPartitionKey{
 					Topic:          topic,
 					Partition:      index,
@@ -206,8 +204,13 @@ func (s *dmlSink) WriteEvents(txns ...*dmlsink.CallbackableEvent[*model.SingleTa
 					Callback:  rowCallback,
 					SinkState: txn.SinkState,
 				},
-			}
+				txnEnd: i == len(txn.Event.Rows)-1,
+			})
 		}
+		// This never be blocked because this is an unbounded channel.
+		// We already limit the memory usage by MemoryQuota at SinkManager level.
+		// So it is safe to send the events to a unbounded channel here.
+		s.alive.worker.addTxnEvents(events)
 	}
 	return nil
 }
@@ -230,6 +233,16 @@ func (s *dmlSink) Close() {
 	}
 }
 
+// SetMemoryQuota sets the memory quota of the changefeed, which decides when
+// the pending encoded messages are spilled to the local disk.
+func (s *dmlSink) SetMemoryQuota(quota codec.SpillQuota) {
+	s.alive.RLock()
+	defer s.alive.RUnlock()
+	if s.alive.worker != nil {
+		s.alive.worker.encoderGroup.SetMemoryQuota(quota)
+	}
+}
+
 // Dead checks whether it's dead or not.
 func (s *dmlSink) Dead() <-chan struct{} {
 	return s.dead
@@ -15,6 +15,7 @@ package mq
 
 import (
 	"context"
+	"sync"
 	"time"
 
 	"github.com/pingcap/errors"
@@ -46,6 +47,8 @@ const (
 type mqEvent struct {
 	key      model.TopicPartitionKey
 	rowEvent *dmlsink.RowChangeCallbackableEvent
+	// txnEnd indicates whether the row is the last one of its transaction.
+	txnEnd bool
 }
 
 // worker will send messages to the DML producer on a batch basis.
@@ -57,6 +60,8 @@ type worker struct {
 	// msgChan caches the messages to be sent.
 	// It is an unbounded channel.
 	msgChan *chann.DrainableChann[mqEvent]
+	// txnMu makes the rows of a transaction contiguous in msgChan.
+	txnMu sync.Mutex
 	// ticker used to force flush the batched messages when the interval is reached.
 	ticker *time.Ticker
 
@@ -64,6 +69,8 @@ type worker struct {
 
 	// producer is used to send the messages to the Kafka broker.
 	producer dmlproducer.DMLProducer
+	// txnProducer is not nil if the producer sends messages in transactions.
+	txnProducer dmlproducer.TxnDMLProducer
 	// statistics is used to record DML metrics.
 	statistics *metrics.Statistics
 }
@@ -84,6 +91,9 @@ func newWorker(
 		producer:     producer,
 		statistics:   metrics.NewStatistics(id, sink.RowSink),
 	}
+	if txnProducer, ok := producer.(dmlproducer.TxnDMLProducer); ok {
+		w.txnProducer = txnProducer
+	}
 	return w
 }
 
@@ -139,14 +149,17 @@ func (w *worker) nonBatchEncodeRun(ctx context.Context) error {
 					zap.String("namespace", w.changeFeedID.Namespace),
 					zap.String("changefeed", w.changeFeedID.ID),
 					zap.Any("event", event))
-				continue
-			}
-			if err := w.encoderGroup.AddEvents(
+			} else if err := w.encoderGroup.AddEvents(
 				ctx,
 				event.key,
 				event.rowEvent); err != nil {
 				return errors.Trace(err)
 			}
+			if w.txnProducer != nil && event.txnEnd {
+				if err := w.encoderGroup.AddTxnBoundary(ctx); err != nil {
+					return errors.Trace(err)
+				}
+			}
 		}
 	}
 }
@@ -180,16 +193,40 @@ func (w *worker) batchEncodeRun(ctx context.Context) (retErr error) {
 		metricBatchDuration.Observe(time.Since(start).Seconds())
 
 		msgs := msgsBuf[:msgCount]
-		// Group messages by its TopicPartitionKey before adding them to the encoder group.
-		groupedMsgs := w.group(msgs)
-		for key, msg := range groupedMsgs {
-			if err := w.encoderGroup.AddEvents(ctx, key, msg...); err != nil {
-				return errors.Trace(err)
+		if w.txnProducer != nil {
+			// Split the batch at the end of its last transaction, so the
+			// transaction can be committed before the rest of the batch.
+			end := len(msgs) - 1
+			for end >= 0 && !msgs[end].txnEnd {
+				end--
+			}
+			if end >= 0 {
+				if err := w.addEvents(ctx, msgs[:end+1]); err != nil {
+					return errors.Trace(err)
+				}
+				if err := w.encoderGroup.AddTxnBoundary(ctx); err != nil {
+					return errors.Trace(err)
+				}
+				msgs = msgs[end+1:]
 			}
 		}
+		if err := w.addEvents(ctx, msgs); err != nil {
+			return errors.Trace(err)
+		}
 	}
 }
 
+// addEvents groups the messages by its TopicPartitionKey and adds them to the encoder group.
+func (w *worker) addEvents(ctx context.Context, msgs []mqEvent) error {
+	groupedMsgs := w.group(msgs)
+	for key, msg := range groupedMsgs {
+		if err := w.encoderGroup.AddEvents(ctx, key, msg...); err != nil {
+			return errors.Trace(err)
+		}
+	}
+	return nil
+}
+
 // batch collects a batch of messages from w.msgChan into buffer.
 // It returns the number of messages collected.
 // Note: It will block until at least one message is received.
@@ -268,6 +305,8 @@ func (w *worker) sendMessages(ctx context.Context) error {
 	defer mq.WorkerSendMessageDuration.DeleteLabelValues(w.changeFeedID.Namespace, w.changeFeedID.ID)
 
 	var err error
+	// txnMessages is the number of messages in the ongoing transaction.
+	txnMessages := 0
 	outCh := w.encoderGroup.Output()
 	for {
 		select {
@@ -300,10 +339,33 @@ func (w *worker) sendMessages(ctx context.Context) error {
 				}
 				metricSendMessageDuration.Observe(time.Since(start).Seconds())
 			}
+			if w.txnProducer == nil {
+				continue
+			}
+			// Commit the transaction only at the boundary of the upstream transactions,
+			// if there is no more encoded messages, or the transaction is too large
+			// to hold back the checkpoint.
+			txnMessages += len(future.Messages)
+			if future.TxnBoundary && (len(outCh) == 0 || txnMessages >= batchSize) {
+				if err = w.txnProducer.Commit(ctx); err != nil {
+					return errors.Trace(err)
+				}
+				txnMessages = 0
+			}
 		}
 	}
 }
 
+// addTxnEvents sends the events of a transaction to msgChan contiguously,
+// so every event with txnEnd is a boundary of the upstream transactions.
+func (w *worker) addTxnEvents(events []mqEvent) {
+	w.txnMu.Lock()
+	defer w.txnMu.Unlock()
+	for _, event := range events {
+		w.msgChan.In() <- event
+	}
+}
+
 func (w *worker) close() {
 	w.msgChan.CloseAndDrain()
 	w.producer.Close()
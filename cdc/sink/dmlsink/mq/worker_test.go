@@ -29,7 +29,10 @@ import (
 	"github.com/pingcap/tiflow/pkg/sink/codec"
 	"github.com/pingcap/tiflow/pkg/sink/codec/builder"
 	"github.com/pingcap/tiflow/pkg/sink/codec/common"
+	"github.com/pingcap/tiflow/pkg/sink/kafka"
+	"github.com/pingcap/tiflow/pkg/util"
 	"github.com/stretchr/testify/require"
+	"go.uber.org/atomic"
 )
 
 func newBatchEncodeWorker(t *testing.T) (*worker, dmlproducer.DMLProducer) {
@@ -579,3 +582,85 @@ func TestNonBatchEncodeWorker_Abort(t *testing.T) {
 	cancel()
 	wg.Wait()
 }
+
+func newTxnWorker(
+	ctx context.Context, t *testing.T, protocol config.Protocol, cluster *kafka.MockCluster,
+) *worker {
+	id := model.DefaultChangeFeedID("test")
+	encoderConfig := common.NewConfig(protocol).WithMaxMessageBytes(300).WithChangefeedID(id)
+	encoderBuilder, err := builder.NewRowEventEncoderBuilder(ctx, encoderConfig)
+	require.NoError(t, err)
+	factory, err := cluster.FactoryCreator()(kafka.NewOptions(), id)
+	require.NoError(t, err)
+	txnProducer, err := factory.TransactionalProducer(ctx, "test-txn-id", make(chan error, 1))
+	require.NoError(t, err)
+	p := dmlproducer.NewKafkaTxnDMLProducer(ctx, id, txnProducer,
+		factory.MetricsCollector(util.RoleTester, nil), make(chan error, 1), make(chan error, 1))
+	encoderGroup := codec.NewEncoderGroup(config.GetDefaultReplicaConfig().Sink, encoderBuilder, id)
+	return newWorker(id, protocol, p, encoderGroup)
+}
+
+func TestTxnWorker_CommitAtTxnBoundary(t *testing.T) {
+	helper := entry.NewSchemaTestHelper(t)
+	defer helper.Close()
+
+	sql := `create table test.t(a varchar(255) primary key)`
+	job := helper.DDL2Job(sql)
+	tableInfo := model.WrapTableInfo(0, "test", 1, job.BinlogInfo.TableInfo)
+
+	for _, protocol := range []config.Protocol{config.ProtocolOpen, config.ProtocolCanalJSON} {
+		ctx, cancel := context.WithCancel(context.Background())
+		cluster := kafka.NewMockCluster()
+		worker := newTxnWorker(ctx, t, protocol, cluster)
+
+		key := model.TopicPartitionKey{Topic: kafka.DefaultMockTopicName}
+		tableStatus := state.TableSinkSinking
+		var acked atomic.Int64
+		newEvent := func(commitTs uint64, value string, txnEnd bool) mqEvent {
+			return mqEvent{
+				key: key,
+				rowEvent: &dmlsink.RowChangeCallbackableEvent{
+					Event: &model.RowChangedEvent{
+						CommitTs:  commitTs,
+						TableInfo: tableInfo,
+						Columns: model.Columns2ColumnDatas(
+							[]*model.Column{{Name: "a", Type: mysql.TypeVarchar, Value: value}}, tableInfo),
+					},
+					Callback:  func() { acked.Add(1) },
+					SinkState: &tableStatus,
+				},
+				txnEnd: txnEnd,
+			}
+		}
+		worker.addTxnEvents([]mqEvent{
+			newEvent(1, "a", false), newEvent(1, "b", false), newEvent(1, "c", true),
+		})
+		var wg sync.WaitGroup
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_ = worker.run(ctx)
+		}()
+		require.Eventually(t, func() bool {
+			return acked.Load() == 3
+		}, 3*time.Second, 10*time.Millisecond, protocol.String())
+		committed := len(cluster.GetMessages(kafka.DefaultMockTopicName, 0))
+		require.NotZero(t, committed)
+
+		// the transaction isn't committed until all its rows are sent.
+		worker.msgChan.In() <- newEvent(2, "d", false)
+		worker.msgChan.In() <- newEvent(2, "e", false)
+		require.Never(t, func() bool {
+			return acked.Load() > 3
+		}, 300*time.Millisecond, 10*time.Millisecond, protocol.String())
+		require.Equal(t, committed, len(cluster.GetMessages(kafka.DefaultMockTopicName, 0)))
+		worker.msgChan.In() <- newEvent(2, "f", true)
+		require.Eventually(t, func() bool {
+			return acked.Load() == 6
+		}, 3*time.Second, 10*time.Millisecond, protocol.String())
+
+		cancel()
+		wg.Wait()
+		worker.close()
+	}
+}
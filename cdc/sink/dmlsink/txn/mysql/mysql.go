@@ -79,6 +79,9 @@ type mysqlBackend struct {
 	// Indicate if the CachePrepStmts should be enabled or not
 	cachePrepStmts   bool
 	maxAllowedPacket int64
+
+	// verifier is nil if the rows written to the downstream are not verified.
+	verifier *rowVerifier
 }
 
 // NewMySQLBackends creates a new MySQL sink using schema storage
@@ -173,6 +176,14 @@ func NewMySQLBackends(
 		maxAllowedPacket = int64(variable.DefMaxAllowedPacket)
 	}
 
+	var verifier *rowVerifier
+	if replicaConfig != nil {
+		verifier, err = newRowVerifier(changefeedID, db, cfg, replicaConfig.Integrity)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	backends := make([]*mysqlBackend, 0, cfg.WorkerCount)
 	for i := 0; i < cfg.WorkerCount; i++ {
 		backends = append(backends, &mysqlBackend{
@@ -189,6 +200,7 @@ func NewMySQLBackends(
 			stmtCache:                       stmtCache,
 			cachePrepStmts:                  cachePrepStmts,
 			maxAllowedPacket:                maxAllowedPacket,
+			verifier:                        verifier,
 		})
 	}
 
@@ -235,6 +247,16 @@ func (s *mysqlBackend) Flush(ctx context.Context) (err error) {
 		}
 		return errors.Trace(err)
 	}
+	if s.verifier != nil {
+		// the rows are verified before the callbacks, so they can't be
+		// overwritten by the following events of the same keys. It delays
+		// the flush by a query per verifyBatchSize rows, each of which is
+		// bounded by verifyTimeout, and the number of rows is bounded by
+		// maxVerifyRowsPerFlush unless the verify level is full.
+		if err := s.verifier.verify(ctx, s.events); err != nil {
+			return errors.Trace(err)
+		}
+	}
 	startCallback := time.Now()
 	for _, callback := range dmls.callbacks {
 		callback()
@@ -0,0 +1,459 @@
+// Copyright 2024 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"math/rand"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pingcap/errors"
+	"github.com/pingcap/log"
+	timodel "github.com/pingcap/tidb/pkg/meta/model"
+	"github.com/pingcap/tidb/pkg/parser/charset"
+	"github.com/pingcap/tidb/pkg/types"
+	"github.com/pingcap/tiflow/cdc/model"
+	"github.com/pingcap/tiflow/cdc/sink/dmlsink"
+	"github.com/pingcap/tiflow/cdc/sink/metrics/txn"
+	"github.com/pingcap/tiflow/pkg/config"
+	cerror "github.com/pingcap/tiflow/pkg/errors"
+	"github.com/pingcap/tiflow/pkg/integrity"
+	"github.com/pingcap/tiflow/pkg/quotes"
+	pmysql "github.com/pingcap/tiflow/pkg/sink/mysql"
+	"github.com/pingcap/tiflow/pkg/util"
+	"go.uber.org/zap"
+)
+
+// verifyTimeout is the timeout of reading a batch of rows back from the downstream.
+const verifyTimeout = 30 * time.Second
+
+// verifyBatchSize is the max number of rows read back from the downstream in one query.
+const verifyBatchSize = 256
+
+// maxVerifyRowsPerFlush is the max number of rows verified in one flush at the
+// sample level, the rows are sampled if there are more, to bound the latency
+// added to a flush. The rows dropped are counted as skipped.
+// At the full level every row is verified.
+const maxVerifyRowsPerFlush = 1024
+
+// skipVerifyLogInterval is the min interval of logging the skipped rows.
+const skipVerifyLogInterval = time.Minute
+
+// rowVerifier reads the rows written by the backend back from the downstream,
+// and verifies their checksums against the checksums calculated by the mounter.
+type rowVerifier struct {
+	changefeedID model.ChangeFeedID
+	db           *sql.DB
+	// tz is the time zone of the downstream session, the timestamps read
+	// back from the downstream are in this time zone.
+	tz    *time.Location
+	ratio float64
+	// full is true if every row is verified, without the maxVerifyRowsPerFlush limit.
+	full        bool
+	errorHandle bool
+
+	// the verifier is shared by the backends, mu protects the following fields.
+	// skipped is the number of the rows skipped since lastSkipLog, and
+	// skipErr is the last error of reading the downstream since lastSkipLog,
+	// they are logged at most once per skipVerifyLogInterval.
+	mu          sync.Mutex
+	skipped     int
+	skipErr     error
+	lastSkipLog time.Time
+}
+
+func newRowVerifier(
+	changefeedID model.ChangeFeedID, db *sql.DB, cfg *pmysql.Config, integrityCfg *integrity.Config,
+) (*rowVerifier, error) {
+	if integrityCfg == nil || !integrityCfg.DownstreamVerifyEnabled() {
+		return nil, nil
+	}
+	// the session time zone is not set if the time zone of the sink is empty,
+	// assume the downstream uses the same time zone as the server in that case.
+	tzName := strings.Trim(cfg.Timezone, `"`)
+	if tzName == "" {
+		tzName = config.GetGlobalServerConfig().TZ
+	}
+	tz, err := util.GetTimezone(tzName)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	log.Info("verify the rows written to the downstream",
+		zap.String("namespace", changefeedID.Namespace),
+		zap.String("changefeed", changefeedID.ID),
+		zap.String("level", integrityCfg.DownstreamVerifyLevel),
+		zap.Float64("ratio", integrityCfg.DownstreamVerifyRatio()))
+	return &rowVerifier{
+		changefeedID: changefeedID,
+		db:           db,
+		tz:           tz,
+		ratio:        integrityCfg.DownstreamVerifyRatio(),
+		full:         integrityCfg.DownstreamVerifyLevel == integrity.DownstreamVerifyLevelFull,
+		errorHandle:  integrityCfg.ErrorHandle(),
+	}, nil
+}
+
+// verify verifies the rows of the flushed events. Only the last image of each
+// row in the events is verified, since the earlier ones are overwritten in the
+// downstream. The rows without checksum or handle key are skipped.
+// The rows of a table are read back from the downstream in batches by their
+// handle keys, at the sample level at most maxVerifyRowsPerFlush rows are
+// verified per call. The call is synchronous, it takes a query of at most
+// verifyTimeout per verifyBatchSize rows.
+// An error is returned only if corrupted rows are found and the corruption
+// handle level is error, the rows failed to be read from the downstream are
+// skipped, they are logged and counted.
+func (v *rowVerifier) verify(ctx context.Context, events []*dmlsink.TxnCallbackableEvent) error {
+	corrupted := false
+	// the rows of different table versions have different columns, so
+	// they are grouped by the table info instead of the table ID.
+	var tables []*model.TableInfo
+	tableRows := make(map[*model.TableInfo][]*model.RowChangedEvent)
+	rows, dropped := v.pickRows(events)
+	for _, row := range dropped {
+		v.skip(row.TableInfo.TableName.String(), 1, nil)
+	}
+	defer v.logSkipped()
+	for _, row := range rows {
+		if row.Checksum.Corrupted {
+			v.report(row, newCorruptedKey(row, integrity.CorruptedReasonUpstream))
+			corrupted = true
+			continue
+		}
+		if _, ok := tableRows[row.TableInfo]; !ok {
+			tables = append(tables, row.TableInfo)
+		}
+		tableRows[row.TableInfo] = append(tableRows[row.TableInfo], row)
+	}
+	for _, tableInfo := range tables {
+		rows := tableRows[tableInfo]
+		for len(rows) > 0 {
+			n := min(len(rows), verifyBatchSize)
+			if !v.verifyRows(ctx, rows[:n]) {
+				corrupted = true
+			}
+			rows = rows[n:]
+		}
+	}
+	if corrupted && v.errorHandle {
+		return cerror.ErrCorruptedDataMutation.GenWithStackByArgs(
+			v.changefeedID.Namespace, v.changefeedID.ID)
+	}
+	return nil
+}
+
+// pickRows returns the last images of the rows in the events to verify,
+// and the rows dropped by the maxVerifyRowsPerFlush limit.
+func (v *rowVerifier) pickRows(
+	events []*dmlsink.TxnCallbackableEvent,
+) (rows, dropped []*model.RowChangedEvent) {
+	type rowKey struct {
+		tableID int64
+		key     string
+	}
+	var order []rowKey
+	latest := make(map[rowKey]*model.RowChangedEvent)
+	for _, event := range events {
+		for _, row := range event.Event.Rows {
+			if row.PreColumns != nil {
+				// the old image is deleted or overwritten.
+				delete(latest, rowKey{tableID: row.PhysicalTableID, key: handleKeyString(row, row.PreColumns)})
+			}
+			if row.IsDelete() || row.Checksum == nil {
+				continue
+			}
+			key := rowKey{tableID: row.PhysicalTableID, key: handleKeyString(row, row.Columns)}
+			if key.key == "" {
+				continue
+			}
+			if _, ok := latest[key]; !ok {
+				order = append(order, key)
+			}
+			latest[key] = row
+		}
+	}
+
+	rows = make([]*model.RowChangedEvent, 0, len(latest))
+	for _, key := range order {
+		row, ok := latest[key]
+		if !ok {
+			continue
+		}
+		// the key is in order more than once if it's deleted and written again.
+		delete(latest, key)
+		if v.ratio < 1 && rand.Float64() >= v.ratio {
+			continue
+		}
+		rows = append(rows, row)
+	}
+	if !v.full && len(rows) > maxVerifyRowsPerFlush {
+		rand.Shuffle(len(rows), func(i, j int) { rows[i], rows[j] = rows[j], rows[i] })
+		rows, dropped = rows[:maxVerifyRowsPerFlush], rows[maxVerifyRowsPerFlush:]
+	}
+	return rows, dropped
+}
+
+// skip counts the rows of the table which are not verified, err is
+// the error of reading them from the downstream if any.
+func (v *rowVerifier) skip(tableName string, n int, err error) {
+	txn.SkippedVerifyRows.WithLabelValues(
+		v.changefeedID.Namespace, v.changefeedID.ID, tableName).Add(float64(n))
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.skipped += n
+	if err != nil {
+		v.skipErr = err
+	}
+}
+
+// logSkipped logs the rows skipped since the last log, at most once per skipVerifyLogInterval.
+func (v *rowVerifier) logSkipped() {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if v.skipped == 0 || time.Since(v.lastSkipLog) < skipVerifyLogInterval {
+		return
+	}
+	log.Warn("rows are skipped to verify against the downstream, "+
+		"they exceed the limit of a flush or fail to be read from the downstream",
+		zap.String("namespace", v.changefeedID.Namespace),
+		zap.String("changefeed", v.changefeedID.ID),
+		zap.Int("rows", v.skipped),
+		zap.Int("maxVerifyRowsPerFlush", maxVerifyRowsPerFlush),
+		zap.Error(v.skipErr))
+	v.skipped, v.skipErr = 0, nil
+	v.lastSkipLog = time.Now()
+}
+
+// verifyRows verifies the rows of the same table version, it returns false
+// if any of them is found corrupted.
+func (v *rowVerifier) verifyRows(ctx context.Context, rows []*model.RowChangedEvent) bool {
+	tableName := rows[0].TableInfo.TableName.String()
+	checksums, found, err := v.downstreamChecksums(ctx, rows)
+	if err != nil {
+		v.skip(tableName, len(rows), err)
+		return true
+	}
+	txn.VerifiedRows.WithLabelValues(
+		v.changefeedID.Namespace, v.changefeedID.ID, tableName).Add(float64(len(rows)))
+	ok := true
+	for i, row := range rows {
+		var key *integrity.CorruptedKey
+		switch {
+		case !found[i]:
+			key = newCorruptedKey(row, integrity.CorruptedReasonMissing)
+		case checksums[i] != row.Checksum.Current:
+			key = newCorruptedKey(row, integrity.CorruptedReasonMismatch)
+			key.Actual = checksums[i]
+		default:
+			continue
+		}
+		v.report(row, key)
+		ok = false
+	}
+	return ok
+}
+
+func newCorruptedKey(row *model.RowChangedEvent, reason string) *integrity.CorruptedKey {
+	return &integrity.CorruptedKey{
+		Schema:   row.TableInfo.GetSchemaName(),
+		Table:    row.TableInfo.GetTableName(),
+		Key:      handleKeyString(row, row.Columns),
+		CommitTs: row.CommitTs,
+		Expected: row.Checksum.Current,
+		Reason:   reason,
+	}
+}
+
+func (v *rowVerifier) report(row *model.RowChangedEvent, key *integrity.CorruptedKey) {
+	key.DetectTime = time.Now()
+	log.Warn("corrupted row found in the downstream",
+		zap.String("namespace", v.changefeedID.Namespace),
+		zap.String("changefeed", v.changefeedID.ID),
+		zap.String("schema", key.Schema),
+		zap.String("table", key.Table),
+		zap.String("key", key.Key),
+		zap.Uint64("commitTs", key.CommitTs),
+		zap.Uint32("expected", key.Expected),
+		zap.Uint32("actual", key.Actual),
+		zap.String("reason", key.Reason))
+	txn.CorruptedRows.WithLabelValues(
+		v.changefeedID.Namespace, v.changefeedID.ID, row.TableInfo.TableName.String()).Inc()
+	integrity.AddCorruptedKey(v.changefeedID.Namespace, v.changefeedID.ID, *key)
+}
+
+// downstreamChecksums reads the rows of the same table version back from
+// the downstream by their handle keys in one query, and calculates their
+// checksums. found[i] is false if the i-th row doesn't exist.
+func (v *rowVerifier) downstreamChecksums(
+	ctx context.Context, rows []*model.RowChangedEvent,
+) (checksums []uint32, found []bool, err error) {
+	tableInfo := rows[0].TableInfo
+	columnInfos := make([]*timodel.ColumnInfo, 0, len(rows[0].Columns))
+	names := make([]string, 0, len(rows[0].Columns))
+	// keyOffsets are the offsets of the handle key columns in columnInfos.
+	var keyOffsets []int
+	for _, col := range rows[0].Columns {
+		if col == nil {
+			continue
+		}
+		if tableInfo.ForceGetColumnFlagType(col.ColumnID).IsHandleKey() {
+			keyOffsets = append(keyOffsets, len(columnInfos))
+		}
+		colInfo := tableInfo.ForceGetColumnInfo(col.ColumnID)
+		columnInfos = append(columnInfos, colInfo)
+		names = append(names, quotes.QuoteName(colInfo.Name.O))
+	}
+	keyInfos := make([]*timodel.ColumnInfo, 0, len(keyOffsets))
+	keyNames := make([]string, 0, len(keyOffsets))
+	for _, offset := range keyOffsets {
+		keyInfos = append(keyInfos, columnInfos[offset])
+		keyNames = append(keyNames, names[offset])
+	}
+
+	// the rows are matched by the handle keys converted in the same way
+	// as the values read back from the downstream.
+	index := make(map[string]int, len(rows))
+	args := make([]interface{}, 0, len(rows)*len(keyOffsets))
+	for i, row := range rows {
+		keyCols, _ := row.HandleKeyColInfos()
+		values := make([]interface{}, 0, len(keyCols))
+		for _, col := range keyCols {
+			values = append(values, col.Value)
+		}
+		key, err := v.datumsKey(keyInfos, values)
+		if err != nil {
+			return nil, nil, errors.Trace(err)
+		}
+		index[key] = i
+		args = append(args, values...)
+	}
+	placeholder := "?"
+	target := keyNames[0]
+	if len(keyNames) > 1 {
+		placeholder = "(" + strings.TrimSuffix(strings.Repeat("?,", len(keyNames)), ",") + ")"
+		target = "(" + strings.Join(keyNames, ", ") + ")"
+	}
+	query := "SELECT " + strings.Join(names, ", ") + " FROM " + tableInfo.TableName.QuoteString() +
+		" WHERE " + target + " IN (" +
+		strings.TrimSuffix(strings.Repeat(placeholder+",", len(rows)), ",") + ")"
+
+	ctx, cancel := context.WithTimeout(ctx, verifyTimeout)
+	defer cancel()
+	result, err := v.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, nil, errors.Trace(err)
+	}
+	defer result.Close()
+
+	checksums = make([]uint32, len(rows))
+	found = make([]bool, len(rows))
+	values := make([]interface{}, len(columnInfos))
+	dest := make([]interface{}, len(columnInfos))
+	for i := range values {
+		dest[i] = &values[i]
+	}
+	for result.Next() {
+		if err := result.Scan(dest...); err != nil {
+			return nil, nil, errors.Trace(err)
+		}
+		datums, err := v.toDatums(columnInfos, values)
+		if err != nil {
+			return nil, nil, errors.Trace(err)
+		}
+		keyValues := make([]interface{}, 0, len(keyOffsets))
+		for _, offset := range keyOffsets {
+			keyValues = append(keyValues, values[offset])
+		}
+		key, err := v.datumsKey(keyInfos, keyValues)
+		if err != nil {
+			return nil, nil, errors.Trace(err)
+		}
+		i, ok := index[key]
+		if !ok {
+			continue
+		}
+		checksum, err := integrity.CalculateColumnChecksum(columnInfos, datums, v.tz)
+		if err != nil {
+			return nil, nil, errors.Trace(err)
+		}
+		checksums[i], found[i] = checksum, true
+	}
+	if err := result.Err(); err != nil {
+		return nil, nil, errors.Trace(err)
+	}
+	return checksums, found, nil
+}
+
+// datumsKey returns the key of the handle key values, the values are
+// converted to the datums of the column types first.
+func (v *rowVerifier) datumsKey(keyInfos []*timodel.ColumnInfo, values []interface{}) (string, error) {
+	datums, err := v.toDatums(keyInfos, values)
+	if err != nil {
+		return "", errors.Trace(err)
+	}
+	var b strings.Builder
+	for i, d := range datums {
+		s, err := d.ToString()
+		if err != nil {
+			return "", errors.Trace(err)
+		}
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(strconv.Quote(s))
+	}
+	return b.String(), nil
+}
+
+// toDatums converts the values read from the downstream to the datums of
+// the column types, in the same form as the datums decoded by the mounter.
+func (v *rowVerifier) toDatums(columnInfos []*timodel.ColumnInfo, values []interface{}) ([]types.Datum, error) {
+	typeCtx := types.DefaultStmtNoWarningContext.WithLocation(v.tz)
+	datums := make([]types.Datum, len(values))
+	for i, value := range values {
+		colInfo := columnInfos[i]
+		if b, ok := value.([]byte); ok && colInfo.GetCharset() != charset.CharsetBin {
+			value = string(b)
+		}
+		d := types.NewDatum(value)
+		d, err := d.ConvertTo(typeCtx, &colInfo.FieldType)
+		if err != nil {
+			return nil, errors.Annotatef(err, "convert column %s", colInfo.Name.O)
+		}
+		datums[i] = d
+	}
+	return datums, nil
+}
+
+// handleKeyString returns the handle key of the row in the columns, in the
+// form of `col1=val1,col2=val2`. It's empty if the row has no handle key.
+func handleKeyString(row *model.RowChangedEvent, cols []*model.ColumnData) string {
+	var b strings.Builder
+	for _, col := range cols {
+		if col == nil || !row.TableInfo.ForceGetColumnFlagType(col.ColumnID).IsHandleKey() {
+			continue
+		}
+		if b.Len() > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(row.TableInfo.ForceGetColumnName(col.ColumnID))
+		b.WriteByte('=')
+		b.WriteString(model.ColumnValueString(col.Value))
+	}
+	return b.String()
+}
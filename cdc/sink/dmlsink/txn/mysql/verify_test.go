@@ -0,0 +1,211 @@
+// Copyright 2024 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mysql
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/pingcap/errors"
+	"github.com/pingcap/tidb/pkg/parser/mysql"
+	"github.com/pingcap/tiflow/cdc/model"
+	"github.com/pingcap/tiflow/cdc/sink/dmlsink"
+	cerror "github.com/pingcap/tiflow/pkg/errors"
+	"github.com/pingcap/tiflow/pkg/integrity"
+	pmysql "github.com/pingcap/tiflow/pkg/sink/mysql"
+	"github.com/stretchr/testify/require"
+)
+
+func newVerifyTestRow(
+	tableInfo *model.TableInfo, pre, post []interface{}, checksum *integrity.Checksum,
+) *model.RowChangedEvent {
+	toColumns := func(values []interface{}) []*model.ColumnData {
+		if values == nil {
+			return nil
+		}
+		return model.Columns2ColumnDatas([]*model.Column{
+			{Name: "a", Value: values[0]},
+			{Name: "b", Value: values[1]},
+		}, tableInfo)
+	}
+	return &model.RowChangedEvent{
+		StartTs:         1,
+		CommitTs:        2,
+		TableInfo:       tableInfo,
+		PhysicalTableID: 1,
+		PreColumns:      toColumns(pre),
+		Columns:         toColumns(post),
+		Checksum:        checksum,
+	}
+}
+
+func newVerifyTestTableInfo() *model.TableInfo {
+	tableInfo := model.BuildTableInfo("s1", "t1", []*model.Column{
+		{
+			Name: "a",
+			Type: mysql.TypeLong,
+			Flag: model.HandleKeyFlag | model.PrimaryKeyFlag,
+		},
+		{
+			Name: "b",
+			Type: mysql.TypeVarchar,
+			Flag: 0,
+		},
+	}, [][]int{{0}})
+	tableInfo.Columns[1].SetFlen(16)
+	return tableInfo
+}
+
+func TestRowVerifierPickRows(t *testing.T) {
+	t.Parallel()
+
+	tableInfo := newVerifyTestTableInfo()
+	checksum := &integrity.Checksum{Current: 1}
+	rows := []*model.RowChangedEvent{
+		// overwritten by the update of the same key.
+		newVerifyTestRow(tableInfo, nil, []interface{}{1, "a"}, checksum),
+		newVerifyTestRow(tableInfo, []interface{}{1, "a"}, []interface{}{1, "b"}, checksum),
+		// deleted later.
+		newVerifyTestRow(tableInfo, nil, []interface{}{2, "a"}, checksum),
+		newVerifyTestRow(tableInfo, []interface{}{2, "a"}, nil, nil),
+		// the handle key is updated.
+		newVerifyTestRow(tableInfo, nil, []interface{}{3, "a"}, checksum),
+		newVerifyTestRow(tableInfo, []interface{}{3, "a"}, []interface{}{4, "a"}, checksum),
+		// without checksum.
+		newVerifyTestRow(tableInfo, nil, []interface{}{5, "a"}, nil),
+	}
+	v := &rowVerifier{ratio: 1}
+	picked, _ := v.pickRows([]*dmlsink.TxnCallbackableEvent{
+		{Event: &model.SingleTableTxn{Rows: rows[:4]}},
+		{Event: &model.SingleTableTxn{Rows: rows[4:]}},
+	})
+	require.Equal(t, []*model.RowChangedEvent{rows[1], rows[5]}, picked)
+	require.Equal(t, "a=1", handleKeyString(rows[1], rows[1].Columns))
+}
+
+func TestRowVerifierReportCorruptedRows(t *testing.T) {
+	t.Parallel()
+
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	require.NoError(t, err)
+	defer db.Close()
+
+	changefeedID := model.DefaultChangeFeedID("test-verify-corrupted-rows")
+	v, err := newRowVerifier(changefeedID, db, &pmysql.Config{Timezone: `"UTC"`},
+		&integrity.Config{
+			IntegrityCheckLevel:   integrity.CheckLevelCorrectness,
+			CorruptionHandleLevel: integrity.CorruptionHandleLevelWarn,
+			DownstreamVerifyLevel: integrity.DownstreamVerifyLevelFull,
+		})
+	require.NoError(t, err)
+	require.NotNil(t, v)
+
+	tableInfo := newVerifyTestTableInfo()
+	columnInfos := tableInfo.Columns
+	datums, err := v.toDatums(columnInfos, []interface{}{int64(1), []byte("a")})
+	require.NoError(t, err)
+	checksum, err := integrity.CalculateColumnChecksum(columnInfos, datums, v.tz)
+	require.NoError(t, err)
+
+	rows := []*model.RowChangedEvent{
+		newVerifyTestRow(tableInfo, nil, []interface{}{1, "a"}, &integrity.Checksum{Current: checksum}),
+		newVerifyTestRow(tableInfo, nil, []interface{}{2, "a"},
+			&integrity.Checksum{Current: 2, Corrupted: true}),
+		newVerifyTestRow(tableInfo, nil, []interface{}{3, "a"}, &integrity.Checksum{Current: checksum}),
+		newVerifyTestRow(tableInfo, nil, []interface{}{4, "a"}, &integrity.Checksum{Current: 4}),
+	}
+	// the rows are read back in one query, the upstream corrupted row is skipped.
+	mock.ExpectQuery("SELECT `a`, `b` FROM `s1`.`t1` WHERE `a` IN (?,?,?)").
+		WithArgs(1, 3, 4).
+		WillReturnRows(sqlmock.NewRows([]string{"a", "b"}).
+			AddRow(int64(4), []byte("a")).
+			AddRow(int64(1), []byte("a")))
+	events := []*dmlsink.TxnCallbackableEvent{{Event: &model.SingleTableTxn{Rows: rows}}}
+	require.NoError(t, v.verify(context.Background(), events))
+	require.NoError(t, mock.ExpectationsWereMet())
+
+	keys := integrity.ListCorruptedKeys(changefeedID.Namespace, changefeedID.ID)
+	require.Len(t, keys, 3)
+	require.Equal(t, "a=2", keys[0].Key)
+	require.Equal(t, integrity.CorruptedReasonUpstream, keys[0].Reason)
+	require.Equal(t, "a=3", keys[1].Key)
+	require.Equal(t, integrity.CorruptedReasonMissing, keys[1].Reason)
+	require.Equal(t, "a=4", keys[2].Key)
+	require.Equal(t, integrity.CorruptedReasonMismatch, keys[2].Reason)
+	require.NotEqual(t, uint32(4), keys[2].Actual)
+
+	// stop the changefeed if the corruption handle level is error.
+	v.errorHandle = true
+	err = v.verify(context.Background(), []*dmlsink.TxnCallbackableEvent{
+		{Event: &model.SingleTableTxn{Rows: rows[1:2]}},
+	})
+	require.ErrorIs(t, err, cerror.ErrCorruptedDataMutation)
+}
+
+func TestRowVerifierSampleRows(t *testing.T) {
+	t.Parallel()
+
+	tableInfo := newVerifyTestTableInfo()
+	checksum := &integrity.Checksum{Current: 1}
+	rows := make([]*model.RowChangedEvent, 0, maxVerifyRowsPerFlush*2)
+	for i := 0; i < maxVerifyRowsPerFlush*2; i++ {
+		rows = append(rows, newVerifyTestRow(tableInfo, nil, []interface{}{i, "a"}, checksum))
+	}
+	v := &rowVerifier{ratio: 1}
+	picked, _ := v.pickRows([]*dmlsink.TxnCallbackableEvent{{Event: &model.SingleTableTxn{Rows: rows}}})
+	require.Len(t, picked, maxVerifyRowsPerFlush)
+}
+
+func TestRowVerifierSkipRows(t *testing.T) {
+	t.Parallel()
+
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	require.NoError(t, err)
+	defer db.Close()
+
+	changefeedID := model.DefaultChangeFeedID("test-verify-skip-rows")
+	v, err := newRowVerifier(changefeedID, db, &pmysql.Config{Timezone: `"UTC"`},
+		&integrity.Config{
+			IntegrityCheckLevel:   integrity.CheckLevelCorrectness,
+			CorruptionHandleLevel: integrity.CorruptionHandleLevelError,
+			DownstreamVerifyLevel: integrity.DownstreamVerifyLevelFull,
+		})
+	require.NoError(t, err)
+	require.True(t, v.full)
+
+	tableInfo := newVerifyTestTableInfo()
+	rows := []*model.RowChangedEvent{
+		newVerifyTestRow(tableInfo, nil, []interface{}{1, "a"}, &integrity.Checksum{Current: 1}),
+		newVerifyTestRow(tableInfo, nil, []interface{}{2, "a"}, &integrity.Checksum{Current: 2}),
+	}
+	// the rows failed to be read from the downstream are skipped and logged.
+	mock.ExpectQuery("SELECT `a`, `b` FROM `s1`.`t1` WHERE `a` IN (?,?)").
+		WithArgs(1, 2).
+		WillReturnError(errors.New("downstream is unavailable"))
+	events := []*dmlsink.TxnCallbackableEvent{{Event: &model.SingleTableTxn{Rows: rows}}}
+	require.NoError(t, v.verify(context.Background(), events))
+	require.NoError(t, mock.ExpectationsWereMet())
+	require.Zero(t, v.skipped)
+	require.False(t, v.lastSkipLog.IsZero())
+
+	// the following skipped rows are accumulated until the next log.
+	mock.ExpectQuery("SELECT `a`, `b` FROM `s1`.`t1` WHERE `a` IN (?,?)").
+		WithArgs(1, 2).
+		WillReturnError(errors.New("downstream is unavailable"))
+	require.NoError(t, v.verify(context.Background(), events))
+	require.NoError(t, mock.ExpectationsWereMet())
+	require.Equal(t, 2, v.skipped)
+	require.Error(t, v.skipErr)
+}
@@ -88,6 +88,34 @@ var (
 			Name:      "txn_prepare_statement_errors",
 			Help:      "Prepare statement errors",
 		}, []string{"namespace", "changefeed"})
+
+	// VerifiedRows records the rows read back from the downstream to verify their checksums.
+	VerifiedRows = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "ticdc",
+			Subsystem: "sink",
+			Name:      "txn_verified_rows",
+			Help:      "The number of rows verified against the downstream",
+		}, []string{"namespace", "changefeed", "table"})
+
+	// SkippedVerifyRows records the rows which should be verified against the
+	// downstream but are skipped, e.g. the downstream fails to be read.
+	SkippedVerifyRows = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "ticdc",
+			Subsystem: "sink",
+			Name:      "txn_skipped_verify_rows",
+			Help:      "The number of rows skipped to verify against the downstream",
+		}, []string{"namespace", "changefeed", "table"})
+
+	// CorruptedRows records the rows whose downstream data is found corrupted.
+	CorruptedRows = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "ticdc",
+			Subsystem: "sink",
+			Name:      "txn_corrupted_rows",
+			Help:      "The number of rows whose downstream data is found corrupted",
+		}, []string{"namespace", "changefeed", "table"})
 )
 
 // InitMetrics registers all metrics in this file.
@@ -100,4 +128,7 @@ func InitMetrics(registry *prometheus.Registry) {
 	registry.MustRegister(SinkDMLBatchCommit)
 	registry.MustRegister(SinkDMLBatchCallback)
 	registry.MustRegister(PrepareStatementErrors)
+	registry.MustRegister(VerifiedRows)
+	registry.MustRegister(SkippedVerifyRows)
+	registry.MustRegister(CorruptedRows)
 }
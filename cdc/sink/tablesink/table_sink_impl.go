@@ -23,8 +23,10 @@ import (
 	"github.com/pingcap/tiflow/cdc/sink/dmlsink"
 	"github.com/pingcap/tiflow/cdc/sink/tablesink/state"
 	"github.com/pingcap/tiflow/pkg/pdutil"
+	"github.com/pingcap/tiflow/pkg/tracing"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/tikv/client-go/v2/oracle"
+	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
 )
 
@@ -145,9 +147,13 @@ func (e *EventTableSink[E, P]) UpdateResolvedTs(resolvedTs model.ResolvedTs) err
 		postEventFlushFunc := e.progressTracker.addEvent()
 		evCommitTs := ev.GetCommitTs()
 		phyCommitTs := oracle.ExtractPhysical(evCommitTs)
+		flushSpan := e.startFlushSpan(ev)
 		ce := &dmlsink.CallbackableEvent[E]{
 			Event: ev,
 			Callback: func() {
+				if flushSpan != nil {
+					flushSpan.End()
+				}
 				// Due to multi workers will call this callback concurrently,
 				// we need to add lock to protect lastSyncedTs
 				// we need make a performance test for it
@@ -178,6 +184,18 @@ func (e *EventTableSink[E, P]) UpdateResolvedTs(resolvedTs model.ResolvedTs) err
 	return nil
 }
 
+// startFlushSpan starts a SpanSinkFlush span which ends when the event is
+// acknowledged, it returns nil if the transaction is not sampled.
+func (e *EventTableSink[E, P]) startFlushSpan(ev E) trace.Span {
+	sc := tracing.TxnSpanContext(e.changefeedID, ev.GetStartTs(), ev.GetCommitTs())
+	if !sc.IsSampled() {
+		return nil
+	}
+	return tracing.StartSpan(sc, tracing.SpanSinkFlush,
+		tracing.TxnAttributes(e.changefeedID, ev.GetStartTs(), ev.GetCommitTs(),
+			tracing.AttrTableID.Int64(e.span.TableID))...)
+}
+
 // GetCheckpointTs returns the checkpoint ts of the table sink.
 func (e *EventTableSink[E, P]) GetCheckpointTs() model.ResolvedTs {
 	if e.state.Load() == state.TableSinkStopping {
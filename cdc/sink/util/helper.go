@@ -51,15 +51,19 @@ func GetProtocol(protocolStr string) (config.Protocol, error) {
 // GetFileExtension returns the extension for specific protocol
 func GetFileExtension(protocol config.Protocol) string {
 	switch protocol {
-	case config.ProtocolAvro, config.ProtocolCanalJSON, config.ProtocolMaxwell,
+	case config.ProtocolCanalJSON, config.ProtocolMaxwell,
 		config.ProtocolOpen, config.ProtocolSimple:
 		return ".json"
+	case config.ProtocolAvro:
+		return ".avro"
 	case config.ProtocolCraft:
 		return ".craft"
 	case config.ProtocolCanal:
 		return ".canal"
 	case config.ProtocolCsv:
 		return ".csv"
+	case config.ProtocolProtobuf:
+		return ".pb"
 	default:
 		return ".unknown"
 	}
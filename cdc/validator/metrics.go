@@ -0,0 +1,91 @@
+// Copyright 2024 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validator
+
+import (
+	"github.com/pingcap/tiflow/cdc/model"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	validationDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: "ticdc",
+			Subsystem: "validator",
+			Name:      "validation_duration",
+			Help:      "Bucketed histogram of the duration (s) of validating a changefeed at a syncpoint.",
+			Buckets:   prometheus.ExponentialBuckets(0.1, 2, 18), // 100ms~3.6h
+		}, []string{"namespace", "changefeed"})
+
+	validationErrors = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "ticdc",
+			Subsystem: "validator",
+			Name:      "validation_errors",
+			Help:      "The number of validations failed before comparing the tables.",
+		}, []string{"namespace", "changefeed"})
+
+	checkedChunks = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "ticdc",
+			Subsystem: "validator",
+			Name:      "checked_chunks",
+			Help:      "The number of chunks compared between the upstream and the downstream.",
+		}, []string{"namespace", "changefeed"})
+
+	mismatchedChunks = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "ticdc",
+			Subsystem: "validator",
+			Name:      "mismatched_chunks",
+			Help:      "The number of chunks whose checksums are different between the upstream and the downstream.",
+		}, []string{"namespace", "changefeed", "table"})
+
+	inconsistentTables = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "ticdc",
+			Subsystem: "validator",
+			Name:      "inconsistent_tables",
+			Help:      "The number of inconsistent tables found by the last validation.",
+		}, []string{"namespace", "changefeed"})
+
+	validatedTsGauge = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "ticdc",
+			Subsystem: "validator",
+			Name:      "validated_ts",
+			Help:      "The physical part of the primary ts of the syncpoint validated last time.",
+		}, []string{"namespace", "changefeed"})
+)
+
+// InitMetrics registers all metrics used in validator
+func InitMetrics(registry *prometheus.Registry) {
+	registry.MustRegister(validationDuration)
+	registry.MustRegister(validationErrors)
+	registry.MustRegister(checkedChunks)
+	registry.MustRegister(mismatchedChunks)
+	registry.MustRegister(inconsistentTables)
+	registry.MustRegister(validatedTsGauge)
+}
+
+func cleanupMetrics(changefeedID model.ChangeFeedID) {
+	validationDuration.DeleteLabelValues(changefeedID.Namespace, changefeedID.ID)
+	validationErrors.DeleteLabelValues(changefeedID.Namespace, changefeedID.ID)
+	checkedChunks.DeleteLabelValues(changefeedID.Namespace, changefeedID.ID)
+	mismatchedChunks.DeletePartialMatch(prometheus.Labels{
+		"namespace": changefeedID.Namespace, "changefeed": changefeedID.ID,
+	})
+	inconsistentTables.DeleteLabelValues(changefeedID.Namespace, changefeedID.ID)
+	validatedTsGauge.DeleteLabelValues(changefeedID.Namespace, changefeedID.ID)
+}
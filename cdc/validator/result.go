@@ -0,0 +1,108 @@
+// Copyright 2024 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validator
+
+import (
+	"sync"
+	"time"
+
+	"github.com/pingcap/tiflow/cdc/model"
+)
+
+const (
+	// maxResults is the number of the recent results kept for each changefeed.
+	maxResults = 16
+	// maxMismatches is the number of the mismatched chunks kept for each table.
+	maxMismatches = 100
+)
+
+// Result is the result of validating a changefeed at a syncpoint.
+type Result struct {
+	// PrimaryTs is the ts of the syncpoint in the upstream.
+	PrimaryTs uint64 `json:"primary_ts"`
+	// SecondaryTs is the ts of the syncpoint in the downstream.
+	SecondaryTs uint64    `json:"secondary_ts"`
+	StartTime   time.Time `json:"start_time"`
+	EndTime     time.Time `json:"end_time"`
+	// Consistent is true if all the validated tables are consistent.
+	Consistent bool           `json:"consistent"`
+	Tables     []*TableResult `json:"tables"`
+	// Error is the error which stops the validation before comparing the tables.
+	Error string `json:"error,omitempty"`
+}
+
+// TableResult is the result of validating a table.
+type TableResult struct {
+	Schema string `json:"schema"`
+	Table  string `json:"table"`
+	// Chunks is the number of the compared chunks.
+	Chunks int `json:"chunks"`
+	// MismatchedChunks is the number of the chunks whose checksums are different.
+	MismatchedChunks int `json:"mismatched_chunks"`
+	// Mismatches are the first mismatched chunks of the table.
+	Mismatches []*ChunkMismatch `json:"mismatches,omitempty"`
+	// Error is the error which stops comparing the table.
+	Error string `json:"error,omitempty"`
+}
+
+// Consistent returns true if the table is compared without any mismatch.
+func (r *TableResult) Consistent() bool {
+	return r.Error == "" && r.MismatchedChunks == 0
+}
+
+// ChunkMismatch is a chunk whose checksums are different between
+// the upstream and the downstream.
+type ChunkMismatch struct {
+	// Range is the condition of the rows in the chunk.
+	Range              string `json:"range"`
+	UpstreamCount      int64  `json:"upstream_count"`
+	DownstreamCount    int64  `json:"downstream_count"`
+	UpstreamChecksum   uint64 `json:"upstream_checksum"`
+	DownstreamChecksum uint64 `json:"downstream_checksum"`
+}
+
+var results = struct {
+	sync.Mutex
+	m map[model.ChangeFeedID][]*Result
+}{m: make(map[model.ChangeFeedID][]*Result)}
+
+func addResult(changefeedID model.ChangeFeedID, r *Result) {
+	results.Lock()
+	defer results.Unlock()
+	rs := append(results.m[changefeedID], r)
+	if len(rs) > maxResults {
+		rs = append(rs[:0:0], rs[len(rs)-maxResults:]...)
+	}
+	results.m[changefeedID] = rs
+}
+
+// GetResults returns the recent validation results of the changefeed
+// on this capture, the newest first.
+func GetResults(changefeedID model.ChangeFeedID) []*Result {
+	results.Lock()
+	defer results.Unlock()
+	rs := results.m[changefeedID]
+	res := make([]*Result, 0, len(rs))
+	for i := len(rs) - 1; i >= 0; i-- {
+		res = append(res, rs[i])
+	}
+	return res
+}
+
+// RemoveResults removes the validation results of the changefeed.
+func RemoveResults(changefeedID model.ChangeFeedID) {
+	results.Lock()
+	defer results.Unlock()
+	delete(results.m, changefeedID)
+}
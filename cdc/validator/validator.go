@@ -0,0 +1,511 @@
+// Copyright 2024 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validator
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pingcap/errors"
+	"github.com/pingcap/log"
+	tifilter "github.com/pingcap/tidb/pkg/util/filter"
+	tfilter "github.com/pingcap/tidb/pkg/util/table-filter"
+	"github.com/pingcap/tiflow/cdc/model"
+	"github.com/pingcap/tiflow/pkg/config"
+	cerror "github.com/pingcap/tiflow/pkg/errors"
+	pfilter "github.com/pingcap/tiflow/pkg/filter"
+	"github.com/pingcap/tiflow/pkg/quotes"
+	pmysql "github.com/pingcap/tiflow/pkg/sink/mysql"
+	"github.com/tikv/client-go/v2/oracle"
+	"go.uber.org/zap"
+)
+
+// Validator validates the data consistency between the upstream and the
+// downstream of a changefeed at the syncpoints. At a syncpoint, the selected
+// tables are split into chunks by their primary keys, and the checksum of each
+// chunk in the upstream snapshot at the primary ts is compared with the one in
+// the downstream snapshot at the secondary ts of the syncpoint.
+type Validator struct {
+	changefeedID  model.ChangeFeedID
+	sinkURI       string
+	replicaConfig *config.ReplicaConfig
+	cfg           *config.ValidatorConfig
+	clusterID     string
+	tableFilter   tfilter.Filter
+	cfFilter      pfilter.Filter
+	dbConnFactory pmysql.ConnectionFactory
+
+	syncPointCh chan uint64
+	// lastStart is the start time of the last validation.
+	lastStart time.Time
+}
+
+// NewValidator creates a Validator, nil is returned if the validator is
+// not enabled in the replica config.
+func NewValidator(
+	changefeedID model.ChangeFeedID, sinkURI string, replicaConfig *config.ReplicaConfig,
+) (*Validator, error) {
+	cfg := replicaConfig.Validator
+	if !cfg.IsEnabled() {
+		return nil, nil
+	}
+	tableFilter, err := tfilter.Parse(cfg.Tables)
+	if err != nil {
+		return nil, cerror.WrapError(cerror.ErrFilterRuleInvalid, err, cfg.Tables)
+	}
+	if !replicaConfig.CaseSensitive {
+		tableFilter = tfilter.CaseInsensitive(tableFilter)
+	}
+	cfFilter, err := pfilter.NewFilter(replicaConfig, "")
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	return &Validator{
+		changefeedID:  changefeedID,
+		sinkURI:       sinkURI,
+		replicaConfig: replicaConfig,
+		cfg:           cfg,
+		clusterID:     config.GetGlobalServerConfig().ClusterID,
+		tableFilter:   tableFilter,
+		cfFilter:      cfFilter,
+		dbConnFactory: pmysql.CreateMySQLDBConn,
+		syncPointCh:   make(chan uint64, 1),
+	}, nil
+}
+
+// OnSyncPoint is called after the syncpoint is written to the downstream.
+// It never blocks, the syncpoint is skipped if the last validation is
+// still running.
+func (v *Validator) OnSyncPoint(primaryTs uint64) {
+	select {
+	case v.syncPointCh <- primaryTs:
+	default:
+		log.Debug("validator is busy, skip the syncpoint",
+			zap.String("namespace", v.changefeedID.Namespace),
+			zap.String("changefeed", v.changefeedID.ID),
+			zap.Uint64("primaryTs", primaryTs))
+	}
+}
+
+// Run validates the changefeed at the syncpoints until the context is done.
+// The errors of the validations are recorded in the results, so they don't
+// stop the changefeed.
+func (v *Validator) Run(ctx context.Context) error {
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case primaryTs := <-v.syncPointCh:
+			if !v.lastStart.IsZero() && time.Since(v.lastStart) < *v.cfg.Interval {
+				continue
+			}
+			v.lastStart = time.Now()
+			r := v.validate(ctx, primaryTs)
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			addResult(v.changefeedID, r)
+		}
+	}
+}
+
+// Close cleans up the metrics of the validator, the results of the changefeed
+// are removed too if it's removed.
+func (v *Validator) Close(removed bool) {
+	cleanupMetrics(v.changefeedID)
+	if removed {
+		RemoveResults(v.changefeedID)
+	}
+}
+
+func (v *Validator) validate(ctx context.Context, primaryTs uint64) *Result {
+	r := &Result{PrimaryTs: primaryTs, StartTime: time.Now()}
+	log.Info("validate the changefeed at the syncpoint",
+		zap.String("namespace", v.changefeedID.Namespace),
+		zap.String("changefeed", v.changefeedID.ID),
+		zap.Uint64("primaryTs", primaryTs))
+
+	err := v.validateTables(ctx, r)
+	r.EndTime = time.Now()
+	validationDuration.WithLabelValues(v.changefeedID.Namespace, v.changefeedID.ID).
+		Observe(r.EndTime.Sub(r.StartTime).Seconds())
+	if err != nil {
+		r.Error = err.Error()
+		validationErrors.WithLabelValues(v.changefeedID.Namespace, v.changefeedID.ID).Inc()
+		log.Warn("failed to validate the changefeed",
+			zap.String("namespace", v.changefeedID.Namespace),
+			zap.String("changefeed", v.changefeedID.ID),
+			zap.Uint64("primaryTs", primaryTs),
+			zap.Error(err))
+		return r
+	}
+
+	r.Consistent = true
+	inconsistent := 0
+	for _, t := range r.Tables {
+		if !t.Consistent() {
+			r.Consistent = false
+			inconsistent++
+		}
+	}
+	inconsistentTables.WithLabelValues(v.changefeedID.Namespace, v.changefeedID.ID).Set(float64(inconsistent))
+	validatedTsGauge.WithLabelValues(v.changefeedID.Namespace, v.changefeedID.ID).
+		Set(float64(oracle.ExtractPhysical(primaryTs)))
+	log.Info("changefeed validated at the syncpoint",
+		zap.String("namespace", v.changefeedID.Namespace),
+		zap.String("changefeed", v.changefeedID.ID),
+		zap.Uint64("primaryTs", primaryTs),
+		zap.Uint64("secondaryTs", r.SecondaryTs),
+		zap.Int("tables", len(r.Tables)),
+		zap.Int("inconsistentTables", inconsistent),
+		zap.Duration("duration", r.EndTime.Sub(r.StartTime)))
+	return r
+}
+
+func (v *Validator) validateTables(ctx context.Context, r *Result) error {
+	downstream, err := v.openDB(ctx, v.sinkURI)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	defer downstream.Close()
+	r.SecondaryTs, err = v.querySecondaryTs(ctx, downstream, r.PrimaryTs)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	upstream, err := v.openDB(ctx, v.cfg.UpstreamURI)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	defer upstream.Close()
+
+	// the connections are discarded with the dbs after the validation,
+	// so the snapshot doesn't need to be reset.
+	upConn, err := snapshotConn(ctx, upstream, r.PrimaryTs)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	defer upConn.Close()
+	downConn, err := snapshotConn(ctx, downstream, r.SecondaryTs)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	defer downConn.Close()
+
+	tables, err := v.listTables(ctx, upConn)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	for _, table := range tables {
+		t := v.validateTable(ctx, upConn, downConn, table.Schema, table.Table)
+		if ctx.Err() != nil {
+			return errors.Trace(ctx.Err())
+		}
+		r.Tables = append(r.Tables, t)
+	}
+	return nil
+}
+
+func (v *Validator) openDB(ctx context.Context, uriStr string) (*sql.DB, error) {
+	uri, err := url.Parse(uriStr)
+	if err != nil {
+		return nil, cerror.WrapError(cerror.ErrSinkURIInvalid, err)
+	}
+	cfg := pmysql.NewConfig()
+	err = cfg.Apply(config.GetGlobalServerConfig().TZ, v.changefeedID, uri, v.replicaConfig)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	dsn, err := pmysql.GenerateDSN(ctx, uri, cfg, v.dbConnFactory)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	db, err := v.dbConnFactory(ctx, dsn)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	db.SetMaxOpenConns(1)
+	db.SetMaxIdleConns(1)
+	return db, nil
+}
+
+// querySecondaryTs returns the secondary ts of the syncpoint in the downstream.
+func (v *Validator) querySecondaryTs(ctx context.Context, db *sql.DB, primaryTs uint64) (uint64, error) {
+	query := "SELECT secondary_ts FROM " + pfilter.TiCDCSystemSchema + "." + pfilter.SyncPointTable +
+		" WHERE ticdc_cluster_id = ? AND changefeed = ? AND primary_ts = ?"
+	var secondaryTs string
+	err := db.QueryRowContext(ctx, query, v.clusterID, v.changefeedID.ID,
+		strconv.FormatUint(primaryTs, 10)).Scan(&secondaryTs)
+	if err == sql.ErrNoRows {
+		return 0, errors.Errorf("syncpoint %d is not found in the downstream", primaryTs)
+	}
+	if err != nil {
+		return 0, errors.Trace(err)
+	}
+	ts, err := strconv.ParseUint(secondaryTs, 10, 64)
+	if err != nil {
+		return 0, errors.Annotatef(err, "invalid secondary ts %s", secondaryTs)
+	}
+	return ts, nil
+}
+
+func snapshotConn(ctx context.Context, db *sql.DB, ts uint64) (*sql.Conn, error) {
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	if _, err := conn.ExecContext(ctx, fmt.Sprintf("SET @@tidb_snapshot = %d", ts)); err != nil {
+		_ = conn.Close()
+		return nil, errors.Annotate(err, "the validator only supports TiDB")
+	}
+	return conn, nil
+}
+
+// listTables returns the tables in the upstream snapshot to validate.
+func (v *Validator) listTables(ctx context.Context, conn *sql.Conn) ([]model.TableName, error) {
+	rows, err := conn.QueryContext(ctx, "SELECT TABLE_SCHEMA, TABLE_NAME FROM information_schema.TABLES "+
+		"WHERE TABLE_TYPE = 'BASE TABLE'")
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	defer rows.Close()
+	var tables []model.TableName
+	for rows.Next() {
+		var t model.TableName
+		if err := rows.Scan(&t.Schema, &t.Table); err != nil {
+			return nil, errors.Trace(err)
+		}
+		if tifilter.IsSystemSchema(t.Schema) || t.Schema == pfilter.TiCDCSystemSchema ||
+			!v.tableFilter.MatchTable(t.Schema, t.Table) ||
+			v.cfFilter.ShouldIgnoreTable(t.Schema, t.Table) {
+			continue
+		}
+		tables = append(tables, t)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.Trace(err)
+	}
+	sort.Slice(tables, func(i, j int) bool {
+		if tables[i].Schema != tables[j].Schema {
+			return tables[i].Schema < tables[j].Schema
+		}
+		return tables[i].Table < tables[j].Table
+	})
+	return tables, nil
+}
+
+// validateTable compares the table chunk by chunk. The table without
+// primary key is compared as a whole.
+func (v *Validator) validateTable(
+	ctx context.Context, upConn, downConn *sql.Conn, schema, table string,
+) *TableResult {
+	t := &TableResult{Schema: schema, Table: table}
+	err := v.compareTable(ctx, upConn, downConn, t)
+	if err != nil {
+		t.Error = err.Error()
+		log.Warn("failed to validate the table",
+			zap.String("namespace", v.changefeedID.Namespace),
+			zap.String("changefeed", v.changefeedID.ID),
+			zap.String("schema", schema),
+			zap.String("table", table),
+			zap.Error(err))
+	}
+	return t
+}
+
+func (v *Validator) compareTable(ctx context.Context, upConn, downConn *sql.Conn, t *TableResult) error {
+	columns, err := queryStrings(ctx, upConn, "SELECT COLUMN_NAME FROM information_schema.COLUMNS "+
+		"WHERE TABLE_SCHEMA = ? AND TABLE_NAME = ? ORDER BY ORDINAL_POSITION", t.Schema, t.Table)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	keys, err := queryStrings(ctx, upConn, "SELECT COLUMN_NAME FROM information_schema.KEY_COLUMN_USAGE "+
+		"WHERE TABLE_SCHEMA = ? AND TABLE_NAME = ? AND CONSTRAINT_NAME = 'PRIMARY' ORDER BY ORDINAL_POSITION",
+		t.Schema, t.Table)
+	if err != nil {
+		return errors.Trace(err)
+	}
+
+	tableName := model.TableName{Schema: t.Schema, Table: t.Table}
+	var lower []interface{}
+	for {
+		var upper []interface{}
+		if len(keys) > 0 {
+			upper, err = nextBoundary(ctx, upConn, tableName, keys, lower, v.cfg.ChunkSize)
+			if err != nil {
+				return errors.Trace(err)
+			}
+		}
+		where, args := chunkRange(keys, lower, upper)
+		mismatch, err := compareChunk(ctx, upConn, downConn, tableName, columns, where, args)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		t.Chunks++
+		checkedChunks.WithLabelValues(v.changefeedID.Namespace, v.changefeedID.ID).Inc()
+		if mismatch != nil {
+			t.MismatchedChunks++
+			if len(t.Mismatches) < maxMismatches {
+				t.Mismatches = append(t.Mismatches, mismatch)
+			}
+			mismatchedChunks.WithLabelValues(
+				v.changefeedID.Namespace, v.changefeedID.ID, tableName.String()).Inc()
+			log.Warn("chunk is inconsistent between the upstream and the downstream",
+				zap.String("namespace", v.changefeedID.Namespace),
+				zap.String("changefeed", v.changefeedID.ID),
+				zap.Stringer("table", tableName),
+				zap.String("range", mismatch.Range),
+				zap.Int64("upstreamCount", mismatch.UpstreamCount),
+				zap.Int64("downstreamCount", mismatch.DownstreamCount))
+		}
+		if upper == nil {
+			return nil
+		}
+		lower = upper
+	}
+}
+
+// nextBoundary returns the primary key of the last row in the chunk after
+// the lower boundary, nil is returned if it's the last chunk.
+func nextBoundary(
+	ctx context.Context, conn *sql.Conn, table model.TableName,
+	keys []string, lower []interface{}, chunkSize int,
+) ([]interface{}, error) {
+	quoted := quoteNames(keys)
+	where, args := chunkRange(keys, lower, nil)
+	query := fmt.Sprintf("SELECT %s FROM %s WHERE %s ORDER BY %s LIMIT 1 OFFSET %d",
+		strings.Join(quoted, ", "), table.QuoteString(), where, strings.Join(quoted, ", "), chunkSize-1)
+	values := make([]interface{}, len(keys))
+	dest := make([]interface{}, len(keys))
+	for i := range values {
+		dest[i] = &values[i]
+	}
+	err := conn.QueryRowContext(ctx, query, args...).Scan(dest...)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	for i, value := range values {
+		// compare the string keys in the collation of the columns.
+		if b, ok := value.([]byte); ok {
+			values[i] = string(b)
+		}
+	}
+	return values, nil
+}
+
+// chunkRange returns the condition of the rows whose primary keys are
+// in the range (lower, upper], nil means unbounded.
+func chunkRange(keys []string, lower, upper []interface{}) (string, []interface{}) {
+	columns := "(" + strings.Join(quoteNames(keys), ", ") + ")"
+	placeholders := "(" + strings.TrimSuffix(strings.Repeat("?, ", len(keys)), ", ") + ")"
+	var (
+		conds []string
+		args  []interface{}
+	)
+	if lower != nil {
+		conds = append(conds, columns+" > "+placeholders)
+		args = append(args, lower...)
+	}
+	if upper != nil {
+		conds = append(conds, columns+" <= "+placeholders)
+		args = append(args, upper...)
+	}
+	if len(conds) == 0 {
+		return "TRUE", nil
+	}
+	return strings.Join(conds, " AND "), args
+}
+
+// compareChunk compares the checksums of the chunk, the mismatch is
+// returned if they are different.
+func compareChunk(
+	ctx context.Context, upConn, downConn *sql.Conn, table model.TableName,
+	columns []string, where string, args []interface{},
+) (*ChunkMismatch, error) {
+	query := checksumQuery(table, columns, where)
+	m := &ChunkMismatch{Range: formatRange(where, args)}
+	if err := upConn.QueryRowContext(ctx, query, args...).
+		Scan(&m.UpstreamCount, &m.UpstreamChecksum); err != nil {
+		return nil, errors.Trace(err)
+	}
+	if err := downConn.QueryRowContext(ctx, query, args...).
+		Scan(&m.DownstreamCount, &m.DownstreamChecksum); err != nil {
+		return nil, errors.Trace(err)
+	}
+	if m.UpstreamCount == m.DownstreamCount && m.UpstreamChecksum == m.DownstreamChecksum {
+		return nil, nil
+	}
+	return m, nil
+}
+
+// checksumQuery returns the query of the row count and the checksum of the rows.
+func checksumQuery(table model.TableName, columns []string, where string) string {
+	quoted := quoteNames(columns)
+	isNull := make([]string, 0, len(quoted))
+	for _, c := range quoted {
+		isNull = append(isNull, "ISNULL("+c+")")
+	}
+	return fmt.Sprintf("SELECT COUNT(*), COALESCE(BIT_XOR(CAST(CRC32(CONCAT_WS(',', %s, CONCAT(%s))) "+
+		"AS UNSIGNED)), 0) FROM %s WHERE %s",
+		strings.Join(quoted, ", "), strings.Join(isNull, ", "), table.QuoteString(), where)
+}
+
+// formatRange replaces the placeholders in the condition with the args.
+func formatRange(where string, args []interface{}) string {
+	var b strings.Builder
+	for _, arg := range args {
+		idx := strings.IndexByte(where, '?')
+		if idx < 0 {
+			break
+		}
+		b.WriteString(where[:idx])
+		b.WriteString(strconv.Quote(model.ColumnValueString(arg)))
+		where = where[idx+1:]
+	}
+	b.WriteString(where)
+	return b.String()
+}
+
+func quoteNames(names []string) []string {
+	quoted := make([]string, 0, len(names))
+	for _, name := range names {
+		quoted = append(quoted, quotes.QuoteName(name))
+	}
+	return quoted
+}
+
+func queryStrings(ctx context.Context, conn *sql.Conn, query string, args ...interface{}) ([]string, error) {
+	rows, err := conn.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	defer rows.Close()
+	var res []string
+	for rows.Next() {
+		var s string
+		if err := rows.Scan(&s); err != nil {
+			return nil, errors.Trace(err)
+		}
+		res = append(res, s)
+	}
+	return res, errors.Trace(rows.Err())
+}
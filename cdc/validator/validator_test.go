@@ -0,0 +1,127 @@
+// Copyright 2024 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validator
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/pingcap/tiflow/cdc/model"
+	"github.com/pingcap/tiflow/pkg/config"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChunkRange(t *testing.T) {
+	t.Parallel()
+
+	where, args := chunkRange([]string{"a"}, nil, nil)
+	require.Equal(t, "TRUE", where)
+	require.Nil(t, args)
+
+	where, args = chunkRange([]string{"a", "b"}, []interface{}{1, "x"}, []interface{}{2, "y"})
+	require.Equal(t, "(`a`, `b`) > (?, ?) AND (`a`, `b`) <= (?, ?)", where)
+	require.Equal(t, []interface{}{1, "x", 2, "y"}, args)
+	require.Equal(t, "(`a`, `b`) > (\"1\", \"x\") AND (`a`, `b`) <= (\"2\", \"y\")",
+		formatRange(where, args))
+
+	require.Equal(t, "SELECT COUNT(*), COALESCE(BIT_XOR(CAST(CRC32(CONCAT_WS(',', `a`, `b`, "+
+		"CONCAT(ISNULL(`a`), ISNULL(`b`)))) AS UNSIGNED)), 0) FROM `s`.`t` WHERE TRUE",
+		checksumQuery(model.TableName{Schema: "s", Table: "t"}, []string{"a", "b"}, "TRUE"))
+}
+
+func newMockConn(t *testing.T) (*sql.Conn, sqlmock.Sqlmock) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	require.NoError(t, err)
+	conn, err := db.Conn(context.Background())
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = conn.Close()
+		_ = db.Close()
+	})
+	return conn, mock
+}
+
+func TestCompareTable(t *testing.T) {
+	t.Parallel()
+
+	upConn, upMock := newMockConn(t)
+	downConn, downMock := newMockConn(t)
+
+	upMock.ExpectQuery("SELECT COLUMN_NAME FROM information_schema.COLUMNS "+
+		"WHERE TABLE_SCHEMA = ? AND TABLE_NAME = ? ORDER BY ORDINAL_POSITION").
+		WithArgs("s", "t").
+		WillReturnRows(sqlmock.NewRows([]string{"COLUMN_NAME"}).AddRow("a").AddRow("b"))
+	upMock.ExpectQuery("SELECT COLUMN_NAME FROM information_schema.KEY_COLUMN_USAGE "+
+		"WHERE TABLE_SCHEMA = ? AND TABLE_NAME = ? AND CONSTRAINT_NAME = 'PRIMARY' ORDER BY ORDINAL_POSITION").
+		WithArgs("s", "t").
+		WillReturnRows(sqlmock.NewRows([]string{"COLUMN_NAME"}).AddRow("a"))
+
+	// the first chunk is consistent.
+	upMock.ExpectQuery("SELECT `a` FROM `s`.`t` WHERE TRUE ORDER BY `a` LIMIT 1 OFFSET 1").
+		WillReturnRows(sqlmock.NewRows([]string{"a"}).AddRow(int64(2)))
+	query := checksumQuery(model.TableName{Schema: "s", Table: "t"}, []string{"a", "b"}, "(`a`) <= (?)")
+	upMock.ExpectQuery(query).WithArgs(int64(2)).
+		WillReturnRows(sqlmock.NewRows([]string{"count", "checksum"}).AddRow(2, 100))
+	downMock.ExpectQuery(query).WithArgs(int64(2)).
+		WillReturnRows(sqlmock.NewRows([]string{"count", "checksum"}).AddRow(2, 100))
+
+	// the last chunk misses a row in the downstream.
+	upMock.ExpectQuery("SELECT `a` FROM `s`.`t` WHERE (`a`) > (?) ORDER BY `a` LIMIT 1 OFFSET 1").
+		WithArgs(int64(2)).
+		WillReturnRows(sqlmock.NewRows([]string{"a"}))
+	query = checksumQuery(model.TableName{Schema: "s", Table: "t"}, []string{"a", "b"}, "(`a`) > (?)")
+	upMock.ExpectQuery(query).WithArgs(int64(2)).
+		WillReturnRows(sqlmock.NewRows([]string{"count", "checksum"}).AddRow(1, 7))
+	downMock.ExpectQuery(query).WithArgs(int64(2)).
+		WillReturnRows(sqlmock.NewRows([]string{"count", "checksum"}).AddRow(0, 0))
+
+	v := &Validator{
+		changefeedID: model.DefaultChangeFeedID("test-compare-table"),
+		cfg:          &config.ValidatorConfig{ChunkSize: 2},
+	}
+	defer cleanupMetrics(v.changefeedID)
+	r := v.validateTable(context.Background(), upConn, downConn, "s", "t")
+	require.NoError(t, upMock.ExpectationsWereMet())
+	require.NoError(t, downMock.ExpectationsWereMet())
+
+	require.Empty(t, r.Error)
+	require.False(t, r.Consistent())
+	require.Equal(t, 2, r.Chunks)
+	require.Equal(t, 1, r.MismatchedChunks)
+	require.Equal(t, &ChunkMismatch{
+		Range:              "(`a`) > (\"2\")",
+		UpstreamCount:      1,
+		DownstreamCount:    0,
+		UpstreamChecksum:   7,
+		DownstreamChecksum: 0,
+	}, r.Mismatches[0])
+}
+
+func TestResults(t *testing.T) {
+	t.Parallel()
+
+	changefeedID := model.DefaultChangeFeedID("test-results")
+	for i := 1; i <= maxResults+1; i++ {
+		addResult(changefeedID, &Result{PrimaryTs: uint64(i)})
+	}
+	results := GetResults(changefeedID)
+	require.Len(t, results, maxResults)
+	require.Equal(t, uint64(maxResults+1), results[0].PrimaryTs)
+	require.Equal(t, uint64(2), results[maxResults-1].PrimaryTs)
+
+	RemoveResults(changefeedID)
+	require.Empty(t, GetResults(changefeedID))
+}
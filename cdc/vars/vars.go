@@ -18,6 +18,7 @@ import (
 	"time"
 
 	"github.com/pingcap/tiflow/cdc/model"
+	"github.com/pingcap/tiflow/cdc/processor/memquota"
 	"github.com/pingcap/tiflow/cdc/processor/sourcemanager/sorter/factory"
 	"github.com/pingcap/tiflow/pkg/config"
 	"github.com/pingcap/tiflow/pkg/etcd"
@@ -36,6 +37,9 @@ type GlobalVars struct {
 	// SortEngineManager is introduced for pull-based sinks.
 	SortEngineFactory *factory.SortEngineFactory
 
+	// MemQuotaArbiter arbitrates memory quotas of all changefeeds on the capture.
+	MemQuotaArbiter *memquota.Arbiter
+
 	// OwnerRevision is the Etcd revision when the owner got elected.
 	OwnerRevision int64
 
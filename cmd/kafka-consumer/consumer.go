@@ -20,6 +20,7 @@ import (
 
 	"github.com/confluentinc/confluent-kafka-go/v2/kafka"
 	"github.com/pingcap/log"
+	pconsumer "github.com/pingcap/tiflow/pkg/consumer"
 	"github.com/pingcap/tiflow/pkg/errors"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
@@ -67,7 +68,7 @@ func getPartitionNum(o *option) (int32, error) {
 
 type consumer struct {
 	client *kafka.Consumer
-	writer *writer
+	writer *pconsumer.Writer
 }
 
 // newConsumer will create a consumer client.
@@ -120,6 +121,7 @@ func newConsumer(ctx context.Context, o *option) *consumer {
 // Consume will read message from Kafka.
 func (c *consumer) Consume(ctx context.Context) {
 	defer func() {
+		c.writer.Close()
 		if err := c.client.Close(); err != nil {
 			log.Panic("close kafka consumer failed", zap.Error(err))
 		}
@@ -136,7 +138,17 @@ func (c *consumer) Consume(ctx context.Context) {
 			log.Error("read message failed, just continue to retry", zap.Error(err))
 			continue
 		}
-		needCommit := c.writer.WriteMessage(ctx, msg)
+		needCommit, err := c.writer.WriteMessage(ctx, &pconsumer.Message{
+			Key:       msg.Key,
+			Value:     msg.Value,
+			Partition: msg.TopicPartition.Partition,
+			Offset:    int64(msg.TopicPartition.Offset),
+		})
+		if err != nil {
+			log.Panic("write message failed",
+				zap.String("topic", *msg.TopicPartition.Topic), zap.Int32("partition", msg.TopicPartition.Partition),
+				zap.Any("offset", msg.TopicPartition.Offset), zap.Error(err))
+		}
 		if !needCommit {
 			continue
 		}
@@ -16,38 +16,28 @@ package main
 import (
 	"context"
 	"fmt"
-	"math"
 	"net/url"
 	"os"
 	"os/signal"
-	"sort"
 	"strconv"
 	"strings"
 	"sync"
-	"sync/atomic"
 	"syscall"
-	"time"
 
 	"github.com/apache/pulsar-client-go/pulsar"
 	"github.com/apache/pulsar-client-go/pulsar/auth"
 	"github.com/pingcap/errors"
 	"github.com/pingcap/log"
 	"github.com/pingcap/tiflow/cdc/model"
-	"github.com/pingcap/tiflow/cdc/sink/ddlsink"
-	ddlsinkfactory "github.com/pingcap/tiflow/cdc/sink/ddlsink/factory"
-	eventsinkfactory "github.com/pingcap/tiflow/cdc/sink/dmlsink/factory"
-	"github.com/pingcap/tiflow/cdc/sink/tablesink"
 	sutil "github.com/pingcap/tiflow/cdc/sink/util"
 	cmdUtil "github.com/pingcap/tiflow/pkg/cmd/util"
 	"github.com/pingcap/tiflow/pkg/config"
+	pconsumer "github.com/pingcap/tiflow/pkg/consumer"
 	"github.com/pingcap/tiflow/pkg/logutil"
-	"github.com/pingcap/tiflow/pkg/quotes"
 	"github.com/pingcap/tiflow/pkg/sink"
 	"github.com/pingcap/tiflow/pkg/sink/codec"
-	"github.com/pingcap/tiflow/pkg/sink/codec/canal"
 	"github.com/pingcap/tiflow/pkg/sink/codec/common"
 	tpulsar "github.com/pingcap/tiflow/pkg/sink/pulsar"
-	"github.com/pingcap/tiflow/pkg/spanz"
 	"github.com/pingcap/tiflow/pkg/util"
 	"github.com/pingcap/tiflow/pkg/version"
 	"github.com/spf13/cobra"
@@ -81,6 +71,9 @@ type ConsumerOption struct {
 
 	downstreamURI string
 	partitionNum  int
+
+	// dedupByCommitTs drops the rows which commit before the previous row of the same key.
+	dedupByCommitTs bool
 }
 
 func newConsumerOption() *ConsumerOption {
@@ -91,9 +84,6 @@ func newConsumerOption() *ConsumerOption {
 
 // Adjust the consumer option by the upstream uri passed in parameters.
 func (o *ConsumerOption) Adjust(upstreamURI *url.URL, configFile string) {
-	// the default value of partitionNum is 1
-	o.partitionNum = 1
-
 	o.topic = strings.TrimFunc(upstreamURI.Path, func(r rune) bool {
 		return r == '/'
 	})
@@ -129,8 +119,9 @@ func (o *ConsumerOption) Adjust(upstreamURI *url.URL, configFile string) {
 			log.Panic("invalid enable-tidb-extension of upstream-uri")
 		}
 		if enableTiDBExtension {
-			if o.protocol != config.ProtocolCanalJSON && o.protocol != config.ProtocolAvro {
-				log.Panic("enable-tidb-extension only work with canal-json / avro")
+			if o.protocol != config.ProtocolCanalJSON && o.protocol != config.ProtocolCanal &&
+				o.protocol != config.ProtocolAvro {
+				log.Panic("enable-tidb-extension only work with canal-json / canal / avro")
 			}
 		}
 		o.enableTiDBExtension = enableTiDBExtension
@@ -172,6 +163,8 @@ func main() {
 	cmd.Flags().StringVar(&consumerOption.oauth2Audience, "oauth2-audience", "", "oauth2 audience")
 	cmd.Flags().StringVar(&consumerOption.mtlsAuthTLSCertificatePath, "auth-tls-certificate-path", "", "mtls certificate path")
 	cmd.Flags().StringVar(&consumerOption.mtlsAuthTLSPrivateKeyPath, "auth-tls-private-key-path", "", "mtls private key path")
+	cmd.Flags().BoolVar(&consumerOption.dedupByCommitTs, "dedup-by-commit-ts", false,
+		"drop the rows sent again by the changefeed, the rows of the same key must be sent in order")
 	if err := cmd.Execute(); err != nil {
 		fmt.Println(err)
 	}
@@ -205,15 +198,24 @@ func run(cmd *cobra.Command, args []string) {
 
 	consumerOption.Adjust(upstreamURI, configFile)
 
+	pulsarConsumer, client := NewPulsarConsumer(consumerOption)
+	defer client.Close()
+	defer pulsarConsumer.Close()
+
+	// the DDL and resolved events are broadcast to every partition of the topic,
+	// so the progress of each partition is tracked separately.
+	partitions, err := client.TopicPartitions(consumerOption.topic)
+	if err != nil {
+		log.Panic("Error getting the partitions of the topic", zap.Error(err))
+	}
+	consumerOption.partitionNum = len(partitions)
+
 	ctx, cancel := context.WithCancel(context.Background())
 	consumer, err := NewConsumer(ctx, consumerOption)
 	if err != nil {
 		log.Panic("Error creating pulsar consumer", zap.Error(err))
 	}
-
-	pulsarConsumer, client := NewPulsarConsumer(consumerOption)
-	defer client.Close()
-	defer pulsarConsumer.Close()
+	defer consumer.Close()
 	msgChan := pulsarConsumer.Chan()
 
 	wg := &sync.WaitGroup{}
@@ -229,7 +231,7 @@ func run(cmd *cobra.Command, args []string) {
 				log.Debug(fmt.Sprintf("Received message msgId: %#v -- content: '%s'\n",
 					consumerMsg.ID(),
 					string(consumerMsg.Payload())))
-				err := consumer.HandleMsg(consumerMsg.Message)
+				err := consumer.HandleMsg(ctx, consumerMsg.Message)
 				if err != nil {
 					log.Panic("Error consuming message", zap.Error(err))
 				}
@@ -241,16 +243,6 @@ func run(cmd *cobra.Command, args []string) {
 		}
 	}()
 
-	wg.Add(1)
-	go func() {
-		defer wg.Done()
-		if err := consumer.Run(ctx); err != nil {
-			if err != context.Canceled {
-				log.Panic("Error running consumer", zap.Error(err))
-			}
-		}
-	}()
-
 	log.Info("TiCDC consumer up and running!...")
 	sigterm := make(chan os.Signal, 1)
 	signal.Notify(sigterm, syscall.SIGINT, syscall.SIGTERM)
@@ -312,6 +304,9 @@ func NewPulsarConsumer(option *ConsumerOption) (pulsar.Consumer, pulsar.Client)
 		log.Fatal("can't create pulsar client", zap.Error(err))
 	}
 
+	// The subscription must be exclusive. A key-shared subscription delivers
+	// the DDL and resolved events to only one of its consumers, the others
+	// never flush their events.
 	consumerConfig := pulsar.ConsumerOptions{
 		Topic:                       topicName,
 		SubscriptionName:            subscriptionName,
@@ -326,450 +321,114 @@ func NewPulsarConsumer(option *ConsumerOption) (pulsar.Consumer, pulsar.Client)
 	return consumer, client
 }
 
-// partitionSinks maintained for each partition, it may sync data for multiple tables.
-type partitionSinks struct {
-	tablesCommitTsMap sync.Map
-	tableSinksMap     sync.Map
-	// resolvedTs record the maximum timestamp of the received event
-	resolvedTs uint64
-}
-
 // Consumer represents a local pulsar consumer
 type Consumer struct {
-	eventGroups          map[int64]*eventsGroup
-	ddlList              []*model.DDLEvent
-	ddlListMu            sync.Mutex
-	lastReceivedDDL      *model.DDLEvent
-	ddlSink              ddlsink.Sink
-	fakeTableIDGenerator *fakeTableIDGenerator
-
-	// sinkFactory is used to create table sink for each table.
-	sinkFactory *eventsinkfactory.SinkFactory
-	sinks       []*partitionSinks
-	sinksMu     sync.Mutex
-
-	// initialize to 0 by default
-	globalResolvedTs uint64
+	writer *pconsumer.Writer
+	// offsets assigns the offsets of the messages in each partition.
+	offsets map[int32]*partitionOffset
+}
 
-	tz *time.Location
+// partitionOffset assigns increasing offsets to the messages of a partition
+// in the order of their message ids. A message id which is not greater than
+// the last one means the message is delivered again, its offset is 0, so it's
+// ignored if it falls behind the watermark.
+type partitionOffset struct {
+	last   pulsar.MessageID
+	offset int64
+}
 
-	codecConfig *common.Config
+func (p *partitionOffset) next(id pulsar.MessageID) int64 {
+	if p.last != nil && compareMessageID(id, p.last) <= 0 {
+		return 0
+	}
+	p.last = id
+	p.offset++
+	return p.offset
+}
 
-	option *ConsumerOption
+// compareMessageID compares the message ids of the same partition.
+// The ledger ids of a partition increase monotonically.
+func compareMessageID(a, b pulsar.MessageID) int {
+	if a.LedgerID() != b.LedgerID() {
+		if a.LedgerID() < b.LedgerID() {
+			return -1
+		}
+		return 1
+	}
+	if a.EntryID() != b.EntryID() {
+		if a.EntryID() < b.EntryID() {
+			return -1
+		}
+		return 1
+	}
+	if a.BatchIdx() != b.BatchIdx() {
+		if a.BatchIdx() < b.BatchIdx() {
+			return -1
+		}
+		return 1
+	}
+	return 0
 }
 
 // NewConsumer creates a new cdc pulsar consumer
 // the consumer is responsible for consuming the data from the pulsar topic
 // and write the data to the downstream.
 func NewConsumer(ctx context.Context, o *ConsumerOption) (*Consumer, error) {
-	c := new(Consumer)
-	c.option = o
-
-	tz, err := util.GetTimezone(o.timezone)
-	if err != nil {
+	if _, err := util.GetTimezone(o.timezone); err != nil {
 		return nil, errors.Annotate(err, "can not load timezone")
 	}
 	config.GetGlobalServerConfig().TZ = o.timezone
-	c.tz = tz
 
-	c.fakeTableIDGenerator = &fakeTableIDGenerator{
-		tableIDs: make(map[string]int64),
-	}
-
-	c.codecConfig = common.NewConfig(o.protocol)
-	c.codecConfig.EnableTiDBExtension = o.enableTiDBExtension
-	if c.codecConfig.Protocol == config.ProtocolAvro {
-		c.codecConfig.AvroEnableWatermark = true
-	}
-
-	c.sinks = make([]*partitionSinks, o.partitionNum)
-	ctx, cancel := context.WithCancel(ctx)
-	errChan := make(chan error, 1)
-	for i := 0; i < o.partitionNum; i++ {
-		c.sinks[i] = &partitionSinks{}
+	codecConfig := common.NewConfig(o.protocol)
+	codecConfig.EnableTiDBExtension = o.enableTiDBExtension
+	if codecConfig.Protocol == config.ProtocolAvro {
+		codecConfig.AvroEnableWatermark = true
 	}
 
 	changefeedID := model.DefaultChangeFeedID("pulsar-consumer")
-	f, err := eventsinkfactory.New(ctx, changefeedID, o.downstreamURI, o.replicaConfig, errChan, nil)
+	downstream, err := pconsumer.NewSinkDownstream(ctx, changefeedID, o.downstreamURI, o.replicaConfig)
 	if err != nil {
-		cancel()
 		return nil, errors.Trace(err)
 	}
-	c.sinkFactory = f
-
-	go func() {
-		err := <-errChan
-		if errors.Cause(err) != context.Canceled {
-			log.Error("error on running consumer", zap.Error(err))
-		} else {
-			log.Info("consumer exited")
-		}
-		cancel()
-	}()
-
-	ddlSink, err := ddlsinkfactory.New(ctx, changefeedID, o.downstreamURI, o.replicaConfig)
+	writer, err := pconsumer.NewWriter(&pconsumer.Config{
+		PartitionNum: int32(o.partitionNum),
+		Protocol:     o.protocol,
+		NewDecoder: func() (codec.RowEventDecoder, error) {
+			return pconsumer.NewDecoder(ctx, codecConfig, "", o.topic, nil)
+		},
+		DedupByKey: o.dedupByCommitTs,
+	}, downstream)
 	if err != nil {
-		cancel()
+		downstream.Close()
 		return nil, errors.Trace(err)
 	}
-	c.ddlSink = ddlSink
-	c.eventGroups = make(map[int64]*eventsGroup)
-	return c, nil
-}
-
-type eventsGroup struct {
-	events []*model.RowChangedEvent
-}
-
-func newEventsGroup() *eventsGroup {
-	return &eventsGroup{
-		events: make([]*model.RowChangedEvent, 0),
-	}
-}
-
-func (g *eventsGroup) Append(e *model.RowChangedEvent) {
-	g.events = append(g.events, e)
-}
-
-func (g *eventsGroup) Resolve(resolveTs uint64) []*model.RowChangedEvent {
-	sort.Slice(g.events, func(i, j int) bool {
-		return g.events[i].CommitTs < g.events[j].CommitTs
-	})
-
-	i := sort.Search(len(g.events), func(i int) bool {
-		return g.events[i].CommitTs > resolveTs
-	})
-	result := g.events[:i]
-	g.events = g.events[i:]
-
-	return result
+	return &Consumer{writer: writer, offsets: make(map[int32]*partitionOffset)}, nil
 }
 
 // HandleMsg handles the message received from the pulsar consumer
-func (c *Consumer) HandleMsg(msg pulsar.Message) error {
-	c.sinksMu.Lock()
-	sink := c.sinks[0]
-	c.sinksMu.Unlock()
-	if sink == nil {
-		panic("sink should initialized")
-	}
-
-	ctx := context.Background()
-	var (
-		decoder codec.RowEventDecoder
-		err     error
-	)
-
-	switch c.codecConfig.Protocol {
-	case config.ProtocolCanalJSON:
-		decoder, err = canal.NewBatchDecoder(ctx, c.codecConfig, nil)
-		if err != nil {
-			return err
-		}
-	default:
-		log.Panic("Protocol not supported", zap.Any("Protocol", c.codecConfig.Protocol))
-	}
-	if err != nil {
-		return errors.Trace(err)
-	}
-
-	if err := decoder.AddKeyValue([]byte(msg.Key()), msg.Payload()); err != nil {
-		log.Error("add key value to the decoder failed", zap.Error(err))
-		return errors.Trace(err)
-	}
-
-	counter := 0
-	for {
-		tp, hasNext, err := decoder.HasNext()
-		if err != nil {
-			log.Panic("decode message key failed", zap.Error(err))
-		}
-		if !hasNext {
-			break
-		}
-
-		counter++
-		switch tp {
-		case model.MessageTypeDDL:
-			// for some protocol, DDL would be dispatched to all partitions,
-			// Consider that DDL a, b, c received from partition-0, the latest DDL is c,
-			// if we receive `a` from partition-1, which would be seemed as DDL regression,
-			// then cause the consumer panic, but it was a duplicate one.
-			// so we only handle DDL received from partition-0 should be enough.
-			// but all DDL event messages should be consumed.
-			ddl, err := decoder.NextDDLEvent()
-			if err != nil {
-				log.Panic("decode message value failed",
-					zap.ByteString("value", msg.Payload()),
-					zap.Error(err))
-			}
-			log.Info("DDL event received", zap.Any("DDL", ddl))
-			c.appendDDL(ddl)
-		case model.MessageTypeRow:
-			row, err := decoder.NextRowChangedEvent()
-			if err != nil {
-				log.Panic("decode message value failed",
-					zap.ByteString("value", msg.Payload()),
-					zap.Error(err))
-			}
-			globalResolvedTs := atomic.LoadUint64(&c.globalResolvedTs)
-			partitionResolvedTs := atomic.LoadUint64(&sink.resolvedTs)
-			if row.CommitTs <= globalResolvedTs || row.CommitTs <= partitionResolvedTs {
-				log.Warn("RowChangedEvent fallback row, ignore it",
-					zap.Uint64("commitTs", row.CommitTs),
-					zap.Uint64("globalResolvedTs", globalResolvedTs),
-					zap.Uint64("partitionResolvedTs", partitionResolvedTs),
-					zap.Int32("partition", msg.ID().PartitionIdx()),
-					zap.Any("row", row))
-				// todo: mark the offset after the DDL is fully synced to the downstream mysql.
-				continue
-			}
-			var partitionID int64
-			if row.TableInfo.IsPartitionTable() {
-				partitionID = row.GetTableID()
-			}
-			// use schema, table and tableID to identify a table
-			tableID := c.fakeTableIDGenerator.
-				generateFakeTableID(row.TableInfo.GetSchemaName(), row.TableInfo.GetTableName(), partitionID)
-			row.TableInfo.TableName.TableID = tableID
-
-			group, ok := c.eventGroups[tableID]
-			if !ok {
-				group = newEventsGroup()
-				c.eventGroups[tableID] = group
-			}
-			group.Append(row)
-		case model.MessageTypeResolved:
-			ts, err := decoder.NextResolvedEvent()
-			if err != nil {
-				log.Panic("decode message value failed",
-					zap.ByteString("value", msg.Payload()),
-					zap.Error(err))
-			}
-
-			globalResolvedTs := atomic.LoadUint64(&c.globalResolvedTs)
-			partitionResolvedTs := atomic.LoadUint64(&sink.resolvedTs)
-			if ts < globalResolvedTs || ts < partitionResolvedTs {
-				log.Warn("partition resolved ts fallback, skip it",
-					zap.Uint64("ts", ts),
-					zap.Uint64("partitionResolvedTs", partitionResolvedTs),
-					zap.Uint64("globalResolvedTs", globalResolvedTs),
-					zap.Int32("partition", msg.ID().PartitionIdx()))
-				continue
-			}
-
-			for tableID, group := range c.eventGroups {
-				events := group.Resolve(ts)
-				if len(events) == 0 {
-					continue
-				}
-				if _, ok := sink.tableSinksMap.Load(tableID); !ok {
-					log.Info("create table sink for consumer", zap.Any("tableID", tableID))
-					tableSink := c.sinkFactory.CreateTableSinkForConsumer(
-						model.DefaultChangeFeedID("pulsar-consumer"),
-						spanz.TableIDToComparableSpan(tableID),
-						events[0].CommitTs)
-
-					log.Info("table sink created", zap.Any("tableID", tableID),
-						zap.Any("tableSink", tableSink.GetCheckpointTs()))
-
-					sink.tableSinksMap.Store(tableID, tableSink)
-				}
-				s, _ := sink.tableSinksMap.Load(tableID)
-				s.(tablesink.TableSink).AppendRowChangedEvents(events...)
-				commitTs := events[len(events)-1].CommitTs
-				lastCommitTs, ok := sink.tablesCommitTsMap.Load(tableID)
-				if !ok || lastCommitTs.(uint64) < commitTs {
-					sink.tablesCommitTsMap.Store(tableID, commitTs)
-				}
-			}
-			atomic.StoreUint64(&sink.resolvedTs, ts)
-		}
-
-	}
-	return nil
-}
-
-// append DDL wait to be handled, only consider the constraint among DDLs.
-// for DDL a / b received in the order, a.CommitTs < b.CommitTs should be true.
-func (c *Consumer) appendDDL(ddl *model.DDLEvent) {
-	c.ddlListMu.Lock()
-	defer c.ddlListMu.Unlock()
-	// DDL CommitTs fallback, just crash it to indicate the bug.
-	if c.lastReceivedDDL != nil && ddl.CommitTs < c.lastReceivedDDL.CommitTs {
-		log.Panic("DDL CommitTs < lastReceivedDDL.CommitTs",
-			zap.Uint64("commitTs", ddl.CommitTs),
-			zap.Uint64("lastReceivedDDLCommitTs", c.lastReceivedDDL.CommitTs),
-			zap.Any("DDL", ddl))
-	}
-
-	// A rename tables DDL job contains multiple DDL events with same CommitTs.
-	// So to tell if a DDL is redundant or not, we must check the equivalence of
-	// the current DDL and the DDL with max CommitTs.
-	if ddl == c.lastReceivedDDL {
-		log.Info("ignore redundant DDL, the DDL is equal to ddlWithMaxCommitTs",
-			zap.Any("DDL", ddl))
-		return
-	}
-
-	c.ddlList = append(c.ddlList, ddl)
-	log.Info("DDL event received", zap.Any("DDL", ddl))
-	c.lastReceivedDDL = ddl
-}
-
-func (c *Consumer) getFrontDDL() *model.DDLEvent {
-	c.ddlListMu.Lock()
-	defer c.ddlListMu.Unlock()
-	if len(c.ddlList) > 0 {
-		return c.ddlList[0]
-	}
-	return nil
-}
-
-func (c *Consumer) popDDL() *model.DDLEvent {
-	c.ddlListMu.Lock()
-	defer c.ddlListMu.Unlock()
-	if len(c.ddlList) > 0 {
-		ddl := c.ddlList[0]
-		c.ddlList = c.ddlList[1:]
-		return ddl
-	}
-	return nil
-}
-
-func (c *Consumer) forEachSink(fn func(sink *partitionSinks) error) error {
-	c.sinksMu.Lock()
-	defer c.sinksMu.Unlock()
-	for _, sink := range c.sinks {
-		if err := fn(sink); err != nil {
-			return errors.Trace(err)
-		}
-	}
-	return nil
-}
-
-// getMinResolvedTs returns the minimum resolvedTs of all the partitionSinks
-func (c *Consumer) getMinResolvedTs() (result uint64, err error) {
-	result = uint64(math.MaxUint64)
-	err = c.forEachSink(func(sink *partitionSinks) error {
-		a := atomic.LoadUint64(&sink.resolvedTs)
-		if a < result {
-			result = a
-		}
-		return nil
+func (c *Consumer) HandleMsg(ctx context.Context, msg pulsar.Message) error {
+	key := msg.OrderingKey()
+	if key == "" {
+		key = msg.Key()
+	}
+	id := msg.ID()
+	// the partition index is 0 if the topic is not partitioned.
+	partition := id.PartitionIdx()
+	offsets, ok := c.offsets[partition]
+	if !ok {
+		offsets = &partitionOffset{}
+		c.offsets[partition] = offsets
+	}
+	_, err := c.writer.WriteMessage(ctx, &pconsumer.Message{
+		Key:       []byte(key),
+		Value:     msg.Payload(),
+		Partition: partition,
+		Offset:    offsets.next(id),
 	})
-	return result, err
+	return errors.Trace(err)
 }
 
-// Run the Consumer
-func (c *Consumer) Run(ctx context.Context) error {
-	ticker := time.NewTicker(200 * time.Millisecond)
-	defer ticker.Stop()
-	for {
-		select {
-		case <-ctx.Done():
-			return ctx.Err()
-		case <-ticker.C:
-			// 1. Get the minimum resolvedTs of all the partitionSinks
-			minResolvedTs, err := c.getMinResolvedTs()
-			if err != nil {
-				return errors.Trace(err)
-			}
-
-			// 2. check if there is a DDL event that can be executed
-			//   if there is, execute it and update the minResolvedTs
-			nextDDL := c.getFrontDDL()
-			if nextDDL != nil {
-				log.Info("get nextDDL", zap.Any("DDL", nextDDL))
-			}
-			if nextDDL != nil && minResolvedTs >= nextDDL.CommitTs {
-				// flush DMLs that commitTs <= todoDDL.CommitTs
-				if err := c.forEachSink(func(sink *partitionSinks) error {
-					return flushRowChangedEvents(ctx, sink, nextDDL.CommitTs)
-				}); err != nil {
-					return errors.Trace(err)
-				}
-				log.Info("begin to execute DDL", zap.Any("DDL", nextDDL))
-				// all DMLs with commitTs <= todoDDL.CommitTs have been flushed to downstream,
-				// so we can execute the DDL now.
-				if err := c.ddlSink.WriteDDLEvent(ctx, nextDDL); err != nil {
-					return errors.Trace(err)
-				}
-				ddl := c.popDDL()
-				log.Info("DDL executed", zap.Any("DDL", ddl))
-				minResolvedTs = ddl.CommitTs
-			}
-
-			// 3. Update global resolved ts
-			if c.globalResolvedTs > minResolvedTs {
-				log.Panic("global ResolvedTs fallback",
-					zap.Uint64("globalResolvedTs", c.globalResolvedTs),
-					zap.Uint64("minPartitionResolvedTs", minResolvedTs))
-			}
-
-			if c.globalResolvedTs < minResolvedTs {
-				c.globalResolvedTs = minResolvedTs
-			}
-
-			// 4. flush all the DMLs that commitTs <= globalResolvedTs
-			if err := c.forEachSink(func(sink *partitionSinks) error {
-				return flushRowChangedEvents(ctx, sink, c.globalResolvedTs)
-			}); err != nil {
-				return errors.Trace(err)
-			}
-		}
-	}
-}
-
-// flushRowChangedEvents flushes all the DMLs that commitTs <= resolvedTs
-// Note: This function is synchronous, it will block until all the DMLs are flushed.
-func flushRowChangedEvents(ctx context.Context, sink *partitionSinks, resolvedTs uint64) error {
-	for {
-		select {
-		case <-ctx.Done():
-			return ctx.Err()
-		default:
-		}
-		flushedResolvedTs := true
-		sink.tablesCommitTsMap.Range(func(key, value interface{}) bool {
-			tableID := key.(int64)
-			resolvedTs := model.NewResolvedTs(resolvedTs)
-			tableSink, ok := sink.tableSinksMap.Load(tableID)
-			if !ok {
-				log.Panic("Table sink not found", zap.Int64("tableID", tableID))
-			}
-			if err := tableSink.(tablesink.TableSink).UpdateResolvedTs(resolvedTs); err != nil {
-				log.Error("Failed to update resolved ts", zap.Error(err))
-				return false
-			}
-			if !tableSink.(tablesink.TableSink).GetCheckpointTs().EqualOrGreater(resolvedTs) {
-				flushedResolvedTs = false
-			}
-			return true
-		})
-		if flushedResolvedTs {
-			return nil
-		}
-	}
-}
-
-type fakeTableIDGenerator struct {
-	tableIDs       map[string]int64
-	currentTableID int64
-	mu             sync.Mutex
-}
-
-func (g *fakeTableIDGenerator) generateFakeTableID(schema, table string, partition int64) int64 {
-	g.mu.Lock()
-	defer g.mu.Unlock()
-	key := quotes.QuoteSchema(schema, table)
-	if partition != 0 {
-		key = fmt.Sprintf("%s.`%d`", key, partition)
-	}
-	if tableID, ok := g.tableIDs[key]; ok {
-		return tableID
-	}
-	g.currentTableID++
-	g.tableIDs[key] = g.currentTableID
-	return g.currentTableID
+// Close closes the Consumer
+func (c *Consumer) Close() {
+	c.writer.Close()
 }
@@ -0,0 +1,388 @@
+// Copyright 2024 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pingcap/errors"
+	"github.com/pingcap/log"
+	"github.com/pingcap/tiflow/cdc/model"
+	"github.com/pingcap/tiflow/pkg/config"
+	cerror "github.com/pingcap/tiflow/pkg/errors"
+	"github.com/pingcap/tiflow/pkg/filter"
+	psink "github.com/pingcap/tiflow/pkg/sink"
+	"github.com/pingcap/tiflow/pkg/sink/cloudstorage"
+	pmysql "github.com/pingcap/tiflow/pkg/sink/mysql"
+	"go.uber.org/zap"
+)
+
+const (
+	checkpointTable = "storage_consumer_checkpoint"
+	// minCompactRecords is the minimum number of the records appended to the
+	// checkpoint file before it's compacted.
+	minCompactRecords = 1024
+)
+
+// appliedFile records the index of the last applied file of a dml path,
+// the schema file is recorded with the fake partition number and index 0
+// once its DDL has been executed. A pruned dml path is recorded with Pruned.
+type appliedFile struct {
+	Schema       string `json:"schema"`
+	Table        string `json:"table"`
+	TableVersion uint64 `json:"table-version"`
+	PartitionNum int64  `json:"partition-num"`
+	Date         string `json:"date"`
+	Index        uint64 `json:"index"`
+	Pruned       bool   `json:"pruned,omitempty"`
+}
+
+func newAppliedFile(key cloudstorage.DmlPathKey, idx uint64) appliedFile {
+	return appliedFile{
+		Schema:       key.Schema,
+		Table:        key.Table,
+		TableVersion: key.TableVersion,
+		PartitionNum: key.PartitionNum,
+		Date:         key.Date,
+		Index:        idx,
+	}
+}
+
+func (f appliedFile) pathKey() cloudstorage.DmlPathKey {
+	return cloudstorage.DmlPathKey{
+		SchemaPathKey: cloudstorage.SchemaPathKey{
+			Schema:       f.Schema,
+			Table:        f.Table,
+			TableVersion: f.TableVersion,
+		},
+		PartitionNum: f.PartitionNum,
+		Date:         f.Date,
+	}
+}
+
+// supersedes returns whether all the files of the dml path have been applied
+// once the other path is applied. The files are applied in the order of table
+// versions and dates, so the older versions of a table are done once the DDL
+// of a newer version is applied, and the older dates of a partition are done
+// once a newer date of it is applied. The superseded paths are pruned from
+// the checkpoint.
+func supersedes(applied, key cloudstorage.DmlPathKey) bool {
+	if applied.Schema != key.Schema || applied.Table != key.Table {
+		return false
+	}
+	if applied.TableVersion > key.TableVersion {
+		return applied.PartitionNum == fakePartitionNumForSchemaFile
+	}
+	return applied.TableVersion == key.TableVersion &&
+		applied.PartitionNum == key.PartitionNum && applied.Date > key.Date
+}
+
+// encodeCheckpoint encodes the changes of the checkpoint, the applied and the
+// pruned dml paths, one json object per line.
+func encodeCheckpoint(
+	applied map[cloudstorage.DmlPathKey]uint64, pruned []cloudstorage.DmlPathKey,
+) ([]byte, error) {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	for _, key := range pruned {
+		f := newAppliedFile(key, 0)
+		f.Pruned = true
+		if err := encoder.Encode(f); err != nil {
+			return nil, errors.Trace(err)
+		}
+	}
+	for key, idx := range applied {
+		if err := encoder.Encode(newAppliedFile(key, idx)); err != nil {
+			return nil, errors.Trace(err)
+		}
+	}
+	return buf.Bytes(), nil
+}
+
+// decodeCheckpoint replays the changes encoded by encodeCheckpoint. The last
+// line is ignored if it's partially written.
+func decodeCheckpoint(data []byte) (map[cloudstorage.DmlPathKey]uint64, error) {
+	applied := make(map[cloudstorage.DmlPathKey]uint64)
+	lines := bytes.Split(data, []byte("\n"))
+	for i, line := range lines {
+		if len(line) == 0 {
+			continue
+		}
+		var f appliedFile
+		if err := json.Unmarshal(line, &f); err != nil {
+			// the last line without the line break may be partially written.
+			if i == len(lines)-1 {
+				log.Warn("ignore the partially written checkpoint", zap.ByteString("line", line))
+				break
+			}
+			return nil, cerror.WrapError(cerror.ErrDecodeFailed, err)
+		}
+		if f.Pruned {
+			delete(applied, f.pathKey())
+		} else {
+			applied[f.pathKey()] = f.Index
+		}
+	}
+	return applied, nil
+}
+
+// checkpointStore persists which files have been applied to the downstream,
+// so the consumer can resume from where it left off after restarting.
+type checkpointStore interface {
+	// Load returns the index of the last applied file of each dml path.
+	Load(ctx context.Context) (map[cloudstorage.DmlPathKey]uint64, error)
+	// Save persists the index of the last applied file of the dml path,
+	// and removes the pruned dml paths.
+	Save(
+		ctx context.Context, key cloudstorage.DmlPathKey, fileIdx uint64,
+		pruned []cloudstorage.DmlPathKey,
+	) error
+	// Close closes the store.
+	Close() error
+}
+
+// newCheckpointStore creates a checkpointStore by the uri, the checkpoint is
+// stored in a local file if the scheme is `file`, or in a table of the MySQL
+// compatible database if the scheme is `mysql` or `tidb`.
+func newCheckpointStore(
+	ctx context.Context, uriStr string, consumerID string, replicaConfig *config.ReplicaConfig,
+) (checkpointStore, error) {
+	uri, err := url.Parse(uriStr)
+	if err != nil {
+		return nil, cerror.WrapError(cerror.ErrSinkURIInvalid, err)
+	}
+	scheme := strings.ToLower(uri.Scheme)
+	switch {
+	case scheme == "file":
+		return &fileCheckpointStore{path: uri.Path}, nil
+	case psink.IsMySQLCompatibleScheme(scheme):
+		return newMySQLCheckpointStore(ctx, uri, consumerID, replicaConfig)
+	default:
+		return nil, cerror.ErrSinkURIInvalid.GenWithStack(
+			"the scheme of checkpoint-uri must be file, mysql or tidb, but got %s", scheme)
+	}
+}
+
+// fileCheckpointStore stores the checkpoint in a local file. The changes are
+// appended to the file, which is compacted when it's loaded or there are too
+// many appended records.
+type fileCheckpointStore struct {
+	path string
+	file *os.File
+	// applied is the checkpoint in the file.
+	applied map[cloudstorage.DmlPathKey]uint64
+	// appended is the number of the records appended since the last compaction.
+	appended int
+}
+
+func (s *fileCheckpointStore) Load(_ context.Context) (map[cloudstorage.DmlPathKey]uint64, error) {
+	data, err := os.ReadFile(s.path)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, errors.Trace(err)
+	}
+	applied, err := decodeCheckpoint(data)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	s.applied = applied
+	if err := s.compact(); err != nil {
+		return nil, errors.Trace(err)
+	}
+	result := make(map[cloudstorage.DmlPathKey]uint64, len(applied))
+	for key, idx := range applied {
+		result[key] = idx
+	}
+	return result, nil
+}
+
+func (s *fileCheckpointStore) Save(
+	_ context.Context, key cloudstorage.DmlPathKey, fileIdx uint64,
+	pruned []cloudstorage.DmlPathKey,
+) error {
+	if s.file == nil {
+		return errors.New("checkpoint file is not loaded")
+	}
+	data, err := encodeCheckpoint(map[cloudstorage.DmlPathKey]uint64{key: fileIdx}, pruned)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if _, err := s.file.Write(data); err != nil {
+		return errors.Trace(err)
+	}
+	if err := s.file.Sync(); err != nil {
+		return errors.Trace(err)
+	}
+	for _, k := range pruned {
+		delete(s.applied, k)
+	}
+	s.applied[key] = fileIdx
+	s.appended += len(pruned) + 1
+	if s.appended >= minCompactRecords && s.appended >= len(s.applied) {
+		return errors.Trace(s.compact())
+	}
+	return nil
+}
+
+// compact rewrites the checkpoint file with the current checkpoint.
+func (s *fileCheckpointStore) compact() error {
+	data, err := encodeCheckpoint(s.applied, nil)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o755); err != nil {
+		return errors.Trace(err)
+	}
+	// write to a temporary file and rename it, so the checkpoint is never partially written.
+	tmpPath := s.path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0o644); err != nil {
+		return errors.Trace(err)
+	}
+	if err := os.Rename(tmpPath, s.path); err != nil {
+		return errors.Trace(err)
+	}
+	if s.file != nil {
+		_ = s.file.Close()
+	}
+	s.file, err = os.OpenFile(s.path, os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	s.appended = 0
+	return nil
+}
+
+func (s *fileCheckpointStore) Close() error {
+	if s.file == nil {
+		return nil
+	}
+	return errors.Trace(s.file.Close())
+}
+
+// mysqlCheckpointStore stores the checkpoint in a table of the MySQL compatible
+// database, one row for each dml path.
+type mysqlCheckpointStore struct {
+	db         *sql.DB
+	consumerID string
+}
+
+func newMySQLCheckpointStore(
+	ctx context.Context, uri *url.URL, consumerID string, replicaConfig *config.ReplicaConfig,
+) (checkpointStore, error) {
+	changefeedID := model.DefaultChangeFeedID(defaultChangefeedName)
+	cfg := pmysql.NewConfig()
+	if err := cfg.Apply(config.GetGlobalServerConfig().TZ, changefeedID, uri, replicaConfig); err != nil {
+		return nil, errors.Trace(err)
+	}
+	dsnStr, err := pmysql.GenerateDSN(ctx, uri, cfg, pmysql.CreateMySQLDBConn)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	db, err := pmysql.CreateMySQLDBConn(ctx, dsnStr)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+
+	queries := []string{
+		"CREATE DATABASE IF NOT EXISTS " + filter.TiCDCSystemSchema,
+		fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s.%s
+	(
+		consumer_id varchar(255) NOT NULL,
+		schema_name varchar(64) NOT NULL,
+		table_name varchar(64) NOT NULL,
+		table_version bigint unsigned NOT NULL,
+		partition_num bigint NOT NULL,
+		date varchar(16) NOT NULL,
+		file_index bigint unsigned NOT NULL,
+		updated_at timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+		PRIMARY KEY (consumer_id, schema_name, table_name, table_version, partition_num, date)
+	);`, filter.TiCDCSystemSchema, checkpointTable),
+	}
+	for _, query := range queries {
+		if _, err := db.ExecContext(ctx, query); err != nil {
+			_ = db.Close()
+			return nil, cerror.WrapError(cerror.ErrMySQLQueryError, err)
+		}
+	}
+	log.Info("checkpoint table created",
+		zap.String("table", filter.TiCDCSystemSchema+"."+checkpointTable),
+		zap.String("consumerID", consumerID))
+	return &mysqlCheckpointStore{db: db, consumerID: consumerID}, nil
+}
+
+func (s *mysqlCheckpointStore) Load(ctx context.Context) (map[cloudstorage.DmlPathKey]uint64, error) {
+	query := fmt.Sprintf("SELECT schema_name, table_name, table_version, partition_num, date, file_index "+
+		"FROM %s.%s WHERE consumer_id = ?", filter.TiCDCSystemSchema, checkpointTable)
+	rows, err := s.db.QueryContext(ctx, query, s.consumerID)
+	if err != nil {
+		return nil, cerror.WrapError(cerror.ErrMySQLQueryError, err)
+	}
+	defer rows.Close()
+	applied := make(map[cloudstorage.DmlPathKey]uint64)
+	for rows.Next() {
+		var f appliedFile
+		err := rows.Scan(&f.Schema, &f.Table, &f.TableVersion, &f.PartitionNum, &f.Date, &f.Index)
+		if err != nil {
+			return nil, cerror.WrapError(cerror.ErrMySQLQueryError, err)
+		}
+		applied[f.pathKey()] = f.Index
+	}
+	if err := rows.Err(); err != nil {
+		return nil, cerror.WrapError(cerror.ErrMySQLQueryError, err)
+	}
+	return applied, nil
+}
+
+func (s *mysqlCheckpointStore) Save(
+	ctx context.Context, key cloudstorage.DmlPathKey, fileIdx uint64,
+	pruned []cloudstorage.DmlPathKey,
+) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return cerror.WrapError(cerror.ErrMySQLTxnError, err)
+	}
+	deleteQuery := fmt.Sprintf("DELETE FROM %s.%s WHERE consumer_id = ? AND schema_name = ? "+
+		"AND table_name = ? AND table_version = ? AND partition_num = ? AND date = ?",
+		filter.TiCDCSystemSchema, checkpointTable)
+	for _, k := range pruned {
+		if _, err := tx.ExecContext(ctx, deleteQuery, s.consumerID,
+			k.Schema, k.Table, k.TableVersion, k.PartitionNum, k.Date); err != nil {
+			_ = tx.Rollback()
+			return cerror.WrapError(cerror.ErrMySQLTxnError, err)
+		}
+	}
+	replaceQuery := fmt.Sprintf("REPLACE INTO %s.%s (consumer_id, schema_name, table_name, "+
+		"table_version, partition_num, date, file_index) VALUES (?, ?, ?, ?, ?, ?, ?)",
+		filter.TiCDCSystemSchema, checkpointTable)
+	if _, err := tx.ExecContext(ctx, replaceQuery, s.consumerID,
+		key.Schema, key.Table, key.TableVersion, key.PartitionNum, key.Date, fileIdx); err != nil {
+		_ = tx.Rollback()
+		return cerror.WrapError(cerror.ErrMySQLTxnError, err)
+	}
+	if err := tx.Commit(); err != nil {
+		return cerror.WrapError(cerror.ErrMySQLTxnError, err)
+	}
+	return nil
+}
+
+func (s *mysqlCheckpointStore) Close() error {
+	return s.db.Close()
+}
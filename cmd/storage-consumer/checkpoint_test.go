@@ -0,0 +1,152 @@
+// Copyright 2024 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/pingcap/tiflow/pkg/sink/cloudstorage"
+	"github.com/stretchr/testify/require"
+)
+
+func newDmlPathKey(table string, tableVersion uint64, partitionNum int64, date string) cloudstorage.DmlPathKey {
+	return cloudstorage.DmlPathKey{
+		SchemaPathKey: cloudstorage.SchemaPathKey{
+			Schema:       "test",
+			Table:        table,
+			TableVersion: tableVersion,
+		},
+		PartitionNum: partitionNum,
+		Date:         date,
+	}
+}
+
+func TestEncodeDecodeCheckpoint(t *testing.T) {
+	t.Parallel()
+
+	key1 := newDmlPathKey("t1", 100, 0, "2024-01-01")
+	key2 := newDmlPathKey("t1", 100, 0, "2024-01-02")
+	key3 := newDmlPathKey("t2", 100, fakePartitionNumForSchemaFile, "")
+
+	data, err := encodeCheckpoint(map[cloudstorage.DmlPathKey]uint64{key1: 3, key3: 0}, nil)
+	require.NoError(t, err)
+	applied, err := decodeCheckpoint(data)
+	require.NoError(t, err)
+	require.Equal(t, map[cloudstorage.DmlPathKey]uint64{key1: 3, key3: 0}, applied)
+
+	// the changes are replayed in order.
+	change, err := encodeCheckpoint(map[cloudstorage.DmlPathKey]uint64{key2: 1},
+		[]cloudstorage.DmlPathKey{key1})
+	require.NoError(t, err)
+	data = append(data, change...)
+	applied, err = decodeCheckpoint(data)
+	require.NoError(t, err)
+	require.Equal(t, map[cloudstorage.DmlPathKey]uint64{key2: 1, key3: 0}, applied)
+
+	// the partially written last line is ignored.
+	change, err = encodeCheckpoint(map[cloudstorage.DmlPathKey]uint64{key2: 2}, nil)
+	require.NoError(t, err)
+	applied, err = decodeCheckpoint(append(data, change[:len(change)/2]...))
+	require.NoError(t, err)
+	require.Equal(t, map[cloudstorage.DmlPathKey]uint64{key2: 1, key3: 0}, applied)
+
+	// the corrupted line in the middle fails the decoding.
+	corrupted := append(append([]byte{}, change[:len(change)/2]...), '\n')
+	_, err = decodeCheckpoint(append(corrupted, data...))
+	require.Error(t, err)
+
+	applied, err = decodeCheckpoint(nil)
+	require.NoError(t, err)
+	require.Empty(t, applied)
+}
+
+func TestSupersedes(t *testing.T) {
+	t.Parallel()
+
+	schema200 := newDmlPathKey("t1", 200, fakePartitionNumForSchemaFile, "")
+	cases := []struct {
+		applied cloudstorage.DmlPathKey
+		key     cloudstorage.DmlPathKey
+		result  bool
+	}{
+		// the DDL of a newer version supersedes the older versions.
+		{schema200, newDmlPathKey("t1", 100, 0, "2024-01-01"), true},
+		{schema200, newDmlPathKey("t1", 100, fakePartitionNumForSchemaFile, ""), true},
+		{schema200, newDmlPathKey("t1", 200, 0, "2024-01-01"), false},
+		{schema200, newDmlPathKey("t2", 100, 0, "2024-01-01"), false},
+		// the dml files of a newer version don't supersede anything
+		// as the DDL of the version may not be applied.
+		{newDmlPathKey("t1", 200, 0, "2024-01-01"), newDmlPathKey("t1", 100, 0, "2024-01-01"), false},
+		// a newer date of a partition supersedes the older dates of it.
+		{newDmlPathKey("t1", 100, 1, "2024-01-02"), newDmlPathKey("t1", 100, 1, "2024-01-01"), true},
+		{newDmlPathKey("t1", 100, 1, "2024-01-02"), newDmlPathKey("t1", 100, 2, "2024-01-01"), false},
+		{newDmlPathKey("t1", 100, 1, "2024-01-01"), newDmlPathKey("t1", 100, 1, "2024-01-01"), false},
+		{newDmlPathKey("t1", 100, 1, "2024-01-01"), newDmlPathKey("t1", 100, 1, "2024-01-02"), false},
+	}
+	for _, c := range cases {
+		require.Equal(t, c.result, supersedes(c.applied, c.key), "%+v", c)
+	}
+}
+
+func TestFileCheckpointStore(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "checkpoint", "checkpoint.json")
+	key1 := newDmlPathKey("t1", 100, 0, "2024-01-01")
+	key2 := newDmlPathKey("t1", 100, 0, "2024-01-02")
+
+	store := &fileCheckpointStore{path: path}
+	require.Error(t, store.Save(ctx, key1, 1, nil))
+	applied, err := store.Load(ctx)
+	require.NoError(t, err)
+	require.Empty(t, applied)
+
+	require.NoError(t, store.Save(ctx, key1, 1, nil))
+	require.NoError(t, store.Save(ctx, key1, 2, nil))
+	require.NoError(t, store.Save(ctx, key2, 1, []cloudstorage.DmlPathKey{key1}))
+	// only the changes are appended.
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.Equal(t, 4, bytes.Count(data, []byte("\n")))
+	require.NoError(t, store.Close())
+
+	store = &fileCheckpointStore{path: path}
+	applied, err = store.Load(ctx)
+	require.NoError(t, err)
+	require.Equal(t, map[cloudstorage.DmlPathKey]uint64{key2: 1}, applied)
+	// the file is compacted after loaded.
+	data, err = os.ReadFile(path)
+	require.NoError(t, err)
+	require.Equal(t, 1, bytes.Count(data, []byte("\n")))
+
+	// the file is compacted if there are too many appended records.
+	for i := 1; i <= minCompactRecords; i++ {
+		require.NoError(t, store.Save(ctx, key2, uint64(i), nil))
+	}
+	data, err = os.ReadFile(path)
+	require.NoError(t, err)
+	require.Equal(t, 1, bytes.Count(data, []byte("\n")))
+	require.NoError(t, store.Close())
+
+	store = &fileCheckpointStore{path: path}
+	applied, err = store.Load(ctx)
+	require.NoError(t, err)
+	require.Equal(t, map[cloudstorage.DmlPathKey]uint64{key2: minCompactRecords}, applied)
+	require.NoError(t, store.Close())
+}
@@ -18,13 +18,13 @@ import (
 	"encoding/json"
 	"flag"
 	"fmt"
+	"math"
 	"net/http"
 	"net/url"
 	"os"
 	"os/signal"
 	"sort"
 	"strings"
-	"sync"
 	"syscall"
 	"time"
 
@@ -32,22 +32,18 @@ import (
 	"github.com/pingcap/log"
 	"github.com/pingcap/tidb/br/pkg/storage"
 	"github.com/pingcap/tiflow/cdc/model"
-	"github.com/pingcap/tiflow/cdc/sink/ddlsink"
-	ddlfactory "github.com/pingcap/tiflow/cdc/sink/ddlsink/factory"
-	dmlfactory "github.com/pingcap/tiflow/cdc/sink/dmlsink/factory"
-	"github.com/pingcap/tiflow/cdc/sink/tablesink"
 	sinkutil "github.com/pingcap/tiflow/cdc/sink/util"
 	"github.com/pingcap/tiflow/pkg/cmd/util"
 	"github.com/pingcap/tiflow/pkg/config"
+	pconsumer "github.com/pingcap/tiflow/pkg/consumer"
 	"github.com/pingcap/tiflow/pkg/logutil"
-	"github.com/pingcap/tiflow/pkg/quotes"
 	psink "github.com/pingcap/tiflow/pkg/sink"
 	"github.com/pingcap/tiflow/pkg/sink/cloudstorage"
 	"github.com/pingcap/tiflow/pkg/sink/codec"
+	"github.com/pingcap/tiflow/pkg/sink/codec/avro"
 	"github.com/pingcap/tiflow/pkg/sink/codec/canal"
 	"github.com/pingcap/tiflow/pkg/sink/codec/common"
 	"github.com/pingcap/tiflow/pkg/sink/codec/csv"
-	"github.com/pingcap/tiflow/pkg/spanz"
 	putil "github.com/pingcap/tiflow/pkg/util"
 	"github.com/pingcap/tiflow/pkg/version"
 	"go.uber.org/zap"
@@ -64,16 +60,18 @@ var (
 	fileIndexWidth   int
 	enableProfiling  bool
 	timezone         string
+	checkpointURIStr string
+	consumerID       string
+	startTs          uint64
+	endTs            uint64
 )
 
 const (
 	defaultChangefeedName         = "storage-consumer"
-	defaultFlushWaitDuration      = 200 * time.Millisecond
 	fakePartitionNumForSchemaFile = -1
 )
 
 func init() {
-	version.LogVersionInfo("storage consumer")
 	flag.StringVar(&upstreamURIStr, "upstream-uri", "", "storage uri")
 	flag.StringVar(&downstreamURIStr, "downstream-uri", "", "downstream sink uri")
 	flag.StringVar(&configFile, "config", "", "changefeed configuration file")
@@ -84,6 +82,19 @@ func init() {
 		config.DefaultFileIndexWidth, "file index width")
 	flag.BoolVar(&enableProfiling, "enable-profiling", false, "whether to enable profiling")
 	flag.StringVar(&timezone, "tz", "System", "Specify time zone of storage consumer")
+	flag.StringVar(&checkpointURIStr, "checkpoint-uri", "",
+		"where to persist the applied files, a local file (file://) or a downstream table (mysql:// or tidb://), "+
+			"the progress is kept in memory only if it's empty")
+	flag.StringVar(&consumerID, "consumer-id", "",
+		"identify the checkpoint of the consumer, default to the host and path of the upstream-uri")
+	flag.Uint64Var(&startTs, "start-ts", 0, "only apply the events whose commit ts is greater than it")
+	flag.Uint64Var(&endTs, "end-ts", 0, "only apply the events whose commit ts is not greater than it, 0 means no limit")
+}
+
+// parseFlags parses and validates the flags, it's not called in init so
+// the functions of the consumer can be tested.
+func parseFlags() {
+	version.LogVersionInfo("storage consumer")
 	flag.Parse()
 
 	err := logutil.InitLogger(&logutil.Config{
@@ -106,6 +117,14 @@ func init() {
 		log.Error("invalid storage scheme, the scheme of upstream-uri must be file/s3/azblob/gcs")
 		os.Exit(1)
 	}
+	if endTs != 0 && endTs <= startTs {
+		log.Error("end-ts must be greater than start-ts",
+			zap.Uint64("startTs", startTs), zap.Uint64("endTs", endTs))
+		os.Exit(1)
+	}
+	if consumerID == "" {
+		consumerID = upstreamURI.Host + upstreamURI.Path
+	}
 }
 
 // fileIndexRange defines a range of files. eg. CDC000002.csv ~ CDC000005.csv
@@ -115,8 +134,8 @@ type fileIndexRange struct {
 }
 
 type consumer struct {
-	sinkFactory     *dmlfactory.SinkFactory
-	ddlSink         ddlsink.Sink
+	// downstream receives the events of all tables as a single partition.
+	downstream      pconsumer.Downstream
 	replicationCfg  *config.ReplicaConfig
 	codecCfg        *common.Config
 	externalStorage storage.ExternalStorage
@@ -126,11 +145,14 @@ type consumer struct {
 	// tableTsMap maintains a map of <TableID, max commit ts>
 	tableTsMap map[model.TableID]model.ResolvedTs
 	// tableDefMap maintains a map of <`schema`.`table`, tableDef slice sorted by TableVersion>
-	tableDefMap map[string]map[uint64]*cloudstorage.TableDefinition
-	// tableSinkMap maintains a map of <TableID, TableSink>
-	tableSinkMap     map[model.TableID]tablesink.TableSink
-	tableIDGenerator *fakeTableIDGenerator
-	errCh            chan error
+	tableDefMap      map[string]map[uint64]*cloudstorage.TableDefinition
+	tableIDGenerator *pconsumer.FakeTableIDGenerator
+	// appliedIdxMap maintains a map of <dmlPathKey, index of the last applied file>,
+	// the schema files whose DDLs have been executed are recorded with index 0.
+	appliedIdxMap   map[cloudstorage.DmlPathKey]uint64
+	checkpointStore checkpointStore
+	// catalogCheckpointTs is the checkpoint ts of the catalog parsed last time.
+	catalogCheckpointTs uint64
 }
 
 func newConsumer(ctx context.Context) (*consumer, error) {
@@ -159,6 +181,7 @@ func newConsumer(ctx context.Context) (*consumer, error) {
 	switch putil.GetOrZero(replicaConfig.Sink.Protocol) {
 	case config.ProtocolCsv.String():
 	case config.ProtocolCanalJSON.String():
+	case config.ProtocolAvro.String():
 	default:
 		return nil, fmt.Errorf(
 			"data encoded in protocol %s is not supported yet",
@@ -185,43 +208,42 @@ func newConsumer(ctx context.Context) (*consumer, error) {
 		return nil, err
 	}
 
-	errCh := make(chan error, 1)
-	stdCtx := ctx
-	sinkFactory, err := dmlfactory.New(
-		stdCtx,
-		model.DefaultChangeFeedID(defaultChangefeedName),
-		downstreamURIStr,
-		replicaConfig,
-		errCh,
-		nil,
-	)
-	if err != nil {
-		log.Error("failed to create event sink factory", zap.Error(err))
-		return nil, err
+	appliedIdxMap := make(map[cloudstorage.DmlPathKey]uint64)
+	var store checkpointStore
+	if checkpointURIStr != "" {
+		store, err = newCheckpointStore(ctx, checkpointURIStr, consumerID, replicaConfig)
+		if err != nil {
+			log.Error("failed to create checkpoint store", zap.Error(err))
+			return nil, err
+		}
+		appliedIdxMap, err = store.Load(ctx)
+		if err != nil {
+			log.Error("failed to load checkpoint", zap.Error(err))
+			return nil, err
+		}
+		log.Info("checkpoint loaded", zap.String("consumerID", consumerID),
+			zap.Int("appliedPathCount", len(appliedIdxMap)))
 	}
 
-	ddlSink, err := ddlfactory.New(ctx, model.DefaultChangeFeedID(defaultChangefeedName),
-		downstreamURIStr, replicaConfig)
+	downstream, err := pconsumer.NewSinkDownstream(ctx,
+		model.DefaultChangeFeedID(defaultChangefeedName), downstreamURIStr, replicaConfig)
 	if err != nil {
-		log.Error("failed to create ddl sink", zap.Error(err))
+		log.Error("failed to create downstream", zap.Error(err))
 		return nil, err
 	}
 
 	return &consumer{
-		sinkFactory:     sinkFactory,
-		ddlSink:         ddlSink,
-		replicationCfg:  replicaConfig,
-		codecCfg:        codecConfig,
-		externalStorage: storage,
-		fileExtension:   extension,
-		errCh:           errCh,
-		tableDMLIdxMap:  make(map[cloudstorage.DmlPathKey]uint64),
-		tableTsMap:      make(map[model.TableID]model.ResolvedTs),
-		tableDefMap:     make(map[string]map[uint64]*cloudstorage.TableDefinition),
-		tableSinkMap:    make(map[model.TableID]tablesink.TableSink),
-		tableIDGenerator: &fakeTableIDGenerator{
-			tableIDs: make(map[string]int64),
-		},
+		downstream:       downstream,
+		replicationCfg:   replicaConfig,
+		codecCfg:         codecConfig,
+		externalStorage:  storage,
+		fileExtension:    extension,
+		tableDMLIdxMap:   make(map[cloudstorage.DmlPathKey]uint64),
+		tableTsMap:       make(map[model.TableID]model.ResolvedTs),
+		tableDefMap:      make(map[string]map[uint64]*cloudstorage.TableDefinition),
+		tableIDGenerator: pconsumer.NewFakeTableIDGenerator(),
+		appliedIdxMap:    appliedIdxMap,
+		checkpointStore:  store,
 	}, nil
 }
 
@@ -259,7 +281,28 @@ func (c *consumer) getNewFiles(
 		origDMLIdxMap[k] = v
 	}
 
-	err := c.externalStorage.WalkDir(ctx, opt, func(path string, size int64) error {
+	catalog, err := cloudstorage.LoadCatalog(ctx, c.externalStorage)
+	if err != nil {
+		return tableDMLMap, errors.Trace(err)
+	}
+	if catalog != nil {
+		// the files are found from the catalog instead of walking the whole storage,
+		// the files not compacted to the catalog yet are found in the next rounds.
+		if catalog.CheckpointTs() == c.catalogCheckpointTs {
+			return tableDMLMap, nil
+		}
+		if err := c.parseCatalog(ctx, catalog); err != nil {
+			return tableDMLMap, errors.Trace(err)
+		}
+		c.catalogCheckpointTs = catalog.CheckpointTs()
+		tableDMLMap = diffDMLMaps(c.tableDMLIdxMap, origDMLIdxMap)
+		return tableDMLMap, nil
+	}
+
+	err = c.externalStorage.WalkDir(ctx, opt, func(path string, size int64) error {
+		if cloudstorage.IsCatalogFile(path) {
+			return nil
+		}
 		if cloudstorage.IsSchemaFile(path) {
 			err := c.parseSchemaFilePath(ctx, path)
 			if err != nil {
@@ -287,6 +330,32 @@ func (c *consumer) getNewFiles(
 	return tableDMLMap, err
 }
 
+// parseCatalog parses the schema files and the dml files recorded in the catalog.
+// The versions of a table older than the one applied at the start ts are skipped,
+// since all the events of them are not in the ts range. Only the schema files not
+// parsed yet are read, the dml files are found from the catalog directly.
+func (c *consumer) parseCatalog(ctx context.Context, catalog *cloudstorage.Catalog) error {
+	for _, table := range catalog.Tables() {
+		first := catalog.VersionAt(table.Schema, table.Table, startTs)
+		for _, version := range catalog.Versions(table.Schema, table.Table) {
+			if first != nil && version.TableVersion < first.TableVersion {
+				continue
+			}
+			if err := c.parseSchemaFilePath(ctx, version.SchemaFile); err != nil {
+				log.Error("failed to parse schema file path", zap.Error(err))
+				// skip handling this version
+				continue
+			}
+			for key, files := range version.DataFileRanges() {
+				if files.Last > c.tableDMLIdxMap[key] {
+					c.tableDMLIdxMap[key] = files.Last
+				}
+			}
+		}
+	}
+	return nil
+}
+
 // emitDMLEvents decodes RowChangedEvents from file content and emit them.
 func (c *consumer) emitDMLEvents(
 	ctx context.Context, tableID int64,
@@ -322,6 +391,12 @@ func (c *consumer) emitDMLEvents(
 		if err != nil {
 			return errors.Trace(err)
 		}
+	case config.ProtocolAvro:
+		// the avro file is self-describing, it embeds the writer schema.
+		decoder, err = avro.NewOCFDecoder(c.codecCfg, tableInfo, content)
+		if err != nil {
+			return errors.Trace(err)
+		}
 	}
 
 	cnt := 0
@@ -343,12 +418,8 @@ func (c *consumer) emitDMLEvents(
 				log.Error("failed to get next row changed event", zap.Error(err))
 				return errors.Trace(err)
 			}
-
-			if _, ok := c.tableSinkMap[tableID]; !ok {
-				c.tableSinkMap[tableID] = c.sinkFactory.CreateTableSinkForConsumer(
-					model.DefaultChangeFeedID(defaultChangefeedName),
-					spanz.TableIDToComparableSpan(tableID),
-					row.CommitTs)
+			if !isInTsRange(row.CommitTs) {
+				continue
 			}
 
 			_, ok := c.tableTsMap[tableID]
@@ -369,7 +440,9 @@ func (c *consumer) emitDMLEvents(
 				continue
 			}
 			row.PhysicalTableID = tableID
-			c.tableSinkMap[tableID].AppendRowChangedEvents(row)
+			if err := c.downstream.AppendRowChangedEvents(ctx, 0, tableID, row); err != nil {
+				return errors.Trace(err)
+			}
 			filteredCnt++
 		}
 	}
@@ -383,27 +456,15 @@ func (c *consumer) emitDMLEvents(
 }
 
 func (c *consumer) waitTableFlushComplete(ctx context.Context, tableID model.TableID) error {
-	for {
-		select {
-		case <-ctx.Done():
-			return ctx.Err()
-		case err := <-c.errCh:
-			return err
-		default:
-		}
-
-		resolvedTs := c.tableTsMap[tableID]
-		err := c.tableSinkMap[tableID].UpdateResolvedTs(resolvedTs)
-		if err != nil {
-			return errors.Trace(err)
-		}
-		checkpoint := c.tableSinkMap[tableID].GetCheckpointTs()
-		if checkpoint.Equal(resolvedTs) {
-			c.tableTsMap[tableID] = resolvedTs.AdvanceBatch()
-			return nil
-		}
-		time.Sleep(defaultFlushWaitDuration)
+	resolvedTs, ok := c.tableTsMap[tableID]
+	if !ok {
+		return nil
 	}
+	if err := c.downstream.Flush(ctx, 0, tableID, resolvedTs); err != nil {
+		return errors.Trace(err)
+	}
+	c.tableTsMap[tableID] = resolvedTs.AdvanceBatch()
+	return nil
 }
 
 func (c *consumer) syncExecDMLEvents(
@@ -418,18 +479,13 @@ func (c *consumer) syncExecDMLEvents(
 	if err != nil {
 		return errors.Trace(err)
 	}
-	tableID := c.tableIDGenerator.generateFakeTableID(
+	tableID := c.tableIDGenerator.GenerateFakeTableID(
 		key.Schema, key.Table, key.PartitionNum)
 	err = c.emitDMLEvents(ctx, tableID, tableDef, key, content)
 	if err != nil {
 		return errors.Trace(err)
 	}
 
-	resolvedTs := c.tableTsMap[tableID]
-	err = c.tableSinkMap[tableID].UpdateResolvedTs(resolvedTs)
-	if err != nil {
-		return errors.Trace(err)
-	}
 	err = c.waitTableFlushComplete(ctx, tableID)
 	if err != nil {
 		return errors.Trace(err)
@@ -570,39 +626,109 @@ func (c *consumer) handleNewFiles(
 		tableDef := c.mustGetTableDef(key.SchemaPathKey)
 		// if the key is a fake dml path key which is mainly used for
 		// sorting schema.json file before the dml files, then execute the ddl query.
-		if key.PartitionNum == fakePartitionNumForSchemaFile &&
-			len(key.Date) == 0 && len(tableDef.Query) > 0 {
-			ddlEvent, err := tableDef.ToDDLEvent()
-			if err != nil {
+		if key.PartitionNum == fakePartitionNumForSchemaFile && len(key.Date) == 0 {
+			if err := c.execDDLEvent(ctx, tableDef, key); err != nil {
 				return err
 			}
-			if err := c.ddlSink.WriteDDLEvent(ctx, ddlEvent); err != nil {
-				return errors.Trace(err)
-			}
-			// TODO: need to cleanup tableDefMap in the future.
-			log.Info("execute ddl event successfully", zap.String("query", tableDef.Query))
 			continue
 		}
 
 		fileRange := dmlFileMap[key]
-		for i := fileRange.start; i <= fileRange.end; i++ {
+		start := fileRange.start
+		if applied, ok := c.appliedIndex(key); ok {
+			if applied >= fileRange.end {
+				continue
+			}
+			if applied >= start {
+				start = applied + 1
+			}
+		}
+		for i := start; i <= fileRange.end; i++ {
 			if err := c.syncExecDMLEvents(ctx, tableDef, key, i); err != nil {
 				return err
 			}
+			if err := c.markApplied(ctx, key, i); err != nil {
+				return err
+			}
 		}
 	}
 
 	return nil
 }
 
+// execDDLEvent executes the DDL recorded in the schema file if it's not applied yet.
+// The schema file without any DDL is marked applied directly.
+func (c *consumer) execDDLEvent(
+	ctx context.Context, tableDef cloudstorage.TableDefinition, key cloudstorage.DmlPathKey,
+) error {
+	if _, ok := c.appliedIndex(key); ok {
+		log.Info("skip the applied ddl event", zap.String("query", tableDef.Query),
+			zap.Uint64("tableVersion", key.TableVersion))
+		return nil
+	}
+	if len(tableDef.Query) == 0 {
+		return c.markApplied(ctx, key, 0)
+	}
+	ddlEvent, err := tableDef.ToDDLEvent()
+	if err != nil {
+		return err
+	}
+	if isInTsRange(ddlEvent.CommitTs) {
+		if err := c.downstream.WriteDDLEvent(ctx, ddlEvent); err != nil {
+			return errors.Trace(err)
+		}
+		// TODO: need to cleanup tableDefMap in the future.
+		log.Info("execute ddl event successfully", zap.String("query", tableDef.Query))
+	} else {
+		log.Info("skip the ddl event out of the ts range", zap.String("query", tableDef.Query),
+			zap.Uint64("commitTs", ddlEvent.CommitTs),
+			zap.Uint64("startTs", startTs), zap.Uint64("endTs", endTs))
+	}
+	return c.markApplied(ctx, key, 0)
+}
+
+// markApplied records the file is applied, prunes the dml paths superseded by it,
+// and persists the changes if the checkpoint store is set.
+func (c *consumer) markApplied(ctx context.Context, key cloudstorage.DmlPathKey, fileIdx uint64) error {
+	c.appliedIdxMap[key] = fileIdx
+	var pruned []cloudstorage.DmlPathKey
+	for k := range c.appliedIdxMap {
+		if supersedes(key, k) {
+			delete(c.appliedIdxMap, k)
+			pruned = append(pruned, k)
+		}
+	}
+	if c.checkpointStore == nil {
+		return nil
+	}
+	return errors.Trace(c.checkpointStore.Save(ctx, key, fileIdx, pruned))
+}
+
+// appliedIndex returns the index of the last applied file of the dml path,
+// it's math.MaxUint64 if the path is pruned as all of its files are applied.
+func (c *consumer) appliedIndex(key cloudstorage.DmlPathKey) (uint64, bool) {
+	if idx, ok := c.appliedIdxMap[key]; ok {
+		return idx, true
+	}
+	for applied := range c.appliedIdxMap {
+		if supersedes(applied, key) {
+			return math.MaxUint64, true
+		}
+	}
+	return 0, false
+}
+
+// isInTsRange returns whether the event with the commit ts should be applied.
+func isInTsRange(commitTs uint64) bool {
+	return commitTs > startTs && (endTs == 0 || commitTs <= endTs)
+}
+
 func (c *consumer) run(ctx context.Context) error {
 	ticker := time.NewTicker(flushInterval)
 	for {
 		select {
 		case <-ctx.Done():
 			return ctx.Err()
-		case err := <-c.errCh:
-			return err
 		case <-ticker.C:
 		}
 
@@ -618,32 +744,12 @@ func (c *consumer) run(ctx context.Context) error {
 	}
 }
 
-// copied from kafka-consumer
-type fakeTableIDGenerator struct {
-	tableIDs       map[string]int64
-	currentTableID int64
-	mu             sync.Mutex
-}
-
-func (g *fakeTableIDGenerator) generateFakeTableID(schema, table string, partition int64) int64 {
-	g.mu.Lock()
-	defer g.mu.Unlock()
-	key := quotes.QuoteSchema(schema, table)
-	if partition != 0 {
-		key = fmt.Sprintf("%s.`%d`", key, partition)
-	}
-	if tableID, ok := g.tableIDs[key]; ok {
-		return tableID
-	}
-	g.currentTableID++
-	g.tableIDs[key] = g.currentTableID
-	return g.currentTableID
-}
-
 func main() {
 	var consumer *consumer
 	var err error
 
+	parseFlags()
+
 	if enableProfiling {
 		go func() {
 			server := &http.Server{
@@ -661,7 +767,10 @@ func main() {
 	deferFunc := func() int {
 		stop()
 		if consumer != nil {
-			consumer.sinkFactory.Close()
+			consumer.downstream.Close()
+			if consumer.checkpointStore != nil {
+				_ = consumer.checkpointStore.Close()
+			}
 		}
 		if err != nil && err != context.Canceled {
 			return 1
@@ -0,0 +1,242 @@
+// Copyright 2024 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/pingcap/tidb/br/pkg/storage"
+	timodel "github.com/pingcap/tidb/pkg/meta/model"
+	"github.com/pingcap/tiflow/cdc/model"
+	"github.com/pingcap/tiflow/pkg/config"
+	pconsumer "github.com/pingcap/tiflow/pkg/consumer"
+	"github.com/pingcap/tiflow/pkg/sink/cloudstorage"
+	"github.com/pingcap/tiflow/pkg/sink/codec/common"
+	putil "github.com/pingcap/tiflow/pkg/util"
+	"github.com/stretchr/testify/require"
+)
+
+type mockDownstream struct {
+	ddls []string
+}
+
+func (d *mockDownstream) WriteDDLEvent(_ context.Context, ddl *model.DDLEvent) error {
+	d.ddls = append(d.ddls, ddl.Query)
+	return nil
+}
+
+func (d *mockDownstream) AppendRowChangedEvents(
+	_ context.Context, _ int32, _ model.TableID, _ ...*model.RowChangedEvent,
+) error {
+	return nil
+}
+
+func (d *mockDownstream) Flush(
+	_ context.Context, _ int32, _ model.TableID, _ model.ResolvedTs,
+) error {
+	return nil
+}
+
+func (d *mockDownstream) Close() {}
+
+// recordStorage records the dml files read from the storage.
+type recordStorage struct {
+	storage.ExternalStorage
+	dmlFiles []string
+}
+
+func (s *recordStorage) ReadFile(ctx context.Context, name string) ([]byte, error) {
+	if strings.HasSuffix(name, ".csv") {
+		s.dmlFiles = append(s.dmlFiles, name)
+	}
+	return s.ExternalStorage.ReadFile(ctx, name)
+}
+
+func newTestConsumer(
+	t *testing.T, upstreamDir, checkpointPath string,
+) (*consumer, *mockDownstream, *recordStorage) {
+	ctx := context.Background()
+	replicaConfig := config.GetDefaultReplicaConfig()
+	replicaConfig.Sink.DateSeparator = putil.AddressOf(config.DateSeparatorNone.String())
+	uri, err := url.Parse("file://" + upstreamDir + "?protocol=csv")
+	require.NoError(t, err)
+	codecConfig := common.NewConfig(config.ProtocolCsv)
+	require.NoError(t, codecConfig.Apply(uri, replicaConfig))
+	extStorage, err := putil.GetExternalStorageFromURI(ctx, "file://"+upstreamDir)
+	require.NoError(t, err)
+
+	store, err := newCheckpointStore(ctx, "file://"+checkpointPath, "", replicaConfig)
+	require.NoError(t, err)
+	applied, err := store.Load(ctx)
+	require.NoError(t, err)
+
+	downstream := &mockDownstream{}
+	recorder := &recordStorage{ExternalStorage: extStorage}
+	return &consumer{
+		downstream:       downstream,
+		replicationCfg:   replicaConfig,
+		codecCfg:         codecConfig,
+		externalStorage:  recorder,
+		fileExtension:    ".csv",
+		tableDMLIdxMap:   make(map[cloudstorage.DmlPathKey]uint64),
+		tableTsMap:       make(map[model.TableID]model.ResolvedTs),
+		tableDefMap:      make(map[string]map[uint64]*cloudstorage.TableDefinition),
+		tableIDGenerator: pconsumer.NewFakeTableIDGenerator(),
+		appliedIdxMap:    applied,
+		checkpointStore:  store,
+	}, downstream, recorder
+}
+
+func writeSchemaFile(t *testing.T, dir string, tableVersion uint64, query string) {
+	def := cloudstorage.TableDefinition{
+		Table:        "t1",
+		Schema:       "test",
+		Version:      1,
+		TableVersion: tableVersion,
+		Query:        query,
+		Type:         timodel.ActionCreateTable,
+		Columns: []cloudstorage.TableCol{
+			{Name: "id", Tp: "INT", Precision: "11", Nullable: "false", IsPK: "true"},
+		},
+		TotalColumns: 1,
+	}
+	path, err := def.GenerateSchemaFilePath()
+	require.NoError(t, err)
+	data, err := def.MarshalWithQuery()
+	require.NoError(t, err)
+	writeFile(t, dir, path, data)
+}
+
+func writeDMLFile(t *testing.T, dir string, key cloudstorage.DmlPathKey, idx uint64) string {
+	path := key.GenerateDMLFilePath(idx, ".csv", config.DefaultFileIndexWidth)
+	writeFile(t, dir, path, nil)
+	return path
+}
+
+func writeFile(t *testing.T, dir, path string, data []byte) {
+	path = filepath.Join(dir, path)
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+	require.NoError(t, os.WriteFile(path, data, 0o644))
+}
+
+func consumeOnce(t *testing.T, c *consumer) {
+	ctx := context.Background()
+	dmlFileMap, err := c.getNewFiles(ctx)
+	require.NoError(t, err)
+	require.NoError(t, c.handleNewFiles(ctx, dmlFileMap))
+}
+
+func TestConsumerResumeAfterRestart(t *testing.T) {
+	dir := t.TempDir()
+	upstreamDir := filepath.Join(dir, "upstream")
+	checkpointPath := filepath.Join(dir, "checkpoint", "checkpoint.json")
+
+	key100 := newDmlPathKey("t1", 100, 0, "")
+	writeSchemaFile(t, upstreamDir, 100, "CREATE TABLE t1 (id INT PRIMARY KEY)")
+	file1 := writeDMLFile(t, upstreamDir, key100, 1)
+	file2 := writeDMLFile(t, upstreamDir, key100, 2)
+
+	c, downstream, recorder := newTestConsumer(t, upstreamDir, checkpointPath)
+	consumeOnce(t, c)
+	require.Equal(t, []string{"CREATE TABLE t1 (id INT PRIMARY KEY)"}, downstream.ddls)
+	require.Equal(t, []string{file1, file2}, recorder.dmlFiles)
+	require.NoError(t, c.checkpointStore.Close())
+
+	// nothing is applied again after restarting.
+	c, downstream, recorder = newTestConsumer(t, upstreamDir, checkpointPath)
+	consumeOnce(t, c)
+	require.Empty(t, downstream.ddls)
+	require.Empty(t, recorder.dmlFiles)
+
+	// only the new files are applied.
+	file3 := writeDMLFile(t, upstreamDir, key100, 3)
+	consumeOnce(t, c)
+	require.Equal(t, []string{file3}, recorder.dmlFiles)
+	require.NoError(t, c.checkpointStore.Close())
+
+	// the old table version is pruned from the checkpoint once the DDL of a
+	// new version is applied, and it's not applied again after restarting.
+	key200 := newDmlPathKey("t1", 200, 0, "")
+	writeSchemaFile(t, upstreamDir, 200, "ALTER TABLE t1 ADD COLUMN c INT")
+	file4 := writeDMLFile(t, upstreamDir, key200, 1)
+	c, downstream, recorder = newTestConsumer(t, upstreamDir, checkpointPath)
+	consumeOnce(t, c)
+	require.Equal(t, []string{"ALTER TABLE t1 ADD COLUMN c INT"}, downstream.ddls)
+	require.Equal(t, []string{file4}, recorder.dmlFiles)
+	require.NoError(t, c.checkpointStore.Close())
+
+	c, downstream, recorder = newTestConsumer(t, upstreamDir, checkpointPath)
+	require.Equal(t, map[cloudstorage.DmlPathKey]uint64{
+		newDmlPathKey("t1", 200, fakePartitionNumForSchemaFile, ""): 0,
+		key200: 1,
+	}, c.appliedIdxMap)
+	consumeOnce(t, c)
+	require.Empty(t, downstream.ddls)
+	require.Empty(t, recorder.dmlFiles)
+	require.NoError(t, c.checkpointStore.Close())
+}
+
+func TestConsumerWithCatalog(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	upstreamDir := filepath.Join(dir, "upstream")
+	checkpointPath := filepath.Join(dir, "checkpoint", "checkpoint.json")
+
+	key100 := newDmlPathKey("t1", 100, 0, "")
+	writeSchemaFile(t, upstreamDir, 100, "CREATE TABLE t1 (id INT PRIMARY KEY)")
+	file1 := writeDMLFile(t, upstreamDir, key100, 1)
+	file2 := writeDMLFile(t, upstreamDir, key100, 2)
+
+	extStorage, err := putil.GetExternalStorageFromURI(ctx, "file://"+upstreamDir)
+	require.NoError(t, err)
+	schemaFiles, err := filepath.Glob(filepath.Join(upstreamDir, "test/t1/meta/schema_100_*.json"))
+	require.NoError(t, err)
+	require.Len(t, schemaFiles, 1)
+	schemaFile, err := filepath.Rel(upstreamDir, schemaFiles[0])
+	require.NoError(t, err)
+	require.NoError(t, cloudstorage.WriteCatalogVersion(ctx, extStorage, "test", "t1", 100, schemaFile))
+	writeDataShard := func(last uint64) {
+		writeFile(t, upstreamDir, fmt.Sprintf(".catalog/test/t1/100/data_20_%d.json", last), []byte(fmt.Sprintf(
+			`{"Schema":"test","Table":"t1","TableVersion":100,"TableID":20,`+
+				`"Dirs":[{"Date":"","Files":{"First":1,"Last":%d}}]}`, last)))
+	}
+	writeDataShard(2)
+	compactor := cloudstorage.NewCatalogCompactor(extStorage)
+	require.NoError(t, compactor.Compact(ctx, 100))
+
+	// only the files recorded in the compacted catalog are applied.
+	file3 := writeDMLFile(t, upstreamDir, key100, 3)
+	writeDataShard(3)
+	c, downstream, recorder := newTestConsumer(t, upstreamDir, checkpointPath)
+	consumeOnce(t, c)
+	require.Equal(t, []string{"CREATE TABLE t1 (id INT PRIMARY KEY)"}, downstream.ddls)
+	require.Equal(t, []string{file1, file2}, recorder.dmlFiles)
+
+	recorder.dmlFiles = nil
+	require.NoError(t, compactor.Compact(ctx, 200))
+	consumeOnce(t, c)
+	require.Equal(t, []string{file3}, recorder.dmlFiles)
+
+	// nothing is found if the catalog is not compacted again.
+	recorder.dmlFiles = nil
+	consumeOnce(t, c)
+	require.Empty(t, recorder.dmlFiles)
+	require.NoError(t, c.checkpointStore.Close())
+}
@@ -0,0 +1,220 @@
+// Copyright 2024 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package alert
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/pingcap/errors"
+	"github.com/pingcap/log"
+	"github.com/pingcap/tiflow/pkg/config"
+	"github.com/pingcap/tiflow/pkg/httputil"
+	"github.com/pingcap/tiflow/pkg/retry"
+	"github.com/pingcap/tiflow/pkg/security"
+	"go.uber.org/zap"
+)
+
+const (
+	defaultQueueSize        = 1024
+	defaultRequestTimeout   = 5 * time.Second
+	defaultCloseTimeout     = 30 * time.Second
+	defaultBackoffBaseInMs  = 500
+	defaultBackoffMaxInMs   = 5000
+	dedupGCIntervalInAlerts = 1024
+)
+
+// Kind is the kind of an alert.
+type Kind string
+
+const (
+	// KindCheckpointLag is fired when the checkpoint lag exceeds the threshold.
+	KindCheckpointLag Kind = "checkpoint-lag"
+	// KindStateChanged is fired when the state of the changefeed is changed.
+	KindStateChanged Kind = "state-changed"
+	// KindDDLBlocked is fired when a DDL is blocked longer than the threshold.
+	KindDDLBlocked Kind = "ddl-blocked"
+)
+
+// Alert is the payload POSTed to webhooks.
+type Alert struct {
+	Namespace    string    `json:"namespace"`
+	Changefeed   string    `json:"changefeed"`
+	Kind         Kind      `json:"kind"`
+	State        string    `json:"state"`
+	CheckpointTs uint64    `json:"checkpoint_ts"`
+	Message      string    `json:"message"`
+	Time         time.Time `json:"time"`
+	// DedupKey identifies alerts that are considered duplicated.
+	// Alerts with the same key are sent at most once in the dedup interval.
+	DedupKey string `json:"dedup_key"`
+}
+
+// Notifier sends alerts to webhooks.
+type Notifier interface {
+	// Notify queues the alert to be sent according to the config.
+	// It never blocks, alerts are dropped if the queue is full.
+	Notify(cfg *config.AlertConfig, alert *Alert)
+	// Pending returns the number of the alerts queued or being sent.
+	Pending() int
+	// Close stops the notifier and waits for background goroutines.
+	// The queued alerts are still sent unless it takes too long, so it
+	// should be called in the background if any alert is pending.
+	Close()
+}
+
+type pendingAlert struct {
+	cfg   *config.AlertConfig
+	alert *Alert
+}
+
+type webhookNotifier struct {
+	client *httputil.Client
+	queue  chan pendingAlert
+	// pending is the number of the alerts queued or being sent.
+	pending atomic.Int64
+
+	mu sync.Mutex
+	// lastSent records the last time an alert with the dedup key was queued.
+	lastSent map[string]time.Time
+
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewWebhookNotifier creates a Notifier which POSTs alerts as JSON to webhooks.
+func NewWebhookNotifier(credential *security.Credential) (Notifier, error) {
+	client, err := httputil.NewClient(credential)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	client.SetTimeout(defaultRequestTimeout)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	n := &webhookNotifier{
+		client:   client,
+		queue:    make(chan pendingAlert, defaultQueueSize),
+		lastSent: make(map[string]time.Time),
+		cancel:   cancel,
+	}
+	n.wg.Add(1)
+	go func() {
+		defer n.wg.Done()
+		n.run(ctx)
+	}()
+	return n, nil
+}
+
+// Notify implements Notifier.
+func (n *webhookNotifier) Notify(cfg *config.AlertConfig, alert *Alert) {
+	if !cfg.Enabled() || n.isDuplicated(cfg, alert) {
+		return
+	}
+	n.pending.Add(1)
+	select {
+	case n.queue <- pendingAlert{cfg: cfg, alert: alert}:
+	default:
+		n.pending.Add(-1)
+		log.Warn("alert queue is full, drop the alert",
+			zap.String("namespace", alert.Namespace),
+			zap.String("changefeed", alert.Changefeed),
+			zap.String("kind", string(alert.Kind)),
+			zap.String("message", alert.Message))
+	}
+}
+
+// Pending implements Notifier.
+func (n *webhookNotifier) Pending() int {
+	return int(n.pending.Load())
+}
+
+// isDuplicated returns true if an alert with the same key has been sent
+// in the dedup interval, otherwise it records the alert.
+func (n *webhookNotifier) isDuplicated(cfg *config.AlertConfig, alert *Alert) bool {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	interval := cfg.GetDedupInterval()
+	key := alert.Namespace + "/" + alert.Changefeed + "/" + alert.DedupKey
+	if last, ok := n.lastSent[key]; ok && alert.Time.Sub(last) < interval {
+		return true
+	}
+	n.lastSent[key] = alert.Time
+	// Clean up expired keys to avoid the map growing infinitely.
+	if len(n.lastSent) > dedupGCIntervalInAlerts {
+		for k, t := range n.lastSent {
+			if alert.Time.Sub(t) >= interval {
+				delete(n.lastSent, k)
+			}
+		}
+	}
+	return false
+}
+
+func (n *webhookNotifier) run(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case pending, ok := <-n.queue:
+			if !ok {
+				return
+			}
+			for _, url := range pending.cfg.WebhookURLs {
+				if err := n.send(ctx, pending.cfg, url, pending.alert); err != nil {
+					log.Warn("fail to send alert to webhook",
+						zap.String("url", url),
+						zap.String("namespace", pending.alert.Namespace),
+						zap.String("changefeed", pending.alert.Changefeed),
+						zap.String("kind", string(pending.alert.Kind)),
+						zap.Error(err))
+				}
+			}
+			n.pending.Add(-1)
+		}
+	}
+}
+
+func (n *webhookNotifier) send(
+	ctx context.Context, cfg *config.AlertConfig, url string, alert *Alert,
+) error {
+	body, err := json.Marshal(alert)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	header := http.Header{}
+	header.Set("Content-Type", "application/json")
+	maxTries := cfg.MaxRetries + 1
+	return retry.Do(ctx, func() error {
+		_, err := n.client.DoRequest(ctx, url, http.MethodPost, header, bytes.NewReader(body))
+		return err
+	}, retry.WithBackoffBaseDelay(defaultBackoffBaseInMs),
+		retry.WithBackoffMaxDelay(defaultBackoffMaxInMs),
+		retry.WithMaxTries(uint64(maxTries)))
+}
+
+// Close implements Notifier. It must not be called concurrently with Notify.
+func (n *webhookNotifier) Close() {
+	close(n.queue)
+	// give up the queued alerts if the webhooks are not responding.
+	timer := time.AfterFunc(defaultCloseTimeout, n.cancel)
+	n.wg.Wait()
+	timer.Stop()
+	n.cancel()
+	n.client.CloseIdleConnections()
+}
@@ -0,0 +1,144 @@
+// Copyright 2024 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package alert
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pingcap/tiflow/pkg/config"
+	"github.com/pingcap/tiflow/pkg/util"
+	"github.com/stretchr/testify/require"
+)
+
+// receiver is a local HTTP receiver of alerts.
+type receiver struct {
+	mu       sync.Mutex
+	alerts   []*Alert
+	failures int
+}
+
+func (r *receiver) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.failures > 0 {
+		r.failures--
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+	a := &Alert{}
+	if err := json.NewDecoder(req.Body).Decode(a); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	r.alerts = append(r.alerts, a)
+	w.WriteHeader(http.StatusOK)
+}
+
+func (r *receiver) received() []*Alert {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]*Alert(nil), r.alerts...)
+}
+
+func TestWebhookNotifier(t *testing.T) {
+	t.Parallel()
+
+	r := &receiver{failures: 2}
+	server := httptest.NewServer(r)
+	defer server.Close()
+
+	n, err := NewWebhookNotifier(nil)
+	require.NoError(t, err)
+	defer n.Close()
+
+	cfg := &config.AlertConfig{
+		WebhookURLs:   []string{server.URL},
+		MaxRetries:    3,
+		DedupInterval: util.AddressOf(time.Minute),
+	}
+	now := time.Now()
+	a := &Alert{
+		Namespace:  "default",
+		Changefeed: "test",
+		Kind:       KindCheckpointLag,
+		Message:    "lag too large",
+		Time:       now,
+		DedupKey:   string(KindCheckpointLag),
+	}
+	n.Notify(cfg, a)
+	// duplicated alerts are dropped
+	dup := *a
+	dup.Time = now.Add(time.Second)
+	n.Notify(cfg, &dup)
+	// alerts with another key are sent
+	other := *a
+	other.Kind = KindStateChanged
+	other.DedupKey = "state-changed/failed"
+	n.Notify(cfg, &other)
+	require.Positive(t, n.Pending())
+
+	require.Eventually(t, func() bool {
+		return len(r.received()) == 2
+	}, 10*time.Second, 50*time.Millisecond)
+	// the alert is pending until all the retries are done.
+	require.Eventually(t, func() bool {
+		return n.Pending() == 0
+	}, 10*time.Second, 50*time.Millisecond)
+	alerts := r.received()
+	require.Equal(t, KindCheckpointLag, alerts[0].Kind)
+	require.Equal(t, "lag too large", alerts[0].Message)
+	require.Equal(t, KindStateChanged, alerts[1].Kind)
+
+	// the same alert is sent again after the dedup interval
+	again := *a
+	again.Time = now.Add(2 * time.Minute)
+	n.Notify(cfg, &again)
+	require.Eventually(t, func() bool {
+		return len(r.received()) == 3
+	}, 10*time.Second, 50*time.Millisecond)
+}
+
+func TestWebhookNotifierDisabled(t *testing.T) {
+	t.Parallel()
+
+	n, err := NewWebhookNotifier(nil)
+	require.NoError(t, err)
+	defer n.Close()
+
+	// nothing happens if there is no webhook
+	n.Notify(nil, &Alert{})
+	n.Notify(&config.AlertConfig{}, &Alert{})
+	require.Len(t, n.(*webhookNotifier).lastSent, 0)
+}
+
+func TestWebhookNotifierClose(t *testing.T) {
+	t.Parallel()
+
+	r := &receiver{}
+	server := httptest.NewServer(r)
+	defer server.Close()
+
+	n, err := NewWebhookNotifier(nil)
+	require.NoError(t, err)
+	cfg := &config.AlertConfig{WebhookURLs: []string{server.URL}}
+	n.Notify(cfg, &Alert{Kind: KindStateChanged, Time: time.Now(), DedupKey: "state-changed/stopped"})
+	// the queued alert is sent before the notifier is closed.
+	n.Close()
+	require.Len(t, r.received(), 1)
+}
@@ -37,6 +37,9 @@ type ChangefeedInterface interface {
 	// Update updates a changefeed
 	Update(ctx context.Context, cfg *v2.ChangefeedConfig,
 		namespace string, name string) (*v2.ChangeFeedInfo, error)
+	// Validate verifies a changefeed config update without applying it
+	Validate(ctx context.Context, cfg *v2.ChangefeedConfig,
+		namespace string, name string) (*v2.ChangefeedValidationResult, error)
 	// Resume resumes a changefeed with given config
 	Resume(ctx context.Context, cfg *v2.ResumeChangefeedConfig, namespace string, name string) error
 	// Delete deletes a changefeed by name
@@ -45,6 +48,9 @@ type ChangefeedInterface interface {
 	Pause(ctx context.Context, namespace string, name string) error
 	// Get gets a changefeed detaail info
 	Get(ctx context.Context, namespace string, name string) (*v2.ChangeFeedInfo, error)
+	// GetLatency gets the latency breakdown of a changefeed
+	GetLatency(ctx context.Context, namespace string, name string,
+		slowRegionLimit int) (*v2.ChangefeedLatency, error)
 	// List lists all changefeeds
 	List(ctx context.Context, namespace string, state string) ([]v2.ChangefeedCommonInfo, error)
 }
@@ -97,6 +103,20 @@ func (c *changefeeds) Update(ctx context.Context,
 	return result, err
 }
 
+// Validate verifies a changefeed config update without applying it
+func (c *changefeeds) Validate(ctx context.Context,
+	cfg *v2.ChangefeedConfig, namespace string, name string,
+) (*v2.ChangefeedValidationResult, error) {
+	result := &v2.ChangefeedValidationResult{}
+	u := fmt.Sprintf("changefeeds/%s/validate?namespace=%s", name, namespace)
+	err := c.client.Post().
+		WithURI(u).
+		WithBody(cfg).
+		Do(ctx).
+		Into(result)
+	return result, err
+}
+
 // Resume a changefeed
 func (c *changefeeds) Resume(ctx context.Context,
 	cfg *v2.ResumeChangefeedConfig, namespace string, name string,
@@ -145,6 +165,24 @@ func (c *changefeeds) Get(ctx context.Context,
 	return result, err
 }
 
+// GetLatency gets the latency breakdown of a changefeed
+func (c *changefeeds) GetLatency(ctx context.Context,
+	namespace string, name string, slowRegionLimit int,
+) (*v2.ChangefeedLatency, error) {
+	err := model.ValidateChangefeedID(name)
+	if err != nil {
+		return nil, err
+	}
+	result := new(v2.ChangefeedLatency)
+	u := fmt.Sprintf("changefeeds/%s/latency?namespace=%s&slow_region_limit=%d",
+		name, namespace, slowRegionLimit)
+	err = c.client.Get().
+		WithURI(u).
+		Do(ctx).
+		Into(result)
+	return result, err
+}
+
 // List lists all changefeeds
 func (c *changefeeds) List(ctx context.Context,
 	namespace string, state string,
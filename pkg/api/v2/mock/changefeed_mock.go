@@ -117,6 +117,21 @@ func (mr *MockChangefeedInterfaceMockRecorder) Get(ctx, namespace, name interfac
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockChangefeedInterface)(nil).Get), ctx, namespace, name)
 }
 
+// GetLatency mocks base method.
+func (m *MockChangefeedInterface) GetLatency(ctx context.Context, namespace, name string, slowRegionLimit int) (*v2.ChangefeedLatency, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetLatency", ctx, namespace, name, slowRegionLimit)
+	ret0, _ := ret[0].(*v2.ChangefeedLatency)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetLatency indicates an expected call of GetLatency.
+func (mr *MockChangefeedInterfaceMockRecorder) GetLatency(ctx, namespace, name, slowRegionLimit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetLatency", reflect.TypeOf((*MockChangefeedInterface)(nil).GetLatency), ctx, namespace, name, slowRegionLimit)
+}
+
 // List mocks base method.
 func (m *MockChangefeedInterface) List(ctx context.Context, namespace, state string) ([]v2.ChangefeedCommonInfo, error) {
 	m.ctrl.T.Helper()
@@ -175,6 +190,21 @@ func (mr *MockChangefeedInterfaceMockRecorder) Update(ctx, cfg, namespace, name
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockChangefeedInterface)(nil).Update), ctx, cfg, namespace, name)
 }
 
+// Validate mocks base method.
+func (m *MockChangefeedInterface) Validate(ctx context.Context, cfg *v2.ChangefeedConfig, namespace, name string) (*v2.ChangefeedValidationResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Validate", ctx, cfg, namespace, name)
+	ret0, _ := ret[0].(*v2.ChangefeedValidationResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Validate indicates an expected call of Validate.
+func (mr *MockChangefeedInterfaceMockRecorder) Validate(ctx, cfg, namespace, name interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Validate", reflect.TypeOf((*MockChangefeedInterface)(nil).Validate), ctx, cfg, namespace, name)
+}
+
 // VerifyTable mocks base method.
 func (m *MockChangefeedInterface) VerifyTable(ctx context.Context, cfg *v2.VerifyTableConfig) (*v2.Tables, error) {
 	m.ctrl.T.Helper()
@@ -0,0 +1,255 @@
+// Copyright 2024 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/pingcap/errors"
+	"github.com/pingcap/log"
+	"github.com/pingcap/tiflow/pkg/config"
+	"github.com/pingcap/tiflow/pkg/etcd"
+	"github.com/pingcap/tiflow/pkg/util"
+	clientv3 "go.etcd.io/etcd/client/v3"
+	"go.uber.org/zap"
+)
+
+const (
+	maskedValue = "xxxxx"
+	// maxBodyLength limits the size of the request body kept in a record.
+	maxBodyLength = 64 * 1024
+)
+
+// Record is an audit record of a mutating API request.
+type Record struct {
+	Time      time.Time `json:"time"`
+	Operation string    `json:"operation"`
+	Method    string    `json:"method"`
+	Path      string    `json:"path"`
+	// User is the basic auth user or the common name of the client certificate.
+	User       string `json:"user"`
+	SourceAddr string `json:"source_addr"`
+	// ForwardedToOwner is true if the request is forwarded to the owner.
+	ForwardedToOwner bool `json:"forwarded_to_owner,omitempty"`
+	// Capture is the capture which received the request from the client.
+	Capture string `json:"capture"`
+	// Body is the request body with the sensitive data masked.
+	Body string `json:"body,omitempty"`
+	// Diff is the changes made by the request, such as the changed
+	// configurations of the changefeed.
+	Diff   json.RawMessage `json:"diff,omitempty"`
+	Status int             `json:"status"`
+	Error  string          `json:"error,omitempty"`
+}
+
+// Logger writes the audit records to the audit log file, and keeps the
+// recent records in etcd.
+type Logger struct {
+	lg          *zap.Logger
+	historySize int
+}
+
+// NewLogger creates a Logger, the audit log is written to `audit.log`
+// in the data dir if the filename is not configured.
+func NewLogger(cfg *config.AuditConfig, dataDir string) (*Logger, error) {
+	filename := cfg.Filename
+	if filename == "" {
+		filename = filepath.Join(dataDir, config.DefaultAuditLogFilename)
+	}
+	logCfg := &log.Config{
+		Level:            "info",
+		Format:           "json",
+		DisableTimestamp: true,
+		File:             log.FileLogConfig{Filename: filename},
+	}
+	if cfg.File != nil {
+		logCfg.File.MaxSize = cfg.File.MaxSize
+		logCfg.File.MaxDays = cfg.File.MaxDays
+		logCfg.File.MaxBackups = cfg.File.MaxBackups
+	}
+	lg, _, err := log.InitLogger(logCfg)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	return &Logger{lg: lg, historySize: cfg.HistorySize}, nil
+}
+
+// Log writes the record to the audit log file and etcd. Failing to write etcd
+// doesn't fail the request, since the record is always in the audit log file.
+func (l *Logger) Log(ctx context.Context, client etcd.CDCEtcdClient, r *Record) {
+	l.lg.Info("audit",
+		zap.Time("time", r.Time),
+		zap.String("operation", r.Operation),
+		zap.String("method", r.Method),
+		zap.String("path", r.Path),
+		zap.String("user", r.User),
+		zap.String("sourceAddr", r.SourceAddr),
+		zap.Bool("forwardedToOwner", r.ForwardedToOwner),
+		zap.String("capture", r.Capture),
+		zap.String("body", r.Body),
+		zap.ByteString("diff", r.Diff),
+		zap.Int("status", r.Status),
+		zap.String("error", r.Error))
+	if client == nil {
+		return
+	}
+	if err := l.putRecord(ctx, client, r); err != nil {
+		log.Warn("failed to save the audit record to etcd",
+			zap.String("operation", r.Operation), zap.Error(err))
+	}
+}
+
+func (l *Logger) putRecord(ctx context.Context, client etcd.CDCEtcdClient, r *Record) error {
+	value, err := json.Marshal(r)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	prefix := etcd.AuditKeyPrefix(client.GetClusterID())
+	// the keys are ordered by time, the capture id avoids the conflicts
+	// between the records of different captures.
+	key := fmt.Sprintf("%s/%020d-%s", prefix, r.Time.UnixNano(), r.Capture)
+	cli := client.GetEtcdClient()
+	if _, err := cli.Put(ctx, key, string(value)); err != nil {
+		return errors.Trace(err)
+	}
+	return l.trimHistory(ctx, cli, prefix)
+}
+
+// trimHistory removes the oldest records beyond the history size.
+func (l *Logger) trimHistory(ctx context.Context, cli *etcd.Client, prefix string) error {
+	resp, err := cli.Get(ctx, prefix+"/", clientv3.WithPrefix(), clientv3.WithCountOnly())
+	if err != nil {
+		return errors.Trace(err)
+	}
+	excess := resp.Count - int64(l.historySize)
+	if excess <= 0 {
+		return nil
+	}
+	resp, err = cli.Get(ctx, prefix+"/", clientv3.WithPrefix(), clientv3.WithKeysOnly(),
+		clientv3.WithSort(clientv3.SortByKey, clientv3.SortAscend), clientv3.WithLimit(excess))
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if len(resp.Kvs) == 0 {
+		return nil
+	}
+	first := string(resp.Kvs[0].Key)
+	last := string(resp.Kvs[len(resp.Kvs)-1].Key)
+	_, err = cli.Delete(ctx, first, clientv3.WithRange(last+"\x00"))
+	return errors.Trace(err)
+}
+
+// ListRecords returns the recent audit records in etcd, the newest first.
+// All the records are returned if the limit is not positive.
+func ListRecords(ctx context.Context, client etcd.CDCEtcdClient, limit int) ([]*Record, error) {
+	opts := []clientv3.OpOption{
+		clientv3.WithPrefix(),
+		clientv3.WithSort(clientv3.SortByKey, clientv3.SortDescend),
+	}
+	if limit > 0 {
+		opts = append(opts, clientv3.WithLimit(int64(limit)))
+	}
+	resp, err := client.GetEtcdClient().Get(ctx, etcd.AuditKeyPrefix(client.GetClusterID())+"/", opts...)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	records := make([]*Record, 0, len(resp.Kvs))
+	for _, kv := range resp.Kvs {
+		r := &Record{}
+		if err := json.Unmarshal(kv.Value, r); err != nil {
+			log.Warn("skip the malformed audit record",
+				zap.ByteString("key", kv.Key), zap.Error(err))
+			continue
+		}
+		records = append(records, r)
+	}
+	return records, nil
+}
+
+// MaskBody masks the sensitive data in the JSON request body, such as the
+// passwords and the credentials in the sink uri. The body which is not
+// a JSON object is dropped, since it can't be masked reliably.
+func MaskBody(body []byte) string {
+	if len(strings.TrimSpace(string(body))) == 0 {
+		return ""
+	}
+	var v interface{}
+	if err := json.Unmarshal(body, &v); err != nil {
+		return "<non-json body omitted>"
+	}
+	masked, err := json.Marshal(maskValue("", v))
+	if err != nil {
+		return "<non-json body omitted>"
+	}
+	if len(masked) > maxBodyLength {
+		return string(masked[:maxBodyLength]) + "...(truncated)"
+	}
+	return string(masked)
+}
+
+func maskValue(name string, v interface{}) interface{} {
+	switch value := v.(type) {
+	case map[string]interface{}:
+		for k, item := range value {
+			value[k] = maskValue(k, item)
+		}
+		return value
+	case []interface{}:
+		for i, item := range value {
+			value[i] = maskValue(name, item)
+		}
+		return value
+	case string:
+		if value == "" {
+			return value
+		}
+		if util.IsSensitiveParameterName(name) {
+			return maskedValue
+		}
+		if strings.Contains(value, "://") {
+			return util.MaskSensitiveDataInURI(value)
+		}
+		return value
+	default:
+		return value
+	}
+}
+
+var defaultLogger atomic.Pointer[Logger]
+
+// InitLogger initializes the default Logger, the audit log is disabled
+// if the config is nil or not enabled.
+func InitLogger(cfg *config.AuditConfig, dataDir string) error {
+	if cfg == nil || !cfg.Enable {
+		defaultLogger.Store(nil)
+		return nil
+	}
+	l, err := NewLogger(cfg, dataDir)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	defaultLogger.Store(l)
+	return nil
+}
+
+// GetLogger returns the default Logger, it's nil if the audit log is disabled.
+func GetLogger() *Logger {
+	return defaultLogger.Load()
+}
@@ -0,0 +1,161 @@
+// Copyright 2024 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"context"
+	"time"
+
+	"github.com/pingcap/errors"
+	"github.com/pingcap/tiflow/cdc/model"
+	apiv2client "github.com/pingcap/tiflow/pkg/api/v2"
+	cmdcontext "github.com/pingcap/tiflow/pkg/cmd/context"
+	"github.com/pingcap/tiflow/pkg/cmd/factory"
+	"github.com/pingcap/tiflow/pkg/cmd/util"
+	"github.com/pingcap/tiflow/pkg/sink/kafka/claimcheck"
+	putil "github.com/pingcap/tiflow/pkg/util"
+	"github.com/spf13/cobra"
+)
+
+// cleanClaimCheckOptions defines flags for the `cli changefeed clean-claim-check` command.
+type cleanClaimCheckOptions struct {
+	apiClient apiv2client.APIV2Interface
+
+	changefeedID string
+	namespace    string
+	storageURI   string
+	checkpointTs uint64
+	ttl          time.Duration
+	dryRun       bool
+}
+
+// newCleanClaimCheckOptions creates new options for the `cli changefeed clean-claim-check` command.
+func newCleanClaimCheckOptions() *cleanClaimCheckOptions {
+	return &cleanClaimCheckOptions{}
+}
+
+// addFlags receives a *cobra.Command reference and binds
+// flags related to template printing to it.
+func (o *cleanClaimCheckOptions) addFlags(cmd *cobra.Command) {
+	cmd.PersistentFlags().StringVarP(&o.namespace, "namespace", "n", "default", "Replication task (changefeed) Namespace")
+	cmd.PersistentFlags().StringVarP(&o.changefeedID, "changefeed-id", "c", "", "Replication task (changefeed) ID")
+	cmd.PersistentFlags().StringVar(&o.storageURI, "storage-uri", "",
+		"Claim-check storage URI, required if the changefeed is removed")
+	cmd.PersistentFlags().Uint64Var(&o.checkpointTs, "checkpoint-ts", 0,
+		"Files committed before it are orphaned, default to the checkpoint ts of the changefeed")
+	cmd.PersistentFlags().DurationVar(&o.ttl, "ttl", 24*time.Hour,
+		"Files are kept for the duration after the checkpoint passes them")
+	cmd.PersistentFlags().BoolVar(&o.dryRun, "dry-run", false, "Only list the orphaned files without removing them")
+}
+
+// complete adapts from the command line args to the data and client required.
+func (o *cleanClaimCheckOptions) complete(f factory.Factory) error {
+	if o.storageURI != "" && o.checkpointTs != 0 {
+		return nil
+	}
+	client, err := f.APIV2Client()
+	if err != nil {
+		return err
+	}
+	o.apiClient = client
+	return nil
+}
+
+// validate checks that the provided options are valid.
+func (o *cleanClaimCheckOptions) validate() error {
+	if o.changefeedID == "" {
+		return errors.New("the changefeed-id must be specified")
+	}
+	if o.ttl < 0 {
+		return errors.New("the ttl must not be negative")
+	}
+	return nil
+}
+
+// fillFromChangefeed fills the storage uri and the checkpoint ts from the changefeed if not specified.
+func (o *cleanClaimCheckOptions) fillFromChangefeed(ctx context.Context) error {
+	if o.storageURI != "" && o.checkpointTs != 0 {
+		return nil
+	}
+	detail, err := o.apiClient.Changefeeds().Get(ctx, o.namespace, o.changefeedID)
+	if err != nil {
+		return err
+	}
+	if o.checkpointTs == 0 {
+		o.checkpointTs = detail.CheckpointTs
+	}
+	if o.storageURI == "" {
+		if detail.Config == nil || detail.Config.Sink == nil ||
+			detail.Config.Sink.KafkaConfig == nil ||
+			detail.Config.Sink.KafkaConfig.LargeMessageHandle == nil ||
+			detail.Config.Sink.KafkaConfig.LargeMessageHandle.ClaimCheckStorageURI == "" {
+			return errors.Errorf("claim-check is not enabled for the changefeed %s", o.changefeedID)
+		}
+		o.storageURI = detail.Config.Sink.KafkaConfig.LargeMessageHandle.ClaimCheckStorageURI
+	}
+	return nil
+}
+
+// run the `cli changefeed clean-claim-check` command.
+func (o *cleanClaimCheckOptions) run(ctx context.Context, cmd *cobra.Command) error {
+	if err := o.fillFromChangefeed(ctx); err != nil {
+		return err
+	}
+	extStorage, err := putil.GetExternalStorageWithDefaultTimeout(ctx, o.storageURI)
+	if err != nil {
+		return err
+	}
+
+	changefeedID := model.ChangeFeedID{Namespace: o.namespace, ID: o.changefeedID}
+	files, err := claimcheck.ExpiredFiles(ctx, extStorage, changefeedID, o.checkpointTs, o.ttl)
+	if err != nil {
+		return err
+	}
+	for _, file := range files {
+		cmd.Println(file)
+	}
+	if o.dryRun {
+		cmd.Printf("Found %d orphaned claim-check files.\nStorageURI: %s\nCheckpointTs: %d\n",
+			len(files), putil.MaskSensitiveDataInURI(o.storageURI), o.checkpointTs)
+		return nil
+	}
+	if err := putil.DeleteFilesInExtStorage(ctx, extStorage, files); err != nil {
+		return err
+	}
+	cmd.Printf("Removed %d orphaned claim-check files.\nStorageURI: %s\nCheckpointTs: %d\n",
+		len(files), putil.MaskSensitiveDataInURI(o.storageURI), o.checkpointTs)
+	return nil
+}
+
+// newCmdCleanClaimCheck creates the `cli changefeed clean-claim-check` command.
+func newCmdCleanClaimCheck(f factory.Factory) *cobra.Command {
+	o := newCleanClaimCheckOptions()
+
+	command := &cobra.Command{
+		Use:   "clean-claim-check",
+		Short: "List and remove the orphaned claim-check files of a replication task (changefeed)",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			ctx := cmdcontext.GetDefaultContext()
+
+			util.CheckErr(o.validate())
+			util.CheckErr(o.complete(f))
+			util.CheckErr(o.run(ctx, cmd))
+		},
+	}
+
+	o.addFlags(command)
+
+	return command
+}
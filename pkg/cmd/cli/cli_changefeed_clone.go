@@ -0,0 +1,115 @@
+// Copyright 2024 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"context"
+
+	"github.com/pingcap/errors"
+	apiv2client "github.com/pingcap/tiflow/pkg/api/v2"
+	cmdcontext "github.com/pingcap/tiflow/pkg/cmd/context"
+	"github.com/pingcap/tiflow/pkg/cmd/factory"
+	"github.com/pingcap/tiflow/pkg/cmd/util"
+	"github.com/spf13/cobra"
+)
+
+// cloneChangefeedOptions defines flags for the `cli changefeed clone` command.
+type cloneChangefeedOptions struct {
+	apiClient apiv2client.APIV2Interface
+
+	changefeedID string
+	namespace    string
+	target       changefeedImportTarget
+}
+
+// newCloneChangefeedOptions creates new options for the `cli changefeed clone` command.
+func newCloneChangefeedOptions() *cloneChangefeedOptions {
+	return &cloneChangefeedOptions{}
+}
+
+// addFlags receives a *cobra.Command reference and binds
+// flags related to template printing to it.
+func (o *cloneChangefeedOptions) addFlags(cmd *cobra.Command) {
+	cmd.PersistentFlags().StringVarP(&o.namespace, "namespace", "n", "default", "Replication task (changefeed) Namespace")
+	cmd.PersistentFlags().StringVarP(&o.changefeedID, "changefeed-id", "c", "", "Replication task (changefeed) ID")
+	cmd.PersistentFlags().StringVar(&o.target.namespace, "target-namespace", "",
+		"Namespace of the cloned changefeed, use the source one if it's empty")
+	cmd.PersistentFlags().StringVar(&o.target.changefeedID, "target-changefeed-id", "",
+		"ID of the cloned changefeed")
+	cmd.PersistentFlags().StringVar(&o.target.sinkURI, "sink-uri", "",
+		"Sink uri of the cloned changefeed, use the source one if it's empty")
+	cmd.PersistentFlags().StringVar(&o.target.configFile, "config", "",
+		"Path of the configuration file, use the source config if it's empty")
+	_ = cmd.MarkPersistentFlagRequired("changefeed-id")
+	_ = cmd.MarkPersistentFlagRequired("target-changefeed-id")
+}
+
+// complete adapts from the command line args to the data and client required.
+func (o *cloneChangefeedOptions) complete(f factory.Factory) error {
+	client, err := f.APIV2Client()
+	if err != nil {
+		return err
+	}
+	o.apiClient = client
+	return nil
+}
+
+// validate checks that the provided clone options are specified.
+func (o *cloneChangefeedOptions) validate() error {
+	targetNamespace := o.target.namespace
+	if targetNamespace == "" {
+		targetNamespace = o.namespace
+	}
+	if targetNamespace == o.namespace && o.target.changefeedID == o.changefeedID {
+		return errors.New("the cloned changefeed must have a different namespace or ID")
+	}
+	return nil
+}
+
+// run the `cli changefeed clone` command.
+func (o *cloneChangefeedOptions) run(ctx context.Context, cmd *cobra.Command) error {
+	doc, err := exportChangefeed(ctx, o.apiClient, o.namespace, o.changefeedID)
+	if err != nil {
+		return err
+	}
+	info, err := importChangefeed(ctx, cmd, o.apiClient, doc, &o.target)
+	if err != nil {
+		return err
+	}
+	cmd.Printf("Clone changefeed successfully!\nID: %s\nNamespace: %s\nStartTs: %d\n",
+		info.ID, info.Namespace, info.StartTs)
+	return nil
+}
+
+// newCmdCloneChangefeed creates the `cli changefeed clone` command.
+func newCmdCloneChangefeed(f factory.Factory) *cobra.Command {
+	o := newCloneChangefeedOptions()
+
+	command := &cobra.Command{
+		Use:   "clone",
+		Short: "Clone a replication task (changefeed) from its checkpoint",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			ctx := cmdcontext.GetDefaultContext()
+
+			util.CheckErr(o.complete(f))
+			util.CheckErr(o.validate())
+			util.CheckErr(o.run(ctx, cmd))
+		},
+	}
+
+	o.addFlags(command)
+
+	return command
+}
@@ -0,0 +1,181 @@
+// Copyright 2024 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/pingcap/errors"
+	v2 "github.com/pingcap/tiflow/cdc/api/v2"
+	"github.com/pingcap/tiflow/cdc/model"
+	apiv2client "github.com/pingcap/tiflow/pkg/api/v2"
+	cmdcontext "github.com/pingcap/tiflow/pkg/cmd/context"
+	"github.com/pingcap/tiflow/pkg/cmd/factory"
+	"github.com/pingcap/tiflow/pkg/cmd/util"
+	putil "github.com/pingcap/tiflow/pkg/util"
+	"github.com/spf13/cobra"
+)
+
+const (
+	// changefeedExportVersion is the version of the exported changefeed document.
+	changefeedExportVersion = 1
+	// redactedMark replaces secrets in the sink URI, see util.MaskSensitiveDataInURI.
+	redactedMark = "xxxxx"
+)
+
+// changefeedExport is a self-contained document describing a changefeed,
+// which can be imported to create the changefeed in another cluster or namespace.
+type changefeedExport struct {
+	Version        int               `json:"version"`
+	ExportTime     model.JSONTime    `json:"export_time"`
+	UpstreamID     uint64            `json:"upstream_id"`
+	Namespace      string            `json:"namespace"`
+	ID             string            `json:"id"`
+	SinkURI        string            `json:"sink_uri"`
+	Config         *v2.ReplicaConfig `json:"config"`
+	State          model.FeedState   `json:"state"`
+	CreatorVersion string            `json:"creator_version"`
+	TargetTs       uint64            `json:"target_ts"`
+	// CheckpointTs is the checkpoint of the changefeed when it's exported,
+	// the imported changefeed starts from it.
+	CheckpointTs   uint64         `json:"checkpoint_tso"`
+	CheckpointTime model.JSONTime `json:"checkpoint_time"`
+	// SinkURIRedacted and ConfigRedacted indicate that secrets are redacted,
+	// they must be provided again when the changefeed is imported.
+	SinkURIRedacted bool `json:"sink_uri_redacted"`
+	ConfigRedacted  bool `json:"config_redacted"`
+}
+
+// exportChangefeed builds the export document of the changefeed,
+// secrets in the sink URI and the replica config are redacted.
+func exportChangefeed(
+	ctx context.Context, client apiv2client.APIV2Interface, namespace, id string,
+) (*changefeedExport, error) {
+	detail, err := client.Changefeeds().Get(ctx, namespace, id)
+	if err != nil {
+		return nil, err
+	}
+	sinkURI := putil.MaskSensitiveDataInURI(detail.SinkURI)
+	doc := &changefeedExport{
+		Version:         changefeedExportVersion,
+		ExportTime:      model.JSONTime(time.Now()),
+		UpstreamID:      detail.UpstreamID,
+		Namespace:       detail.Namespace,
+		ID:              detail.ID,
+		SinkURI:         sinkURI,
+		State:           detail.State,
+		CreatorVersion:  detail.CreatorVersion,
+		TargetTs:        detail.TargetTs,
+		CheckpointTs:    detail.CheckpointTs,
+		CheckpointTime:  detail.CheckpointTime,
+		SinkURIRedacted: isSinkURIRedacted(sinkURI),
+	}
+	if detail.Config != nil {
+		cfg := detail.Config.ToInternalReplicaConfig()
+		masked := cfg.Clone()
+		masked.MaskSensitiveData()
+		doc.ConfigRedacted = !reflect.DeepEqual(cfg, masked)
+		doc.Config = v2.ToAPIReplicaConfig(masked)
+	}
+	return doc, nil
+}
+
+// isSinkURIRedacted returns true if the sink URI contains redacted secrets.
+func isSinkURIRedacted(sinkURI string) bool {
+	return strings.Contains(sinkURI, redactedMark)
+}
+
+// exportChangefeedOptions defines flags for the `cli changefeed export` command.
+type exportChangefeedOptions struct {
+	apiClient apiv2client.APIV2Interface
+
+	changefeedID string
+	namespace    string
+	file         string
+}
+
+// newExportChangefeedOptions creates new options for the `cli changefeed export` command.
+func newExportChangefeedOptions() *exportChangefeedOptions {
+	return &exportChangefeedOptions{}
+}
+
+// addFlags receives a *cobra.Command reference and binds
+// flags related to template printing to it.
+func (o *exportChangefeedOptions) addFlags(cmd *cobra.Command) {
+	cmd.PersistentFlags().StringVarP(&o.namespace, "namespace", "n", "default", "Replication task (changefeed) Namespace")
+	cmd.PersistentFlags().StringVarP(&o.changefeedID, "changefeed-id", "c", "", "Replication task (changefeed) ID")
+	cmd.PersistentFlags().StringVarP(&o.file, "file", "f", "", "Path of the exported file, print to stdout if it's empty")
+	_ = cmd.MarkPersistentFlagRequired("changefeed-id")
+}
+
+// complete adapts from the command line args to the data and client required.
+func (o *exportChangefeedOptions) complete(f factory.Factory) error {
+	client, err := f.APIV2Client()
+	if err != nil {
+		return err
+	}
+	o.apiClient = client
+	return nil
+}
+
+// run the `cli changefeed export` command.
+func (o *exportChangefeedOptions) run(ctx context.Context, cmd *cobra.Command) error {
+	doc, err := exportChangefeed(ctx, o.apiClient, o.namespace, o.changefeedID)
+	if err != nil {
+		return err
+	}
+	if o.file == "" {
+		return util.JSONPrint(cmd, doc)
+	}
+	data, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if err := os.WriteFile(o.file, data, 0o600); err != nil {
+		return errors.Trace(err)
+	}
+	cmd.Printf("Export changefeed successfully!\nID: %s\nCheckpointTs: %d\nFile: %s\n",
+		doc.ID, doc.CheckpointTs, o.file)
+	if doc.SinkURIRedacted || doc.ConfigRedacted {
+		cmd.Printf("[WARN] Secrets in the sink uri or config are redacted, " +
+			"please provide them again when importing the changefeed.\n")
+	}
+	return nil
+}
+
+// newCmdExportChangefeed creates the `cli changefeed export` command.
+func newCmdExportChangefeed(f factory.Factory) *cobra.Command {
+	o := newExportChangefeedOptions()
+
+	command := &cobra.Command{
+		Use:   "export",
+		Short: "Export a replication task (changefeed) to a self-contained document",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			ctx := cmdcontext.GetDefaultContext()
+
+			util.CheckErr(o.complete(f))
+			util.CheckErr(o.run(ctx, cmd))
+		},
+	}
+
+	o.addFlags(command)
+
+	return command
+}
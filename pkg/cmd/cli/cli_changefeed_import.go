@@ -0,0 +1,217 @@
+// Copyright 2024 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"strings"
+
+	"github.com/pingcap/errors"
+	v2 "github.com/pingcap/tiflow/cdc/api/v2"
+	apiv2client "github.com/pingcap/tiflow/pkg/api/v2"
+	cmdcontext "github.com/pingcap/tiflow/pkg/cmd/context"
+	"github.com/pingcap/tiflow/pkg/cmd/factory"
+	"github.com/pingcap/tiflow/pkg/cmd/util"
+	"github.com/pingcap/tiflow/pkg/config"
+	cerror "github.com/pingcap/tiflow/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+// changefeedImportTarget describes where and how an exported changefeed is created.
+type changefeedImportTarget struct {
+	namespace    string
+	changefeedID string
+	// sinkURI and configFile override the exported ones if they are not empty.
+	sinkURI    string
+	configFile string
+	// force imports the changefeed even if its upstream is not verified.
+	force bool
+}
+
+// checkImportUpstream checks the upstream of the exported changefeed is an upstream
+// of the TiCDC cluster, since the checkpoint is meaningless in other upstreams.
+func checkImportUpstream(
+	ctx context.Context, client apiv2client.APIV2Interface, upstreamID uint64,
+) error {
+	if upstreamID == 0 {
+		return errors.New("the upstream of the exported changefeed is unknown, " +
+			"please use `--force` to import it anyway")
+	}
+	// The query fails if the upstream is not found in the TiCDC cluster.
+	_, err := client.Tso().Query(ctx, &v2.UpstreamConfig{ID: upstreamID})
+	if err != nil {
+		if strings.Contains(err.Error(), "ErrUpstreamNotFound") {
+			return errors.Errorf("the upstream %d of the exported changefeed is not "+
+				"the upstream of the TiCDC cluster, please use `--force` to import it anyway", upstreamID)
+		}
+		return err
+	}
+	return nil
+}
+
+// importChangefeed creates the changefeed described by the document
+// from its checkpoint. It refuses to overwrite an existing changefeed,
+// or to import a changefeed of another upstream unless it's forced.
+func importChangefeed(
+	ctx context.Context, cmd *cobra.Command, client apiv2client.APIV2Interface,
+	doc *changefeedExport, target *changefeedImportTarget,
+) (*v2.ChangeFeedInfo, error) {
+	if doc.Version > changefeedExportVersion {
+		return nil, errors.Errorf("unsupported changefeed export version %d", doc.Version)
+	}
+	if !target.force {
+		if err := checkImportUpstream(ctx, client, doc.UpstreamID); err != nil {
+			return nil, err
+		}
+	}
+	namespace, id := target.namespace, target.changefeedID
+	if namespace == "" {
+		namespace = doc.Namespace
+	}
+	if id == "" {
+		id = doc.ID
+	}
+
+	sinkURI := target.sinkURI
+	if sinkURI == "" {
+		if doc.SinkURIRedacted {
+			return nil, errors.New("secrets in the exported sink uri are redacted, " +
+				"please specify the sink uri with `--sink-uri`")
+		}
+		sinkURI = doc.SinkURI
+	}
+	replicaConfig := doc.Config
+	if target.configFile != "" {
+		cfg := config.GetDefaultReplicaConfig()
+		if err := util.StrictDecodeFile(target.configFile, "TiCDC changefeed", cfg); err != nil {
+			return nil, err
+		}
+		replicaConfig = v2.ToAPIReplicaConfig(cfg)
+	} else if doc.ConfigRedacted {
+		return nil, errors.New("secrets in the exported config are redacted, " +
+			"please specify the config file with `--config`")
+	}
+	if replicaConfig == nil {
+		replicaConfig = v2.GetDefaultReplicaConfig()
+	}
+
+	_, err := client.Changefeeds().Get(ctx, namespace, id)
+	if err == nil {
+		return nil, cerror.ErrChangeFeedAlreadyExists.GenWithStackByArgs(id)
+	}
+	if !strings.Contains(err.Error(), "ErrChangeFeedNotExists") {
+		return nil, err
+	}
+
+	// The server makes sure the checkpoint is not behind the GC safepoint
+	// of the upstream before creating the changefeed.
+	info, err := client.Changefeeds().Create(ctx, &v2.ChangefeedConfig{
+		Namespace:     namespace,
+		ID:            id,
+		StartTs:       doc.CheckpointTs,
+		TargetTs:      doc.TargetTs,
+		SinkURI:       sinkURI,
+		ReplicaConfig: replicaConfig,
+	})
+	if err != nil {
+		if strings.Contains(err.Error(), "ErrStartTsBeforeGC") {
+			cmd.Printf("[WARN] The checkpoint %d of the exported changefeed is behind "+
+				"the GC safepoint of the upstream, it can't be imported.\n", doc.CheckpointTs)
+		}
+		return nil, err
+	}
+	return info, nil
+}
+
+// importChangefeedOptions defines flags for the `cli changefeed import` command.
+type importChangefeedOptions struct {
+	apiClient apiv2client.APIV2Interface
+
+	file   string
+	target changefeedImportTarget
+}
+
+// newImportChangefeedOptions creates new options for the `cli changefeed import` command.
+func newImportChangefeedOptions() *importChangefeedOptions {
+	return &importChangefeedOptions{}
+}
+
+// addFlags receives a *cobra.Command reference and binds
+// flags related to template printing to it.
+func (o *importChangefeedOptions) addFlags(cmd *cobra.Command) {
+	cmd.PersistentFlags().StringVarP(&o.file, "file", "f", "", "Path of the exported changefeed file")
+	cmd.PersistentFlags().StringVarP(&o.target.namespace, "namespace", "n", "",
+		"Namespace of the imported changefeed, use the exported one if it's empty")
+	cmd.PersistentFlags().StringVarP(&o.target.changefeedID, "changefeed-id", "c", "",
+		"ID of the imported changefeed, use the exported one if it's empty")
+	cmd.PersistentFlags().StringVar(&o.target.sinkURI, "sink-uri", "",
+		"Sink uri of the imported changefeed, use the exported one if it's empty")
+	cmd.PersistentFlags().StringVar(&o.target.configFile, "config", "",
+		"Path of the configuration file, use the exported config if it's empty")
+	cmd.PersistentFlags().BoolVar(&o.target.force, "force", false,
+		"Import the changefeed even if its upstream is not the upstream of the TiCDC cluster")
+	_ = cmd.MarkPersistentFlagRequired("file")
+}
+
+// complete adapts from the command line args to the data and client required.
+func (o *importChangefeedOptions) complete(f factory.Factory) error {
+	client, err := f.APIV2Client()
+	if err != nil {
+		return err
+	}
+	o.apiClient = client
+	return nil
+}
+
+// run the `cli changefeed import` command.
+func (o *importChangefeedOptions) run(ctx context.Context, cmd *cobra.Command) error {
+	data, err := os.ReadFile(o.file)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	doc := &changefeedExport{}
+	if err := json.Unmarshal(data, doc); err != nil {
+		return errors.Annotatef(err, "invalid changefeed export file %s", o.file)
+	}
+	info, err := importChangefeed(ctx, cmd, o.apiClient, doc, &o.target)
+	if err != nil {
+		return err
+	}
+	cmd.Printf("Import changefeed successfully!\nID: %s\nNamespace: %s\nStartTs: %d\n",
+		info.ID, info.Namespace, info.StartTs)
+	return nil
+}
+
+// newCmdImportChangefeed creates the `cli changefeed import` command.
+func newCmdImportChangefeed(f factory.Factory) *cobra.Command {
+	o := newImportChangefeedOptions()
+
+	command := &cobra.Command{
+		Use:   "import",
+		Short: "Create a replication task (changefeed) from an exported document",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			ctx := cmdcontext.GetDefaultContext()
+
+			util.CheckErr(o.complete(f))
+			util.CheckErr(o.run(ctx, cmd))
+		},
+	}
+
+	o.addFlags(command)
+
+	return command
+}
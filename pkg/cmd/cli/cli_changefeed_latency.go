@@ -0,0 +1,155 @@
+// Copyright 2024 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"text/tabwriter"
+
+	v2 "github.com/pingcap/tiflow/cdc/api/v2"
+	apiv2client "github.com/pingcap/tiflow/pkg/api/v2"
+	cmdcontext "github.com/pingcap/tiflow/pkg/cmd/context"
+	"github.com/pingcap/tiflow/pkg/cmd/factory"
+	"github.com/pingcap/tiflow/pkg/cmd/util"
+	"github.com/spf13/cobra"
+	"github.com/tikv/client-go/v2/oracle"
+)
+
+// latencyChangefeedOptions defines flags for the `cli changefeed latency` command.
+type latencyChangefeedOptions struct {
+	apiClient apiv2client.APIV2Interface
+
+	changefeedID    string
+	namespace       string
+	slowRegionLimit int
+	json            bool
+}
+
+// newLatencyChangefeedOptions creates new options for the `cli changefeed latency` command.
+func newLatencyChangefeedOptions() *latencyChangefeedOptions {
+	return &latencyChangefeedOptions{}
+}
+
+// addFlags receives a *cobra.Command reference and binds
+// flags related to template printing to it.
+func (o *latencyChangefeedOptions) addFlags(cmd *cobra.Command) {
+	cmd.PersistentFlags().StringVarP(&o.namespace, "namespace", "n", "default", "Replication task (changefeed) Namespace")
+	cmd.PersistentFlags().StringVarP(&o.changefeedID, "changefeed-id", "c", "", "Replication task (changefeed) ID")
+	cmd.PersistentFlags().IntVar(&o.slowRegionLimit, "slow-region-limit", 10, "The max number of the slowest regions of each processor")
+	cmd.PersistentFlags().BoolVar(&o.json, "json", false, "Output the latency breakdown in JSON")
+	_ = cmd.MarkPersistentFlagRequired("changefeed-id")
+}
+
+// complete adapts from the command line args to the data and client required.
+func (o *latencyChangefeedOptions) complete(f factory.Factory) error {
+	var err error
+	o.apiClient, err = f.APIV2Client()
+	return err
+}
+
+// run the `cli changefeed latency` command.
+func (o *latencyChangefeedOptions) run(cmd *cobra.Command) error {
+	ctx := cmdcontext.GetDefaultContext()
+	latency, err := o.apiClient.Changefeeds().GetLatency(
+		ctx, o.namespace, o.changefeedID, o.slowRegionLimit)
+	if err != nil {
+		return err
+	}
+	if o.json {
+		return util.JSONPrint(cmd, latency)
+	}
+	return printLatency(cmd.OutOrStdout(), latency)
+}
+
+// printLatency renders the latency breakdown as tables. The progress of each stage
+// is shown as the lag behind the current ts of the processor.
+func printLatency(out io.Writer, latency *v2.ChangefeedLatency) error {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "Changefeed: %s/%s, checkpoint ts: %d, resolved ts: %d\n\n",
+		latency.Namespace, latency.ChangefeedID, latency.CheckpointTs, latency.ResolvedTs)
+
+	fmt.Fprintln(w, "CAPTURE\tTABLE ID\tTABLE\tPULLER\tSORTER\tMOUNTER\tSINK\tREDO\t")
+	for _, p := range latency.Processors {
+		for _, t := range p.Tables {
+			name := t.TableName
+			if name == "" {
+				name = "-"
+			}
+			redo := "-"
+			if t.RedoResolvedTs != 0 {
+				redo = formatLag(p.CurrentTs, t.RedoResolvedTs)
+			}
+			fmt.Fprintf(w, "%s\t%d\t%s\t%s\t%s\t%s\t%s\t%s\t\n",
+				p.CaptureID, t.TableID, name,
+				formatLag(p.CurrentTs, t.PullerResolvedTs), formatLag(p.CurrentTs, t.SorterResolvedTs),
+				formatLag(p.CurrentTs, t.MounterResolvedTs), formatLag(p.CurrentTs, t.SinkCheckpointTs), redo)
+		}
+	}
+
+	fmt.Fprintln(w, "\nCAPTURE\tSINK MEMORY QUOTA USED\tREDO MEMORY QUOTA USED\t")
+	for _, p := range latency.Processors {
+		fmt.Fprintf(w, "%s\t%d\t%d\t\n", p.CaptureID, p.SinkMemQuotaUsed, p.RedoMemQuotaUsed)
+	}
+
+	fmt.Fprintln(w, "\nCAPTURE\tTABLE ID\tREGION ID\tRESOLVED\tINITIALIZED\t")
+	for _, p := range latency.Processors {
+		for _, r := range p.SlowRegions {
+			fmt.Fprintf(w, "%s\t%d\t%d\t%s\t%s\t\n", p.CaptureID, r.TableID, r.RegionID,
+				formatLag(p.CurrentTs, r.ResolvedTs), strconv.FormatBool(r.Initialized))
+		}
+	}
+
+	if len(latency.Errors) > 0 {
+		captureIDs := make([]string, 0, len(latency.Errors))
+		for captureID := range latency.Errors {
+			captureIDs = append(captureIDs, captureID)
+		}
+		sort.Strings(captureIDs)
+		fmt.Fprintln(w, "\nCAPTURE\tERROR\t")
+		for _, captureID := range captureIDs {
+			fmt.Fprintf(w, "%s\t%s\t\n", captureID, latency.Errors[captureID])
+		}
+	}
+	return w.Flush()
+}
+
+// formatLag returns how far the ts falls behind the current ts.
+func formatLag(currentTs, ts uint64) string {
+	if currentTs == 0 || ts == 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%dms", oracle.ExtractPhysical(currentTs)-oracle.ExtractPhysical(ts))
+}
+
+// newCmdLatencyChangefeed creates the `cli changefeed latency` command.
+func newCmdLatencyChangefeed(f factory.Factory) *cobra.Command {
+	o := newLatencyChangefeedOptions()
+
+	command := &cobra.Command{
+		Use:   "latency",
+		Short: "Show the latency breakdown of a replication task (changefeed)",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			util.CheckErr(o.complete(f))
+			util.CheckErr(o.run(cmd))
+		},
+	}
+
+	o.addFlags(command)
+
+	return command
+}
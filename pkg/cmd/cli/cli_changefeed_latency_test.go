@@ -0,0 +1,76 @@
+// Copyright 2024 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/pingcap/errors"
+	v2 "github.com/pingcap/tiflow/cdc/api/v2"
+	"github.com/pingcap/tiflow/cdc/model"
+	"github.com/pingcap/tiflow/pkg/api/v2/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/tikv/client-go/v2/oracle"
+)
+
+func TestChangefeedLatencyCli(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	cfV2 := mock.NewMockChangefeedInterface(ctrl)
+	f := &mockFactory{changefeeds: cfV2}
+
+	cmd := newCmdLatencyChangefeed(f)
+	o := newLatencyChangefeedOptions()
+	require.NoError(t, o.complete(f))
+	o.namespace = "default"
+	o.changefeedID = "abc"
+	o.slowRegionLimit = 10
+
+	currentTs := oracle.ComposeTS(10000, 0)
+	cfV2.EXPECT().GetLatency(gomock.Any(), "default", "abc", 10).Return(&v2.ChangefeedLatency{
+		Namespace:    "default",
+		ChangefeedID: "abc",
+		Processors: []*model.ProcessorLatency{{
+			CaptureID:        "capture-1",
+			CurrentTs:        currentTs,
+			SinkMemQuotaUsed: 1024,
+			Tables: []model.TableLatency{{
+				TableID:           100,
+				TableName:         "`test`.`t`",
+				PullerResolvedTs:  oracle.ComposeTS(9900, 0),
+				SorterResolvedTs:  oracle.ComposeTS(9800, 0),
+				MounterResolvedTs: oracle.ComposeTS(9700, 0),
+				SinkCheckpointTs:  oracle.ComposeTS(9000, 0),
+			}},
+			SlowRegions: []model.SlowRegion{{
+				TableID: 100, RegionID: 7, ResolvedTs: oracle.ComposeTS(9500, 0),
+			}},
+		}},
+		Errors: map[string]string{"capture-2": "capture offline"},
+	}, nil)
+	b := bytes.NewBufferString("")
+	cmd.SetOut(b)
+	require.NoError(t, o.run(cmd))
+	out := b.String()
+	require.Regexp(t, "capture-1 +100 +`test`.`t` +100ms +200ms +300ms +1000ms +-", out)
+	require.Regexp(t, "capture-1 +1024 +0", out)
+	require.Regexp(t, "capture-1 +100 +7 +500ms +false", out)
+	require.Regexp(t, "capture-2 +capture offline", out)
+
+	cfV2.EXPECT().GetLatency(gomock.Any(), "default", "abc", 10).
+		Return(nil, errors.New("test"))
+	require.Error(t, o.run(cmd))
+}
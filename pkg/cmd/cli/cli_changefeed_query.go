@@ -42,7 +42,7 @@ type cfMeta struct {
 	Engine         model.SortEngine          `json:"sort_engine,omitempty"`
 	FeedState      model.FeedState           `json:"state"`
 	RunningError   *v2.RunningError          `json:"error,omitempty"`
-	ErrorHis       []int64                   `json:"error_history,omitempty"`
+	ErrorHistory   []*v2.RunningError        `json:"error_history,omitempty"`
 	CreatorVersion string                    `json:"creator_version"`
 	TaskStatus     []model.CaptureTaskStatus `json:"task_status,omitempty"`
 }
@@ -113,6 +113,7 @@ func (o *queryChangefeedOptions) run(cmd *cobra.Command) error {
 		CheckpointTime: detail.CheckpointTime,
 		FeedState:      detail.State,
 		RunningError:   detail.Error,
+		ErrorHistory:   detail.ErrorHistory,
 		CreatorVersion: detail.CreatorVersion,
 		TaskStatus:     detail.TaskStatus,
 	}
@@ -17,6 +17,7 @@ import (
 	"encoding/json"
 	"strings"
 
+	"github.com/pingcap/errors"
 	"github.com/pingcap/log"
 	v2 "github.com/pingcap/tiflow/cdc/api/v2"
 	apiv2client "github.com/pingcap/tiflow/pkg/api/v2"
@@ -37,6 +38,7 @@ type updateChangefeedOptions struct {
 	commonChangefeedOptions *changefeedCommonOptions
 	changefeedID            string
 	namespace               string
+	dryRun                  bool
 }
 
 // newUpdateChangefeedOptions creates new options for the `cli changefeed update` command.
@@ -52,6 +54,8 @@ func (o *updateChangefeedOptions) addFlags(cmd *cobra.Command) {
 	o.commonChangefeedOptions.addFlags(cmd)
 	cmd.PersistentFlags().StringVarP(&o.namespace, "namespace", "n", "default", "Replication task (changefeed) Namespace")
 	cmd.PersistentFlags().StringVarP(&o.changefeedID, "changefeed-id", "c", "", "Replication task (changefeed) ID")
+	cmd.PersistentFlags().BoolVar(&o.dryRun, "dry-run", false,
+		"Validate the changes and show their effects without applying them")
 	_ = cmd.MarkPersistentFlagRequired("changefeed-id")
 }
 
@@ -119,6 +123,22 @@ func (o *updateChangefeedOptions) run(cmd *cobra.Command) error {
 		cmd.Printf("%+v\n", change)
 	}
 
+	if o.dryRun {
+		result, err := o.apiV2Client.Changefeeds().Validate(ctx,
+			o.getChangefeedConfig(cmd, newInfo), o.namespace, o.changefeedID)
+		if err != nil {
+			return err
+		}
+		cmd.Printf("Validation result of changefeed config:\n")
+		if err := util.JSONPrint(cmd, result); err != nil {
+			return err
+		}
+		if !result.Valid {
+			return errors.New("changefeed config is invalid, no update to changefeed")
+		}
+		return nil
+	}
+
 	if !o.commonChangefeedOptions.noConfirm {
 		cmd.Printf("Could you agree to apply changes above to changefeed [Y/N]\n")
 		confirmed := readYOrN(cmd)
@@ -169,7 +189,7 @@ func (o *updateChangefeedOptions) applyChanges(oldInfo *v2.ChangeFeedInfo,
 		case "sort-engine":
 		case "sort-dir":
 			log.Warn("this flag cannot be updated and will be ignored", zap.String("flagName", flag.Name))
-		case "changefeed-id", "no-confirm":
+		case "changefeed-id", "no-confirm", "dry-run":
 			// Do nothing, these are some flags from the changefeed command,
 			// we don't use it to update, but we do use these flags.
 		case "pd", "log-level", "key", "cert", "ca", "server":
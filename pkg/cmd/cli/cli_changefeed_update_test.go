@@ -171,3 +171,41 @@ func TestChangefeedUpdateCli(t *testing.T) {
 	o.namespace = "ns"
 	require.NotNil(t, o.run(cmd))
 }
+
+func TestChangefeedUpdateDryRunCli(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	f := newMockFactory(ctrl)
+
+	cmd := newCmdUpdateChangefeed(f)
+	f.changefeeds.EXPECT().Get(gomock.Any(), "ns", "abc").
+		Return(&v2.ChangeFeedInfo{ID: "abc", SinkURI: "blackhole://"}, nil)
+	f.changefeeds.EXPECT().Validate(gomock.Any(), gomock.Any(), "ns", "abc").
+		Return(&v2.ChangefeedValidationResult{
+			Valid: true,
+			ConfigChanges: []v2.ConfigChange{
+				{Path: "target_ts", From: 0, To: 10},
+			},
+		}, nil)
+	os.Args = []string{"update", "--dry-run", "--target-ts=10", "-c", "abc", "-n", "ns"}
+	require.Nil(t, cmd.Execute())
+
+	// invalid changes are not applied
+	cmd = NewCmdCli()
+	o := newUpdateChangefeedOptions(newChangefeedCommonOptions())
+	require.Nil(t, o.complete(f))
+	o.addFlags(cmd)
+	o.changefeedID = "abc"
+	o.namespace = "ns"
+	o.dryRun = true
+	require.Nil(t, cmd.ParseFlags([]string{"--target-ts=10"}))
+	o.commonChangefeedOptions.targetTs = 10
+	f.changefeeds.EXPECT().Get(gomock.Any(), "ns", "abc").
+		Return(&v2.ChangeFeedInfo{ID: "abc", SinkURI: "blackhole://"}, nil)
+	f.changefeeds.EXPECT().Validate(gomock.Any(), gomock.Any(), "ns", "abc").
+		Return(&v2.ChangefeedValidationResult{
+			Valid:  false,
+			Errors: []string{"invalid dispatcher rule"},
+		}, nil)
+	require.NotNil(t, o.run(cmd))
+}
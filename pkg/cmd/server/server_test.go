@@ -169,6 +169,8 @@ func TestParseCfg(t *testing.T) {
 			WorkerPoolSize:       0,
 			RegionScanLimit:      40,
 			RegionRetryDuration:  config.TomlDuration(time.Minute),
+			StoreScanConcurrency: 64,
+			StoreBusyBackoff:     config.TomlDuration(time.Second),
 		},
 		Debug: &config.DebugConfig{
 			DB: &config.DBConfig{
@@ -317,6 +319,8 @@ check-balance-interval = "10s"
 			WorkerPoolSize:       0,
 			RegionScanLimit:      40,
 			RegionRetryDuration:  config.TomlDuration(3 * time.Second),
+			StoreScanConcurrency: 64,
+			StoreBusyBackoff:     config.TomlDuration(time.Second),
 		},
 		Debug: &config.DebugConfig{
 			DB: &config.DBConfig{
@@ -455,6 +459,8 @@ cert-allowed-cn = ["dd","ee"]
 			WorkerPoolSize:       0,
 			RegionScanLimit:      40,
 			RegionRetryDuration:  config.TomlDuration(time.Minute),
+			StoreScanConcurrency: 64,
+			StoreBusyBackoff:     config.TomlDuration(time.Second),
 		},
 		Debug: &config.DebugConfig{
 			DB: &config.DBConfig{
@@ -0,0 +1,90 @@
+// Copyright 2024 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"net/url"
+	"time"
+
+	cerror "github.com/pingcap/tiflow/pkg/errors"
+)
+
+const (
+	// DefaultAlertMaxRetries is the default max retries of sending an alert to a webhook.
+	DefaultAlertMaxRetries = 3
+	// DefaultAlertDedupInterval is the default interval in which the same alert
+	// of a changefeed is sent only once.
+	DefaultAlertDedupInterval = 10 * time.Minute
+)
+
+// AlertConfig is the configuration of alerts sent by the owner to webhooks.
+// It can be set globally in the server config or per changefeed, the
+// changefeed level config takes precedence if it is set.
+type AlertConfig struct {
+	// WebhookURLs are HTTP endpoints that alerts are POSTed to as JSON.
+	WebhookURLs []string `toml:"webhook-urls" json:"webhook-urls"`
+	// CheckpointLagThreshold fires an alert once the checkpoint lag exceeds it.
+	// Nil or 0 disables the alert.
+	CheckpointLagThreshold *time.Duration `toml:"checkpoint-lag-threshold" json:"checkpoint-lag-threshold,omitempty"`
+	// DDLBlockedThreshold fires an alert once a DDL is being executed longer than it.
+	// Nil or 0 disables the alert.
+	DDLBlockedThreshold *time.Duration `toml:"ddl-blocked-threshold" json:"ddl-blocked-threshold,omitempty"`
+	// NotifyStates fires an alert when the changefeed changes to one of the states,
+	// such as "warning" and "failed".
+	NotifyStates []string `toml:"notify-states" json:"notify-states,omitempty"`
+	// MaxRetries is the max retries of sending an alert to a webhook.
+	MaxRetries int `toml:"max-retries" json:"max-retries"`
+	// DedupInterval is the interval in which the same alert is sent only once.
+	DedupInterval *time.Duration `toml:"dedup-interval" json:"dedup-interval,omitempty"`
+}
+
+// Enabled returns true if there is any webhook to send alerts to.
+func (c *AlertConfig) Enabled() bool {
+	return c != nil && len(c.WebhookURLs) > 0
+}
+
+// ValidateAndAdjust validates and adjusts the alert config.
+func (c *AlertConfig) ValidateAndAdjust() error {
+	for _, u := range c.WebhookURLs {
+		parsed, err := url.Parse(u)
+		if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") {
+			return cerror.ErrInvalidReplicaConfig.GenWithStack(
+				"invalid alert webhook url %s, only http and https are supported", u)
+		}
+	}
+	if c.MaxRetries < 0 {
+		return cerror.ErrInvalidReplicaConfig.GenWithStack(
+			"alert max-retries must not be negative, got %d", c.MaxRetries)
+	}
+	if c.MaxRetries == 0 {
+		c.MaxRetries = DefaultAlertMaxRetries
+	}
+	for _, state := range c.NotifyStates {
+		switch state {
+		case "normal", "warning", "pending", "stopped", "failed", "finished", "removed":
+		default:
+			return cerror.ErrInvalidReplicaConfig.GenWithStack(
+				"unknown changefeed state %s in alert notify-states", state)
+		}
+	}
+	return nil
+}
+
+// GetDedupInterval returns the dedup interval of alerts.
+func (c *AlertConfig) GetDedupInterval() time.Duration {
+	if c.DedupInterval == nil {
+		return DefaultAlertDedupInterval
+	}
+	return *c.DedupInterval
+}
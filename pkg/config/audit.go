@@ -0,0 +1,57 @@
+// Copyright 2024 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	cerror "github.com/pingcap/tiflow/pkg/errors"
+)
+
+const (
+	// DefaultAuditLogFilename is the default name of the audit log file in the data dir.
+	DefaultAuditLogFilename = "audit.log"
+	// DefaultAuditHistorySize is the default number of the recent audit records kept in etcd.
+	DefaultAuditHistorySize = 100
+	// maxAuditHistorySize limits the audit records kept in etcd.
+	maxAuditHistorySize = 1000
+)
+
+// AuditConfig is the configuration of the audit log, which records the
+// mutating API requests, such as creating, pausing and removing changefeeds.
+type AuditConfig struct {
+	// Enable records the mutating API requests to the audit log.
+	Enable bool `toml:"enable" json:"enable"`
+	// Filename is the path of the audit log file,
+	// it's `audit.log` in the data dir if it's empty.
+	Filename string `toml:"filename" json:"filename"`
+	// File is the rotation config of the audit log file.
+	File *LogFileConfig `toml:"file" json:"file"`
+	// HistorySize is the number of the recent records kept in etcd, which can be
+	// retrieved by the `GET /api/v2/audit` API, 0 means DefaultAuditHistorySize.
+	HistorySize int `toml:"history-size" json:"history-size"`
+}
+
+// ValidateAndAdjust validates and adjusts the audit config.
+func (c *AuditConfig) ValidateAndAdjust() error {
+	if c.HistorySize < 0 || c.HistorySize > maxAuditHistorySize {
+		return cerror.ErrInvalidServerOption.GenWithStack(
+			"audit history-size should be in [0, %d], got %d", maxAuditHistorySize, c.HistorySize)
+	}
+	if c.HistorySize == 0 {
+		c.HistorySize = DefaultAuditHistorySize
+	}
+	if c.File == nil {
+		c.File = &LogFileConfig{MaxSize: 300}
+	}
+	return nil
+}
@@ -143,7 +143,11 @@ const (
     "cert-allowed-cn": null,
     "mtls": false,
     "client-user-required": false,
-    "client-allowed-user": null
+    "client-allowed-user": null,
+    "secret-key-path": "",
+    "rotated-secret-key-paths": null,
+    "role-bindings": null,
+    "cluster-common-names": null
   },
   "kv-client": {
     "enable-multiplexing": true,
@@ -153,7 +157,9 @@ const (
     "frontier-concurrent": 8,
     "worker-pool-size": 0,
     "region-scan-limit": 40,
-    "region-retry-duration": 60000000000
+    "region-retry-duration": 60000000000,
+    "store-scan-concurrency": 64,
+    "store-busy-backoff": 1000000000
   },
   "debug": {
     "db": {
@@ -367,6 +373,7 @@ const (
     "region-per-span": 0,
     "region-threshold": 100001,
     "write-key-threshold": 100001,
+    "hot-table-event-rate-threshold": 0,
     "region-per-span": 0
   },
   "integrity": {
@@ -123,3 +123,8 @@ func (c *ConsistentConfig) ValidateAndAdjust() error {
 func (c *ConsistentConfig) MaskSensitiveData() {
 	c.Storage = util.MaskSensitiveDataInURI(c.Storage)
 }
+
+// RangeSensitiveData calls fn with the sensitive data in ConsistentConfig, fn can update them in place.
+func (c *ConsistentConfig) RangeSensitiveData(fn func(*string) error) error {
+	return rangeSensitiveURI(&c.Storage, fn)
+}
@@ -36,6 +36,12 @@ type KVClientConfig struct {
 	RegionScanLimit int `toml:"region-scan-limit" json:"region-scan-limit"`
 	// the total retry duration of connecting a region
 	RegionRetryDuration TomlDuration `toml:"region-retry-duration" json:"region-retry-duration"`
+	// the max number of concurrent region incremental scans in a single store,
+	// which is shared by all the changefeeds of the capture
+	StoreScanConcurrency int `toml:"store-scan-concurrency" json:"store-scan-concurrency"`
+	// the initial duration of pausing incremental scans in a store when it's busy,
+	// the duration is doubled every time the store is busy again
+	StoreBusyBackoff TomlDuration `toml:"store-busy-backoff" json:"store-busy-backoff"`
 }
 
 // NewDefaultKVClientConfig return the default kv client configuration
@@ -50,7 +56,9 @@ func NewDefaultKVClientConfig() *KVClientConfig {
 		RegionScanLimit:      40,
 		// The default TiKV region election timeout is [10s, 20s],
 		// Use 1 minute to cover region leader missing.
-		RegionRetryDuration: TomlDuration(time.Minute),
+		RegionRetryDuration:  TomlDuration(time.Minute),
+		StoreScanConcurrency: 64,
+		StoreBusyBackoff:     TomlDuration(time.Second),
 	}
 }
 
@@ -64,5 +72,13 @@ func (c *KVClientConfig) ValidateAndAdjust() error {
 		return errors.ErrInvalidServerOption.GenWithStackByArgs(
 			"region-scan-limit should be positive")
 	}
+	if c.StoreScanConcurrency <= 0 {
+		return errors.ErrInvalidServerOption.GenWithStackByArgs(
+			"store-scan-concurrency should be at least 1")
+	}
+	if c.StoreBusyBackoff <= 0 {
+		return errors.ErrInvalidServerOption.GenWithStackByArgs(
+			"store-busy-backoff should be positive")
+	}
 	return nil
 }
@@ -25,6 +25,9 @@ const (
 	LargeMessageHandleOptionClaimCheck string = "claim-check"
 	// LargeMessageHandleOptionHandleKeyOnly means handling large message by sending only handle key columns.
 	LargeMessageHandleOptionHandleKeyOnly string = "handle-key-only"
+
+	// DefaultClaimCheckFileCleanupCronSpec is the default cron spec to clean up the expired claim-check files.
+	DefaultClaimCheckFileCleanupCronSpec = "0 0 3 * * *"
 )
 
 // LargeMessageHandleConfig is the configuration for handling large message.
@@ -33,6 +36,12 @@ type LargeMessageHandleConfig struct {
 	LargeMessageHandleCompression string `toml:"large-message-handle-compression" json:"large-message-handle-compression"`
 	ClaimCheckStorageURI          string `toml:"claim-check-storage-uri" json:"claim-check-storage-uri"`
 	ClaimCheckRawValue            bool   `toml:"claim-check-raw-value" json:"claim-check-raw-value"`
+
+	// ClaimCheckFileExpirationDays is the number of days to keep the claim-check files
+	// after the checkpoint passes their commit ts, 0 means the files are never removed.
+	// The files written by the versions before it's introduced are never removed.
+	ClaimCheckFileExpirationDays  int    `toml:"claim-check-file-expiration-days" json:"claim-check-file-expiration-days,omitempty"`
+	ClaimCheckFileCleanupCronSpec string `toml:"claim-check-file-cleanup-cron-spec" json:"claim-check-file-cleanup-cron-spec,omitempty"`
 }
 
 // NewDefaultLargeMessageHandleConfig return the default Config.
@@ -85,6 +94,13 @@ func (c *LargeMessageHandleConfig) AdjustAndValidate(protocol Protocol, enableTi
 			return cerror.ErrInvalidReplicaConfig.GenWithStack(
 				"large message handle is set to claim-check, raw value is not supported for the open protocol")
 		}
+		if c.ClaimCheckFileExpirationDays < 0 {
+			return cerror.ErrInvalidReplicaConfig.GenWithStack(
+				"claim-check-file-expiration-days must not be negative, got %d", c.ClaimCheckFileExpirationDays)
+		}
+		if c.ClaimCheckFileCleanupCronSpec == "" {
+			c.ClaimCheckFileCleanupCronSpec = DefaultClaimCheckFileCleanupCronSpec
+		}
 	}
 
 	return nil
@@ -0,0 +1,72 @@
+// Copyright 2024 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"strings"
+
+	cerror "github.com/pingcap/tiflow/pkg/errors"
+)
+
+// ChangefeedPriority is the priority class of a changefeed. Changefeeds with
+// a higher priority are preferred when captures arbitrate the memory quota.
+// Since the sorted events are read only after the memory quota is acquired,
+// the sorter reads of throttled changefeeds are throttled too. The incremental
+// scans, which feed the sorter writes, are queued by the priority in each store,
+// and the low priority changefeeds add and move tables with a lower task
+// concurrency.
+type ChangefeedPriority string
+
+const (
+	// ChangefeedPriorityHigh is for latency-sensitive changefeeds. They are
+	// never throttled by the capture level memory arbitration.
+	ChangefeedPriorityHigh ChangefeedPriority = "high"
+	// ChangefeedPriorityNormal is the default priority.
+	ChangefeedPriorityNormal ChangefeedPriority = "normal"
+	// ChangefeedPriorityLow is for backfill or batch changefeeds. They are
+	// throttled first when the capture is under memory pressure.
+	ChangefeedPriorityLow ChangefeedPriority = "low"
+)
+
+// Normalize returns the canonical form of the priority,
+// an empty priority is treated as normal.
+func (p ChangefeedPriority) Normalize() ChangefeedPriority {
+	if p == "" {
+		return ChangefeedPriorityNormal
+	}
+	return ChangefeedPriority(strings.ToLower(string(p)))
+}
+
+// Validate checks whether the priority is a known priority class.
+func (p ChangefeedPriority) Validate() error {
+	switch p.Normalize() {
+	case ChangefeedPriorityHigh, ChangefeedPriorityNormal, ChangefeedPriorityLow:
+		return nil
+	}
+	return cerror.ErrInvalidReplicaConfig.GenWithStack(
+		"unknown changefeed priority %s, it must be one of high, normal and low", string(p))
+}
+
+// Rank returns an integer for comparison, a larger rank means a higher priority.
+// The blocked changefeeds with higher ranks are woken up first.
+func (p ChangefeedPriority) Rank() int {
+	switch p.Normalize() {
+	case ChangefeedPriorityHigh:
+		return 2
+	case ChangefeedPriorityLow:
+		return 0
+	default:
+		return 1
+	}
+}
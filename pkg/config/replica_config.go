@@ -97,9 +97,10 @@ var defaultReplicaConfig = &ReplicaConfig{
 		},
 	},
 	Scheduler: &ChangefeedSchedulerConfig{
-		EnableTableAcrossNodes: false,
-		RegionThreshold:        100_000,
-		WriteKeyThreshold:      0,
+		EnableTableAcrossNodes:     false,
+		RegionThreshold:            100_000,
+		WriteKeyThreshold:          0,
+		HotTableEventRateThreshold: 0,
 	},
 	Integrity: &integrity.Config{
 		IntegrityCheckLevel:   integrity.CheckLevelNone,
@@ -160,6 +161,20 @@ type replicaConfig struct {
 	Integrity                    *integrity.Config   `toml:"integrity" json:"integrity"`
 	ChangefeedErrorStuckDuration *time.Duration      `toml:"changefeed-error-stuck-duration" json:"changefeed-error-stuck-duration,omitempty"`
 	SyncedStatus                 *SyncedStatusConfig `toml:"synced-status" json:"synced-status,omitempty"`
+	// Priority is the priority class of the changefeed, it is used by captures
+	// to arbitrate the memory quota and to queue the incremental scans among
+	// changefeeds, and by the owner to throttle the table scheduling of the low
+	// priority changefeeds. Empty means normal.
+	Priority ChangefeedPriority `toml:"priority" json:"priority,omitempty"`
+	// RetryPolicy controls how the owner retries the changefeed on errors.
+	RetryPolicy *ChangefeedRetryPolicy `toml:"retry-policy" json:"retry-policy,omitempty"`
+	// Alert overwrites the alert config of the server for this changefeed.
+	Alert *AlertConfig `toml:"alert" json:"alert,omitempty"`
+	// Tracing controls the sampled tracing of the event pipeline of the changefeed.
+	Tracing *ChangefeedTracingConfig `toml:"tracing" json:"tracing,omitempty"`
+	// Validator validates the data consistency between the upstream and the
+	// downstream at the syncpoints, it's only available for the mysql sink.
+	Validator *ValidatorConfig `toml:"validator" json:"validator,omitempty"`
 
 	// Deprecated: we don't use this field since v8.0.0.
 	SQLMode string `toml:"sql-mode" json:"sql-mode"`
@@ -279,6 +294,12 @@ func (c *ReplicaConfig) ValidateAndAdjust(sinkURI *url.URL) error { // check sin
 	if c.MemoryQuota == uint64(0) {
 		c.FixMemoryQuota()
 	}
+	if c.Priority != "" {
+		if err := c.Priority.Validate(); err != nil {
+			return err
+		}
+		c.Priority = c.Priority.Normalize()
+	}
 	if c.Scheduler == nil {
 		c.FixScheduler(false)
 	} else {
@@ -293,14 +314,21 @@ func (c *ReplicaConfig) ValidateAndAdjust(sinkURI *url.URL) error { // check sin
 	}
 
 	if c.Integrity != nil {
-		switch strings.ToLower(sinkURI.Scheme) {
-		case sink.KafkaScheme, sink.KafkaSSLScheme:
+		scheme := strings.ToLower(sinkURI.Scheme)
+		switch {
+		case scheme == sink.KafkaScheme || scheme == sink.KafkaSSLScheme:
+		case sink.IsMySQLCompatibleScheme(scheme) && c.Integrity.DownstreamVerifyEnabled():
+			// the checksums are kept to verify the rows written to the downstream.
 		default:
 			if c.Integrity.Enabled() {
 				log.Warn("integrity checksum only support kafka sink now, disable integrity")
 				c.Integrity.IntegrityCheckLevel = integrity.CheckLevelNone
 			}
 		}
+		if c.Integrity.DownstreamVerifyEnabled() && !sink.IsMySQLCompatibleScheme(scheme) {
+			log.Warn("downstream verification only support mysql sink, disable it")
+			c.Integrity.DownstreamVerifyLevel = integrity.DownstreamVerifyLevelNone
+		}
 
 		if err := c.Integrity.Validate(); err != nil {
 			return err
@@ -314,6 +342,27 @@ func (c *ReplicaConfig) ValidateAndAdjust(sinkURI *url.URL) error { // check sin
 		}
 	}
 
+	if c.RetryPolicy != nil {
+		if err := c.RetryPolicy.Validate(); err != nil {
+			return err
+		}
+	}
+	if c.Alert != nil {
+		if err := c.Alert.ValidateAndAdjust(); err != nil {
+			return err
+		}
+	}
+	if c.Tracing != nil {
+		if err := c.Tracing.Validate(); err != nil {
+			return err
+		}
+	}
+	if c.Validator != nil {
+		if err := c.Validator.ValidateAndAdjust(util.GetOrZero(c.EnableSyncPoint), sinkURI); err != nil {
+			return err
+		}
+	}
+
 	if c.ChangefeedErrorStuckDuration != nil &&
 		*c.ChangefeedErrorStuckDuration < minChangeFeedErrorStuckDuration {
 		return cerror.ErrInvalidReplicaConfig.
@@ -359,4 +408,25 @@ func (c *ReplicaConfig) MaskSensitiveData() {
 	if c.Consistent != nil {
 		c.Consistent.MaskSensitiveData()
 	}
+	if c.Validator != nil {
+		c.Validator.MaskSensitiveData()
+	}
+}
+
+// RangeSensitiveData calls fn with the sensitive data in ReplicaConfig, fn can update them in place.
+func (c *ReplicaConfig) RangeSensitiveData(fn func(*string) error) error {
+	if c.Sink != nil {
+		if err := c.Sink.RangeSensitiveData(fn); err != nil {
+			return err
+		}
+	}
+	if c.Consistent != nil {
+		if err := c.Consistent.RangeSensitiveData(fn); err != nil {
+			return err
+		}
+	}
+	if c.Validator != nil {
+		return c.Validator.RangeSensitiveData(fn)
+	}
+	return nil
 }
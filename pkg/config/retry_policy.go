@@ -0,0 +1,115 @@
+// Copyright 2024 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"time"
+
+	cerror "github.com/pingcap/tiflow/pkg/errors"
+)
+
+const (
+	// DefaultErrorHistorySize is the default number of errors kept in the
+	// error history of a changefeed.
+	DefaultErrorHistorySize = 10
+	// maxErrorHistorySize limits the size of changefeed info stored in etcd.
+	maxErrorHistorySize = 100
+)
+
+// ChangefeedRetryPolicy is the policy used by the owner to retry a changefeed
+// when it meets errors. Zero values mean using the default behavior.
+type ChangefeedRetryPolicy struct {
+	// MaxRetries is the max number of continuous retries before the changefeed
+	// is set to failed. 0 means only ChangefeedErrorStuckDuration is checked.
+	MaxRetries int `toml:"max-retries" json:"max-retries"`
+	// BackoffInitInterval is the initial interval of the exponential backoff.
+	BackoffInitInterval *time.Duration `toml:"backoff-init-interval" json:"backoff-init-interval,omitempty"`
+	// BackoffMaxInterval is the max interval of the exponential backoff.
+	BackoffMaxInterval *time.Duration `toml:"backoff-max-interval" json:"backoff-max-interval,omitempty"`
+	// RetryableErrorCodes are error codes, such as "CDC:ErrMySQLTxnError",
+	// that are always retried even if they are classified as unretryable by TiCDC.
+	// Errors that make the changefeed fall behind the GC safepoint can't be retried.
+	RetryableErrorCodes []string `toml:"retryable-error-codes" json:"retryable-error-codes,omitempty"`
+	// FatalErrorCodes are error codes that set the changefeed to failed immediately.
+	FatalErrorCodes []string `toml:"fatal-error-codes" json:"fatal-error-codes,omitempty"`
+	// ErrorHistorySize is the number of recent errors recorded in the changefeed info.
+	ErrorHistorySize int `toml:"error-history-size" json:"error-history-size"`
+}
+
+// Validate validates the retry policy.
+func (p *ChangefeedRetryPolicy) Validate() error {
+	if p.MaxRetries < 0 {
+		return cerror.ErrInvalidReplicaConfig.GenWithStack(
+			"retry-policy.max-retries must not be negative, got %d", p.MaxRetries)
+	}
+	if p.BackoffInitInterval != nil && *p.BackoffInitInterval <= 0 {
+		return cerror.ErrInvalidReplicaConfig.GenWithStack(
+			"retry-policy.backoff-init-interval must be positive, got %s", p.BackoffInitInterval)
+	}
+	if p.BackoffMaxInterval != nil && *p.BackoffMaxInterval <= 0 {
+		return cerror.ErrInvalidReplicaConfig.GenWithStack(
+			"retry-policy.backoff-max-interval must be positive, got %s", p.BackoffMaxInterval)
+	}
+	if p.BackoffInitInterval != nil && p.BackoffMaxInterval != nil &&
+		*p.BackoffInitInterval > *p.BackoffMaxInterval {
+		return cerror.ErrInvalidReplicaConfig.GenWithStack(
+			"retry-policy.backoff-init-interval %s is larger than backoff-max-interval %s",
+			p.BackoffInitInterval, p.BackoffMaxInterval)
+	}
+	if p.ErrorHistorySize < 0 || p.ErrorHistorySize > maxErrorHistorySize {
+		return cerror.ErrInvalidReplicaConfig.GenWithStack(
+			"retry-policy.error-history-size must be in [0, %d], got %d",
+			maxErrorHistorySize, p.ErrorHistorySize)
+	}
+	for _, code := range p.FatalErrorCodes {
+		if p.IsRetryableCode(code) {
+			return cerror.ErrInvalidReplicaConfig.GenWithStack(
+				"error code %s can't be both retryable and fatal", code)
+		}
+	}
+	return nil
+}
+
+// GetErrorHistorySize returns the number of errors kept in the error history.
+func (p *ChangefeedRetryPolicy) GetErrorHistorySize() int {
+	if p == nil || p.ErrorHistorySize == 0 {
+		return DefaultErrorHistorySize
+	}
+	return p.ErrorHistorySize
+}
+
+// IsRetryableCode returns true if the error code is configured as retryable.
+func (p *ChangefeedRetryPolicy) IsRetryableCode(code string) bool {
+	if p == nil {
+		return false
+	}
+	return containsCode(p.RetryableErrorCodes, code)
+}
+
+// IsFatalCode returns true if the error code is configured as fatal.
+func (p *ChangefeedRetryPolicy) IsFatalCode(code string) bool {
+	if p == nil {
+		return false
+	}
+	return containsCode(p.FatalErrorCodes, code)
+}
+
+func containsCode(codes []string, code string) bool {
+	for _, c := range codes {
+		if c == code {
+			return true
+		}
+	}
+	return false
+}
@@ -29,6 +29,12 @@ type ChangefeedSchedulerConfig struct {
 	RegionThreshold int `toml:"region-threshold" json:"region-threshold"`
 	// WriteKeyThreshold is the written keys threshold of splitting a table.
 	WriteKeyThreshold int `toml:"write-key-threshold" json:"write-key-threshold"`
+	// HotTableEventRateThreshold is the threshold of the events received from
+	// TiKV per second to split a hot table when it's being replicated.
+	// A table is split only if it keeps being hot for minutes, and at most one
+	// table of a changefeed is split every few minutes. The replication of the
+	// table pauses while it's split, and resumes from its checkpoint.
+	HotTableEventRateThreshold int `toml:"hot-table-event-rate-threshold" json:"hot-table-event-rate-threshold"`
 	// Deprecated.
 	RegionPerSpan int `toml:"region-per-span" json:"region-per-span"`
 }
@@ -44,6 +50,9 @@ func (c *ChangefeedSchedulerConfig) Validate() error {
 	if c.WriteKeyThreshold < 0 {
 		return errors.New("write-key-threshold must be larger than 0")
 	}
+	if c.HotTableEventRateThreshold < 0 {
+		return errors.New("hot-table-event-rate-threshold must be larger than 0")
+	}
 	return nil
 }
 
@@ -84,6 +93,22 @@ func NewDefaultSchedulerConfig() *SchedulerConfig {
 	}
 }
 
+// lowPrioritySchedulerDivisor divides the task concurrency and the add table
+// batch size of the low priority changefeeds.
+const lowPrioritySchedulerDivisor = 4
+
+// AdjustByPriority throttles the table scheduling of the low priority changefeeds.
+// They add and move tables with a fraction of the task concurrency and the add
+// table batch size, so the incremental scans and the sorter writes of their new
+// tables don't burst on the captures shared with the higher priority changefeeds.
+func (c *SchedulerConfig) AdjustByPriority(priority ChangefeedPriority) {
+	if priority.Normalize() != ChangefeedPriorityLow {
+		return
+	}
+	c.MaxTaskConcurrency = max(c.MaxTaskConcurrency/lowPrioritySchedulerDivisor, 1)
+	c.AddTableBatchSize = max(c.AddTableBatchSize/lowPrioritySchedulerDivisor, 1)
+}
+
 // ValidateAndAdjust verifies that each parameter is valid.
 func (c *SchedulerConfig) ValidateAndAdjust() error {
 	if c.HeartbeatTick <= 0 {
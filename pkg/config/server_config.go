@@ -38,6 +38,14 @@ const (
 	// DefaultRedoDir is a subordinate directory path of data-dir.
 	DefaultRedoDir = "/tmp/redo"
 
+	// DefaultMQSpillDir is a subordinate directory path of data-dir, the MQ sink
+	// spills the pending encoded messages to it.
+	DefaultMQSpillDir = "/tmp/sink/mq"
+
+	// DefaultSchemaCacheDir is a subordinate directory path of data-dir, schema
+	// snapshots of changefeeds are persisted in it.
+	DefaultSchemaCacheDir = "/tmp/schema"
+
 	// DebugConfigurationItem is the name of debug configurations
 	DebugConfigurationItem = "debug"
 
@@ -149,10 +157,18 @@ type ServerConfig struct {
 	Debug                  *DebugConfig         `toml:"debug" json:"debug"`
 	ClusterID              string               `toml:"cluster-id" json:"cluster-id"`
 	GcTunerMemoryThreshold uint64               `toml:"gc-tuner-memory-threshold" json:"gc-tuner-memory-threshold"`
+	// Alert is the default alert config of all changefeeds.
+	Alert *AlertConfig `toml:"alert" json:"alert,omitempty"`
+	// Audit is the config of the audit log of the mutating API requests.
+	Audit *AuditConfig `toml:"audit" json:"audit,omitempty"`
+	// Tracing is the config of the exporters of the changefeed traces.
+	Tracing *TracingConfig `toml:"tracing" json:"tracing,omitempty"`
 
 	// Deprecated: we don't use this field anymore.
 	PerTableMemoryQuota uint64 `toml:"per-table-memory-quota" json:"per-table-memory-quota"`
-	// Deprecated: we don't use this field anymore.
+	// MaxMemoryPercentage is the percentage of the total memory that all changefeeds
+	// on a capture can use. When the budget is under pressure, memory quota of
+	// low priority changefeeds is throttled first. 0 means no capture level limit.
 	MaxMemoryPercentage int `toml:"max-memory-percentage" json:"max-memory-percentage"`
 }
 
@@ -240,6 +256,19 @@ func (c *ServerConfig) ValidateAndAdjust() error {
 					"It's highly recommended to enable TLS to secure the communication")
 			}
 		}
+		if c.Security.SecretKeyPath == "" && len(c.Security.RotatedSecretKeyPaths) != 0 {
+			return cerror.ErrInvalidServerOption.GenWithStack(
+				"secret-key-path should not be empty when rotated-secret-key-paths is set")
+		}
+		for _, binding := range c.Security.RoleBindings {
+			if err := binding.Validate(); err != nil {
+				return cerror.WrapError(cerror.ErrInvalidServerOption, err)
+			}
+			if len(binding.CommonNames) != 0 && !c.Security.IsTLSEnabled() {
+				return cerror.ErrInvalidServerOption.GenWithStack(
+					"tls should be enabled when the role is granted to common-names")
+			}
+		}
 		if c.Security.IsTLSEnabled() {
 			var err error
 			_, err = c.Security.ToTLSConfig()
@@ -253,6 +282,11 @@ func (c *ServerConfig) ValidateAndAdjust() error {
 		}
 	}
 
+	if c.MaxMemoryPercentage < 0 || c.MaxMemoryPercentage > 100 {
+		return cerror.ErrInvalidServerOption.GenWithStack(
+			"max-memory-percentage should be in [0, 100], got %d", c.MaxMemoryPercentage)
+	}
+
 	defaultCfg := GetDefaultServerConfig()
 	if c.Sorter == nil {
 		c.Sorter = defaultCfg.Sorter
@@ -270,6 +304,17 @@ func (c *ServerConfig) ValidateAndAdjust() error {
 		return errors.Trace(err)
 	}
 
+	if c.Alert != nil {
+		if err = c.Alert.ValidateAndAdjust(); err != nil {
+			return errors.Trace(err)
+		}
+	}
+	if c.Audit != nil {
+		if err = c.Audit.ValidateAndAdjust(); err != nil {
+			return errors.Trace(err)
+		}
+	}
+
 	if c.Debug == nil {
 		c.Debug = defaultCfg.Debug
 	}
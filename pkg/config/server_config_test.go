@@ -17,6 +17,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/pingcap/tiflow/pkg/security"
 	"github.com/stretchr/testify/require"
 )
 
@@ -75,6 +76,10 @@ func TestServerConfigValidateAndAdjust(t *testing.T) {
 	conf.Debug.Messages.ServerWorkerPoolSize = 0
 	require.Nil(t, conf.ValidateAndAdjust())
 	require.EqualValues(t, GetDefaultServerConfig().Debug.Messages.ServerWorkerPoolSize, conf.Debug.Messages.ServerWorkerPoolSize)
+	conf.Security = &security.Credential{RotatedSecretKeyPaths: []string{"old-secret-key"}}
+	require.Regexp(t, ".*secret-key-path should not be empty.*", conf.ValidateAndAdjust())
+	conf.Security.SecretKeyPath = "secret-key"
+	require.Nil(t, conf.ValidateAndAdjust())
 }
 
 func TestDBConfigValidateAndAdjust(t *testing.T) {
@@ -99,6 +104,13 @@ func TestKVClientConfigValidateAndAdjust(t *testing.T) {
 	require.Nil(t, conf.ValidateAndAdjust())
 	conf.RegionRetryDuration = -TomlDuration(time.Second)
 	require.Error(t, conf.ValidateAndAdjust())
+
+	conf = GetDefaultServerConfig().Clone().KVClient
+	conf.StoreScanConcurrency = 0
+	require.Error(t, conf.ValidateAndAdjust())
+	conf.StoreScanConcurrency = 1
+	conf.StoreBusyBackoff = 0
+	require.Error(t, conf.ValidateAndAdjust())
 }
 
 func TestSchedulerConfigValidateAndAdjust(t *testing.T) {
@@ -135,6 +147,26 @@ func TestSchedulerConfigValidateAndAdjust(t *testing.T) {
 	require.Error(t, conf.ValidateAndAdjust())
 }
 
+func TestSchedulerConfigAdjustByPriority(t *testing.T) {
+	t.Parallel()
+	for _, priority := range []ChangefeedPriority{"", ChangefeedPriorityNormal, ChangefeedPriorityHigh} {
+		conf := GetDefaultServerConfig().Clone().Debug.Scheduler
+		conf.AdjustByPriority(priority)
+		require.Equal(t, GetDefaultServerConfig().Debug.Scheduler, conf)
+	}
+
+	conf := GetDefaultServerConfig().Clone().Debug.Scheduler
+	conf.AdjustByPriority(ChangefeedPriorityLow)
+	require.Equal(t, 2, conf.MaxTaskConcurrency)
+	require.Equal(t, 12, conf.AddTableBatchSize)
+	require.Nil(t, conf.ValidateAndAdjust())
+
+	conf.MaxTaskConcurrency, conf.AddTableBatchSize = 1, 1
+	conf.AdjustByPriority(ChangefeedPriorityLow)
+	require.Equal(t, 1, conf.MaxTaskConcurrency)
+	require.Equal(t, 1, conf.AddTableBatchSize)
+}
+
 func TestIsValidClusterID(t *testing.T) {
 	cases := []struct {
 		id    string
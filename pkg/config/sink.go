@@ -25,6 +25,7 @@ import (
 	"github.com/pingcap/errors"
 	"github.com/pingcap/log"
 	cerror "github.com/pingcap/tiflow/pkg/errors"
+	"github.com/pingcap/tiflow/pkg/security"
 	"github.com/pingcap/tiflow/pkg/sink"
 	"github.com/pingcap/tiflow/pkg/util"
 	"go.uber.org/zap"
@@ -143,6 +144,13 @@ type SinkConfig struct {
 	SchemaRegistry *string `toml:"schema-registry" json:"schema-registry,omitempty"`
 	// EncoderConcurrency is only available when the downstream is MQ.
 	EncoderConcurrency *int `toml:"encoder-concurrency" json:"encoder-concurrency,omitempty"`
+	// MQSpillThreshold is the max bytes of the encoded messages pending in memory,
+	// the exceeded messages, or all the following messages once the memory quota of
+	// the changefeed is nearly used up, are spilled to the local disk under the data
+	// dir until they are sent, and the spilled bytes don't hold the memory quota.
+	// It's only available when the downstream is MQ, and the spilling is disabled
+	// if it's not positive.
+	MQSpillThreshold *int64 `toml:"mq-spill-threshold" json:"mq-spill-threshold,omitempty"`
 	// Terminator is NOT available when the downstream is DB.
 	Terminator *string `toml:"terminator" json:"terminator,omitempty"`
 	// DateSeparator is only available when the downstream is Storage.
@@ -218,6 +226,44 @@ func (s *SinkConfig) MaskSensitiveData() {
 	}
 }
 
+// RangeSensitiveData calls fn with the sensitive data in SinkConfig, fn can update them in place.
+func (s *SinkConfig) RangeSensitiveData(fn func(*string) error) error {
+	if err := rangeSensitiveURI(s.SchemaRegistry, fn); err != nil {
+		return err
+	}
+	if s.KafkaConfig != nil {
+		if err := s.KafkaConfig.RangeSensitiveData(fn); err != nil {
+			return err
+		}
+	}
+	if s.PulsarConfig != nil {
+		if err := s.PulsarConfig.RangeSensitiveData(fn); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// rangeSensitiveString calls fn with the value if it's not empty.
+func rangeSensitiveString(value *string, fn func(*string) error) error {
+	if value == nil || *value == "" {
+		return nil
+	}
+	return fn(value)
+}
+
+// rangeSensitiveURI calls fn with the uri if it contains sensitive data,
+// or it's already encrypted.
+func rangeSensitiveURI(uri *string, fn func(*string) error) error {
+	if uri == nil || *uri == "" {
+		return nil
+	}
+	if !security.IsEncryptedSecret(*uri) && !util.ContainsSensitiveDataInURI(*uri) {
+		return nil
+	}
+	return fn(uri)
+}
+
 // ShouldSendBootstrapMsg returns whether the sink should send bootstrap message.
 // Only enable bootstrap sending function for simple protocol
 // and when both send-bootstrap-interval-in-sec and send-bootstrap-in-msg-count are > 0
@@ -451,6 +497,12 @@ type KafkaConfig struct {
 
 	// OutputRawChangeEvent controls whether to split the update pk/uk events.
 	OutputRawChangeEvent *bool `toml:"output-raw-change-event" json:"output-raw-change-event,omitempty"`
+
+	// TransactionalIDPrefix enables the exactly-once mode of the DML producer,
+	// the rows are sent in kafka transactions whose id starts with the prefix,
+	// and each transaction ends at the boundary of the upstream transactions.
+	// It can't be used with enable-kafka-sink-v2.
+	TransactionalIDPrefix *string `toml:"transactional-id-prefix" json:"transactional-id-prefix,omitempty"`
 }
 
 // GetOutputRawChangeEvent returns the value of OutputRawChangeEvent
@@ -477,6 +529,24 @@ func (k *KafkaConfig) MaskSensitiveData() {
 	}
 }
 
+// RangeSensitiveData calls fn with the sensitive data in KafkaConfig, fn can update them in place.
+func (k *KafkaConfig) RangeSensitiveData(fn func(*string) error) error {
+	for _, value := range []*string{k.SASLPassword, k.SASLGssAPIPassword, k.SASLOAuthClientSecret, k.Key} {
+		if err := rangeSensitiveString(value, fn); err != nil {
+			return err
+		}
+	}
+	if k.GlueSchemaRegistryConfig != nil {
+		g := k.GlueSchemaRegistryConfig
+		for _, value := range []*string{&g.AccessKey, &g.SecretAccessKey, &g.Token} {
+			if err := rangeSensitiveString(value, fn); err != nil {
+				return err
+			}
+		}
+	}
+	return rangeSensitiveURI(k.SASLOAuthTokenURL, fn)
+}
+
 // PulsarCompressionType is the compression type for pulsar
 type PulsarCompressionType string
 
@@ -616,6 +686,19 @@ type PulsarConfig struct {
 	// OutputRawChangeEvent controls whether to split the update pk/uk events.
 	OutputRawChangeEvent *bool `toml:"output-raw-change-event" json:"output-raw-change-event,omitempty"`
 
+	// EnableOrderingKey sets the partition key generated by the partition dispatcher
+	// as the ordering key of the messages, and batches the messages by the key,
+	// so the key-shared subscriptions receive the messages of the same key in order.
+	// Notice: the DDL and resolved events are not dispatched to every consumer of
+	// a key-shared subscription, so a key-shared consumer must not rely on them.
+	EnableOrderingKey *bool `toml:"enable-ordering-key" json:"enable-ordering-key,omitempty"`
+
+	// EnableTransaction sends the rows in pulsar transactions, which are invisible
+	// to the consumers until committed, to avoid the duplicated messages.
+	EnableTransaction *bool `toml:"enable-transaction" json:"enable-transaction,omitempty"`
+	// TransactionTimeout is the timeout of a pulsar transaction (default: 60 seconds)
+	TransactionTimeout *TimeSec `toml:"transaction-timeout" json:"transaction-timeout,omitempty"`
+
 	// BrokerURL is used to configure service brokerUrl for the Pulsar service.
 	// This parameter is a part of the `sink-uri`. Internal use only.
 	BrokerURL string `toml:"-" json:"-"`
@@ -631,6 +714,22 @@ func (c *PulsarConfig) GetOutputRawChangeEvent() bool {
 	return *c.OutputRawChangeEvent
 }
 
+// GetEnableOrderingKey returns the value of EnableOrderingKey
+func (c *PulsarConfig) GetEnableOrderingKey() bool {
+	if c == nil || c.EnableOrderingKey == nil {
+		return false
+	}
+	return *c.EnableOrderingKey
+}
+
+// GetEnableTransaction returns the value of EnableTransaction
+func (c *PulsarConfig) GetEnableTransaction() bool {
+	if c == nil || c.EnableTransaction == nil {
+		return false
+	}
+	return *c.EnableTransaction
+}
+
 // MaskSensitiveData masks sensitive data in PulsarConfig
 func (c *PulsarConfig) MaskSensitiveData() {
 	if c.AuthenticationToken != nil {
@@ -644,6 +743,19 @@ func (c *PulsarConfig) MaskSensitiveData() {
 	}
 }
 
+// RangeSensitiveData calls fn with the sensitive data in PulsarConfig, fn can update them in place.
+func (c *PulsarConfig) RangeSensitiveData(fn func(*string) error) error {
+	for _, value := range []*string{c.AuthenticationToken, c.BasicPassword} {
+		if err := rangeSensitiveString(value, fn); err != nil {
+			return err
+		}
+	}
+	if c.OAuth2 != nil {
+		return rangeSensitiveString(&c.OAuth2.OAuth2PrivateKey, fn)
+	}
+	return nil
+}
+
 // Check get broker url
 func (c *PulsarConfig) validate() (err error) {
 	if c.OAuth2 != nil {
@@ -811,6 +923,13 @@ func (s *SinkConfig) validateAndAdjust(sinkURI *url.URL) error {
 		if err := s.CSVConfig.validateAndAdjust(); err != nil {
 			return err
 		}
+
+		// all columns are required, since the rows in the avro file share the same schema.
+		if util.GetOrZero(s.DeleteOnlyOutputHandleKeyColumns) && protocol == ProtocolAvro {
+			return cerror.ErrSinkInvalidConfig.GenWithStack(
+				"Avro protocol always output all columns for the delete event in the storage sink, " +
+					"do not set `delete-only-output-handle-key-columns` to true")
+		}
 	}
 
 	if util.GetOrZero(s.AdvanceTimeoutInSec) == 0 {
@@ -879,7 +998,7 @@ func (s *SinkConfig) ValidateProtocol(scheme string) error {
 		if s.CSVConfig != nil {
 			outputOldValue = s.CSVConfig.OutputOldValue
 		}
-	case ProtocolAvro:
+	case ProtocolAvro, ProtocolProtobuf:
 		outputOldValue = false
 	default:
 		return nil
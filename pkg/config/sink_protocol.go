@@ -40,6 +40,7 @@ const (
 	ProtocolCsv
 	ProtocolDebezium
 	ProtocolSimple
+	ProtocolProtobuf
 )
 
 // IsBatchEncode returns whether the protocol is a batch encoder.
@@ -72,6 +73,8 @@ func ParseSinkProtocolFromString(protocol string) (Protocol, error) {
 		return ProtocolDebezium, nil
 	case "simple":
 		return ProtocolSimple, nil
+	case "protobuf":
+		return ProtocolProtobuf, nil
 	default:
 		return ProtocolUnknown, cerror.ErrSinkUnknownProtocol.GenWithStackByArgs(protocol)
 	}
@@ -100,6 +103,8 @@ func (p Protocol) String() string {
 		return "debezium"
 	case ProtocolSimple:
 		return "simple"
+	case ProtocolProtobuf:
+		return "protobuf"
 	default:
 		panic("unreachable")
 	}
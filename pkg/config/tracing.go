@@ -0,0 +1,59 @@
+// Copyright 2024 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	cerror "github.com/pingcap/tiflow/pkg/errors"
+)
+
+// TracingConfig is the configuration of the exporters of the OpenTelemetry
+// traces, the changefeeds are traced only if an exporter is configured.
+type TracingConfig struct {
+	// OTLPEndpoint is the `host:port` of the OTLP gRPC collector.
+	OTLPEndpoint string `toml:"otlp-endpoint" json:"otlp-endpoint"`
+	// OTLPInsecure disables the TLS of the connection to the OTLP collector.
+	OTLPInsecure bool `toml:"otlp-insecure" json:"otlp-insecure"`
+	// File is the path of the local file which the spans are written to
+	// as json lines, it's mainly used in tests.
+	File string `toml:"file" json:"file"`
+}
+
+// IsEnabled returns true if any exporter is configured.
+func (c *TracingConfig) IsEnabled() bool {
+	return c != nil && (c.OTLPEndpoint != "" || c.File != "")
+}
+
+// ChangefeedTracingConfig is the tracing configuration of a changefeed.
+type ChangefeedTracingConfig struct {
+	// SampleRatio is the ratio of the transactions traced through the
+	// event pipeline, 0 disables the tracing of the changefeed.
+	SampleRatio float64 `toml:"sample-ratio" json:"sample-ratio"`
+}
+
+// Validate validates the tracing config of the changefeed.
+func (c *ChangefeedTracingConfig) Validate() error {
+	if c.SampleRatio < 0 || c.SampleRatio > 1 {
+		return cerror.ErrInvalidReplicaConfig.GenWithStack(
+			"tracing.sample-ratio must be in [0, 1], got %v", c.SampleRatio)
+	}
+	return nil
+}
+
+// GetSampleRatio returns the sample ratio, it's 0 if the tracing is not configured.
+func (c *ChangefeedTracingConfig) GetSampleRatio() float64 {
+	if c == nil {
+		return 0
+	}
+	return c.SampleRatio
+}
@@ -0,0 +1,106 @@
+// Copyright 2024 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"net/url"
+	"strings"
+	"time"
+
+	tfilter "github.com/pingcap/tidb/pkg/util/table-filter"
+	cerror "github.com/pingcap/tiflow/pkg/errors"
+	"github.com/pingcap/tiflow/pkg/sink"
+	"github.com/pingcap/tiflow/pkg/util"
+)
+
+const (
+	// defaultValidatorChunkSize is the default number of rows in a chunk.
+	defaultValidatorChunkSize = 10000
+	// defaultValidatorInterval is the default min interval between validations.
+	defaultValidatorInterval = 30 * time.Minute
+)
+
+// ValidatorConfig is the config of the data consistency validator of a changefeed.
+// The validator compares the checksums of the selected tables between the upstream
+// at the primary ts of a syncpoint and the downstream at its secondary ts.
+type ValidatorConfig struct {
+	Enable bool `toml:"enable" json:"enable"`
+	// UpstreamURI is the uri of the upstream TiDB, such as `mysql://root:@127.0.0.1:4000/`.
+	UpstreamURI string `toml:"upstream-uri" json:"upstream-uri"`
+	// Tables are the table filter rules of the validated tables.
+	Tables []string `toml:"tables" json:"tables"`
+	// Interval is the min interval between two validations, the validation
+	// starts at the first syncpoint after the interval.
+	Interval *time.Duration `toml:"interval" json:"interval,omitempty"`
+	// ChunkSize is the number of rows compared by a checksum.
+	ChunkSize int `toml:"chunk-size" json:"chunk-size"`
+}
+
+// ValidateAndAdjust validates the validator config and sets the default values.
+func (c *ValidatorConfig) ValidateAndAdjust(enableSyncPoint bool, sinkURI *url.URL) error {
+	if !c.Enable {
+		return nil
+	}
+	if !enableSyncPoint {
+		return cerror.ErrInvalidReplicaConfig.GenWithStack(
+			"validator requires enable-sync-point to be true")
+	}
+	if sinkURI == nil || !sink.IsMySQLCompatibleScheme(strings.ToLower(sinkURI.Scheme)) {
+		return cerror.ErrInvalidReplicaConfig.GenWithStack(
+			"validator only supports the mysql sink")
+	}
+	upstreamURI, err := url.Parse(c.UpstreamURI)
+	if err != nil || !sink.IsMySQLCompatibleScheme(strings.ToLower(upstreamURI.Scheme)) {
+		return cerror.ErrInvalidReplicaConfig.GenWithStack(
+			"invalid validator.upstream-uri %s", util.MaskSensitiveDataInURI(c.UpstreamURI))
+	}
+	if len(c.Tables) == 0 {
+		return cerror.ErrInvalidReplicaConfig.GenWithStack(
+			"validator.tables must not be empty")
+	}
+	if _, err := tfilter.Parse(c.Tables); err != nil {
+		return cerror.ErrInvalidReplicaConfig.GenWithStack(
+			"invalid validator.tables: %s", err.Error())
+	}
+	if c.Interval == nil {
+		c.Interval = util.AddressOf(defaultValidatorInterval)
+	}
+	if *c.Interval <= 0 {
+		return cerror.ErrInvalidReplicaConfig.GenWithStack(
+			"validator.interval must be positive, got %s", *c.Interval)
+	}
+	if c.ChunkSize == 0 {
+		c.ChunkSize = defaultValidatorChunkSize
+	}
+	if c.ChunkSize < 0 {
+		return cerror.ErrInvalidReplicaConfig.GenWithStack(
+			"validator.chunk-size must be positive, got %d", c.ChunkSize)
+	}
+	return nil
+}
+
+// IsEnabled returns true if the validator is enabled.
+func (c *ValidatorConfig) IsEnabled() bool {
+	return c != nil && c.Enable
+}
+
+// MaskSensitiveData masks sensitive data in ValidatorConfig.
+func (c *ValidatorConfig) MaskSensitiveData() {
+	c.UpstreamURI = util.MaskSensitiveDataInURI(c.UpstreamURI)
+}
+
+// RangeSensitiveData calls fn with the sensitive data in ValidatorConfig, fn can update them in place.
+func (c *ValidatorConfig) RangeSensitiveData(fn func(*string) error) error {
+	return rangeSensitiveURI(&c.UpstreamURI, fn)
+}
@@ -0,0 +1,182 @@
+// Copyright 2024 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package consumer
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"os"
+	"sync"
+
+	"github.com/pingcap/tiflow/cdc/model"
+	"github.com/pingcap/tiflow/pkg/errors"
+)
+
+var _ Downstream = (*CallbackDownstream)(nil)
+
+// CallbackDownstream passes the decoded events to the user callbacks synchronously,
+// the row changed events are buffered until they are flushed, so the callbacks
+// observe the events in the same order as a sink does. A nil callback ignores the events.
+type CallbackDownstream struct {
+	OnDDLEvent         func(ctx context.Context, ddl *model.DDLEvent) error
+	OnRowChangedEvents func(ctx context.Context, partition int32, tableID model.TableID, events []*model.RowChangedEvent) error
+	OnClose            func()
+
+	mu     sync.Mutex
+	groups map[tableKey]*EventsGroup
+}
+
+// WriteDDLEvent implements Downstream.
+func (d *CallbackDownstream) WriteDDLEvent(ctx context.Context, ddl *model.DDLEvent) error {
+	if d.OnDDLEvent == nil {
+		return nil
+	}
+	return d.OnDDLEvent(ctx, ddl)
+}
+
+// AppendRowChangedEvents implements Downstream.
+func (d *CallbackDownstream) AppendRowChangedEvents(
+	_ context.Context, partition int32, tableID model.TableID, events ...*model.RowChangedEvent,
+) error {
+	if d.OnRowChangedEvents == nil || len(events) == 0 {
+		return nil
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.groups == nil {
+		d.groups = make(map[tableKey]*EventsGroup)
+	}
+	key := tableKey{partition: partition, tableID: tableID}
+	group, ok := d.groups[key]
+	if !ok {
+		group = NewEventsGroup()
+		d.groups[key] = group
+	}
+	for _, e := range events {
+		group.Append(e)
+	}
+	return nil
+}
+
+// Flush implements Downstream.
+func (d *CallbackDownstream) Flush(
+	ctx context.Context, partition int32, tableID model.TableID, resolvedTs model.ResolvedTs,
+) error {
+	if d.OnRowChangedEvents == nil {
+		return nil
+	}
+	d.mu.Lock()
+	group, ok := d.groups[tableKey{partition: partition, tableID: tableID}]
+	var events []*model.RowChangedEvent
+	if ok {
+		events = group.Resolve(resolvedTs.Ts)
+	}
+	d.mu.Unlock()
+	if len(events) == 0 {
+		return nil
+	}
+	return d.OnRowChangedEvents(ctx, partition, tableID, events)
+}
+
+// Close implements Downstream.
+func (d *CallbackDownstream) Close() {
+	if d.OnClose != nil {
+		d.OnClose()
+	}
+}
+
+// fileEvent is the line written by the file downstream for each event.
+type fileEvent struct {
+	Type       string                 `json:"type"`
+	CommitTs   uint64                 `json:"commitTs"`
+	Schema     string                 `json:"schema"`
+	Table      string                 `json:"table"`
+	Query      string                 `json:"query,omitempty"`
+	Columns    map[string]interface{} `json:"columns,omitempty"`
+	PreColumns map[string]interface{} `json:"preColumns,omitempty"`
+}
+
+func columnsToMap(cols []*model.Column) map[string]interface{} {
+	if len(cols) == 0 {
+		return nil
+	}
+	result := make(map[string]interface{}, len(cols))
+	for _, col := range cols {
+		if col == nil {
+			continue
+		}
+		value := col.Value
+		if b, ok := value.([]byte); ok {
+			value = string(b)
+		}
+		result[col.Name] = value
+	}
+	return result
+}
+
+// NewFileDownstream creates a Downstream which appends the events to the file
+// as JSON lines, it's useful to inspect the events or to feed other systems.
+func NewFileDownstream(path string) (Downstream, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	var mu sync.Mutex
+	writer := bufio.NewWriter(file)
+	encoder := json.NewEncoder(writer)
+	write := func(events ...*fileEvent) error {
+		mu.Lock()
+		defer mu.Unlock()
+		for _, e := range events {
+			if err := encoder.Encode(e); err != nil {
+				return errors.Trace(err)
+			}
+		}
+		return errors.Trace(writer.Flush())
+	}
+
+	return &CallbackDownstream{
+		OnDDLEvent: func(_ context.Context, ddl *model.DDLEvent) error {
+			e := &fileEvent{Type: "ddl", CommitTs: ddl.CommitTs, Query: ddl.Query}
+			if ddl.TableInfo != nil {
+				e.Schema = ddl.TableInfo.GetSchemaName()
+				e.Table = ddl.TableInfo.GetTableName()
+			}
+			return write(e)
+		},
+		OnRowChangedEvents: func(
+			_ context.Context, _ int32, _ model.TableID, rows []*model.RowChangedEvent,
+		) error {
+			events := make([]*fileEvent, 0, len(rows))
+			for _, row := range rows {
+				events = append(events, &fileEvent{
+					Type:       "row",
+					CommitTs:   row.CommitTs,
+					Schema:     row.TableInfo.GetSchemaName(),
+					Table:      row.TableInfo.GetTableName(),
+					Columns:    columnsToMap(row.GetColumns()),
+					PreColumns: columnsToMap(row.GetPreColumns()),
+				})
+			}
+			return write(events...)
+		},
+		OnClose: func() {
+			mu.Lock()
+			defer mu.Unlock()
+			_ = writer.Flush()
+			_ = file.Close()
+		},
+	}, nil
+}
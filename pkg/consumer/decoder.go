@@ -0,0 +1,73 @@
+// Copyright 2024 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package consumer
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/pingcap/tiflow/pkg/config"
+	"github.com/pingcap/tiflow/pkg/errors"
+	"github.com/pingcap/tiflow/pkg/sink/codec"
+	"github.com/pingcap/tiflow/pkg/sink/codec/avro"
+	"github.com/pingcap/tiflow/pkg/sink/codec/canal"
+	"github.com/pingcap/tiflow/pkg/sink/codec/common"
+	"github.com/pingcap/tiflow/pkg/sink/codec/open"
+	"github.com/pingcap/tiflow/pkg/sink/codec/protobuf"
+	"github.com/pingcap/tiflow/pkg/sink/codec/simple"
+)
+
+// NewDecoder creates a decoder for the protocol of the codec config.
+// schemaRegistryURI is required by the avro and protobuf protocols, and
+// upstreamTiDB is used to fetch the whole row if only the handle key is sent.
+func NewDecoder(
+	ctx context.Context,
+	codecConfig *common.Config,
+	schemaRegistryURI string,
+	topic string,
+	upstreamTiDB *sql.DB,
+) (codec.RowEventDecoder, error) {
+	var (
+		decoder codec.RowEventDecoder
+		err     error
+	)
+	switch codecConfig.Protocol {
+	case config.ProtocolOpen, config.ProtocolDefault:
+		decoder, err = open.NewBatchDecoder(ctx, codecConfig, upstreamTiDB)
+	case config.ProtocolCanalJSON:
+		decoder, err = canal.NewBatchDecoder(ctx, codecConfig, upstreamTiDB)
+	case config.ProtocolCanal:
+		decoder = canal.NewProtobufBatchDecoder(codecConfig)
+	case config.ProtocolAvro:
+		schemaM, err := avro.NewConfluentSchemaManager(ctx, schemaRegistryURI, nil)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		decoder = avro.NewDecoder(codecConfig, schemaM, topic, upstreamTiDB)
+	case config.ProtocolSimple:
+		decoder, err = simple.NewDecoder(ctx, codecConfig, upstreamTiDB)
+	case config.ProtocolProtobuf:
+		schemaM, err := protobuf.NewConfluentSchemaManager(ctx, schemaRegistryURI, nil)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		decoder = protobuf.NewDecoder(codecConfig, schemaM, topic)
+	default:
+		return nil, errors.ErrSinkUnknownProtocol.GenWithStackByArgs(codecConfig.Protocol)
+	}
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	return decoder, nil
+}
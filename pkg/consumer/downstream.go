@@ -0,0 +1,170 @@
+// Copyright 2024 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package consumer
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/pingcap/log"
+	"github.com/pingcap/tiflow/cdc/model"
+	"github.com/pingcap/tiflow/cdc/sink/ddlsink"
+	ddlsinkfactory "github.com/pingcap/tiflow/cdc/sink/ddlsink/factory"
+	dmlsinkfactory "github.com/pingcap/tiflow/cdc/sink/dmlsink/factory"
+	"github.com/pingcap/tiflow/cdc/sink/tablesink"
+	"github.com/pingcap/tiflow/pkg/config"
+	"github.com/pingcap/tiflow/pkg/errors"
+	"github.com/pingcap/tiflow/pkg/spanz"
+	"go.uber.org/zap"
+)
+
+const defaultFlushWaitDuration = 10 * time.Millisecond
+
+// Downstream is where the consumer writes the decoded events to.
+// The events of the same table received from different partitions
+// are appended and flushed independently, and the methods may be called
+// concurrently for different partitions.
+type Downstream interface {
+	// WriteDDLEvent writes the DDL event synchronously, all the row changed
+	// events before it have been flushed when it's called.
+	WriteDDLEvent(ctx context.Context, ddl *model.DDLEvent) error
+	// AppendRowChangedEvents appends the row changed events of the table,
+	// they are sorted by CommitTs and may be written asynchronously.
+	AppendRowChangedEvents(
+		ctx context.Context, partition int32, tableID model.TableID, events ...*model.RowChangedEvent,
+	) error
+	// Flush blocks until all the appended row changed events of the table,
+	// whose CommitTs is not greater than the resolved ts, are written.
+	Flush(ctx context.Context, partition int32, tableID model.TableID, resolvedTs model.ResolvedTs) error
+	// Close closes the downstream.
+	Close()
+}
+
+type tableKey struct {
+	partition int32
+	tableID   model.TableID
+}
+
+var _ Downstream = (*sinkDownstream)(nil)
+
+// sinkDownstream writes the events to a TiCDC sink, such as MySQL, TiDB or cloud storage.
+type sinkDownstream struct {
+	changefeedID model.ChangeFeedID
+	sinkFactory  *dmlsinkfactory.SinkFactory
+	ddlSink      ddlsink.Sink
+	errCh        chan error
+
+	errMu sync.Mutex
+	err   error
+
+	// tableSinks maps tableKey to tablesink.TableSink.
+	tableSinks sync.Map
+}
+
+// NewSinkDownstream creates a Downstream which writes the events to the sink of the sinkURI.
+func NewSinkDownstream(
+	ctx context.Context,
+	changefeedID model.ChangeFeedID,
+	sinkURI string,
+	replicaConfig *config.ReplicaConfig,
+) (Downstream, error) {
+	errCh := make(chan error, 1)
+	sinkFactory, err := dmlsinkfactory.New(ctx, changefeedID, sinkURI, replicaConfig, errCh, nil)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	ddlSink, err := ddlsinkfactory.New(ctx, changefeedID, sinkURI, replicaConfig)
+	if err != nil {
+		sinkFactory.Close()
+		return nil, errors.Trace(err)
+	}
+	return &sinkDownstream{
+		changefeedID: changefeedID,
+		sinkFactory:  sinkFactory,
+		ddlSink:      ddlSink,
+		errCh:        errCh,
+	}, nil
+}
+
+func (s *sinkDownstream) WriteDDLEvent(ctx context.Context, ddl *model.DDLEvent) error {
+	return s.ddlSink.WriteDDLEvent(ctx, ddl)
+}
+
+func (s *sinkDownstream) AppendRowChangedEvents(
+	_ context.Context, partition int32, tableID model.TableID, events ...*model.RowChangedEvent,
+) error {
+	if len(events) == 0 {
+		return nil
+	}
+	key := tableKey{partition: partition, tableID: tableID}
+	tableSink, ok := s.tableSinks.Load(key)
+	if !ok {
+		tableSink = s.sinkFactory.CreateTableSinkForConsumer(
+			s.changefeedID,
+			spanz.TableIDToComparableSpan(tableID),
+			events[0].CommitTs,
+		)
+		s.tableSinks.Store(key, tableSink)
+		log.Info("table sink created", zap.Int32("partition", partition),
+			zap.Int64("tableID", tableID), zap.Uint64("startTs", events[0].CommitTs))
+	}
+	tableSink.(tablesink.TableSink).AppendRowChangedEvents(events...)
+	return nil
+}
+
+func (s *sinkDownstream) Flush(
+	ctx context.Context, partition int32, tableID model.TableID, resolvedTs model.ResolvedTs,
+) error {
+	value, ok := s.tableSinks.Load(tableKey{partition: partition, tableID: tableID})
+	if !ok {
+		return nil
+	}
+	tableSink := value.(tablesink.TableSink)
+	for {
+		select {
+		case <-ctx.Done():
+			return errors.Trace(ctx.Err())
+		default:
+		}
+		if err := s.error(); err != nil {
+			return errors.Trace(err)
+		}
+		if err := tableSink.UpdateResolvedTs(resolvedTs); err != nil {
+			return errors.Trace(err)
+		}
+		if !tableSink.GetCheckpointTs().Less(resolvedTs) {
+			return nil
+		}
+		time.Sleep(defaultFlushWaitDuration)
+	}
+}
+
+// error returns the first error reported by the sink.
+func (s *sinkDownstream) error() error {
+	s.errMu.Lock()
+	defer s.errMu.Unlock()
+	if s.err == nil {
+		select {
+		case s.err = <-s.errCh:
+		default:
+		}
+	}
+	return s.err
+}
+
+func (s *sinkDownstream) Close() {
+	s.ddlSink.Close()
+	s.sinkFactory.Close()
+}
@@ -11,7 +11,7 @@
 // See the License for the specific language governing permissions and
 // limitations under the License.
 
-package main
+package consumer
 
 import (
 	"sort"
@@ -19,29 +19,32 @@ import (
 	"github.com/pingcap/tiflow/cdc/model"
 )
 
-// EventsGroup could store change event message.
-type eventsGroup struct {
+// EventsGroup buffers the row changed events of a table until they are resolved.
+type EventsGroup struct {
 	events []*model.RowChangedEvent
 }
 
-// NewEventsGroup will create new event group.
-func NewEventsGroup() *eventsGroup {
-	return &eventsGroup{
+// NewEventsGroup creates a new EventsGroup.
+func NewEventsGroup() *EventsGroup {
+	return &EventsGroup{
 		events: make([]*model.RowChangedEvent, 0),
 	}
 }
 
-// Append will append an event to event groups.
-func (g *eventsGroup) Append(e *model.RowChangedEvent) {
+// Append appends an event to the group.
+func (g *EventsGroup) Append(e *model.RowChangedEvent) {
 	g.events = append(g.events, e)
 }
 
-// Resolve will get events where CommitTs is less than resolveTs.
-func (g *eventsGroup) Resolve(resolveTs uint64) []*model.RowChangedEvent {
+// Resolve returns the events whose CommitTs is not greater than resolveTs, sorted by CommitTs.
+func (g *EventsGroup) Resolve(resolveTs uint64) []*model.RowChangedEvent {
+	sort.SliceStable(g.events, func(i, j int) bool {
+		return g.events[i].CommitTs < g.events[j].CommitTs
+	})
+
 	i := sort.Search(len(g.events), func(i int) bool {
 		return g.events[i].CommitTs > resolveTs
 	})
-
 	result := g.events[:i]
 	g.events = g.events[i:]
 	return result
@@ -0,0 +1,52 @@
+// Copyright 2024 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package consumer
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/pingcap/tiflow/pkg/quotes"
+)
+
+// FakeTableIDGenerator generates the table ID for the events whose protocol
+// doesn't carry the upstream table ID, the same table always gets the same ID.
+type FakeTableIDGenerator struct {
+	mu             sync.Mutex
+	tableIDs       map[string]int64
+	currentTableID int64
+}
+
+// NewFakeTableIDGenerator creates a new FakeTableIDGenerator.
+func NewFakeTableIDGenerator() *FakeTableIDGenerator {
+	return &FakeTableIDGenerator{
+		tableIDs: make(map[string]int64),
+	}
+}
+
+// GenerateFakeTableID returns the fake table ID of the table or the partition.
+func (g *FakeTableIDGenerator) GenerateFakeTableID(schema, table string, partition int64) int64 {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	key := quotes.QuoteSchema(schema, table)
+	if partition != 0 {
+		key = fmt.Sprintf("%s.`%d`", key, partition)
+	}
+	if tableID, ok := g.tableIDs[key]; ok {
+		return tableID
+	}
+	g.currentTableID++
+	g.tableIDs[key] = g.currentTableID
+	return g.currentTableID
+}
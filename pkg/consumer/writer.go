@@ -0,0 +1,569 @@
+// Copyright 2024 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package consumer
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sync"
+	"sync/atomic"
+
+	"github.com/pingcap/log"
+	"github.com/pingcap/tiflow/cdc/model"
+	"github.com/pingcap/tiflow/cdc/sink/dmlsink/mq/dispatcher"
+	"github.com/pingcap/tiflow/pkg/config"
+	"github.com/pingcap/tiflow/pkg/errors"
+	"github.com/pingcap/tiflow/pkg/sink/codec"
+	"github.com/pingcap/tiflow/pkg/sink/codec/simple"
+	"go.uber.org/zap"
+)
+
+// Message is a message received from the message queue, such as kafka or pulsar.
+type Message struct {
+	Key       []byte
+	Value     []byte
+	Partition int32
+	// Offset is the position of the message in the partition, it should increase
+	// monotonically. It's 0 if the message is known to be delivered again,
+	// then the events fall behind the watermark are ignored.
+	Offset int64
+}
+
+// Config is the configuration of the Writer.
+type Config struct {
+	// PartitionNum is the number of the partitions of the topic.
+	PartitionNum int32
+	Protocol     config.Protocol
+	// MaxMessageBytes and MaxBatchSize are the limitations of the producer,
+	// a message violates them is considered invalid.
+	MaxMessageBytes int
+	MaxBatchSize    int
+	// EventRouter checks whether the row changed events are dispatched to the
+	// expected partitions, the check is skipped if it's nil.
+	EventRouter *dispatcher.EventRouter
+	// NewDecoder creates the decoder for each partition.
+	NewDecoder func() (codec.RowEventDecoder, error)
+	// DedupByKey drops the row changed events which commit before another event
+	// received with the same message key. It should be enabled only if the events
+	// of the same key are sent in the commit ts order, such as the pulsar ordering
+	// keys, then such events are sent again by the restarted changefeed.
+	DedupByKey bool
+}
+
+type partitionProgress struct {
+	partition       int32
+	watermark       uint64
+	watermarkOffset int64
+	// tableIDs are the tables whose events have been appended to the downstream.
+	tableIDs map[model.TableID]struct{}
+	// keyCommitTs is the max commit ts of the events received with each message key
+	// after the watermark, it's only used if DedupByKey is enabled.
+	keyCommitTs map[string]uint64
+
+	eventGroups map[model.TableID]*EventsGroup
+	decoder     codec.RowEventDecoder
+}
+
+// Writer decodes the messages of all partitions of a topic, and writes the
+// events to the downstream in order. It tracks the watermark of each partition,
+// only flushes the row changed events and executes the DDL events once all
+// partitions have caught up, and ignores the duplicate events which are consumed
+// again after the consumer restarts.
+// Writer is not thread-safe, WriteMessage should be called in one goroutine.
+type Writer struct {
+	cfg        *Config
+	downstream Downstream
+
+	ddlList              []*model.DDLEvent
+	ddlWithMaxCommitTs   *model.DDLEvent
+	fakeTableIDGenerator *FakeTableIDGenerator
+
+	progresses []*partitionProgress
+}
+
+// NewWriter creates a new Writer.
+func NewWriter(cfg *Config, downstream Downstream) (*Writer, error) {
+	if cfg.PartitionNum <= 0 {
+		return nil, errors.ErrConsumerInvalidMessage.GenWithStackByArgs(
+			fmt.Sprintf("invalid partition number %d", cfg.PartitionNum))
+	}
+	if cfg.MaxMessageBytes <= 0 {
+		cfg.MaxMessageBytes = math.MaxInt
+	}
+	if cfg.MaxBatchSize <= 0 {
+		cfg.MaxBatchSize = math.MaxInt
+	}
+	w := &Writer{
+		cfg:                  cfg,
+		downstream:           downstream,
+		fakeTableIDGenerator: NewFakeTableIDGenerator(),
+		progresses:           make([]*partitionProgress, cfg.PartitionNum),
+	}
+	for i := range w.progresses {
+		decoder, err := cfg.NewDecoder()
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		w.progresses[i] = &partitionProgress{
+			partition:   int32(i),
+			tableIDs:    make(map[model.TableID]struct{}),
+			keyCommitTs: make(map[string]uint64),
+			eventGroups: make(map[model.TableID]*EventsGroup),
+			decoder:     decoder,
+		}
+	}
+	return w, nil
+}
+
+// appendDDL appends the DDL waiting to be executed, only consider the constraint among DDLs.
+// for DDL a / b received in the order, a.CommitTs < b.CommitTs should be true.
+func (w *Writer) appendDDL(ddl *model.DDLEvent) {
+	// DDL CommitTs fallback, the DDL is received again.
+	if w.ddlWithMaxCommitTs != nil && ddl.CommitTs < w.ddlWithMaxCommitTs.CommitTs {
+		log.Warn("DDL CommitTs < maxCommitTsDDL.CommitTs",
+			zap.Uint64("commitTs", ddl.CommitTs),
+			zap.Uint64("maxCommitTs", w.ddlWithMaxCommitTs.CommitTs),
+			zap.String("DDL", ddl.Query))
+		return
+	}
+
+	// A rename tables DDL job contains multiple DDL events with same CommitTs.
+	// So to tell if a DDL is redundant or not, we must check the equivalence of
+	// the current DDL and the DDL with max CommitTs.
+	if ddl == w.ddlWithMaxCommitTs {
+		log.Warn("ignore redundant DDL, the DDL is equal to ddlWithMaxCommitTs",
+			zap.Uint64("commitTs", ddl.CommitTs), zap.String("DDL", ddl.Query))
+		return
+	}
+
+	w.ddlList = append(w.ddlList, ddl)
+	w.ddlWithMaxCommitTs = ddl
+}
+
+func (w *Writer) getFrontDDL() *model.DDLEvent {
+	if len(w.ddlList) > 0 {
+		return w.ddlList[0]
+	}
+	return nil
+}
+
+func (w *Writer) popDDL() {
+	if len(w.ddlList) > 0 {
+		w.ddlList = w.ddlList[1:]
+	}
+}
+
+// Watermark returns the minimum watermark of all partitions, all the events
+// whose CommitTs is not greater than it have been received.
+func (w *Writer) Watermark() uint64 {
+	result := uint64(math.MaxUint64)
+	for _, p := range w.progresses {
+		watermark := atomic.LoadUint64(&p.watermark)
+		if watermark < result {
+			result = watermark
+		}
+	}
+	return result
+}
+
+// forEachPartition flushes the partitions concurrently.
+func (w *Writer) forEachPartition(fn func(p *partitionProgress) error) error {
+	var (
+		wg       sync.WaitGroup
+		errMu    sync.Mutex
+		firstErr error
+	)
+	for _, p := range w.progresses {
+		wg.Add(1)
+		go func(p *partitionProgress) {
+			defer wg.Done()
+			if err := fn(p); err != nil {
+				errMu.Lock()
+				if firstErr == nil {
+					firstErr = err
+				}
+				errMu.Unlock()
+			}
+		}(p)
+	}
+	wg.Wait()
+	return firstErr
+}
+
+func (w *Writer) flush(ctx context.Context, progress *partitionProgress, watermark uint64) error {
+	resolvedTs := model.NewResolvedTs(watermark)
+	for tableID := range progress.tableIDs {
+		if err := w.downstream.Flush(ctx, progress.partition, tableID, resolvedTs); err != nil {
+			return errors.Trace(err)
+		}
+	}
+	return nil
+}
+
+// write executes the DDLs and flushes the row changed events which are
+// resolved by all partitions, it returns true if nothing is left behind.
+func (w *Writer) write(ctx context.Context, messageType model.MessageType) (bool, error) {
+	watermark := w.Watermark()
+	var todoDDL *model.DDLEvent
+	for {
+		todoDDL = w.getFrontDDL()
+		// watermark is the min value for all partitions,
+		// the DDL only executed by the first partition, other partitions may be slow
+		// so that the watermark can be smaller than the DDL's commitTs,
+		// which means some DML events may not be consumed yet, so cannot execute the DDL right now.
+		if todoDDL == nil || todoDDL.CommitTs > watermark {
+			break
+		}
+		// flush DMLs
+		commitTs := todoDDL.CommitTs
+		if err := w.forEachPartition(func(p *partitionProgress) error {
+			return w.flush(ctx, p, commitTs)
+		}); err != nil {
+			return false, errors.Trace(err)
+		}
+		// DDL can be executed, do it first.
+		if err := w.downstream.WriteDDLEvent(ctx, todoDDL); err != nil {
+			log.Error("write DDL event failed", zap.Error(err),
+				zap.String("DDL", todoDDL.Query), zap.Uint64("commitTs", todoDDL.CommitTs))
+			return false, errors.Trace(err)
+		}
+		w.popDDL()
+	}
+
+	if messageType == model.MessageTypeResolved {
+		if err := w.forEachPartition(func(p *partitionProgress) error {
+			return w.flush(ctx, p, watermark)
+		}); err != nil {
+			return false, errors.Trace(err)
+		}
+	}
+
+	// The DDL events will only execute in partition0
+	if messageType == model.MessageTypeDDL && todoDDL != nil {
+		log.Info("DDL event will be flushed in the future",
+			zap.Uint64("watermark", watermark),
+			zap.Uint64("CommitTs", todoDDL.CommitTs),
+			zap.String("Query", todoDDL.Query))
+		return false, nil
+	}
+	return true, nil
+}
+
+// WriteMessage decodes the message and writes the events to the downstream.
+// It returns true if the message and all the previous messages of the partition
+// have been written, so the offset of the message can be committed.
+func (w *Writer) WriteMessage(ctx context.Context, message *Message) (bool, error) {
+	var (
+		key       = message.Key
+		value     = message.Value
+		partition = message.Partition
+	)
+	if partition < 0 || partition >= w.cfg.PartitionNum {
+		return false, errors.ErrConsumerInvalidMessage.GenWithStackByArgs(
+			fmt.Sprintf("partition %d out of range [0, %d)", partition, w.cfg.PartitionNum))
+	}
+
+	progress := w.progresses[partition]
+	decoder := progress.decoder
+	if err := decoder.AddKeyValue(key, value); err != nil {
+		log.Error("add key value to the decoder failed",
+			zap.Int32("partition", partition), zap.Int64("offset", message.Offset),
+			zap.Error(err))
+		return false, errors.Trace(err)
+	}
+	var (
+		counter     int
+		needFlush   bool
+		messageType model.MessageType
+	)
+	for {
+		ty, hasNext, err := decoder.HasNext()
+		if err != nil {
+			log.Error("decode message key failed",
+				zap.Int32("partition", partition), zap.Int64("offset", message.Offset),
+				zap.Error(err))
+			return false, errors.Trace(err)
+		}
+		if !hasNext {
+			break
+		}
+		counter++
+		// If the message containing only one event exceeds the length limit, CDC will allow it and issue a warning.
+		if len(key)+len(value) > w.cfg.MaxMessageBytes && counter > 1 {
+			log.Error("max-messages-bytes exceeded",
+				zap.Int32("partition", partition), zap.Int64("offset", message.Offset),
+				zap.Int("max-message-bytes", w.cfg.MaxMessageBytes),
+				zap.Int("receivedBytes", len(key)+len(value)))
+			return false, errors.ErrConsumerInvalidMessage.GenWithStackByArgs("max-message-bytes exceeded")
+		}
+		messageType = ty
+		switch messageType {
+		case model.MessageTypeDDL:
+			// for some protocol, DDL would be dispatched to all partitions,
+			// Consider that DDL a, b, c received from partition-0, the latest DDL is c,
+			// if we receive `a` from partition-1, which would be seemed as DDL regression,
+			// then cause the consumer panic, but it was a duplicate one.
+			// so we only handle DDL received from partition-0 should be enough.
+			// but all DDL event messages should be consumed.
+			ddl, err := decoder.NextDDLEvent()
+			if err != nil {
+				log.Error("decode message value failed",
+					zap.Int32("partition", partition), zap.Int64("offset", message.Offset),
+					zap.ByteString("value", value),
+					zap.Error(err))
+				return false, errors.Trace(err)
+			}
+
+			if simple, ok := decoder.(*simple.Decoder); ok {
+				cachedEvents := simple.GetCachedEvents()
+				for _, row := range cachedEvents {
+					row.TableInfo.TableName.TableID = row.PhysicalTableID
+					if err := w.appendRow(progress, row.PhysicalTableID, row, message); err != nil {
+						return false, errors.Trace(err)
+					}
+				}
+			}
+
+			// the Query maybe empty if using simple protocol, it's comes from `bootstrap` event.
+			if partition == 0 && ddl.Query != "" {
+				w.appendDDL(ddl)
+				needFlush = true
+				log.Info("DDL message received",
+					zap.Int32("partition", partition),
+					zap.Int64("offset", message.Offset),
+					zap.Uint64("commitTs", ddl.CommitTs),
+					zap.String("DDL", ddl.Query))
+			}
+		case model.MessageTypeRow:
+			row, err := decoder.NextRowChangedEvent()
+			if err != nil {
+				log.Error("decode message value failed",
+					zap.Int32("partition", partition), zap.Int64("offset", message.Offset),
+					zap.ByteString("value", value),
+					zap.Error(err))
+				return false, errors.Trace(err)
+			}
+			// when using simple protocol, the row may be nil, since it's table info not received yet,
+			// it's cached in the decoder, so just continue here.
+			if w.cfg.Protocol == config.ProtocolSimple && row == nil {
+				continue
+			}
+
+			tableID := row.PhysicalTableID
+			// simple protocol decoder should have set the table id already.
+			if w.cfg.Protocol != config.ProtocolSimple {
+				tableID = w.fakeTableIDGenerator.
+					GenerateFakeTableID(row.TableInfo.GetSchemaName(), row.TableInfo.GetTableName(), row.PhysicalTableID)
+				row.TableInfo.TableName.TableID = tableID
+			}
+			if err := w.appendRow(progress, tableID, row, message); err != nil {
+				return false, errors.Trace(err)
+			}
+		case model.MessageTypeResolved:
+			ts, err := decoder.NextResolvedEvent()
+			if err != nil {
+				log.Error("decode message value failed",
+					zap.Int32("partition", partition), zap.Int64("offset", message.Offset),
+					zap.ByteString("value", value),
+					zap.Error(err))
+				return false, errors.Trace(err)
+			}
+
+			log.Debug("watermark event received",
+				zap.Int32("partition", partition),
+				zap.Int64("offset", message.Offset),
+				zap.Uint64("watermark", ts))
+
+			isOld, err := w.checkOldMessage(progress, ts, nil, message)
+			if err != nil {
+				return false, errors.Trace(err)
+			}
+			if isOld {
+				continue
+			}
+
+			for tableID, group := range progress.eventGroups {
+				events := group.Resolve(ts)
+				if len(events) == 0 {
+					continue
+				}
+				if err := w.downstream.AppendRowChangedEvents(ctx, partition, tableID, events...); err != nil {
+					return false, errors.Trace(err)
+				}
+				progress.tableIDs[tableID] = struct{}{}
+				log.Debug("append row changed events to the downstream",
+					zap.Uint64("resolvedTs", ts), zap.Int64("tableID", tableID), zap.Int("count", len(events)),
+					zap.Int32("partition", partition), zap.Int64("offset", message.Offset))
+			}
+			atomic.StoreUint64(&progress.watermark, ts)
+			progress.watermarkOffset = message.Offset
+			// the events before the watermark are dropped anyway.
+			for key, commitTs := range progress.keyCommitTs {
+				if commitTs <= ts {
+					delete(progress.keyCommitTs, key)
+				}
+			}
+			needFlush = true
+		default:
+			log.Error("unknown message type", zap.Any("messageType", messageType),
+				zap.Int32("partition", partition), zap.Int64("offset", message.Offset))
+			return false, errors.ErrConsumerInvalidMessage.GenWithStackByArgs(
+				fmt.Sprintf("unknown message type %d", messageType))
+		}
+	}
+
+	if counter > w.cfg.MaxBatchSize {
+		log.Error("max-batch-size exceeded",
+			zap.Int("max-batch-size", w.cfg.MaxBatchSize), zap.Int("actual-batch-size", counter),
+			zap.Int32("partition", partition), zap.Int64("offset", message.Offset))
+		return false, errors.ErrConsumerInvalidMessage.GenWithStackByArgs("max-batch-size exceeded")
+	}
+
+	if !needFlush {
+		return false, nil
+	}
+	// flush when received DDL event or resolvedTs
+	return w.write(ctx, messageType)
+}
+
+// appendRow buffers the row changed event until it's resolved.
+func (w *Writer) appendRow(
+	progress *partitionProgress, tableID model.TableID, row *model.RowChangedEvent, message *Message,
+) error {
+	if err := w.checkPartition(row, message); err != nil {
+		return errors.Trace(err)
+	}
+	isOld, err := w.checkOldMessage(progress, row.CommitTs, row, message)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if isOld {
+		return nil
+	}
+	if w.cfg.DedupByKey && isDuplicate(progress, row, message) {
+		return nil
+	}
+	group, ok := progress.eventGroups[tableID]
+	if !ok {
+		group = NewEventsGroup()
+		progress.eventGroups[tableID] = group
+	}
+	group.Append(row)
+	log.Debug("DML event received",
+		zap.Int32("partition", message.Partition),
+		zap.Int64("offset", message.Offset),
+		zap.Uint64("commitTs", row.CommitTs),
+		zap.Int64("physicalTableID", row.PhysicalTableID),
+		zap.Int64("tableID", tableID),
+		zap.String("schema", row.TableInfo.GetSchemaName()),
+		zap.String("table", row.TableInfo.GetTableName()))
+	return nil
+}
+
+// checkPartition checks whether the row changed event is dispatched to the expected partition.
+func (w *Writer) checkPartition(row *model.RowChangedEvent, message *Message) error {
+	if w.cfg.EventRouter == nil {
+		return nil
+	}
+	target, _, err := w.cfg.EventRouter.GetPartitionForRowChange(row, w.cfg.PartitionNum)
+	if err != nil {
+		log.Error("cannot calculate partition for the row changed event",
+			zap.Int32("partition", message.Partition), zap.Int64("offset", message.Offset),
+			zap.Int32("partitionNum", w.cfg.PartitionNum), zap.Int64("tableID", row.TableInfo.TableName.TableID),
+			zap.Error(err), zap.Any("event", row))
+		return errors.Trace(err)
+	}
+	if message.Partition != target {
+		log.Error("RowChangedEvent dispatched to wrong partition",
+			zap.Int32("partition", message.Partition), zap.Int32("expected", target),
+			zap.Int32("partitionNum", w.cfg.PartitionNum),
+			zap.Int64("offset", message.Offset),
+			zap.Int64("tableID", row.TableInfo.TableName.TableID), zap.Any("row", row),
+		)
+		return errors.ErrConsumerInvalidMessage.GenWithStackByArgs(
+			fmt.Sprintf("row changed event dispatched to partition %d, expected %d", message.Partition, target))
+	}
+	return nil
+}
+
+// isDuplicate returns true if the row changed event commits before another event
+// received with the same message key, and records the commit ts of the key otherwise.
+// The events committed at the same ts are kept, since they may belong to the same
+// transaction, the downstream is expected to apply them idempotently.
+func isDuplicate(progress *partitionProgress, row *model.RowChangedEvent, message *Message) bool {
+	key := string(message.Key)
+	if maxCommitTs, ok := progress.keyCommitTs[key]; ok && row.CommitTs < maxCommitTs {
+		log.Warn("Row changed event commits before the previous one of the same key, ignore it",
+			zap.Uint64("commitTs", row.CommitTs), zap.Uint64("maxCommitTs", maxCommitTs),
+			zap.Int32("partition", message.Partition), zap.String("key", key),
+			zap.String("schema", row.TableInfo.GetSchemaName()),
+			zap.String("table", row.TableInfo.GetTableName()))
+		return true
+	}
+	progress.keyCommitTs[key] = row.CommitTs
+	return false
+}
+
+// checkOldMessage returns true if the event falls behind the watermark of the partition,
+// which happens if the consumer reads the messages before the committed offset again,
+// such events are written already and should be ignored.
+// The row is nil if the ts comes from a resolved event.
+func (w *Writer) checkOldMessage(
+	progress *partitionProgress, ts uint64, row *model.RowChangedEvent, message *Message,
+) (bool, error) {
+	watermark := atomic.LoadUint64(&progress.watermark)
+	// if the message queue is normal, this should not hit.
+	// else if the cluster is abnormal, the consumer may consume old message, then cause the watermark fallback.
+	if ts >= watermark {
+		return false, nil
+	}
+	// if commit message failed, the consumer may read previous message,
+	// just ignore this message should be fine, otherwise it's a bug.
+	if message.Offset > progress.watermarkOffset {
+		if row == nil {
+			log.Error("partition resolved ts fallback",
+				zap.Uint64("ts", ts), zap.Int64("offset", message.Offset),
+				zap.Uint64("watermark", watermark), zap.Int64("watermarkOffset", progress.watermarkOffset),
+				zap.Int32("partition", message.Partition))
+			return false, errors.ErrConsumerInvalidMessage.GenWithStackByArgs("resolved ts fallback")
+		}
+		log.Error("RowChangedEvent fallback row",
+			zap.Uint64("commitTs", ts), zap.Int64("offset", message.Offset),
+			zap.Uint64("watermark", watermark), zap.Int64("watermarkOffset", progress.watermarkOffset),
+			zap.Int32("partition", message.Partition), zap.Int64("tableID", row.TableInfo.TableName.TableID),
+			zap.String("schema", row.TableInfo.GetSchemaName()),
+			zap.String("table", row.TableInfo.GetTableName()))
+		return false, errors.ErrConsumerInvalidMessage.GenWithStackByArgs("row changed event fallback")
+	}
+	if row == nil {
+		log.Warn("partition resolved ts fall back, ignore it, since consumer read old offset message",
+			zap.Uint64("ts", ts), zap.Int64("offset", message.Offset),
+			zap.Uint64("watermark", watermark), zap.Int64("watermarkOffset", progress.watermarkOffset),
+			zap.Int32("partition", message.Partition))
+		return true, nil
+	}
+	log.Warn("Row changed event fall back, ignore it, since consumer read old offset message",
+		zap.Uint64("commitTs", ts), zap.Int64("offset", message.Offset),
+		zap.Uint64("watermark", watermark), zap.Int64("watermarkOffset", progress.watermarkOffset),
+		zap.Int32("partition", message.Partition), zap.Int64("tableID", row.TableInfo.TableName.TableID),
+		zap.String("schema", row.TableInfo.GetSchemaName()),
+		zap.String("table", row.TableInfo.GetTableName()))
+	return true, nil
+}
+
+// Close closes the downstream.
+func (w *Writer) Close() {
+	w.downstream.Close()
+}
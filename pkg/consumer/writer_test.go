@@ -0,0 +1,213 @@
+// Copyright 2024 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package consumer
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/pingcap/tiflow/cdc/model"
+	"github.com/pingcap/tiflow/pkg/config"
+	"github.com/pingcap/tiflow/pkg/errors"
+	"github.com/pingcap/tiflow/pkg/sink/codec"
+	"github.com/stretchr/testify/require"
+)
+
+type testEvent struct {
+	Type  model.MessageType
+	Ts    uint64
+	Table string
+	Query string
+}
+
+// testDecoder decodes the testEvents encoded in JSON.
+type testDecoder struct {
+	events []testEvent
+}
+
+func (d *testDecoder) AddKeyValue(_, value []byte) error {
+	return json.Unmarshal(value, &d.events)
+}
+
+func (d *testDecoder) HasNext() (model.MessageType, bool, error) {
+	if len(d.events) == 0 {
+		return model.MessageTypeUnknown, false, nil
+	}
+	return d.events[0].Type, true, nil
+}
+
+func (d *testDecoder) next() testEvent {
+	e := d.events[0]
+	d.events = d.events[1:]
+	return e
+}
+
+func (d *testDecoder) NextResolvedEvent() (uint64, error) {
+	return d.next().Ts, nil
+}
+
+func (d *testDecoder) NextRowChangedEvent() (*model.RowChangedEvent, error) {
+	e := d.next()
+	return &model.RowChangedEvent{
+		CommitTs: e.Ts,
+		TableInfo: &model.TableInfo{
+			TableName: model.TableName{Schema: "test", Table: e.Table},
+		},
+	}, nil
+}
+
+func (d *testDecoder) NextDDLEvent() (*model.DDLEvent, error) {
+	e := d.next()
+	return &model.DDLEvent{CommitTs: e.Ts, Query: e.Query}, nil
+}
+
+func TestWriter(t *testing.T) {
+	ctx := context.Background()
+
+	var (
+		mu     sync.Mutex
+		output []string
+	)
+	downstream := &CallbackDownstream{
+		OnDDLEvent: func(_ context.Context, ddl *model.DDLEvent) error {
+			mu.Lock()
+			defer mu.Unlock()
+			output = append(output, fmt.Sprintf("ddl %d", ddl.CommitTs))
+			return nil
+		},
+		OnRowChangedEvents: func(
+			_ context.Context, _ int32, _ model.TableID, events []*model.RowChangedEvent,
+		) error {
+			mu.Lock()
+			defer mu.Unlock()
+			for _, e := range events {
+				output = append(output, fmt.Sprintf("row %d", e.CommitTs))
+			}
+			return nil
+		},
+	}
+	writer, err := NewWriter(&Config{
+		PartitionNum: 2,
+		Protocol:     config.ProtocolCanalJSON,
+		NewDecoder: func() (codec.RowEventDecoder, error) {
+			return &testDecoder{}, nil
+		},
+	}, downstream)
+	require.NoError(t, err)
+	defer writer.Close()
+
+	write := func(partition int32, offset int64, events ...testEvent) (bool, error) {
+		value, err := json.Marshal(events)
+		require.NoError(t, err)
+		return writer.WriteMessage(ctx, &Message{Value: value, Partition: partition, Offset: offset})
+	}
+	row := func(ts uint64) testEvent {
+		return testEvent{Type: model.MessageTypeRow, Ts: ts, Table: "t"}
+	}
+	resolved := func(ts uint64) testEvent {
+		return testEvent{Type: model.MessageTypeResolved, Ts: ts}
+	}
+
+	needCommit, err := write(0, 1, row(10))
+	require.NoError(t, err)
+	require.False(t, needCommit)
+	_, err = write(1, 1, row(12))
+	require.NoError(t, err)
+	// the DDL waits for the partition 1.
+	needCommit, err = write(0, 2, testEvent{Type: model.MessageTypeDDL, Ts: 15, Query: "alter table t"})
+	require.NoError(t, err)
+	require.False(t, needCommit)
+	_, err = write(0, 3, row(20))
+	require.NoError(t, err)
+	needCommit, err = write(0, 4, resolved(25))
+	require.NoError(t, err)
+	require.True(t, needCommit)
+	require.Empty(t, output)
+
+	// all the rows before the DDL are flushed before it's executed.
+	_, err = write(1, 2, resolved(16))
+	require.NoError(t, err)
+	require.Len(t, output, 3)
+	require.ElementsMatch(t, []string{"row 10", "row 12"}, output[:2])
+	require.Equal(t, "ddl 15", output[2])
+	require.Equal(t, uint64(16), writer.Watermark())
+
+	_, err = write(1, 3, resolved(30))
+	require.NoError(t, err)
+	require.Equal(t, []string{"row 20"}, output[3:])
+	require.Equal(t, uint64(25), writer.Watermark())
+
+	// the messages before the committed offset are consumed again.
+	needCommit, err = write(1, 1, row(12), resolved(16))
+	require.NoError(t, err)
+	require.False(t, needCommit)
+	require.Len(t, output, 4)
+
+	// the events fall behind the watermark in the new messages are invalid.
+	_, err = write(1, 4, row(12))
+	require.True(t, errors.ErrConsumerInvalidMessage.Equal(err))
+	_, err = write(0, 5, resolved(20))
+	require.True(t, errors.ErrConsumerInvalidMessage.Equal(err))
+}
+
+func TestWriterDedupByKey(t *testing.T) {
+	ctx := context.Background()
+
+	var output []string
+	downstream := &CallbackDownstream{
+		OnRowChangedEvents: func(
+			_ context.Context, _ int32, _ model.TableID, events []*model.RowChangedEvent,
+		) error {
+			for _, e := range events {
+				output = append(output, fmt.Sprintf("row %d", e.CommitTs))
+			}
+			return nil
+		},
+	}
+	writer, err := NewWriter(&Config{
+		PartitionNum: 1,
+		Protocol:     config.ProtocolCanalJSON,
+		NewDecoder: func() (codec.RowEventDecoder, error) {
+			return &testDecoder{}, nil
+		},
+		DedupByKey: true,
+	}, downstream)
+	require.NoError(t, err)
+	defer writer.Close()
+
+	write := func(key string, events ...testEvent) {
+		value, err := json.Marshal(events)
+		require.NoError(t, err)
+		_, err = writer.WriteMessage(ctx, &Message{Key: []byte(key), Value: value})
+		require.NoError(t, err)
+	}
+	row := func(ts uint64) testEvent {
+		return testEvent{Type: model.MessageTypeRow, Ts: ts, Table: "t"}
+	}
+
+	write("a", row(10))
+	write("a", row(12))
+	// the rows of the other keys are not affected.
+	write("b", row(11))
+	// the rows sent again by the restarted changefeed are dropped,
+	// except the ones committed at the same ts as the last row of the key.
+	write("a", row(10))
+	write("a", row(12))
+	write("b", row(11))
+	write("a", testEvent{Type: model.MessageTypeResolved, Ts: 20})
+	require.Equal(t, []string{"row 10", "row 11", "row 11", "row 12", "row 12"}, output)
+}
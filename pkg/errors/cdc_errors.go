@@ -124,6 +124,10 @@ var (
 		"decode failed: %s",
 		errors.RFCCodeText("CDC:ErrDecodeFailed"),
 	)
+	ErrConsumerInvalidMessage = errors.Normalize(
+		"invalid message received by the consumer: %s",
+		errors.RFCCodeText("CDC:ErrConsumerInvalidMessage"),
+	)
 	ErrFilterRuleInvalid = errors.Normalize(
 		"filter rule is invalid %v",
 		errors.RFCCodeText("CDC:ErrFilterRuleInvalid"),
@@ -223,6 +227,10 @@ var (
 		"kafka config item not found",
 		errors.RFCCodeText("CDC:ErrKafkaConfigNotFound"),
 	)
+	ErrKafkaTransactionFailed = errors.Normalize(
+		"kafka transaction failed",
+		errors.RFCCodeText("CDC:ErrKafkaTransactionFailed"),
+	)
 	// for pulsar
 	ErrPulsarSendMessage = errors.Normalize(
 		"pulsar send message failed",
@@ -279,6 +287,10 @@ var (
 	ErrPulsarTopicNotExists = errors.Normalize("pulsar topic not exists after creation",
 		errors.RFCCodeText("CDC:ErrPulsarTopicNotExists"),
 	)
+	ErrPulsarTransactionFailed = errors.Normalize(
+		"pulsar transaction failed",
+		errors.RFCCodeText("CDC:ErrPulsarTransactionFailed"),
+	)
 
 	ErrRedoConfigInvalid = errors.Normalize(
 		"redo log config invalid",
@@ -387,6 +399,18 @@ var (
 		"avro invalid message format, %s",
 		errors.RFCCodeText("CDC:ErrAvroInvalidMessage"),
 	)
+	ErrProtobufEncodeFailed = errors.Normalize(
+		"protobuf encode failed, %s",
+		errors.RFCCodeText("CDC:ErrProtobufEncodeFailed"),
+	)
+	ErrProtobufSchemaAPIError = errors.Normalize(
+		"protobuf schema registry API error, %s",
+		errors.RFCCodeText("CDC:ErrProtobufSchemaAPIError"),
+	)
+	ErrProtobufInvalidMessage = errors.Normalize(
+		"protobuf invalid message format, %s",
+		errors.RFCCodeText("CDC:ErrProtobufInvalidMessage"),
+	)
 	ErrMaxwellEncodeFailed = errors.Normalize(
 		"maxwell encode failed",
 		errors.RFCCodeText("CDC:ErrMaxwellEncodeFailed"),
@@ -618,6 +642,10 @@ var (
 		"owner running unknown error",
 		errors.RFCCodeText("CDC:ErrOwnerUnknown"),
 	)
+	ErrProcessorNotFound = errors.Normalize(
+		"processor of changefeed %s not found in this capture",
+		errors.RFCCodeText("CDC:ErrProcessorNotFound"),
+	)
 	ErrProcessorTableNotFound = errors.Normalize(
 		"table not found in processor cache",
 		errors.RFCCodeText("CDC:ErrProcessorTableNotFound"),
@@ -1014,4 +1042,22 @@ var (
 		"user %s unauthorized, error: %s",
 		errors.RFCCodeText("CDC:ErrUnauthorized"),
 	)
+	ErrPermissionDenied = errors.Normalize(
+		"permission denied, %s",
+		errors.RFCCodeText("CDC:ErrPermissionDenied"),
+	)
+
+	// secret encryption related errors
+	ErrInvalidSecretKey = errors.Normalize(
+		"invalid secret key in %s",
+		errors.RFCCodeText("CDC:ErrInvalidSecretKey"),
+	)
+	ErrEncryptSecretFailed = errors.Normalize(
+		"encrypt secret failed",
+		errors.RFCCodeText("CDC:ErrEncryptSecretFailed"),
+	)
+	ErrDecryptSecretFailed = errors.Normalize(
+		"decrypt secret failed, %s",
+		errors.RFCCodeText("CDC:ErrDecryptSecretFailed"),
+	)
 )
@@ -280,3 +280,40 @@ func OriginError(err error) error {
 	}
 	return err
 }
+
+// tableError attaches the table involved to an error.
+type tableError struct {
+	error
+	table string
+}
+
+// Unwrap implements the Unwrap interface of errors.
+func (e *tableError) Unwrap() error {
+	return e.error
+}
+
+// Cause implements the causer interface of pingcap/errors.
+func (e *tableError) Cause() error {
+	return e.error
+}
+
+// WithTable annotates the error with the table involved in it,
+// so that the table can be reported along with the error.
+func WithTable(err error, table string) error {
+	if err == nil || table == "" {
+		return err
+	}
+	return &tableError{error: err, table: table}
+}
+
+// TableOf returns the table annotated by WithTable, or an empty string
+// if the error is not related to any table.
+func TableOf(err error) string {
+	for err != nil {
+		if terr, ok := err.(*tableError); ok {
+			return terr.table
+		}
+		err = errors.Unwrap(err)
+	}
+	return ""
+}
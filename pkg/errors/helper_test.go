@@ -236,3 +236,21 @@ func TestOriginError(t *testing.T) {
 	err3 := errors.Trace(err2)
 	require.Equal(t, err1, OriginError(err3))
 }
+
+func TestWithTable(t *testing.T) {
+	t.Parallel()
+
+	require.Nil(t, WithTable(nil, "test.t"))
+	require.Equal(t, "", TableOf(nil))
+
+	err := ErrMySQLTxnError.GenWithStackByArgs()
+	require.Equal(t, err, WithTable(err, ""))
+	require.Equal(t, "", TableOf(err))
+
+	err2 := errors.Trace(WithTable(err, "test.t"))
+	require.Equal(t, "test.t", TableOf(err2))
+	require.Equal(t, err.Error(), err2.Error())
+	code, ok := RFCCode(err2)
+	require.True(t, ok)
+	require.Equal(t, ErrMySQLTxnError.RFCCode(), code)
+}
@@ -49,6 +49,11 @@ func CaptureInfoKeyPrefix(clusterID string) string {
 	return BaseKey(clusterID) + metaPrefix + captureKey
 }
 
+// AuditKeyPrefix is the prefix of the audit record keys of the cluster
+func AuditKeyPrefix(clusterID string) string {
+	return auditPrefix + "/" + clusterID
+}
+
 // TaskPositionKeyPrefix is the prefix of task position keys
 func TaskPositionKeyPrefix(clusterID, namespace string) string {
 	return NamespacedPrefix(clusterID, namespace) + taskPositionKey
@@ -234,7 +239,8 @@ func (c *CDCEtcdClientImpl) CheckMultipleCDCClusterExist(ctx context.Context) er
 	for _, kv := range resp.Kvs {
 		key := string(kv.Key)
 		if strings.HasPrefix(key, BaseKey(DefaultCDCClusterID)) ||
-			strings.HasPrefix(key, migrateBackupPrefix) {
+			strings.HasPrefix(key, migrateBackupPrefix) ||
+			strings.HasPrefix(key, auditPrefix) {
 			continue
 		}
 		// skip the reserved cluster id
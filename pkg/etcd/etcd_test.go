@@ -202,6 +202,11 @@ func TestCheckMultipleCDCClusterExist(t *testing.T) {
 		require.NoError(t, err)
 	}
 
+	_, err = rawEtcdClient.Put(ctx, AuditKeyPrefix(DefaultCDCClusterID)+"/test-key", "test-value")
+	require.NoError(t, err)
+	err = s.client.CheckMultipleCDCClusterExist(ctx)
+	require.NoError(t, err)
+
 	newClusterKey := NamespacedPrefix("new-cluster", "new-namespace") +
 		"/test-key"
 	_, err = rawEtcdClient.Put(ctx, newClusterKey, "test-value")
@@ -50,6 +50,9 @@ const (
 
 	// MigrateBackupPrefix is the prefix of backup keys during a migration
 	migrateBackupPrefix = "/tidb/cdc/__backup__"
+	// auditPrefix is the prefix of the audit records, it's out of the
+	// cluster prefixes so that the etcd workers don't watch the records.
+	auditPrefix = "/tidb/cdc/__audit__"
 )
 
 // CDCKeyType is the type of etcd key
@@ -13,6 +13,16 @@
 
 package integrity
 
+import (
+	"sort"
+	"time"
+
+	"github.com/pingcap/errors"
+	timodel "github.com/pingcap/tidb/pkg/meta/model"
+	"github.com/pingcap/tidb/pkg/types"
+	"github.com/pingcap/tidb/pkg/util/rowcodec"
+)
+
 // Checksum represent checksum for an RowChangedEvent
 type Checksum struct {
 	Current   uint32
@@ -20,3 +30,32 @@ type Checksum struct {
 	Corrupted bool
 	Version   int
 }
+
+// CalculateColumnChecksum calculates the checksum of the row in the same way
+// as the upstream TiDB, the datums are in the same order as the column infos.
+func CalculateColumnChecksum(
+	columnInfos []*timodel.ColumnInfo, datums []types.Datum, tz *time.Location,
+) (uint32, error) {
+	columns := make([]rowcodec.ColData, 0, len(datums))
+	for idx, col := range columnInfos {
+		column := rowcodec.ColData{
+			ColumnInfo: col,
+			Datum:      &datums[idx],
+		}
+		columns = append(columns, column)
+	}
+	sort.Slice(columns, func(i, j int) bool {
+		return columns[i].ID < columns[j].ID
+	})
+
+	calculator := rowcodec.RowData{
+		Cols: columns,
+		Data: make([]byte, 0),
+	}
+
+	checksum, err := calculator.Checksum(tz)
+	if err != nil {
+		return 0, errors.Trace(err)
+	}
+	return checksum, nil
+}
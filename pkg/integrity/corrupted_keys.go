@@ -0,0 +1,95 @@
+// Copyright 2024 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package integrity
+
+import (
+	"sync"
+	"time"
+)
+
+// maxCorruptedKeys limits the number of the corrupted keys kept for each
+// changefeed, the oldest ones are dropped first. The keys are kept after the
+// changefeed is stopped on this capture, so that they can still be inspected,
+// and dropped once the changefeed is removed.
+const maxCorruptedKeys = 1024
+
+// The reasons why a row is reported as corrupted.
+const (
+	// CorruptedReasonMismatch means the checksum of the downstream row
+	// doesn't match the checksum of the upstream row.
+	CorruptedReasonMismatch = "checksum-mismatch"
+	// CorruptedReasonMissing means the row is not found in the downstream.
+	CorruptedReasonMissing = "row-missing"
+	// CorruptedReasonUpstream means the row is already corrupted when it's
+	// decoded from the upstream.
+	CorruptedReasonUpstream = "upstream-corrupted"
+)
+
+// CorruptedKey is a row whose downstream data is found corrupted.
+type CorruptedKey struct {
+	Schema string `json:"schema"`
+	Table  string `json:"table"`
+	// Key is the handle key of the row, in the form of `col1=val1,col2=val2`.
+	Key      string `json:"key"`
+	CommitTs uint64 `json:"commit_ts"`
+	// Expected is the checksum of the upstream row.
+	Expected uint32 `json:"expected"`
+	// Actual is the checksum of the downstream row, it's 0 if the row is missing.
+	Actual     uint32    `json:"actual"`
+	Reason     string    `json:"reason"`
+	DetectTime time.Time `json:"detect_time"`
+}
+
+type changefeedKey struct {
+	namespace string
+	id        string
+}
+
+var corruptedKeys = struct {
+	sync.Mutex
+	m map[changefeedKey][]CorruptedKey
+}{m: make(map[changefeedKey][]CorruptedKey)}
+
+// AddCorruptedKey records a corrupted key of the changefeed on this capture.
+func AddCorruptedKey(namespace, id string, key CorruptedKey) {
+	corruptedKeys.Lock()
+	defer corruptedKeys.Unlock()
+	k := changefeedKey{namespace: namespace, id: id}
+	keys := append(corruptedKeys.m[k], key)
+	if len(keys) > maxCorruptedKeys {
+		keys = append(keys[:0:0], keys[len(keys)-maxCorruptedKeys:]...)
+	}
+	corruptedKeys.m[k] = keys
+}
+
+// ListCorruptedKeys returns the corrupted keys of the changefeed found
+// by this capture, the oldest first.
+func ListCorruptedKeys(namespace, id string) []CorruptedKey {
+	corruptedKeys.Lock()
+	defer corruptedKeys.Unlock()
+	keys := corruptedKeys.m[changefeedKey{namespace: namespace, id: id}]
+	return append([]CorruptedKey{}, keys...)
+}
+
+// RetainCorruptedKeys drops the corrupted keys of the changefeeds which
+// keep returns false for, it's used to drop the keys of removed changefeeds.
+func RetainCorruptedKeys(keep func(namespace, id string) bool) {
+	corruptedKeys.Lock()
+	defer corruptedKeys.Unlock()
+	for k := range corruptedKeys.m {
+		if !keep(k.namespace, k.id) {
+			delete(corruptedKeys.m, k)
+		}
+	}
+}
@@ -0,0 +1,37 @@
+// Copyright 2024 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package integrity
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCorruptedKeys(t *testing.T) {
+	for i := 0; i < maxCorruptedKeys+1; i++ {
+		AddCorruptedKey("ns", "removed", CorruptedKey{CommitTs: uint64(i)})
+	}
+	AddCorruptedKey("ns", "kept", CorruptedKey{CommitTs: 1})
+
+	keys := ListCorruptedKeys("ns", "removed")
+	require.Len(t, keys, maxCorruptedKeys)
+	require.Equal(t, uint64(1), keys[0].CommitTs)
+
+	RetainCorruptedKeys(func(namespace, id string) bool {
+		return id == "kept"
+	})
+	require.Empty(t, ListCorruptedKeys("ns", "removed"))
+	require.Len(t, ListCorruptedKeys("ns", "kept"), 1)
+}
@@ -23,6 +23,12 @@ import (
 type Config struct {
 	IntegrityCheckLevel   string `toml:"integrity-check-level" json:"integrity-check-level"`
 	CorruptionHandleLevel string `toml:"corruption-handle-level" json:"corruption-handle-level"`
+	// DownstreamVerifyLevel controls whether the rows written by the MySQL sink
+	// are read back from the downstream to verify their checksums.
+	DownstreamVerifyLevel string `toml:"downstream-verify-level" json:"downstream-verify-level,omitempty"`
+	// DownstreamVerifySampleRatio is the ratio of the verified rows
+	// if the DownstreamVerifyLevel is sample.
+	DownstreamVerifySampleRatio float64 `toml:"downstream-verify-sample-ratio" json:"downstream-verify-sample-ratio,omitempty"`
 }
 
 const (
@@ -40,6 +46,18 @@ const (
 	CorruptionHandleLevelError string = "error"
 )
 
+const (
+	// DownstreamVerifyLevelNone means the rows written to the downstream are
+	// not verified, the default value.
+	DownstreamVerifyLevelNone string = "none"
+	// DownstreamVerifyLevelSample means a sample of the rows written to the
+	// downstream are verified.
+	DownstreamVerifyLevelSample string = "sample"
+	// DownstreamVerifyLevelFull means all the rows written to the downstream
+	// are verified.
+	DownstreamVerifyLevelFull string = "full"
+)
+
 // Validate the integrity config.
 func (c *Config) Validate() error {
 	if c.IntegrityCheckLevel != CheckLevelNone &&
@@ -50,11 +68,29 @@ func (c *Config) Validate() error {
 		c.CorruptionHandleLevel != CorruptionHandleLevelError {
 		return cerror.ErrInvalidReplicaConfig.GenWithStackByArgs()
 	}
+	switch c.DownstreamVerifyLevel {
+	case "", DownstreamVerifyLevelNone, DownstreamVerifyLevelFull:
+	case DownstreamVerifyLevelSample:
+		if c.DownstreamVerifySampleRatio <= 0 || c.DownstreamVerifySampleRatio > 1 {
+			return cerror.ErrInvalidReplicaConfig.GenWithStack(
+				"integrity.downstream-verify-sample-ratio must be in (0, 1], got %v",
+				c.DownstreamVerifySampleRatio)
+		}
+	default:
+		return cerror.ErrInvalidReplicaConfig.GenWithStack(
+			"invalid integrity.downstream-verify-level %s", c.DownstreamVerifyLevel)
+	}
+	if c.DownstreamVerifyEnabled() && !c.Enabled() {
+		return cerror.ErrInvalidReplicaConfig.GenWithStack(
+			"integrity.downstream-verify-level requires integrity.integrity-check-level to be %s",
+			CheckLevelCorrectness)
+	}
 
 	if c.Enabled() {
 		log.Info("integrity check is enabled",
 			zap.Any("integrityCheckLevel", c.IntegrityCheckLevel),
-			zap.Any("corruptionHandleLevel", c.CorruptionHandleLevel))
+			zap.Any("corruptionHandleLevel", c.CorruptionHandleLevel),
+			zap.String("downstreamVerifyLevel", c.DownstreamVerifyLevel))
 	}
 
 	return nil
@@ -69,3 +105,26 @@ func (c *Config) Enabled() bool {
 func (c *Config) ErrorHandle() bool {
 	return c.CorruptionHandleLevel == CorruptionHandleLevelError
 }
+
+// DownstreamVerifyEnabled returns true if the rows written to the downstream
+// are verified.
+func (c *Config) DownstreamVerifyEnabled() bool {
+	return c.DownstreamVerifyLevel == DownstreamVerifyLevelSample ||
+		c.DownstreamVerifyLevel == DownstreamVerifyLevelFull
+}
+
+// DownstreamVerifyRatio returns the ratio of the verified rows,
+// it's 0 if the downstream verification is disabled.
+func (c *Config) DownstreamVerifyRatio() float64 {
+	if c == nil {
+		return 0
+	}
+	switch c.DownstreamVerifyLevel {
+	case DownstreamVerifyLevelFull:
+		return 1
+	case DownstreamVerifyLevelSample:
+		return c.DownstreamVerifySampleRatio
+	default:
+		return 0
+	}
+}
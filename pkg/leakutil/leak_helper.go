@@ -32,6 +32,8 @@ var defaultOpts = []goleak.Option{
 	goleak.IgnoreTopFunction("github.com/IBM/sarama.(*client).backgroundMetadataUpdater"),
 	goleak.IgnoreTopFunction("github.com/IBM/sarama.(*Broker).responseReceiver"),
 	goleak.IgnoreTopFunction("github.com/lestrrat-go/httprc.runFetchWorker"),
+	// The global scan scheduler runs for the lifetime of the process once a kv client is created.
+	goleak.IgnoreTopFunction("github.com/pingcap/tiflow/cdc/kv.(*scanScheduler).run"),
 }
 
 // VerifyNone verifies that no unexpected leaks occur
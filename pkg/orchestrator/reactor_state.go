@@ -242,6 +242,18 @@ func (s *ChangefeedReactorState) SetError(lastError *model.RunningError) {
 	})
 }
 
+// AppendErrorHistory records the error in the error history of the changefeed,
+// at most maxSize recent errors are kept.
+func (s *ChangefeedReactorState) AppendErrorHistory(err *model.RunningError, maxSize int) {
+	s.PatchInfo(func(info *model.ChangeFeedInfo) (*model.ChangeFeedInfo, bool, error) {
+		if info == nil || err == nil {
+			return info, false, nil
+		}
+		info.AppendErrorHistory(err, maxSize)
+		return info, true, nil
+	})
+}
+
 // RemoveChangefeed removes the changefeed and clean the information and status.
 func (s *ChangefeedReactorState) RemoveChangefeed() {
 	// remove info
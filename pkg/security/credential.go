@@ -46,6 +46,29 @@ type Credential struct {
 
 	ClientUserRequired bool     `toml:"client-user-required" json:"client-user-required"`
 	ClientAllowedUser  []string `toml:"client-allowed-user" json:"client-allowed-user"`
+
+	// SecretKeyPath is the path of the file containing the hex encoded 256-bit key,
+	// which is used to encrypt the secrets of the changefeeds in the meta store.
+	// Note that the captures of the versions without this option can't read the
+	// encrypted sink uri, so it should be set only after all the captures are
+	// upgraded, otherwise the old captures fail the changefeeds during a rolling
+	// upgrade.
+	SecretKeyPath string `toml:"secret-key-path" json:"secret-key-path"`
+	// RotatedSecretKeyPaths are the paths of the previous secret keys,
+	// they are only used to decrypt the secrets encrypted before the rotation.
+	RotatedSecretKeyPaths []string `toml:"rotated-secret-key-paths" json:"rotated-secret-key-paths"`
+
+	// RoleBindings grants the roles of the HTTP API to the users and the
+	// certificate common names, all the APIs except the health and status
+	// ones are authorized by the roles if it's not empty. The requests are
+	// authorized by the TiCDC server which receives them from the clients,
+	// the ones forwarded by the other servers carry the authorized subject.
+	RoleBindings []*RoleBinding `toml:"role-bindings" json:"role-bindings"`
+	// ClusterCommonNames are the common names of the certificates of the other
+	// TiCDC servers in the cluster, the subjects carried by the requests they
+	// forward are trusted. The common name of the server's own certificate is
+	// always trusted, so it's only needed if the servers use different ones.
+	ClusterCommonNames []string `toml:"cluster-common-names" json:"cluster-common-names"`
 }
 
 // Value implements the driver.Valuer interface
@@ -0,0 +1,131 @@
+// Copyright 2024 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package security
+
+import (
+	"fmt"
+)
+
+// Role is the role of the HTTP API users.
+type Role string
+
+const (
+	// RoleNone means no role is granted.
+	RoleNone Role = ""
+	// RoleViewer can only read the states of the cluster and the changefeeds.
+	RoleViewer Role = "viewer"
+	// RoleOperator can create, update, pause and resume the changefeeds additionally.
+	RoleOperator Role = "operator"
+	// RoleAdmin can do everything, including removing the changefeeds,
+	// draining the captures and calling the unsafe APIs.
+	RoleAdmin Role = "admin"
+)
+
+var roleLevels = map[Role]int{
+	RoleNone:     0,
+	RoleViewer:   1,
+	RoleOperator: 2,
+	RoleAdmin:    3,
+}
+
+// Covers returns true if the role has all the permissions of the other role.
+func (r Role) Covers(other Role) bool {
+	return roleLevels[r] >= roleLevels[other]
+}
+
+// Validate checks whether the role is valid.
+func (r Role) Validate() error {
+	if _, ok := roleLevels[r]; !ok || r == RoleNone {
+		return fmt.Errorf("invalid role %q, should be one of %q, %q and %q",
+			r, RoleViewer, RoleOperator, RoleAdmin)
+	}
+	return nil
+}
+
+// RoleBinding grants the role to the users and the certificate common names.
+type RoleBinding struct {
+	Role Role `toml:"role" json:"role"`
+	// Users are authenticated by the upstream TiDB with the HTTP basic auth.
+	Users []string `toml:"users" json:"users"`
+	// CommonNames are the common names of the mTLS client certificates.
+	CommonNames []string `toml:"common-names" json:"common-names"`
+	// Namespaces limits the role to the changefeeds in the namespaces,
+	// the role is granted cluster-wide if it's empty.
+	Namespaces []string `toml:"namespaces" json:"namespaces"`
+}
+
+// Validate checks whether the role binding is valid.
+func (b *RoleBinding) Validate() error {
+	if err := b.Role.Validate(); err != nil {
+		return err
+	}
+	if len(b.Users) == 0 && len(b.CommonNames) == 0 {
+		return fmt.Errorf("neither users nor common-names is specified for the role %q", b.Role)
+	}
+	return nil
+}
+
+func (b *RoleBinding) matches(user, commonName, namespace string) bool {
+	if user != "" && !contains(b.Users, user) {
+		return false
+	}
+	if user == "" && (commonName == "" || !contains(b.CommonNames, commonName)) {
+		return false
+	}
+	if len(b.Namespaces) == 0 {
+		return true
+	}
+	// the namespace scoped role is never granted cluster-wide.
+	return namespace != "" && contains(b.Namespaces, namespace)
+}
+
+func contains(values []string, target string) bool {
+	for _, v := range values {
+		if v == target {
+			return true
+		}
+	}
+	return false
+}
+
+// IsRBACEnabled returns true if the HTTP API is authorized by the role bindings.
+func (s *Credential) IsRBACEnabled() bool {
+	return len(s.RoleBindings) != 0
+}
+
+// GetRole returns the highest role granted to the user, or the common name if
+// the user is empty, in the namespace. The empty namespace means cluster-wide,
+// only the bindings without namespaces are taken into account.
+func (s *Credential) GetRole(user, commonName, namespace string) Role {
+	role := RoleNone
+	for _, b := range s.RoleBindings {
+		if b.matches(user, commonName, namespace) && !role.Covers(b.Role) {
+			role = b.Role
+		}
+	}
+	return role
+}
+
+// IsClusterCommonName returns true if the common name is the one of the
+// certificate of a TiCDC server in the cluster.
+func (s *Credential) IsClusterCommonName(commonName string) bool {
+	if commonName == "" {
+		return false
+	}
+	if contains(s.ClusterCommonNames, commonName) {
+		return true
+	}
+	self, err := s.getSelfCommonName()
+	return err == nil && self == commonName
+}
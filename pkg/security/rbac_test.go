@@ -0,0 +1,66 @@
+// Copyright 2024 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package security
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRoleCovers(t *testing.T) {
+	t.Parallel()
+
+	require.True(t, RoleAdmin.Covers(RoleOperator))
+	require.True(t, RoleOperator.Covers(RoleViewer))
+	require.True(t, RoleViewer.Covers(RoleViewer))
+	require.False(t, RoleViewer.Covers(RoleOperator))
+	require.False(t, RoleNone.Covers(RoleViewer))
+
+	require.NoError(t, RoleViewer.Validate())
+	require.Error(t, RoleNone.Validate())
+	require.Error(t, Role("root").Validate())
+}
+
+func TestRoleBindingValidate(t *testing.T) {
+	t.Parallel()
+
+	require.NoError(t, (&RoleBinding{Role: RoleAdmin, Users: []string{"root"}}).Validate())
+	require.Error(t, (&RoleBinding{Role: RoleAdmin}).Validate())
+	require.Error(t, (&RoleBinding{Role: "root", Users: []string{"root"}}).Validate())
+}
+
+func TestGetRole(t *testing.T) {
+	t.Parallel()
+
+	credential := &Credential{}
+	require.False(t, credential.IsRBACEnabled())
+
+	credential.RoleBindings = []*RoleBinding{
+		{Role: RoleAdmin, Users: []string{"root"}},
+		{Role: RoleViewer, Users: []string{"dev"}, CommonNames: []string{"dashboard"}},
+		{Role: RoleOperator, Users: []string{"dev"}, Namespaces: []string{"dev"}},
+	}
+	require.True(t, credential.IsRBACEnabled())
+
+	require.Equal(t, RoleAdmin, credential.GetRole("root", "", ""))
+	require.Equal(t, RoleAdmin, credential.GetRole("root", "", "dev"))
+	require.Equal(t, RoleViewer, credential.GetRole("dev", "", ""))
+	require.Equal(t, RoleViewer, credential.GetRole("dev", "", "default"))
+	require.Equal(t, RoleOperator, credential.GetRole("dev", "", "dev"))
+	require.Equal(t, RoleViewer, credential.GetRole("", "dashboard", "dev"))
+	require.Equal(t, RoleNone, credential.GetRole("", "unknown", "dev"))
+	// the user takes precedence over the common name.
+	require.Equal(t, RoleNone, credential.GetRole("unknown", "dashboard", ""))
+}
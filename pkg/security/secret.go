@@ -0,0 +1,220 @@
+// Copyright 2024 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package security
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"os"
+	"strings"
+	"sync/atomic"
+
+	"github.com/pingcap/log"
+	"github.com/pingcap/tiflow/pkg/errors"
+	"go.uber.org/zap"
+)
+
+const (
+	// encryptedSecretPrefix is the prefix of the encrypted secrets, the format is
+	// `encrypted:v1:<key id>:<base64 encrypted data key>:<base64 encrypted secret>`.
+	encryptedSecretPrefix = "encrypted:v1:"
+	secretKeyLen          = 32
+	dataKeyLen            = 32
+)
+
+// SecretCipher encrypts the secrets with envelope encryption, each secret is
+// encrypted by a random data key, which is encrypted by the secret key in turn.
+// The id of the secret key is kept along with the secret, so the secrets
+// encrypted by the rotated keys can still be decrypted.
+type SecretCipher struct {
+	current *secretKey
+	keys    map[string]*secretKey
+}
+
+type secretKey struct {
+	id   string
+	aead cipher.AEAD
+}
+
+// NewSecretCipher creates a SecretCipher from the secret key files, the secrets are
+// encrypted by the current key, and decrypted by either the current or the rotated keys.
+func NewSecretCipher(keyPath string, rotatedKeyPaths []string) (*SecretCipher, error) {
+	current, err := loadSecretKey(keyPath)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	c := &SecretCipher{
+		current: current,
+		keys:    map[string]*secretKey{current.id: current},
+	}
+	for _, path := range rotatedKeyPaths {
+		key, err := loadSecretKey(path)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		c.keys[key.id] = key
+	}
+	return c, nil
+}
+
+// loadSecretKey reads the hex encoded 256-bit key from the file.
+func loadSecretKey(path string) (*secretKey, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errors.WrapError(errors.ErrInvalidSecretKey, err, path)
+	}
+	key, err := hex.DecodeString(strings.TrimSpace(string(content)))
+	if err != nil {
+		return nil, errors.WrapError(errors.ErrInvalidSecretKey, err, path)
+	}
+	if len(key) != secretKeyLen {
+		return nil, errors.ErrInvalidSecretKey.GenWithStack(
+			"the secret key in %s should be %d bytes, but got %d", path, secretKeyLen, len(key))
+	}
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, errors.WrapError(errors.ErrInvalidSecretKey, err, path)
+	}
+	sum := sha256.Sum256(key)
+	return &secretKey{id: hex.EncodeToString(sum[:4]), aead: aead}, nil
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+func seal(aead cipher.AEAD, plaintext []byte) ([]byte, error) {
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(plaintext)+aead.Overhead())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return aead.Seal(nonce, nonce, plaintext, nil), nil
+}
+
+func open(aead cipher.AEAD, ciphertext []byte) ([]byte, error) {
+	if len(ciphertext) < aead.NonceSize() {
+		return nil, errors.New("ciphertext is too short")
+	}
+	nonce, ciphertext := ciphertext[:aead.NonceSize()], ciphertext[aead.NonceSize():]
+	return aead.Open(nil, nonce, ciphertext, nil)
+}
+
+// Encrypt encrypts the secret by the current key,
+// the empty and the already encrypted secrets are returned as is.
+func (c *SecretCipher) Encrypt(secret string) (string, error) {
+	if secret == "" || IsEncryptedSecret(secret) {
+		return secret, nil
+	}
+	dataKey := make([]byte, dataKeyLen)
+	if _, err := rand.Read(dataKey); err != nil {
+		return "", errors.WrapError(errors.ErrEncryptSecretFailed, err)
+	}
+	aead, err := newAEAD(dataKey)
+	if err != nil {
+		return "", errors.WrapError(errors.ErrEncryptSecretFailed, err)
+	}
+	encryptedSecret, err := seal(aead, []byte(secret))
+	if err != nil {
+		return "", errors.WrapError(errors.ErrEncryptSecretFailed, err)
+	}
+	encryptedDataKey, err := seal(c.current.aead, dataKey)
+	if err != nil {
+		return "", errors.WrapError(errors.ErrEncryptSecretFailed, err)
+	}
+	return encryptedSecretPrefix + c.current.id + ":" +
+		base64.StdEncoding.EncodeToString(encryptedDataKey) + ":" +
+		base64.StdEncoding.EncodeToString(encryptedSecret), nil
+}
+
+// Decrypt decrypts the secret encrypted by Encrypt, the plaintext secrets are returned as is.
+func (c *SecretCipher) Decrypt(secret string) (string, error) {
+	if !IsEncryptedSecret(secret) {
+		return secret, nil
+	}
+	parts := strings.Split(strings.TrimPrefix(secret, encryptedSecretPrefix), ":")
+	if len(parts) != 3 {
+		return "", errors.ErrDecryptSecretFailed.GenWithStackByArgs("malformed encrypted secret")
+	}
+	key, ok := c.keys[parts[0]]
+	if !ok {
+		return "", errors.ErrDecryptSecretFailed.GenWithStackByArgs(
+			"the secret key " + parts[0] + " is not found, it may be rotated")
+	}
+	encryptedDataKey, err := base64.StdEncoding.DecodeString(parts[1])
+	if err != nil {
+		return "", errors.WrapError(errors.ErrDecryptSecretFailed, err, "malformed data key")
+	}
+	encryptedSecret, err := base64.StdEncoding.DecodeString(parts[2])
+	if err != nil {
+		return "", errors.WrapError(errors.ErrDecryptSecretFailed, err, "malformed secret")
+	}
+	dataKey, err := open(key.aead, encryptedDataKey)
+	if err != nil {
+		return "", errors.WrapError(errors.ErrDecryptSecretFailed, err, "decrypt data key failed")
+	}
+	aead, err := newAEAD(dataKey)
+	if err != nil {
+		return "", errors.WrapError(errors.ErrDecryptSecretFailed, err, "invalid data key")
+	}
+	plaintext, err := open(aead, encryptedSecret)
+	if err != nil {
+		return "", errors.WrapError(errors.ErrDecryptSecretFailed, err, "decrypt secret failed")
+	}
+	return string(plaintext), nil
+}
+
+// IsEncryptedSecret returns true if the secret is encrypted by SecretCipher.
+func IsEncryptedSecret(secret string) bool {
+	return strings.HasPrefix(secret, encryptedSecretPrefix)
+}
+
+// defaultSecretCipher is used to encrypt the secrets of the changefeeds
+// persisted in the meta store, it's nil if the secret key is not configured.
+var defaultSecretCipher atomic.Pointer[SecretCipher]
+
+// InitSecretCipher initializes the default SecretCipher from the credential,
+// the secrets are kept in plaintext if the secret key is not configured.
+func InitSecretCipher(credential *Credential) error {
+	if credential == nil || credential.SecretKeyPath == "" {
+		defaultSecretCipher.Store(nil)
+		return nil
+	}
+	c, err := NewSecretCipher(credential.SecretKeyPath, credential.RotatedSecretKeyPaths)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	defaultSecretCipher.Store(c)
+	log.Info("secrets of changefeeds will be encrypted",
+		zap.String("keyID", c.current.id),
+		zap.Int("rotatedKeys", len(c.keys)-1))
+	return nil
+}
+
+// GetSecretCipher returns the default SecretCipher, it's nil if the secret key is not configured.
+func GetSecretCipher() *SecretCipher {
+	return defaultSecretCipher.Load()
+}
+
+// SetSecretCipher sets the default SecretCipher, only used in tests.
+func SetSecretCipher(c *SecretCipher) {
+	defaultSecretCipher.Store(c)
+}
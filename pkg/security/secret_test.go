@@ -0,0 +1,107 @@
+// Copyright 2024 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package security
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/pingcap/tiflow/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSecretCipher(t *testing.T) {
+	t.Parallel()
+
+	keyPath, err := NewSecretKeyFile4Test()
+	require.NoError(t, err)
+	c, err := NewSecretCipher(keyPath, nil)
+	require.NoError(t, err)
+
+	encrypted, err := c.Encrypt("password")
+	require.NoError(t, err)
+	require.True(t, IsEncryptedSecret(encrypted))
+	require.NotContains(t, encrypted, "password")
+	// the data key is random, so the same secret is encrypted differently.
+	another, err := c.Encrypt("password")
+	require.NoError(t, err)
+	require.NotEqual(t, encrypted, another)
+	// the encrypted and the empty secrets are returned as is.
+	again, err := c.Encrypt(encrypted)
+	require.NoError(t, err)
+	require.Equal(t, encrypted, again)
+	empty, err := c.Encrypt("")
+	require.NoError(t, err)
+	require.Equal(t, "", empty)
+
+	plaintext, err := c.Decrypt(encrypted)
+	require.NoError(t, err)
+	require.Equal(t, "password", plaintext)
+	plaintext, err = c.Decrypt("password")
+	require.NoError(t, err)
+	require.Equal(t, "password", plaintext)
+
+	// the tampered secret can't be decrypted.
+	tampered := encrypted[:len(encrypted)-4] + "AAA="
+	_, err = c.Decrypt(tampered)
+	require.ErrorIs(t, err, errors.ErrDecryptSecretFailed)
+	_, err = c.Decrypt(encryptedSecretPrefix + "malformed")
+	require.ErrorIs(t, err, errors.ErrDecryptSecretFailed)
+}
+
+func TestSecretCipherRotation(t *testing.T) {
+	t.Parallel()
+
+	oldKeyPath, err := NewSecretKeyFile4Test()
+	require.NoError(t, err)
+	newKeyPath, err := NewSecretKeyFile4Test()
+	require.NoError(t, err)
+
+	oldCipher, err := NewSecretCipher(oldKeyPath, nil)
+	require.NoError(t, err)
+	encrypted, err := oldCipher.Encrypt("password")
+	require.NoError(t, err)
+
+	newCipher, err := NewSecretCipher(newKeyPath, nil)
+	require.NoError(t, err)
+	_, err = newCipher.Decrypt(encrypted)
+	require.ErrorIs(t, err, errors.ErrDecryptSecretFailed)
+
+	// the secrets encrypted by the rotated keys can still be decrypted.
+	newCipher, err = NewSecretCipher(newKeyPath, []string{oldKeyPath})
+	require.NoError(t, err)
+	plaintext, err := newCipher.Decrypt(encrypted)
+	require.NoError(t, err)
+	require.Equal(t, "password", plaintext)
+	reencrypted, err := newCipher.Encrypt(plaintext)
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(reencrypted, encryptedSecretPrefix+newCipher.current.id))
+}
+
+func TestLoadSecretKey(t *testing.T) {
+	t.Parallel()
+
+	_, err := NewSecretCipher("/not/exist/secret-key", nil)
+	require.ErrorIs(t, err, errors.ErrInvalidSecretKey)
+
+	path, err := WriteFile("ticdc-test-secret-key", []byte("not-hex"))
+	require.NoError(t, err)
+	_, err = NewSecretCipher(path, nil)
+	require.ErrorIs(t, err, errors.ErrInvalidSecretKey)
+
+	path, err = WriteFile("ticdc-test-secret-key", []byte("0123456789abcdef\n"))
+	require.NoError(t, err)
+	_, err = NewSecretCipher(path, nil)
+	require.ErrorIs(t, err, errors.ErrInvalidSecretKey)
+}
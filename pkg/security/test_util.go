@@ -19,6 +19,7 @@ import (
 	"crypto/rsa"
 	"crypto/x509"
 	"crypto/x509/pkix"
+	"encoding/hex"
 	"encoding/pem"
 	"math/big"
 	"net"
@@ -36,6 +37,15 @@ func WriteFile(fileName string, content []byte) (path string, err error) {
 	return cert.Name(), err
 }
 
+// NewSecretKeyFile4Test writes a random secret key to a temp file and returns its path.
+func NewSecretKeyFile4Test() (string, error) {
+	key := make([]byte, secretKeyLen)
+	if _, err := rand.Read(key); err != nil {
+		return "", err
+	}
+	return WriteFile("ticdc-test-secret-key", []byte(hex.EncodeToString(key)))
+}
+
 // NewServerCredential4Test return a Credential for testing
 func NewServerCredential4Test(cn string) (*CA, *Credential, error) {
 	var caPath, certPath, keyPath string
@@ -0,0 +1,554 @@
+// Copyright 2024 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cloudstorage
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/pingcap/tidb/br/pkg/storage"
+	"github.com/pingcap/tiflow/cdc/model"
+	"github.com/pingcap/tiflow/pkg/errors"
+)
+
+// The catalog indexes every table version and its data files written by the
+// sink, so the readers can find the schema applied to a data file without
+// scanning the whole storage. The shards of it are written by the sink writers,
+// and the root index is compacted from the shards by the DDL sink, which is the
+// only writer of it. The layout is as follows:
+//
+//	.catalog/index.json
+//	.catalog/<schema>/<table>/<tableVersion>/version.json
+//	.catalog/<schema>/<table>/<tableVersion>/data_<physicalTableID>_<seq>.json
+//
+// The catalog directory is dot-prefixed, which is never generated for a data
+// or schema file, since they are placed under the schema directory.
+// The version file is written by the DDL sink or before the first data file of
+// the table version. The data file records the range of the indexes of the data
+// files in each data directory written for the physical table, it's updated
+// after every data file is written. A physical table is replicated by a single
+// capture and written by a single DML worker of it at a time, so each data file
+// of the catalog has a single writer, even if the partitions of a table share
+// the same data directories when the partition separator is disabled.
+// The files of the catalog are never overwritten, every update of the data file
+// is written to a new file with the next sequence number and the previous one is
+// removed, so the compactor only reads the files it has not read before.
+const (
+	catalogDir             = ".catalog"
+	catalogIndexFileName   = "index.json"
+	catalogVersionFileName = "version.json"
+	catalogDataFilePrefix  = "data_"
+	catalogFileExtension   = ".json"
+)
+
+// IsCatalogFile checks whether the file is a file of the catalog.
+func IsCatalogFile(filePath string) bool {
+	if !strings.HasPrefix(filePath, catalogDir+"/") {
+		return false
+	}
+	name := path.Base(filePath)
+	return name == catalogVersionFileName || name == catalogIndexFileName ||
+		(strings.HasPrefix(name, catalogDataFilePrefix) && strings.HasSuffix(name, catalogFileExtension))
+}
+
+func generateCatalogIndexFilePath() string {
+	return path.Join(catalogDir, catalogIndexFileName)
+}
+
+func generateCatalogVersionDir(schema, table string, tableVersion uint64) string {
+	return path.Join(catalogDir, schema, table, fmt.Sprintf("%d", tableVersion))
+}
+
+func generateCatalogVersionFilePath(schema, table string, tableVersion uint64) string {
+	return path.Join(generateCatalogVersionDir(schema, table, tableVersion), catalogVersionFileName)
+}
+
+func generateCatalogDataFilePrefix(schema, table string, tableVersion uint64, tableID int64) string {
+	name := fmt.Sprintf("%s%d_", catalogDataFilePrefix, tableID)
+	return path.Join(generateCatalogVersionDir(schema, table, tableVersion), name)
+}
+
+func generateCatalogDataFilePath(schema, table string, tableVersion uint64, tableID int64, seq uint64) string {
+	prefix := generateCatalogDataFilePrefix(schema, table, tableVersion, tableID)
+	return fmt.Sprintf("%s%d%s", prefix, seq, catalogFileExtension)
+}
+
+// parseCatalogDataFileName parses the physical table id and the sequence number
+// from the name of the data file of the catalog.
+func parseCatalogDataFileName(name string) (tableID int64, seq uint64, err error) {
+	_, err = fmt.Sscanf(name, catalogDataFilePrefix+"%d_%d"+catalogFileExtension, &tableID, &seq)
+	if err != nil {
+		return 0, 0, errors.WrapError(errors.ErrStorageSinkInvalidFileName,
+			fmt.Errorf("'%s' is a invalid catalog file name: %w", name, err))
+	}
+	return tableID, seq, nil
+}
+
+// FileIndexRange is the range of the indexes of the data files in a data directory.
+type FileIndexRange struct {
+	First uint64 `json:"First"`
+	Last  uint64 `json:"Last"`
+}
+
+// merge extends the range to include the other one.
+func (r *FileIndexRange) merge(other FileIndexRange) {
+	if other.First < r.First {
+		r.First = other.First
+	}
+	if other.Last > r.Last {
+		r.Last = other.Last
+	}
+}
+
+// CatalogDataDir records the data files written to a data directory.
+type CatalogDataDir struct {
+	// PartitionNum is the partition of the data directory, it's 0 if the
+	// partition separator is disabled.
+	PartitionNum int64 `json:"PartitionNum,omitempty"`
+	// Date is the date of the data directory, it's an empty string if the
+	// date separator is none.
+	Date  string         `json:"Date"`
+	Files FileIndexRange `json:"Files"`
+}
+
+// CatalogDataFiles records the data files of a table version written for a
+// physical table, the partitions of a table are recorded separately.
+type CatalogDataFiles struct {
+	Schema       string `json:"Schema"`
+	Table        string `json:"Table"`
+	TableVersion uint64 `json:"TableVersion"`
+	TableID      int64  `json:"TableID"`
+	// Dirs are the data directories in the written order.
+	Dirs []*CatalogDataDir `json:"Dirs"`
+
+	// seq is the sequence number of the file the data files are recorded in.
+	seq uint64
+}
+
+// add records the data file of the index in the data directory, it returns
+// false if it's recorded already.
+func (d *CatalogDataFiles) add(partitionNum int64, date string, fileIndex uint64) bool {
+	for _, dir := range d.Dirs {
+		if dir.PartitionNum == partitionNum && dir.Date == date {
+			if fileIndex >= dir.Files.First && fileIndex <= dir.Files.Last {
+				return false
+			}
+			dir.Files.merge(FileIndexRange{First: fileIndex, Last: fileIndex})
+			return true
+		}
+	}
+	d.Dirs = append(d.Dirs, &CatalogDataDir{
+		PartitionNum: partitionNum,
+		Date:         date,
+		Files:        FileIndexRange{First: fileIndex, Last: fileIndex},
+	})
+	return true
+}
+
+// CatalogVersion records a version of a table in the catalog.
+type CatalogVersion struct {
+	Schema       string `json:"Schema"`
+	Table        string `json:"Table"`
+	TableVersion uint64 `json:"TableVersion"`
+	// SchemaFile is the path of the schema file of the version.
+	SchemaFile string `json:"SchemaFile"`
+	// DataFiles are the data files of each physical table, it's empty if
+	// no data file has been written.
+	DataFiles []*CatalogDataFiles `json:"DataFiles,omitempty"`
+}
+
+// DataFileRanges returns the range of the indexes of the data files in each
+// data directory of the version. The ranges of the physical tables sharing
+// the same data directory are merged.
+func (v *CatalogVersion) DataFileRanges() map[DmlPathKey]FileIndexRange {
+	ranges := make(map[DmlPathKey]FileIndexRange)
+	for _, files := range v.DataFiles {
+		for _, dir := range files.Dirs {
+			key := DmlPathKey{
+				SchemaPathKey: SchemaPathKey{
+					Schema:       v.Schema,
+					Table:        v.Table,
+					TableVersion: v.TableVersion,
+				},
+				PartitionNum: dir.PartitionNum,
+				Date:         dir.Date,
+			}
+			if r, ok := ranges[key]; ok {
+				r.merge(dir.Files)
+				ranges[key] = r
+			} else {
+				ranges[key] = dir.Files
+			}
+		}
+	}
+	return ranges
+}
+
+// ReadTableDefinition reads the table definition of the version from the schema file.
+func (v *CatalogVersion) ReadTableDefinition(
+	ctx context.Context, extStorage storage.ExternalStorage,
+) (*TableDefinition, error) {
+	data, err := extStorage.ReadFile(ctx, v.SchemaFile)
+	if err != nil {
+		return nil, errors.WrapError(errors.ErrExternalStorageAPI, err, "ReadFile")
+	}
+	var def TableDefinition
+	if err := json.Unmarshal(data, &def); err != nil {
+		return nil, errors.WrapError(errors.ErrUnmarshalFailed, err)
+	}
+	return &def, nil
+}
+
+// WriteCatalogVersion records the table version and its schema file in the catalog.
+// The schema level definitions are ignored since they don't belong to any table.
+func WriteCatalogVersion(
+	ctx context.Context, extStorage storage.ExternalStorage,
+	schema, table string, tableVersion uint64, schemaFile string,
+) error {
+	if table == "" {
+		return nil
+	}
+	data, err := json.Marshal(&CatalogVersion{
+		Schema:       schema,
+		Table:        table,
+		TableVersion: tableVersion,
+		SchemaFile:   schemaFile,
+	})
+	if err != nil {
+		return errors.WrapError(errors.ErrMarshalFailed, err)
+	}
+	return extStorage.WriteFile(ctx, generateCatalogVersionFilePath(schema, table, tableVersion), data)
+}
+
+// catalogIndex is the content of the root index file of the catalog.
+type catalogIndex struct {
+	// CheckpointTs is the checkpoint ts of the changefeed when the index is
+	// compacted, all the data files of the events not greater than it are
+	// recorded in the index.
+	CheckpointTs uint64            `json:"CheckpointTs"`
+	Versions     []*CatalogVersion `json:"Versions"`
+}
+
+// CatalogCompactor compacts the shards of the catalog to the root index.
+// It reads only the shards written since the last compaction.
+type CatalogCompactor struct {
+	storage storage.ExternalStorage
+	// checkpointTs is the checkpoint ts of the last compaction.
+	checkpointTs uint64
+	// versions maps the version directory to the version.
+	versions map[string]*CatalogVersion
+	// dataFiles maps the prefix of the data shards of a physical table to the
+	// content of the latest one.
+	dataFiles map[string]*CatalogDataFiles
+}
+
+// NewCatalogCompactor creates a CatalogCompactor.
+func NewCatalogCompactor(extStorage storage.ExternalStorage) *CatalogCompactor {
+	return &CatalogCompactor{
+		storage:   extStorage,
+		versions:  make(map[string]*CatalogVersion),
+		dataFiles: make(map[string]*CatalogDataFiles),
+	}
+}
+
+// Compact writes the root index of the catalog with all the shards written
+// before the changefeed reaches the checkpoint ts. The checkpoint ts of the
+// index never goes backward.
+func (c *CatalogCompactor) Compact(ctx context.Context, checkpointTs uint64) error {
+	if checkpointTs < c.checkpointTs {
+		checkpointTs = c.checkpointTs
+	}
+	err := c.storage.WalkDir(ctx, &storage.WalkOption{SubDir: catalogDir}, func(filePath string, _ int64) error {
+		name := path.Base(filePath)
+		if !IsCatalogFile(filePath) || name == catalogIndexFileName {
+			return nil
+		}
+		if name == catalogVersionFileName {
+			if _, ok := c.versions[path.Dir(filePath)]; ok {
+				return nil
+			}
+			v, err := readCatalogFile[CatalogVersion](ctx, c.storage, filePath)
+			if err != nil {
+				return err
+			}
+			c.versions[path.Dir(filePath)] = v
+			return nil
+		}
+
+		tableID, seq, err := parseCatalogDataFileName(name)
+		if err != nil {
+			return err
+		}
+		key := path.Join(path.Dir(filePath), fmt.Sprintf("%d", tableID))
+		if files, ok := c.dataFiles[key]; ok && files.seq >= seq {
+			return nil
+		}
+		files, err := readCatalogFile[CatalogDataFiles](ctx, c.storage, filePath)
+		if err != nil {
+			// the file may be removed after a newer one is written.
+			if exist, _ := c.storage.FileExists(ctx, filePath); !exist {
+				return nil
+			}
+			return err
+		}
+		files.seq = seq
+		c.dataFiles[key] = files
+		return nil
+	})
+	if err != nil {
+		return errors.Trace(err)
+	}
+
+	index := &catalogIndex{CheckpointTs: checkpointTs}
+	for _, v := range c.versions {
+		version := *v
+		version.DataFiles = nil
+		index.Versions = append(index.Versions, &version)
+	}
+	sort.Slice(index.Versions, func(i, j int) bool {
+		vi, vj := index.Versions[i], index.Versions[j]
+		if vi.Schema != vj.Schema {
+			return vi.Schema < vj.Schema
+		}
+		if vi.Table != vj.Table {
+			return vi.Table < vj.Table
+		}
+		return vi.TableVersion < vj.TableVersion
+	})
+	versions := make(map[string]*CatalogVersion, len(index.Versions))
+	for _, v := range index.Versions {
+		versions[generateCatalogVersionDir(v.Schema, v.Table, v.TableVersion)] = v
+	}
+	for _, files := range c.dataFiles {
+		v, ok := versions[generateCatalogVersionDir(files.Schema, files.Table, files.TableVersion)]
+		if !ok {
+			// the version file is not written yet, it's invisible to the readers.
+			continue
+		}
+		v.DataFiles = append(v.DataFiles, files)
+	}
+	for _, v := range index.Versions {
+		sort.Slice(v.DataFiles, func(i, j int) bool {
+			return v.DataFiles[i].TableID < v.DataFiles[j].TableID
+		})
+	}
+
+	data, err := json.Marshal(index)
+	if err != nil {
+		return errors.WrapError(errors.ErrMarshalFailed, err)
+	}
+	err = c.storage.WriteFile(ctx, generateCatalogIndexFilePath(), data)
+	if err != nil {
+		return errors.WrapError(errors.ErrExternalStorageAPI, err, "WriteFile")
+	}
+	c.checkpointTs = checkpointTs
+	return nil
+}
+
+// readCatalogFile reads and decodes a file of the catalog.
+func readCatalogFile[T any](
+	ctx context.Context, extStorage storage.ExternalStorage, filePath string,
+) (*T, error) {
+	data, err := extStorage.ReadFile(ctx, filePath)
+	if err != nil {
+		return nil, errors.WrapError(errors.ErrExternalStorageAPI, err, "ReadFile")
+	}
+	v := new(T)
+	if err := json.Unmarshal(data, v); err != nil {
+		return nil, errors.WrapError(errors.ErrUnmarshalFailed, err)
+	}
+	return v, nil
+}
+
+// Catalog is the index of all the table versions in the storage.
+type Catalog struct {
+	checkpointTs uint64
+	// versions maps the quoted table name to its versions sorted by the table version.
+	versions map[string][]*CatalogVersion
+}
+
+// LoadCatalog reads the catalog from its root index in the storage,
+// it returns nil if the index has not been compacted yet.
+func LoadCatalog(ctx context.Context, extStorage storage.ExternalStorage) (*Catalog, error) {
+	exist, err := extStorage.FileExists(ctx, generateCatalogIndexFilePath())
+	if err != nil {
+		return nil, errors.WrapError(errors.ErrExternalStorageAPI, err, "FileExists")
+	}
+	if !exist {
+		return nil, nil
+	}
+	data, err := extStorage.ReadFile(ctx, generateCatalogIndexFilePath())
+	if err != nil {
+		return nil, errors.WrapError(errors.ErrExternalStorageAPI, err, "ReadFile")
+	}
+	var index catalogIndex
+	if err := json.Unmarshal(data, &index); err != nil {
+		return nil, errors.WrapError(errors.ErrUnmarshalFailed, err)
+	}
+
+	// the versions are sorted by the table version in the index.
+	c := &Catalog{
+		checkpointTs: index.CheckpointTs,
+		versions:     make(map[string][]*CatalogVersion),
+	}
+	for _, v := range index.Versions {
+		key := (&SchemaPathKey{Schema: v.Schema, Table: v.Table}).GetKey()
+		c.versions[key] = append(c.versions[key], v)
+	}
+	return c, nil
+}
+
+// CheckpointTs returns the checkpoint ts of the changefeed when the catalog is
+// compacted, all the data files of the events not greater than it are recorded.
+func (c *Catalog) CheckpointTs() uint64 {
+	return c.checkpointTs
+}
+
+// Tables returns the names of all the tables in the catalog.
+func (c *Catalog) Tables() []model.TableName {
+	tables := make([]model.TableName, 0, len(c.versions))
+	for _, vs := range c.versions {
+		tables = append(tables, model.TableName{Schema: vs[0].Schema, Table: vs[0].Table})
+	}
+	sort.Slice(tables, func(i, j int) bool {
+		if tables[i].Schema != tables[j].Schema {
+			return tables[i].Schema < tables[j].Schema
+		}
+		return tables[i].Table < tables[j].Table
+	})
+	return tables
+}
+
+// Versions returns all the versions of the table sorted by the table version.
+func (c *Catalog) Versions(schema, table string) []*CatalogVersion {
+	return c.versions[(&SchemaPathKey{Schema: schema, Table: table}).GetKey()]
+}
+
+// VersionAt returns the version of the table applied to the events at the commit ts,
+// which is the latest version not greater than the commit ts, or nil if not found.
+func (c *Catalog) VersionAt(schema, table string, commitTs uint64) *CatalogVersion {
+	vs := c.Versions(schema, table)
+	i := sort.Search(len(vs), func(i int) bool {
+		return vs[i].TableVersion > commitTs
+	})
+	if i == 0 {
+		return nil
+	}
+	return vs[i-1]
+}
+
+// ensureCatalogVersion writes the version file of the catalog if it doesn't exist,
+// which happens if the schema file is written before the catalog is introduced.
+func (f *FilePathGenerator) ensureCatalogVersion(
+	ctx context.Context, schema, table string, tableVersion uint64, schemaFile string,
+) error {
+	exist, err := f.storage.FileExists(ctx, generateCatalogVersionFilePath(schema, table, tableVersion))
+	if err != nil {
+		return err
+	}
+	if exist {
+		return nil
+	}
+	return WriteCatalogVersion(ctx, f.storage, schema, table, tableVersion, schemaFile)
+}
+
+// UpdateCatalog records the last data file generated for the table in the
+// catalog, it should be called after the data file is written to the storage.
+func (f *FilePathGenerator) UpdateCatalog(
+	ctx context.Context, tbl VersionedTableName, date string,
+) error {
+	idx, ok := f.fileIndex[tbl]
+	if !ok {
+		return errors.ErrInternalCheckFailed.GenWithStackByArgs(
+			"no data file is generated before updating the catalog")
+	}
+	files, ok := f.catalog[tbl]
+	if !ok {
+		var err error
+		files, err = f.loadCatalogDataFiles(ctx, tbl)
+		if err != nil {
+			return err
+		}
+		f.catalog[tbl] = files
+	}
+
+	var partitionNum int64
+	if f.config.EnablePartitionSeparator && tbl.TableNameWithPhysicTableID.IsPartition {
+		partitionNum = tbl.TableNameWithPhysicTableID.TableID
+	}
+	if !files.add(partitionNum, date, idx.index) {
+		return nil
+	}
+	data, err := json.Marshal(files)
+	if err != nil {
+		return errors.WrapError(errors.ErrMarshalFailed, err)
+	}
+	prevSeq := files.seq
+	files.seq++
+	err = f.storage.WriteFile(ctx, generateCatalogDataFilePath(
+		files.Schema, files.Table, files.TableVersion, files.TableID, files.seq), data)
+	if err != nil {
+		return err
+	}
+	if prevSeq == 0 {
+		return nil
+	}
+	return f.storage.DeleteFile(ctx, generateCatalogDataFilePath(
+		files.Schema, files.Table, files.TableVersion, files.TableID, prevSeq))
+}
+
+// loadCatalogDataFiles loads the data files of the table recorded in the catalog,
+// which are written by the same table before the changefeed is restarted.
+func (f *FilePathGenerator) loadCatalogDataFiles(
+	ctx context.Context, tbl VersionedTableName,
+) (*CatalogDataFiles, error) {
+	files := &CatalogDataFiles{
+		Schema:       tbl.TableNameWithPhysicTableID.Schema,
+		Table:        tbl.TableNameWithPhysicTableID.Table,
+		TableVersion: f.versionMap[tbl],
+		TableID:      tbl.TableNameWithPhysicTableID.TableID,
+	}
+	var lastSeq uint64
+	prefix := generateCatalogDataFilePrefix(files.Schema, files.Table, files.TableVersion, files.TableID)
+	err := f.storage.WalkDir(ctx, &storage.WalkOption{
+		SubDir:    path.Dir(prefix),
+		ObjPrefix: prefix,
+	}, func(filePath string, _ int64) error {
+		_, seq, err := parseCatalogDataFileName(path.Base(filePath))
+		if err != nil {
+			return err
+		}
+		if seq > lastSeq {
+			lastSeq = seq
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if lastSeq == 0 {
+		return files, nil
+	}
+	files, err = readCatalogFile[CatalogDataFiles](ctx, f.storage, generateCatalogDataFilePath(
+		files.Schema, files.Table, files.TableVersion, files.TableID, lastSeq))
+	if err != nil {
+		return nil, err
+	}
+	files.seq = lastSeq
+	return files, nil
+}
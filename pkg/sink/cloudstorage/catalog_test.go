@@ -0,0 +1,212 @@
+// Copyright 2024 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cloudstorage
+
+import (
+	"context"
+	"path"
+	"sort"
+	"testing"
+
+	"github.com/pingcap/tidb/br/pkg/storage"
+	timodel "github.com/pingcap/tidb/pkg/meta/model"
+	pmodel "github.com/pingcap/tidb/pkg/parser/model"
+	"github.com/pingcap/tidb/pkg/parser/mysql"
+	"github.com/pingcap/tidb/pkg/parser/types"
+	"github.com/pingcap/tiflow/cdc/model"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsCatalogFile(t *testing.T) {
+	t.Parallel()
+
+	require.True(t, IsCatalogFile(".catalog/index.json"))
+	require.True(t, IsCatalogFile(".catalog/test/table1/100/version.json"))
+	require.True(t, IsCatalogFile(".catalog/test/table1/100/data_20_1.json"))
+	require.False(t, IsCatalogFile("test/table1/100/CDC000001.json"))
+	require.False(t, IsCatalogFile("test/table1/meta/schema_100_0123456789.json"))
+	require.False(t, IsCatalogFile(".catalog/test/table1/100/CDC000001.json"))
+	require.False(t, IsCatalogFile("catalog/test/table1/100/version.json"))
+}
+
+// countingStorage counts the files written to the storage.
+type countingStorage struct {
+	storage.ExternalStorage
+	written map[string]int
+}
+
+func (s *countingStorage) WriteFile(ctx context.Context, name string, data []byte) error {
+	s.written[name]++
+	return s.ExternalStorage.WriteFile(ctx, name, data)
+}
+
+// readCountingStorage counts the files read from the storage.
+type readCountingStorage struct {
+	storage.ExternalStorage
+	read map[string]int
+}
+
+func (s *readCountingStorage) ReadFile(ctx context.Context, name string) ([]byte, error) {
+	s.read[name]++
+	return s.ExternalStorage.ReadFile(ctx, name)
+}
+
+func TestCatalog(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	dir := t.TempDir()
+	f := testFilePathGenerator(ctx, t, dir)
+	extStorage := &countingStorage{ExternalStorage: f.storage, written: make(map[string]int)}
+	f.storage = extStorage
+
+	ft := types.NewFieldType(mysql.TypeLong)
+	ft.SetFlag(mysql.PriKeyFlag | mysql.NotNullFlag)
+	tableInfo := &model.TableInfo{
+		TableInfo: &timodel.TableInfo{Columns: []*timodel.ColumnInfo{
+			{Name: pmodel.NewCIStr("Id"), FieldType: *ft},
+		}},
+		Version: 100,
+		TableName: model.TableName{
+			Schema:  "test",
+			Table:   "table1",
+			TableID: 20,
+		},
+	}
+	table := VersionedTableName{
+		TableNameWithPhysicTableID: tableInfo.TableName,
+		TableInfoVersion:           tableInfo.Version,
+	}
+	writeDataFile := func(table VersionedTableName, date string) {
+		dataFile, err := f.GenerateDataFilePath(ctx, table, date)
+		require.NoError(t, err)
+		require.NoError(t, f.storage.WriteFile(ctx, dataFile, []byte("data")))
+		indexFile := f.GenerateIndexFilePath(table, date)
+		require.NoError(t, f.storage.WriteFile(ctx, indexFile, []byte(path.Base(dataFile)+"\n")))
+		require.NoError(t, f.UpdateCatalog(ctx, table, date))
+	}
+	require.NoError(t, f.CheckOrWriteSchema(ctx, table, tableInfo))
+	for i := 0; i < 3; i++ {
+		writeDataFile(table, "2024-01-01")
+	}
+	writeDataFile(table, "2024-01-02")
+	// every update is written to a new file and the previous one is removed.
+	listShards := func() []string {
+		var shards []string
+		err := f.storage.WalkDir(ctx, &storage.WalkOption{SubDir: ".catalog/test/table1/100"},
+			func(filePath string, _ int64) error {
+				shards = append(shards, path.Base(filePath))
+				return nil
+			})
+		require.NoError(t, err)
+		sort.Strings(shards)
+		return shards
+	}
+	require.Equal(t, 1, extStorage.written[".catalog/test/table1/100/data_20_4.json"])
+	require.Equal(t, []string{"data_20_4.json", "version.json"}, listShards())
+
+	// the partitions are recorded separately.
+	partition := VersionedTableName{
+		TableNameWithPhysicTableID: model.TableName{
+			Schema: "test", Table: "table1", TableID: 21, IsPartition: true,
+		},
+		TableInfoVersion: tableInfo.Version,
+	}
+	require.NoError(t, f.CheckOrWriteSchema(ctx, partition, tableInfo))
+	writeDataFile(partition, "2024-01-02")
+	require.Equal(t, []string{"data_20_4.json", "data_21_1.json", "version.json"}, listShards())
+
+	// the catalog is invisible until it's compacted.
+	catalog, err := LoadCatalog(ctx, f.storage)
+	require.NoError(t, err)
+	require.Nil(t, catalog)
+	compactor := NewCatalogCompactor(f.storage)
+	require.NoError(t, compactor.Compact(ctx, 150))
+
+	// a new version written by the ddl sink, and no data file is written.
+	var def TableDefinition
+	def.FromTableInfo(tableInfo, 200, false)
+	schemaFile, err := def.GenerateSchemaFilePath()
+	require.NoError(t, err)
+	require.NoError(t, WriteCatalogVersion(ctx, f.storage, "test", "table1", 200, schemaFile))
+	// the schema level definitions are ignored.
+	require.NoError(t, WriteCatalogVersion(ctx, f.storage, "test", "", 300, "test/meta/schema_300_0123456789.json"))
+	require.NoError(t, compactor.Compact(ctx, 250))
+
+	catalog, err = LoadCatalog(ctx, f.storage)
+	require.NoError(t, err)
+	require.Equal(t, uint64(250), catalog.CheckpointTs())
+	require.Equal(t, []model.TableName{{Schema: "test", Table: "table1"}}, catalog.Tables())
+	versions := catalog.Versions("test", "table1")
+	require.Len(t, versions, 2)
+	require.Equal(t, uint64(100), versions[0].TableVersion)
+	require.Equal(t, uint64(200), versions[1].TableVersion)
+	key := func(partitionNum int64, date string) DmlPathKey {
+		return DmlPathKey{
+			SchemaPathKey: SchemaPathKey{Schema: "test", Table: "table1", TableVersion: 100},
+			PartitionNum:  partitionNum,
+			Date:          date,
+		}
+	}
+	require.Equal(t, map[DmlPathKey]FileIndexRange{
+		key(0, "2024-01-01"):  {First: 1, Last: 3},
+		key(0, "2024-01-02"):  {First: 1, Last: 1},
+		key(21, "2024-01-02"): {First: 1, Last: 1},
+	}, versions[0].DataFileRanges())
+	require.Empty(t, versions[1].DataFileRanges())
+
+	require.Nil(t, catalog.VersionAt("test", "table1", 99))
+	require.Equal(t, versions[0], catalog.VersionAt("test", "table1", 199))
+	require.Equal(t, versions[1], catalog.VersionAt("test", "table1", 200))
+	require.Nil(t, catalog.VersionAt("test", "table2", 200))
+
+	tableDef, err := versions[0].ReadTableDefinition(ctx, f.storage)
+	require.NoError(t, err)
+	require.Equal(t, "table1", tableDef.Table)
+	require.Equal(t, uint64(100), tableDef.TableVersion)
+
+	// the checkpoint ts of the index never goes backward.
+	require.NoError(t, compactor.Compact(ctx, 200))
+	catalog, err = LoadCatalog(ctx, f.storage)
+	require.NoError(t, err)
+	require.Equal(t, uint64(250), catalog.CheckpointTs())
+
+	// the recorded files are recovered by a new generator after the changefeed
+	// is restarted, and the ranges are extended by the new files.
+	f = testFilePathGenerator(ctx, t, dir)
+	f.storage = extStorage
+	require.NoError(t, f.CheckOrWriteSchema(ctx, table, tableInfo))
+	writeDataFile(table, "2024-01-02")
+	writeDataFile(table, "2024-01-03")
+	require.Equal(t, []string{"data_20_6.json", "data_21_1.json", "version.json"}, listShards())
+
+	// the new files are visible after the catalog is compacted again.
+	require.NoError(t, compactor.Compact(ctx, 350))
+	catalog, err = LoadCatalog(ctx, f.storage)
+	require.NoError(t, err)
+	require.Equal(t, map[DmlPathKey]FileIndexRange{
+		key(0, "2024-01-01"):  {First: 1, Last: 3},
+		key(0, "2024-01-02"):  {First: 1, Last: 2},
+		key(0, "2024-01-03"):  {First: 1, Last: 1},
+		key(21, "2024-01-02"): {First: 1, Last: 1},
+	}, catalog.Versions("test", "table1")[0].DataFileRanges())
+
+	// only the new shards are read by the compactor.
+	reading := &readCountingStorage{ExternalStorage: f.storage, read: make(map[string]int)}
+	compactor.storage = reading
+	writeDataFile(table, "2024-01-03")
+	require.NoError(t, compactor.Compact(ctx, 400))
+	require.Equal(t, map[string]int{".catalog/test/table1/100/data_20_7.json": 1}, reading.read)
+}
@@ -143,6 +143,8 @@ type FilePathGenerator struct {
 
 	hasher     *hash.PositionInertia
 	versionMap map[VersionedTableName]uint64
+	// catalog caches the data files recorded in the catalog of each table.
+	catalog map[VersionedTableName]*CatalogDataFiles
 }
 
 // NewFilePathGenerator creates a FilePathGenerator.
@@ -168,6 +170,7 @@ func NewFilePathGenerator(
 		fileIndex:    make(map[VersionedTableName]*indexWithDate),
 		hasher:       hash.NewPositionInertia(),
 		versionMap:   make(map[VersionedTableName]uint64),
+		catalog:      make(map[VersionedTableName]*CatalogDataFiles),
 	}
 }
 
@@ -205,7 +208,7 @@ func (f *FilePathGenerator) CheckOrWriteSchema(
 	}
 	if exist {
 		f.versionMap[table] = table.TableInfoVersion
-		return nil
+		return f.ensureCatalogVersion(ctx, def.Schema, def.Table, table.TableInfoVersion, tblSchemaFile)
 	}
 
 	// walk the table meta path to find the last schema file
@@ -244,7 +247,8 @@ func (f *FilePathGenerator) CheckOrWriteSchema(
 	// Case 2: the table meta path is not empty.
 	if schemaFileCnt != 0 && lastVersion != 0 {
 		f.versionMap[table] = lastVersion
-		return nil
+		lastSchemaFile := generateSchemaFilePath(def.Schema, def.Table, lastVersion, checksum)
+		return f.ensureCatalogVersion(ctx, def.Schema, def.Table, lastVersion, lastSchemaFile)
 	}
 
 	// Case 3: the table meta path is empty, which happens when:
@@ -262,7 +266,10 @@ func (f *FilePathGenerator) CheckOrWriteSchema(
 		return err
 	}
 	f.versionMap[table] = table.TableInfoVersion
-	return f.storage.WriteFile(ctx, tblSchemaFile, encodedDetail)
+	if err := f.storage.WriteFile(ctx, tblSchemaFile, encodedDetail); err != nil {
+		return err
+	}
+	return WriteCatalogVersion(ctx, f.storage, def.Schema, def.Table, table.TableInfoVersion, tblSchemaFile)
 }
 
 // SetClock is used for unit test
@@ -315,6 +315,9 @@ func (a *BatchEncoder) Build() (messages []*common.Message) {
 const (
 	insertOperation = "c"
 	updateOperation = "u"
+	// deleteOperation is only used by the object container file,
+	// the delete event sent to the MQ has no value part.
+	deleteOperation = "d"
 )
 
 func getOperation(e *model.RowChangedEvent) string {
@@ -322,6 +325,8 @@ func getOperation(e *model.RowChangedEvent) string {
 		return insertOperation
 	} else if e.IsUpdate() {
 		return updateOperation
+	} else if e.IsDelete() {
+		return deleteOperation
 	}
 	return ""
 }
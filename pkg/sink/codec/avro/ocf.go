@@ -0,0 +1,304 @@
+// Copyright 2024 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package avro
+
+import (
+	"bytes"
+	"crypto/md5"
+	"encoding/binary"
+	"encoding/json"
+
+	"github.com/linkedin/goavro/v2"
+	"github.com/pingcap/errors"
+	"github.com/pingcap/tiflow/cdc/model"
+	"github.com/pingcap/tiflow/pkg/config"
+	cerror "github.com/pingcap/tiflow/pkg/errors"
+	"github.com/pingcap/tiflow/pkg/sink/codec"
+	"github.com/pingcap/tiflow/pkg/sink/codec/common"
+)
+
+// The cloud storage sink writes the avro object container files, see
+// https://avro.apache.org/docs/1.11.1/specification/#object-container-files
+// The file header is generated from the table info by the dml worker, and each
+// encoded transaction is a data block. The sync marker is derived from the schema,
+// so that the blocks encoded by different encoders can be put into the same file.
+var ocfMagic = []byte{'O', 'b', 'j', 1}
+
+const (
+	ocfSchemaKey = "avro.schema"
+	ocfCodecKey  = "avro.codec"
+	ocfNullCodec = "null"
+)
+
+// TxnEventEncoder encodes the txn events into the avro object container file blocks.
+type TxnEventEncoder struct {
+	// encoder is only used to generate the schema and convert the columns,
+	// no schema registry is required since the schema is embedded in the file.
+	encoder *BatchEncoder
+
+	// tableInfo is the table of the block being encoded.
+	tableInfo  *model.TableInfo
+	codec      *goavro.Codec
+	syncMarker []byte
+
+	blockBuf  *bytes.Buffer
+	batchSize int
+	messages  []*common.Message
+	callback  func()
+}
+
+// AppendTxnEvent implements the TxnEventEncoder interface
+func (t *TxnEventEncoder) AppendTxnEvent(
+	e *model.SingleTableTxn,
+	callback func(),
+) error {
+	for _, row := range e.Rows {
+		if err := t.appendRow(row); err != nil {
+			return errors.Trace(err)
+		}
+	}
+	t.callback = callback
+	return nil
+}
+
+func (t *TxnEventEncoder) appendRow(e *model.RowChangedEvent) error {
+	if t.tableInfo != e.TableInfo {
+		// the block only contains the rows of the same schema.
+		t.flushBlock()
+		schema, err := t.encoder.tableInfo2AvroSchema(e.TableInfo)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		avroCodec, err := goavro.NewCodec(schema)
+		if err != nil {
+			return cerror.WrapError(cerror.ErrAvroEncodeFailed, err)
+		}
+		t.tableInfo, t.codec, t.syncMarker = e.TableInfo, avroCodec, ocfSyncMarker(schema)
+	}
+
+	columns := e.Columns
+	if e.IsDelete() {
+		columns = e.PreColumns
+	}
+	native, err := t.encoder.columns2AvroData(avroEncodeInput{
+		TableInfo: e.TableInfo,
+		columns:   columns,
+	})
+	if err != nil {
+		return errors.Trace(err)
+	}
+	native = t.encoder.nativeValueWithExtension(native, e)
+
+	bin, err := t.codec.BinaryFromNative(nil, native)
+	if err != nil {
+		return cerror.WrapError(cerror.ErrAvroEncodeToBinary, err)
+	}
+	t.blockBuf.Write(bin)
+	t.batchSize++
+	return nil
+}
+
+// flushBlock appends the buffered rows as a data block to the messages.
+func (t *TxnEventEncoder) flushBlock() {
+	if t.batchSize == 0 {
+		return
+	}
+	value := make([]byte, 0, t.blockBuf.Len()+2*binary.MaxVarintLen64+len(t.syncMarker))
+	value = binary.AppendVarint(value, int64(t.batchSize))
+	value = binary.AppendVarint(value, int64(t.blockBuf.Len()))
+	value = append(value, t.blockBuf.Bytes()...)
+	value = append(value, t.syncMarker...)
+
+	msg := common.NewMsg(config.ProtocolAvro, nil, value, 0, model.MessageTypeRow, nil, nil)
+	msg.SetRowsCount(t.batchSize)
+	t.messages = append(t.messages, msg)
+
+	if t.blockBuf.Cap() > codec.MemBufShrinkThreshold {
+		t.blockBuf = &bytes.Buffer{}
+	} else {
+		t.blockBuf.Reset()
+	}
+	t.batchSize = 0
+}
+
+// Build implements the TxnEventEncoder interface
+func (t *TxnEventEncoder) Build() []*common.Message {
+	t.flushBlock()
+	messages := t.messages
+	if len(messages) != 0 {
+		messages[len(messages)-1].Callback = t.callback
+	}
+	t.messages = nil
+	t.callback = nil
+	return messages
+}
+
+// tableInfo2AvroSchema returns the value schema of all columns in the table.
+func (a *BatchEncoder) tableInfo2AvroSchema(tableInfo *model.TableInfo) (string, error) {
+	// copy the column infos, since they may be sorted by the schema generation.
+	colInfos := append(tableInfo.GetColInfosForRowChangedEvent()[:0:0],
+		tableInfo.GetColInfosForRowChangedEvent()...)
+	columns := make([]*model.ColumnData, 0, len(colInfos))
+	for _, colInfo := range colInfos {
+		columns = append(columns, &model.ColumnData{ColumnID: colInfo.ID})
+	}
+	return a.value2AvroSchema(tableInfo.TableName, avroEncodeInput{
+		TableInfo: tableInfo,
+		columns:   columns,
+		colInfos:  colInfos,
+	})
+}
+
+// ocfSyncMarker returns the sync marker of the file written by the schema.
+func ocfSyncMarker(schema string) []byte {
+	sum := md5.Sum([]byte(schema))
+	return sum[:]
+}
+
+// encodeOCFHeader encodes the object container file header with the schema embedded.
+func encodeOCFHeader(schema string) []byte {
+	appendBytes := func(buf []byte, b []byte) []byte {
+		buf = binary.AppendVarint(buf, int64(len(b)))
+		return append(buf, b...)
+	}
+
+	header := append([]byte{}, ocfMagic...)
+	// the file metadata is a map with a single block of 2 entries.
+	header = binary.AppendVarint(header, 2)
+	header = appendBytes(header, []byte(ocfSchemaKey))
+	header = appendBytes(header, []byte(schema))
+	header = appendBytes(header, []byte(ocfCodecKey))
+	header = appendBytes(header, []byte(ocfNullCodec))
+	header = binary.AppendVarint(header, 0)
+	return append(header, ocfSyncMarker(schema)...)
+}
+
+type txnEventEncoderBuilder struct {
+	namespace string
+	config    *common.Config
+}
+
+// NewTxnEventEncoderBuilder creates an avro txnEventEncoderBuilder for the cloud storage sink.
+func NewTxnEventEncoderBuilder(config *common.Config) codec.TxnEventEncoderBuilder {
+	// the operation and the commit ts are always required to restore the events.
+	cfg := *config
+	cfg.EnableTiDBExtension = true
+	return &txnEventEncoderBuilder{
+		namespace: config.ChangefeedID.Namespace,
+		config:    &cfg,
+	}
+}
+
+// Build an avro TxnEventEncoder
+func (b *txnEventEncoderBuilder) Build() codec.TxnEventEncoder {
+	return &TxnEventEncoder{
+		encoder:  &BatchEncoder{namespace: b.namespace, config: b.config},
+		blockBuf: &bytes.Buffer{},
+	}
+}
+
+// EncodeFileHeader implements the FileHeaderEncoder interface
+func (b *txnEventEncoderBuilder) EncodeFileHeader(tableInfo *model.TableInfo) ([]byte, error) {
+	encoder := &BatchEncoder{namespace: b.namespace, config: b.config}
+	schema, err := encoder.tableInfo2AvroSchema(tableInfo)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	return encodeOCFHeader(schema), nil
+}
+
+// ocfDecoder decodes the rows in the avro object container file.
+type ocfDecoder struct {
+	reader *goavro.OCFReader
+	schema map[string]interface{}
+	// keyMap holds the handle key column names of the table.
+	keyMap map[string]interface{}
+}
+
+// NewOCFDecoder creates an avro object container file decoder,
+// tableInfo is used to recognize the handle key columns, it can be nil.
+func NewOCFDecoder(
+	_ *common.Config, tableInfo *model.TableInfo, content []byte,
+) (codec.RowEventDecoder, error) {
+	reader, err := goavro.NewOCFReader(bytes.NewReader(content))
+	if err != nil {
+		return nil, cerror.ErrAvroInvalidMessage.GenWithStackByArgs(err.Error())
+	}
+	schema := make(map[string]interface{})
+	if err := json.Unmarshal([]byte(reader.Codec().Schema()), &schema); err != nil {
+		return nil, errors.Trace(err)
+	}
+
+	keyMap := make(map[string]interface{})
+	if tableInfo != nil {
+		for _, col := range tableInfo.Columns {
+			if tableInfo.ForceGetColumnFlagType(col.ID).IsHandleKey() {
+				keyMap[common.SanitizeName(col.Name.O)] = struct{}{}
+			}
+		}
+	}
+	return &ocfDecoder{reader: reader, schema: schema, keyMap: keyMap}, nil
+}
+
+// AddKeyValue implements the RowEventDecoder interface,
+// the content is set when creating the decoder.
+func (d *ocfDecoder) AddKeyValue(_, _ []byte) error {
+	return cerror.ErrAvroInvalidMessage.GenWithStackByArgs(
+		"the object container file decoder doesn't accept key value")
+}
+
+// HasNext implements the RowEventDecoder interface,
+// the object container file only contains the row changed events.
+func (d *ocfDecoder) HasNext() (model.MessageType, bool, error) {
+	if !d.reader.Scan() {
+		if err := d.reader.Err(); err != nil {
+			return model.MessageTypeUnknown, false, cerror.ErrAvroInvalidMessage.GenWithStackByArgs(err.Error())
+		}
+		return model.MessageTypeUnknown, false, nil
+	}
+	return model.MessageTypeRow, true, nil
+}
+
+// NextRowChangedEvent implements the RowEventDecoder interface
+func (d *ocfDecoder) NextRowChangedEvent() (*model.RowChangedEvent, error) {
+	native, err := d.reader.Read()
+	if err != nil {
+		return nil, cerror.ErrAvroInvalidMessage.GenWithStackByArgs(err.Error())
+	}
+	valueMap, ok := native.(map[string]interface{})
+	if !ok {
+		return nil, cerror.ErrAvroInvalidMessage.GenWithStackByArgs("the row is not a record")
+	}
+
+	event, err := assembleEvent(d.keyMap, valueMap, d.schema, false)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	if op, _ := valueMap[tidbOp].(string); op == deleteOperation {
+		event.PreColumns, event.Columns = event.Columns, nil
+	}
+	return event, nil
+}
+
+// NextResolvedEvent implements the RowEventDecoder interface
+func (d *ocfDecoder) NextResolvedEvent() (uint64, error) {
+	return 0, cerror.ErrAvroInvalidMessage.GenWithStackByArgs(
+		"the object container file has no resolved event")
+}
+
+// NextDDLEvent implements the RowEventDecoder interface
+func (d *ocfDecoder) NextDDLEvent() (*model.DDLEvent, error) {
+	return nil, cerror.ErrAvroInvalidMessage.GenWithStackByArgs(
+		"the object container file has no DDL event")
+}
@@ -0,0 +1,98 @@
+// Copyright 2024 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package avro
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/pingcap/tiflow/cdc/entry"
+	"github.com/pingcap/tiflow/cdc/model"
+	"github.com/pingcap/tiflow/pkg/config"
+	"github.com/pingcap/tiflow/pkg/sink/codec"
+	"github.com/pingcap/tiflow/pkg/sink/codec/common"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOCFEncodeDecode(t *testing.T) {
+	helper := entry.NewSchemaTestHelper(t)
+	defer helper.Close()
+
+	_ = helper.DDL2Event(`create table test.t(a int primary key, b varchar(10), c double)`)
+	insertEvent := helper.DML2Event(`insert into test.t values(1, "aa", null)`, "test", "t")
+	updateEvent := *helper.DML2Event(`insert into test.t values(2, "bb", 1.5)`, "test", "t")
+	updateEvent.PreColumns = insertEvent.Columns
+	deleteEvent := *insertEvent
+	deleteEvent.PreColumns = insertEvent.Columns
+	deleteEvent.Columns = nil
+
+	codecConfig := common.NewConfig(config.ProtocolAvro)
+	codecConfig.ChangefeedID = model.DefaultChangeFeedID("test")
+	builder := NewTxnEventEncoderBuilder(codecConfig)
+	header, err := builder.(codec.FileHeaderEncoder).EncodeFileHeader(insertEvent.TableInfo)
+	require.NoError(t, err)
+
+	// the blocks encoded by different encoders can be written to the same file.
+	buf := bytes.NewBuffer(header)
+	called := 0
+	events := []*model.RowChangedEvent{insertEvent, &updateEvent, &deleteEvent}
+	for _, rows := range [][]*model.RowChangedEvent{events[:1], events[1:]} {
+		encoder := builder.Build()
+		err = encoder.AppendTxnEvent(&model.SingleTableTxn{
+			TableInfo: insertEvent.TableInfo,
+			Rows:      rows,
+		}, func() { called++ })
+		require.NoError(t, err)
+		messages := encoder.Build()
+		require.Len(t, messages, 1)
+		require.Equal(t, len(rows), messages[0].GetRowsCount())
+		messages[0].Callback()
+		buf.Write(messages[0].Value)
+	}
+	require.Equal(t, 2, called)
+
+	expectedValues := [][]interface{}{
+		{int32(1), "aa", nil},
+		{int32(2), "bb", 1.5},
+		{int32(1), "aa", nil},
+	}
+	decoder, err := NewOCFDecoder(codecConfig, insertEvent.TableInfo, buf.Bytes())
+	require.NoError(t, err)
+	for i, event := range events {
+		tp, hasNext, err := decoder.HasNext()
+		require.NoError(t, err)
+		require.True(t, hasNext)
+		require.Equal(t, model.MessageTypeRow, tp)
+
+		decoded, err := decoder.NextRowChangedEvent()
+		require.NoError(t, err)
+		require.Equal(t, event.CommitTs, decoded.CommitTs)
+		require.Equal(t, "test", decoded.TableInfo.GetSchemaName())
+		require.Equal(t, "t", decoded.TableInfo.GetTableName())
+		require.Equal(t, event.IsDelete(), decoded.IsDelete())
+
+		columns := decoded.Columns
+		if event.IsDelete() {
+			columns = decoded.PreColumns
+		}
+		require.Len(t, columns, len(expectedValues[i]))
+		for j, col := range columns {
+			require.Equal(t, expectedValues[i][j], col.Value)
+		}
+		require.True(t, decoded.TableInfo.ForceGetColumnFlagType(columns[0].ColumnID).IsHandleKey())
+	}
+	_, hasNext, err := decoder.HasNext()
+	require.NoError(t, err)
+	require.False(t, hasNext)
+}
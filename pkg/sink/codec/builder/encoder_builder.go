@@ -27,6 +27,7 @@ import (
 	"github.com/pingcap/tiflow/pkg/sink/codec/debezium"
 	"github.com/pingcap/tiflow/pkg/sink/codec/maxwell"
 	"github.com/pingcap/tiflow/pkg/sink/codec/open"
+	"github.com/pingcap/tiflow/pkg/sink/codec/protobuf"
 	"github.com/pingcap/tiflow/pkg/sink/codec/simple"
 )
 
@@ -52,6 +53,8 @@ func NewRowEventEncoderBuilder(
 		return debezium.NewBatchEncoderBuilder(cfg, config.GetGlobalServerConfig().ClusterID), nil
 	case config.ProtocolSimple:
 		return simple.NewBuilder(ctx, cfg)
+	case config.ProtocolProtobuf:
+		return protobuf.NewBatchEncoderBuilder(ctx, cfg)
 	default:
 		return nil, cerror.ErrSinkUnknownProtocol.GenWithStackByArgs(cfg.Protocol)
 	}
@@ -66,6 +69,8 @@ func NewTxnEventEncoderBuilder(
 		return csv.NewTxnEventEncoderBuilder(c), nil
 	case config.ProtocolCanalJSON:
 		return canal.NewJSONTxnEventEncoderBuilder(c), nil
+	case config.ProtocolAvro:
+		return avro.NewTxnEventEncoderBuilder(c), nil
 	default:
 		return nil, cerror.ErrSinkUnknownProtocol.GenWithStackByArgs(c.Protocol)
 	}
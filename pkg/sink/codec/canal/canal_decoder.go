@@ -0,0 +1,237 @@
+// Copyright 2024 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package canal
+
+import (
+	"strconv"
+
+	"github.com/pingcap/tiflow/cdc/model"
+	cerror "github.com/pingcap/tiflow/pkg/errors"
+	"github.com/pingcap/tiflow/pkg/sink/codec"
+	"github.com/pingcap/tiflow/pkg/sink/codec/common"
+	canal "github.com/pingcap/tiflow/proto/canal"
+	"github.com/tikv/client-go/v2/oracle"
+)
+
+// protobufBatchDecoder decodes the binary canal packets.
+type protobufBatchDecoder struct {
+	config *common.Config
+
+	// entries are the encoded entries in the packet which are not decoded yet.
+	entries [][]byte
+
+	// header and rowChange belong to the entry being decoded.
+	header    *canal.Header
+	rowChange *canal.RowChange
+	// rowDatas are the rows in the rowChange which are not decoded yet.
+	rowDatas    []*canal.RowData
+	watermarkTs uint64
+}
+
+// NewProtobufBatchDecoder returns a decoder for the binary canal protocol.
+func NewProtobufBatchDecoder(codecConfig *common.Config) codec.RowEventDecoder {
+	return &protobufBatchDecoder{config: codecConfig}
+}
+
+// AddKeyValue implements the RowEventDecoder interface
+func (b *protobufBatchDecoder) AddKeyValue(_, value []byte) error {
+	packet := &canal.Packet{}
+	if err := packet.Unmarshal(value); err != nil {
+		return cerror.WrapError(cerror.ErrCanalDecodeFailed, err)
+	}
+	if packet.GetType() != canal.PacketType_MESSAGES {
+		return cerror.ErrCanalDecodeFailed.GenWithStack(
+			"unexpected canal packet type %s", packet.GetType())
+	}
+	messages := &canal.Messages{}
+	if err := messages.Unmarshal(packet.GetBody()); err != nil {
+		return cerror.WrapError(cerror.ErrCanalDecodeFailed, err)
+	}
+	b.entries = append(b.entries, messages.GetMessages()...)
+	return nil
+}
+
+// HasNext implements the RowEventDecoder interface
+func (b *protobufBatchDecoder) HasNext() (model.MessageType, bool, error) {
+	if len(b.rowDatas) > 0 {
+		return model.MessageTypeRow, true, nil
+	}
+	b.header, b.rowChange = nil, nil
+
+	for len(b.entries) > 0 {
+		entry := &canal.Entry{}
+		err := entry.Unmarshal(b.entries[0])
+		b.entries = b.entries[1:]
+		if err != nil {
+			return model.MessageTypeUnknown, false, cerror.WrapError(cerror.ErrCanalDecodeFailed, err)
+		}
+
+		switch entry.GetEntryType() {
+		case canal.EntryType_ENTRYHEARTBEAT:
+			ts, ok, err := getHeaderProp(entry.GetHeader(), tidbWaterMarkTsKey)
+			if err != nil {
+				return model.MessageTypeUnknown, false, err
+			}
+			if ok {
+				b.watermarkTs = ts
+				return model.MessageTypeResolved, true, nil
+			}
+		case canal.EntryType_ROWDATA:
+			rowChange := &canal.RowChange{}
+			if err := rowChange.Unmarshal(entry.GetStoreValue()); err != nil {
+				return model.MessageTypeUnknown, false, cerror.WrapError(cerror.ErrCanalDecodeFailed, err)
+			}
+			b.header, b.rowChange = entry.GetHeader(), rowChange
+			if rowChange.GetIsDdl() {
+				return model.MessageTypeDDL, true, nil
+			}
+			if len(rowChange.GetRowDatas()) > 0 {
+				b.rowDatas = rowChange.GetRowDatas()
+				return model.MessageTypeRow, true, nil
+			}
+		}
+		// other entries, such as the transaction begin and end, are skipped.
+	}
+	return model.MessageTypeUnknown, false, nil
+}
+
+// NextRowChangedEvent implements the RowEventDecoder interface
+// `HasNext` should be called before this.
+func (b *protobufBatchDecoder) NextRowChangedEvent() (*model.RowChangedEvent, error) {
+	if len(b.rowDatas) == 0 {
+		return nil, cerror.ErrCanalDecodeFailed.
+			GenWithStack("not found row changed event message")
+	}
+	rowData := b.rowDatas[0]
+	b.rowDatas = b.rowDatas[1:]
+
+	commitTs, err := b.getCommitTs()
+	if err != nil {
+		return nil, err
+	}
+	result := &model.RowChangedEvent{CommitTs: commitTs}
+
+	pkNames := make(map[string]struct{})
+	preCols := canalColumns2RowChangeColumns(rowData.GetBeforeColumns(), pkNames)
+	cols := canalColumns2RowChangeColumns(rowData.GetAfterColumns(), pkNames)
+	schema, table := b.header.GetSchemaName(), b.header.GetTableName()
+	switch b.rowChange.GetEventType() {
+	case canal.EventType_DELETE:
+		result.TableInfo = model.BuildTableInfoWithPKNames4Test(schema, table, preCols, pkNames)
+		result.PreColumns = model.Columns2ColumnDatas(preCols, result.TableInfo)
+	case canal.EventType_INSERT:
+		result.TableInfo = model.BuildTableInfoWithPKNames4Test(schema, table, cols, pkNames)
+		result.Columns = model.Columns2ColumnDatas(cols, result.TableInfo)
+	case canal.EventType_UPDATE:
+		if len(preCols) != len(cols) {
+			return nil, cerror.ErrCanalDecodeFailed.GenWithStack(
+				"column count mismatch, before: %d, after: %d", len(preCols), len(cols))
+		}
+		result.TableInfo = model.BuildTableInfoWithPKNames4Test(schema, table, cols, pkNames)
+		result.Columns = model.Columns2ColumnDatas(cols, result.TableInfo)
+		result.PreColumns = model.Columns2ColumnDatas(preCols, result.TableInfo)
+	default:
+		return nil, cerror.ErrCanalDecodeFailed.GenWithStack(
+			"unexpected event type %s of the row changed event", b.rowChange.GetEventType())
+	}
+	return result, nil
+}
+
+// canalColumns2RowChangeColumns converts the canal columns,
+// and collects the names of the key columns.
+func canalColumns2RowChangeColumns(
+	columns []*canal.Column, pkNames map[string]struct{},
+) []*model.Column {
+	if len(columns) == 0 {
+		return nil
+	}
+	result := make([]*model.Column, 0, len(columns))
+	for _, c := range columns {
+		var value interface{}
+		if !c.GetIsNull() {
+			value = c.GetValue()
+		}
+		// the value is formatted as the same as canal-json.
+		result = append(result, canalJSONFormatColumn(value, c.GetName(), c.GetMysqlType()))
+		if c.GetIsKey() {
+			pkNames[c.GetName()] = struct{}{}
+		}
+	}
+	return result
+}
+
+// NextDDLEvent implements the RowEventDecoder interface
+// `HasNext` should be called before this.
+func (b *protobufBatchDecoder) NextDDLEvent() (*model.DDLEvent, error) {
+	if b.rowChange == nil || !b.rowChange.GetIsDdl() {
+		return nil, cerror.ErrCanalDecodeFailed.
+			GenWithStack("not found ddl event message")
+	}
+	commitTs, err := b.getCommitTs()
+	if err != nil {
+		return nil, err
+	}
+
+	result := new(model.DDLEvent)
+	result.CommitTs = commitTs
+	result.TableInfo = new(model.TableInfo)
+	result.TableInfo.TableName = model.TableName{
+		Schema: b.header.GetSchemaName(),
+		Table:  b.header.GetTableName(),
+	}
+	result.Query = b.rowChange.GetSql()
+	result.Type = getDDLActionType(result.Query)
+	b.header, b.rowChange = nil, nil
+	return result, nil
+}
+
+// NextResolvedEvent implements the RowEventDecoder interface
+// `HasNext` should be called before this.
+func (b *protobufBatchDecoder) NextResolvedEvent() (uint64, error) {
+	if b.watermarkTs == 0 {
+		return 0, cerror.ErrCanalDecodeFailed.
+			GenWithStack("not found resolved event message")
+	}
+	ts := b.watermarkTs
+	b.watermarkTs = 0
+	return ts, nil
+}
+
+// getCommitTs returns the commit ts carried by the TiDB extension,
+// it falls back to the execute time, which only has the physical part.
+func (b *protobufBatchDecoder) getCommitTs() (uint64, error) {
+	ts, ok, err := getHeaderProp(b.header, tidbCommitTsKey)
+	if err != nil {
+		return 0, err
+	}
+	if ok {
+		return ts, nil
+	}
+	return oracle.ComposeTS(b.header.GetExecuteTime(), 0), nil
+}
+
+// getHeaderProp returns the ts value of the prop in the header.
+func getHeaderProp(header *canal.Header, key string) (uint64, bool, error) {
+	for _, p := range header.GetProps() {
+		if p.GetKey() != key {
+			continue
+		}
+		ts, err := strconv.ParseUint(p.GetValue(), 10, 64)
+		if err != nil {
+			return 0, false, cerror.WrapError(cerror.ErrCanalDecodeFailed, err)
+		}
+		return ts, true, nil
+	}
+	return 0, false, nil
+}
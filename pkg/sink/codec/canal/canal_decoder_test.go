@@ -0,0 +1,145 @@
+// Copyright 2024 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package canal
+
+import (
+	"context"
+	"testing"
+
+	timodel "github.com/pingcap/tidb/pkg/meta/model"
+	"github.com/pingcap/tiflow/cdc/entry"
+	"github.com/pingcap/tiflow/cdc/model"
+	"github.com/pingcap/tiflow/pkg/config"
+	"github.com/pingcap/tiflow/pkg/sink/codec/common"
+	"github.com/stretchr/testify/require"
+	"github.com/tikv/client-go/v2/oracle"
+)
+
+func TestCanalProtobufDecoderRowChangedEvents(t *testing.T) {
+	helper := entry.NewSchemaTestHelper(t)
+	defer helper.Close()
+
+	_ = helper.DDL2Event(`create table test.t(a int primary key, b varchar(10), c int)`)
+	insertEvent := helper.DML2Event(`insert into test.t values(1, "aa", null)`, "test", "t")
+	updateEvent := *insertEvent
+	updateEvent.PreColumns = insertEvent.Columns
+	deleteEvent := *insertEvent
+	deleteEvent.PreColumns = insertEvent.Columns
+	deleteEvent.Columns = nil
+
+	for _, enableTiDBExtension := range []bool{false, true} {
+		codecConfig := common.NewConfig(config.ProtocolCanal)
+		codecConfig.EnableTiDBExtension = enableTiDBExtension
+		encoder := newBatchEncoder(codecConfig)
+		events := []*model.RowChangedEvent{insertEvent, &updateEvent, &deleteEvent}
+		for _, event := range events {
+			err := encoder.AppendRowChangedEvent(context.Background(), "", event, nil)
+			require.NoError(t, err)
+		}
+		messages := encoder.Build()
+		require.Len(t, messages, 1)
+
+		decoder := NewProtobufBatchDecoder(codecConfig)
+		err := decoder.AddKeyValue(messages[0].Key, messages[0].Value)
+		require.NoError(t, err)
+
+		expectedCommitTs := insertEvent.CommitTs
+		if !enableTiDBExtension {
+			// only the physical part is kept in the execute time.
+			expectedCommitTs = oracle.ComposeTS(oracle.ExtractPhysical(insertEvent.CommitTs), 0)
+		}
+		for _, event := range events {
+			tp, hasNext, err := decoder.HasNext()
+			require.NoError(t, err)
+			require.True(t, hasNext)
+			require.Equal(t, model.MessageTypeRow, tp)
+
+			decoded, err := decoder.NextRowChangedEvent()
+			require.NoError(t, err)
+			require.Equal(t, expectedCommitTs, decoded.CommitTs)
+			require.Equal(t, "test", decoded.TableInfo.GetSchemaName())
+			require.Equal(t, "t", decoded.TableInfo.GetTableName())
+			require.Equal(t, event.IsInsert(), decoded.IsInsert())
+			require.Equal(t, event.IsUpdate(), decoded.IsUpdate())
+			require.Equal(t, event.IsDelete(), decoded.IsDelete())
+
+			columns := decoded.Columns
+			if decoded.IsDelete() {
+				columns = decoded.PreColumns
+			}
+			require.Len(t, columns, 3)
+			require.Equal(t, int64(1), columns[0].Value)
+			require.Equal(t, "aa", columns[1].Value)
+			require.Nil(t, columns[2].Value)
+			require.True(t, decoded.TableInfo.ForceGetColumnFlagType(columns[0].ColumnID).IsPrimaryKey())
+		}
+		_, hasNext, err := decoder.HasNext()
+		require.NoError(t, err)
+		require.False(t, hasNext)
+	}
+}
+
+func TestCanalProtobufDecoderDDLAndResolvedEvents(t *testing.T) {
+	helper := entry.NewSchemaTestHelper(t)
+	defer helper.Close()
+
+	ddlEvent := helper.DDL2Event(`create table test.t(a int primary key)`)
+
+	codecConfig := common.NewConfig(config.ProtocolCanal)
+	encoder := newBatchEncoder(codecConfig)
+	// the watermark is not sent without the TiDB extension.
+	msg, err := encoder.EncodeCheckpointEvent(ddlEvent.CommitTs + 1)
+	require.NoError(t, err)
+	require.Nil(t, msg)
+
+	codecConfig.EnableTiDBExtension = true
+	encoder = newBatchEncoder(codecConfig)
+	decoder := NewProtobufBatchDecoder(codecConfig)
+
+	msg, err = encoder.EncodeDDLEvent(ddlEvent)
+	require.NoError(t, err)
+	err = decoder.AddKeyValue(msg.Key, msg.Value)
+	require.NoError(t, err)
+	tp, hasNext, err := decoder.HasNext()
+	require.NoError(t, err)
+	require.True(t, hasNext)
+	require.Equal(t, model.MessageTypeDDL, tp)
+
+	decodedDDL, err := decoder.NextDDLEvent()
+	require.NoError(t, err)
+	require.Equal(t, ddlEvent.CommitTs, decodedDDL.CommitTs)
+	require.Equal(t, ddlEvent.Query, decodedDDL.Query)
+	// the canal protocol only tells the action type of the schema DDLs.
+	require.Equal(t, timodel.ActionNone, decodedDDL.Type)
+	require.Equal(t, "test", decodedDDL.TableInfo.TableName.Schema)
+	require.Equal(t, "t", decodedDDL.TableInfo.TableName.Table)
+
+	msg, err = encoder.EncodeCheckpointEvent(ddlEvent.CommitTs + 1)
+	require.NoError(t, err)
+	require.NotNil(t, msg)
+	err = decoder.AddKeyValue(msg.Key, msg.Value)
+	require.NoError(t, err)
+	tp, hasNext, err = decoder.HasNext()
+	require.NoError(t, err)
+	require.True(t, hasNext)
+	require.Equal(t, model.MessageTypeResolved, tp)
+
+	ts, err := decoder.NextResolvedEvent()
+	require.NoError(t, err)
+	require.Equal(t, ddlEvent.CommitTs+1, ts)
+
+	_, hasNext, err = decoder.HasNext()
+	require.NoError(t, err)
+	require.False(t, hasNext)
+}
@@ -34,6 +34,12 @@ type BatchEncoder struct {
 	callbackBuf  []func()
 	packet       *canal.Packet
 	entryBuilder *canalEntryBuilder
+	// built are the messages built before the pending rows, a new message is
+	// started once the pending rows are going to exceed the max message bytes.
+	// Notice: canal-json encodes a message for each row, and the open protocol
+	// and craft encoders split their batches already, so only this encoder and
+	// the maxwell one split the oversize batches.
+	built []*common.Message
 
 	config *common.Config
 }
@@ -41,8 +47,15 @@ type BatchEncoder struct {
 // EncodeCheckpointEvent implements the RowEventEncoder interface
 func (d *BatchEncoder) EncodeCheckpointEvent(ts uint64) (*common.Message, error) {
 	// For canal now, there is no such a corresponding type to ResolvedEvent so far.
-	// Therefore, the event is ignored.
-	return nil, nil
+	// Therefore, the event is ignored, unless the TiDB extension is enabled.
+	if !d.config.EnableTiDBExtension {
+		return nil, nil
+	}
+	value, err := encodeSingleEntryPacket(d.entryBuilder.fromCheckpointEvent(ts))
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	return common.NewResolvedMsg(config.ProtocolCanal, nil, value, ts), nil
 }
 
 // AppendRowChangedEvent implements the RowEventEncoder interface
@@ -60,6 +73,11 @@ func (d *BatchEncoder) AppendRowChangedEvent(
 	if err != nil {
 		return cerror.WrapError(cerror.ErrCanalEncodeFailed, err)
 	}
+	// the packet wraps the messages with a few bytes of the headers.
+	if len(d.messages.Messages) != 0 && d.config.MaxMessageBytes > 0 &&
+		proto.Size(d.messages)+len(b)+common.MaxRecordOverhead+16 > d.config.MaxMessageBytes {
+		d.built = append(d.built, d.buildMessage())
+	}
 	d.messages.Messages = append(d.messages.Messages, b)
 	if callback != nil {
 		d.callbackBuf = append(d.callbackBuf, callback)
@@ -73,6 +91,16 @@ func (d *BatchEncoder) EncodeDDLEvent(e *model.DDLEvent) (*common.Message, error
 	if err != nil {
 		return nil, errors.Trace(err)
 	}
+	b, err := encodeSingleEntryPacket(entry)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+
+	return common.NewDDLMsg(config.ProtocolCanal, nil, b, e), nil
+}
+
+// encodeSingleEntryPacket encodes the packet which only contains the entry.
+func encodeSingleEntryPacket(entry *canal.Entry) ([]byte, error) {
 	b, err := proto.Marshal(entry)
 	if err != nil {
 		return nil, cerror.WrapError(cerror.ErrCanalEncodeFailed, err)
@@ -96,16 +124,22 @@ func (d *BatchEncoder) EncodeDDLEvent(e *model.DDLEvent) (*common.Message, error
 	if err != nil {
 		return nil, cerror.WrapError(cerror.ErrCanalEncodeFailed, err)
 	}
-
-	return common.NewDDLMsg(config.ProtocolCanal, nil, b, e), nil
+	return b, nil
 }
 
 // Build implements the RowEventEncoder interface
 func (d *BatchEncoder) Build() []*common.Message {
-	rowCount := len(d.messages.Messages)
-	if rowCount == 0 {
-		return nil
+	ret := d.built
+	d.built = nil
+	if len(d.messages.Messages) != 0 {
+		ret = append(ret, d.buildMessage())
 	}
+	return ret
+}
+
+// buildMessage builds a message of the pending rows.
+func (d *BatchEncoder) buildMessage() *common.Message {
+	rowCount := len(d.messages.Messages)
 
 	err := d.refreshPacketBody()
 	if err != nil {
@@ -130,7 +164,7 @@ func (d *BatchEncoder) Build() []*common.Message {
 		}
 		d.callbackBuf = make([]func(), 0)
 	}
-	return []*common.Message{ret}
+	return ret
 }
 
 // refreshPacketBody() marshals the messages to the packet body
@@ -42,6 +42,13 @@ const (
 	CanalServerEncode    string = "UTF-8"
 )
 
+// the keys of the header props which carry the TiDB extension information,
+// they are only set if the TiDB extension is enabled.
+const (
+	tidbCommitTsKey    = "tidbCommitTs"
+	tidbWaterMarkTsKey = "tidbWaterMarkTs"
+)
+
 type canalEntryBuilder struct {
 	bytesDecoder *encoding.Decoder // default charset is ISO-8859-1
 	config       *common.Config
@@ -74,6 +81,12 @@ func (b *canalEntryBuilder) buildHeader(commitTs uint64, schema string, table st
 		}
 		h.Props = append(h.Props, p)
 	}
+	if b.config != nil && b.config.EnableTiDBExtension {
+		h.Props = append(h.Props, &canal.Pair{
+			Key:   tidbCommitTsKey,
+			Value: strconv.FormatUint(commitTs, 10),
+		})
+	}
 	return h
 }
 
@@ -234,6 +247,24 @@ func (b *canalEntryBuilder) fromDDLEvent(e *model.DDLEvent) (*canal.Entry, error
 	return entry, nil
 }
 
+// fromCheckpointEvent builds the heartbeat entry carrying the watermark,
+// it's only used if the TiDB extension is enabled.
+func (b *canalEntryBuilder) fromCheckpointEvent(ts uint64) *canal.Entry {
+	header := &canal.Header{
+		VersionPresent: &canal.Header_Version{Version: CanalProtocolVersion},
+		ServerenCode:   CanalServerEncode,
+		ExecuteTime:    convertToCanalTs(ts),
+		Props: []*canal.Pair{{
+			Key:   tidbWaterMarkTsKey,
+			Value: strconv.FormatUint(ts, 10),
+		}},
+	}
+	return &canal.Entry{
+		Header:           header,
+		EntryTypePresent: &canal.Entry_EntryType{EntryType: canal.EntryType_ENTRYHEARTBEAT},
+	}
+}
+
 // convert ts in tidb to timestamp(in ms) in canal
 func convertToCanalTs(commitTs uint64) int64 {
 	return int64(commitTs >> 18)
@@ -443,7 +443,7 @@ func (c *JSONRowEventEncoder) AppendRowChangedEvent(
 		}
 
 		if c.config.LargeMessageHandle.EnableClaimCheck() {
-			claimCheckFileName := claimcheck.NewFileName()
+			claimCheckFileName := c.claimCheck.NewFileName(e.CommitTs)
 			if err := c.claimCheck.WriteMessage(ctx, m.Key, m.Value, claimCheckFileName); err != nil {
 				return errors.Trace(err)
 			}
@@ -25,6 +25,7 @@ import (
 	"github.com/pingcap/tiflow/cdc/model"
 	"github.com/pingcap/tiflow/pkg/config"
 	cerror "github.com/pingcap/tiflow/pkg/errors"
+	"github.com/pingcap/tiflow/pkg/sink"
 	"github.com/pingcap/tiflow/pkg/util"
 	"go.uber.org/zap"
 )
@@ -65,6 +66,9 @@ type Config struct {
 	ContentCompatible bool
 
 	// for sinking to cloud storage
+	// IsStorageSink is true if the events are written to the cloud storage,
+	// avro doesn't need the schema registry since the schema is embedded in the file.
+	IsStorageSink        bool
 	Delimiter            string
 	Quote                string
 	NullString           string
@@ -222,7 +226,12 @@ func (c *Config) Apply(sinkURI *url.URL, replicaConfig *config.ReplicaConfig) er
 		return cerror.ErrCodecInvalidConfig.GenWithStack(
 			`force-replicate must be disabled, when using avro protocol`)
 	}
+	if c.Protocol == config.ProtocolProtobuf && replicaConfig.ForceReplicate {
+		return cerror.ErrCodecInvalidConfig.GenWithStack(
+			`force-replicate must be disabled, when using protobuf protocol`)
+	}
 
+	c.IsStorageSink = sinkURI != nil && sink.IsStorageScheme(sinkURI.Scheme)
 	if replicaConfig.Sink != nil {
 		c.Terminator = util.GetOrZero(replicaConfig.Sink.Terminator)
 		if replicaConfig.Sink.CSVConfig != nil {
@@ -339,9 +348,10 @@ func (c *Config) WithChangefeedID(id model.ChangeFeedID) *Config {
 // Validate the Config
 func (c *Config) Validate() error {
 	if c.EnableTiDBExtension &&
-		!(c.Protocol == config.ProtocolCanalJSON || c.Protocol == config.ProtocolAvro) {
+		!(c.Protocol == config.ProtocolCanalJSON || c.Protocol == config.ProtocolCanal ||
+			c.Protocol == config.ProtocolAvro || c.Protocol == config.ProtocolProtobuf) {
 		log.Warn("ignore invalid config, enable-tidb-extension"+
-			"only supports canal-json/avro protocol",
+			"only supports canal-json/canal/avro/protobuf protocol",
 			zap.Bool("enableTidbExtension", c.EnableTiDBExtension),
 			zap.String("protocol", c.Protocol.String()))
 	}
@@ -355,7 +365,7 @@ func (c *Config) Validate() error {
 			)
 		}
 
-		if c.AvroConfluentSchemaRegistry == "" && c.AvroGlueSchemaRegistry == nil {
+		if !c.IsStorageSink && c.AvroConfluentSchemaRegistry == "" && c.AvroGlueSchemaRegistry == nil {
 			return cerror.ErrCodecInvalidConfig.GenWithStack(
 				`Avro protocol requires parameter "%s" or "%s" to specify the schema registry`,
 				codecOPTAvroSchemaRegistry,
@@ -396,6 +406,21 @@ func (c *Config) Validate() error {
 		}
 	}
 
+	if c.Protocol == config.ProtocolProtobuf {
+		if c.AvroGlueSchemaRegistry != nil {
+			return cerror.ErrCodecInvalidConfig.GenWithStack(
+				`Protobuf protocol only supports the confluent schema registry, "%s" is not supported`,
+				coderOPTAvroGlueSchemaRegistry,
+			)
+		}
+		if c.AvroConfluentSchemaRegistry == "" {
+			return cerror.ErrCodecInvalidConfig.GenWithStack(
+				`Protobuf protocol requires parameter "%s" to specify the schema registry`,
+				codecOPTAvroSchemaRegistry,
+			)
+		}
+	}
+
 	if c.MaxMessageBytes <= 0 {
 		return cerror.ErrCodecInvalidConfig.Wrap(
 			errors.Errorf("invalid max-message-bytes %d", c.MaxMessageBytes),
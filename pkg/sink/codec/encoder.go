@@ -71,6 +71,13 @@ type TxnEventEncoderBuilder interface {
 	Build() TxnEventEncoder
 }
 
+// FileHeaderEncoder is optionally implemented by the TxnEventEncoderBuilder,
+// if the data file must start with a header, such as the avro object container file.
+type FileHeaderEncoder interface {
+	// EncodeFileHeader returns the header written at the beginning of each data file of the table.
+	EncodeFileHeader(tableInfo *model.TableInfo) ([]byte, error)
+}
+
 // IsColumnValueEqual checks whether the preValue and updatedValue are equal.
 func IsColumnValueEqual(preValue, updatedValue interface{}) bool {
 	if preValue == nil || updatedValue == nil {
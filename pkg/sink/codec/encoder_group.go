@@ -25,6 +25,7 @@ import (
 	"github.com/pingcap/tiflow/cdc/sink/dmlsink"
 	"github.com/pingcap/tiflow/pkg/config"
 	"github.com/pingcap/tiflow/pkg/sink/codec/common"
+	"github.com/pingcap/tiflow/pkg/tracing"
 	"github.com/pingcap/tiflow/pkg/util"
 	"go.uber.org/zap"
 	"golang.org/x/sync/errgroup"
@@ -42,8 +43,16 @@ type EncoderGroup interface {
 	// AddEvents add events into the group and encode them by one of the encoders in the group.
 	// Note: The caller should make sure all events should belong to the same topic and partition.
 	AddEvents(ctx context.Context, key model.TopicPartitionKey, events ...*dmlsink.RowChangeCallbackableEvent) error
+	// AddTxnBoundary adds a future without any message after the futures of the
+	// events added so far, which indicates all of them belong to the finished
+	// upstream transactions.
+	AddTxnBoundary(ctx context.Context) error
 	// Output returns a channel produce futures
 	Output() <-chan *future
+	// SetMemoryQuota sets the memory quota of the changefeed, which decides
+	// when the pending encoded messages are spilled to the local disk if the
+	// spilling is enabled.
+	SetMemoryQuota(quota SpillQuota)
 }
 
 type encoderGroup struct {
@@ -58,6 +67,12 @@ type encoderGroup struct {
 
 	outputCh        chan *future
 	bootstrapWorker *bootstrapWorker
+
+	// spillQueue is not nil if the pending encoded messages are spilled to the
+	// local disk under memory pressure, the futures in outputCh are moved to it
+	// once they are ready, and sent to spillOutputCh in the same order.
+	spillQueue    *spillQueue
+	spillOutputCh chan *future
 }
 
 // NewEncoderGroup creates a new EncoderGroup instance
@@ -94,7 +109,7 @@ func NewEncoderGroup(
 		)
 	}
 
-	return &encoderGroup{
+	g := &encoderGroup{
 		changefeedID:    changefeedID,
 		builder:         builder,
 		concurrency:     concurrency,
@@ -103,6 +118,12 @@ func NewEncoderGroup(
 		outputCh:        outCh,
 		bootstrapWorker: bootstrapWorker,
 	}
+	if threshold := util.GetOrZero(cfg.MQSpillThreshold); threshold > 0 {
+		dir := spillDir(config.GetGlobalServerConfig().DataDir, changefeedID)
+		g.spillQueue = newSpillQueue(changefeedID, dir, threshold)
+		g.spillOutputCh = make(chan *future)
+	}
+	return g
 }
 
 func (g *encoderGroup) Run(ctx context.Context) error {
@@ -129,17 +150,67 @@ func (g *encoderGroup) Run(ctx context.Context) error {
 		})
 	}
 
+	if g.spillQueue != nil {
+		defer g.spillQueue.close()
+		eg.Go(func() error {
+			return g.runSpillIn(ctx)
+		})
+		eg.Go(func() error {
+			return g.runSpillOut(ctx)
+		})
+	}
+
 	return eg.Wait()
 }
 
+// runSpillIn moves the ready futures from the output channel to the spill queue.
+func (g *encoderGroup) runSpillIn(ctx context.Context) error {
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case future := <-g.outputCh:
+			if err := future.Ready(ctx); err != nil {
+				return nil
+			}
+			if err := g.spillQueue.push(ctx, future); err != nil {
+				if errors.Cause(err) == context.Canceled {
+					return nil
+				}
+				return errors.Trace(err)
+			}
+		}
+	}
+}
+
+// runSpillOut sends the futures in the spill queue to the spill output channel.
+func (g *encoderGroup) runSpillOut(ctx context.Context) error {
+	for {
+		future, err := g.spillQueue.pop(ctx)
+		if err != nil {
+			if errors.Cause(err) == context.Canceled {
+				return nil
+			}
+			return errors.Trace(err)
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case g.spillOutputCh <- future:
+		}
+	}
+}
+
 func (g *encoderGroup) collectMetrics(ctx context.Context) error {
 	ticker := time.NewTicker(defaultMetricInterval)
 	inputChSize := encoderGroupInputChanSizeGauge.WithLabelValues(g.changefeedID.Namespace, g.changefeedID.ID)
 	outputChSize := encoderGroupOutputChanSizeGauge.WithLabelValues(g.changefeedID.Namespace, g.changefeedID.ID)
+	spilledBytes := encoderGroupSpilledBytesGauge.WithLabelValues(g.changefeedID.Namespace, g.changefeedID.ID)
 	defer func() {
 		ticker.Stop()
 		encoderGroupInputChanSizeGauge.DeleteLabelValues(g.changefeedID.Namespace, g.changefeedID.ID)
 		encoderGroupOutputChanSizeGauge.DeleteLabelValues(g.changefeedID.Namespace, g.changefeedID.ID)
+		encoderGroupSpilledBytesGauge.DeleteLabelValues(g.changefeedID.Namespace, g.changefeedID.ID)
 	}()
 	for {
 		select {
@@ -152,6 +223,9 @@ func (g *encoderGroup) collectMetrics(ctx context.Context) error {
 			}
 			inputChSize.Set(float64(total))
 			outputChSize.Set(float64(len(g.outputCh)))
+			if g.spillQueue != nil {
+				spilledBytes.Set(float64(g.spillQueue.getSpilledBytes()))
+			}
 		}
 	}
 }
@@ -165,8 +239,7 @@ func (g *encoderGroup) runEncoder(ctx context.Context, idx int) error {
 			return nil
 		case future := <-inputCh:
 			for _, event := range future.events {
-				err := encoder.AppendRowChangedEvent(ctx, future.Key.Topic, event.Event, event.Callback)
-				if err != nil {
+				if err := g.appendRowChangedEvent(ctx, encoder, future.Key.Topic, event); err != nil {
 					return errors.Trace(err)
 				}
 			}
@@ -176,6 +249,22 @@ func (g *encoderGroup) runEncoder(ctx context.Context, idx int) error {
 	}
 }
 
+// appendRowChangedEvent appends the event to the encoder, the encoding
+// is traced if the transaction of the event is sampled.
+func (g *encoderGroup) appendRowChangedEvent(
+	ctx context.Context, encoder RowEventEncoder, topic string, event *dmlsink.RowChangeCallbackableEvent,
+) error {
+	row := event.Event
+	sc := tracing.TxnSpanContext(g.changefeedID, row.StartTs, row.CommitTs)
+	if sc.IsSampled() {
+		span := tracing.StartSpan(sc, tracing.SpanSinkEncode,
+			tracing.TxnAttributes(g.changefeedID, row.StartTs, row.CommitTs,
+				tracing.AttrTableID.Int64(row.PhysicalTableID))...)
+		defer span.End()
+	}
+	return encoder.AppendRowChangedEvent(ctx, topic, row, event.Callback)
+}
+
 func (g *encoderGroup) AddEvents(
 	ctx context.Context,
 	key model.TopicPartitionKey,
@@ -206,10 +295,31 @@ func (g *encoderGroup) AddEvents(
 	return nil
 }
 
+func (g *encoderGroup) AddTxnBoundary(ctx context.Context) error {
+	future := newFuture(model.TopicPartitionKey{})
+	future.TxnBoundary = true
+	close(future.done)
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case g.outputCh <- future:
+	}
+	return nil
+}
+
 func (g *encoderGroup) Output() <-chan *future {
+	if g.spillQueue != nil {
+		return g.spillOutputCh
+	}
 	return g.outputCh
 }
 
+func (g *encoderGroup) SetMemoryQuota(quota SpillQuota) {
+	if g.spillQueue != nil {
+		g.spillQueue.setMemoryQuota(quota)
+	}
+}
+
 func (g *encoderGroup) cleanMetrics() {
 	g.builder.CleanMetrics()
 	common.CleanMetrics(g.changefeedID)
@@ -221,7 +331,15 @@ type future struct {
 	Key      model.TopicPartitionKey
 	events   []*dmlsink.RowChangeCallbackableEvent
 	Messages []*common.Message
-	done     chan struct{}
+	// TxnBoundary is true if the future is added by AddTxnBoundary.
+	TxnBoundary bool
+	done        chan struct{}
+	// spilled are the locations of the messages if they are spilled to the disk.
+	spilled []spilledMessage
+	// spillQuota is the memory quota which spillQuotaBytes of the spilled
+	// messages are transferred out of, they're transferred back once read back.
+	spillQuota      SpillQuota
+	spillQuotaBytes uint64
 }
 
 func newFuture(key model.TopicPartitionKey,
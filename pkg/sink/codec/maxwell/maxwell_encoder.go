@@ -31,6 +31,9 @@ type BatchEncoder struct {
 	valueBuf    *bytes.Buffer
 	callbackBuf []func()
 	batchSize   int
+	// built are the messages built before the pending rows, a new message is
+	// started once the pending rows are going to exceed the max message bytes.
+	built []*common.Message
 
 	config *common.Config
 }
@@ -54,6 +57,10 @@ func (d *BatchEncoder) AppendRowChangedEvent(
 	if err != nil {
 		return errors.Trace(err)
 	}
+	if d.batchSize != 0 && d.config.MaxMessageBytes > 0 &&
+		d.keyBuf.Len()+d.valueBuf.Len()+len(value)+common.MaxRecordOverhead > d.config.MaxMessageBytes {
+		d.built = append(d.built, d.buildMessage())
+	}
 	d.valueBuf.Write(value)
 	d.batchSize++
 	if callback != nil {
@@ -80,10 +87,16 @@ func (d *BatchEncoder) EncodeDDLEvent(e *model.DDLEvent) (*common.Message, error
 
 // Build implements the RowEventEncoder interface
 func (d *BatchEncoder) Build() []*common.Message {
-	if d.batchSize == 0 {
-		return nil
+	ret := d.built
+	d.built = nil
+	if d.batchSize != 0 {
+		ret = append(ret, d.buildMessage())
 	}
+	return ret
+}
 
+// buildMessage builds a message of the pending rows.
+func (d *BatchEncoder) buildMessage() *common.Message {
 	ret := common.NewMsg(config.ProtocolMaxwell,
 		d.keyBuf.Bytes(), d.valueBuf.Bytes(), 0, model.MessageTypeRow, nil, nil)
 	ret.SetRowsCount(d.batchSize)
@@ -97,7 +110,7 @@ func (d *BatchEncoder) Build() []*common.Message {
 		d.callbackBuf = make([]func(), 0)
 	}
 	d.reset()
-	return []*common.Message{ret}
+	return ret
 }
 
 // reset implements the RowEventEncoder interface
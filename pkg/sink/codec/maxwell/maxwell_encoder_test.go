@@ -120,3 +120,34 @@ func TestMaxwellAppendRowChangedEventWithCallback(t *testing.T) {
 	msgs[0].Callback()
 	require.Equal(t, 15, count, "expected all callbacks to be called")
 }
+
+func TestMaxwellBatchEncoderSplitByMaxMessageBytes(t *testing.T) {
+	helper := entry.NewSchemaTestHelper(t)
+	defer helper.Close()
+
+	_ = helper.DDL2Event("create table test.t(col1 varchar(255) primary key)")
+	row := helper.DML2Event("insert into test.t values ('aa')", "test", "t")
+
+	codecConfig := &common.Config{}
+	encoder := newBatchEncoder(codecConfig)
+	require.NoError(t, encoder.AppendRowChangedEvent(context.Background(), "", row, nil))
+	msgs := encoder.Build()
+	require.Len(t, msgs, 1)
+	// each message holds at most 2 rows.
+	codecConfig.MaxMessageBytes = msgs[0].Length() * 2
+
+	count := 0
+	for i := 0; i < 5; i++ {
+		err := encoder.AppendRowChangedEvent(context.Background(), "", row, func() { count++ })
+		require.NoError(t, err)
+	}
+	msgs = encoder.Build()
+	require.Len(t, msgs, 3)
+	for i, expected := range []int{2, 2, 1} {
+		require.Equal(t, expected, msgs[i].GetRowsCount())
+		require.LessOrEqual(t, msgs[i].Length(), codecConfig.MaxMessageBytes)
+		msgs[i].Callback()
+	}
+	require.Equal(t, 5, count)
+	require.Empty(t, encoder.Build())
+}
@@ -34,11 +34,20 @@ var (
 			Name:      "encoder_group_output_chan_size",
 			Help:      "The size of output channel of encoder group",
 		}, []string{"namespace", "changefeed"})
+	// encoderGroupSpilledBytesGauge tracks the bytes of the encoded messages spilled to the disk
+	encoderGroupSpilledBytesGauge = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "ticdc",
+			Subsystem: "sink",
+			Name:      "encoder_group_spilled_bytes",
+			Help:      "The bytes of the encoded messages spilled to the disk by encoder group",
+		}, []string{"namespace", "changefeed"})
 )
 
 // InitMetrics registers all metrics in this file
 func InitMetrics(registry *prometheus.Registry) {
 	registry.MustRegister(encoderGroupInputChanSizeGauge)
 	registry.MustRegister(encoderGroupOutputChanSizeGauge)
+	registry.MustRegister(encoderGroupSpilledBytesGauge)
 	common.InitMetrics(registry)
 }
@@ -120,7 +120,7 @@ func (d *BatchEncoder) AppendRowChangedEvent(
 		if d.config.LargeMessageHandle.EnableClaimCheck() {
 			// send the large message to the external storage first, then
 			// create a new message contains the reference of the large message.
-			claimCheckFileName := claimcheck.NewFileName()
+			claimCheckFileName := d.claimCheck.NewFileName(e.CommitTs)
 			m := newMessage(key, value)
 			err = d.claimCheck.WriteMessage(ctx, m.Key, m.Value, claimCheckFileName)
 			if err != nil {
@@ -16,6 +16,7 @@ package open
 import (
 	"context"
 	"database/sql"
+	"strconv"
 	"testing"
 
 	"github.com/pingcap/tidb/pkg/types"
@@ -28,6 +29,7 @@ import (
 	"github.com/pingcap/tiflow/pkg/sink/codec/common"
 	"github.com/pingcap/tiflow/pkg/sink/codec/internal"
 	"github.com/pingcap/tiflow/pkg/sink/codec/utils"
+	"github.com/pingcap/tiflow/pkg/sink/kafka/claimcheck"
 	"github.com/stretchr/testify/require"
 )
 
@@ -374,8 +376,11 @@ func TestE2EClaimCheckMessage(t *testing.T) {
 	ctx := context.Background()
 	topic := ""
 
-	a := 263
-	codecConfig := common.NewConfig(config.ProtocolOpen).WithMaxMessageBytes(a)
+	// the claim-check file name starts with the changefeed prefix and the commit ts.
+	codecConfig := common.NewConfig(config.ProtocolOpen)
+	a := 263 + len(claimcheck.FilePrefix(codecConfig.ChangefeedID)) +
+		len(strconv.FormatUint(insertEvent.CommitTs, 10)) + 1
+	codecConfig = codecConfig.WithMaxMessageBytes(a)
 	codecConfig.LargeMessageHandle.LargeMessageHandleOption = config.LargeMessageHandleOptionClaimCheck
 	codecConfig.LargeMessageHandle.LargeMessageHandleCompression = compression.LZ4
 	codecConfig.LargeMessageHandle.ClaimCheckStorageURI = "file:///tmp/claim-check"
@@ -0,0 +1,205 @@
+// Copyright 2024 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package protobuf
+
+import (
+	"context"
+
+	"github.com/pingcap/errors"
+	"github.com/pingcap/tidb/pkg/parser/mysql"
+	"github.com/pingcap/tidb/pkg/types"
+	"github.com/pingcap/tiflow/cdc/model"
+	cerror "github.com/pingcap/tiflow/pkg/errors"
+	"github.com/pingcap/tiflow/pkg/sink/codec"
+	"github.com/pingcap/tiflow/pkg/sink/codec/common"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+type decoder struct {
+	config *common.Config
+	topic  string
+
+	schemaM *SchemaManager
+
+	key   []byte
+	value []byte
+}
+
+// NewDecoder returns a protobuf decoder.
+func NewDecoder(config *common.Config, schemaM *SchemaManager, topic string) codec.RowEventDecoder {
+	return &decoder{
+		config:  config,
+		topic:   topic,
+		schemaM: schemaM,
+	}
+}
+
+// AddKeyValue implements the RowEventDecoder interface.
+func (d *decoder) AddKeyValue(key, value []byte) error {
+	if d.key != nil || d.value != nil {
+		return errors.New("key or value is not nil")
+	}
+	d.key = key
+	d.value = value
+	return nil
+}
+
+// HasNext implements the RowEventDecoder interface,
+// protobuf only sends row changed events.
+func (d *decoder) HasNext() (model.MessageType, bool, error) {
+	if d.key == nil && d.value == nil {
+		return model.MessageTypeUnknown, false, nil
+	}
+	return model.MessageTypeRow, true, nil
+}
+
+// NextRowChangedEvent returns the next row changed event if exists.
+func (d *decoder) NextRowChangedEvent() (*model.RowChangedEvent, error) {
+	ctx := context.Background()
+	keyEntry, keyMsg, err := d.decode(ctx, d.key)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+
+	// the delete event only has the key part, which holds the handle key columns.
+	isDelete := len(d.value) == 0
+	entry, msg := keyEntry, keyMsg
+	if !isDelete {
+		entry, msg, err = d.decode(ctx, d.value)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+	}
+	d.key, d.value = nil, nil
+	if entry == nil {
+		return nil, cerror.ErrProtobufInvalidMessage.GenWithStackByArgs("both key and value are empty")
+	}
+
+	columns := make([]*model.Column, 0, len(entry.schema.fields))
+	pkNames := make(map[string]struct{})
+	event := new(model.RowChangedEvent)
+	for _, f := range entry.schema.fields {
+		fd := entry.descriptor.Fields().ByNumber(protoreflect.FieldNumber(f.number))
+		if f.meta == nil {
+			// the TiDB extension fields, only the commit ts is used.
+			if f.number == tidbCommitTsNumber {
+				event.CommitTs = msg.Get(fd).Uint()
+			}
+			continue
+		}
+		flag := model.ColumnFlagType(f.meta.Flag)
+		if flag.IsHandleKey() {
+			pkNames[f.meta.Name] = struct{}{}
+		}
+		var value interface{}
+		if !f.optional || msg.Has(fd) {
+			value, err = protoValueToColumnValue(msg.Get(fd), fd.Kind(), f.meta)
+			if err != nil {
+				return nil, errors.Trace(err)
+			}
+		}
+		columns = append(columns, &model.Column{
+			Name:  f.meta.Name,
+			Type:  f.meta.Type,
+			Flag:  flag,
+			Value: value,
+		})
+	}
+
+	event.TableInfo = model.BuildTableInfoWithPKNames4Test(
+		entry.schema.meta.Schema, entry.schema.meta.Table, columns, pkNames)
+	if isDelete {
+		event.PreColumns = model.Columns2ColumnDatas(columns, event.TableInfo)
+	} else {
+		event.Columns = model.Columns2ColumnDatas(columns, event.TableInfo)
+	}
+	return event, nil
+}
+
+// decode the message by the schema carried in the header.
+func (d *decoder) decode(
+	ctx context.Context, data []byte,
+) (*schemaCacheEntry, *dynamicpb.Message, error) {
+	if len(data) == 0 {
+		return nil, nil, nil
+	}
+	schemaID, data, err := extractSchemaIDAndBinaryData(data)
+	if err != nil {
+		return nil, nil, errors.Trace(err)
+	}
+	entry, err := d.schemaM.Lookup(ctx, schemaID)
+	if err != nil {
+		return nil, nil, errors.Trace(err)
+	}
+	msg := dynamicpb.NewMessage(entry.descriptor)
+	if err := proto.Unmarshal(data, msg); err != nil {
+		return nil, nil, cerror.WrapError(cerror.ErrProtobufInvalidMessage, err)
+	}
+	return entry, msg, nil
+}
+
+// protoValueToColumnValue converts the field value back to the column value.
+func protoValueToColumnValue(
+	value protoreflect.Value, kind protoreflect.Kind, meta *fieldMeta,
+) (interface{}, error) {
+	switch kind {
+	case protoreflect.Int64Kind:
+		return value.Int(), nil
+	case protoreflect.Uint64Kind:
+		return value.Uint(), nil
+	case protoreflect.FloatKind:
+		return float32(value.Float()), nil
+	case protoreflect.DoubleKind:
+		return value.Float(), nil
+	case protoreflect.BytesKind:
+		return value.Bytes(), nil
+	}
+
+	s := value.String()
+	switch meta.Type {
+	case mysql.TypeEnum:
+		enum, err := types.ParseEnum(meta.Elems, s, "")
+		if err != nil {
+			return nil, cerror.WrapError(cerror.ErrProtobufInvalidMessage, err)
+		}
+		return enum.Value, nil
+	case mysql.TypeSet:
+		set, err := types.ParseSet(meta.Elems, s, "")
+		if err != nil {
+			return nil, cerror.WrapError(cerror.ErrProtobufInvalidMessage, err)
+		}
+		return set.Value, nil
+	case mysql.TypeTiDBVectorFloat32:
+		vec, err := types.ParseVectorFloat32(s)
+		if err != nil {
+			return nil, cerror.WrapError(cerror.ErrProtobufInvalidMessage, err)
+		}
+		return vec, nil
+	}
+	return s, nil
+}
+
+// NextResolvedEvent implements the RowEventDecoder interface,
+// protobuf doesn't send resolved events.
+func (d *decoder) NextResolvedEvent() (uint64, error) {
+	return 0, cerror.ErrProtobufInvalidMessage.GenWithStackByArgs("protobuf has no resolved event")
+}
+
+// NextDDLEvent implements the RowEventDecoder interface,
+// protobuf doesn't send DDL events.
+func (d *decoder) NextDDLEvent() (*model.DDLEvent, error) {
+	return nil, cerror.ErrProtobufInvalidMessage.GenWithStackByArgs("protobuf has no DDL event")
+}
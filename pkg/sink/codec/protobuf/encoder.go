@@ -0,0 +1,315 @@
+// Copyright 2024 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package protobuf
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/pingcap/errors"
+	"github.com/pingcap/log"
+	"github.com/pingcap/tidb/pkg/parser/mysql"
+	"github.com/pingcap/tidb/pkg/types"
+	"github.com/pingcap/tiflow/cdc/model"
+	"github.com/pingcap/tiflow/pkg/config"
+	cerror "github.com/pingcap/tiflow/pkg/errors"
+	"github.com/pingcap/tiflow/pkg/sink/codec"
+	"github.com/pingcap/tiflow/pkg/sink/codec/common"
+	"github.com/tikv/client-go/v2/oracle"
+	"go.uber.org/zap"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+const (
+	insertOperation = "c"
+	updateOperation = "u"
+)
+
+// BatchEncoder encodes the row changed events to protobuf messages,
+// the schema of each table version is registered to the schema registry.
+type BatchEncoder struct {
+	namespace string
+	schemaM   *SchemaManager
+	result    []*common.Message
+
+	config *common.Config
+}
+
+// NewBatchEncoder creates a protobuf encoder.
+func NewBatchEncoder(namespace string, schemaM *SchemaManager, config *common.Config) codec.RowEventEncoder {
+	return &BatchEncoder{
+		namespace: namespace,
+		schemaM:   schemaM,
+		result:    make([]*common.Message, 0, 1),
+		config:    config,
+	}
+}
+
+// AppendRowChangedEvent appends a row change event to the encoder.
+// The key holds the handle key columns, the value holds all columns,
+// and it's nil for the delete event.
+func (e *BatchEncoder) AppendRowChangedEvent(
+	ctx context.Context,
+	topic string,
+	event *model.RowChangedEvent,
+	callback func(),
+) error {
+	topic = common.SanitizeTopicName(topic)
+
+	keyColumns, _ := event.HandleKeyColInfos()
+	key, err := e.encode(ctx, topic+keySchemaSuffix, event, keyColumns, false)
+	if err != nil {
+		log.Error("protobuf encoding key failed", zap.Error(err), zap.Any("event", event))
+		return errors.Trace(err)
+	}
+
+	var value []byte
+	if !event.IsDelete() {
+		value, err = e.encode(ctx, topic+valueSchemaSuffix, event, event.Columns, e.config.EnableTiDBExtension)
+		if err != nil {
+			log.Error("protobuf encoding value failed", zap.Error(err), zap.Any("event", event))
+			return errors.Trace(err)
+		}
+	}
+
+	message := common.NewMsg(
+		config.ProtocolProtobuf,
+		key,
+		value,
+		event.CommitTs,
+		model.MessageTypeRow,
+		event.TableInfo.GetSchemaNamePtr(),
+		event.TableInfo.GetTableNamePtr(),
+	)
+	message.Callback = callback
+	message.IncRowsCount()
+
+	if message.Length() > e.config.MaxMessageBytes {
+		log.Warn("Single message is too large for protobuf",
+			zap.Int("maxMessageBytes", e.config.MaxMessageBytes),
+			zap.Int("length", message.Length()),
+			zap.Any("table", event.TableInfo.TableName))
+		return cerror.ErrMessageTooLarge.GenWithStackByArgs(message.Length())
+	}
+	e.result = append(e.result, message)
+	return nil
+}
+
+// encode the columns with the schema registered under the subject.
+func (e *BatchEncoder) encode(
+	ctx context.Context, subject string,
+	event *model.RowChangedEvent, columns []*model.ColumnData, withExtension bool,
+) ([]byte, error) {
+	// there is no handle key columns in the force replicate mode, which is disallowed.
+	if len(columns) == 0 {
+		return nil, nil
+	}
+	entry, err := e.schemaM.GetCachedOrRegister(ctx, subject, event.TableInfo.Version,
+		func() (*messageSchema, error) {
+			return newMessageSchema(e.namespace, event.TableInfo, columns, withExtension)
+		})
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+
+	msg := dynamicpb.NewMessage(entry.descriptor)
+	fields := entry.descriptor.Fields()
+	for _, col := range columns {
+		if col == nil || col.Value == nil {
+			continue
+		}
+		fd := fields.ByNumber(protoreflect.FieldNumber(col.ColumnID))
+		if fd == nil {
+			return nil, cerror.ErrProtobufEncodeFailed.GenWithStackByArgs(
+				fmt.Sprintf("field %d not found in the schema", col.ColumnID))
+		}
+		value, err := columnToProtoValue(model.GetColumnDataX(col, event.TableInfo), fd.Kind())
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		msg.Set(fd, value)
+	}
+	if withExtension {
+		op := insertOperation
+		if event.IsUpdate() {
+			op = updateOperation
+		}
+		msg.Set(fields.ByNumber(tidbOpNumber), protoreflect.ValueOfString(op))
+		msg.Set(fields.ByNumber(tidbCommitTsNumber), protoreflect.ValueOfUint64(event.CommitTs))
+		msg.Set(fields.ByNumber(tidbPhysicalTimeNumber),
+			protoreflect.ValueOfInt64(oracle.ExtractPhysical(event.CommitTs)))
+	}
+
+	data, err := proto.Marshal(msg)
+	if err != nil {
+		return nil, cerror.WrapError(cerror.ErrProtobufEncodeFailed, err)
+	}
+	result := make([]byte, 0, len(entry.header)+len(data))
+	result = append(result, entry.header...)
+	return append(result, data...), nil
+}
+
+// columnToProtoValue converts the column value to the value of the field kind.
+func columnToProtoValue(col model.ColumnDataX, kind protoreflect.Kind) (protoreflect.Value, error) {
+	switch kind {
+	case protoreflect.Int64Kind:
+		switch v := col.Value.(type) {
+		case int64:
+			return protoreflect.ValueOfInt64(v), nil
+		case uint64:
+			return protoreflect.ValueOfInt64(int64(v)), nil
+		case string:
+			n, err := strconv.ParseInt(v, 10, 64)
+			if err != nil {
+				return protoreflect.Value{}, cerror.WrapError(cerror.ErrProtobufEncodeFailed, err)
+			}
+			return protoreflect.ValueOfInt64(n), nil
+		}
+	case protoreflect.Uint64Kind:
+		switch v := col.Value.(type) {
+		case uint64:
+			return protoreflect.ValueOfUint64(v), nil
+		case int64:
+			return protoreflect.ValueOfUint64(uint64(v)), nil
+		case string:
+			n, err := strconv.ParseUint(v, 10, 64)
+			if err != nil {
+				return protoreflect.Value{}, cerror.WrapError(cerror.ErrProtobufEncodeFailed, err)
+			}
+			return protoreflect.ValueOfUint64(n), nil
+		case []byte:
+			n, err := types.BinaryLiteral(v).ToInt(types.DefaultStmtNoWarningContext)
+			if err != nil {
+				return protoreflect.Value{}, cerror.WrapError(cerror.ErrProtobufEncodeFailed, err)
+			}
+			return protoreflect.ValueOfUint64(n), nil
+		}
+	case protoreflect.FloatKind:
+		switch v := col.Value.(type) {
+		case float32:
+			return protoreflect.ValueOfFloat32(v), nil
+		case float64:
+			return protoreflect.ValueOfFloat32(float32(v)), nil
+		case string:
+			n, err := strconv.ParseFloat(v, 32)
+			if err != nil {
+				return protoreflect.Value{}, cerror.WrapError(cerror.ErrProtobufEncodeFailed, err)
+			}
+			return protoreflect.ValueOfFloat32(float32(n)), nil
+		}
+	case protoreflect.DoubleKind:
+		switch v := col.Value.(type) {
+		case float64:
+			return protoreflect.ValueOfFloat64(v), nil
+		case float32:
+			return protoreflect.ValueOfFloat64(float64(v)), nil
+		case string:
+			n, err := strconv.ParseFloat(v, 64)
+			if err != nil {
+				return protoreflect.Value{}, cerror.WrapError(cerror.ErrProtobufEncodeFailed, err)
+			}
+			return protoreflect.ValueOfFloat64(n), nil
+		}
+	case protoreflect.BytesKind:
+		switch v := col.Value.(type) {
+		case []byte:
+			return protoreflect.ValueOfBytes(v), nil
+		case string:
+			return protoreflect.ValueOfBytes([]byte(v)), nil
+		}
+	case protoreflect.StringKind:
+		return columnToProtoString(col)
+	}
+	return protoreflect.Value{}, cerror.ErrProtobufEncodeFailed.GenWithStackByArgs(
+		fmt.Sprintf("unexpected value %v of column %s for %s field", col.Value, col.GetName(), kind))
+}
+
+// columnToProtoString converts the column value to the string field value.
+func columnToProtoString(col model.ColumnDataX) (protoreflect.Value, error) {
+	switch v := col.Value.(type) {
+	case string:
+		return protoreflect.ValueOfString(v), nil
+	case []byte:
+		return protoreflect.ValueOfString(string(v)), nil
+	case types.VectorFloat32:
+		return protoreflect.ValueOfString(v.String()), nil
+	case uint64:
+		elements := col.GetColumnInfo().GetElems()
+		switch col.GetType() {
+		case mysql.TypeEnum:
+			enum, err := types.ParseEnumValue(elements, v)
+			if err != nil {
+				return protoreflect.Value{}, cerror.WrapError(cerror.ErrProtobufEncodeFailed, err)
+			}
+			return protoreflect.ValueOfString(enum.Name), nil
+		case mysql.TypeSet:
+			set, err := types.ParseSetValue(elements, v)
+			if err != nil {
+				return protoreflect.Value{}, cerror.WrapError(cerror.ErrProtobufEncodeFailed, err)
+			}
+			return protoreflect.ValueOfString(set.Name), nil
+		}
+	}
+	return protoreflect.ValueOfString(fmt.Sprintf("%v", col.Value)), nil
+}
+
+// EncodeCheckpointEvent is a no-op, protobuf doesn't send checkpoint events.
+func (e *BatchEncoder) EncodeCheckpointEvent(_ uint64) (*common.Message, error) {
+	return nil, nil
+}
+
+// EncodeDDLEvent is a no-op, protobuf doesn't send DDL events,
+// the schema evolves when the first row of the new table version is encoded.
+func (e *BatchEncoder) EncodeDDLEvent(_ *model.DDLEvent) (*common.Message, error) {
+	return nil, nil
+}
+
+// Build returns the encoded messages.
+func (e *BatchEncoder) Build() []*common.Message {
+	result := e.result
+	e.result = nil
+	return result
+}
+
+type batchEncoderBuilder struct {
+	namespace string
+	config    *common.Config
+	schemaM   *SchemaManager
+}
+
+// NewBatchEncoderBuilder creates a protobuf batchEncoderBuilder.
+func NewBatchEncoderBuilder(
+	ctx context.Context, config *common.Config,
+) (codec.RowEventEncoderBuilder, error) {
+	schemaM, err := NewConfluentSchemaManager(ctx, config.AvroConfluentSchemaRegistry, nil)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	return &batchEncoderBuilder{
+		namespace: config.ChangefeedID.Namespace,
+		config:    config,
+		schemaM:   schemaM,
+	}, nil
+}
+
+// Build a protobuf BatchEncoder.
+func (b *batchEncoderBuilder) Build() codec.RowEventEncoder {
+	return NewBatchEncoder(b.namespace, b.schemaM, b.config)
+}
+
+// CleanMetrics is a no-op for the protobuf BatchEncoder.
+func (b *batchEncoderBuilder) CleanMetrics() {}
@@ -0,0 +1,119 @@
+// Copyright 2024 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package protobuf
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"sync"
+
+	"github.com/jarcoal/httpmock"
+)
+
+type mockRegistrySchema struct {
+	content string
+	version int
+	ID      int
+}
+
+type mockRegistry struct {
+	mu       sync.Mutex
+	subjects map[string]*mockRegistrySchema
+	// schemas keeps all registered schemas by ID, including the old versions.
+	schemas map[int]string
+	newID   int
+}
+
+func startHTTPInterceptForTestingRegistry() {
+	httpmock.Activate()
+
+	registry := mockRegistry{
+		subjects: make(map[string]*mockRegistrySchema),
+		schemas:  make(map[int]string),
+		newID:    1,
+	}
+
+	httpmock.RegisterResponder(
+		"GET",
+		"http://127.0.0.1:8081",
+		httpmock.NewStringResponder(200, "{}"),
+	)
+
+	httpmock.RegisterResponder("POST", `=~^http://127.0.0.1:8081/subjects/(.+)/versions`,
+		func(req *http.Request) (*http.Response, error) {
+			subject, err := httpmock.GetSubmatch(req, 1)
+			if err != nil {
+				return nil, err
+			}
+			reqBody, err := io.ReadAll(req.Body)
+			if err != nil {
+				return nil, err
+			}
+			var reqData registerRequest
+			if err := json.Unmarshal(reqBody, &reqData); err != nil {
+				return nil, err
+			}
+			if reqData.SchemaType != schemaTypeProtobuf {
+				return httpmock.NewStringResponse(422, "Invalid schema type"), nil
+			}
+
+			var respData registerResponse
+			registry.mu.Lock()
+			item, exists := registry.subjects[subject]
+			if !exists {
+				item = &mockRegistrySchema{
+					content: reqData.Schema,
+					version: 1,
+					ID:      registry.newID,
+				}
+				registry.subjects[subject] = item
+				respData.SchemaID = registry.newID
+			} else if item.content == reqData.Schema {
+				respData.SchemaID = item.ID
+			} else {
+				item.content = reqData.Schema
+				item.version++
+				item.ID = registry.newID
+				respData.SchemaID = registry.newID
+			}
+			registry.schemas[respData.SchemaID] = reqData.Schema
+			registry.newID++
+			registry.mu.Unlock()
+			return httpmock.NewJsonResponse(200, &respData)
+		})
+
+	httpmock.RegisterResponder("GET", `=~^http://127.0.0.1:8081/schemas/ids/(.+)`,
+		func(req *http.Request) (*http.Response, error) {
+			id, err := httpmock.GetSubmatchAsInt(req, 1)
+			if err != nil {
+				return httpmock.NewStringResponse(500, "Internal Server Error"), err
+			}
+
+			registry.mu.Lock()
+			defer registry.mu.Unlock()
+			if schema, ok := registry.schemas[int(id)]; ok {
+				return httpmock.NewJsonResponse(200, &lookupResponse{
+					SchemaID:   int(id),
+					Schema:     schema,
+					SchemaType: schemaTypeProtobuf,
+				})
+			}
+			return httpmock.NewStringResponse(404, "Not Found"), nil
+		})
+}
+
+func stopHTTPInterceptForTestingRegistry() {
+	httpmock.DeactivateAndReset()
+}
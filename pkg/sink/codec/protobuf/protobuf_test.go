@@ -0,0 +1,196 @@
+// Copyright 2024 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package protobuf
+
+import (
+	"context"
+	"testing"
+
+	"github.com/pingcap/tiflow/cdc/entry"
+	"github.com/pingcap/tiflow/cdc/model"
+	"github.com/pingcap/tiflow/pkg/config"
+	"github.com/pingcap/tiflow/pkg/sink/codec/common"
+	"github.com/pingcap/tiflow/pkg/sink/codec/utils"
+	"github.com/stretchr/testify/require"
+)
+
+const testRegistryURL = "http://127.0.0.1:8081"
+
+func newEncoderAndDecoder4Test(
+	ctx context.Context, t *testing.T, codecConfig *common.Config, topic string,
+) (*BatchEncoder, *decoder) {
+	schemaM, err := NewConfluentSchemaManager(ctx, testRegistryURL, nil)
+	require.NoError(t, err)
+	encoder := NewBatchEncoder(model.DefaultNamespace, schemaM, codecConfig).(*BatchEncoder)
+
+	// the decoder uses its own schema manager, so that the schema is fetched from the registry.
+	decoderSchemaM, err := NewConfluentSchemaManager(ctx, testRegistryURL, nil)
+	require.NoError(t, err)
+	return encoder, NewDecoder(codecConfig, decoderSchemaM, topic).(*decoder)
+}
+
+func encodeAndDecode(
+	ctx context.Context, t *testing.T, encoder *BatchEncoder, dec *decoder,
+	topic string, event *model.RowChangedEvent,
+) (*common.Message, *model.RowChangedEvent) {
+	err := encoder.AppendRowChangedEvent(ctx, topic, event, func() {})
+	require.NoError(t, err)
+	messages := encoder.Build()
+	require.Len(t, messages, 1)
+	require.Equal(t, config.ProtocolProtobuf, messages[0].Protocol)
+
+	err = dec.AddKeyValue(messages[0].Key, messages[0].Value)
+	require.NoError(t, err)
+	tp, ok, err := dec.HasNext()
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, model.MessageTypeRow, tp)
+	decoded, err := dec.NextRowChangedEvent()
+	require.NoError(t, err)
+	return messages[0], decoded
+}
+
+func TestProtobufEncodeDecode(t *testing.T) {
+	startHTTPInterceptForTestingRegistry()
+	defer stopHTTPInterceptForTestingRegistry()
+
+	ctx := context.Background()
+	codecConfig := common.NewConfig(config.ProtocolProtobuf)
+	codecConfig.EnableTiDBExtension = true
+	topic := "protobuf-test-topic"
+	encoder, dec := newEncoderAndDecoder4Test(ctx, t, codecConfig, topic)
+
+	_, insertEvent, _, deleteEvent := utils.NewLargeEvent4Test(t, config.GetDefaultReplicaConfig())
+	message, decoded := encodeAndDecode(ctx, t, encoder, dec, topic, insertEvent)
+	require.NotNil(t, message.Key)
+	require.NotNil(t, message.Value)
+	require.Equal(t, insertEvent.CommitTs, decoded.CommitTs)
+	require.Equal(t, insertEvent.TableInfo.GetSchemaName(), decoded.TableInfo.GetSchemaName())
+	require.Equal(t, insertEvent.TableInfo.GetTableName(), decoded.TableInfo.GetTableName())
+	require.Len(t, decoded.Columns, len(insertEvent.Columns))
+	for i, col := range decoded.Columns {
+		expected := model.GetColumnDataX(insertEvent.Columns[i], insertEvent.TableInfo)
+		actual := model.GetColumnDataX(col, decoded.TableInfo)
+		require.Equal(t, expected.GetName(), actual.GetName())
+		require.Equal(t, expected.GetType(), actual.GetType())
+		if expected.Value == nil {
+			require.Nil(t, actual.Value, expected.GetName())
+		}
+		switch v := expected.Value.(type) {
+		case int64, uint64, float32, float64:
+			require.Equal(t, v, actual.Value, expected.GetName())
+		}
+	}
+
+	// the delete event only has the key part.
+	message, decoded = encodeAndDecode(ctx, t, encoder, dec, topic, deleteEvent)
+	require.NotNil(t, message.Key)
+	require.Nil(t, message.Value)
+	require.True(t, decoded.IsDelete())
+	keyColumns, _ := deleteEvent.HandleKeyColInfos()
+	require.Len(t, decoded.PreColumns, len(keyColumns))
+	require.Equal(t, keyColumns[0].Value, decoded.PreColumns[0].Value)
+}
+
+func TestProtobufSchemaEvolution(t *testing.T) {
+	startHTTPInterceptForTestingRegistry()
+	defer stopHTTPInterceptForTestingRegistry()
+
+	helper := entry.NewSchemaTestHelper(t)
+	defer helper.Close()
+	helper.Tk().MustExec("use test")
+	_ = helper.DDL2Event("create table t (id int primary key, name varchar(32), e enum('a', 'b'))")
+
+	ctx := context.Background()
+	codecConfig := common.NewConfig(config.ProtocolProtobuf)
+	topic := "protobuf-test-topic"
+	encoder, dec := newEncoderAndDecoder4Test(ctx, t, codecConfig, topic)
+
+	event := helper.DML2Event(`insert into test.t values (1, "jack", "b")`, "test", "t")
+	message, decoded := encodeAndDecode(ctx, t, encoder, dec, topic, event)
+	schemaID, _, err := extractSchemaIDAndBinaryData(message.Value)
+	require.NoError(t, err)
+	require.Len(t, decoded.Columns, 3)
+	require.Equal(t, "jack", decoded.Columns[1].Value)
+	// enum is encoded as its name, and decoded to its index.
+	require.Equal(t, uint64(2), decoded.Columns[2].Value)
+
+	// the same table version reuses the registered schema.
+	event = helper.DML2Event(`insert into test.t values (2, null, "a")`, "test", "t")
+	message, decoded = encodeAndDecode(ctx, t, encoder, dec, topic, event)
+	id, _, err := extractSchemaIDAndBinaryData(message.Value)
+	require.NoError(t, err)
+	require.Equal(t, schemaID, id)
+	require.Nil(t, decoded.Columns[1].Value)
+
+	// the schema evolves after the DDL.
+	_ = helper.DDL2Event("alter table t add column age bigint unsigned")
+	event = helper.DML2Event(`insert into test.t values (3, "anna", "a", 18)`, "test", "t")
+	message, decoded = encodeAndDecode(ctx, t, encoder, dec, topic, event)
+	id, _, err = extractSchemaIDAndBinaryData(message.Value)
+	require.NoError(t, err)
+	require.NotEqual(t, schemaID, id)
+	require.Len(t, decoded.Columns, 4)
+	require.Equal(t, "age", decoded.TableInfo.ForceGetColumnName(decoded.Columns[3].ColumnID))
+	require.Equal(t, uint64(18), decoded.Columns[3].Value)
+}
+
+func TestMessageSchemaRenderAndParse(t *testing.T) {
+	schema := &messageSchema{
+		pkg:  getPackageName("default", "test"),
+		name: "t",
+		meta: messageMeta{Schema: "test", Table: "t"},
+		fields: []*field{
+			{name: "id", number: 1, tp: protoTypeInt64, meta: &fieldMeta{Name: "id", Type: 3, Flag: 11}},
+			{name: "_1name", number: 2, tp: protoTypeString, optional: true, meta: &fieldMeta{Name: "1name", Type: 15}},
+			{name: tidbOp, number: tidbOpNumber, tp: protoTypeString},
+		},
+	}
+	text, err := schema.render()
+	require.NoError(t, err)
+	require.Contains(t, text, "package ticdc.default.test;")
+	require.Contains(t, text, "optional string _1name = 2;")
+
+	parsed, err := parseMessageSchema(text)
+	require.NoError(t, err)
+	require.Equal(t, schema, parsed)
+
+	descriptor, err := parsed.descriptor()
+	require.NoError(t, err)
+	require.Equal(t, "ticdc.default.test.t", string(descriptor.FullName()))
+	require.True(t, descriptor.Fields().ByNumber(2).HasPresence())
+
+	_, err = parseMessageSchema("syntax = \"proto3\";\nmessage t {\n  map<string, string> m = 1;\n}\n")
+	require.Error(t, err)
+}
+
+func TestMessageHeader(t *testing.T) {
+	header := getMsgHeader(42)
+	id, data, err := extractSchemaIDAndBinaryData(append(header, 0x08, 0x01))
+	require.NoError(t, err)
+	require.Equal(t, 42, id)
+	require.Equal(t, []byte{0x08, 0x01}, data)
+
+	// message indexes [0] written without the shortcut.
+	id, data, err = extractSchemaIDAndBinaryData([]byte{0, 0, 0, 0, 7, 0x02, 0x00, 0x08})
+	require.NoError(t, err)
+	require.Equal(t, 7, id)
+	require.Equal(t, []byte{0x08}, data)
+
+	// nested message is not supported.
+	_, _, err = extractSchemaIDAndBinaryData([]byte{0, 0, 0, 0, 7, 0x02, 0x02, 0x08})
+	require.Error(t, err)
+	_, _, err = extractSchemaIDAndBinaryData([]byte{1, 0, 0, 0, 7, 0x00})
+	require.Error(t, err)
+}
@@ -0,0 +1,333 @@
+// Copyright 2024 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package protobuf
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/pingcap/errors"
+	"github.com/pingcap/tidb/pkg/parser/mysql"
+	"github.com/pingcap/tiflow/cdc/model"
+	cerror "github.com/pingcap/tiflow/pkg/errors"
+	"github.com/pingcap/tiflow/pkg/sink/codec/common"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+const (
+	// field numbers in the range are reserved by the protobuf implementation.
+	reservedFieldNumberStart = 19000
+	reservedFieldNumberEnd   = 19999
+	maxFieldNumber           = 536870911
+
+	// the TiDB extension fields take the largest field numbers,
+	// so that they never conflict with the column IDs.
+	tidbOp                 = "_tidb_op"
+	tidbCommitTs           = "_tidb_commit_ts"
+	tidbPhysicalTime       = "_tidb_commit_physical_time"
+	tidbOpNumber           = maxFieldNumber - 2
+	tidbCommitTsNumber     = maxFieldNumber - 1
+	tidbPhysicalTimeNumber = maxFieldNumber
+
+	// metaPrefix is the prefix of the comment which carries the TiDB
+	// information of a message or a field in the generated schema.
+	metaPrefix = "// tidb: "
+
+	protoTypeInt64  = "int64"
+	protoTypeUint64 = "uint64"
+	protoTypeFloat  = "float"
+	protoTypeDouble = "double"
+	protoTypeString = "string"
+	protoTypeBytes  = "bytes"
+)
+
+var protoTypes = map[string]descriptorpb.FieldDescriptorProto_Type{
+	protoTypeInt64:  descriptorpb.FieldDescriptorProto_TYPE_INT64,
+	protoTypeUint64: descriptorpb.FieldDescriptorProto_TYPE_UINT64,
+	protoTypeFloat:  descriptorpb.FieldDescriptorProto_TYPE_FLOAT,
+	protoTypeDouble: descriptorpb.FieldDescriptorProto_TYPE_DOUBLE,
+	protoTypeString: descriptorpb.FieldDescriptorProto_TYPE_STRING,
+	protoTypeBytes:  descriptorpb.FieldDescriptorProto_TYPE_BYTES,
+}
+
+// messageMeta is the TiDB information of the message.
+type messageMeta struct {
+	Schema string `json:"schema"`
+	Table  string `json:"table"`
+}
+
+// fieldMeta is the TiDB information of the column that the field associated with.
+type fieldMeta struct {
+	// Name is the original column name, the field name may be sanitized.
+	Name  string   `json:"name"`
+	Type  byte     `json:"type"`
+	Flag  uint64   `json:"flag"`
+	Elems []string `json:"elems,omitempty"`
+}
+
+type field struct {
+	name     string
+	number   int32
+	tp       string
+	optional bool
+	// meta is nil for the TiDB extension fields.
+	meta *fieldMeta
+}
+
+// messageSchema is the schema of one version of a table, it can be
+// rendered to the proto3 schema text, and built as the message descriptor.
+type messageSchema struct {
+	pkg    string
+	name   string
+	meta   messageMeta
+	fields []*field
+}
+
+// getPackageName returns the protobuf package of tables in the schema.
+func getPackageName(namespace, schema string) string {
+	return "ticdc." + common.SanitizeName(namespace) + "." + common.SanitizeName(schema)
+}
+
+// newMessageSchema generates the message schema by the given columns.
+func newMessageSchema(
+	namespace string, tableInfo *model.TableInfo,
+	columns []*model.ColumnData, withExtension bool,
+) (*messageSchema, error) {
+	result := &messageSchema{
+		pkg:  getPackageName(namespace, tableInfo.GetSchemaName()),
+		name: common.SanitizeName(tableInfo.GetTableName()),
+		meta: messageMeta{
+			Schema: tableInfo.GetSchemaName(),
+			Table:  tableInfo.GetTableName(),
+		},
+		fields: make([]*field, 0, len(columns)+3),
+	}
+	for _, col := range columns {
+		if col == nil {
+			continue
+		}
+		if (col.ColumnID >= reservedFieldNumberStart && col.ColumnID <= reservedFieldNumberEnd) ||
+			col.ColumnID <= 0 || col.ColumnID >= tidbOpNumber {
+			return nil, cerror.ErrProtobufEncodeFailed.GenWithStackByArgs(
+				fmt.Sprintf("column id %d can't be used as the field number", col.ColumnID))
+		}
+		x := model.GetColumnDataX(col, tableInfo)
+		tp, err := getProtoType(x)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		result.fields = append(result.fields, &field{
+			name:     common.SanitizeName(x.GetName()),
+			number:   int32(col.ColumnID),
+			tp:       tp,
+			optional: !x.GetFlag().IsHandleKey() && x.GetFlag().IsNullable(),
+			meta: &fieldMeta{
+				Name:  x.GetName(),
+				Type:  x.GetType(),
+				Flag:  uint64(x.GetFlag()),
+				Elems: x.GetColumnInfo().GetElems(),
+			},
+		})
+	}
+	if withExtension {
+		result.fields = append(result.fields,
+			&field{name: tidbOp, number: tidbOpNumber, tp: protoTypeString},
+			&field{name: tidbCommitTs, number: tidbCommitTsNumber, tp: protoTypeUint64},
+			&field{name: tidbPhysicalTime, number: tidbPhysicalTimeNumber, tp: protoTypeInt64},
+		)
+	}
+	return result, nil
+}
+
+// getProtoType returns the protobuf scalar type of the column.
+func getProtoType(col model.ColumnDataX) (string, error) {
+	switch col.GetType() {
+	case mysql.TypeTiny, mysql.TypeShort, mysql.TypeInt24, mysql.TypeLong, mysql.TypeLonglong:
+		if col.GetFlag().IsUnsigned() {
+			return protoTypeUint64, nil
+		}
+		return protoTypeInt64, nil
+	case mysql.TypeYear:
+		return protoTypeInt64, nil
+	case mysql.TypeBit:
+		return protoTypeUint64, nil
+	case mysql.TypeFloat:
+		return protoTypeFloat, nil
+	case mysql.TypeDouble:
+		return protoTypeDouble, nil
+	case mysql.TypeVarchar, mysql.TypeString, mysql.TypeVarString,
+		mysql.TypeTinyBlob, mysql.TypeBlob, mysql.TypeMediumBlob, mysql.TypeLongBlob:
+		if col.GetFlag().IsBinary() {
+			return protoTypeBytes, nil
+		}
+		return protoTypeString, nil
+	case mysql.TypeNewDecimal, mysql.TypeJSON, mysql.TypeEnum, mysql.TypeSet,
+		mysql.TypeDate, mysql.TypeDatetime, mysql.TypeTimestamp, mysql.TypeDuration,
+		mysql.TypeTiDBVectorFloat32:
+		return protoTypeString, nil
+	default:
+		return "", cerror.ErrProtobufEncodeFailed.GenWithStackByArgs(
+			fmt.Sprintf("unknown mysql type %d of column %s", col.GetType(), col.GetName()))
+	}
+}
+
+// render returns the proto3 schema text, which is registered to the schema registry.
+func (s *messageSchema) render() (string, error) {
+	var sb strings.Builder
+	sb.WriteString("syntax = \"proto3\";\n\n")
+	sb.WriteString("package " + s.pkg + ";\n\n")
+	meta, err := json.Marshal(s.meta)
+	if err != nil {
+		return "", cerror.WrapError(cerror.ErrProtobufEncodeFailed, err)
+	}
+	sb.WriteString(metaPrefix + string(meta) + "\n")
+	sb.WriteString("message " + s.name + " {\n")
+	for _, f := range s.fields {
+		if f.meta != nil {
+			meta, err := json.Marshal(f.meta)
+			if err != nil {
+				return "", cerror.WrapError(cerror.ErrProtobufEncodeFailed, err)
+			}
+			sb.WriteString("  " + metaPrefix + string(meta) + "\n")
+		}
+		sb.WriteString("  ")
+		if f.optional {
+			sb.WriteString("optional ")
+		}
+		sb.WriteString(fmt.Sprintf("%s %s = %d;\n", f.tp, f.name, f.number))
+	}
+	sb.WriteString("}\n")
+	return sb.String(), nil
+}
+
+// parseMessageSchema parses the schema text generated by render.
+func parseMessageSchema(text string) (*messageSchema, error) {
+	result := &messageSchema{}
+	var (
+		meta      string
+		inMessage bool
+	)
+	scanner := bufio.NewScanner(strings.NewReader(text))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case line == "" || strings.HasPrefix(line, "syntax"):
+		case strings.HasPrefix(line, metaPrefix):
+			meta = strings.TrimPrefix(line, metaPrefix)
+		case strings.HasPrefix(line, "package "):
+			result.pkg = strings.TrimSuffix(strings.TrimPrefix(line, "package "), ";")
+		case strings.HasPrefix(line, "message "):
+			if result.name != "" {
+				return nil, cerror.ErrProtobufInvalidMessage.GenWithStackByArgs(
+					"only one message is allowed in the schema")
+			}
+			result.name = strings.TrimSpace(strings.TrimSuffix(strings.TrimPrefix(line, "message "), "{"))
+			if err := json.Unmarshal([]byte(meta), &result.meta); err != nil {
+				return nil, cerror.WrapError(cerror.ErrProtobufInvalidMessage, err)
+			}
+			meta = ""
+			inMessage = true
+		case line == "}":
+			inMessage = false
+		case inMessage:
+			f, err := parseField(line, meta)
+			if err != nil {
+				return nil, errors.Trace(err)
+			}
+			result.fields = append(result.fields, f)
+			meta = ""
+		default:
+			return nil, cerror.ErrProtobufInvalidMessage.GenWithStackByArgs(
+				fmt.Sprintf("unexpected line %q in the schema", line))
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, cerror.WrapError(cerror.ErrProtobufInvalidMessage, err)
+	}
+	if result.name == "" {
+		return nil, cerror.ErrProtobufInvalidMessage.GenWithStackByArgs("message not found in the schema")
+	}
+	return result, nil
+}
+
+// parseField parses the field definition like `optional int64 id = 1;`.
+func parseField(line string, meta string) (*field, error) {
+	tokens := strings.Fields(strings.TrimSuffix(line, ";"))
+	result := &field{}
+	if len(tokens) > 0 && tokens[0] == "optional" {
+		result.optional = true
+		tokens = tokens[1:]
+	}
+	if len(tokens) != 4 || tokens[2] != "=" {
+		return nil, cerror.ErrProtobufInvalidMessage.GenWithStackByArgs(
+			fmt.Sprintf("invalid field %q", line))
+	}
+	if _, ok := protoTypes[tokens[0]]; !ok {
+		return nil, cerror.ErrProtobufInvalidMessage.GenWithStackByArgs(
+			fmt.Sprintf("unsupported field type %q", tokens[0]))
+	}
+	number, err := strconv.ParseInt(tokens[3], 10, 32)
+	if err != nil {
+		return nil, cerror.WrapError(cerror.ErrProtobufInvalidMessage, err)
+	}
+	result.tp = tokens[0]
+	result.name = tokens[1]
+	result.number = int32(number)
+	if meta != "" {
+		result.meta = &fieldMeta{}
+		if err := json.Unmarshal([]byte(meta), result.meta); err != nil {
+			return nil, cerror.WrapError(cerror.ErrProtobufInvalidMessage, err)
+		}
+	}
+	return result, nil
+}
+
+// descriptor builds the message descriptor of the schema,
+// which is used to encode and decode the message dynamically.
+func (s *messageSchema) descriptor() (protoreflect.MessageDescriptor, error) {
+	msg := &descriptorpb.DescriptorProto{Name: proto.String(s.name)}
+	for _, f := range s.fields {
+		fd := &descriptorpb.FieldDescriptorProto{
+			Name:   proto.String(f.name),
+			Number: proto.Int32(f.number),
+			Label:  descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+			Type:   protoTypes[f.tp].Enum(),
+		}
+		if f.optional {
+			// proto3 optional field is a member of a synthetic oneof.
+			fd.Proto3Optional = proto.Bool(true)
+			fd.OneofIndex = proto.Int32(int32(len(msg.OneofDecl)))
+			msg.OneofDecl = append(msg.OneofDecl,
+				&descriptorpb.OneofDescriptorProto{Name: proto.String("_" + f.name)})
+		}
+		msg.Field = append(msg.Field, fd)
+	}
+	file := &descriptorpb.FileDescriptorProto{
+		Name:        proto.String(s.pkg + "." + s.name + ".proto"),
+		Package:     proto.String(s.pkg),
+		Syntax:      proto.String("proto3"),
+		MessageType: []*descriptorpb.DescriptorProto{msg},
+	}
+	fileDesc, err := protodesc.NewFile(file, nil)
+	if err != nil {
+		return nil, cerror.WrapError(cerror.ErrProtobufEncodeFailed, err)
+	}
+	return fileDesc.Messages().Get(0), nil
+}
@@ -0,0 +1,369 @@
+// Copyright 2024 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package protobuf
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cenkalti/backoff/v4"
+	"github.com/pingcap/errors"
+	"github.com/pingcap/log"
+	cerror "github.com/pingcap/tiflow/pkg/errors"
+	"github.com/pingcap/tiflow/pkg/httputil"
+	"github.com/pingcap/tiflow/pkg/security"
+	"go.uber.org/zap"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+const (
+	// confluent protobuf wire format, the first byte is always 0, then the 4 bytes schema ID,
+	// and the message indexes, which is a single 0 for the first message in the schema.
+	// https://docs.confluent.io/platform/current/schema-registry/fundamentals/serdes-develop/index.html#wire-format
+	magicByte = uint8(0)
+
+	schemaTypeProtobuf = "PROTOBUF"
+
+	keySchemaSuffix   = "-key"
+	valueSchemaSuffix = "-value"
+)
+
+type registerRequest struct {
+	Schema     string `json:"schema"`
+	SchemaType string `json:"schemaType"`
+}
+
+type registerResponse struct {
+	SchemaID int `json:"id"`
+}
+
+type lookupResponse struct {
+	SchemaID   int    `json:"id"`
+	Schema     string `json:"schema"`
+	SchemaType string `json:"schemaType"`
+}
+
+// schemaCacheEntry is a registered schema.
+type schemaCacheEntry struct {
+	// tableVersion is the table's version which the schema generated from,
+	// encoder use it to detect the schema evolution.
+	tableVersion uint64
+	schemaID     int
+	schema       *messageSchema
+	descriptor   protoreflect.MessageDescriptor
+	header       []byte
+}
+
+// SchemaManager registers protobuf schemas to the confluent schema registry,
+// and looks up schemas by the ID carried in the message header.
+type SchemaManager struct {
+	registryURL string
+	credential  *security.Credential
+
+	cacheRWLock sync.RWMutex
+	// subjectCache is used by the encoder, keyed by the schema subject.
+	subjectCache map[string]*schemaCacheEntry
+	// idCache is used by the decoder, keyed by the schema ID.
+	idCache map[int]*schemaCacheEntry
+}
+
+// NewConfluentSchemaManager creates the schema manager,
+// and tests connectivity to the schema registry.
+func NewConfluentSchemaManager(
+	ctx context.Context,
+	registryURL string,
+	credential *security.Credential,
+) (*SchemaManager, error) {
+	registryURL = strings.TrimRight(registryURL, "/")
+	httpCli, err := httputil.NewClient(credential)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	resp, err := httpCli.Get(ctx, registryURL)
+	if err != nil {
+		log.Error("Test connection to Schema Registry failed", zap.Error(err))
+		return nil, cerror.WrapError(cerror.ErrProtobufSchemaAPIError, err)
+	}
+	defer resp.Body.Close()
+
+	text, err := io.ReadAll(resp.Body)
+	if err != nil {
+		log.Error("Reading response from Schema Registry failed", zap.Error(err))
+		return nil, cerror.WrapError(cerror.ErrProtobufSchemaAPIError, err)
+	}
+	if string(text) != "{}" {
+		log.Error("Unexpected response from Schema Registry", zap.ByteString("response", text))
+		return nil, cerror.ErrProtobufSchemaAPIError.GenWithStackByArgs(
+			"unexpected response from Schema Registry")
+	}
+
+	log.Info("Successfully tested connectivity to Schema Registry",
+		zap.String("registryURL", registryURL))
+	return &SchemaManager{
+		registryURL:  registryURL,
+		credential:   credential,
+		subjectCache: make(map[string]*schemaCacheEntry),
+		idCache:      make(map[int]*schemaCacheEntry),
+	}, nil
+}
+
+// register the schema text under the subject, returns the schema ID.
+// Re-registering an existing schema returns the same ID.
+func (m *SchemaManager) register(ctx context.Context, subject string, schema string) (int, error) {
+	payload, err := json.Marshal(&registerRequest{Schema: schema, SchemaType: schemaTypeProtobuf})
+	if err != nil {
+		return 0, cerror.WrapError(cerror.ErrProtobufSchemaAPIError, err)
+	}
+	uri := m.registryURL + "/subjects/" + url.QueryEscape(subject) + "/versions"
+	req, err := http.NewRequestWithContext(ctx, "POST", uri, bytes.NewReader(payload))
+	if err != nil {
+		return 0, cerror.WrapError(cerror.ErrProtobufSchemaAPIError, err)
+	}
+	req.Header.Add("Accept", "application/vnd.schemaregistry.v1+json, "+
+		"application/vnd.schemaregistry+json, application/json")
+	req.Header.Add("Content-Type", "application/vnd.schemaregistry.v1+json")
+
+	body, status, err := m.do(ctx, req)
+	if err != nil {
+		return 0, errors.Trace(err)
+	}
+	if status != http.StatusOK {
+		// 409 for incompatible schema
+		log.Error("Failed to register schema to the Registry, HTTP error",
+			zap.Int("status", status),
+			zap.String("uri", uri),
+			zap.ByteString("responseBody", body))
+		return 0, cerror.ErrProtobufSchemaAPIError.GenWithStackByArgs(
+			"failed to register schema, status " + strconv.Itoa(status))
+	}
+
+	var resp registerResponse
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return 0, cerror.WrapError(cerror.ErrProtobufSchemaAPIError, err)
+	}
+	if resp.SchemaID == 0 {
+		return 0, cerror.ErrProtobufSchemaAPIError.GenWithStackByArgs(
+			"illegal schema ID returned from Registry")
+	}
+	log.Info("Registered protobuf schema successfully",
+		zap.String("subject", subject), zap.Int("schemaID", resp.SchemaID))
+	return resp.SchemaID, nil
+}
+
+// GetCachedOrRegister returns the schema of the subject for the table version.
+// If the table version is changed, a new schema is generated and registered,
+// so the schema evolves along with the DDL.
+func (m *SchemaManager) GetCachedOrRegister(
+	ctx context.Context,
+	subject string,
+	tableVersion uint64,
+	schemaGen func() (*messageSchema, error),
+) (*schemaCacheEntry, error) {
+	m.cacheRWLock.RLock()
+	entry, ok := m.subjectCache[subject]
+	m.cacheRWLock.RUnlock()
+	if ok && entry.tableVersion == tableVersion {
+		return entry, nil
+	}
+
+	log.Info("Protobuf schema lookup cache miss",
+		zap.String("subject", subject), zap.Uint64("tableVersion", tableVersion))
+	schema, err := schemaGen()
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	text, err := schema.render()
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	descriptor, err := schema.descriptor()
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	id, err := m.register(ctx, subject, text)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+
+	entry = &schemaCacheEntry{
+		tableVersion: tableVersion,
+		schemaID:     id,
+		schema:       schema,
+		descriptor:   descriptor,
+		header:       getMsgHeader(id),
+	}
+	m.cacheRWLock.Lock()
+	m.subjectCache[subject] = entry
+	m.idCache[id] = entry
+	m.cacheRWLock.Unlock()
+	return entry, nil
+}
+
+// Lookup returns the schema by the schema ID, fetch it from the Registry
+// if it's not cached.
+func (m *SchemaManager) Lookup(ctx context.Context, schemaID int) (*schemaCacheEntry, error) {
+	m.cacheRWLock.RLock()
+	entry, ok := m.idCache[schemaID]
+	m.cacheRWLock.RUnlock()
+	if ok {
+		return entry, nil
+	}
+
+	uri := m.registryURL + "/schemas/ids/" + strconv.Itoa(schemaID)
+	req, err := http.NewRequestWithContext(ctx, "GET", uri, nil)
+	if err != nil {
+		return nil, cerror.WrapError(cerror.ErrProtobufSchemaAPIError, err)
+	}
+	req.Header.Add("Accept", "application/vnd.schemaregistry.v1+json, "+
+		"application/vnd.schemaregistry+json, application/json")
+
+	body, status, err := m.do(ctx, req)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	if status == http.StatusNotFound {
+		log.Warn("Specified schema not found in Registry", zap.Int("schemaID", schemaID))
+		return nil, cerror.ErrProtobufSchemaAPIError.GenWithStackByArgs(
+			"schema " + strconv.Itoa(schemaID) + " not found in Registry")
+	}
+	if status != http.StatusOK {
+		log.Error("Failed to query schema from the Registry, HTTP error",
+			zap.Int("status", status),
+			zap.String("uri", uri),
+			zap.ByteString("responseBody", body))
+		return nil, cerror.ErrProtobufSchemaAPIError.GenWithStackByArgs(
+			"failed to query schema, status " + strconv.Itoa(status))
+	}
+
+	var resp lookupResponse
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil, cerror.WrapError(cerror.ErrProtobufSchemaAPIError, err)
+	}
+	schema, err := parseMessageSchema(resp.Schema)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	descriptor, err := schema.descriptor()
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	entry = &schemaCacheEntry{
+		schemaID:   schemaID,
+		schema:     schema,
+		descriptor: descriptor,
+		header:     getMsgHeader(schemaID),
+	}
+	m.cacheRWLock.Lock()
+	m.idCache[schemaID] = entry
+	m.cacheRWLock.Unlock()
+	return entry, nil
+}
+
+// do sends the request, and retries if the server returns 5xx.
+func (m *SchemaManager) do(ctx context.Context, req *http.Request) ([]byte, int, error) {
+	httpCli, err := httputil.NewClient(m.credential)
+	if err != nil {
+		return nil, 0, errors.Trace(err)
+	}
+	var data []byte
+	if req.Body != nil {
+		data, err = io.ReadAll(req.Body)
+		_ = req.Body.Close()
+		if err != nil {
+			return nil, 0, cerror.WrapError(cerror.ErrProtobufSchemaAPIError, err)
+		}
+	}
+
+	expBackoff := backoff.NewExponentialBackOff()
+	expBackoff.MaxInterval = time.Second * 30
+	for {
+		if data != nil {
+			req.Body = io.NopCloser(bytes.NewReader(data))
+		}
+		resp, err := httpCli.Do(req)
+		if err == nil {
+			body, err := io.ReadAll(resp.Body)
+			_ = resp.Body.Close()
+			if err != nil {
+				return nil, 0, cerror.WrapError(cerror.ErrProtobufSchemaAPIError, err)
+			}
+			// retry 4xx codes like 409 & 422 has no meaning since it's non-recoverable
+			if resp.StatusCode < 500 {
+				return body, resp.StatusCode, nil
+			}
+			log.Warn("HTTP server returned with error", zap.Int("status", resp.StatusCode))
+		} else {
+			log.Warn("HTTP request failed", zap.Error(err))
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, 0, errors.Trace(ctx.Err())
+		case <-time.After(expBackoff.NextBackOff()):
+		}
+	}
+}
+
+// getMsgHeader returns the header of the message encoded by the schema,
+// the message index of the only message in the schema is 0.
+func getMsgHeader(schemaID int) []byte {
+	header := make([]byte, 6)
+	header[0] = magicByte
+	binary.BigEndian.PutUint32(header[1:5], uint32(schemaID))
+	header[5] = 0
+	return header
+}
+
+// extractSchemaIDAndBinaryData returns the schema ID in the header and the encoded message.
+func extractSchemaIDAndBinaryData(data []byte) (int, []byte, error) {
+	if len(data) < 6 {
+		return 0, nil, cerror.ErrProtobufInvalidMessage.GenWithStackByArgs(
+			"a protobuf message should have at least 6 bytes")
+	}
+	if data[0] != magicByte {
+		return 0, nil, cerror.ErrProtobufInvalidMessage.GenWithStackByArgs(
+			"magic byte is not match, it should be 0")
+	}
+	schemaID := int(binary.BigEndian.Uint32(data[1:5]))
+	data = data[5:]
+
+	// the message indexes is an array of zigzag varints prefixed by its length,
+	// the length 0 is a shortcut of the indexes [0].
+	count, n := binary.Varint(data)
+	if n <= 0 {
+		return 0, nil, cerror.ErrProtobufInvalidMessage.GenWithStackByArgs("invalid message indexes")
+	}
+	data = data[n:]
+	for i := int64(0); i < count; i++ {
+		index, n := binary.Varint(data)
+		if n <= 0 {
+			return 0, nil, cerror.ErrProtobufInvalidMessage.GenWithStackByArgs("invalid message indexes")
+		}
+		if index != 0 {
+			return 0, nil, cerror.ErrProtobufInvalidMessage.GenWithStackByArgs(
+				"only the first message in the schema is supported")
+		}
+		data = data[n:]
+	}
+	return schemaID, data, nil
+}
@@ -77,7 +77,7 @@ func (e *encoder) AppendRowChangedEvent(
 
 	var claimCheckLocation string
 	if e.config.LargeMessageHandle.EnableClaimCheck() {
-		fileName := claimcheck.NewFileName()
+		fileName := e.claimCheck.NewFileName(event.CommitTs)
 		claimCheckLocation = e.claimCheck.FileNameWithPrefix(fileName)
 		if err = e.claimCheck.WriteMessage(ctx, result.Key, result.Value, fileName); err != nil {
 			return errors.Trace(err)
@@ -0,0 +1,363 @@
+// Copyright 2024 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package codec
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+
+	"github.com/pingcap/errors"
+	"github.com/pingcap/log"
+	"github.com/pingcap/tiflow/cdc/model"
+	"github.com/pingcap/tiflow/pkg/config"
+	"go.uber.org/zap"
+)
+
+const (
+	// defaultMaxSpilledBytes is the max bytes of the messages spilled to the disk,
+	// pushing a future which needs to be spilled blocks once it's exceeded.
+	defaultMaxSpilledBytes = 8 * 1024 * 1024 * 1024
+	// defaultSpillSegmentBytes is the size of a spill file, the following messages
+	// are spilled to a new file once it's exceeded.
+	defaultSpillSegmentBytes = 64 * 1024 * 1024
+)
+
+// spillSegment is a spill file, it's removed once all its messages are read back,
+// or truncated if it's still being written.
+type spillSegment struct {
+	file *os.File
+	// size is the write offset of the file.
+	size int64
+	// pending is the number of the messages in the file which are not read back.
+	pending int
+}
+
+// spilledMessage is the location of a message spilled to the file.
+type spilledMessage struct {
+	segment  *spillSegment
+	offset   int64
+	keyLen   int
+	valueLen int
+}
+
+// SpillQuota is the memory quota of the changefeed which decides when the
+// pending encoded messages are spilled. The bytes of the spilled messages are
+// transferred out of the used memory of the quota, so they don't hold back the
+// following acquisitions, and transferred back once they're read back.
+type SpillQuota interface {
+	// ShouldSpill reports whether the pending messages should be spilled.
+	ShouldSpill() bool
+	// Spill transfers nBytes of the spilled messages out of the used memory.
+	Spill(nBytes uint64)
+	// Unspill transfers nBytes of the messages read back into the used memory.
+	Unspill(nBytes uint64)
+}
+
+// spillQueue is a FIFO queue of the encoded futures. The futures are kept in
+// memory until their messages exceed the threshold or the memory quota of the
+// changefeed decides to spill, then the keys and values of the following messages
+// are spilled to the local files, and read back before the futures are popped.
+// The events of the futures are dropped since they are encoded already, the
+// other fields of the messages, especially the callbacks, are kept in memory,
+// so the ordering and the callbacks of the messages are not affected.
+type spillQueue struct {
+	changefeedID    model.ChangeFeedID
+	dir             string
+	threshold       int64
+	maxSpilledBytes int64
+	segmentBytes    int64
+	// quota is the memory quota of the changefeed, it can be nil.
+	quota atomic.Pointer[SpillQuota]
+
+	mu      sync.Mutex
+	futures []*future
+	// memBytes is the bytes of the messages kept in memory.
+	memBytes int64
+	// spilledBytes is the bytes of the messages spilled to the files.
+	spilledBytes int64
+	// segments are the spill files in the order of creation,
+	// the last one is being written.
+	segments []*spillSegment
+
+	// pushCh is notified once a future is pushed.
+	pushCh chan struct{}
+	// popCh is notified once a future is popped.
+	popCh chan struct{}
+}
+
+func newSpillQueue(changefeedID model.ChangeFeedID, dir string, threshold int64) *spillQueue {
+	return &spillQueue{
+		changefeedID:    changefeedID,
+		dir:             dir,
+		threshold:       threshold,
+		maxSpilledBytes: defaultMaxSpilledBytes,
+		segmentBytes:    defaultSpillSegmentBytes,
+		pushCh:          make(chan struct{}, 1),
+		popCh:           make(chan struct{}, 1),
+	}
+}
+
+// setMemoryQuota sets the memory quota of the changefeed.
+func (q *spillQueue) setMemoryQuota(quota SpillQuota) {
+	q.quota.Store(&quota)
+}
+
+func (q *spillQueue) getMemoryQuota() SpillQuota {
+	if quota := q.quota.Load(); quota != nil {
+		return *quota
+	}
+	return nil
+}
+
+// push appends the ready future to the queue. It blocks if the future needs to
+// be spilled but the spilled bytes exceed the limit, until enough futures are
+// popped, so the encoder group is throttled by the producer.
+func (q *spillQueue) push(ctx context.Context, f *future) error {
+	// the events are encoded already, release them.
+	f.events = nil
+	var size int64
+	for _, m := range f.Messages {
+		size += int64(len(m.Key) + len(m.Value))
+	}
+	for {
+		pushed, err := q.tryPush(f, size)
+		if err != nil || pushed {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return errors.Trace(ctx.Err())
+		case <-q.popCh:
+		}
+	}
+}
+
+func (q *spillQueue) tryPush(f *future, size int64) (bool, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	quota := q.getMemoryQuota()
+	if q.memBytes+size > q.threshold || (quota != nil && quota.ShouldSpill()) {
+		// a future is always accepted if nothing is spilled,
+		// otherwise a huge one blocks forever.
+		if q.spilledBytes > 0 && q.spilledBytes+size > q.maxSpilledBytes {
+			return false, nil
+		}
+		if err := q.spill(f); err != nil {
+			return false, errors.Trace(err)
+		}
+		q.spilledBytes += size
+		if quota != nil && size > 0 {
+			quota.Spill(uint64(size))
+			f.spillQuota, f.spillQuotaBytes = quota, uint64(size)
+		}
+	} else {
+		q.memBytes += size
+	}
+	q.futures = append(q.futures, f)
+
+	select {
+	case q.pushCh <- struct{}{}:
+	default:
+	}
+	return true, nil
+}
+
+// spill writes the keys and values of the messages to the last spill file.
+func (q *spillQueue) spill(f *future) error {
+	if len(f.Messages) == 0 {
+		return nil
+	}
+	segment, err := q.writableSegment()
+	if err != nil {
+		return errors.Trace(err)
+	}
+	f.spilled = make([]spilledMessage, 0, len(f.Messages))
+	for _, m := range f.Messages {
+		loc := spilledMessage{
+			segment: segment, offset: segment.size,
+			keyLen: len(m.Key), valueLen: len(m.Value),
+		}
+		if _, err := segment.file.WriteAt(m.Key, loc.offset); err != nil {
+			return errors.Trace(err)
+		}
+		if _, err := segment.file.WriteAt(m.Value, loc.offset+int64(loc.keyLen)); err != nil {
+			return errors.Trace(err)
+		}
+		segment.size += int64(loc.keyLen + loc.valueLen)
+		segment.pending++
+		m.Key, m.Value = nil, nil
+		f.spilled = append(f.spilled, loc)
+	}
+	return nil
+}
+
+// writableSegment returns the last spill file, a new one is created if there
+// is no spill file or the last one exceeds the segment size.
+func (q *spillQueue) writableSegment() (*spillSegment, error) {
+	if len(q.segments) > 0 {
+		last := q.segments[len(q.segments)-1]
+		if last.size < q.segmentBytes {
+			return last, nil
+		}
+	}
+	if err := os.MkdirAll(q.dir, 0o755); err != nil {
+		return nil, errors.Trace(err)
+	}
+	file, err := os.CreateTemp(q.dir, "spill-*")
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	if len(q.segments) == 0 {
+		log.Info("start spilling the encoded messages to the local disk",
+			zap.String("namespace", q.changefeedID.Namespace),
+			zap.String("changefeed", q.changefeedID.ID),
+			zap.String("file", file.Name()),
+			zap.Int64("threshold", q.threshold))
+	}
+	segment := &spillSegment{file: file}
+	q.segments = append(q.segments, segment)
+	return segment, nil
+}
+
+// pop removes the first future from the queue, and reads back its messages
+// if they are spilled. It blocks until a future is available.
+func (q *spillQueue) pop(ctx context.Context) (*future, error) {
+	for {
+		f, err := q.tryPop()
+		if err != nil || f != nil {
+			return f, err
+		}
+		select {
+		case <-ctx.Done():
+			return nil, errors.Trace(ctx.Err())
+		case <-q.pushCh:
+		}
+	}
+}
+
+func (q *spillQueue) tryPop() (*future, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if len(q.futures) == 0 {
+		return nil, nil
+	}
+	f := q.futures[0]
+	q.futures[0] = nil
+	q.futures = q.futures[1:]
+	defer func() {
+		select {
+		case q.popCh <- struct{}{}:
+		default:
+		}
+	}()
+	if f.spilled == nil {
+		for _, m := range f.Messages {
+			q.memBytes -= int64(len(m.Key) + len(m.Value))
+		}
+		return f, nil
+	}
+
+	for i, loc := range f.spilled {
+		buf := make([]byte, loc.keyLen+loc.valueLen)
+		if _, err := loc.segment.file.ReadAt(buf, loc.offset); err != nil {
+			return nil, errors.Trace(err)
+		}
+		m := f.Messages[i]
+		if loc.keyLen != 0 {
+			m.Key = buf[:loc.keyLen]
+		}
+		if loc.valueLen != 0 {
+			m.Value = buf[loc.keyLen:]
+		}
+		q.spilledBytes -= int64(len(buf))
+		loc.segment.pending--
+		if loc.segment.pending == 0 {
+			if err := q.releaseSegment(loc.segment); err != nil {
+				return nil, errors.Trace(err)
+			}
+		}
+	}
+	f.spilled = nil
+	f.unspillQuota()
+	return f, nil
+}
+
+// unspillQuota transfers the bytes of the spilled messages back to the memory quota.
+func (f *future) unspillQuota() {
+	if f.spillQuota != nil {
+		f.spillQuota.Unspill(f.spillQuotaBytes)
+		f.spillQuota, f.spillQuotaBytes = nil, 0
+	}
+}
+
+// releaseSegment removes the spill file whose messages are all read back,
+// the last one is truncated and reused instead.
+func (q *spillQueue) releaseSegment(segment *spillSegment) error {
+	if segment == q.segments[len(q.segments)-1] {
+		if err := segment.file.Truncate(0); err != nil {
+			return errors.Trace(err)
+		}
+		segment.size = 0
+		return nil
+	}
+	for i, s := range q.segments {
+		if s == segment {
+			q.segments = append(q.segments[:i], q.segments[i+1:]...)
+			break
+		}
+	}
+	removeSpillFile(segment.file)
+	return nil
+}
+
+// getSpilledBytes returns the bytes of the messages spilled to the files.
+func (q *spillQueue) getSpilledBytes() int64 {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.spilledBytes
+}
+
+// close removes the spill files.
+func (q *spillQueue) close() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for _, segment := range q.segments {
+		removeSpillFile(segment.file)
+	}
+	for _, f := range q.futures {
+		f.unspillQuota()
+	}
+	q.segments = nil
+	q.futures = nil
+}
+
+func removeSpillFile(file *os.File) {
+	name := file.Name()
+	if err := file.Close(); err != nil {
+		log.Warn("close spill file failed", zap.String("file", name), zap.Error(err))
+	}
+	if err := os.Remove(name); err != nil {
+		log.Warn("remove spill file failed", zap.String("file", name), zap.Error(err))
+	}
+}
+
+// spillDir returns the directory of the spill files of the changefeed.
+func spillDir(dataDir string, changefeedID model.ChangeFeedID) string {
+	return filepath.Join(dataDir, config.DefaultMQSpillDir, changefeedID.Namespace, changefeedID.ID)
+}
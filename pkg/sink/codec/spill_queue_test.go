@@ -0,0 +1,199 @@
+// Copyright 2024 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package codec
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/pingcap/errors"
+	"github.com/pingcap/tiflow/cdc/model"
+	"github.com/pingcap/tiflow/cdc/sink/dmlsink"
+	"github.com/pingcap/tiflow/pkg/sink/codec/common"
+	"github.com/stretchr/testify/require"
+)
+
+func newSpillTestFuture(i int, callback func()) *future {
+	f := newFuture(model.TopicPartitionKey{Topic: "test"})
+	f.Messages = []*common.Message{{
+		Key:      []byte(fmt.Sprintf("key-%d", i)),
+		Value:    []byte(fmt.Sprintf("value-%d", i)),
+		Callback: callback,
+	}}
+	return f
+}
+
+func TestSpillQueue(t *testing.T) {
+	ctx := context.Background()
+	changefeedID := model.DefaultChangeFeedID("test")
+	dir := spillDir(t.TempDir(), changefeedID)
+	q := newSpillQueue(changefeedID, dir, 20)
+	defer q.close()
+
+	called := 0
+	for i := 0; i < 5; i++ {
+		require.NoError(t, q.push(ctx, newSpillTestFuture(i, func() { called++ })))
+	}
+	// only the first message is kept in memory.
+	require.Equal(t, int64(48), q.getSpilledBytes())
+	require.Len(t, q.segments, 1)
+	segment := q.segments[0]
+
+	for i := 0; i < 5; i++ {
+		f, err := q.pop(ctx)
+		require.NoError(t, err)
+		require.Len(t, f.Messages, 1)
+		require.Equal(t, []byte(fmt.Sprintf("key-%d", i)), f.Messages[0].Key)
+		require.Equal(t, []byte(fmt.Sprintf("value-%d", i)), f.Messages[0].Value)
+		f.Messages[0].Callback()
+	}
+	require.Equal(t, 5, called)
+	require.Equal(t, int64(0), q.getSpilledBytes())
+
+	// the file is truncated once all the spilled messages are popped.
+	info, err := segment.file.Stat()
+	require.NoError(t, err)
+	require.Equal(t, int64(0), info.Size())
+
+	// pop blocks until a future is pushed.
+	cctx, cancel := context.WithCancel(ctx)
+	cancel()
+	_, err = q.pop(cctx)
+	require.Equal(t, context.Canceled, errors.Cause(err))
+
+	name := segment.file.Name()
+	q.close()
+	_, err = os.Stat(name)
+	require.True(t, os.IsNotExist(err))
+}
+
+func TestSpillQueueRotateFiles(t *testing.T) {
+	ctx := context.Background()
+	changefeedID := model.DefaultChangeFeedID("test")
+	q := newSpillQueue(changefeedID, spillDir(t.TempDir(), changefeedID), 0)
+	defer q.close()
+	// each file holds 2 messages.
+	q.segmentBytes = 24
+
+	for i := 0; i < 5; i++ {
+		require.NoError(t, q.push(ctx, newSpillTestFuture(i, nil)))
+	}
+	require.Len(t, q.segments, 3)
+	first := q.segments[0].file.Name()
+
+	// the first file is removed once its messages are read back.
+	for i := 0; i < 2; i++ {
+		f, err := q.pop(ctx)
+		require.NoError(t, err)
+		require.Equal(t, []byte(fmt.Sprintf("key-%d", i)), f.Messages[0].Key)
+	}
+	require.Len(t, q.segments, 2)
+	_, err := os.Stat(first)
+	require.True(t, os.IsNotExist(err))
+
+	for i := 2; i < 5; i++ {
+		f, err := q.pop(ctx)
+		require.NoError(t, err)
+		require.Equal(t, []byte(fmt.Sprintf("value-%d", i)), f.Messages[0].Value)
+	}
+	require.Len(t, q.segments, 1)
+	require.Equal(t, int64(0), q.segments[0].size)
+}
+
+func TestSpillQueueBackpressure(t *testing.T) {
+	ctx := context.Background()
+	changefeedID := model.DefaultChangeFeedID("test")
+	q := newSpillQueue(changefeedID, spillDir(t.TempDir(), changefeedID), 0)
+	defer q.close()
+	q.maxSpilledBytes = 24
+
+	f := newSpillTestFuture(0, nil)
+	f.events = []*dmlsink.RowChangeCallbackableEvent{{}}
+	require.NoError(t, q.push(ctx, f))
+	// the events are released once the future is pushed.
+	require.Nil(t, f.events)
+	require.NoError(t, q.push(ctx, newSpillTestFuture(1, nil)))
+
+	// the spilled bytes exceed the limit, push blocks until a future is popped.
+	pushed := make(chan error, 1)
+	go func() {
+		pushed <- q.push(ctx, newSpillTestFuture(2, nil))
+	}()
+	select {
+	case <-pushed:
+		require.FailNow(t, "push should be blocked")
+	case <-time.After(100 * time.Millisecond):
+	}
+	_, err := q.pop(ctx)
+	require.NoError(t, err)
+	require.NoError(t, <-pushed)
+
+	cctx, cancel := context.WithCancel(ctx)
+	cancel()
+	err = q.push(cctx, newSpillTestFuture(3, nil))
+	require.Equal(t, context.Canceled, errors.Cause(err))
+}
+
+// mockSpillQuota is a SpillQuota whose spilling is decided by the test.
+type mockSpillQuota struct {
+	spill        bool
+	spilledBytes uint64
+}
+
+func (m *mockSpillQuota) ShouldSpill() bool { return m.spill }
+
+func (m *mockSpillQuota) Spill(nBytes uint64) { m.spilledBytes += nBytes }
+
+func (m *mockSpillQuota) Unspill(nBytes uint64) { m.spilledBytes -= nBytes }
+
+func TestSpillQueueMemoryQuota(t *testing.T) {
+	ctx := context.Background()
+	changefeedID := model.DefaultChangeFeedID("test")
+	q := newSpillQueue(changefeedID, spillDir(t.TempDir(), changefeedID), 1024)
+	defer q.close()
+
+	require.NoError(t, q.push(ctx, newSpillTestFuture(0, nil)))
+	require.Equal(t, int64(0), q.getSpilledBytes())
+
+	// the messages are spilled once the memory quota decides to spill,
+	// even if the threshold is not exceeded, and their bytes are transferred
+	// out of the quota.
+	quota := &mockSpillQuota{spill: true}
+	q.setMemoryQuota(quota)
+	require.NoError(t, q.push(ctx, newSpillTestFuture(1, nil)))
+	require.Equal(t, int64(12), q.getSpilledBytes())
+	require.Equal(t, uint64(12), quota.spilledBytes)
+
+	quota.spill = false
+	require.NoError(t, q.push(ctx, newSpillTestFuture(2, nil)))
+	require.Equal(t, int64(12), q.getSpilledBytes())
+
+	for i := 0; i < 3; i++ {
+		f, err := q.pop(ctx)
+		require.NoError(t, err)
+		require.Equal(t, []byte(fmt.Sprintf("key-%d", i)), f.Messages[0].Key)
+	}
+	// the bytes are transferred back once they're read back.
+	require.Equal(t, uint64(0), quota.spilledBytes)
+
+	// and the bytes of the messages never read back are transferred back on close.
+	quota.spill = true
+	require.NoError(t, q.push(ctx, newSpillTestFuture(3, nil)))
+	require.Equal(t, uint64(12), quota.spilledBytes)
+	q.close()
+	require.Equal(t, uint64(0), quota.spilledBytes)
+}
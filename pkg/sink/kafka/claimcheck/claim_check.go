@@ -16,6 +16,7 @@ package claimcheck
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"strings"
 	"time"
 
@@ -115,9 +116,18 @@ func (c *ClaimCheck) CleanMetrics() {
 }
 
 // NewFileName return the file name for the message which is delivered to the external storage system.
-// UUID V4 is used to generate random and unique file names.
+// The file name starts with the prefix of the changefeed, so that the storage can be shared by the changefeeds,
+// followed by the commit ts of the event, so that the expired files can be found by the checkpoint ts,
+// and UUID V4 is used to generate random and unique file names.
 // This should not exceed the S3 object name length limit.
 // ref https://docs.aws.amazon.com/AmazonS3/latest/userguide/object-keys.html
-func NewFileName() string {
-	return uuid.NewString() + ".json"
+func (c *ClaimCheck) NewFileName(commitTs uint64) string {
+	return fmt.Sprintf("%s%d_%s%s", FilePrefix(c.changefeedID), commitTs, uuid.NewString(), fileExtension)
+}
+
+// FilePrefix returns the prefix of the claim-check file names of the changefeed, like `<namespace>_<changefeed>_`.
+// The namespace and the changefeed ID never contain `_`, so the prefix of a changefeed never matches the files
+// of the others.
+func FilePrefix(changefeedID model.ChangeFeedID) string {
+	return fmt.Sprintf("%s_%s_", changefeedID.Namespace, changefeedID.ID)
 }
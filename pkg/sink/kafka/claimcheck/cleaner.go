@@ -0,0 +1,228 @@
+// Copyright 2024 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package claimcheck
+
+import (
+	"context"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pingcap/log"
+	"github.com/pingcap/tidb/br/pkg/storage"
+	"github.com/pingcap/tiflow/cdc/model"
+	"github.com/pingcap/tiflow/pkg/config"
+	"github.com/pingcap/tiflow/pkg/errors"
+	"github.com/pingcap/tiflow/pkg/util"
+	"github.com/robfig/cron"
+	"github.com/tikv/client-go/v2/oracle"
+	"go.uber.org/atomic"
+	"go.uber.org/zap"
+)
+
+const fileExtension = ".json"
+
+// fileNameRegexp matches the suffix of the file name generated by NewFileName, like `<commitTs>_<uuid>.json`.
+// The files named `<uuid>.json` by the previous versions don't carry the changefeed and the commit ts,
+// so they are never matched and removed by the Cleaner, they have to be removed
+// manually, e.g. by the lifecycle rules of the storage.
+var fileNameRegexp = regexp.MustCompile(`^(\d+)_[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}\.json$`)
+
+// ParseFileName returns the commit ts of the claim-check file of the changefeed,
+// ok is false if the file is not generated by NewFileName for the changefeed.
+func ParseFileName(changefeedID model.ChangeFeedID, fileName string) (commitTs uint64, ok bool) {
+	suffix, ok := strings.CutPrefix(fileName, FilePrefix(changefeedID))
+	if !ok {
+		return 0, false
+	}
+	matches := fileNameRegexp.FindStringSubmatch(suffix)
+	if matches == nil {
+		return 0, false
+	}
+	commitTs, err := strconv.ParseUint(matches[1], 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	return commitTs, true
+}
+
+// ExpiredFiles returns the claim-check files of the changefeed whose commit ts is earlier than
+// the checkpoint ts by more than the ttl. The consumers are expected to fetch
+// the files within the ttl after the checkpoint passes them, so such files,
+// including the orphan files written by the failed sends, are safe to remove.
+// The files of the other changefeeds sharing the storage are never returned.
+func ExpiredFiles(
+	ctx context.Context,
+	extStorage storage.ExternalStorage,
+	changefeedID model.ChangeFeedID,
+	checkpointTs model.Ts,
+	ttl time.Duration,
+) ([]string, error) {
+	if checkpointTs == 0 {
+		return nil, nil
+	}
+	expiredTs := oracle.GoTimeToTS(oracle.GetTimeFromTS(checkpointTs).Add(-ttl))
+
+	var files []string
+	// only part of the storages support the ObjPrefix, so the file names are checked again.
+	opt := &storage.WalkOption{ObjPrefix: FilePrefix(changefeedID)}
+	err := extStorage.WalkDir(ctx, opt, func(path string, _ int64) error {
+		path = strings.TrimPrefix(path, "/")
+		commitTs, ok := ParseFileName(changefeedID, path)
+		if ok && commitTs < expiredTs {
+			files = append(files, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, errors.ErrExternalStorageAPI.Wrap(err).GenWithStackByArgs("WalkDir")
+	}
+	return files, nil
+}
+
+// RemoveExpiredFiles removes the expired claim-check files of the changefeed,
+// and returns the number of the removed files.
+func RemoveExpiredFiles(
+	ctx context.Context,
+	extStorage storage.ExternalStorage,
+	changefeedID model.ChangeFeedID,
+	checkpointTs model.Ts,
+	ttl time.Duration,
+) (uint64, error) {
+	files, err := ExpiredFiles(ctx, extStorage, changefeedID, checkpointTs, ttl)
+	if err != nil {
+		return 0, errors.Trace(err)
+	}
+	if err := util.DeleteFilesInExtStorage(ctx, extStorage, files); err != nil {
+		return 0, errors.Trace(err)
+	}
+	return uint64(len(files)), nil
+}
+
+// Cleaner removes the expired claim-check files of the changefeed in the background.
+type Cleaner struct {
+	changefeedID model.ChangeFeedID
+	storage      storage.ExternalStorage
+	ttl          time.Duration
+	schedule     cron.Schedule
+
+	checkpointTs atomic.Uint64
+	isRunning    atomic.Bool
+	// mu protects stopped, no cleanup is started after the Cleaner is stopped.
+	mu      sync.Mutex
+	stopped bool
+	// wg waits for the running cleanup when the Cleaner is stopped.
+	wg sync.WaitGroup
+}
+
+// NewCleaner creates a Cleaner, it returns nil if the claim-check is disabled,
+// or the claim-check files are never expired.
+func NewCleaner(
+	ctx context.Context, cfg *config.LargeMessageHandleConfig, changefeedID model.ChangeFeedID,
+) (*Cleaner, error) {
+	if !cfg.EnableClaimCheck() || cfg.ClaimCheckFileExpirationDays <= 0 {
+		return nil, nil
+	}
+	cronSpec := cfg.ClaimCheckFileCleanupCronSpec
+	if cronSpec == "" {
+		cronSpec = config.DefaultClaimCheckFileCleanupCronSpec
+	}
+	schedule, err := cron.Parse(cronSpec)
+	if err != nil {
+		return nil, errors.WrapError(errors.ErrInvalidReplicaConfig, err)
+	}
+	externalStorage, err := util.GetExternalStorageWithDefaultTimeout(ctx, cfg.ClaimCheckStorageURI)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+
+	return &Cleaner{
+		changefeedID: changefeedID,
+		storage:      externalStorage,
+		ttl:          time.Duration(cfg.ClaimCheckFileExpirationDays) * time.Hour * 24,
+		schedule:     schedule,
+	}, nil
+}
+
+// UpdateCheckpointTs updates the checkpoint ts of the changefeed.
+func (c *Cleaner) UpdateCheckpointTs(ts model.Ts) {
+	c.checkpointTs.Store(ts)
+}
+
+// Run schedules the cleanup until the context is done, and then waits for
+// the running cleanup to exit and closes the storage.
+func (c *Cleaner) Run(ctx context.Context) {
+	cr := cron.New()
+	cr.Schedule(c.schedule, cron.FuncJob(func() {
+		c.mu.Lock()
+		if c.stopped {
+			c.mu.Unlock()
+			return
+		}
+		c.wg.Add(1)
+		c.mu.Unlock()
+		defer c.wg.Done()
+		c.cleanup(ctx)
+	}))
+	cr.Start()
+	defer func() {
+		cr.Stop()
+		c.mu.Lock()
+		c.stopped = true
+		c.mu.Unlock()
+		c.wg.Wait()
+		c.storage.Close()
+	}()
+	log.Info("start schedule cleanup expired claim-check files",
+		zap.String("namespace", c.changefeedID.Namespace),
+		zap.String("changefeed", c.changefeedID.ID),
+		zap.Duration("ttl", c.ttl))
+
+	<-ctx.Done()
+	log.Info("stop schedule cleanup expired claim-check files",
+		zap.String("namespace", c.changefeedID.Namespace),
+		zap.String("changefeed", c.changefeedID.ID),
+		zap.Error(ctx.Err()))
+}
+
+func (c *Cleaner) cleanup(ctx context.Context) {
+	if !c.isRunning.CompareAndSwap(false, true) {
+		log.Warn("cleanup expired claim-check files is already running, skip this round",
+			zap.String("namespace", c.changefeedID.Namespace),
+			zap.String("changefeed", c.changefeedID.ID))
+		return
+	}
+	defer c.isRunning.Store(false)
+
+	start := time.Now()
+	checkpointTs := c.checkpointTs.Load()
+	cnt, err := RemoveExpiredFiles(ctx, c.storage, c.changefeedID, checkpointTs, c.ttl)
+	if err != nil {
+		log.Error("failed to remove expired claim-check files",
+			zap.String("namespace", c.changefeedID.Namespace),
+			zap.String("changefeed", c.changefeedID.ID),
+			zap.Uint64("checkpointTs", checkpointTs),
+			zap.Duration("cost", time.Since(start)),
+			zap.Error(err))
+		return
+	}
+	log.Info("remove expired claim-check files",
+		zap.String("namespace", c.changefeedID.Namespace),
+		zap.String("changefeed", c.changefeedID.ID),
+		zap.Uint64("checkpointTs", checkpointTs),
+		zap.Uint64("count", cnt),
+		zap.Duration("cost", time.Since(start)))
+}
@@ -0,0 +1,163 @@
+// Copyright 2024 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package claimcheck
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pingcap/tiflow/cdc/model"
+	"github.com/pingcap/tiflow/pkg/config"
+	"github.com/pingcap/tiflow/pkg/util"
+	"github.com/stretchr/testify/require"
+	"github.com/tikv/client-go/v2/oracle"
+)
+
+func TestParseFileName(t *testing.T) {
+	changefeedID := model.DefaultChangeFeedID("test")
+	claimCheck := &ClaimCheck{changefeedID: changefeedID}
+	fileName := claimCheck.NewFileName(12345)
+	require.True(t, strings.HasPrefix(fileName, "default_test_12345_"))
+	commitTs, ok := ParseFileName(changefeedID, fileName)
+	require.True(t, ok)
+	require.Equal(t, uint64(12345), commitTs)
+
+	// the files of the other changefeeds are not matched, even if the ID is a prefix.
+	_, ok = ParseFileName(model.DefaultChangeFeedID("tes"), fileName)
+	require.False(t, ok)
+	_, ok = ParseFileName(model.DefaultChangeFeedID("test-1"), fileName)
+	require.False(t, ok)
+	_, ok = ParseFileName(model.ChangeFeedID{Namespace: "other", ID: "test"}, fileName)
+	require.False(t, ok)
+
+	// the file names of the previous versions don't contain the changefeed and the commit ts.
+	_, ok = ParseFileName(changefeedID, "7c3e8ce9-3f4e-4c8f-9d5f-1c1b1e7a8f0d.json")
+	require.False(t, ok)
+	_, ok = ParseFileName(changefeedID, "metadata")
+	require.False(t, ok)
+}
+
+func TestRemoveExpiredFiles(t *testing.T) {
+	ctx := context.Background()
+	extStorage, err := util.GetExternalStorageWithDefaultTimeout(ctx, "file://"+t.TempDir())
+	require.NoError(t, err)
+
+	changefeedID := model.DefaultChangeFeedID("test")
+	claimCheck := &ClaimCheck{changefeedID: changefeedID}
+	// the other changefeed shares the same storage.
+	otherClaimCheck := &ClaimCheck{changefeedID: model.DefaultChangeFeedID("test-1")}
+
+	now := time.Now()
+	checkpointTs := oracle.GoTimeToTS(now)
+	expired := claimCheck.NewFileName(oracle.GoTimeToTS(now.Add(-25 * time.Hour)))
+	kept := claimCheck.NewFileName(oracle.GoTimeToTS(now.Add(-23 * time.Hour)))
+	other := otherClaimCheck.NewFileName(oracle.GoTimeToTS(now.Add(-25 * time.Hour)))
+	unknown := "unknown.json"
+	for _, name := range []string{expired, kept, other, unknown} {
+		require.NoError(t, extStorage.WriteFile(ctx, name, []byte("value")))
+	}
+
+	files, err := ExpiredFiles(ctx, extStorage, changefeedID, checkpointTs, 24*time.Hour)
+	require.NoError(t, err)
+	require.Equal(t, []string{expired}, files)
+
+	// nothing is removed before the checkpoint is known.
+	cnt, err := RemoveExpiredFiles(ctx, extStorage, changefeedID, 0, 24*time.Hour)
+	require.NoError(t, err)
+	require.Equal(t, uint64(0), cnt)
+
+	cnt, err = RemoveExpiredFiles(ctx, extStorage, changefeedID, checkpointTs, 24*time.Hour)
+	require.NoError(t, err)
+	require.Equal(t, uint64(1), cnt)
+	for name, exists := range map[string]bool{expired: false, kept: true, other: true, unknown: true} {
+		ok, err := extStorage.FileExists(ctx, name)
+		require.NoError(t, err)
+		require.Equal(t, exists, ok)
+	}
+}
+
+func TestNewCleaner(t *testing.T) {
+	ctx := context.Background()
+	changefeedID := model.DefaultChangeFeedID("test")
+	largeHandleConfig := config.NewDefaultLargeMessageHandleConfig()
+
+	cleaner, err := NewCleaner(ctx, largeHandleConfig, changefeedID)
+	require.NoError(t, err)
+	require.Nil(t, cleaner)
+
+	// the files are never expired by default.
+	largeHandleConfig.LargeMessageHandleOption = config.LargeMessageHandleOptionClaimCheck
+	largeHandleConfig.ClaimCheckStorageURI = "file://" + t.TempDir()
+	cleaner, err = NewCleaner(ctx, largeHandleConfig, changefeedID)
+	require.NoError(t, err)
+	require.Nil(t, cleaner)
+
+	largeHandleConfig.ClaimCheckFileExpirationDays = 1
+	cleaner, err = NewCleaner(ctx, largeHandleConfig, changefeedID)
+	require.NoError(t, err)
+	require.NotNil(t, cleaner)
+
+	largeHandleConfig.ClaimCheckFileCleanupCronSpec = "invalid"
+	_, err = NewCleaner(ctx, largeHandleConfig, changefeedID)
+	require.Error(t, err)
+}
+
+func TestCleanerRun(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	extStorage, err := util.GetExternalStorageWithDefaultTimeout(ctx, "file://"+dir)
+	require.NoError(t, err)
+
+	changefeedID := model.DefaultChangeFeedID("test")
+	now := time.Now()
+	expired := (&ClaimCheck{changefeedID: changefeedID}).NewFileName(oracle.GoTimeToTS(now.Add(-25 * time.Hour)))
+	legacy := "7c3e8ce9-3f4e-4c8f-9d5f-1c1b1e7a8f0d.json"
+	for _, name := range []string{expired, legacy} {
+		require.NoError(t, extStorage.WriteFile(ctx, name, []byte("value")))
+	}
+
+	largeHandleConfig := config.NewDefaultLargeMessageHandleConfig()
+	largeHandleConfig.LargeMessageHandleOption = config.LargeMessageHandleOptionClaimCheck
+	largeHandleConfig.ClaimCheckStorageURI = "file://" + dir
+	largeHandleConfig.ClaimCheckFileExpirationDays = 1
+	largeHandleConfig.ClaimCheckFileCleanupCronSpec = "@every 1s"
+	cleaner, err := NewCleaner(ctx, largeHandleConfig, changefeedID)
+	require.NoError(t, err)
+	cleaner.UpdateCheckpointTs(oracle.GoTimeToTS(now))
+
+	runCtx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		cleaner.Run(runCtx)
+	}()
+	require.Eventually(t, func() bool {
+		ok, err := extStorage.FileExists(ctx, expired)
+		return err == nil && !ok
+	}, 10*time.Second, 100*time.Millisecond)
+
+	// Run returns after the context is canceled.
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(10 * time.Second):
+		require.FailNow(t, "cleaner is not stopped")
+	}
+	// the legacy files without the commit ts are never removed.
+	ok, err := extStorage.FileExists(ctx, legacy)
+	require.NoError(t, err)
+	require.True(t, ok)
+}
@@ -35,6 +35,11 @@ type Factory interface {
 	SyncProducer(ctx context.Context) (SyncProducer, error)
 	// AsyncProducer creates an async producer to writer message to kafka
 	AsyncProducer(ctx context.Context, failpointCh chan error) (AsyncProducer, error)
+	// TransactionalProducer creates an async producer which sends messages in
+	// kafka transactions with the given transactional id.
+	TransactionalProducer(
+		ctx context.Context, transactionalID string, failpointCh chan error,
+	) (TransactionalProducer, error)
 	// MetricsCollector returns the kafka metrics collector
 	MetricsCollector(role util.Role, adminClient ClusterAdminClient) MetricsCollector
 }
@@ -82,6 +87,21 @@ type AsyncProducer interface {
 	AsyncRunCallback(ctx context.Context) error
 }
 
+// TransactionalProducer is the kafka async producer which sends messages in
+// kafka transactions. The messages of an aborted transaction are never seen by
+// the consumers reading with `isolation.level=read_committed`.
+type TransactionalProducer interface {
+	AsyncProducer
+
+	// BeginTxn begins a new transaction, the messages sent afterwards belong to it.
+	BeginTxn() error
+	// CommitTxn waits for all messages of the current transaction to be
+	// acknowledged, and then commits the transaction.
+	CommitTxn(ctx context.Context) error
+	// AbortTxn aborts the current transaction.
+	AbortTxn(ctx context.Context) error
+}
+
 type saramaSyncProducer struct {
 	id       model.ChangeFeedID
 	client   sarama.Client
@@ -268,3 +288,29 @@ func (p *saramaAsyncProducer) AsyncSend(ctx context.Context, topic string, parti
 	}
 	return nil
 }
+
+type saramaTransactionalProducer struct {
+	*saramaAsyncProducer
+}
+
+func (p *saramaTransactionalProducer) BeginTxn() error {
+	if err := p.producer.BeginTxn(); err != nil {
+		return cerror.WrapError(cerror.ErrKafkaTransactionFailed, err)
+	}
+	return nil
+}
+
+// CommitTxn flushes the messages of the transaction before committing it.
+func (p *saramaTransactionalProducer) CommitTxn(_ context.Context) error {
+	if err := p.producer.CommitTxn(); err != nil {
+		return cerror.WrapError(cerror.ErrKafkaTransactionFailed, err)
+	}
+	return nil
+}
+
+func (p *saramaTransactionalProducer) AbortTxn(_ context.Context) error {
+	if err := p.producer.AbortTxn(); err != nil {
+		return cerror.WrapError(cerror.ErrKafkaTransactionFailed, err)
+	}
+	return nil
+}
@@ -0,0 +1,220 @@
+// Copyright 2024 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kafka
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/pingcap/errors"
+	"github.com/pingcap/tiflow/cdc/model"
+	cerror "github.com/pingcap/tiflow/pkg/errors"
+	"github.com/pingcap/tiflow/pkg/sink/codec/common"
+)
+
+// MockCluster is a mock kafka cluster which keeps the messages of the committed
+// transactions. Like the kafka transaction coordinator, it fences the previous
+// producer when a new producer with the same transactional id is created.
+type MockCluster struct {
+	mu sync.Mutex
+	// epochs holds the latest producer epoch of each transactional id.
+	epochs    map[string]int
+	committed map[string][]*common.Message
+	aborted   int
+	commitErr error
+}
+
+// NewMockCluster creates a mock kafka cluster.
+func NewMockCluster() *MockCluster {
+	return &MockCluster{
+		epochs:    make(map[string]int),
+		committed: make(map[string][]*common.Message),
+	}
+}
+
+// FactoryCreator returns a FactoryCreator whose factories share the cluster.
+func (c *MockCluster) FactoryCreator() FactoryCreator {
+	return func(o *Options, changefeedID model.ChangeFeedID) (Factory, error) {
+		return &MockFactory{
+			o:            o,
+			changefeedID: changefeedID,
+			cluster:      c,
+		}, nil
+	}
+}
+
+// InjectCommitError makes the next commit fail with the given error.
+func (c *MockCluster) InjectCommitError(err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.commitErr = err
+}
+
+// GetMessages returns the committed messages of the topic partition.
+func (c *MockCluster) GetMessages(topic string, partition int32) []*common.Message {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.committed[fmt.Sprintf("%s-%d", topic, partition)]
+}
+
+// AbortedTxnCount returns the number of the aborted transactions.
+func (c *MockCluster) AbortedTxnCount() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.aborted
+}
+
+func (c *MockCluster) initProducer(transactionalID string) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.epochs[transactionalID]++
+	return c.epochs[transactionalID]
+}
+
+func (c *MockCluster) checkFenced(transactionalID string, epoch int) error {
+	if c.epochs[transactionalID] != epoch {
+		return cerror.ErrKafkaTransactionFailed.GenWithStack(
+			"producer with transactional id %s is fenced", transactionalID)
+	}
+	return nil
+}
+
+func (c *MockCluster) commit(transactionalID string, epoch int, messages []mockTxnMessage) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := c.checkFenced(transactionalID, epoch); err != nil {
+		return err
+	}
+	if c.commitErr != nil {
+		err := c.commitErr
+		c.commitErr = nil
+		return cerror.WrapError(cerror.ErrKafkaTransactionFailed, err)
+	}
+	for _, m := range messages {
+		key := fmt.Sprintf("%s-%d", m.topic, m.partition)
+		c.committed[key] = append(c.committed[key], m.message)
+	}
+	return nil
+}
+
+func (c *MockCluster) abort(transactionalID string, epoch int) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := c.checkFenced(transactionalID, epoch); err != nil {
+		return err
+	}
+	c.aborted++
+	return nil
+}
+
+type mockTxnMessage struct {
+	topic     string
+	partition int32
+	message   *common.Message
+}
+
+// MockTransactionalProducer is a mock implementation of TransactionalProducer interface.
+type MockTransactionalProducer struct {
+	cluster         *MockCluster
+	transactionalID string
+	epoch           int
+	failpointCh     chan error
+
+	mu       sync.Mutex
+	inTxn    bool
+	messages []mockTxnMessage
+}
+
+// newMockTransactionalProducer creates a producer which fences the previous
+// producer with the same transactional id.
+func newMockTransactionalProducer(
+	cluster *MockCluster, transactionalID string, failpointCh chan error,
+) *MockTransactionalProducer {
+	return &MockTransactionalProducer{
+		cluster:         cluster,
+		transactionalID: transactionalID,
+		epoch:           cluster.initProducer(transactionalID),
+		failpointCh:     failpointCh,
+	}
+}
+
+// BeginTxn implement the TransactionalProducer interface.
+func (p *MockTransactionalProducer) BeginTxn() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.inTxn {
+		return cerror.ErrKafkaTransactionFailed.GenWithStack("transaction already begun")
+	}
+	p.inTxn = true
+	return nil
+}
+
+// AsyncSend implement the AsyncProducer interface,
+// the message is acknowledged when the transaction is committed.
+func (p *MockTransactionalProducer) AsyncSend(
+	_ context.Context, topic string, partition int32, message *common.Message,
+) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if !p.inTxn {
+		return cerror.ErrKafkaTransactionFailed.GenWithStack("no transaction is begun")
+	}
+	p.messages = append(p.messages, mockTxnMessage{
+		topic: topic, partition: partition, message: message,
+	})
+	return nil
+}
+
+// CommitTxn implement the TransactionalProducer interface.
+func (p *MockTransactionalProducer) CommitTxn(_ context.Context) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if !p.inTxn {
+		return cerror.ErrKafkaTransactionFailed.GenWithStack("no transaction is begun")
+	}
+	for _, m := range p.messages {
+		if m.message.Callback != nil {
+			m.message.Callback()
+		}
+	}
+	if err := p.cluster.commit(p.transactionalID, p.epoch, p.messages); err != nil {
+		return err
+	}
+	p.inTxn = false
+	p.messages = nil
+	return nil
+}
+
+// AbortTxn implement the TransactionalProducer interface.
+func (p *MockTransactionalProducer) AbortTxn(_ context.Context) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.inTxn = false
+	p.messages = nil
+	return p.cluster.abort(p.transactionalID, p.epoch)
+}
+
+// AsyncRunCallback implement the AsyncProducer interface.
+func (p *MockTransactionalProducer) AsyncRunCallback(ctx context.Context) error {
+	select {
+	case <-ctx.Done():
+		return errors.Trace(ctx.Err())
+	case err := <-p.failpointCh:
+		return errors.Trace(err)
+	}
+}
+
+// Close implement the AsyncProducer interface.
+func (p *MockTransactionalProducer) Close() {}
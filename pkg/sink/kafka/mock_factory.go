@@ -30,6 +30,8 @@ import (
 type MockFactory struct {
 	o            *Options
 	changefeedID model.ChangeFeedID
+	// cluster keeps the messages sent by the transactional producers.
+	cluster *MockCluster
 }
 
 // NewMockFactory constructs a Factory with mock implementation.
@@ -39,6 +41,7 @@ func NewMockFactory(
 	return &MockFactory{
 		o:            o,
 		changefeedID: changefeedID,
+		cluster:      NewMockCluster(),
 	}, nil
 }
 
@@ -78,6 +81,19 @@ func (f *MockFactory) AsyncProducer(
 	}, nil
 }
 
+// TransactionalProducer creates a transactional producer of the mock cluster
+func (f *MockFactory) TransactionalProducer(
+	_ context.Context,
+	transactionalID string,
+	failpointCh chan error,
+) (TransactionalProducer, error) {
+	if transactionalID == "" {
+		return nil, cerror.ErrKafkaInvalidConfig.GenWithStack(
+			"transactional id is required by the transactional producer")
+	}
+	return newMockTransactionalProducer(f.cluster, transactionalID, failpointCh), nil
+}
+
 // MetricsCollector returns the metric collector
 func (f *MockFactory) MetricsCollector(
 	_ util.Role, _ ClusterAdminClient,
@@ -32,6 +32,7 @@ import (
 	"github.com/pingcap/tiflow/pkg/config"
 	cerror "github.com/pingcap/tiflow/pkg/errors"
 	"github.com/pingcap/tiflow/pkg/security"
+	"github.com/pingcap/tiflow/pkg/util"
 	"go.uber.org/zap"
 )
 
@@ -138,6 +139,7 @@ type urlConfig struct {
 	Cert                         *string `form:"cert"`
 	Key                          *string `form:"key"`
 	InsecureSkipVerify           *bool   `form:"insecure-skip-verify"`
+	TransactionalIDPrefix        *string `form:"transactional-id-prefix"`
 }
 
 // Options stores user specified configurations
@@ -170,6 +172,10 @@ type Options struct {
 	DialTimeout  time.Duration
 	WriteTimeout time.Duration
 	ReadTimeout  time.Duration
+
+	// TransactionalID is used by the DML producer to send messages in kafka
+	// transactions, the exactly-once mode is disabled if it's empty.
+	TransactionalID string
 }
 
 // NewOptions returns a default Kafka configuration
@@ -308,6 +314,25 @@ func (o *Options) Apply(changefeedID model.ChangeFeedID,
 		o.RequiredAcks = r
 	}
 
+	if urlParameter.TransactionalIDPrefix != nil && *urlParameter.TransactionalIDPrefix != "" {
+		if o.RequiredAcks != WaitForAll {
+			return cerror.ErrKafkaInvalidConfig.GenWithStack(
+				"required-acks must be -1(WaitForAll) if the transactional-id-prefix is set")
+		}
+		if replicaConfig.Sink != nil && util.GetOrZero(replicaConfig.Sink.EnableKafkaSinkV2) {
+			return cerror.ErrKafkaInvalidConfig.GenWithStack(
+				"kafka transaction is not supported by the kafka-go client, " +
+					"please disable the enable-kafka-sink-v2 to use the transactional-id-prefix")
+		}
+		o.TransactionalID, err = NewKafkaTransactionalID(
+			config.GetGlobalServerConfig().AdvertiseAddr,
+			changefeedID,
+			*urlParameter.TransactionalIDPrefix)
+		if err != nil {
+			return err
+		}
+	}
+
 	err = o.applySASL(urlParameter, replicaConfig)
 	if err != nil {
 		return err
@@ -355,6 +380,7 @@ func mergeConfig(
 		dest.Cert = fileConifg.Cert
 		dest.Key = fileConifg.Key
 		dest.InsecureSkipVerify = fileConifg.InsecureSkipVerify
+		dest.TransactionalIDPrefix = fileConifg.TransactionalIDPrefix
 	}
 	if err := mergo.Merge(dest, urlParameters, mergo.WithOverride); err != nil {
 		return nil, err
@@ -566,6 +592,27 @@ func NewKafkaClientID(captureAddr string,
 	return
 }
 
+// NewKafkaTransactionalID generates the kafka transactional id of the DML producer.
+// All the tables of the changefeed on a capture are sent by one transactional
+// producer, so the id is derived from the capture and the changefeed. It's stable
+// for the changefeed on the same capture, so the producer created after the
+// changefeed is restarted fences the previous one, whose ongoing transaction is
+// aborted by the kafka cluster, and the producers on different captures never
+// fence each other.
+func NewKafkaTransactionalID(captureAddr string,
+	changefeedID model.ChangeFeedID,
+	prefix string,
+) (string, error) {
+	transactionalID := fmt.Sprintf("%s_%s_%s_%s",
+		prefix, captureAddr, changefeedID.Namespace, changefeedID.ID)
+	transactionalID = commonInvalidChar.ReplaceAllString(transactionalID, "_")
+	if !validClientID.MatchString(transactionalID) {
+		return "", cerror.ErrKafkaInvalidConfig.GenWithStack(
+			"invalid kafka transactional id '%s'", transactionalID)
+	}
+	return transactionalID, nil
+}
+
 // AdjustOptions adjust the `Options` and `sarama.Config` by condition.
 func AdjustOptions(
 	ctx context.Context,
@@ -29,6 +29,7 @@ import (
 	"github.com/pingcap/tiflow/pkg/config"
 	cerror "github.com/pingcap/tiflow/pkg/errors"
 	"github.com/pingcap/tiflow/pkg/sink/codec/common"
+	"github.com/pingcap/tiflow/pkg/util"
 	"github.com/stretchr/testify/require"
 )
 
@@ -172,6 +173,40 @@ func TestClientID(t *testing.T) {
 	}
 }
 
+func TestTransactionalID(t *testing.T) {
+	id, err := NewKafkaTransactionalID("127.0.0.1:1234",
+		model.DefaultChangeFeedID("test"), "cdc")
+	require.NoError(t, err)
+	require.Equal(t, "cdc_127.0.0.1_1234_default_test", id)
+
+	_, err = NewKafkaTransactionalID("127.0.0.1:1234",
+		model.DefaultChangeFeedID("test"), "中文")
+	require.ErrorIs(t, err, cerror.ErrKafkaInvalidConfig)
+
+	options := NewOptions()
+	uri := "kafka://127.0.0.1:9092/kafka-test?transactional-id-prefix=cdc"
+	sinkURI, err := url.Parse(uri)
+	require.NoError(t, err)
+	err = options.Apply(model.DefaultChangeFeedID("test"), sinkURI, config.GetDefaultReplicaConfig())
+	require.NoError(t, err)
+	require.Contains(t, options.TransactionalID, "cdc_")
+
+	// the transaction requires all replicas to acknowledge the messages.
+	options = NewOptions()
+	sinkURI, err = url.Parse(uri + "&required-acks=1")
+	require.NoError(t, err)
+	err = options.Apply(model.DefaultChangeFeedID("test"), sinkURI, config.GetDefaultReplicaConfig())
+	require.ErrorIs(t, err, cerror.ErrKafkaInvalidConfig)
+
+	// the kafka-go client doesn't support the transaction.
+	sinkURI, err = url.Parse(uri)
+	require.NoError(t, err)
+	replicaConfig := config.GetDefaultReplicaConfig()
+	replicaConfig.Sink.EnableKafkaSinkV2 = util.AddressOf(true)
+	err = NewOptions().Apply(model.DefaultChangeFeedID("test"), sinkURI, replicaConfig)
+	require.ErrorIs(t, err, cerror.ErrKafkaInvalidConfig)
+}
+
 func TestTimeout(t *testing.T) {
 	options := NewOptions()
 	require.Equal(t, 10*time.Second, options.DialTimeout)
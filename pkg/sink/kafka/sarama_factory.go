@@ -135,6 +135,57 @@ func (f *saramaFactory) AsyncProducer(
 	}, nil
 }
 
+// TransactionalProducer return a transactional Async Producer,
+// it should be the caller's responsibility to close the producer
+func (f *saramaFactory) TransactionalProducer(
+	ctx context.Context,
+	transactionalID string,
+	failpointCh chan error,
+) (TransactionalProducer, error) {
+	if transactionalID == "" {
+		return nil, errors.ErrKafkaInvalidConfig.GenWithStack(
+			"transactional id is required by the transactional producer")
+	}
+	config, err := NewSaramaConfig(ctx, f.option)
+	if err != nil {
+		return nil, err
+	}
+	if !config.Version.IsAtLeast(sarama.V0_11_0_0) {
+		return nil, errors.ErrKafkaInvalidConfig.GenWithStack(
+			"kafka transaction requires kafka version at least 0.11.0, but got %s",
+			config.Version.String())
+	}
+	config.MetricRegistry = f.registry
+	// the transactional producer must be idempotent, which requires
+	// only one in-flight request per connection in sarama.
+	config.Producer.Transaction.ID = transactionalID
+	config.Producer.Idempotent = true
+	config.Producer.RequiredAcks = sarama.WaitForAll
+	config.Net.MaxOpenRequests = 1
+
+	// sarama binds the transactional id to the config of the client, so the
+	// client can't be shared by the producers of different transactional ids,
+	// the DML sink creates only one transactional producer for all the tables.
+	client, err := sarama.NewClient(f.option.BrokerEndpoints, config)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	// the producer id is initialized with the transactional id here,
+	// which fences the previous producer with the same transactional id.
+	p, err := sarama.NewAsyncProducerFromClient(client)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	return &saramaTransactionalProducer{
+		saramaAsyncProducer: &saramaAsyncProducer{
+			client:       client,
+			producer:     p,
+			changefeedID: f.changefeedID,
+			failpointCh:  failpointCh,
+		},
+	}, nil
+}
+
 func (f *saramaFactory) MetricsCollector(
 	role util.Role,
 	adminClient ClusterAdminClient,
@@ -260,6 +260,19 @@ func (f *factory) AsyncProducer(
 	return aw, nil
 }
 
+// TransactionalProducer is not supported by the kafka-go client,
+// since it doesn't implement the kafka transaction protocol. The
+// transactional-id-prefix is rejected by Options.Apply if the client is used.
+func (f *factory) TransactionalProducer(
+	_ context.Context,
+	_ string,
+	_ chan error,
+) (pkafka.TransactionalProducer, error) {
+	return nil, errors.ErrKafkaInvalidConfig.GenWithStack(
+		"kafka transaction is not supported by the kafka-go client, " +
+			"please disable the enable-kafka-sink-v2 to use the transactional-id-prefix")
+}
+
 // MetricsCollector returns the kafka metrics collector
 func (f *factory) MetricsCollector(
 	role util.Role,
@@ -36,6 +36,7 @@ const (
 	// defaultSendTimeout 30s
 	defaultSendTimeout = 30 // 30s
 
+	defaultTransactionTimeout = 60 // 60s
 )
 
 func checkSinkURI(sinkURI *url.URL) error {
@@ -60,6 +61,7 @@ func NewPulsarConfig(sinkURI *url.URL, pulsarConfig *config.PulsarConfig) (*conf
 		BatchingMaxMessages:     toUint(defaultBatchingMaxSize),
 		BatchingMaxPublishDelay: toMill(defaultBatchingMaxPublishDelay),
 		SendTimeout:             toSec(defaultSendTimeout),
+		TransactionTimeout:      toSec(defaultTransactionTimeout),
 	}
 	err := checkSinkURI(sinkURI)
 	if err != nil {
@@ -108,6 +110,9 @@ func NewPulsarConfig(sinkURI *url.URL, pulsarConfig *config.PulsarConfig) (*conf
 	if pulsarConfig.SendTimeout == nil {
 		pulsarConfig.SendTimeout = c.SendTimeout
 	}
+	if pulsarConfig.TransactionTimeout == nil {
+		pulsarConfig.TransactionTimeout = c.TransactionTimeout
+	}
 
 	log.L().Debug("new pulsar config success", zap.Any("config", pulsarConfig))
 
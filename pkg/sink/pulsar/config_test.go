@@ -83,6 +83,9 @@ func TestPulsarConfig(t *testing.T) {
 				assert.Equal(t, *config.BatchingMaxMessages, defaultBatchingMaxSize)
 				assert.Equal(t, config.BatchingMaxPublishDelay.Duration(), defaultBatchingMaxPublishDelay*time.Millisecond)
 				assert.Equal(t, config.SendTimeout.Duration(), 123*time.Second)
+				assert.Equal(t, config.TransactionTimeout.Duration(), defaultTransactionTimeout*time.Second)
+				assert.False(t, config.GetEnableOrderingKey())
+				assert.False(t, config.GetEnableTransaction())
 			}
 		})
 	}
@@ -40,6 +40,8 @@ func NewCreatorFactory(config *config.PulsarConfig, changefeedID model.ChangeFee
 		// add pulsar default metrics
 		MetricsRegisterer: mq.GetMetricRegistry(),
 		Logger:            NewPulsarLogger(log.L()),
+		// the transaction coordinator is required to send messages in transactions.
+		EnableTransaction: config.GetEnableTransaction(),
 	}
 	log.Info("pulsar client factory created",
 		zap.Stringer("changefeedID", changefeedID),
@@ -0,0 +1,94 @@
+// Copyright 2024 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tracing
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/pingcap/errors"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// fileExporter writes the spans to a local file as json lines.
+type fileExporter struct {
+	mu      sync.Mutex
+	file    *os.File
+	encoder *json.Encoder
+}
+
+// spanRecord is a span written by fileExporter.
+type spanRecord struct {
+	Name         string                 `json:"name"`
+	TraceID      string                 `json:"trace_id"`
+	SpanID       string                 `json:"span_id"`
+	ParentSpanID string                 `json:"parent_span_id"`
+	Start        time.Time              `json:"start"`
+	End          time.Time              `json:"end"`
+	Attributes   map[string]interface{} `json:"attributes,omitempty"`
+	Status       string                 `json:"status,omitempty"`
+}
+
+func newFileExporter(path string) (*fileExporter, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	return &fileExporter{file: file, encoder: json.NewEncoder(file)}, nil
+}
+
+// ExportSpans implements sdktrace.SpanExporter.
+func (e *fileExporter) ExportSpans(_ context.Context, spans []sdktrace.ReadOnlySpan) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.file == nil {
+		return nil
+	}
+	for _, span := range spans {
+		record := &spanRecord{
+			Name:         span.Name(),
+			TraceID:      span.SpanContext().TraceID().String(),
+			SpanID:       span.SpanContext().SpanID().String(),
+			ParentSpanID: span.Parent().SpanID().String(),
+			Start:        span.StartTime(),
+			End:          span.EndTime(),
+			Status:       span.Status().Description,
+		}
+		if attrs := span.Attributes(); len(attrs) != 0 {
+			record.Attributes = make(map[string]interface{}, len(attrs))
+			for _, attr := range attrs {
+				record.Attributes[string(attr.Key)] = attr.Value.AsInterface()
+			}
+		}
+		if err := e.encoder.Encode(record); err != nil {
+			return errors.Trace(err)
+		}
+	}
+	return nil
+}
+
+// Shutdown implements sdktrace.SpanExporter.
+func (e *fileExporter) Shutdown(context.Context) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.file == nil {
+		return nil
+	}
+	err := e.file.Close()
+	e.file = nil
+	return errors.Trace(err)
+}
@@ -0,0 +1,228 @@
+// Copyright 2024 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tracing
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/pingcap/errors"
+	"github.com/pingcap/log"
+	"github.com/pingcap/tiflow/cdc/model"
+	"github.com/pingcap/tiflow/pkg/config"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+const (
+	tracerName  = "github.com/pingcap/tiflow/cdc"
+	serviceName = "ticdc"
+
+	kindTxn    = "txn"
+	kindRegion = "region"
+)
+
+// The names of the spans of the event pipeline.
+const (
+	// SpanRegionScan is the initial scan of a region, from sending the
+	// request to TiKV to receiving the initialized event.
+	SpanRegionScan = "puller.region-scan"
+	// SpanSorterFetch is fetching an event from the sort engine.
+	SpanSorterFetch = "sorter.fetch"
+	// SpanMounterDecode is decoding a raw kv event into a row changed event.
+	SpanMounterDecode = "mounter.decode-event"
+	// SpanSinkEncode is encoding a row changed event into messages.
+	SpanSinkEncode = "sink.encode"
+	// SpanSinkFlush is from writing an event to the backend sink
+	// to the event being acknowledged by the downstream.
+	SpanSinkFlush = "sink.flush"
+)
+
+// Attribute keys of the spans.
+const (
+	AttrNamespace  = attribute.Key("namespace")
+	AttrChangefeed = attribute.Key("changefeed")
+	AttrTableID    = attribute.Key("table-id")
+	AttrRegionID   = attribute.Key("region-id")
+	AttrStartTs    = attribute.Key("start-ts")
+	AttrCommitTs   = attribute.Key("commit-ts")
+)
+
+var (
+	// enabled is true if the tracer provider is initialized with an exporter.
+	enabled atomic.Bool
+	// sampleRatios are the sample ratios of the changefeeds on this capture.
+	sampleRatios sync.Map // model.ChangeFeedID -> float64
+)
+
+// InitTracerProvider initializes the global tracer provider with the exporters
+// in the config, the returned function flushes and shuts down the exporters.
+// Nothing is traced if no exporter is configured.
+func InitTracerProvider(
+	ctx context.Context, cfg *config.TracingConfig, captureAddr string,
+) (func(context.Context) error, error) {
+	if !cfg.IsEnabled() {
+		return func(context.Context) error { return nil }, nil
+	}
+	res := resource.NewSchemaless(
+		attribute.String("service.name", serviceName),
+		attribute.String("service.instance.id", captureAddr))
+	opts := []sdktrace.TracerProviderOption{
+		sdktrace.WithResource(res),
+		// The sampling is decided by the sample ratio of each changefeed,
+		// the spans are started only for the sampled transactions.
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.NeverSample())),
+	}
+	if cfg.OTLPEndpoint != "" {
+		exporterOpts := []otlptracegrpc.Option{otlptracegrpc.WithEndpoint(cfg.OTLPEndpoint)}
+		if cfg.OTLPInsecure {
+			exporterOpts = append(exporterOpts, otlptracegrpc.WithInsecure())
+		}
+		exporter, err := otlptracegrpc.New(ctx, exporterOpts...)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		opts = append(opts, sdktrace.WithBatcher(exporter))
+	}
+	if cfg.File != "" {
+		exporter, err := newFileExporter(cfg.File)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		opts = append(opts, sdktrace.WithBatcher(exporter))
+	}
+	tp := sdktrace.NewTracerProvider(opts...)
+	otel.SetTracerProvider(tp)
+	enabled.Store(true)
+	log.Info("tracing of the changefeeds is enabled",
+		zap.String("otlpEndpoint", cfg.OTLPEndpoint),
+		zap.String("file", cfg.File))
+	return func(ctx context.Context) error {
+		enabled.Store(false)
+		return tp.Shutdown(ctx)
+	}, nil
+}
+
+// SetSampleRatio sets the ratio of the sampled transactions of the changefeed.
+func SetSampleRatio(changefeed model.ChangeFeedID, ratio float64) {
+	if ratio <= 0 {
+		sampleRatios.Delete(changefeed)
+		return
+	}
+	sampleRatios.Store(changefeed, ratio)
+}
+
+// RemoveChangefeed stops tracing the changefeed.
+func RemoveChangefeed(changefeed model.ChangeFeedID) {
+	sampleRatios.Delete(changefeed)
+}
+
+// TxnSpanContext returns the span context of the transaction in the changefeed,
+// it's the parent of the spans of all the stages the transaction goes through.
+// The span context is derived from the transaction deterministically, so that
+// it survives the stages which don't keep it, such as the on-disk sorter.
+// The invalid span context is returned if the transaction is not sampled.
+func TxnSpanContext(changefeed model.ChangeFeedID, startTs, commitTs uint64) trace.SpanContext {
+	return sampledSpanContext(changefeed, kindTxn, startTs, commitTs)
+}
+
+// StartRegionScanSpan starts a SpanRegionScan span if the region scan is sampled,
+// it returns nil otherwise.
+func StartRegionScanSpan(
+	changefeed model.ChangeFeedID, tableID int64, regionID uint64, requestID uint64,
+) trace.Span {
+	parent := sampledSpanContext(changefeed, kindRegion, regionID, requestID)
+	if !parent.IsSampled() {
+		return nil
+	}
+	return StartSpanAt(parent, SpanRegionScan, time.Now(),
+		AttrNamespace.String(changefeed.Namespace),
+		AttrChangefeed.String(changefeed.ID),
+		AttrTableID.Int64(tableID),
+		AttrRegionID.Int64(int64(regionID)))
+}
+
+// StartSpan starts a child span of the parent, the caller should make sure
+// the parent is sampled, and end the returned span.
+func StartSpan(parent trace.SpanContext, name string, attrs ...attribute.KeyValue) trace.Span {
+	return StartSpanAt(parent, name, time.Now(), attrs...)
+}
+
+// StartSpanAt is like StartSpan, but the span starts at the given time.
+func StartSpanAt(
+	parent trace.SpanContext, name string, start time.Time, attrs ...attribute.KeyValue,
+) trace.Span {
+	ctx := trace.ContextWithRemoteSpanContext(context.Background(), parent)
+	_, span := otel.Tracer(tracerName).Start(ctx, name,
+		trace.WithTimestamp(start), trace.WithAttributes(attrs...))
+	return span
+}
+
+// TxnAttributes returns the attributes of the transaction.
+func TxnAttributes(
+	changefeed model.ChangeFeedID, startTs, commitTs uint64, extra ...attribute.KeyValue,
+) []attribute.KeyValue {
+	return append([]attribute.KeyValue{
+		AttrNamespace.String(changefeed.Namespace),
+		AttrChangefeed.String(changefeed.ID),
+		AttrStartTs.Int64(int64(startTs)),
+		AttrCommitTs.Int64(int64(commitTs)),
+	}, extra...)
+}
+
+func sampledSpanContext(changefeed model.ChangeFeedID, kind string, a, b uint64) trace.SpanContext {
+	if !enabled.Load() {
+		return trace.SpanContext{}
+	}
+	v, ok := sampleRatios.Load(changefeed)
+	if !ok {
+		return trace.SpanContext{}
+	}
+	ratio := v.(float64)
+
+	h := sha256.New()
+	_, _ = h.Write([]byte(kind))
+	_, _ = h.Write([]byte(changefeed.Namespace))
+	_, _ = h.Write([]byte{0})
+	_, _ = h.Write([]byte(changefeed.ID))
+	var buf [16]byte
+	binary.BigEndian.PutUint64(buf[:8], a)
+	binary.BigEndian.PutUint64(buf[8:], b)
+	_, _ = h.Write(buf[:])
+	sum := h.Sum(nil)
+
+	// compare in 53 bits to keep the threshold exact in float64.
+	if ratio < 1 && binary.BigEndian.Uint64(sum[:8])>>11 >= uint64(ratio*(1<<53)) {
+		return trace.SpanContext{}
+	}
+	var traceID trace.TraceID
+	var spanID trace.SpanID
+	copy(traceID[:], sum[8:])
+	copy(spanID[:], sum[24:])
+	return trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    traceID,
+		SpanID:     spanID,
+		TraceFlags: trace.FlagsSampled,
+		Remote:     true,
+	})
+}
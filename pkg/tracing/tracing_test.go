@@ -0,0 +1,93 @@
+// Copyright 2024 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tracing
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/pingcap/tiflow/cdc/model"
+	"github.com/pingcap/tiflow/pkg/config"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTxnSpanContext(t *testing.T) {
+	changefeed := model.DefaultChangeFeedID("test")
+	// nothing is sampled if the tracing is not enabled.
+	SetSampleRatio(changefeed, 1)
+	defer RemoveChangefeed(changefeed)
+	require.False(t, TxnSpanContext(changefeed, 1, 2).IsValid())
+
+	shutdown, err := InitTracerProvider(context.Background(),
+		&config.TracingConfig{File: filepath.Join(t.TempDir(), "spans.json")}, "127.0.0.1:8300")
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, shutdown(context.Background()))
+	}()
+
+	sc := TxnSpanContext(changefeed, 1, 2)
+	require.True(t, sc.IsSampled())
+	// the span context is derived from the transaction deterministically.
+	require.Equal(t, sc, TxnSpanContext(changefeed, 1, 2))
+	require.NotEqual(t, sc.TraceID(), TxnSpanContext(changefeed, 1, 3).TraceID())
+	require.False(t, TxnSpanContext(model.DefaultChangeFeedID("other"), 1, 2).IsValid())
+
+	SetSampleRatio(changefeed, 0.25)
+	sampled := 0
+	for ts := uint64(0); ts < 10000; ts++ {
+		if TxnSpanContext(changefeed, ts, ts+1).IsSampled() {
+			sampled++
+		}
+	}
+	require.InDelta(t, 2500, sampled, 250)
+
+	SetSampleRatio(changefeed, 0)
+	require.False(t, TxnSpanContext(changefeed, 1, 2).IsValid())
+}
+
+func TestFileExporter(t *testing.T) {
+	changefeed := model.DefaultChangeFeedID("test")
+	SetSampleRatio(changefeed, 1)
+	defer RemoveChangefeed(changefeed)
+
+	path := filepath.Join(t.TempDir(), "spans.json")
+	shutdown, err := InitTracerProvider(context.Background(),
+		&config.TracingConfig{File: path}, "127.0.0.1:8300")
+	require.NoError(t, err)
+
+	sc := TxnSpanContext(changefeed, 10, 20)
+	StartSpan(sc, SpanMounterDecode, TxnAttributes(changefeed, 10, 20)...).End()
+	StartSpan(sc, SpanSinkFlush, TxnAttributes(changefeed, 10, 20, AttrTableID.Int64(1))...).End()
+	require.NoError(t, shutdown(context.Background()))
+
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(string(content)), "\n")
+	require.Len(t, lines, 2)
+	names := make([]string, 0, len(lines))
+	for _, line := range lines {
+		record := &spanRecord{}
+		require.NoError(t, json.Unmarshal([]byte(line), record))
+		require.Equal(t, sc.TraceID().String(), record.TraceID)
+		require.Equal(t, sc.SpanID().String(), record.ParentSpanID)
+		require.Equal(t, "test", record.Attributes[string(AttrChangefeed)])
+		require.EqualValues(t, 20, record.Attributes[string(AttrCommitTs)])
+		names = append(names, record.Name)
+	}
+	require.ElementsMatch(t, []string{SpanMounterDecode, SpanSinkFlush}, names)
+}
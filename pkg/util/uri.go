@@ -95,6 +95,35 @@ var sensitiveQueryParameterNames = []string{
 	"client",
 }
 
+// ContainsSensitiveDataInURI returns true if the uri contains a password or sensitive query parameters.
+func ContainsSensitiveDataInURI(uri string) bool {
+	uriParsed, err := url.Parse(uri)
+	if err != nil {
+		// treat the unparsable uri as sensitive to be safe.
+		return true
+	}
+	if _, ok := uriParsed.User.Password(); ok {
+		return true
+	}
+	for key := range uriParsed.Query() {
+		if IsSensitiveParameterName(key) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsSensitiveParameterName returns true if the parameter name implies a sensitive value.
+func IsSensitiveParameterName(name string) bool {
+	lower := strings.ToLower(name)
+	for _, secretKey := range sensitiveQueryParameterNames {
+		if strings.Contains(lower, secretKey) {
+			return true
+		}
+	}
+	return false
+}
+
 // MaskSensitiveDataInURI returns an uri that sensitive infos has been masked.
 func MaskSensitiveDataInURI(uri string) string {
 	uriParsed, err := url.Parse(uri)
@@ -285,6 +285,9 @@ type ChangefeedSchedulerConfig struct {
 	RegionThreshold int `toml:"region_threshold" json:"region_threshold"`
 	// WriteKeyThreshold is the written keys threshold of splitting a table.
 	WriteKeyThreshold int `toml:"write_key_threshold" json:"write_key_threshold"`
+	// HotTableEventRateThreshold is the threshold of the events received from
+	// TiKV per second to split a hot table when it's being replicated.
+	HotTableEventRateThreshold int `toml:"hot_table_event_rate_threshold" json:"hot_table_event_rate_threshold"`
 }
 
 // IntegrityConfig is the config for integrity check